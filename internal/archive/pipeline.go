@@ -10,12 +10,15 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/NielsdaWheelz/agency/internal/checkpoint"
 	"github.com/NielsdaWheelz/agency/internal/config"
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
 	agencyfs "github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
 	"github.com/NielsdaWheelz/agency/internal/store"
 	"github.com/NielsdaWheelz/agency/internal/tmux"
 	"github.com/NielsdaWheelz/agency/internal/worktree"
@@ -43,6 +46,10 @@ type Result struct {
 
 	// LogPath is the path to the archive log file.
 	LogPath string
+
+	// SnapshotRefsDeleted is the number of checkpoint snapshot refs deleted.
+	// Snapshot cleanup is best-effort and does not affect Success.
+	SnapshotRefsDeleted int
 }
 
 // Success returns true if all archive steps succeeded.
@@ -83,6 +90,7 @@ type Deps struct {
 // 1. Run archive script (timeout, capture logs)
 // 2. Kill tmux session (missing session is ok)
 // 3. Delete worktree (git worktree remove, fallback to safe rm-rf)
+// 4. Delete the run's checkpoint snapshot refs (needs RepoRoot)
 //
 // All steps are attempted regardless of earlier failures (best-effort).
 // Returns a Result indicating what succeeded/failed.
@@ -108,12 +116,19 @@ func Archive(ctx context.Context, cfg Config, deps Deps, st *store.Store) *Resul
 	// Step 1: Run archive script
 	result.ScriptOK, result.ScriptReason = runArchiveScript(ctx, cfg, deps, logPath)
 
-	// Step 2: Kill tmux session
+	// Step 2: Kill tmux session (terminate the runner for headless runs)
 	result.TmuxOK, result.TmuxReason = killTmuxSession(ctx, cfg.Meta, deps)
 
 	// Step 3: Delete worktree
 	result.DeleteOK, result.DeleteReason = deleteWorktree(ctx, cfg, deps, worktreePath)
 
+	// Step 4: Delete snapshot refs, after the worktree so a final checkpoint
+	// taken as the runner stops is deleted too
+	if cfg.RepoRoot != "" {
+		deleted, _ := checkpoint.DeleteRefs(ctx, deps.CR, cfg.RepoRoot, runID)
+		result.SnapshotRefsDeleted = len(deleted)
+	}
+
 	return result
 }
 
@@ -207,8 +222,15 @@ func buildArchiveEnv(meta *store.RunMeta, repoRoot, dataDir string) []string {
 	return env
 }
 
-// killTmuxSession kills the tmux session for a run.
+// killTmuxSession kills the tmux session for a run. Headless runs have no
+// session; their runner's process group gets SIGTERM instead.
 func killTmuxSession(ctx context.Context, meta *store.RunMeta, deps Deps) (ok bool, reason string) {
+	if meta.Headless != nil {
+		if _, err := headless.SignalRunner(meta, syscall.SIGTERM); err != nil {
+			return false, err.Error()
+		}
+		return true, ""
+	}
 	sessionName := tmux.SessionName(meta.RunID)
 
 	err := deps.TmuxClient.KillSession(ctx, sessionName)
@@ -4,13 +4,18 @@ import (
 	"context"
 	"io"
 	"os"
+	osexec "os/exec"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/NielsdaWheelz/agency/internal/checkpoint"
 	"github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
 	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
 	"github.com/NielsdaWheelz/agency/internal/tmux"
 )
 
@@ -44,6 +49,10 @@ func (f *fakeTmuxClient) SendKeys(ctx context.Context, name string, keys []tmux.
 	return nil
 }
 
+func (f *fakeTmuxClient) SendText(ctx context.Context, name, text string) error {
+	return nil
+}
+
 // fakeRunner is a test fake for exec.CommandRunner.
 type fakeRunner struct {
 	results map[string]exec.CmdResult
@@ -358,3 +367,105 @@ func TestResult_Success(t *testing.T) {
 		})
 	}
 }
+
+func TestArchive_HeadlessTerminatesRunner(t *testing.T) {
+	tmpDir := t.TempDir()
+	dataDir := filepath.Join(tmpDir, "data")
+	repoID := "test-repo-id"
+	runID := "20260115-test"
+	worktreePath := filepath.Join(dataDir, "repos", repoID, "worktrees", runID)
+	if err := os.MkdirAll(worktreePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	scriptPath := filepath.Join(tmpDir, "archive.sh")
+	if err := os.WriteFile(scriptPath, []byte("#!/bin/sh\nexit 0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := osexec.Command("sleep", "60")
+	runner.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := runner.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = runner.Wait()
+		close(done)
+	}()
+	defer func() { _ = syscall.Kill(-runner.Process.Pid, syscall.SIGKILL) }()
+
+	meta := &store.RunMeta{
+		RunID:        runID,
+		RepoID:       repoID,
+		WorktreePath: worktreePath,
+		Headless:     &store.RunMetaHeadless{},
+		RunnerPID:    runner.Process.Pid,
+	}
+	fakeTmux := &fakeTmuxClient{}
+	deps := Deps{
+		CR:         &fakeRunner{results: map[string]exec.CmdResult{}},
+		TmuxClient: fakeTmux,
+		Stdout:     io.Discard,
+		Stderr:     io.Discard,
+	}
+	cfg := Config{Meta: meta, DataDir: dataDir, ArchiveScript: scriptPath, Timeout: 5 * time.Second}
+	result := Archive(context.Background(), cfg, deps, store.NewStore(fs.NewRealFS(), dataDir, time.Now))
+
+	if !result.TmuxOK || fakeTmux.killCalled {
+		t.Errorf("TmuxOK = %v, killCalled = %v; want the runner terminated without tmux", result.TmuxOK, fakeTmux.killCalled)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("headless runner still running after archive")
+	}
+}
+
+func TestArchive_DeletesSnapshotRefs(t *testing.T) {
+	testutil.HermeticGitEnv(t)
+	tmpDir := t.TempDir()
+	dataDir := filepath.Join(tmpDir, "data")
+	repoID := "test-repo-id"
+	runID := "20260115-test"
+	worktreePath := filepath.Join(dataDir, "repos", repoID, "worktrees", runID)
+	if err := os.MkdirAll(worktreePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	scriptPath := filepath.Join(tmpDir, "archive.sh")
+	if err := os.WriteFile(scriptPath, []byte("#!/bin/sh\nexit 0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Repo with snapshot refs for this run and another one
+	repoRoot := filepath.Join(tmpDir, "repo")
+	cr := exec.NewRealRunner()
+	git := func(args ...string) string {
+		t.Helper()
+		result, err := cr.Run(context.Background(), "git", args, exec.RunOpts{Dir: repoRoot})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+		return strings.TrimSpace(result.Stdout)
+	}
+	if err := os.MkdirAll(repoRoot, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-b", "main")
+	git("commit", "--allow-empty", "-m", "init")
+	head := git("rev-parse", "HEAD")
+	git("update-ref", checkpoint.Ref(runID, 1), head)
+	git("update-ref", checkpoint.Ref(runID, 2), head)
+	git("update-ref", checkpoint.Ref("other", 1), head)
+
+	meta := &store.RunMeta{RunID: runID, RepoID: repoID, WorktreePath: worktreePath}
+	deps := Deps{CR: cr, TmuxClient: &fakeTmuxClient{}, Stdout: io.Discard, Stderr: io.Discard}
+	cfg := Config{Meta: meta, RepoRoot: repoRoot, DataDir: dataDir, ArchiveScript: scriptPath, Timeout: 5 * time.Second}
+	result := Archive(context.Background(), cfg, deps, store.NewStore(fs.NewRealFS(), dataDir, time.Now))
+
+	if result.SnapshotRefsDeleted != 2 {
+		t.Errorf("SnapshotRefsDeleted = %d, want 2", result.SnapshotRefsDeleted)
+	}
+	if refs := git("for-each-ref", "--format=%(refname)", checkpoint.RefPrefix); refs != checkpoint.Ref("other", 1) {
+		t.Errorf("remaining snapshot refs = %q, want only the other run's", refs)
+	}
+}
@@ -0,0 +1,436 @@
+// Package checkpoint snapshots run worktrees into private git refs so agent
+// work survives destructive commands (`git checkout .`, `rm -rf`) run inside
+// the worktree.
+//
+// A snapshot stages the full working tree, including untracked files, into a
+// throwaway index, writes it with commit-tree, and stores the commit under
+// refs/agency/snapshots/<run_id>/<n>. Each snapshot is also recorded as one
+// line in the run's checkpoints.jsonl. The real index, HEAD and branches are
+// never touched, so snapshotting cannot disturb the runner.
+package checkpoint
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/git"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// RefPrefix is the ref namespace holding all run snapshots.
+const RefPrefix = "refs/agency/snapshots/"
+
+// Snapshot triggers recorded in Record.Trigger.
+const (
+	// TriggerFS is a debounced file-change notification.
+	TriggerFS = "fs"
+
+	// TriggerPoll is the periodic dirty-check fallback.
+	TriggerPoll = "poll"
+
+	// TriggerExit is the final snapshot taken when the watcher stops.
+	TriggerExit = "exit"
+
+	// TriggerPreApply is the safety snapshot taken before a rollback.
+	TriggerPreApply = "pre-apply"
+)
+
+// EventCheckpointApplied is the run event appended after a rollback.
+const EventCheckpointApplied = "checkpoint_applied"
+
+// maxRefAttempts bounds retries when another process claims the next number.
+const maxRefAttempts = 5
+
+// Record is one snapshot, persisted as a line of checkpoints.jsonl.
+type Record struct {
+	// N is the snapshot number (1-based, increasing).
+	N int `json:"n"`
+
+	// Ref is the full ref name (refs/agency/snapshots/<run_id>/<n>).
+	Ref string `json:"ref"`
+
+	// Commit is the snapshot commit sha.
+	Commit string `json:"commit"`
+
+	// Tree is the snapshot tree sha.
+	Tree string `json:"tree"`
+
+	// Head is the worktree HEAD at snapshot time (the commit's parent).
+	Head string `json:"head,omitempty"`
+
+	// Trigger is what caused the snapshot (see Trigger* constants).
+	Trigger string `json:"trigger"`
+
+	// FilesChanged is the number of paths changed since the previous snapshot
+	// (or since HEAD for the first one).
+	FilesChanged int `json:"files_changed"`
+
+	// CreatedAt is the snapshot timestamp in RFC3339 UTC format.
+	CreatedAt string `json:"created_at"`
+}
+
+// Ref returns the snapshot ref name for a run and snapshot number.
+func Ref(runID string, n int) string {
+	return fmt.Sprintf("%s%s/%d", RefPrefix, runID, n)
+}
+
+// ListRefs returns a run's snapshot refs in the repository at repoRoot.
+func ListRefs(ctx context.Context, cr exec.CommandRunner, repoRoot, runID string) ([]string, error) {
+	result, err := cr.Run(ctx, "git", []string{"for-each-ref", "--format=%(refname)", RefPrefix + runID + "/"}, exec.RunOpts{Dir: repoRoot})
+	if err != nil {
+		return nil, errors.Wrap(errors.ECheckpointFailed, "git for-each-ref failed", err)
+	}
+	if result.ExitCode != 0 {
+		return nil, errors.New(errors.ECheckpointFailed, "git for-each-ref failed: "+strings.TrimSpace(result.Stderr))
+	}
+	return strings.Fields(result.Stdout), nil
+}
+
+// DeleteRefs deletes a run's snapshot refs in the repository at repoRoot,
+// making their commits unreachable for git gc. Returns the refs deleted;
+// the error reports the first ref that could not be deleted.
+func DeleteRefs(ctx context.Context, cr exec.CommandRunner, repoRoot, runID string) ([]string, error) {
+	refs, err := ListRefs(ctx, cr, repoRoot, runID)
+	if err != nil {
+		return nil, err
+	}
+	deleted := make([]string, 0, len(refs))
+	var firstErr error
+	for _, ref := range refs {
+		result, err := cr.Run(ctx, "git", []string{"update-ref", "-d", ref}, exec.RunOpts{Dir: repoRoot})
+		if err == nil && result.ExitCode == 0 {
+			deleted = append(deleted, ref)
+		} else if firstErr == nil {
+			firstErr = errors.New(errors.ECheckpointFailed, "failed to delete "+ref)
+		}
+	}
+	return deleted, firstErr
+}
+
+// Engine takes and restores snapshots for one run worktree.
+type Engine struct {
+	CR           exec.CommandRunner
+	Store        *store.Store
+	RepoID       string
+	RunID        string
+	WorktreePath string
+}
+
+// NewEngine creates a checkpoint engine for a run.
+func NewEngine(cr exec.CommandRunner, st *store.Store, meta *store.RunMeta) *Engine {
+	return &Engine{
+		CR:           cr,
+		Store:        st,
+		RepoID:       meta.RepoID,
+		RunID:        meta.RunID,
+		WorktreePath: meta.WorktreePath,
+	}
+}
+
+// Take snapshots the worktree if it changed since the last snapshot.
+// Returns (nil, nil) when the working tree matches the previous snapshot
+// (or HEAD, before the first snapshot).
+func (e *Engine) Take(ctx context.Context, trigger string) (*Record, error) {
+	rec, _, err := e.take(ctx, trigger)
+	return rec, err
+}
+
+// take is Take that also returns the current working tree sha.
+func (e *Engine) take(ctx context.Context, trigger string) (*Record, string, error) {
+	records, err := List(e.Store, e.RepoID, e.RunID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tree, err := e.snapshotTree(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	head := ""
+	if r, ok := e.git(ctx, nil, "rev-parse", "-q", "--verify", "HEAD"); ok {
+		head = strings.TrimSpace(r)
+	}
+
+	prevTree := ""
+	next := 1
+	if len(records) > 0 {
+		last := records[len(records)-1]
+		prevTree = last.Tree
+		next = last.N + 1
+	} else if head != "" {
+		if r, ok := e.git(ctx, nil, "rev-parse", head+"^{tree}"); ok {
+			prevTree = strings.TrimSpace(r)
+		}
+	}
+	if tree == prevTree {
+		return nil, tree, nil
+	}
+
+	changed := 0
+	if prevTree != "" {
+		if r, ok := e.git(ctx, nil, "diff-tree", "-r", "--name-only", "--no-renames", prevTree, tree); ok {
+			changed = len(strings.Fields(r))
+		}
+	} else if r, ok := e.git(ctx, nil, "ls-tree", "-r", "--name-only", tree); ok {
+		changed = len(strings.Fields(r))
+	}
+
+	now := time.Now
+	if e.Store.Now != nil {
+		now = e.Store.Now
+	}
+	createdAt := now().UTC().Format(time.RFC3339)
+
+	args := []string{"commit-tree", tree, "-m", fmt.Sprintf("agency checkpoint %s (%s)", e.RunID, trigger)}
+	if head != "" {
+		args = append(args, "-p", head)
+	}
+	commit, ok := e.git(ctx, identityEnv(), args...)
+	if !ok {
+		return nil, tree, errors.New(errors.ECheckpointFailed, "git commit-tree failed")
+	}
+	commit = strings.TrimSpace(commit)
+
+	// Create-only ref update: a concurrent snapshot claiming n wins, we take n+1
+	for attempt := 0; attempt < maxRefAttempts; attempt++ {
+		ref := Ref(e.RunID, next)
+		if _, ok := e.git(ctx, nil, "update-ref", ref, commit, ""); ok {
+			rec := &Record{
+				N:            next,
+				Ref:          ref,
+				Commit:       commit,
+				Tree:         tree,
+				Head:         head,
+				Trigger:      trigger,
+				FilesChanged: changed,
+				CreatedAt:    createdAt,
+			}
+			if err := appendRecord(e.Store.RunCheckpointsPath(e.RepoID, e.RunID), rec); err != nil {
+				return nil, tree, err
+			}
+			return rec, tree, nil
+		}
+		next++
+	}
+	return nil, tree, errors.NewWithDetails(
+		errors.ECheckpointFailed,
+		"failed to create snapshot ref",
+		map[string]string{"commit": commit},
+	)
+}
+
+// ApplyResult holds the outcome of a rollback.
+type ApplyResult struct {
+	// Target is the snapshot the worktree was restored to.
+	Target Record
+
+	// Safety is the pre-apply snapshot of the replaced state (nil if the
+	// working tree already matched the latest snapshot).
+	Safety *Record
+
+	// Removed are paths deleted because they did not exist in the target.
+	Removed []string
+}
+
+// Apply restores the worktree's files to snapshot n. A pre-apply snapshot is
+// taken first so the rollback itself can be undone. HEAD and the real index
+// are not changed; afterwards `git status` shows the snapshot's changes.
+func (e *Engine) Apply(ctx context.Context, n int) (*ApplyResult, error) {
+	records, err := List(e.Store, e.RepoID, e.RunID)
+	if err != nil {
+		return nil, err
+	}
+	var target *Record
+	for i := range records {
+		if records[i].N == n {
+			target = &records[i]
+		}
+	}
+	if target == nil {
+		return nil, errors.NewWithDetails(
+			errors.ECheckpointNotFound,
+			fmt.Sprintf("checkpoint %d not found", n),
+			map[string]string{"run_id": e.RunID},
+		)
+	}
+	if _, ok := e.git(ctx, nil, "cat-file", "-e", target.Ref); !ok {
+		return nil, errors.NewWithDetails(
+			errors.ECheckpointNotFound,
+			fmt.Sprintf("checkpoint %d ref is missing", n),
+			map[string]string{"ref": target.Ref},
+		)
+	}
+
+	safety, current, err := e.take(ctx, TriggerPreApply)
+	if err != nil {
+		return nil, err
+	}
+	res := &ApplyResult{Target: *target, Safety: safety, Removed: []string{}}
+
+	// Delete paths that exist now but not in the target snapshot
+	added, ok := e.git(ctx, nil, "diff-tree", "-r", "-z", "--name-only", "--no-renames", "--diff-filter=A", target.Tree, current)
+	if !ok {
+		return nil, errors.New(errors.ECheckpointFailed, "failed to compare snapshot trees")
+	}
+	for _, p := range strings.Split(added, "\x00") {
+		if p == "" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(e.WorktreePath, p)); err == nil {
+			res.Removed = append(res.Removed, p)
+			removeEmptyParents(e.WorktreePath, filepath.Dir(p))
+		}
+	}
+
+	// Write the target's files through a throwaway index
+	tmpDir, err := os.MkdirTemp("", "agency-checkpoint-")
+	if err != nil {
+		return nil, errors.Wrap(errors.EInternal, "failed to create temp dir", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	env := map[string]string{"GIT_INDEX_FILE": filepath.Join(tmpDir, "index")}
+	if _, ok := e.git(ctx, env, "read-tree", target.Tree); !ok {
+		return nil, errors.New(errors.ECheckpointFailed, "git read-tree failed")
+	}
+	if _, ok := e.git(ctx, env, "checkout-index", "-a", "-f"); !ok {
+		return nil, errors.New(errors.ECheckpointFailed, "git checkout-index failed")
+	}
+
+	data := map[string]any{"n": target.N, "commit": target.Commit, "removed": len(res.Removed)}
+	if safety != nil {
+		data["safety_n"] = safety.N
+	}
+	_ = events.AppendEvent(e.Store.EventsPath(e.RepoID, e.RunID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		RepoID:        e.RepoID,
+		RunID:         e.RunID,
+		Event:         EventCheckpointApplied,
+		Data:          data,
+	})
+	return res, nil
+}
+
+// snapshotTree stages the whole working tree (including untracked files) into
+// a throwaway index seeded from the real one and returns the written tree sha.
+// Ignored files are not staged: they are often large or generated (build
+// output, dependencies) and may hold secrets such as .env files.
+func (e *Engine) snapshotTree(ctx context.Context) (string, error) {
+	if info, err := os.Stat(e.WorktreePath); err != nil || !info.IsDir() {
+		return "", errors.NewWithDetails(
+			errors.EWorktreeMissing,
+			"worktree path missing on disk",
+			map[string]string{"worktree_path": e.WorktreePath},
+		)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "agency-checkpoint-")
+	if err != nil {
+		return "", errors.Wrap(errors.EInternal, "failed to create temp dir", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// Seed from the real index so unchanged files are not rehashed
+	indexPath := filepath.Join(tmpDir, "index")
+	if realIndex := git.GitPath(ctx, e.CR, e.WorktreePath, "index"); realIndex != "" {
+		if data, err := os.ReadFile(realIndex); err == nil {
+			_ = os.WriteFile(indexPath, data, 0o600)
+		}
+	}
+
+	env := map[string]string{"GIT_INDEX_FILE": indexPath}
+	if _, ok := e.git(ctx, env, "add", "-A"); !ok {
+		return "", errors.New(errors.ECheckpointFailed, "failed to stage working tree")
+	}
+	tree, ok := e.git(ctx, env, "write-tree")
+	if !ok {
+		return "", errors.New(errors.ECheckpointFailed, "git write-tree failed")
+	}
+	return strings.TrimSpace(tree), nil
+}
+
+// git runs a git command in the worktree and returns stdout and success.
+// Optional locks are disabled so snapshots never contend with the runner's git.
+func (e *Engine) git(ctx context.Context, env map[string]string, args ...string) (string, bool) {
+	merged := map[string]string{"GIT_OPTIONAL_LOCKS": "0"}
+	for k, v := range env {
+		merged[k] = v
+	}
+	result, err := e.CR.Run(ctx, "git", args, exec.RunOpts{Dir: e.WorktreePath, Env: merged})
+	if err != nil || result.ExitCode != 0 {
+		return "", false
+	}
+	return result.Stdout, true
+}
+
+// identityEnv supplies a fixed author/committer so commit-tree works without
+// user git identity configured.
+func identityEnv() map[string]string {
+	return map[string]string{
+		"GIT_AUTHOR_NAME":     "agency",
+		"GIT_AUTHOR_EMAIL":    "agency@localhost",
+		"GIT_COMMITTER_NAME":  "agency",
+		"GIT_COMMITTER_EMAIL": "agency@localhost",
+	}
+}
+
+// removeEmptyParents removes now-empty directories from dir up to (not including) root.
+func removeEmptyParents(root, dir string) {
+	for dir != "." && dir != "/" && dir != "" {
+		if os.Remove(filepath.Join(root, dir)) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// List reads a run's snapshot records, oldest first.
+// Returns an empty slice if no snapshot has been taken. Malformed lines are skipped.
+func List(st *store.Store, repoID, runID string) ([]Record, error) {
+	path := st.RunCheckpointsPath(repoID, runID)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Record{}, nil
+		}
+		return nil, errors.Wrap(errors.EInternal, "failed to open checkpoints.jsonl", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	records := []Record{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec Record
+		if json.Unmarshal(scanner.Bytes(), &rec) == nil && rec.N > 0 {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// appendRecord appends one record line to checkpoints.jsonl.
+func appendRecord(path string, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to marshal checkpoint record", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to open checkpoints.jsonl", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(errors.EInternal, "failed to write checkpoints.jsonl", err)
+	}
+	return nil
+}
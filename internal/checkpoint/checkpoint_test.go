@@ -0,0 +1,240 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+// newTestEngine creates a repo with one commit and an engine whose run dir exists.
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	testutil.HermeticGitEnv(t)
+
+	wt := t.TempDir()
+	gitRun(t, wt, "init", "-b", "main")
+	writeFile(t, filepath.Join(wt, "README.md"), "# Test\n")
+	gitRun(t, wt, "add", ".")
+	gitRun(t, wt, "commit", "-m", "Initial commit")
+
+	st := store.NewStore(fs.NewRealFS(), t.TempDir(), time.Now)
+	if _, err := st.EnsureRunDir("repo1", "r1"); err != nil {
+		t.Fatal(err)
+	}
+	return &Engine{CR: exec.NewRealRunner(), Store: st, RepoID: "repo1", RunID: "r1", WorktreePath: wt}
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	result, err := exec.NewRealRunner().Run(context.Background(), "git", args, exec.RunOpts{Dir: dir})
+	if err != nil || result.ExitCode != 0 {
+		t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+	}
+	return strings.TrimSpace(result.Stdout)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTake(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := context.Background()
+
+	// Clean worktree matches HEAD: nothing to snapshot
+	rec, err := e.Take(ctx, TriggerPoll)
+	if err != nil || rec != nil {
+		t.Fatalf("Take(clean) = %+v, %v; want nil, nil", rec, err)
+	}
+
+	writeFile(t, filepath.Join(e.WorktreePath, "README.md"), "# Changed\n")
+	writeFile(t, filepath.Join(e.WorktreePath, "src", "new.go"), "package src\n")
+	writeFile(t, filepath.Join(e.WorktreePath, ".git", "info", "exclude"), "*.log\n")
+	writeFile(t, filepath.Join(e.WorktreePath, "debug.log"), "ignored\n")
+	rec, err = e.Take(ctx, TriggerFS)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if rec == nil || rec.N != 1 || rec.Ref != "refs/agency/snapshots/r1/1" || rec.FilesChanged != 2 || rec.Trigger != TriggerFS {
+		t.Fatalf("Take() = %+v", rec)
+	}
+	if got := gitRun(t, e.WorktreePath, "rev-parse", rec.Ref); got != rec.Commit {
+		t.Errorf("ref points to %s, want %s", got, rec.Commit)
+	}
+	if got := gitRun(t, e.WorktreePath, "show", rec.Ref+":src/new.go"); got != "package src" {
+		t.Errorf("untracked file not in snapshot: %q", got)
+	}
+	if files := gitRun(t, e.WorktreePath, "ls-tree", "-r", "--name-only", rec.Ref); strings.Contains(files, "debug.log") {
+		t.Errorf("ignored file in snapshot:\n%s", files)
+	}
+	if got := gitRun(t, e.WorktreePath, "rev-parse", rec.Ref+"^"); got != rec.Head {
+		t.Errorf("snapshot parent = %s, want HEAD %s", got, rec.Head)
+	}
+
+	// The real index is untouched: the new file is still untracked
+	if status := gitRun(t, e.WorktreePath, "status", "--porcelain"); !strings.Contains(status, "?? src/") {
+		t.Errorf("real index was modified:\n%s", status)
+	}
+
+	// Unchanged since the last snapshot: nothing recorded
+	if rec, err := e.Take(ctx, TriggerPoll); err != nil || rec != nil {
+		t.Errorf("Take(unchanged) = %+v, %v; want nil, nil", rec, err)
+	}
+
+	writeFile(t, filepath.Join(e.WorktreePath, "README.md"), "# Again\n")
+	rec, err = e.Take(ctx, TriggerPoll)
+	if err != nil || rec == nil || rec.N != 2 || rec.FilesChanged != 1 {
+		t.Fatalf("second Take() = %+v, %v", rec, err)
+	}
+
+	records, err := List(e.Store, "repo1", "r1")
+	if err != nil || len(records) != 2 {
+		t.Fatalf("List() = %+v, %v", records, err)
+	}
+}
+
+func TestDeleteRefs(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := context.Background()
+
+	for _, content := range []string{"a\n", "b\n"} {
+		writeFile(t, filepath.Join(e.WorktreePath, "f.txt"), content)
+		if _, err := e.Take(ctx, TriggerPoll); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Another run whose id shares the prefix keeps its snapshots
+	head := gitRun(t, e.WorktreePath, "rev-parse", "HEAD")
+	gitRun(t, e.WorktreePath, "update-ref", Ref("r10", 1), head)
+
+	refs, err := ListRefs(ctx, e.CR, e.WorktreePath, "r1")
+	if err != nil || len(refs) != 2 {
+		t.Fatalf("ListRefs() = %v, %v; want 2 refs", refs, err)
+	}
+	deleted, err := DeleteRefs(ctx, e.CR, e.WorktreePath, "r1")
+	if err != nil || len(deleted) != 2 {
+		t.Fatalf("DeleteRefs() = %v, %v; want 2 refs", deleted, err)
+	}
+	if refs, _ := ListRefs(ctx, e.CR, e.WorktreePath, "r1"); len(refs) != 0 {
+		t.Errorf("refs left after delete: %v", refs)
+	}
+	if refs, _ := ListRefs(ctx, e.CR, e.WorktreePath, "r10"); len(refs) != 1 {
+		t.Errorf("other run's refs = %v, want 1", refs)
+	}
+}
+
+func TestApply(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := context.Background()
+
+	writeFile(t, filepath.Join(e.WorktreePath, "work.txt"), "precious\n")
+	if rec, err := e.Take(ctx, TriggerFS); err != nil || rec == nil {
+		t.Fatalf("Take() = %+v, %v", rec, err)
+	}
+
+	// The runner wrecks the worktree
+	if err := os.Remove(filepath.Join(e.WorktreePath, "work.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(e.WorktreePath, "README.md"), "# Wrecked\n")
+	writeFile(t, filepath.Join(e.WorktreePath, "junk", "tmp.txt"), "junk\n")
+
+	res, err := e.Apply(ctx, 1)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if res.Safety == nil || res.Safety.N != 2 || res.Safety.Trigger != TriggerPreApply {
+		t.Errorf("safety = %+v", res.Safety)
+	}
+	if strings.Join(res.Removed, ",") != "junk/tmp.txt" {
+		t.Errorf("removed = %v", res.Removed)
+	}
+
+	for path, want := range map[string]string{"work.txt": "precious\n", "README.md": "# Test\n"} {
+		data, err := os.ReadFile(filepath.Join(e.WorktreePath, path))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", path, data, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(e.WorktreePath, "junk")); !os.IsNotExist(err) {
+		t.Errorf("empty junk/ dir should be removed, stat err = %v", err)
+	}
+
+	// The rollback can be undone from the safety snapshot
+	if _, err := e.Apply(ctx, res.Safety.N); err != nil {
+		t.Fatalf("undo Apply() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(e.WorktreePath, "junk", "tmp.txt")); string(data) != "junk\n" {
+		t.Errorf("undo did not restore junk/tmp.txt: %q", data)
+	}
+
+	if _, err := e.Apply(ctx, 99); errors.GetCode(err) != errors.ECheckpointNotFound {
+		t.Errorf("Apply(99) expected E_CHECKPOINT_NOT_FOUND, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	e := newTestEngine(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var alive atomic.Bool
+	alive.Store(true)
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Watch(ctx, WatchOpts{
+			Debounce:     50 * time.Millisecond,
+			PollInterval: 300 * time.Millisecond,
+			Alive:        alive.Load,
+		})
+	}()
+
+	// Give the watcher time to register, then change a file
+	time.Sleep(100 * time.Millisecond)
+	writeFile(t, filepath.Join(e.WorktreePath, "a.txt"), "a\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		records, _ := List(e.Store, "repo1", "r1")
+		if len(records) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no checkpoint taken after a file change")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// Runner exits: a final snapshot is taken and Watch returns
+	writeFile(t, filepath.Join(e.WorktreePath, "b.txt"), "b\n")
+	alive.Store(false)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Watch() error = %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("Watch() did not return after runner exit")
+	}
+
+	records, _ := List(e.Store, "repo1", "r1")
+	if last := records[len(records)-1]; gitRun(t, e.WorktreePath, "show", last.Ref+":b.txt") != "b" {
+		t.Errorf("final snapshot missing b.txt: %+v", last)
+	}
+}
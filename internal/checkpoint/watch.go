@@ -0,0 +1,232 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+)
+
+// WatcherCommand is the hidden agency subcommand that runs the checkpoint watcher.
+const WatcherCommand = "checkpoint-watch"
+
+// Default watcher timings.
+const (
+	// DefaultDebounce is the quiet period after the last file change before a snapshot.
+	DefaultDebounce = 5 * time.Second
+
+	// DefaultPollInterval is the periodic dirty-check and liveness interval.
+	DefaultPollInterval = 60 * time.Second
+)
+
+// WatchOpts configures Watch.
+type WatchOpts struct {
+	// Debounce is the quiet period after file changes (default: DefaultDebounce).
+	Debounce time.Duration
+
+	// PollInterval is the dirty-check fallback interval (default: DefaultPollInterval).
+	PollInterval time.Duration
+
+	// Alive reports whether the run's runner is still going. Checked every
+	// poll interval; when false a final snapshot is taken and Watch returns.
+	Alive func() bool
+
+	// Log receives one line per snapshot or failure (may be nil).
+	Log io.Writer
+}
+
+// Watch snapshots the worktree on debounced file changes, with a periodic
+// dirty-check fallback, until ctx is done or opts.Alive reports false.
+// Snapshot failures are logged and never stop the watcher. If file
+// notifications are unavailable, Watch runs on the poll interval alone.
+func (e *Engine) Watch(ctx context.Context, opts WatchOpts) error {
+	debounce := opts.Debounce
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	poll := opts.PollInterval
+	if poll <= 0 {
+		poll = DefaultPollInterval
+	}
+	logf := func(format string, args ...any) {
+		if opts.Log != nil {
+			_, _ = fmt.Fprintf(opts.Log, time.Now().UTC().Format(time.RFC3339)+" "+format+"\n", args...)
+		}
+	}
+	snapshot := func(trigger string) {
+		rec, err := e.Take(ctx, trigger)
+		switch {
+		case err != nil:
+			logf("checkpoint failed (%s): %v", trigger, err)
+		case rec != nil:
+			logf("checkpoint %d (%s): %d files, %s", rec.N, trigger, rec.FilesChanged, rec.Commit)
+		}
+	}
+
+	var fsEvents <-chan fsnotify.Event
+	var fsErrors <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logf("file notifications unavailable, polling only: %v", err)
+	} else {
+		defer func() { _ = watcher.Close() }()
+		skip := e.ignoredDirs(ctx)
+		e.addTree(watcher, e.WorktreePath, skip, logf)
+		fsEvents, fsErrors = watcher.Events, watcher.Errors
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	snapshot(TriggerPoll)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-fsEvents:
+			if !ok {
+				fsEvents = nil
+				continue
+			}
+			if isInternalPath(e.WorktreePath, ev.Name) {
+				continue
+			}
+			if ev.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					e.addTree(watcher, ev.Name, nil, logf)
+				}
+			}
+			timer.Reset(debounce)
+		case err, ok := <-fsErrors:
+			if !ok {
+				fsErrors = nil
+				continue
+			}
+			logf("watch error: %v", err)
+		case <-timer.C:
+			snapshot(TriggerFS)
+		case <-ticker.C:
+			if opts.Alive != nil && !opts.Alive() {
+				if _, err := os.Stat(e.WorktreePath); err == nil {
+					snapshot(TriggerExit)
+				}
+				logf("runner gone; watcher exiting")
+				return nil
+			}
+			snapshot(TriggerPoll)
+		}
+	}
+}
+
+// addTree adds dir and its subdirectories to the watcher, skipping .git,
+// .agency and the given ignored directories (absolute paths).
+func (e *Engine) addTree(w *fsnotify.Watcher, dir string, skip map[string]bool, logf func(string, ...any)) {
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != dir && (isInternalPath(e.WorktreePath, path) || skip[path]) {
+			return filepath.SkipDir
+		}
+		if err := w.Add(path); err != nil {
+			logf("cannot watch %s: %v", path, err)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// ignoredDirs returns the absolute paths of directories git ignores in the
+// worktree (e.g. node_modules), which are not watched.
+func (e *Engine) ignoredDirs(ctx context.Context) map[string]bool {
+	skip := map[string]bool{}
+	out, ok := e.git(ctx, nil, "ls-files", "-z", "--others", "--ignored", "--exclude-standard", "--directory")
+	if !ok {
+		return skip
+	}
+	for _, p := range strings.Split(out, "\x00") {
+		if strings.HasSuffix(p, "/") {
+			skip[filepath.Join(e.WorktreePath, strings.TrimSuffix(p, "/"))] = true
+		}
+	}
+	return skip
+}
+
+// isInternalPath reports whether path is inside the worktree's .git or .agency.
+func isInternalPath(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	return first == ".git" || first == ".agency"
+}
+
+// SpawnOpts configures a watcher spawn.
+type SpawnOpts struct {
+	// Executable is the agency binary to re-exec (default: os.Executable()).
+	Executable string
+
+	// DataDir, RepoID and RunID identify the run to watch.
+	DataDir string
+	RepoID  string
+	RunID   string
+
+	// LogPath receives the watcher's output (logs/checkpoint.log).
+	LogPath string
+}
+
+// Spawn starts a detached checkpoint watcher for the run in its own session
+// and returns its pid. The caller never waits for it.
+func Spawn(opts SpawnOpts) (int, error) {
+	exe := opts.Executable
+	if exe == "" {
+		var err error
+		exe, err = os.Executable()
+		if err != nil {
+			return 0, errors.Wrap(errors.ECheckpointFailed, "failed to locate agency executable", err)
+		}
+	}
+
+	logFile, err := os.OpenFile(opts.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, errors.WrapWithDetails(errors.ECheckpointFailed, "failed to open checkpoint log", err,
+			map[string]string{"log_path": opts.LogPath})
+	}
+	defer func() { _ = logFile.Close() }()
+
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		return 0, errors.Wrap(errors.ECheckpointFailed, "failed to open /dev/null", err)
+	}
+	defer func() { _ = devnull.Close() }()
+
+	cmd := osexec.Command(exe, WatcherCommand,
+		"--data-dir", opts.DataDir,
+		"--repo-id", opts.RepoID,
+		"--run-id", opts.RunID,
+	)
+	cmd.Stdin = devnull
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, errors.WrapWithDetails(errors.ECheckpointFailed, "failed to start checkpoint watcher", err,
+			map[string]string{"log_path": opts.LogPath})
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
@@ -1,9 +1,15 @@
 package cobra
 
 import (
+	"context"
+	"os"
+
 	"github.com/spf13/cobra"
 
+	"github.com/NielsdaWheelz/agency/internal/commands"
 	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
 )
 
 func newAgentCmd() *cobra.Command {
@@ -13,27 +19,164 @@ func newAgentCmd() *cobra.Command {
 		Long: `Manage agent invocations.
 
 Agent invocations are executions of runners (Claude, Codex, etc.) inside
-sandbox worktrees. Each invocation is isolated and produces logs,
-checkpoints, and outcomes.
+sandbox worktrees. Each invocation gets its own sandbox branched from an
+integration worktree; runners never execute in the integration tree itself.
 
-Available subcommands will be added in future releases:
+Subcommands:
   start     Start a new agent invocation
   ls        List agent invocations
-  show      Show details of an invocation
-  attach    Attach to a running invocation
-  stop      Stop an invocation gracefully
-  kill      Kill an invocation forcefully
-  diff      Show sandbox changes
-  land      Apply sandbox changes to integration
-  discard   Discard sandbox changes
-  open      Open sandbox in editor
-  logs      View invocation logs`,
+  show      Show details of an invocation`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			_ = cmd.Help()
-			return errors.New(errors.EUsage, "specify a subcommand: agency agent <start|ls|show|attach|stop|kill|diff|land|discard|open|logs>")
+			return errors.New(errors.EUsage, "specify a subcommand: agency agent <start|ls|show>")
+		},
+	}
+
+	cmd.AddCommand(
+		newAgentStartCmd(),
+		newAgentLSCmd(),
+		newAgentShowCmd(),
+	)
+
+	return cmd
+}
+
+func newAgentStartCmd() *cobra.Command {
+	var worktree string
+	var name string
+	var runner string
+	var repoPath string
+	var detached bool
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start a new agent invocation",
+		Long: `Start a new agent invocation against an integration worktree.
+
+Creates a sandbox worktree on branch agency/sandbox-<invocation_id> from the
+integration branch, records the base commit, and starts the runner in a tmux
+session with the sandbox as its working directory.
+
+Example:
+  agency agent start --worktree my-feature
+  agency agent start --worktree my-feature --runner codex --detached`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if worktree == "" {
+				return errors.New(errors.EUsage, "--worktree is required")
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get cwd", err)
+			}
+
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			return commands.AgentStart(ctx, cr, fsys, cwd, commands.AgentStartOpts{
+				Worktree: worktree,
+				Name:     name,
+				Runner:   runner,
+				RepoPath: repoPath,
+				Detached: detached,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
 		},
 	}
 
+	cmd.Flags().StringVar(&worktree, "worktree", "", "Integration worktree name, id, or prefix (required)")
+	cmd.Flags().StringVar(&name, "name", "", "Optional label for the invocation (display only)")
+	cmd.Flags().StringVar(&runner, "runner", "", "Runner name (default: user config defaults.runner)")
+	cmd.Flags().StringVar(&repoPath, "repo", "", "Path to git repository")
+	cmd.Flags().BoolVar(&detached, "detached", false, "Do not attach to the tmux session")
+
+	return cmd
+}
+
+func newAgentLSCmd() *cobra.Command {
+	var repoPath string
+	var worktree string
+	var all bool
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List agent invocations",
+		Long: `List agent invocations for the current repository, grouped by
+integration worktree.
+
+By default, broken invocations (missing meta or sandbox) are hidden.
+
+Example:
+  agency agent ls
+  agency agent ls --worktree my-feature
+  agency agent ls --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get cwd", err)
+			}
+
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			return commands.AgentLS(ctx, cr, fsys, cwd, commands.AgentLSOpts{
+				RepoPath: repoPath,
+				Worktree: worktree,
+				All:      all,
+				JSON:     jsonOut,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "Path to git repository")
+	cmd.Flags().StringVar(&worktree, "worktree", "", "Only list invocations for this integration worktree")
+	cmd.Flags().BoolVar(&all, "all", false, "Include broken invocations")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+func newAgentShowCmd() *cobra.Command {
+	var repoPath string
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "show <invocation_id|prefix>",
+		Short: "Show details of an invocation",
+		Long: `Show details of an agent invocation.
+
+The invocation is specified by invocation id or unique prefix. Invocation
+names are labels only and are not accepted here.
+
+Example:
+  agency agent show 20260131120500-b7c9
+  agency agent show --json 20260131`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get cwd", err)
+			}
+
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			return commands.AgentShow(ctx, cr, fsys, cwd, commands.AgentShowOpts{
+				InvocationRef: args[0],
+				RepoPath:      repoPath,
+				JSON:          jsonOut,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "Path to git repository")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+
 	return cmd
 }
@@ -0,0 +1,146 @@
+package cobra
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/checkpoint"
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newCheckpointCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "List and restore run worktree checkpoints",
+		Long: `List and restore run worktree checkpoints.
+
+While a run's runner is alive, agency snapshots the run worktree (including
+untracked files) after file changes settle and on a periodic dirty-check.
+Files ignored by git (.gitignore, .git/info/exclude, core.excludesFile) are
+not captured, so a checkpoint cannot restore them.
+Snapshots are stored as refs/agency/snapshots/<run_id>/<n> and never touch
+the worktree's index, HEAD, or branches.
+
+Subcommands:
+  ls       List a run's checkpoints
+  apply    Restore a run's worktree files to a checkpoint`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = cmd.Help()
+			return errors.New(errors.EUsage, "specify a subcommand: agency checkpoint <ls|apply>")
+		},
+	}
+
+	cmd.AddCommand(
+		newCheckpointLSCmd(),
+		newCheckpointApplyCmd(),
+	)
+
+	return cmd
+}
+
+func newCheckpointLSCmd() *cobra.Command {
+	var repoPath string
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "ls <run>",
+		Short: "List a run's checkpoints",
+		Long: `List a run's worktree checkpoints, oldest first.
+
+Arguments:
+  run    run name, run_id, or unique run_id prefix`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			return commands.CheckpointLS(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), cwd, commands.CheckpointLSOpts{
+				RunID:    args[0],
+				RepoPath: repoPath,
+				JSON:     jsonOutput,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "scope name resolution to a specific repo")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON (stable format)")
+
+	return cmd
+}
+
+func newCheckpointApplyCmd() *cobra.Command {
+	var repoPath string
+
+	cmd := &cobra.Command{
+		Use:   "apply <run> <n>",
+		Short: "Restore a run's worktree files to a checkpoint",
+		Long: `Restore a run's worktree files to checkpoint <n>.
+
+The current state is snapshotted first (trigger "pre-apply"), so the
+rollback can itself be undone. Files that did not exist at the checkpoint
+are removed. Files ignored by git are left as they are. HEAD and the index
+are not changed.
+
+Arguments:
+  run    run name, run_id, or unique run_id prefix
+  n      checkpoint number (see agency checkpoint ls)`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return errors.New(errors.EUsage, "checkpoint number must be a positive integer")
+			}
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			return commands.CheckpointApply(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), cwd, commands.CheckpointApplyOpts{
+				RunID:    args[0],
+				RepoPath: repoPath,
+				N:        n,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "scope name resolution to a specific repo")
+
+	return cmd
+}
+
+// newCheckpointWatchCmd creates the hidden watcher command spawned by run and resume.
+func newCheckpointWatchCmd() *cobra.Command {
+	var dataDir string
+	var repoID string
+	var runID string
+
+	cmd := &cobra.Command{
+		Use:    checkpoint.WatcherCommand,
+		Short:  "Watch a run worktree and take checkpoints (internal)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.CheckpointWatch(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), commands.CheckpointWatchOpts{
+				DataDir: dataDir,
+				RepoID:  repoID,
+				RunID:   runID,
+			}, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&dataDir, "data-dir", "", "agency data directory")
+	cmd.Flags().StringVar(&repoID, "repo-id", "", "repo id of the run")
+	cmd.Flags().StringVar(&runID, "run-id", "", "run id to watch")
+
+	return cmd
+}
@@ -0,0 +1,116 @@
+package cobra
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newDaemonCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Manage the optional background supervisor",
+		Long: `Manage the optional background supervisor.
+
+The daemon polls every repo in repo_index.json: it records runner exits,
+detects stalls, fires notify hooks on state transitions and starts queued runs,
+so these happen without any agency command being invoked. It is opt-in;
+every command works the same without it.
+
+Subcommands:
+  start   Start the daemon in the background
+  stop    Stop the daemon
+  status  Show whether the daemon is running and its health
+  run     Run the daemon in the foreground`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = cmd.Help()
+			return errors.New(errors.EUsage, "specify a subcommand: agency daemon <start|stop|status|run>")
+		},
+	}
+
+	cmd.AddCommand(
+		newDaemonStartCmd(),
+		newDaemonStopCmd(),
+		newDaemonStatusCmd(),
+		newDaemonRunCmd(),
+	)
+
+	return cmd
+}
+
+func newDaemonStartCmd() *cobra.Command {
+	var opts commands.DaemonStartOpts
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon in the background",
+		Long: `Start the daemon detached, logging to ${AGENCY_DATA_DIR}/daemon.log.
+Does nothing if a daemon is already running.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.DaemonStart(context.Background(), opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().DurationVar(&opts.Interval, "interval", 0, "poll interval (default 15s)")
+
+	return cmd
+}
+
+func newDaemonStopCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon",
+		Long:  `Send SIGTERM to the running daemon and wait for it to exit.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.DaemonStop(context.Background(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	return cmd
+}
+
+func newDaemonStatusCmd() *cobra.Command {
+	var opts commands.DaemonStatusOpts
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the daemon is running and its health",
+		Long: `Show whether the daemon is running (from its pidfile) and the health it
+reports on its socket: last poll, runs seen, active and stalled runs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.DaemonStatus(context.Background(), opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "output as JSON (stable format)")
+
+	return cmd
+}
+
+func newDaemonRunCmd() *cobra.Command {
+	var opts commands.DaemonRunOpts
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run the daemon in the foreground",
+		Long: `Run the daemon in the foreground until interrupted, e.g. under launchd or
+systemd. Fails with E_DAEMON_RUNNING if another daemon is running.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.DaemonRun(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().DurationVar(&opts.Interval, "interval", 0, "poll interval (default 15s)")
+
+	return cmd
+}
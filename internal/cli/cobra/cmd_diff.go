@@ -0,0 +1,67 @@
+package cobra
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newDiffCmd() *cobra.Command {
+	var repoPath string
+	var stat bool
+	var nameOnly bool
+	var uncommitted bool
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "diff <run>",
+		Short: "Show a run's changes against its parent branch",
+		Long: `Show a run's commits and changes against the merge-base with its parent branch.
+Works from any directory; resolves runs globally.
+
+Arguments:
+  run    run name, run_id, or unique run_id prefix
+
+By default only committed changes are shown. Use --uncommitted to include
+working-tree edits and new (untracked, non-ignored) files.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stdout := cmd.OutOrStdout()
+			stderr := cmd.ErrOrStderr()
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			opts := commands.DiffOpts{
+				RunID:       args[0],
+				RepoPath:    repoPath,
+				Stat:        stat,
+				NameOnly:    nameOnly,
+				Uncommitted: uncommitted,
+				JSON:        jsonOutput,
+			}
+
+			return commands.Diff(ctx, cr, fsys, cwd, opts, stdout, stderr)
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "scope name resolution to a specific repo")
+	cmd.Flags().BoolVar(&stat, "stat", false, "show a diffstat instead of the full patch")
+	cmd.Flags().BoolVar(&nameOnly, "name-only", false, "print only changed file paths")
+	cmd.Flags().BoolVar(&uncommitted, "uncommitted", false, "include working-tree changes and new files")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON (stable format)")
+
+	return cmd
+}
@@ -0,0 +1,59 @@
+package cobra
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newGCCmd() *cobra.Command {
+	var opts commands.GCOpts
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Prune old run records, stale branches and logs",
+		Long: `Prune run records (with their logs and transcripts), their branches,
+and stale agency/* branches across all repos in the data dir.
+
+Selection:
+  - --merged, --abandoned, --broken select runs by status (any of them);
+    with none of them every archived, merged, abandoned or broken run is a
+    candidate
+  - --all also selects runs that were never archived (e.g. whose worktree
+    was removed by hand) and cannot be combined with a status filter
+  - --older-than limits to runs archived (or created) longer ago than the age
+  - runs with a live tmux session or a present worktree are never touched
+    unless --include-active is given (which kills the session and removes
+    the worktree)
+  - with --all, agency/* branches that no run, integration worktree or
+    invocation refers to are also deleted
+
+Examples:
+  agency gc --dry-run
+  agency gc --merged --older-than 30d
+  agency gc --broken
+  agency gc --all --older-than 90d`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			return commands.GC(ctx, cr, fsys, opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OlderThan, "older-than", "", "only prune runs older than this age (e.g. 30d, 2w, 12h)")
+	cmd.Flags().BoolVar(&opts.Merged, "merged", false, "prune merged runs")
+	cmd.Flags().BoolVar(&opts.Abandoned, "abandoned", false, "prune abandoned runs")
+	cmd.Flags().BoolVar(&opts.Broken, "broken", false, "prune runs with unreadable meta.json")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "also prune runs that were never archived, and stale agency/* branches")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "print what would be removed without removing it")
+	cmd.Flags().BoolVar(&opts.IncludeActive, "include-active", false, "also prune runs with a live tmux session or present worktree")
+
+	return cmd
+}
@@ -0,0 +1,102 @@
+package cobra
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newGroupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "group",
+		Short: "Compare and pick best-of-N run attempts",
+		Long: `Compare and pick best-of-N run attempts.
+
+agency run --attempts N creates N sibling runs of the same prompt that share
+a group id. Each attempt is verified once its runner reports ready_for_review.
+
+Subcommands:
+  show    Compare a group's attempts side by side
+  pick    Keep one attempt and abandon the others`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = cmd.Help()
+			return errors.New(errors.EUsage, "specify a subcommand: agency group <show|pick>")
+		},
+	}
+
+	cmd.AddCommand(
+		newGroupShowCmd(),
+		newGroupPickCmd(),
+	)
+
+	return cmd
+}
+
+func newGroupShowCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "show <group>",
+		Short: "Compare a group's attempts side by side",
+		Long: `Compare a group's attempts side by side: state, verify result, committed
+diff size against the parent branch, files touched, and runner summary.
+
+Arguments:
+  group    group id or unique group id prefix`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			return commands.GroupShow(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), cwd, commands.GroupShowOpts{
+				GroupID: args[0],
+				JSON:    jsonOutput,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON (stable format)")
+
+	return cmd
+}
+
+func newGroupPickCmd() *cobra.Command {
+	var opts commands.GroupPickOpts
+
+	cmd := &cobra.Command{
+		Use:   "pick <run>",
+		Short: "Keep one attempt and abandon the others",
+		Long: `Keep one attempt of a group and abandon every other active attempt through
+the clean pipeline (archive script, tmux kill, worktree removal, marked
+abandoned). Asks for confirmation unless --yes is given.
+
+Arguments:
+  run    run name, run_id, or unique run_id prefix of the attempt to keep`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			opts.RunID = args[0]
+			return commands.GroupPick(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), cwd, opts, os.Stdin, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.RepoPath, "repo", "", "scope name resolution to a specific repo")
+	cmd.Flags().BoolVar(&opts.Yes, "yes", false, "skip the confirmation prompt")
+	cmd.Flags().BoolVar(&opts.AllowDirty, "allow-dirty", false, "abandon attempts even if their worktrees have uncommitted changes")
+	cmd.Flags().BoolVar(&opts.DeleteBranch, "delete-branch", false, "delete the abandoned attempts' branches and close their PRs")
+
+	return cmd
+}
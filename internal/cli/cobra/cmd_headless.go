@@ -0,0 +1,60 @@
+package cobra
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
+	"github.com/NielsdaWheelz/agency/internal/lifecycle"
+)
+
+// newHeadlessSuperviseCmd creates the hidden supervisor command spawned by `run --headless`.
+func newHeadlessSuperviseCmd() *cobra.Command {
+	var dataDir string
+	var repoID string
+	var runID string
+
+	cmd := &cobra.Command{
+		Use:    headless.SupervisorCommand,
+		Short:  "Supervise a headless runner (internal)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.HeadlessSupervise(fs.NewRealFS(), commands.HeadlessSuperviseOpts{
+				DataDir: dataDir,
+				RepoID:  repoID,
+				RunID:   runID,
+			}, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&dataDir, "data-dir", "", "agency data directory")
+	cmd.Flags().StringVar(&repoID, "repo-id", "", "repo id of the run")
+	cmd.Flags().StringVar(&runID, "run-id", "", "run id to supervise")
+
+	return cmd
+}
+
+// newRunnerExitedCmd creates the hidden command the tmux session wrapper
+// calls when a run's runner exits.
+func newRunnerExitedCmd() *cobra.Command {
+	var opts commands.RunnerExitedOpts
+
+	cmd := &cobra.Command{
+		Use:    lifecycle.RunnerExitedCommand,
+		Short:  "Record a runner exit (internal)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.RunnerExited(fs.NewRealFS(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.DataDir, "data-dir", "", "agency data directory")
+	cmd.Flags().StringVar(&opts.RepoID, "repo-id", "", "repo id of the run")
+	cmd.Flags().StringVar(&opts.RunID, "run-id", "", "run id whose runner exited")
+	cmd.Flags().IntVar(&opts.Status, "status", 0, "runner exit status")
+
+	return cmd
+}
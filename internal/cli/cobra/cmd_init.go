@@ -16,6 +16,7 @@ func newInitCmd() *cobra.Command {
 	var repoPath string
 	var noGitignore bool
 	var force bool
+	var mcp bool
 
 	cmd := &cobra.Command{
 		Use:   "init",
@@ -40,6 +41,7 @@ Defaults to current directory; use --repo to target a different repo.`,
 				RepoPath:    repoPath,
 				NoGitignore: noGitignore,
 				Force:       force,
+				MCP:         mcp,
 			}
 
 			return commands.Init(ctx, cr, fsys, cwd, opts, stdout, stderr)
@@ -49,6 +51,7 @@ Defaults to current directory; use --repo to target a different repo.`,
 	cmd.Flags().StringVar(&repoPath, "repo", "", "target a specific repo (default: current directory)")
 	cmd.Flags().BoolVar(&noGitignore, "no-gitignore", false, "do not modify .gitignore")
 	cmd.Flags().BoolVar(&force, "force", false, "overwrite existing agency.json")
+	cmd.Flags().BoolVar(&mcp, "mcp", false, "register the agency MCP server in .mcp.json (keeps an existing agency.json)")
 
 	return cmd
 }
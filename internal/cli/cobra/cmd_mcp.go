@@ -0,0 +1,35 @@
+package cobra
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newMCPCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve runner tools over the Model Context Protocol (stdio)",
+		Long: `Serve agency's runner tools as a Model Context Protocol server on stdin/stdout.
+
+Started by the runner (not by hand) from inside a run worktree. Tools act on
+that run: set_status, ask_question, write_report, run_verify and get_run_info.
+Register it for claude with: agency init --mcp`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+			return commands.MCP(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), cwd, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,39 @@
+package cobra
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/monitor"
+)
+
+// newRunMonitorCmd creates the hidden run monitor command spawned by run and resume.
+func newRunMonitorCmd() *cobra.Command {
+	var dataDir string
+	var repoID string
+	var runID string
+
+	cmd := &cobra.Command{
+		Use:    monitor.Command,
+		Short:  "Enforce budgets and send notifications for a run (internal)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.RunMonitor(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), commands.RunMonitorOpts{
+				DataDir: dataDir,
+				RepoID:  repoID,
+				RunID:   runID,
+			}, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&dataDir, "data-dir", "", "agency data directory")
+	cmd.Flags().StringVar(&repoID, "repo-id", "", "repo id of the run")
+	cmd.Flags().StringVar(&runID, "run-id", "", "run id to monitor")
+
+	return cmd
+}
@@ -16,6 +16,7 @@ func newPushCmd() *cobra.Command {
 	var allowDirty bool
 	var force bool
 	var forceWithLease bool
+	var allowDenylisted bool
 
 	cmd := &cobra.Command{
 		Use:   "push <run>",
@@ -31,6 +32,9 @@ Notes:
   - requires gh to be authenticated
   - does NOT bypass E_EMPTY_DIFF (at least one commit required)
   - fails if worktree has uncommitted changes unless --allow-dirty
+  - fails if files added on the branch or untracked files match the secret
+    denylist (.env, *.pem, credentials.json, ... plus agency.json "denylist")
+    unless --allow-denylisted
   - uses report as PR body when complete; otherwise auto-generates a PR body
   - use --force-with-lease after rebasing to update an existing branch safely`,
 		Args: cobra.ExactArgs(1),
@@ -48,10 +52,11 @@ Notes:
 			ctx := context.Background()
 
 			opts := commands.PushOpts{
-				RunID:          args[0],
-				Force:          force,
-				AllowDirty:     allowDirty,
-				ForceWithLease: forceWithLease,
+				RunID:           args[0],
+				Force:           force,
+				AllowDirty:      allowDirty,
+				ForceWithLease:  forceWithLease,
+				AllowDenylisted: allowDenylisted,
 			}
 
 			return commands.Push(ctx, cr, fsys, cwd, opts, stdout, stderr)
@@ -61,6 +66,7 @@ Notes:
 	cmd.Flags().BoolVar(&allowDirty, "allow-dirty", false, "allow push even if worktree has uncommitted changes")
 	cmd.Flags().BoolVar(&force, "force", false, "retained for compatibility (no-op for report checks)")
 	cmd.Flags().BoolVar(&forceWithLease, "force-with-lease", false, "use git push --force-with-lease (required after rebase)")
+	cmd.Flags().BoolVar(&allowDenylisted, "allow-denylisted", false, "allow push even if files match the secret denylist")
 
 	return cmd
 }
@@ -0,0 +1,106 @@
+package cobra
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newQueueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Manage runs waiting for a free max_active_runs slot",
+		Long: `Manage runs waiting for a free max_active_runs slot.
+
+agency run --queue queues a run when the limits in the user config are
+reached. Queued runs start oldest first, detached, when agency ls runs, when a
+runner exits, or on agency queue run.
+
+Subcommands:
+  ls     List queued runs
+  rm     Remove a queued run
+  run    Start queued runs while slots are free`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = cmd.Help()
+			return errors.New(errors.EUsage, "specify a subcommand: agency queue <ls|rm|run>")
+		},
+	}
+
+	cmd.AddCommand(
+		newQueueLSCmd(),
+		newQueueRmCmd(),
+		newQueueRunCmd(),
+	)
+
+	return cmd
+}
+
+func newQueueLSCmd() *cobra.Command {
+	var opts commands.QueueLSOpts
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List queued runs",
+		Long: `List queued runs across all repos, oldest first, with the number of active
+runs and the configured limits. Runs that failed to start stay listed with
+their error until removed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.QueueLS(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "output as JSON (stable format)")
+
+	return cmd
+}
+
+func newQueueRmCmd() *cobra.Command {
+	var opts commands.QueueRmOpts
+
+	cmd := &cobra.Command{
+		Use:   "rm <run>",
+		Short: "Remove a queued run",
+		Long: `Remove a queued run that has not started, deleting its run record.
+
+Arguments:
+  run    run name, run_id, or unique run_id prefix`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			opts.RunID = args[0]
+			return commands.QueueRm(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), cwd, opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.RepoPath, "repo", "", "scope name resolution to a specific repo")
+
+	return cmd
+}
+
+func newQueueRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Start queued runs while slots are free",
+		Long: `Start queued runs, oldest first, while the max_active_runs limits allow.
+Runs start detached; a run that fails to start is marked failed and the
+others continue.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.QueueRun(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	return cmd
+}
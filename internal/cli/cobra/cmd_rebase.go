@@ -0,0 +1,65 @@
+package cobra
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newRebaseCmd() *cobra.Command {
+	var repoPath string
+	var agent bool
+
+	cmd := &cobra.Command{
+		Use:   "rebase <run>",
+		Short: "Rebase a run branch onto its up-to-date parent",
+		Long: `Fetch origin and rebase the run branch onto its parent branch.
+Uses origin/<parent> when the local parent is missing or behind it.
+Works from any directory; resolves runs globally.
+
+Arguments:
+  run    run name, run_id, or unique run_id prefix
+
+On conflict:
+  - by default the rebase is aborted and a conflict card is printed
+  - with --agent the rebase is left in progress and the runner is told to resolve it
+  - either way the run is flagged needs_attention (reason: rebase_conflict)
+
+Notes:
+  - the worktree must have no uncommitted tracked changes
+  - after rebasing a pushed run, push with --force-with-lease`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stdout := cmd.OutOrStdout()
+			stderr := cmd.ErrOrStderr()
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			opts := commands.RebaseOpts{
+				RunID:    args[0],
+				RepoPath: repoPath,
+				Agent:    agent,
+			}
+
+			return commands.Rebase(ctx, cr, fsys, cwd, opts, stdout, stderr)
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "scope name resolution to a specific repo")
+	cmd.Flags().BoolVar(&agent, "agent", false, "on conflict, leave the rebase in progress and ask the runner to resolve it")
+
+	return cmd
+}
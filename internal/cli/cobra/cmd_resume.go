@@ -17,6 +17,7 @@ func newResumeCmd() *cobra.Command {
 	var detached bool
 	var restart bool
 	var yes bool
+	var fresh bool
 
 	cmd := &cobra.Command{
 		Use:   "resume <run>",
@@ -25,13 +26,17 @@ func newResumeCmd() *cobra.Command {
 If session is missing, creates one and starts the runner.
 Works from any directory; resolves runs globally.
 
+A new runner continues its last conversation (claude --resume, codex resume)
+using the session id discovered from the runner's session log. Use --fresh
+to start a new conversation instead.
+
 Arguments:
   run    run name, run_id, or unique run_id prefix
 
 Notes:
   - resume never runs scripts (setup/verify/archive)
   - resume preserves git state; only tmux session changes
-  - --restart will lose in-tool history (chat context, etc.)`,
+  - --restart --fresh loses in-tool history (chat context, etc.)`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			stdout := cmd.OutOrStdout()
@@ -52,6 +57,7 @@ Notes:
 				Detached: detached,
 				Restart:  restart,
 				Yes:      yes,
+				Fresh:    fresh,
 			}
 
 			return commands.Resume(ctx, cr, fsys, cwd, opts, os.Stdin, stdout, stderr)
@@ -62,6 +68,7 @@ Notes:
 	cmd.Flags().BoolVar(&detached, "detached", false, "do not attach; return after ensuring session exists")
 	cmd.Flags().BoolVar(&restart, "restart", false, "kill existing session (if any) and recreate")
 	cmd.Flags().BoolVar(&yes, "yes", false, "skip confirmation prompt for --restart")
+	cmd.Flags().BoolVar(&fresh, "fresh", false, "start a new runner conversation instead of continuing the last one")
 
 	return cmd
 }
@@ -3,6 +3,7 @@ package cobra
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -18,6 +19,16 @@ func newRunCmd() *cobra.Command {
 	var runner string
 	var parent string
 	var detached bool
+	var headless bool
+	var prompt string
+	var promptFile string
+	var from string
+	var concurrency int
+	var attempts int
+	var runners []string
+	var queue bool
+	var maxDuration time.Duration
+	var maxStall time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "run",
@@ -25,18 +36,38 @@ func newRunCmd() *cobra.Command {
 		Long: `Create workspace, run setup, and start tmux runner session.
 Defaults to current directory; use --repo to target a different repo.
 Requires the target repo to have agency.json.
-By default, attaches to the tmux session after creation.`,
+By default, attaches to the tmux session after creation.
+
+With --headless, the runner runs non-interactively as a supervised background
+process fed --prompt/--prompt-file on stdin (claude -p --output-format
+stream-json, codex exec --json). Stdout is captured to logs/raw.jsonl and
+stderr to logs/stderr.log; no tmux session is created.
+
+With --from, creates one detached run per task in a YAML (or .json) task
+file. Each task sets name and prompt/prompt_file, and optionally runner,
+parent and headless (--runner/--parent/--headless are the defaults). Setup
+scripts run at most --concurrency at a time; tmux runners get their prompt as
+the first message. Prints a summary table and the setup log of each failure.
+
+With --attempts N, creates N detached sibling runs (<name>-1..<name>-N) of the
+same prompt in a best-of-N group; --runners cycles runners across attempts.
+Each attempt is verified once its runner reports ready_for_review. Compare
+them with agency group show and keep one with agency group pick.
+
+When the max_active_runs limits in the user config are reached, run fails
+with E_RUN_LIMIT; with --queue the run is queued instead (meta created,
+worktree deferred) and started detached once a slot frees up. See agency
+queue ls.
+
+--max-duration and --max-stall override the agency.json budgets: the run's
+monitor interrupts the runner (C-c, like agency stop) once it has
+run that long or shown no activity for that long, and marks the run
+needs_attention with reason budget_exceeded.`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			stdout := cmd.OutOrStdout()
 			stderr := cmd.ErrOrStderr()
 
-			// --name is required
-			if name == "" {
-				_ = cmd.Help()
-				return errors.New(errors.EUsage, "--name is required")
-			}
-
 			cwd, err := os.Getwd()
 			if err != nil {
 				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
@@ -46,12 +77,47 @@ By default, attaches to the tmux session after creation.`,
 			fsys := fs.NewRealFS()
 			ctx := context.Background()
 
+			if from != "" {
+				if name != "" || prompt != "" || promptFile != "" || attempts != 0 {
+					return errors.New(errors.EUsage, "--from cannot be combined with --name, --prompt, --prompt-file or --attempts")
+				}
+				opts := commands.RunBatchOpts{
+					From:        from,
+					RepoPath:    repoPath,
+					Runner:      runner,
+					Parent:      parent,
+					Headless:    headless,
+					Concurrency: concurrency,
+					Queue:       queue,
+					MaxDuration: maxDuration,
+					MaxStall:    maxStall,
+				}
+				return commands.RunBatch(ctx, cr, fsys, cwd, opts, stdout, stderr)
+			}
+			if cmd.Flags().Changed("concurrency") {
+				return errors.New(errors.EUsage, "--concurrency requires --from")
+			}
+
+			// --name is required
+			if name == "" {
+				_ = cmd.Help()
+				return errors.New(errors.EUsage, "--name is required")
+			}
+
 			opts := commands.RunOpts{
-				Name:     name,
-				RepoPath: repoPath,
-				Runner:   runner,
-				Parent:   parent,
-				Attach:   !detached,
+				Name:        name,
+				RepoPath:    repoPath,
+				Runner:      runner,
+				Parent:      parent,
+				Attach:      !detached,
+				Headless:    headless,
+				Prompt:      prompt,
+				PromptFile:  promptFile,
+				Attempts:    attempts,
+				Runners:     runners,
+				Queue:       queue,
+				MaxDuration: maxDuration,
+				MaxStall:    maxStall,
 			}
 
 			return commands.Run(ctx, cr, fsys, cwd, opts, stdout, stderr)
@@ -63,6 +129,16 @@ By default, attaches to the tmux session after creation.`,
 	cmd.Flags().StringVar(&runner, "runner", "", "runner name: claude or codex (default: user config defaults.runner)")
 	cmd.Flags().StringVar(&parent, "parent", "", "parent branch (default: current branch)")
 	cmd.Flags().BoolVar(&detached, "detached", false, "do not attach to tmux session after creation")
+	cmd.Flags().BoolVar(&headless, "headless", false, "run the runner non-interactively without tmux (requires --prompt or --prompt-file)")
+	cmd.Flags().StringVar(&prompt, "prompt", "", "prompt text for a headless run or --attempts")
+	cmd.Flags().StringVar(&promptFile, "prompt-file", "", "file containing the prompt for a headless run or --attempts")
+	cmd.Flags().StringVar(&from, "from", "", "create one detached run per task in a YAML/JSON task file")
+	cmd.Flags().IntVar(&attempts, "attempts", 0, "create N sibling runs of the prompt in a best-of-N group (detached)")
+	cmd.Flags().StringSliceVar(&runners, "runners", nil, "with --attempts: comma-separated runners cycled across attempts")
+	cmd.Flags().BoolVar(&queue, "queue", false, "queue the run when max_active_runs is reached instead of failing")
+	cmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "interrupt the runner after this long, e.g. 2h (default: agency.json budgets.max_duration)")
+	cmd.Flags().DurationVar(&maxStall, "max-stall", 0, "interrupt the runner after no activity for this long, e.g. 45m (default: agency.json budgets.max_stall)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 0, "with --from: max setup scripts running at once (default: task file concurrency, else 4)")
 
 	return cmd
 }
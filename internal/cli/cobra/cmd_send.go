@@ -0,0 +1,107 @@
+package cobra
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newSendCmd() *cobra.Command {
+	var repoPath string
+
+	cmd := &cobra.Command{
+		Use:   "send <run> <message>",
+		Short: "Send a message to the runner without attaching",
+		Long: `Type a message into the runner's tmux session and press Enter.
+Works from any directory; resolves runs globally.
+
+Arguments:
+  run        run name, run_id, or unique run_id prefix
+  message    text to send (remaining arguments are joined with spaces;
+             newlines are collapsed so the message is submitted once)
+
+Notes:
+  - requires a live tmux session (use 'agency resume' to start one)
+  - headless runs cannot receive messages
+  - each message is recorded in events.jsonl (message_sent)`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stdout := cmd.OutOrStdout()
+			stderr := cmd.ErrOrStderr()
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			opts := commands.SendOpts{
+				RunID:    args[0],
+				RepoPath: repoPath,
+				Message:  strings.Join(args[1:], " "),
+			}
+
+			return commands.Send(ctx, cr, fsys, cwd, opts, stdout, stderr)
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "scope name resolution to a specific repo")
+
+	return cmd
+}
+
+func newAnswerCmd() *cobra.Command {
+	var repoPath string
+
+	cmd := &cobra.Command{
+		Use:   "answer <run>",
+		Short: "Answer the runner's open questions without attaching",
+		Long: `List the questions[] from the runner's runner_status.json, prompt for
+an answer to each, and send the answers to the runner as one reply.
+Works from any directory; resolves runs globally.
+
+Arguments:
+  run    run name, run_id, or unique run_id prefix
+
+Notes:
+  - answers are read one line per question from stdin, so they can be piped
+  - an empty answer skips that question; if all are skipped nothing is sent
+  - requires a live tmux session; headless runs cannot receive answers
+  - each reply is recorded in events.jsonl (answer_sent)`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stdout := cmd.OutOrStdout()
+			stderr := cmd.ErrOrStderr()
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			opts := commands.AnswerOpts{
+				RunID:    args[0],
+				RepoPath: repoPath,
+			}
+
+			return commands.Answer(ctx, cr, fsys, cwd, opts, os.Stdin, stdout, stderr)
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "scope name resolution to a specific repo")
+
+	return cmd
+}
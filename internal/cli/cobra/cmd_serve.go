@@ -0,0 +1,38 @@
+package cobra
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newServeCmd() *cobra.Command {
+	var opts commands.ServeOpts
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a local JSON API over a unix socket",
+		Long: `Serve a versioned HTTP/JSON API over a unix domain socket until interrupted.
+
+The API lists and shows runs and integration worktrees, returns runner status
+and events, runs actions (stop, kill, push, verify, merge, clean) and streams
+run status changes as server-sent events. Errors carry agency error codes as
+structured fields. The socket is only accessible to the current user.
+
+Example:
+  agency serve --socket /tmp/agency.sock &
+  curl --unix-socket /tmp/agency.sock http://agency/v1/runs`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return commands.Serve(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Socket, "socket", "", "unix socket path (default ${AGENCY_DATA_DIR}/agency.sock)")
+
+	return cmd
+}
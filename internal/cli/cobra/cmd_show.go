@@ -16,6 +16,7 @@ func newShowCmd() *cobra.Command {
 	var jsonOutput bool
 	var pathOutput bool
 	var capture bool
+	var history bool
 
 	cmd := &cobra.Command{
 		Use:   "show <run>",
@@ -44,6 +45,7 @@ Arguments:
 				JSON:    jsonOutput,
 				Path:    pathOutput,
 				Capture: capture,
+				History: history,
 				Args:    args,
 			}
 
@@ -54,6 +56,7 @@ Arguments:
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output as JSON (stable format)")
 	cmd.Flags().BoolVar(&pathOutput, "path", false, "output only resolved filesystem paths")
 	cmd.Flags().BoolVar(&capture, "capture", false, "capture tmux scrollback to transcript files (mutating mode)")
+	cmd.Flags().BoolVar(&history, "history", false, "show the runner status timeline and time spent per status")
 
 	return cmd
 }
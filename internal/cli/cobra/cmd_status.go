@@ -0,0 +1,76 @@
+package cobra
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NielsdaWheelz/agency/internal/commands"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func newStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Report runner status from inside a run worktree",
+		Long: `Report runner status from inside a run worktree.
+
+Subcommands:
+  set    Validate and write .agency/state/runner_status.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = cmd.Help()
+			return errors.New(errors.EUsage, "specify a subcommand: agency status <set>")
+		},
+	}
+
+	cmd.AddCommand(newStatusSetCmd())
+
+	return cmd
+}
+
+func newStatusSetCmd() *cobra.Command {
+	var opts commands.StatusSetOpts
+
+	cmd := &cobra.Command{
+		Use:   "set <status>",
+		Short: "Validate and write the runner status",
+		Long: `Validate and write .agency/state/runner_status.json for the run whose
+worktree contains the current directory, stamping updated_at and logging a
+runner_status_changed event.
+
+Arguments:
+  status    working, needs_input, blocked or ready_for_review
+
+Required flags per status:
+  working             --summary
+  needs_input         --question (summary defaults to the first question)
+  blocked             --blocker (summary defaults to the first blocker)
+  ready_for_review    --summary and --how-to-test
+
+Examples:
+  agency status set working --summary "implementing token refresh"
+  agency status set needs_input --question "postgres or sqlite?"
+  agency status set ready_for_review --summary "added oauth" --how-to-test "go test ./..."`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+
+			opts.Status = args[0]
+			return commands.StatusSet(context.Background(), fs.NewRealFS(), cwd, opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Summary, "summary", "", "one-line summary of the current state")
+	cmd.Flags().StringArrayVar(&opts.Questions, "question", nil, "question for the user (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.Blockers, "blocker", nil, "what blocks progress (repeatable)")
+	cmd.Flags().StringVar(&opts.HowToTest, "how-to-test", "", "how to test the change")
+	cmd.Flags().StringArrayVar(&opts.Risks, "risk", nil, "known risk (repeatable)")
+
+	return cmd
+}
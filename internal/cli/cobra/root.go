@@ -50,19 +50,35 @@ and provides commands to control the runner session.`,
 		newRunCmd(),
 		newLSCmd(),
 		newShowCmd(),
+		newDiffCmd(),
+		newCheckpointCmd(),
+		newGroupCmd(),
+		newQueueCmd(),
+		newDaemonCmd(),
+		newServeCmd(),
+		newMCPCmd(),
+		newStatusCmd(),
 		newPathCmd(),
 		newOpenCmd(),
 		newAttachCmd(),
 		newResumeCmd(),
 		newStopCmd(),
+		newSendCmd(),
+		newAnswerCmd(),
 		newKillCmd(),
 		newPushCmd(),
+		newRebaseCmd(),
 		newVerifyCmd(),
 		newMergeCmd(),
 		newCleanCmd(),
+		newGCCmd(),
 		newCompletionCmd(),
 		newResolveCmd(),
 		newVersionCmd(),
+		newHeadlessSuperviseCmd(),
+		newRunnerExitedCmd(),
+		newCheckpointWatchCmd(),
+		newRunMonitorCmd(),
 		// v2 command shells (empty for now)
 		newWorktreeCmd(),
 		newAgentCmd(),
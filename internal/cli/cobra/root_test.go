@@ -295,11 +295,25 @@ func TestAgentCmd_ReturnsUsageError(t *testing.T) {
 	}
 }
 
-func TestWatchCmd_ReturnsUsageError(t *testing.T) {
+func TestWatchCmd_RequiresInteractiveTerminal(t *testing.T) {
 	_, _, err := executeCmd("watch")
 	if err == nil {
-		t.Fatal("expected error when watch called (not implemented)")
+		t.Fatal("expected error when watch runs without a terminal")
 	}
+	if errors.GetCode(err) != errors.ENotInteractive {
+		t.Errorf("code = %q, want %q", errors.GetCode(err), errors.ENotInteractive)
+	}
+}
+
+func TestWatchCmd_RejectsNonPositiveInterval(t *testing.T) {
+	_, _, err := executeCmd("watch", "--interval", "0s")
+	if errors.GetCode(err) != errors.EUsage {
+		t.Errorf("code = %q, want %q", errors.GetCode(err), errors.EUsage)
+	}
+}
+
+func TestWatchCmd_OnceRequiresJSON(t *testing.T) {
+	_, _, err := executeCmd("watch", "--once")
 	if errors.GetCode(err) != errors.EUsage {
 		t.Errorf("code = %q, want %q", errors.GetCode(err), errors.EUsage)
 	}
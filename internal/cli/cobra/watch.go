@@ -1,30 +1,78 @@
 package cobra
 
 import (
+	"context"
+	"os"
+	"time"
+
 	"github.com/spf13/cobra"
 
+	"github.com/NielsdaWheelz/agency/internal/commands"
 	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/watch"
 )
 
 func newWatchCmd() *cobra.Command {
+	var repoPath string
+	var all bool
+	var allRepos bool
+	var interval time.Duration
+	var jsonOutput bool
+	var once bool
+
 	cmd := &cobra.Command{
 		Use:   "watch",
-		Short: "Interactive TUI for monitoring worktrees and agents",
-		Long: `Interactive TUI for monitoring worktrees and agents.
+		Short: "Interactive TUI for monitoring worktrees and runs",
+		Long: `Interactive TUI for monitoring worktrees and runs.
+
+Watch shows a live hierarchical view of:
+  - integration worktrees and their agent invocations
+  - runs, with derived status, runner summary and stall duration
 
-Watch provides a hierarchical live view of:
-  - Integration worktrees
-  - Agent invocations per worktree
+The detail pane shows the selected run's runner_status questions, blockers
+and risks. Watch refreshes by polling meta files and tmux; it never writes
+state itself. Actions run the matching agency command:
 
-Actions include attach, view logs, land/discard, stop/kill, and more.
+  enter  attach          o  open in editor    S  shell
+  s      show            x  stop              K  kill (confirms)
+  p      push (confirms) v  verify            r  refresh
+  esc    close output    q  quit
 
-This command will be implemented in a future release.`,
+Scope follows ls: the current repo, or all repos when outside a git repo.
+
+With --json, watch prints no TUI. It writes a snapshot line with every run in
+scope, then one JSON line per transition (status_changed, runner_status_updated,
+stalled, pr_created, verify_finished, archived, run_added) until interrupted.
+--once prints the snapshot line and exits.`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_ = cmd.Help()
-			return errors.New(errors.EUsage, "watch is not yet implemented")
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get working directory", err)
+			}
+			if interval <= 0 {
+				return errors.New(errors.EUsage, "--interval must be positive")
+			}
+
+			return commands.Watch(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), cwd, commands.WatchOpts{
+				RepoPath: repoPath,
+				AllRepos: allRepos,
+				All:      all,
+				Interval: interval,
+				JSON:     jsonOutput,
+				Once:     once,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
 		},
 	}
 
+	cmd.Flags().StringVar(&repoPath, "repo", "", "scope to a specific repo")
+	cmd.Flags().BoolVar(&all, "all", false, "include archived runs and worktrees")
+	cmd.Flags().BoolVar(&allRepos, "all-repos", false, "show all repos (ignores current repo scope)")
+	cmd.Flags().DurationVar(&interval, "interval", watch.DefaultInterval, "refresh interval")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "stream run state transitions as JSON lines")
+	cmd.Flags().BoolVar(&once, "once", false, "with --json, print the snapshot line and exit")
+
 	return cmd
 }
@@ -28,11 +28,12 @@ Subcommands:
   path      Output worktree path for scripting
   open      Open worktree in editor
   shell     Open shell in worktree
-  rm        Remove a worktree`,
+  rm        Remove a worktree
+  land      Land a run's work into a worktree`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			_ = cmd.Help()
-			return errors.New(errors.EUsage, "specify a subcommand: agency worktree <create|ls|show|path|open|shell|rm>")
+			return errors.New(errors.EUsage, "specify a subcommand: agency worktree <create|ls|show|path|open|shell|rm|land>")
 		},
 	}
 
@@ -44,6 +45,7 @@ Subcommands:
 		newWorktreeOpenCmd(),
 		newWorktreeShellCmd(),
 		newWorktreeRmCmd(),
+		newWorktreeLandCmd(),
 	)
 
 	return cmd
@@ -311,3 +313,55 @@ Example:
 
 	return cmd
 }
+
+func newWorktreeLandCmd() *cobra.Command {
+	var apply bool
+	var requireBase bool
+
+	cmd := &cobra.Command{
+		Use:   "land <worktree> <run>",
+		Short: "Land a run's work into a worktree",
+		Long: `Land a run's work into an integration worktree.
+
+By default, cherry-picks the run's commits (since its merge-base with the
+parent branch) onto the worktree HEAD. With --apply, applies the run's full
+working-tree diff (committed, uncommitted, and untracked changes) as a single
+commit with a generated message.
+
+On conflict the landing is aborted, the worktree is left unchanged, and a
+conflict card with manual steps is printed.
+
+With --require-base, fails unless the worktree HEAD already contains the
+run's base commit.
+
+The landing is recorded in both the worktree and run meta.json.
+
+Example:
+  agency worktree land my-feature fix-login
+  agency worktree land my-feature fix-login --apply
+  agency worktree land my-feature fix-login --require-base`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return errors.Wrap(errors.EInternal, "failed to get cwd", err)
+			}
+
+			cr := exec.NewRealRunner()
+			fsys := fs.NewRealFS()
+			ctx := context.Background()
+
+			return commands.WorktreeLand(ctx, cr, fsys, cwd, commands.WorktreeLandOpts{
+				WorktreeRef: args[0],
+				RunRef:      args[1],
+				Apply:       apply,
+				RequireBase: requireBase,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().BoolVar(&apply, "apply", false, "Apply the run's working-tree diff as one commit instead of cherry-picking")
+	cmd.Flags().BoolVar(&requireBase, "require-base", false, "Fail unless the worktree HEAD contains the run's base commit")
+
+	return cmd
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/sessionlog"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// runActivity loads the normalized session log activity for a tmux (headed) run.
+// When write is true the run's logs/stream.jsonl is refreshed; read-only
+// commands pass false. The session log is looked up by the recorded
+// runner_session_id; a newly discovered session is recorded in meta.json when
+// st is non-nil, so the session log roots are only scanned until the runner's
+// session is known. Returns nil for headless runs, missing worktrees, or if
+// no session log is found. Errors are swallowed: activity is best-effort.
+func runActivity(st *store.Store, rec store.RunRecord, write bool) *sessionlog.Activity {
+	meta := rec.Meta
+	if meta == nil || meta.Headless != nil || rec.RunDir == "" || !dirExists(meta.WorktreePath) {
+		return nil
+	}
+
+	sources, ok := runSessionSources()
+	if !ok {
+		return nil
+	}
+
+	streamPath := filepath.Join(rec.RunDir, "logs", "stream.jsonl")
+	load := sessionlog.Load
+	if write {
+		load = sessionlog.Sync
+	}
+	act, err := load(sources, meta.Runner, meta.WorktreePath, meta.RunnerSessionID, runSessionSince(meta), streamPath)
+	if err != nil || act == nil {
+		return nil
+	}
+	if st != nil {
+		recordRunnerSession(st, &rec, act.Session)
+	}
+	return act
+}
+
+// findRunnerSession locates the session log the runner wrote for the run's
+// worktree, by the recorded runner_session_id if set. Returns nil if none is
+// found. Best-effort like runActivity.
+func findRunnerSession(meta *store.RunMeta) *sessionlog.Session {
+	sources, ok := runSessionSources()
+	if !ok {
+		return nil
+	}
+	sess, err := sources.Locate(meta.Runner, meta.WorktreePath, meta.RunnerSessionID, runSessionSince(meta))
+	if err != nil {
+		return nil
+	}
+	return sess
+}
+
+// recordRunnerSession stores a discovered runner session id in meta.json if it
+// changed, updating rec.Meta in place. Best-effort: errors are ignored.
+func recordRunnerSession(st *store.Store, rec *store.RunRecord, sess *sessionlog.Session) {
+	if rec.Meta == nil || sess == nil || sess.ID == "" || sess.ID == rec.Meta.RunnerSessionID {
+		return
+	}
+	err := st.UpdateMeta(rec.RepoID, rec.RunID, func(m *store.RunMeta) {
+		m.RunnerSessionID = sess.ID
+	})
+	if err == nil {
+		rec.Meta.RunnerSessionID = sess.ID
+	}
+}
+
+// runSessionSources returns the runner session log roots for the current user.
+func runSessionSources() (sessionlog.Sources, bool) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return sessionlog.Sources{}, false
+	}
+	return sessionlog.DefaultSources(osEnv{}, homeDir), true
+}
+
+// runSessionSince returns when the run's runner last started (its creation
+// time if not recorded): only session logs touched since then belong to the
+// run. Zero if unparseable.
+func runSessionSince(meta *store.RunMeta) time.Time {
+	started := meta.RunnerStartedAt
+	if started == "" {
+		started = meta.CreatedAt
+	}
+	t, err := time.Parse(time.RFC3339, started)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
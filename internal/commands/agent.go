@@ -0,0 +1,468 @@
+// Package commands implements agency CLI commands.
+// This file implements agent invocation commands (Slice 8 PR-02/PR-03).
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/integrationworktree"
+	"github.com/NielsdaWheelz/agency/internal/invocation"
+	"github.com/NielsdaWheelz/agency/internal/lock"
+	"github.com/NielsdaWheelz/agency/internal/paths"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+// AgentStartOpts holds options for the agent start command.
+type AgentStartOpts struct {
+	// Worktree is the integration worktree reference (name, id, or prefix).
+	Worktree string
+
+	// Name is an optional display label for the invocation.
+	Name string
+
+	// Runner is the runner name (default: user config defaults.runner).
+	Runner string
+
+	// RepoPath is the optional --repo flag.
+	RepoPath string
+
+	// Detached means do not attach after starting the tmux session.
+	Detached bool
+}
+
+// AgentStart creates a sandbox for an integration worktree and starts a headed runner in it.
+func AgentStart(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, opts AgentStartOpts, stdout, stderr io.Writer) error {
+	tmuxClient := tmux.NewExecClient(cr)
+	return AgentStartWithTmux(ctx, cr, fsys, tmuxClient, cwd, opts, stdout, stderr)
+}
+
+// AgentStartWithTmux starts an agent invocation using the provided tmux client.
+// This variant is used for testing with a fake tmux client.
+func AgentStartWithTmux(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, tmuxClient tmux.Client, cwd string, opts AgentStartOpts, stdout, stderr io.Writer) error {
+	if opts.Worktree == "" {
+		return errors.New(errors.EUsage, "--worktree is required")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dirs := paths.ResolveDirs(osEnv{}, homeDir)
+
+	repoRoot, repoID, err := ResolveRepoContext(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+
+	st := store.NewStore(fsys, dirs.DataDir, time.Now)
+	wtSvc := integrationworktree.NewService(st, cr, fsys, time.Now)
+	invSvc := invocation.NewService(st, cr, fsys, time.Now)
+
+	// Resolve integration worktree (non-archived only)
+	record, err := wtSvc.Resolve(repoID, opts.Worktree, false)
+	if err != nil {
+		return err
+	}
+	if record.Broken || record.Meta == nil {
+		return errors.NewWithDetails(
+			errors.EWorktreeBroken,
+			"worktree exists but meta.json is unreadable or invalid",
+			map[string]string{"worktree_id": record.WorktreeID},
+		)
+	}
+
+	// Resolve runner command
+	userCfg, _, err := config.LoadUserConfig(fsys, dirs.ConfigDir)
+	if err != nil {
+		return err
+	}
+	runnerName := opts.Runner
+	if runnerName == "" {
+		runnerName = userCfg.Defaults.Runner
+	}
+	runnerCmd, err := config.ResolveRunnerCmd(cr, fsys, dirs.ConfigDir, userCfg, runnerName)
+	if err != nil {
+		return err
+	}
+
+	createOpts := invocation.CreateOpts{
+		RepoRoot:  repoRoot,
+		RepoID:    repoID,
+		Worktree:  record.Meta,
+		Name:      opts.Name,
+		Runner:    runnerName,
+		RunnerCmd: runnerCmd,
+		Mode:      store.InvocationModeHeaded,
+	}
+
+	// Pre-lock checks: integration marker + sandbox path safety
+	invocationID, err := invSvc.Prepare(createOpts)
+	if err != nil {
+		return err
+	}
+
+	// Create sandbox under the repo lock (git worktree add + marker + meta only)
+	meta, err := createSandboxLocked(ctx, invSvc, dirs.DataDir, repoID, invocationID, createOpts)
+	if err != nil {
+		return err
+	}
+
+	// Start runner in tmux (outside the lock)
+	if err := invSvc.StartHeaded(ctx, tmuxClient, meta); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Started agent invocation %s\n", meta.InvocationID)
+	_, _ = fmt.Fprintf(stdout, "  worktree: %s (%s)\n", record.Meta.Name, record.WorktreeID)
+	_, _ = fmt.Fprintf(stdout, "  sandbox:  %s\n", meta.SandboxPath)
+	_, _ = fmt.Fprintf(stdout, "  branch:   %s\n", meta.SandboxBranch)
+	_, _ = fmt.Fprintf(stdout, "  base:     %s\n", meta.BaseCommit)
+	_, _ = fmt.Fprintf(stdout, "  tmux:     %s\n", meta.TmuxSession)
+
+	if opts.Detached {
+		_, _ = fmt.Fprintf(stdout, "attach: tmux attach -t %s\n", meta.TmuxSession)
+		return nil
+	}
+
+	return attachToTmuxSession(meta.TmuxSession, stdout, stderr)
+}
+
+// createSandboxLocked acquires the repo lock only for sandbox creation.
+func createSandboxLocked(ctx context.Context, invSvc *invocation.Service, dataDir, repoID, invocationID string, opts invocation.CreateOpts) (*store.InvocationMeta, error) {
+	repoLock := lock.NewRepoLock(dataDir)
+	unlock, err := repoLock.Lock(repoID, "agent start")
+	if err != nil {
+		if _, ok := err.(*lock.ErrLocked); ok {
+			return nil, errors.New(errors.ERepoLocked, err.Error())
+		}
+		return nil, errors.Wrap(errors.EInternal, "failed to acquire repo lock", err)
+	}
+	defer func() { _ = unlock() }()
+
+	return invSvc.Create(ctx, invocationID, opts)
+}
+
+// AgentLSOpts holds options for the agent ls command.
+type AgentLSOpts struct {
+	// RepoPath is the optional --repo flag.
+	RepoPath string
+
+	// Worktree filters to a single integration worktree (name, id, or prefix).
+	Worktree string
+
+	// All includes broken invocations.
+	All bool
+
+	// JSON enables JSON output.
+	JSON bool
+}
+
+// AgentLS lists agent invocations grouped by integration worktree.
+func AgentLS(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, opts AgentLSOpts, stdout, stderr io.Writer) error {
+	tmuxClient := tmux.NewExecClient(cr)
+	return AgentLSWithTmux(ctx, cr, fsys, tmuxClient, cwd, opts, stdout, stderr)
+}
+
+// AgentLSWithTmux lists agent invocations using the provided tmux client for reconciliation.
+func AgentLSWithTmux(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, tmuxClient tmux.Client, cwd string, opts AgentLSOpts, stdout, stderr io.Writer) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dirs := paths.ResolveDirs(osEnv{}, homeDir)
+
+	_, repoID, err := ResolveRepoContext(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+
+	st := store.NewStore(fsys, dirs.DataDir, time.Now)
+	invSvc := invocation.NewService(st, cr, fsys, time.Now)
+
+	worktrees, err := store.ScanIntegrationWorktreesForRepo(dirs.DataDir, repoID)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to scan integration worktrees", err)
+	}
+
+	filterWorktreeID := ""
+	if opts.Worktree != "" {
+		wtSvc := integrationworktree.NewService(st, cr, fsys, time.Now)
+		record, err := wtSvc.Resolve(repoID, opts.Worktree, true)
+		if err != nil {
+			return err
+		}
+		filterWorktreeID = record.WorktreeID
+	}
+
+	records, err := store.ScanInvocationsForRepo(dirs.DataDir, repoID)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to scan invocations", err)
+	}
+
+	var filtered []store.InvocationRecord
+	for _, r := range records {
+		if r.Broken && !opts.All {
+			continue
+		}
+		if filterWorktreeID != "" && (r.Meta == nil || r.Meta.IntegrationWorktreeID != filterWorktreeID) {
+			continue
+		}
+		if !r.Broken {
+			r.Meta, _ = invSvc.Reconcile(ctx, tmuxClient, r.Meta)
+		}
+		filtered = append(filtered, r)
+	}
+
+	groups := groupInvocationsByWorktree(filtered, worktrees)
+
+	if opts.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(groups)
+	}
+
+	return writeAgentLSHuman(stdout, groups)
+}
+
+// agentWorktreeGroupJSON is the JSON shape for invocations grouped by worktree.
+type agentWorktreeGroupJSON struct {
+	WorktreeID   string                `json:"worktree_id"`
+	WorktreeName string                `json:"worktree_name,omitempty"`
+	Invocations  []agentInvocationJSON `json:"invocations"`
+}
+
+// agentInvocationJSON is the JSON shape for a single invocation.
+type agentInvocationJSON struct {
+	InvocationID          string `json:"invocation_id"`
+	InvocationName        string `json:"invocation_name,omitempty"`
+	IntegrationWorktreeID string `json:"integration_worktree_id,omitempty"`
+	WorktreeName          string `json:"worktree_name,omitempty"`
+	Status                string `json:"status,omitempty"`
+	Mode                  string `json:"mode,omitempty"`
+	Runner                string `json:"runner,omitempty"`
+	SandboxPath           string `json:"sandbox_path,omitempty"`
+	SandboxBranch         string `json:"sandbox_branch,omitempty"`
+	BaseCommit            string `json:"base_commit,omitempty"`
+	TmuxSession           string `json:"tmux_session,omitempty"`
+	StartedAt             string `json:"started_at,omitempty"`
+	FinishedAt            string `json:"finished_at,omitempty"`
+	ExitReason            string `json:"exit_reason,omitempty"`
+	InvocationDir         string `json:"invocation_dir,omitempty"`
+	Broken                bool   `json:"broken,omitempty"`
+}
+
+func invocationToJSON(r store.InvocationRecord, worktreeName string) agentInvocationJSON {
+	out := agentInvocationJSON{
+		InvocationID:  r.InvocationID,
+		WorktreeName:  worktreeName,
+		InvocationDir: r.InvocationDir,
+		Broken:        r.Broken,
+	}
+	if m := r.Meta; m != nil {
+		out.InvocationName = m.InvocationName
+		out.IntegrationWorktreeID = m.IntegrationWorktreeID
+		out.Status = string(m.Status)
+		out.Mode = string(m.Mode)
+		out.Runner = m.Runner
+		out.SandboxPath = m.SandboxPath
+		out.SandboxBranch = m.SandboxBranch
+		out.BaseCommit = m.BaseCommit
+		out.TmuxSession = m.TmuxSession
+		out.StartedAt = m.StartedAt
+		out.FinishedAt = m.FinishedAt
+		out.ExitReason = m.ExitReason
+	}
+	return out
+}
+
+// groupInvocationsByWorktree groups invocation records by integration worktree,
+// in worktree scan order. Invocations without readable meta are grouped under
+// an empty worktree_id, last.
+func groupInvocationsByWorktree(records []store.InvocationRecord, worktrees []store.IntegrationWorktreeRecord) []agentWorktreeGroupJSON {
+	names := make(map[string]string, len(worktrees))
+	for _, wt := range worktrees {
+		names[wt.WorktreeID] = wt.Name
+	}
+
+	byWorktree := make(map[string][]agentInvocationJSON)
+	var order []string
+	for _, r := range records {
+		wtID := ""
+		if r.Meta != nil {
+			wtID = r.Meta.IntegrationWorktreeID
+		}
+		if _, ok := byWorktree[wtID]; !ok {
+			order = append(order, wtID)
+		}
+		byWorktree[wtID] = append(byWorktree[wtID], invocationToJSON(r, names[wtID]))
+	}
+
+	// Order groups by worktree scan order; unknown worktrees after, unreadable last
+	rank := make(map[string]int, len(worktrees))
+	for i, wt := range worktrees {
+		rank[wt.WorktreeID] = i
+	}
+	groupRank := func(id string) int {
+		if id == "" {
+			return len(worktrees) + 1
+		}
+		if r, ok := rank[id]; ok {
+			return r
+		}
+		return len(worktrees)
+	}
+
+	groups := make([]agentWorktreeGroupJSON, 0, len(order))
+	for _, id := range order {
+		groups = append(groups, agentWorktreeGroupJSON{
+			WorktreeID:   id,
+			WorktreeName: names[id],
+			Invocations:  byWorktree[id],
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groupRank(groups[i].WorktreeID) < groupRank(groups[j].WorktreeID)
+	})
+	return groups
+}
+
+func writeAgentLSHuman(w io.Writer, groups []agentWorktreeGroupJSON) error {
+	if len(groups) == 0 {
+		_, _ = fmt.Fprintln(w, "No agent invocations found.")
+		return nil
+	}
+
+	for i, g := range groups {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		switch {
+		case g.WorktreeID == "":
+			_, _ = fmt.Fprintln(w, "(unknown worktree)")
+		case g.WorktreeName == "":
+			_, _ = fmt.Fprintf(w, "%s\n", g.WorktreeID)
+		default:
+			_, _ = fmt.Fprintf(w, "%s (%s)\n", g.WorktreeName, g.WorktreeID)
+		}
+		for _, inv := range g.Invocations {
+			if inv.Broken {
+				_, _ = fmt.Fprintf(w, "  %s  [broken]\n", inv.InvocationID)
+				continue
+			}
+			name := ""
+			if inv.InvocationName != "" {
+				name = "  " + inv.InvocationName
+			}
+			_, _ = fmt.Fprintf(w, "  %s  %-8s  %s%s\n", inv.InvocationID, inv.Status, inv.Runner, name)
+		}
+	}
+
+	return nil
+}
+
+// AgentShowOpts holds options for the agent show command.
+type AgentShowOpts struct {
+	// InvocationRef is the invocation id or unique prefix.
+	InvocationRef string
+
+	// RepoPath is the optional --repo flag.
+	RepoPath string
+
+	// JSON enables JSON output.
+	JSON bool
+}
+
+// AgentShow shows details of an agent invocation.
+func AgentShow(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, opts AgentShowOpts, stdout, stderr io.Writer) error {
+	tmuxClient := tmux.NewExecClient(cr)
+	return AgentShowWithTmux(ctx, cr, fsys, tmuxClient, cwd, opts, stdout, stderr)
+}
+
+// AgentShowWithTmux shows an agent invocation using the provided tmux client for reconciliation.
+func AgentShowWithTmux(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, tmuxClient tmux.Client, cwd string, opts AgentShowOpts, stdout, stderr io.Writer) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dirs := paths.ResolveDirs(osEnv{}, homeDir)
+
+	_, repoID, err := ResolveRepoContext(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+
+	st := store.NewStore(fsys, dirs.DataDir, time.Now)
+	invSvc := invocation.NewService(st, cr, fsys, time.Now)
+
+	record, err := invSvc.Resolve(repoID, opts.InvocationRef)
+	if err != nil {
+		return err
+	}
+
+	if record.Meta == nil {
+		return errors.NewWithDetails(
+			errors.EInvocationBroken,
+			"invocation exists but meta.json is missing, unreadable, or invalid",
+			map[string]string{
+				"invocation_id":  record.InvocationID,
+				"invocation_dir": record.InvocationDir,
+				"hint":           "inspect or remove the directory manually",
+			},
+		)
+	}
+
+	if !record.Broken {
+		record.Meta, _ = invSvc.Reconcile(ctx, tmuxClient, record.Meta)
+	}
+
+	worktreeName := ""
+	if wt, err := st.ReadIntegrationWorktreeMeta(repoID, record.Meta.IntegrationWorktreeID); err == nil {
+		worktreeName = wt.Name
+	}
+
+	out := invocationToJSON(*record, worktreeName)
+
+	if opts.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	_, _ = fmt.Fprintf(stdout, "invocation_id:   %s\n", out.InvocationID)
+	if out.InvocationName != "" {
+		_, _ = fmt.Fprintf(stdout, "name:            %s\n", out.InvocationName)
+	}
+	if worktreeName != "" {
+		_, _ = fmt.Fprintf(stdout, "worktree:        %s (%s)\n", worktreeName, out.IntegrationWorktreeID)
+	} else {
+		_, _ = fmt.Fprintf(stdout, "worktree:        %s\n", out.IntegrationWorktreeID)
+	}
+	_, _ = fmt.Fprintf(stdout, "status:          %s\n", out.Status)
+	_, _ = fmt.Fprintf(stdout, "runner:          %s (%s)\n", out.Runner, out.Mode)
+	_, _ = fmt.Fprintf(stdout, "sandbox_branch:  %s\n", out.SandboxBranch)
+	_, _ = fmt.Fprintf(stdout, "base_commit:     %s\n", out.BaseCommit)
+	_, _ = fmt.Fprintf(stdout, "sandbox_path:    %s\n", out.SandboxPath)
+	if out.TmuxSession != "" {
+		_, _ = fmt.Fprintf(stdout, "tmux:            %s\n", out.TmuxSession)
+	}
+	_, _ = fmt.Fprintf(stdout, "started_at:      %s\n", out.StartedAt)
+	if out.FinishedAt != "" {
+		_, _ = fmt.Fprintf(stdout, "finished_at:     %s (%s)\n", out.FinishedAt, out.ExitReason)
+	}
+	if record.Broken {
+		_, _ = fmt.Fprintln(stdout, "warning: sandbox tree is missing")
+	}
+
+	return nil
+}
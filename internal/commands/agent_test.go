@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/invocation"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+// setupAgentTestEnv creates a git repo with one integration worktree named "feature"
+// and a user config with a "fake" runner. Returns the repo dir.
+func setupAgentTestEnv(t *testing.T) string {
+	t.Helper()
+	testutil.HermeticGitEnv(t)
+
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	if result, err := cr.Run(ctx, "git", []string{"--version"}, exec.RunOpts{}); err != nil || result.ExitCode != 0 {
+		t.Skip("git not available")
+	}
+
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, ".gitignore"), []byte(".agency/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"add", "."},
+		{"commit", "-m", "Initial commit"},
+	} {
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: repoDir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+
+	t.Setenv("AGENCY_DATA_DIR", t.TempDir())
+	configDir := t.TempDir()
+	t.Setenv("AGENCY_CONFIG_DIR", configDir)
+	cfg := `{"version": 1, "defaults": {"runner": "fake", "editor": "code"}, "runners": {"fake": "/bin/true"}}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := WorktreeCreate(ctx, cr, fs.NewRealFS(), repoDir, WorktreeCreateOpts{Name: "feature", ParentBranch: "main"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("WorktreeCreate() error = %v", err)
+	}
+
+	return repoDir
+}
+
+func TestAgentStartLSShow(t *testing.T) {
+	repoDir := setupAgentTestEnv(t)
+	cr := exec.NewRealRunner()
+	fsys := fs.NewRealFS()
+	ctx := context.Background()
+	tmuxClient := &fakeTmuxClient{hasSessionResult: true}
+
+	// Start two invocations against the same integration worktree
+	for i := 0; i < 2; i++ {
+		var stdout, stderr bytes.Buffer
+		err := AgentStartWithTmux(ctx, cr, fsys, tmuxClient, repoDir, AgentStartOpts{
+			Worktree: "feature",
+			Name:     "arch-agent",
+			Detached: true,
+		}, &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("AgentStartWithTmux() error = %v", err)
+		}
+	}
+
+	// ls --json groups by worktree
+	var stdout, stderr bytes.Buffer
+	if err := AgentLSWithTmux(ctx, cr, fsys, tmuxClient, repoDir, AgentLSOpts{JSON: true}, &stdout, &stderr); err != nil {
+		t.Fatalf("AgentLSWithTmux() error = %v", err)
+	}
+
+	var groups []agentWorktreeGroupJSON
+	if err := json.Unmarshal(stdout.Bytes(), &groups); err != nil {
+		t.Fatalf("failed to parse ls json: %v\n%s", err, stdout.String())
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	if groups[0].WorktreeName != "feature" {
+		t.Errorf("WorktreeName = %v, want feature", groups[0].WorktreeName)
+	}
+	if len(groups[0].Invocations) != 2 {
+		t.Fatalf("got %d invocations, want 2", len(groups[0].Invocations))
+	}
+
+	inv := groups[0].Invocations[0]
+	if inv.Status != "running" {
+		t.Errorf("Status = %v, want running", inv.Status)
+	}
+	if !invocation.HasSandboxMarker(inv.SandboxPath) {
+		t.Error("sandbox marker missing")
+	}
+
+	// show --json by unique id
+	stdout.Reset()
+	if err := AgentShowWithTmux(ctx, cr, fsys, tmuxClient, repoDir, AgentShowOpts{InvocationRef: inv.InvocationID, JSON: true}, &stdout, &stderr); err != nil {
+		t.Fatalf("AgentShowWithTmux() error = %v", err)
+	}
+	var shown agentInvocationJSON
+	if err := json.Unmarshal(stdout.Bytes(), &shown); err != nil {
+		t.Fatalf("failed to parse show json: %v", err)
+	}
+	if shown.InvocationID != inv.InvocationID || shown.WorktreeName != "feature" || shown.BaseCommit == "" {
+		t.Errorf("show = %+v", shown)
+	}
+
+	// Session gone: show reconciles to finished
+	tmuxClient.hasSessionResult = false
+	stdout.Reset()
+	if err := AgentShowWithTmux(ctx, cr, fsys, tmuxClient, repoDir, AgentShowOpts{InvocationRef: inv.InvocationID, JSON: true}, &stdout, &stderr); err != nil {
+		t.Fatalf("AgentShowWithTmux() error = %v", err)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &shown); err != nil {
+		t.Fatalf("failed to parse show json: %v", err)
+	}
+	if shown.Status != "finished" || shown.ExitReason != "exited" {
+		t.Errorf("after session loss: status=%v exit_reason=%v", shown.Status, shown.ExitReason)
+	}
+}
+
+func TestAgentStart_RequiresWorktree(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := AgentStartWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, t.TempDir(), AgentStartOpts{}, &stdout, &stderr)
+	if errors.GetCode(err) != errors.EUsage {
+		t.Errorf("error = %v, want %s", err, errors.EUsage)
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/lifecycle"
 	"github.com/NielsdaWheelz/agency/internal/runservice"
+	"github.com/NielsdaWheelz/agency/internal/store"
 	"github.com/NielsdaWheelz/agency/internal/tmux"
 )
 
@@ -64,6 +66,11 @@ func AttachWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS
 		return errors.Wrap(errors.ETmuxNotInstalled, "failed to check tmux session", err)
 	}
 	if !exists {
+		// Record the runner exit (best-effort, idempotent)
+		if resolved.Record != nil {
+			reconcileRunnerExit(store.NewStore(fsys, rctx.DataDir, nil), resolved.Record, nil)
+		}
+
 		// Session doesn't exist (was killed, system restarted, etc.)
 		// Return E_SESSION_NOT_FOUND with suggestion to use resume
 		return errors.NewWithDetails(
@@ -77,6 +84,12 @@ func AttachWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS
 		)
 	}
 
+	// The runner may have exited, leaving a shell in the worktree
+	if resolved.Record != nil && lifecycle.RunnerInShell(resolved.Record.Meta, exists) {
+		_, _ = fmt.Fprintf(stderr, "note: runner exited (%s); attaching to the shell in the worktree\n", lifecycle.FormatExitStatus(resolved.Record.Meta))
+		_, _ = fmt.Fprintf(stderr, "relaunch the runner with: agency resume %s\n", opts.RunID)
+	}
+
 	// Attach to the tmux session
 	// We need to use exec.Command directly for interactive attach (bypass tmuxClient)
 	return attachToTmuxSession(sessionName, stdout, stderr)
@@ -45,6 +45,10 @@ func (f *attachFakeTmuxClient) SendKeys(ctx context.Context, name string, keys [
 	return nil
 }
 
+func (f *attachFakeTmuxClient) SendText(ctx context.Context, name, text string) error {
+	return nil
+}
+
 // setupAttachTestEnv creates a temporary test environment for attach tests.
 func setupAttachTestEnv(t *testing.T, runID string, setupMeta bool) (string, string, string, *fakeCommandRunner, fs.FS) {
 	t.Helper()
@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/headless"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+// NeedsAttentionReasonBudgetExceeded is the reason set when a runner is
+// interrupted for exceeding its max_duration or max_stall budget.
+const NeedsAttentionReasonBudgetExceeded = "budget_exceeded"
+
+// NeedsAttentionReasonBudgetUnenforced is the reason set when a run has
+// budgets but the run monitor that enforces them could not start.
+const NeedsAttentionReasonBudgetUnenforced = "budget_unenforced"
+
+// Budget names recorded in meta.budget.exceeded and budget_exceeded events.
+const (
+	budgetMaxDuration = "max_duration"
+	budgetMaxStall    = "max_stall"
+)
+
+// budgetCheckInterval is how often the run monitor checks a run's budgets.
+var budgetCheckInterval = 30 * time.Second
+
+// validateBudgetFlags checks --max-duration and --max-stall (0 = unset).
+func validateBudgetFlags(maxDuration, maxStall time.Duration) error {
+	if maxDuration != 0 && maxDuration < config.MinTimeout {
+		return errors.New(errors.EUsage, "--max-duration must be at least 1m")
+	}
+	if maxStall != 0 && maxStall < config.MinTimeout {
+		return errors.New(errors.EUsage, "--max-stall must be at least 1m")
+	}
+	return nil
+}
+
+// budgetOpts returns a queued run's budgets as pipeline overrides.
+func budgetOpts(meta *store.RunMeta) (maxDuration, maxStall time.Duration) {
+	if meta.Budget == nil {
+		return 0, 0
+	}
+	maxDuration, _ = time.ParseDuration(meta.Budget.MaxDuration)
+	maxStall, _ = time.ParseDuration(meta.Budget.MaxStall)
+	return maxDuration, maxStall
+}
+
+// runStartedAt returns when the run's runner started: the headless start
+// time or runner_started_at if recorded, else the run's creation time.
+func runStartedAt(meta *store.RunMeta) time.Time {
+	started := meta.RunnerStartedAt
+	if meta.Headless != nil {
+		started = meta.Headless.StartedAt
+	}
+	if t, err := time.Parse(time.RFC3339, started); err == nil {
+		return t
+	}
+	t, _ := time.Parse(time.RFC3339, meta.CreatedAt)
+	return t
+}
+
+// lastRunActivity returns the run's latest activity for the max_stall check:
+// runner_status.json mtime, session log activity, headless output, or the
+// runner start (so a runner that never acts still stalls).
+func lastRunActivity(st *store.Store, meta *store.RunMeta) time.Time {
+	last := runStartedAt(meta)
+	if _, modTime, err := runnerstatus.LoadWithModTime(meta.WorktreePath); err == nil && modTime.After(last) {
+		last = modTime
+	}
+	rec := store.RunRecord{RepoID: meta.RepoID, RunID: meta.RunID, Name: meta.Name, Meta: meta, RunDir: st.RunDir(meta.RepoID, meta.RunID)}
+	if act := runActivity(st, rec, false); act != nil && act.LastActivityAt.After(last) {
+		last = act.LastActivityAt
+	}
+	if t, err := time.Parse(time.RFC3339, store.ReadLastOutputAt(st.RunLastOutputPath(meta.RepoID, meta.RunID))); err == nil && t.After(last) {
+		last = t
+	}
+	return last
+}
+
+// exceededBudget returns the budget the run exceeds at now ("" = none),
+// with its limit and the observed duration (elapsed or stalled).
+func exceededBudget(meta *store.RunMeta, lastActivity, now time.Time) (string, time.Duration, time.Duration) {
+	b := meta.Budget
+	if b == nil {
+		return "", 0, 0
+	}
+	if limit, err := time.ParseDuration(b.MaxDuration); err == nil && limit > 0 {
+		if started := runStartedAt(meta); !started.IsZero() {
+			if elapsed := now.Sub(started); elapsed >= limit {
+				return budgetMaxDuration, limit, elapsed
+			}
+		}
+	}
+	if limit, err := time.ParseDuration(b.MaxStall); err == nil && limit > 0 && !lastActivity.IsZero() {
+		if stalled := now.Sub(lastActivity); stalled >= limit {
+			return budgetMaxStall, limit, stalled
+		}
+	}
+	return "", 0, 0
+}
+
+// enforceBudget interrupts the run's runner once it exceeds a budget, like
+// agency stop: C-c to the tmux session (SIGINT to the process group for
+// headless runs), then needs_attention with reason budget_exceeded and a
+// budget_exceeded event. Each run is interrupted at most once. Returns true
+// if the runner was interrupted.
+func enforceBudget(ctx context.Context, tmuxClient tmux.Client, st *store.Store, repoID, runID string, now time.Time, log io.Writer) bool {
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil || meta.Budget == nil || meta.Budget.ExceededAt != "" || meta.Archive != nil || meta.RunnerExitedAt != "" {
+		return false
+	}
+	budget, limit, observed := exceededBudget(meta, lastRunActivity(st, meta), now)
+	if budget == "" {
+		return false
+	}
+
+	if meta.Headless != nil {
+		signaled, err := headless.SignalRunner(meta, syscall.SIGINT)
+		if err != nil {
+			_, _ = fmt.Fprintf(log, "budget: %s exceeded but interrupting the runner failed: %v\n", budget, err)
+			return false
+		}
+		if !signaled {
+			return false
+		}
+	} else {
+		if meta.TmuxSessionName == "" {
+			return false
+		}
+		if err := tmuxClient.SendKeys(ctx, meta.TmuxSessionName, []tmux.Key{tmux.KeyCtrlC}); err != nil {
+			_, _ = fmt.Fprintf(log, "budget: %s exceeded but sending C-c failed: %v\n", budget, err)
+			return false
+		}
+	}
+
+	exceededAt := now.UTC().Format(time.RFC3339)
+	_ = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		if m.Flags == nil {
+			m.Flags = &store.RunMetaFlags{}
+		}
+		m.Flags.NeedsAttention = true
+		m.Flags.NeedsAttentionReason = NeedsAttentionReasonBudgetExceeded
+		if m.Budget != nil {
+			m.Budget.ExceededAt = exceededAt
+			m.Budget.Exceeded = budget
+		}
+	})
+	_ = events.AppendEvent(st.EventsPath(repoID, runID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     exceededAt,
+		RepoID:        repoID,
+		RunID:         runID,
+		Event:         "budget_exceeded",
+		Data: map[string]any{
+			"budget":   budget,
+			"limit":    limit.String(),
+			"observed": observed.Round(time.Second).String(),
+		},
+	})
+	_, _ = fmt.Fprintf(log, "budget: %s %s exceeded (%s); interrupted the runner\n", budget, limit, observed.Round(time.Second))
+	return true
+}
+
+// recordBudgetEnforcement records whether a run's budgets are enforced after
+// an attempt to start its monitor (monitorErr is the failure, nil on success). Unenforced budgets are recorded in meta.budget.unenforced, flag
+// the run needs_attention with reason budget_unenforced and append a
+// budget_unenforced event; a later successful start clears them.
+func recordBudgetEnforcement(st *store.Store, repoID, runID string, monitorErr error) {
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil || meta.Budget == nil {
+		return
+	}
+
+	if monitorErr == nil {
+		if meta.Budget.Unenforced == "" {
+			return
+		}
+		_ = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+			if m.Budget != nil {
+				m.Budget.Unenforced = ""
+			}
+			if m.Flags != nil && m.Flags.NeedsAttentionReason == NeedsAttentionReasonBudgetUnenforced {
+				m.Flags.NeedsAttention = false
+				m.Flags.NeedsAttentionReason = ""
+			}
+		})
+		return
+	}
+
+	reason := "run monitor not started: " + monitorErr.Error()
+	_ = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		if m.Budget != nil {
+			m.Budget.Unenforced = reason
+		}
+		if m.Flags == nil {
+			m.Flags = &store.RunMetaFlags{}
+		}
+		m.Flags.NeedsAttention = true
+		m.Flags.NeedsAttentionReason = NeedsAttentionReasonBudgetUnenforced
+	})
+	_ = events.AppendEvent(st.EventsPath(repoID, runID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		RepoID:        repoID,
+		RunID:         runID,
+		Event:         "budget_unenforced",
+		Data:          map[string]any{"reason": reason},
+	})
+}
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/monitor"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+func TestExceededBudget(t *testing.T) {
+	created := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	meta := func(maxDuration, maxStall string) *store.RunMeta {
+		return &store.RunMeta{
+			CreatedAt: created.Format(time.RFC3339),
+			Budget:    &store.RunMetaBudget{MaxDuration: maxDuration, MaxStall: maxStall},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		meta         *store.RunMeta
+		lastActivity time.Time
+		now          time.Time
+		want         string
+		wantObserved time.Duration
+	}{
+		{"no budget", &store.RunMeta{CreatedAt: created.Format(time.RFC3339)}, created, created.Add(10 * time.Hour), "", 0},
+		{"within duration", meta("2h0m0s", ""), created, created.Add(time.Hour), "", 0},
+		{"duration exceeded", meta("2h0m0s", ""), created, created.Add(2 * time.Hour), budgetMaxDuration, 2 * time.Hour},
+		{"active run not stalled", meta("", "45m0s"), created.Add(time.Hour), created.Add(90 * time.Minute), "", 0},
+		{"stalled", meta("", "45m0s"), created.Add(time.Hour), created.Add(2 * time.Hour), budgetMaxStall, time.Hour},
+		{"duration wins over stall", meta("2h0m0s", "45m0s"), created, created.Add(3 * time.Hour), budgetMaxDuration, 3 * time.Hour},
+		{"duration counts from the runner start", func() *store.RunMeta {
+			m := meta("2h0m0s", "")
+			m.RunnerStartedAt = created.Add(30 * time.Minute).Format(time.RFC3339)
+			return m
+		}(), created.Add(30 * time.Minute), created.Add(2 * time.Hour), "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, observed := exceededBudget(tt.meta, tt.lastActivity, tt.now)
+			if got != tt.want || observed != tt.wantObserved {
+				t.Errorf("exceededBudget() = %q, %v; want %q, %v", got, observed, tt.want, tt.wantObserved)
+			}
+		})
+	}
+}
+
+func TestValidateBudgetFlags(t *testing.T) {
+	if err := validateBudgetFlags(0, 0); err != nil {
+		t.Errorf("unset flags error = %v", err)
+	}
+	if err := validateBudgetFlags(2*time.Hour, 45*time.Minute); err != nil {
+		t.Errorf("valid flags error = %v", err)
+	}
+	if err := validateBudgetFlags(30*time.Second, 0); errors.GetCode(err) != errors.EUsage || !strings.Contains(err.Error(), "--max-duration") {
+		t.Errorf("short --max-duration error = %v, want E_USAGE", err)
+	}
+	if err := validateBudgetFlags(0, -time.Minute); errors.GetCode(err) != errors.EUsage || !strings.Contains(err.Error(), "--max-stall") {
+		t.Errorf("negative --max-stall error = %v, want E_USAGE", err)
+	}
+}
+
+func TestEnforceBudget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	st := store.NewStore(fs.NewRealFS(), t.TempDir(), time.Now)
+	repoID, runID := "abcd1234ef567890", "20260110120000-a3f2"
+	if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+		t.Fatal(err)
+	}
+	created := time.Now().Add(-2 * time.Hour)
+	meta := store.NewRunMeta(runID, repoID, "auth-fix", "claude", "claude", "main", "agency/auth-fix-a3f2", t.TempDir(), created)
+	meta.TmuxSessionName = "agency_" + runID
+	meta.Budget = &store.RunMetaBudget{MaxStall: "45m0s"}
+	if err := st.WriteInitialMeta(repoID, runID, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeTmuxClient{}
+	var log bytes.Buffer
+	if !enforceBudget(context.Background(), fake, st, repoID, runID, time.Now(), &log) {
+		t.Fatalf("enforceBudget() = false, want true; log: %s", log.String())
+	}
+	if len(fake.sendKeysCalls) != 1 || fake.sendKeysCalls[0].Name != meta.TmuxSessionName ||
+		len(fake.sendKeysCalls[0].Keys) != 1 || fake.sendKeysCalls[0].Keys[0] != tmux.KeyCtrlC {
+		t.Errorf("send-keys calls = %+v, want one C-c", fake.sendKeysCalls)
+	}
+
+	got, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Flags == nil || !got.Flags.NeedsAttention || got.Flags.NeedsAttentionReason != NeedsAttentionReasonBudgetExceeded {
+		t.Errorf("flags = %+v, want needs_attention budget_exceeded", got.Flags)
+	}
+	if got.Budget.Exceeded != budgetMaxStall || got.Budget.ExceededAt == "" {
+		t.Errorf("budget = %+v", got.Budget)
+	}
+	data, err := os.ReadFile(st.EventsPath(repoID, runID))
+	if err != nil || !strings.Contains(string(data), `"event":"budget_exceeded"`) || !strings.Contains(string(data), `"budget":"max_stall"`) {
+		t.Errorf("expected budget_exceeded event, got %s (err = %v)", data, err)
+	}
+
+	// A run is interrupted only once
+	if enforceBudget(context.Background(), fake, st, repoID, runID, time.Now(), &log) {
+		t.Error("second enforceBudget() = true, want false")
+	}
+	if len(fake.sendKeysCalls) != 1 {
+		t.Errorf("send-keys calls = %d, want 1", len(fake.sendKeysCalls))
+	}
+}
+
+func TestEnforceBudget_RunnerExited(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	st := store.NewStore(fs.NewRealFS(), t.TempDir(), time.Now)
+	repoID, runID := "abcd1234ef567890", "20260110120000-a3f2"
+	if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+		t.Fatal(err)
+	}
+	meta := store.NewRunMeta(runID, repoID, "auth-fix", "claude", "claude", "main", "agency/auth-fix-a3f2", t.TempDir(), time.Now().Add(-3*time.Hour))
+	meta.TmuxSessionName = "agency_" + runID
+	meta.RunnerExitedAt = time.Now().UTC().Format(time.RFC3339)
+	meta.Budget = &store.RunMetaBudget{MaxDuration: "2h0m0s"}
+	if err := st.WriteInitialMeta(repoID, runID, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeTmuxClient{}
+	var log bytes.Buffer
+	if enforceBudget(context.Background(), fake, st, repoID, runID, time.Now(), &log) {
+		t.Error("enforceBudget() = true for an exited runner")
+	}
+	if len(fake.sendKeysCalls) != 0 {
+		t.Errorf("send-keys calls = %+v, want none (the session is a worktree shell)", fake.sendKeysCalls)
+	}
+}
+
+func TestStartRunMonitor_BudgetUnenforced(t *testing.T) {
+	st, repoID := setupDaemonTest(t)
+	runID := "20260110120000-a3f2"
+	writeDaemonTestRun(t, st, repoID, runID, "auth-fix")
+	if err := st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.Budget = &store.RunMetaBudget{MaxDuration: "2h0m0s"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(orig func(monitor.SpawnOpts) (int, error)) { spawnRunMonitor = orig }(spawnRunMonitor)
+	spawnRunMonitor = func(monitor.SpawnOpts) (int, error) { return 0, stderrors.New("exec format error") }
+
+	err := startRunMonitor(st, repoID, runID)
+	if err == nil || !strings.Contains(err.Error(), "budgets are not enforced") {
+		t.Fatalf("startRunMonitor() error = %v, want budgets-not-enforced error", err)
+	}
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Budget.Unenforced == "" || meta.Flags == nil || !meta.Flags.NeedsAttention || meta.Flags.NeedsAttentionReason != NeedsAttentionReasonBudgetUnenforced {
+		t.Errorf("budget = %+v, flags = %+v; want unenforced and needs_attention budget_unenforced", meta.Budget, meta.Flags)
+	}
+	if evs := readTestEvents(t, st, repoID, runID, "budget_unenforced"); len(evs) != 1 {
+		t.Errorf("got %d budget_unenforced events, want 1", len(evs))
+	}
+
+	// A later successful start (e.g. resume) clears it
+	spawnRunMonitor = func(monitor.SpawnOpts) (int, error) { return 0, nil }
+	if err := startRunMonitor(st, repoID, runID); err != nil {
+		t.Fatalf("startRunMonitor() error = %v", err)
+	}
+	meta, err = st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Budget.Unenforced != "" || meta.Flags.NeedsAttention {
+		t.Errorf("budget = %+v, flags = %+v; want cleared", meta.Budget, meta.Flags)
+	}
+}
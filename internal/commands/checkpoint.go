@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/checkpoint"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// spawnCheckpointWatcher starts the detached watcher (stubbed in tests).
+var spawnCheckpointWatcher = checkpoint.Spawn
+
+// startRunWatchers starts the run's checkpoint watcher and its monitor.
+// Best-effort: a failure to start either is returned as a warning for the
+// caller to print, and never fails the run.
+func startRunWatchers(st *store.Store, repoID, runID string) []string {
+	var warnings []string
+	if err := startCheckpointWatcher(st, repoID, runID); err != nil {
+		warnings = append(warnings, "checkpoint watcher not started: "+err.Error())
+	}
+	if err := startRunMonitor(st, repoID, runID); err != nil {
+		warnings = append(warnings, "run monitor not started: "+err.Error())
+	}
+	return warnings
+}
+
+// startCheckpointWatcher spawns the run's checkpoint watcher unless one is
+// already alive, and records its pid in meta.json.
+func startCheckpointWatcher(st *store.Store, repoID, runID string) error {
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		return err
+	}
+	if headless.ProcessAlive(meta.CheckpointWatcherPID) {
+		return nil
+	}
+	pid, err := spawnCheckpointWatcher(checkpoint.SpawnOpts{
+		DataDir: st.DataDir,
+		RepoID:  repoID,
+		RunID:   runID,
+		LogPath: st.RunCheckpointLogPath(repoID, runID),
+	})
+	if err != nil {
+		return err
+	}
+	return st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.CheckpointWatcherPID = pid
+	})
+}
+
+// CheckpointWatchOpts holds options for the hidden checkpoint watcher command.
+type CheckpointWatchOpts struct {
+	// DataDir is the resolved AGENCY_DATA_DIR of the spawning process.
+	DataDir string
+
+	// RepoID and RunID identify the run to watch.
+	RepoID string
+	RunID  string
+}
+
+// CheckpointWatch snapshots a run's worktree until its runner is gone. It is
+// spawned detached by run and resume; output goes to logs/checkpoint.log.
+func CheckpointWatch(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, opts CheckpointWatchOpts, stdout io.Writer) error {
+	if opts.DataDir == "" || opts.RepoID == "" || opts.RunID == "" {
+		return errors.New(errors.EUsage, "--data-dir, --repo-id and --run-id are required")
+	}
+
+	st := store.NewStore(fsys, opts.DataDir, time.Now)
+	meta, err := st.ReadMeta(opts.RepoID, opts.RunID)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	self := os.Getpid()
+	engine := checkpoint.NewEngine(cr, st, meta)
+	return engine.Watch(ctx, checkpoint.WatchOpts{
+		Alive: func() bool {
+			meta, err := st.ReadMeta(opts.RepoID, opts.RunID)
+			if err != nil || (meta.CheckpointWatcherPID != 0 && meta.CheckpointWatcherPID != self) {
+				return false
+			}
+			return runnerAlive(ctx, cr, meta)
+		},
+		Log: stdout,
+	})
+}
+
+// runnerAlive reports whether the run's watchers should keep going: the run
+// is not archived, its worktree exists, and its runner (tmux or headless) has
+// not exited. A tmux session that lives on as a shell after the runner exited
+// does not count.
+func runnerAlive(ctx context.Context, cr agencyexec.CommandRunner, meta *store.RunMeta) bool {
+	if meta.Archive != nil {
+		return false
+	}
+	if info, err := os.Stat(meta.WorktreePath); err != nil || !info.IsDir() {
+		return false
+	}
+	if meta.Headless != nil {
+		return headless.IsActive(meta)
+	}
+	if meta.TmuxSessionName == "" || meta.RunnerExitedAt != "" {
+		return false
+	}
+	result, err := cr.Run(ctx, "tmux", []string{"has-session", "-t", meta.TmuxSessionName}, agencyexec.RunOpts{})
+	return err == nil && result.ExitCode == 0
+}
+
+// CheckpointLSOpts holds options for the checkpoint ls command.
+type CheckpointLSOpts struct {
+	// RunID is the run reference (name, run_id, or unique prefix).
+	RunID string
+
+	// RepoPath is the optional --repo flag to scope name resolution.
+	RepoPath string
+
+	// JSON enables JSON output.
+	JSON bool
+}
+
+// checkpointLSJSONEnvelope is the stable JSON output format for checkpoint ls --json.
+type checkpointLSJSONEnvelope struct {
+	SchemaVersion string              `json:"schema_version"`
+	Data          []checkpoint.Record `json:"data"`
+}
+
+// CheckpointLS lists a run's worktree checkpoints, oldest first.
+func CheckpointLS(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts CheckpointLSOpts, stdout, stderr io.Writer) error {
+	st, meta, err := resolveCheckpointRun(ctx, cr, fsys, cwd, opts.RunID, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	records, err := checkpoint.List(st, meta.RepoID, meta.RunID)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(checkpointLSJSONEnvelope{SchemaVersion: "1.0", Data: records})
+	}
+
+	if len(records) == 0 {
+		_, _ = fmt.Fprintln(stdout, "No checkpoints.")
+		return nil
+	}
+	_, _ = fmt.Fprintf(stdout, "%-4s  %-20s  %-9s  %5s  %s\n", "N", "CREATED", "TRIGGER", "FILES", "COMMIT")
+	for _, r := range records {
+		_, _ = fmt.Fprintf(stdout, "%-4d  %-20s  %-9s  %5d  %s\n", r.N, r.CreatedAt, r.Trigger, r.FilesChanged, shortSHA(r.Commit))
+	}
+	return nil
+}
+
+// CheckpointApplyOpts holds options for the checkpoint apply command.
+type CheckpointApplyOpts struct {
+	// RunID is the run reference (name, run_id, or unique prefix).
+	RunID string
+
+	// RepoPath is the optional --repo flag to scope name resolution.
+	RepoPath string
+
+	// N is the checkpoint number to restore.
+	N int
+}
+
+// CheckpointApply restores a run's worktree files to checkpoint N.
+func CheckpointApply(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts CheckpointApplyOpts, stdout, stderr io.Writer) error {
+	if opts.N <= 0 {
+		return errors.New(errors.EUsage, "checkpoint number must be a positive integer")
+	}
+	st, meta, err := resolveCheckpointRun(ctx, cr, fsys, cwd, opts.RunID, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	if info, statErr := os.Stat(meta.WorktreePath); statErr != nil || !info.IsDir() {
+		return errors.NewWithDetails(
+			errors.EWorktreeMissing,
+			"worktree path missing on disk (run may be archived)",
+			map[string]string{"worktree_path": meta.WorktreePath},
+		)
+	}
+
+	res, err := checkpoint.NewEngine(cr, st, meta).Apply(ctx, opts.N)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Restored worktree to checkpoint %d (%s)\n", res.Target.N, shortSHA(res.Target.Commit))
+	if len(res.Removed) > 0 {
+		_, _ = fmt.Fprintf(stdout, "removed %d file(s) not in the checkpoint\n", len(res.Removed))
+	}
+	if res.Safety != nil {
+		_, _ = fmt.Fprintf(stdout, "previous state saved as checkpoint %d; undo with: agency checkpoint apply %s %d\n", res.Safety.N, opts.RunID, res.Safety.N)
+	}
+	return nil
+}
+
+// resolveCheckpointRun resolves a run reference to its store and meta.
+func resolveCheckpointRun(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd, ref, repoPath string) (*store.Store, *store.RunMeta, error) {
+	if ref == "" {
+		return nil, nil, errors.New(errors.EUsage, "run_id is required")
+	}
+	rctx, err := ResolveRunContext(ctx, cr, cwd, repoPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, err := ResolveRun(rctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resolved.Broken || resolved.Record == nil || resolved.Record.Meta == nil {
+		return nil, nil, errors.NewWithDetails(
+			errors.ERunBroken,
+			"run exists but meta.json is unreadable or invalid",
+			map[string]string{"run_id": resolved.RunID, "repo_id": resolved.RepoID},
+		)
+	}
+	return store.NewStore(fsys, rctx.DataDir, time.Now), resolved.Record.Meta, nil
+}
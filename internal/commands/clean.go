@@ -37,6 +37,10 @@ type CleanOpts struct {
 	// DeleteBranch deletes the local and remote branch after archiving.
 	// Also closes any associated PR.
 	DeleteBranch bool
+
+	// ConfirmFromStdin reads the typed confirmation from stdin without
+	// requiring a TTY (agency serve passes the client's confirmation).
+	ConfirmFromStdin bool
 }
 
 // Clean archives a run without merging.
@@ -56,7 +60,7 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 	}
 
 	// Check for interactive TTY (stdin and stderr must be TTYs)
-	if !isInteractive() {
+	if !opts.ConfirmFromStdin && !isInteractive() {
 		return errors.New(errors.ENotInteractive, "clean requires an interactive terminal; stdin and stderr must be TTYs")
 	}
 
@@ -148,13 +152,39 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 	eventsPath := st.EventsPath(repoID, opts.RunID)
 
 	// Dirty worktree gate
+	if err := cleanDirtyGate(ctx, cr, eventsPath, repoID, opts.RunID, worktreePath, opts.AllowDirty, stderr); err != nil {
+		return err
+	}
+
+	// Print lock acquisition message (per spec)
+	_, _ = fmt.Fprintln(stderr, "lock: acquired repo lock (held during clean/archive)")
+
+	// Prompt for confirmation
+	_, _ = fmt.Fprint(stderr, "confirm: type 'clean' to proceed: ")
+	reader := bufio.NewReader(stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return errors.Wrap(errors.EAborted, "failed to read confirmation", err)
+	}
+
+	if strings.TrimSpace(input) != "clean" {
+		return errors.New(errors.EAborted, "confirmation failed; expected 'clean'")
+	}
+
+	return runCleanPipeline(ctx, cr, fsys, tmuxClient, st, dataDir, repoRoot, meta, opts.DeleteBranch, stdout, stderr)
+}
+
+// cleanDirtyGate fails with E_DIRTY_WORKTREE if the worktree has uncommitted
+// changes, unless allowDirty is set (then it warns). Records clean_failed or
+// dirty_allowed events.
+func cleanDirtyGate(ctx context.Context, cr agencyexec.CommandRunner, eventsPath, repoID, runID, worktreePath string, allowDirty bool, stderr io.Writer) error {
 	isClean, status, err := getDirtyStatus(ctx, cr, worktreePath)
 	if err != nil {
 		_ = events.AppendEvent(eventsPath, events.Event{
 			SchemaVersion: "1.0",
 			Timestamp:     time.Now().UTC().Format(time.RFC3339),
 			RepoID:        repoID,
-			RunID:         opts.RunID,
+			RunID:         runID,
 			Event:         "clean_failed",
 			Data: map[string]any{
 				"error_code": string(errors.GetCode(err)),
@@ -164,12 +194,12 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 		return err
 	}
 	if !isClean {
-		if !opts.AllowDirty {
+		if !allowDirty {
 			_ = events.AppendEvent(eventsPath, events.Event{
 				SchemaVersion: "1.0",
 				Timestamp:     time.Now().UTC().Format(time.RFC3339),
 				RepoID:        repoID,
-				RunID:         opts.RunID,
+				RunID:         runID,
 				Event:         "clean_failed",
 				Data: map[string]any{
 					"error_code": string(errors.EDirtyWorktree),
@@ -182,7 +212,7 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 			SchemaVersion: "1.0",
 			Timestamp:     time.Now().UTC().Format(time.RFC3339),
 			RepoID:        repoID,
-			RunID:         opts.RunID,
+			RunID:         runID,
 			Event:         "dirty_allowed",
 			Data: map[string]any{
 				"cmd":    "clean",
@@ -191,21 +221,17 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 		})
 		printDirtyWarning(stderr, status)
 	}
+	return nil
+}
 
-	// Print lock acquisition message (per spec)
-	_, _ = fmt.Fprintln(stderr, "lock: acquired repo lock (held during clean/archive)")
-
-	// Prompt for confirmation
-	_, _ = fmt.Fprint(stderr, "confirm: type 'clean' to proceed: ")
-	reader := bufio.NewReader(stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return errors.Wrap(errors.EAborted, "failed to read confirmation", err)
-	}
-
-	if strings.TrimSpace(input) != "clean" {
-		return errors.New(errors.EAborted, "confirmation failed; expected 'clean'")
-	}
+// runCleanPipeline archives a run as abandoned: runs the archive script, kills
+// the tmux session, removes the worktree, marks meta.json abandoned/archived,
+// and optionally deletes the branch and closes the PR. The caller holds the
+// repo lock and has already confirmed.
+func runCleanPipeline(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, tmuxClient tmux.Client, st *store.Store, dataDir, repoRoot string, meta *store.RunMeta, deleteBranch bool, stdout, stderr io.Writer) error {
+	repoID, runID := meta.RepoID, meta.RunID
+	eventsPath := st.EventsPath(repoID, runID)
+	worktreePath := meta.WorktreePath
 
 	// Append clean_started event
 	now := time.Now().UTC()
@@ -213,9 +239,9 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 		SchemaVersion: "1.0",
 		Timestamp:     now.Format(time.RFC3339),
 		RepoID:        repoID,
-		RunID:         opts.RunID,
+		RunID:         runID,
 		Event:         "clean_started",
-		Data:          events.CleanStartedData(opts.RunID),
+		Data:          events.CleanStartedData(runID),
 	})
 
 	// Append archive_started event
@@ -223,9 +249,9 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 		SchemaVersion: "1.0",
 		Timestamp:     time.Now().UTC().Format(time.RFC3339),
 		RepoID:        repoID,
-		RunID:         opts.RunID,
+		RunID:         runID,
 		Event:         "archive_started",
-		Data:          events.ArchiveStartedData(opts.RunID),
+		Data:          events.ArchiveStartedData(runID),
 	})
 
 	// Load agency.json to get archive script
@@ -260,7 +286,7 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 			SchemaVersion: "1.0",
 			Timestamp:     time.Now().UTC().Format(time.RFC3339),
 			RepoID:        repoID,
-			RunID:         opts.RunID,
+			RunID:         runID,
 			Event:         "archive_finished",
 			Data:          events.ArchiveFinishedData(true),
 		})
@@ -269,7 +295,7 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 			SchemaVersion: "1.0",
 			Timestamp:     time.Now().UTC().Format(time.RFC3339),
 			RepoID:        repoID,
-			RunID:         opts.RunID,
+			RunID:         runID,
 			Event:         "archive_failed",
 			Data:          events.ArchiveFailedData(result.ScriptOK, result.TmuxOK, result.DeleteOK, result.ScriptReason, result.TmuxReason, result.DeleteReason),
 		})
@@ -277,7 +303,7 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 
 	// Update meta on success
 	if result.Success() {
-		updateErr := st.UpdateMeta(repoID, opts.RunID, func(m *store.RunMeta) {
+		updateErr := st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
 			if m.Flags == nil {
 				m.Flags = &store.RunMetaFlags{}
 			}
@@ -295,7 +321,7 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 
 	// Handle --delete-branch after successful archive
 	var branchResult *branchDeletionResult
-	if opts.DeleteBranch && result.Success() {
+	if deleteBranch && result.Success() {
 		branchResult = deleteBranchAndClosePR(ctx, cr, meta, repoRoot, eventsPath, repoID, stderr)
 	}
 
@@ -311,7 +337,7 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 		SchemaVersion: "1.0",
 		Timestamp:     time.Now().UTC().Format(time.RFC3339),
 		RepoID:        repoID,
-		RunID:         opts.RunID,
+		RunID:         runID,
 		Event:         "clean_finished",
 		Data:          cleanFinishedData,
 	})
@@ -322,7 +348,7 @@ func CleanWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 	}
 
 	// Print success message (informational output to user)
-	_, _ = fmt.Fprintf(stdout, "cleaned: %s\n", opts.RunID)
+	_, _ = fmt.Fprintf(stdout, "cleaned: %s\n", runID)
 	if result.LogPath != "" {
 		_, _ = fmt.Fprintf(stdout, "log: %s\n", result.LogPath)
 	}
@@ -0,0 +1,342 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/daemon"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
+	"github.com/NielsdaWheelz/agency/internal/paths"
+	"github.com/NielsdaWheelz/agency/internal/status"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// spawnDaemon starts a detached daemon (stubbed in tests).
+var spawnDaemon = daemon.Spawn
+
+// daemonWaitTimeout bounds how long start and stop wait for the daemon.
+var daemonWaitTimeout = 5 * time.Second
+
+// resolveDataDir returns the resolved AGENCY_DATA_DIR.
+func resolveDataDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	return paths.ResolveDirs(osEnv{}, homeDir).DataDir, nil
+}
+
+// DaemonRunOpts holds options for the daemon run command.
+type DaemonRunOpts struct {
+	// Interval is the poll interval (0 = daemon.DefaultInterval).
+	Interval time.Duration
+}
+
+// daemonState tracks the running daemon's health for the socket.
+type daemonState struct {
+	mu     sync.Mutex
+	health daemon.Health
+}
+
+func (s *daemonState) get() daemon.Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.health
+}
+
+func (s *daemonState) recordPoll(stats daemonPollStats, err error, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Polls++
+	s.health.LastPollAt = now.UTC().Format(time.RFC3339)
+	s.health.LastPollError = ""
+	if err != nil {
+		s.health.LastPollError = err.Error()
+		return
+	}
+	s.health.Repos = stats.Repos
+	s.health.Runs = stats.Runs
+	s.health.Active = stats.Active
+	s.health.Stalled = stats.Stalled
+}
+
+// DaemonRun runs the daemon in the foreground until interrupted: it polls
+// every repo in repo_index.json each interval and serves its health on
+// daemon.sock. Spawned detached by daemon start; usable directly under a
+// service manager.
+func DaemonRun(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, opts DaemonRunOpts, stdout, stderr io.Writer) error {
+	interval := opts.Interval
+	if interval == 0 {
+		interval = daemon.DefaultInterval
+	}
+	if interval < time.Second {
+		return errors.New(errors.EUsage, "--interval must be at least 1s")
+	}
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+
+	release, err := daemon.Acquire(dataDir)
+	if err != nil {
+		return err
+	}
+	defer release()
+	ln, err := daemon.Listen(dataDir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(daemon.SocketPath(dataDir)) }()
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	state := &daemonState{health: daemon.Health{
+		SchemaVersion: "1.0",
+		PID:           os.Getpid(),
+		StartedAt:     time.Now().UTC().Format(time.RFC3339),
+		Interval:      interval.String(),
+	}}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- daemon.Serve(ctx, ln, state.get) }()
+
+	_, _ = fmt.Fprintf(stdout, "%s daemon: started (pid %d, interval %s)\n", time.Now().UTC().Format(time.RFC3339), os.Getpid(), interval)
+	st := store.NewStore(fsys, dataDir, time.Now)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stats, err := daemonPoll(ctx, cr, fsys, st)
+		state.recordPoll(stats, err, time.Now())
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s daemon: poll failed: %v\n", time.Now().UTC().Format(time.RFC3339), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			_, _ = fmt.Fprintf(stdout, "%s daemon: stopped\n", time.Now().UTC().Format(time.RFC3339))
+			return nil
+		case err := <-serveErr:
+			if err != nil {
+				return errors.Wrap(errors.EDaemonFailed, "daemon socket failed", err)
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// daemonPollStats counts what one poll saw.
+type daemonPollStats struct {
+	Repos   int
+	Runs    int
+	Active  int
+	Stalled int
+}
+
+// daemonPoll reconciles every run of the repos in repo_index.json once, doing
+// what agency ls does on demand: runner exits are recorded in meta.json,
+// status (with watchdog stall detection) is derived, notify hooks fire on new
+// transitions, and the queue is kicked if a slot freed up.
+func daemonPoll(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, st *store.Store) (daemonPollStats, error) {
+	var stats daemonPollStats
+	idx, err := st.LoadRepoIndex()
+	if err != nil {
+		return stats, err
+	}
+	seen := make(map[string]bool, len(idx.Repos))
+	repoIDs := make([]string, 0, len(idx.Repos))
+	for _, entry := range idx.Repos {
+		if entry.RepoID != "" && !seen[entry.RepoID] {
+			seen[entry.RepoID] = true
+			repoIDs = append(repoIDs, entry.RepoID)
+		}
+	}
+	sort.Strings(repoIDs)
+	stats.Repos = len(repoIDs)
+
+	// Re-read every poll so config edits apply without a restart
+	userCfg, cfgErr := loadUserConfig(fsys)
+	tmuxSessions, tmuxOK := listTmuxSessions(ctx, cr)
+
+	for _, repoID := range repoIDs {
+		records, err := store.ScanRunsForRepo(st.DataDir, repoID)
+		if err != nil {
+			continue
+		}
+		for _, rec := range records {
+			if rec.Meta != nil && rec.Meta.Archive != nil {
+				continue
+			}
+			stats.Runs++
+			if tmuxOK {
+				reconcileRunnerExit(st, &rec, tmuxSessions)
+			}
+			observeRunnerStatus(st, rec, time.Now())
+			summary := recordToSummary(st, rec, tmuxSessions, fsys)
+			if cfgErr == nil {
+				notifyTransition(ctx, cr, st, userCfg.Notify, rec, summary)
+			}
+			if runHoldsSlot(rec.Meta, tmuxSessions) {
+				stats.Active++
+			}
+			if summary.DerivedStatus == status.StatusStalled {
+				stats.Stalled++
+			}
+		}
+	}
+
+	kickQueue(ctx, cr, fsys, st.DataDir)
+	return stats, nil
+}
+
+// DaemonStartOpts holds options for the daemon start command.
+type DaemonStartOpts struct {
+	// Interval is the poll interval passed to daemon run (0 = default).
+	Interval time.Duration
+}
+
+// DaemonStart spawns the daemon detached unless one is already running, and
+// waits for its socket to answer.
+func DaemonStart(ctx context.Context, opts DaemonStartOpts, stdout, stderr io.Writer) error {
+	if opts.Interval != 0 && opts.Interval < time.Second {
+		return errors.New(errors.EUsage, "--interval must be at least 1s")
+	}
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+	if pid := daemon.Running(dataDir); pid != 0 {
+		_, _ = fmt.Fprintf(stdout, "daemon already running (pid %d)\n", pid)
+		return nil
+	}
+
+	pid, err := spawnDaemon(dataDir, opts.Interval)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(daemonWaitTimeout)
+	for {
+		if _, err := daemon.QueryHealth(ctx, daemon.SocketPath(dataDir)); err == nil {
+			break
+		}
+		if !headless.ProcessAlive(pid) || time.Now().After(deadline) {
+			return errors.NewWithDetails(errors.EDaemonFailed, "daemon did not start",
+				map[string]string{"pid": fmt.Sprintf("%d", pid), "log_path": daemon.LogPath(dataDir)})
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	_, _ = fmt.Fprintf(stdout, "daemon started (pid %d)\n", pid)
+	_, _ = fmt.Fprintf(stdout, "log: %s\n", daemon.LogPath(dataDir))
+	return nil
+}
+
+// DaemonStop sends SIGTERM to the running daemon and waits for it to exit.
+// Stopping a daemon that is not running is not an error.
+func DaemonStop(ctx context.Context, stdout, stderr io.Writer) error {
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+	// Only a pid whose daemon holds the pidfile lock is signaled: a crashed
+	// daemon's pid may have been reused by an unrelated process
+	pid := daemon.Running(dataDir)
+	if pid == 0 {
+		// Clear files left by a daemon that died without cleaning up
+		daemon.RemoveStale(dataDir)
+		_, _ = fmt.Fprintln(stdout, "daemon not running")
+		return nil
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return errors.WrapWithDetails(errors.EDaemonFailed, "failed to signal daemon", err,
+			map[string]string{"pid": fmt.Sprintf("%d", pid)})
+	}
+	deadline := time.Now().Add(daemonWaitTimeout)
+	for daemon.Running(dataDir) != 0 {
+		if time.Now().After(deadline) {
+			return errors.NewWithDetails(errors.EDaemonFailed, "daemon did not stop",
+				map[string]string{"pid": fmt.Sprintf("%d", pid)})
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	_, _ = fmt.Fprintf(stdout, "daemon stopped (pid %d)\n", pid)
+	return nil
+}
+
+// DaemonStatusOpts holds options for the daemon status command.
+type DaemonStatusOpts struct {
+	// JSON enables JSON output.
+	JSON bool
+}
+
+// DaemonStatusResult is the data payload of daemon status --json.
+type DaemonStatusResult struct {
+	Running bool           `json:"running"`
+	PID     int            `json:"pid,omitempty"`
+	Socket  string         `json:"socket,omitempty"`
+	Health  *daemon.Health `json:"health,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// daemonStatusJSONEnvelope is the stable JSON output format for daemon status --json.
+type daemonStatusJSONEnvelope struct {
+	SchemaVersion string             `json:"schema_version"`
+	Data          DaemonStatusResult `json:"data"`
+}
+
+// DaemonStatus reports whether the daemon is running and its health.
+func DaemonStatus(ctx context.Context, opts DaemonStatusOpts, stdout, stderr io.Writer) error {
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+	var res DaemonStatusResult
+	if pid := daemon.Running(dataDir); pid != 0 {
+		res.Running = true
+		res.PID = pid
+		res.Socket = daemon.SocketPath(dataDir)
+		if h, err := daemon.QueryHealth(ctx, res.Socket); err == nil {
+			res.Health = h
+		} else {
+			res.Error = "socket not answering: " + err.Error()
+		}
+	}
+
+	if opts.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(daemonStatusJSONEnvelope{SchemaVersion: "1.0", Data: res})
+	}
+
+	if !res.Running {
+		_, _ = fmt.Fprintln(stdout, "daemon: not running")
+		return nil
+	}
+	_, _ = fmt.Fprintf(stdout, "daemon: running (pid %d)\n", res.PID)
+	_, _ = fmt.Fprintf(stdout, "socket: %s\n", res.Socket)
+	if res.Health == nil {
+		_, _ = fmt.Fprintf(stdout, "health: %s\n", res.Error)
+		return nil
+	}
+	h := res.Health
+	_, _ = fmt.Fprintf(stdout, "started: %s\n", h.StartedAt)
+	_, _ = fmt.Fprintf(stdout, "interval: %s\n", h.Interval)
+	if h.LastPollAt != "" {
+		_, _ = fmt.Fprintf(stdout, "last poll: %s (%d polls)\n", h.LastPollAt, h.Polls)
+	}
+	if h.LastPollError != "" {
+		_, _ = fmt.Fprintf(stdout, "last poll error: %s\n", h.LastPollError)
+	}
+	_, _ = fmt.Fprintf(stdout, "runs: %d in %d repos (%d active, %d stalled)\n", h.Runs, h.Repos, h.Active, h.Stalled)
+	return nil
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	osexec "os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/daemon"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// setupDaemonTest creates a data dir with one indexed repo and returns its store.
+func setupDaemonTest(t *testing.T) (*store.Store, string) {
+	t.Helper()
+	dataDir, err := os.MkdirTemp("", "agd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dataDir) })
+	t.Setenv("AGENCY_DATA_DIR", dataDir)
+	t.Setenv("AGENCY_CONFIG_DIR", t.TempDir())
+
+	st := store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+	repoID := "repo123456789012"
+	idx, err := st.LoadRepoIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SaveRepoIndex(st.UpsertRepoIndexEntry(idx, "path:/tmp/repo", repoID, "/tmp/repo")); err != nil {
+		t.Fatal(err)
+	}
+	return st, repoID
+}
+
+// writeDaemonTestRun writes a tmux run with a worktree in the repo.
+func writeDaemonTestRun(t *testing.T, st *store.Store, repoID, runID, name string) {
+	t.Helper()
+	if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+		t.Fatal(err)
+	}
+	meta := store.NewRunMeta(runID, repoID, name, "claude", "claude", "main", "agency/"+name, t.TempDir(), time.Now().Add(-time.Hour))
+	meta.TmuxSessionName = "agency_" + runID
+	if err := st.WriteInitialMeta(repoID, runID, meta); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDaemonPoll(t *testing.T) {
+	st, repoID := setupDaemonTest(t)
+	writeDaemonTestRun(t, st, repoID, "20260110120000-a3f2", "alive")
+	writeDaemonTestRun(t, st, repoID, "20260110120000-b4c3", "exited")
+
+	cr := &fakeCommandRunner{responses: map[string]fakeResponse{
+		"tmux list-sessions -F #{session_name}": {stdout: "agency_20260110120000-a3f2\n"},
+	}}
+	stats, err := daemonPoll(context.Background(), cr, fs.NewRealFS(), st)
+	if err != nil {
+		t.Fatalf("daemonPoll() error = %v", err)
+	}
+	if stats.Repos != 1 || stats.Runs != 2 || stats.Active != 1 {
+		t.Errorf("stats = %+v, want 1 repo, 2 runs, 1 active", stats)
+	}
+
+	// The run whose session is gone has its exit recorded
+	exited, err := st.ReadMeta(repoID, "20260110120000-b4c3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exited.RunnerExitedAt == "" {
+		t.Error("runner_exited_at not recorded for the run without a session")
+	}
+	alive, err := st.ReadMeta(repoID, "20260110120000-a3f2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alive.RunnerExitedAt != "" {
+		t.Error("runner_exited_at recorded for a live session")
+	}
+}
+
+func TestDaemonRun(t *testing.T) {
+	st, _ := setupDaemonTest(t)
+	cr := &fakeCommandRunner{responses: map[string]fakeResponse{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	var stdout, stderr bytes.Buffer
+	go func() {
+		done <- DaemonRun(ctx, cr, fs.NewRealFS(), DaemonRunOpts{Interval: time.Second}, &stdout, &stderr)
+	}()
+
+	var h *daemon.Health
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var err error
+		if h, err = daemon.QueryHealth(context.Background(), daemon.SocketPath(st.DataDir)); err == nil && h.Polls > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("daemon socket not answering: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if h.PID != os.Getpid() || h.Repos != 1 || h.Interval != "1s" {
+		t.Errorf("health = %+v", h)
+	}
+
+	// A second daemon refuses to start
+	if err := DaemonRun(context.Background(), cr, fs.NewRealFS(), DaemonRunOpts{}, &bytes.Buffer{}, &bytes.Buffer{}); errors.GetCode(err) != errors.EDaemonRunning {
+		t.Errorf("second DaemonRun() error = %v, want E_DAEMON_RUNNING", err)
+	}
+
+	var status bytes.Buffer
+	if err := DaemonStatus(context.Background(), DaemonStatusOpts{}, &status, &bytes.Buffer{}); err != nil {
+		t.Fatalf("DaemonStatus() error = %v", err)
+	}
+	if !strings.Contains(status.String(), "daemon: running") || !strings.Contains(status.String(), "runs: 0 in 1 repos") {
+		t.Errorf("status output:\n%s", status.String())
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("DaemonRun() error = %v", err)
+	}
+	for _, path := range []string{daemon.PIDPath(st.DataDir), daemon.SocketPath(st.DataDir)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s not removed on shutdown", path)
+		}
+	}
+}
+
+func TestDaemonStatus_NotRunning(t *testing.T) {
+	setupDaemonTest(t)
+	var stdout bytes.Buffer
+	if err := DaemonStatus(context.Background(), DaemonStatusOpts{JSON: true}, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("DaemonStatus() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"running": false`) {
+		t.Errorf("status --json = %s", stdout.String())
+	}
+	stdout.Reset()
+	if err := DaemonStop(context.Background(), &stdout, &bytes.Buffer{}); err != nil || !strings.Contains(stdout.String(), "daemon not running") {
+		t.Errorf("DaemonStop() = %v, output %q", err, stdout.String())
+	}
+}
+
+func TestDaemonStop_StalePIDfileOfLiveProcess(t *testing.T) {
+	st, _ := setupDaemonTest(t)
+
+	// A crashed daemon's pid now belongs to an unrelated process
+	other := osexec.Command("sleep", "30")
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = other.Process.Kill(); _ = other.Wait() })
+	if err := os.WriteFile(daemon.PIDPath(st.DataDir), []byte(strconv.Itoa(other.Process.Pid)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	if err := DaemonStatus(context.Background(), DaemonStatusOpts{JSON: true}, &stdout, &bytes.Buffer{}); err != nil ||
+		!strings.Contains(stdout.String(), `"running": false`) {
+		t.Errorf("DaemonStatus() = %v, output %s", err, stdout.String())
+	}
+	stdout.Reset()
+	if err := DaemonStop(context.Background(), &stdout, &bytes.Buffer{}); err != nil || !strings.Contains(stdout.String(), "daemon not running") {
+		t.Errorf("DaemonStop() = %v, output %q", err, stdout.String())
+	}
+	if err := other.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("unrelated process was signaled: %v", err)
+	}
+	if _, err := os.Stat(daemon.PIDPath(st.DataDir)); !os.IsNotExist(err) {
+		t.Errorf("stale pidfile not removed: %v", err)
+	}
+}
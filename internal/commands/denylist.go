@@ -0,0 +1,93 @@
+// Package commands implements agency CLI commands.
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+)
+
+const (
+	denylistWarningMessage = "warning: pushing denylisted files; proceeding due to --allow-denylisted"
+	denylistErrorMessage   = "push would ship files matching the secret denylist; remove them or use --allow-denylisted to proceed"
+)
+
+// loadPushDenylist reads the denylist section of agency.json as committed on
+// parentRef. The run's own branch and worktree are never consulted: the runner
+// controls them, and could allow-list the very files the gate exists to stop.
+// A missing agency.json yields the built-in defaults only; an invalid one is
+// an error so a typo never silently disables configured patterns.
+func loadPushDenylist(ctx context.Context, cr exec.CommandRunner, workDir, parentRef string) (config.Denylist, error) {
+	listed, ok := gitText(ctx, cr, workDir, []string{"ls-tree", "--name-only", parentRef, "--", "agency.json"})
+	if !ok {
+		return config.Denylist{}, errors.NewWithDetails(errors.EInternal, "failed to read agency.json from parent",
+			map[string]string{"parent_ref": parentRef})
+	}
+	if strings.TrimSpace(listed) == "" {
+		return config.Denylist{}, nil
+	}
+	data, ok := gitText(ctx, cr, workDir, []string{"show", parentRef + ":agency.json"})
+	if !ok {
+		return config.Denylist{}, errors.NewWithDetails(errors.EInternal, "failed to read agency.json from parent",
+			map[string]string{"parent_ref": parentRef})
+	}
+	cfg, err := config.ParseAgencyConfig([]byte(data))
+	if err != nil {
+		return config.Denylist{}, err
+	}
+	return cfg.Denylist, nil
+}
+
+// findDenylistedFiles returns the sorted, de-duplicated paths that match the
+// denylist among files added in merge-base(parentRef, branch)..branch and the
+// worktree's current untracked (non-ignored) files.
+func findDenylistedFiles(ctx context.Context, cr exec.CommandRunner, workDir, parentRef, branch string, denylist config.Denylist) ([]string, error) {
+	added, ok := gitText(ctx, cr, workDir, []string{
+		"diff", "-z", "--name-only", "--no-renames", "--diff-filter=A", parentRef + "..." + branch,
+	})
+	if !ok {
+		return nil, errors.NewWithDetails(errors.EInternal, "failed to list files added on branch",
+			map[string]string{"rev_range": parentRef + "..." + branch})
+	}
+	untracked, ok := gitText(ctx, cr, workDir, []string{"ls-files", "-z", "--others", "--exclude-standard"})
+	if !ok {
+		return nil, errors.New(errors.EInternal, "failed to list untracked files")
+	}
+
+	seen := map[string]bool{}
+	var matches []string
+	for _, p := range append(strings.Split(added, "\x00"), strings.Split(untracked, "\x00")...) {
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		if denylist.Match(p) {
+			matches = append(matches, p)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func denylistErrorWithContext(paths []string) error {
+	return errors.NewWithDetails(
+		errors.EDenylistedFile,
+		denylistErrorMessage+"\n"+formatDenylistContext(paths),
+		map[string]string{"paths": strings.Join(paths, ",")},
+	)
+}
+
+func printDenylistWarning(w io.Writer, paths []string) {
+	_, _ = fmt.Fprintln(w, denylistWarningMessage)
+	_, _ = fmt.Fprintln(w, formatDenylistContext(paths))
+}
+
+func formatDenylistContext(paths []string) string {
+	return "denylisted_files:\n  " + strings.Join(paths, "\n  ")
+}
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+func TestFindDenylistedFiles(t *testing.T) {
+	testutil.HermeticGitEnv(t)
+
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	repoDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: repoDir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(repoDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// server.pem predates the branch: only files added on the branch count
+	write("README.md", "# Test\n")
+	write("server.pem", "old\n")
+	write(".gitignore", "ignored.key\n")
+	git("init", "-b", "main")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	git("checkout", "-b", "agency/feature")
+	write(".env", "TOKEN=x\n")
+	write(".env.example", "TOKEN=\n")
+	write("certs/client.pem", "pem\n")
+	write("data/app.sqlite", "db\n")
+	write("server.pem", "changed\n")
+	git("add", ".")
+	git("commit", "-m", "Add files")
+
+	write("credentials.json", "{}\n")
+	write("ignored.key", "ignored\n")
+	write("notes.txt", "notes\n")
+
+	denylist := config.Denylist{Patterns: []string{"*.sqlite"}}
+	got, err := findDenylistedFiles(ctx, cr, repoDir, "main", "agency/feature", denylist)
+	if err != nil {
+		t.Fatalf("findDenylistedFiles() error = %v", err)
+	}
+	want := []string{".env", "certs/client.pem", "credentials.json", "data/app.sqlite"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("denylisted = %v, want %v", got, want)
+	}
+
+	err = denylistErrorWithContext(got)
+	if errors.GetCode(err) != errors.EDenylistedFile {
+		t.Errorf("code = %s, want E_DENYLISTED_FILE", errors.GetCode(err))
+	}
+	if !strings.Contains(err.Error(), "certs/client.pem") {
+		t.Errorf("error should list paths: %v", err)
+	}
+}
+
+func TestLoadPushDenylist(t *testing.T) {
+	testutil.HermeticGitEnv(t)
+
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	repoDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: repoDir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoDir, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("README.md", "# Test\n")
+	git("init", "-b", "main")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	denylist, err := loadPushDenylist(ctx, cr, repoDir, "main")
+	if err != nil {
+		t.Fatalf("missing agency.json should use defaults, got %v", err)
+	}
+	if !denylist.Match(".env") || len(denylist.Patterns) != 0 {
+		t.Errorf("expected defaults only, got %+v", denylist)
+	}
+
+	// The branch allow-lists its own secret; the parent's config still applies
+	write("agency.json", `{"version": 1, "denylist": {"patterns": ["*.sqlite"]}}`)
+	git("add", "agency.json")
+	git("commit", "-m", "Add agency.json")
+	git("checkout", "-b", "agency/feature")
+	write("agency.json", `{"version": 1, "denylist": {"allow": ["*", "**"]}}`)
+	write(".env", "TOKEN=x\n")
+	git("add", ".")
+	git("commit", "-m", "Allow everything")
+
+	denylist, err = loadPushDenylist(ctx, cr, repoDir, "main")
+	if err != nil {
+		t.Fatalf("loadPushDenylist() error = %v", err)
+	}
+	if len(denylist.Allow) != 0 || len(denylist.Patterns) != 1 {
+		t.Errorf("denylist = %+v, want the parent's", denylist)
+	}
+	got, err := findDenylistedFiles(ctx, cr, repoDir, "main", "agency/feature", denylist)
+	if err != nil || strings.Join(got, ",") != ".env" {
+		t.Errorf("denylisted = %v (err = %v), want [.env]", got, err)
+	}
+
+	git("checkout", "main")
+	write("agency.json", `{"version": 1, "denylist": {"pattern": []}}`)
+	git("commit", "-am", "Break agency.json")
+	if _, err := loadPushDenylist(ctx, cr, repoDir, "main"); errors.GetCode(err) != errors.EInvalidAgencyJSON {
+		t.Errorf("expected E_INVALID_AGENCY_JSON, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/git"
+)
+
+// DiffOpts holds options for the diff command.
+type DiffOpts struct {
+	// RunID is the run reference (name, run_id, or unique prefix).
+	RunID string
+
+	// RepoPath is the optional --repo flag to scope name resolution.
+	RepoPath string
+
+	// Stat shows a diffstat instead of the full patch.
+	Stat bool
+
+	// NameOnly prints only changed file paths.
+	NameOnly bool
+
+	// Uncommitted includes working-tree changes and untracked files.
+	Uncommitted bool
+
+	// JSON enables JSON output.
+	JSON bool
+}
+
+// DiffCommit is one commit on the run branch since the merge-base.
+type DiffCommit struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+}
+
+// DiffFile is one changed file with line counts.
+type DiffFile struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"`
+}
+
+// DiffResult is the data for diff --json.
+type DiffResult struct {
+	RunID        string       `json:"run_id"`
+	Name         string       `json:"name"`
+	Branch       string       `json:"branch"`
+	ParentBranch string       `json:"parent_branch"`
+	ParentRef    string       `json:"parent_ref"`
+	BaseSHA      string       `json:"base_sha"`
+	HeadSHA      string       `json:"head_sha"`
+	Uncommitted  bool         `json:"uncommitted"`
+	Commits      []DiffCommit `json:"commits"`
+	Files        []DiffFile   `json:"files"`
+	Patch        string       `json:"patch,omitempty"`
+}
+
+// diffJSONEnvelope is the stable JSON output format for diff --json.
+type diffJSONEnvelope struct {
+	SchemaVersion string      `json:"schema_version"`
+	Data          *DiffResult `json:"data"`
+}
+
+// Diff shows a run's commits and changes against the merge-base with its parent branch.
+// This is a read-only command: neither the repo index nor any state file is modified.
+func Diff(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts DiffOpts, stdout, stderr io.Writer) error {
+	if opts.RunID == "" {
+		return errors.New(errors.EUsage, "run_id is required")
+	}
+	if opts.Stat && opts.NameOnly {
+		return errors.New(errors.EUsage, "--stat and --name-only are mutually exclusive")
+	}
+
+	rctx, err := ResolveRunContext(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	resolved, err := ResolveRun(rctx, opts.RunID)
+	if err != nil {
+		return err
+	}
+	if resolved.Broken || resolved.Record == nil || resolved.Record.Meta == nil {
+		return errors.NewWithDetails(
+			errors.ERunBroken,
+			"run exists but meta.json is unreadable or invalid",
+			map[string]string{"run_id": resolved.RunID, "repo_id": resolved.RepoID},
+		)
+	}
+	meta := resolved.Record.Meta
+
+	workDir := meta.WorktreePath
+	if info, statErr := os.Stat(workDir); statErr != nil || !info.IsDir() {
+		return errors.NewWithDetails(
+			errors.EWorktreeMissing,
+			"worktree path missing on disk (run may be archived)",
+			map[string]string{"worktree_path": workDir},
+		)
+	}
+
+	// Diff against the merge-base with the parent branch (what a PR would show)
+	parentRef, err := resolveParentRef(ctx, cr, workDir, meta.ParentBranch)
+	if err != nil {
+		return err
+	}
+	base, ok := gitText(ctx, cr, workDir, []string{"merge-base", parentRef, "HEAD"})
+	if !ok {
+		return errors.NewWithDetails(
+			errors.EParentNotFound,
+			fmt.Sprintf("no merge-base between %s and the run branch", parentRef),
+			map[string]string{"parent_ref": parentRef},
+		)
+	}
+	base = strings.TrimSpace(base)
+	head, _ := gitText(ctx, cr, workDir, []string{"rev-parse", "HEAD"})
+
+	res := &DiffResult{
+		RunID:        meta.RunID,
+		Name:         meta.Name,
+		Branch:       meta.Branch,
+		ParentBranch: meta.ParentBranch,
+		ParentRef:    parentRef,
+		BaseSHA:      base,
+		HeadSHA:      strings.TrimSpace(head),
+		Uncommitted:  opts.Uncommitted,
+		Commits:      []DiffCommit{},
+		Files:        []DiffFile{},
+	}
+
+	commitLines, _ := gitLines(ctx, cr, workDir, []string{"log", "--format=%h%x09%s", base + "..HEAD"})
+	for _, line := range commitLines {
+		sha, subject, _ := strings.Cut(line, "\t")
+		res.Commits = append(res.Commits, DiffCommit{SHA: sha, Subject: subject})
+	}
+
+	// Committed changes compare base..HEAD. With --uncommitted, stage the whole
+	// working tree (including untracked files) into a throwaway index and
+	// compare that against base, leaving the real index untouched.
+	var env map[string]string
+	diffArgs := []string{"diff", base + "..HEAD"}
+	if opts.Uncommitted {
+		indexDir, err := os.MkdirTemp("", "agency-diff-")
+		if err != nil {
+			return errors.Wrap(errors.EInternal, "failed to create temp dir", err)
+		}
+		defer func() { _ = os.RemoveAll(indexDir) }()
+
+		// Seed from the real index so unchanged files are not rehashed
+		indexPath := filepath.Join(indexDir, "index")
+		if realIndex := git.GitPath(ctx, cr, workDir, "index"); realIndex != "" {
+			if data, err := os.ReadFile(realIndex); err == nil {
+				_ = os.WriteFile(indexPath, data, 0o600)
+			}
+		}
+
+		env = map[string]string{"GIT_INDEX_FILE": indexPath}
+		if _, ok := gitTextEnv(ctx, cr, workDir, env, []string{"add", "-A"}); !ok {
+			return errors.New(errors.EInternal, "failed to snapshot working tree changes")
+		}
+		diffArgs = []string{"diff", "--cached", base}
+	}
+
+	numstat, ok := gitTextEnv(ctx, cr, workDir, env, append(append([]string{}, diffArgs...), "--numstat"))
+	if !ok {
+		return errors.New(errors.EInternal, "git diff failed")
+	}
+	res.Files = parseNumstat(numstat)
+
+	if opts.NameOnly && !opts.JSON {
+		for _, f := range res.Files {
+			_, _ = fmt.Fprintln(stdout, f.Path)
+		}
+		return nil
+	}
+
+	// Body: diffstat (human --stat only) or the full patch
+	var body string
+	switch {
+	case opts.NameOnly:
+	case opts.Stat:
+		if !opts.JSON {
+			body, _ = gitTextEnv(ctx, cr, workDir, env, append(append([]string{}, diffArgs...), "--stat"))
+		}
+	default:
+		patch, ok := gitTextEnv(ctx, cr, workDir, env, diffArgs)
+		if !ok {
+			return errors.New(errors.EInternal, "git diff failed")
+		}
+		res.Patch = patch
+		body = patch
+	}
+
+	if opts.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffJSONEnvelope{SchemaVersion: "1.0", Data: res})
+	}
+
+	_ = stderr
+	return writeDiffHuman(stdout, res, body)
+}
+
+// writeDiffHuman writes the commit list header followed by body (patch or stat).
+func writeDiffHuman(w io.Writer, res *DiffResult, body string) error {
+	name := res.Name
+	if name == "" {
+		name = res.RunID
+	}
+	_, _ = fmt.Fprintf(w, "run: %s (%s)\n", name, res.RunID)
+	_, _ = fmt.Fprintf(w, "base: %s @ %s\n", res.ParentRef, shortSHA(res.BaseSHA))
+	if res.Uncommitted {
+		_, _ = fmt.Fprintln(w, "uncommitted: included")
+	}
+	_, _ = fmt.Fprintf(w, "commits: %d\n", len(res.Commits))
+	for _, c := range res.Commits {
+		_, _ = fmt.Fprintf(w, "  %s %s\n", c.SHA, c.Subject)
+	}
+	_, _ = fmt.Fprintln(w)
+
+	if len(res.Files) == 0 {
+		_, _ = fmt.Fprintln(w, "(no changes)")
+		return nil
+	}
+	_, _ = io.WriteString(w, body)
+	return nil
+}
+
+// parseNumstat parses `git diff --numstat` output. Binary files report "-".
+func parseNumstat(out string) []DiffFile {
+	files := []DiffFile{}
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		f := DiffFile{Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			f.Binary = true
+		} else {
+			f.Additions, _ = strconv.Atoi(parts[0])
+			f.Deletions, _ = strconv.Atoi(parts[1])
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// shortSHA returns the first 7 characters of a sha.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+// setupDiffTestRun creates a repo on main, a run worktree on its own branch with
+// one commit, and the run's meta.json. Returns (repoDir, worktreePath, runID).
+func setupDiffTestRun(t *testing.T) (string, string, string) {
+	t.Helper()
+	testutil.HermeticGitEnv(t)
+
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: dir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repoDir := t.TempDir()
+	write(filepath.Join(repoDir, "README.md"), "# Test\n")
+	git(repoDir, "init", "-b", "main")
+	git(repoDir, "add", ".")
+	git(repoDir, "commit", "-m", "Initial commit")
+
+	worktreePath := filepath.Join(t.TempDir(), "wt")
+	git(repoDir, "worktree", "add", "-b", "agency/feature-a3f2", worktreePath, "main")
+	write(filepath.Join(worktreePath, "feature.go"), "package feature\n")
+	git(worktreePath, "add", ".")
+	git(worktreePath, "commit", "-m", "Add feature")
+
+	// main moves on after the run branched; the diff must not include this
+	write(filepath.Join(repoDir, "later.txt"), "later\n")
+	git(repoDir, "add", ".")
+	git(repoDir, "commit", "-m", "Later change on main")
+
+	dataDir := t.TempDir()
+	t.Setenv("AGENCY_DATA_DIR", dataDir)
+	st := store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+	repoID := "repo123456789012"
+	runID := "20260115120000-a3f2"
+	if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+		t.Fatal(err)
+	}
+	meta := store.NewRunMeta(runID, repoID, "feature", "claude", "claude", "main", "agency/feature-a3f2", worktreePath, time.Now())
+	if err := st.WriteInitialMeta(repoID, runID, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	return repoDir, worktreePath, runID
+}
+
+func TestDiff_CommittedOnly(t *testing.T) {
+	repoDir, worktreePath, runID := setupDiffTestRun(t)
+	if err := os.WriteFile(filepath.Join(worktreePath, "untracked.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := Diff(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), repoDir, DiffOpts{RunID: runID}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	out := stdout.String()
+	for _, want := range []string{"run: feature (" + runID + ")", "base: main @ ", "commits: 1", "Add feature", "+package feature"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"later.txt", "untracked.txt"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output should not contain %q:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestDiff_UncommittedNameOnly(t *testing.T) {
+	repoDir, worktreePath, runID := setupDiffTestRun(t)
+	if err := os.WriteFile(filepath.Join(worktreePath, "untracked.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktreePath, "README.md"), []byte("# Changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	opts := DiffOpts{RunID: runID, Uncommitted: true, NameOnly: true}
+	if err := Diff(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), repoDir, opts, &stdout, &stderr); err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	got := strings.Fields(stdout.String())
+	want := []string{"README.md", "feature.go", "untracked.txt"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	// The real index must be untouched: untracked.txt is still untracked
+	result, err := exec.NewRealRunner().Run(context.Background(), "git", []string{"status", "--porcelain"}, exec.RunOpts{Dir: worktreePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Stdout, "?? untracked.txt") {
+		t.Errorf("index was modified; git status:\n%s", result.Stdout)
+	}
+}
+
+func TestDiff_JSON(t *testing.T) {
+	repoDir, _, runID := setupDiffTestRun(t)
+
+	var stdout, stderr bytes.Buffer
+	opts := DiffOpts{RunID: runID, JSON: true, Stat: true}
+	if err := Diff(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), repoDir, opts, &stdout, &stderr); err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	var env diffJSONEnvelope
+	if err := json.Unmarshal(stdout.Bytes(), &env); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	d := env.Data
+	if env.SchemaVersion != "1.0" || d == nil {
+		t.Fatalf("envelope = %+v", env)
+	}
+	if len(d.Commits) != 1 || d.Commits[0].Subject != "Add feature" {
+		t.Errorf("commits = %+v", d.Commits)
+	}
+	if len(d.Files) != 1 || d.Files[0].Path != "feature.go" || d.Files[0].Additions != 1 {
+		t.Errorf("files = %+v", d.Files)
+	}
+	if d.Patch != "" {
+		t.Errorf("patch should be omitted with --stat, got %q", d.Patch)
+	}
+	if d.BaseSHA == "" || d.HeadSHA == "" || d.BaseSHA == d.HeadSHA {
+		t.Errorf("base=%q head=%q", d.BaseSHA, d.HeadSHA)
+	}
+}
+
+func TestDiff_StatAndNameOnlyExclusive(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := Diff(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), t.TempDir(), DiffOpts{RunID: "x", Stat: true, NameOnly: true}, &stdout, &stderr)
+	if errors.GetCode(err) != errors.EUsage {
+		t.Fatalf("expected E_USAGE, got %v", err)
+	}
+}
@@ -0,0 +1,511 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/checkpoint"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	agencyfs "github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
+	"github.com/NielsdaWheelz/agency/internal/lock"
+	"github.com/NielsdaWheelz/agency/internal/paths"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+	"github.com/NielsdaWheelz/agency/internal/worktree"
+)
+
+// GCOpts holds options for the gc command.
+type GCOpts struct {
+	// OlderThan limits pruning to runs (and stale branches) older than this
+	// age, e.g. "30d", "2w", "12h". Empty means any age.
+	OlderThan string
+
+	// Merged selects runs whose PR was merged.
+	Merged bool
+
+	// Abandoned selects runs abandoned with agency clean.
+	Abandoned bool
+
+	// Broken selects runs whose meta.json is unreadable.
+	Broken bool
+
+	// All also selects runs that were never archived, and deletes stale
+	// agency/* branches. Cannot be combined with a status filter.
+	All bool
+
+	// DryRun prints what would be removed without removing anything.
+	DryRun bool
+
+	// IncludeActive also prunes runs with a live tmux session or a present
+	// worktree (killing the session and removing the worktree).
+	IncludeActive bool
+}
+
+// gcRun is a run selected for pruning.
+type gcRun struct {
+	rec    store.RunRecord
+	reason string
+	active bool
+	size   int64
+	// refs are the run's checkpoint snapshot refs.
+	refs []string
+}
+
+// gcBranch is a local agency/* branch selected for deletion.
+type gcBranch struct {
+	repoRoot string
+	name     string
+}
+
+// GC prunes run records, their worktrees, branches and checkpoint refs, and
+// stale agency/* branches across all repos in the data dir.
+//
+// Runs are selected by the status filters (--merged, --abandoned, --broken;
+// any of them, or every archived, merged, abandoned or broken run if none is
+// given; every run with --all) and --older-than. Runs with a live tmux
+// session or a present worktree are skipped unless --include-active. Stale
+// branches (agency/* branches no run, integration worktree, or invocation
+// refers to) are pruned only with --all.
+func GC(ctx context.Context, cr agencyexec.CommandRunner, fsys agencyfs.FS, opts GCOpts, stdout, stderr io.Writer) error {
+	tmuxClient := tmux.NewExecClient(cr)
+	return GCWithTmux(ctx, cr, fsys, tmuxClient, opts, stdout, stderr)
+}
+
+// GCWithTmux is the test-friendly version of GC that accepts a tmux client.
+func GCWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys agencyfs.FS, tmuxClient tmux.Client, opts GCOpts, stdout, stderr io.Writer) error {
+	var maxAge time.Duration
+	if opts.OlderThan != "" {
+		d, err := parseGCAge(opts.OlderThan)
+		if err != nil {
+			return err
+		}
+		maxAge = d
+	}
+	statusFilter := opts.Merged || opts.Abandoned || opts.Broken
+	if opts.All && statusFilter {
+		return errors.New(errors.EUsage, "--all cannot be combined with a status filter (--merged, --abandoned, --broken)")
+	}
+	if opts.IncludeActive && !statusFilter && maxAge == 0 {
+		return errors.New(errors.EUsage, "--include-active requires --older-than or a status filter (--merged, --abandoned, --broken)")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dataDir := paths.ResolveDirs(osEnv{}, homeDir).DataDir
+	st := store.NewStore(fsys, dataDir, time.Now)
+	now := time.Now()
+
+	records, err := store.ScanAllRuns(dataDir)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to scan runs", err)
+	}
+
+	// Select runs, grouped by repo
+	byRepo := make(map[string][]gcRun)
+	kept := make(map[string][]store.RunRecord)
+	skippedActive := 0
+	for _, rec := range records {
+		reason, ok := gcRunReason(rec, opts)
+		if ok && maxAge > 0 {
+			ok = now.Sub(gcRunAge(rec)) > maxAge
+		}
+		if ok {
+			active := gcRunActive(ctx, tmuxClient, rec)
+			if active && !opts.IncludeActive {
+				skippedActive++
+				ok = false
+			} else {
+				byRepo[rec.RepoID] = append(byRepo[rec.RepoID], gcRun{rec: rec, reason: reason, active: active})
+			}
+		}
+		if !ok {
+			kept[rec.RepoID] = append(kept[rec.RepoID], rec)
+		}
+	}
+
+	repoIDs := make([]string, 0, len(byRepo))
+	for repoID := range byRepo {
+		repoIDs = append(repoIDs, repoID)
+	}
+	if opts.All {
+		// Repos with no selected runs may still have stale branches
+		for repoID := range kept {
+			if _, ok := byRepo[repoID]; !ok {
+				repoIDs = append(repoIDs, repoID)
+			}
+		}
+	}
+	sort.Strings(repoIDs)
+
+	verb := map[bool]string{true: "would remove", false: "removed"}[opts.DryRun]
+	branchVerb := map[bool]string{true: "would delete", false: "deleted"}[opts.DryRun]
+	var runsRemoved, branchesDeleted, refsDeleted int
+	var reclaimed int64
+
+	for _, repoID := range repoIDs {
+		runs := byRepo[repoID]
+		repoRoot := gcRepoRoot(st, repoID)
+
+		var unlock func() error
+		if !opts.DryRun {
+			unlock, err = lock.NewRepoLock(dataDir).Lock(repoID, "gc")
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "warning: skipping repo %s: %v\n", repoID, err)
+				continue
+			}
+		}
+
+		var removed []store.RunRecord
+		for _, r := range runs {
+			r.size = dirSize(r.rec.RunDir)
+			if r.active && r.rec.Meta != nil {
+				r.size += dirSize(r.rec.Meta.WorktreePath)
+			}
+			if repoRoot != "" {
+				r.refs, _ = checkpoint.ListRefs(ctx, cr, repoRoot, r.rec.RunID)
+			}
+			if !opts.DryRun {
+				if err := gcRemoveRun(ctx, cr, tmuxClient, dataDir, repoRoot, r); err != nil {
+					_, _ = fmt.Fprintf(stderr, "warning: failed to remove run %s: %v\n", r.rec.RunID, err)
+					kept[repoID] = append(kept[repoID], r.rec)
+					continue
+				}
+			}
+			removed = append(removed, r.rec)
+			runsRemoved++
+			refsDeleted += len(r.refs)
+			reclaimed += r.size
+			label := r.rec.RunID
+			if r.rec.Name != "" {
+				label = fmt.Sprintf("%s (%s)", r.rec.Name, r.rec.RunID)
+			}
+			refsNote := ""
+			if len(r.refs) > 0 {
+				refsNote = fmt.Sprintf(", %d checkpoint %s", len(r.refs), plural(len(r.refs), "ref", "refs"))
+			}
+			_, _ = fmt.Fprintf(stdout, "%s run %s: %s, %s%s\n", verb, label, r.reason, formatBytes(r.size), refsNote)
+		}
+
+		if repoRoot != "" {
+			if !opts.DryRun {
+				_, _ = gitText(ctx, cr, repoRoot, []string{"worktree", "prune"})
+			}
+			for _, b := range gcBranches(ctx, cr, dataDir, repoRoot, repoID, removed, kept[repoID], opts.All, maxAge, now) {
+				if !opts.DryRun {
+					if _, ok := gitText(ctx, cr, b.repoRoot, []string{"branch", "-D", b.name}); !ok {
+						_, _ = fmt.Fprintf(stderr, "warning: failed to delete branch %s in %s\n", b.name, b.repoRoot)
+						continue
+					}
+				}
+				branchesDeleted++
+				_, _ = fmt.Fprintf(stdout, "%s branch %s (%s)\n", branchVerb, b.name, b.repoRoot)
+			}
+		}
+
+		if unlock != nil {
+			_ = unlock()
+		}
+	}
+
+	if skippedActive > 0 {
+		_, _ = fmt.Fprintf(stdout, "skipped %d active %s (live tmux session or worktree present; use --include-active)\n",
+			skippedActive, plural(skippedActive, "run", "runs"))
+	}
+	reclaimVerb := map[bool]string{true: "would reclaim", false: "reclaimed"}[opts.DryRun]
+	refsNote := ""
+	if refsDeleted > 0 {
+		refsNote = fmt.Sprintf(", %d checkpoint %s", refsDeleted, plural(refsDeleted, "ref", "refs"))
+	}
+	_, _ = fmt.Fprintf(stdout, "%s %d %s, %d %s%s; %s %s\n",
+		verb, runsRemoved, plural(runsRemoved, "run", "runs"),
+		branchesDeleted, plural(branchesDeleted, "branch", "branches"), refsNote,
+		reclaimVerb, formatBytes(reclaimed))
+	return nil
+}
+
+// gcRunReason reports whether rec matches the status filters, and the
+// status to print for it. With no status filter, a run that was never
+// archived only matches with --all: its worktree may be gone, but its
+// branch can still hold unmerged work.
+func gcRunReason(rec store.RunRecord, opts GCOpts) (string, bool) {
+	var reason string
+	switch {
+	case rec.Broken || rec.Meta == nil:
+		reason = "broken"
+	case rec.Meta.Archive != nil && rec.Meta.Archive.MergedAt != "":
+		reason = "merged"
+	case rec.Meta.Flags != nil && rec.Meta.Flags.Abandoned:
+		reason = "abandoned"
+	case rec.Meta.Archive != nil && rec.Meta.Archive.ArchivedAt != "":
+		reason = "archived"
+	default:
+		reason = "not archived"
+	}
+	if !opts.Merged && !opts.Abandoned && !opts.Broken {
+		return reason, opts.All || reason != "not archived"
+	}
+	return reason, (opts.Merged && reason == "merged") ||
+		(opts.Abandoned && reason == "abandoned") ||
+		(opts.Broken && reason == "broken")
+}
+
+// gcRunAge returns the time a run's age is measured from: archived_at if
+// archived, else created_at. Broken runs use the run dir mtime.
+func gcRunAge(rec store.RunRecord) time.Time {
+	if rec.Meta != nil {
+		ts := rec.Meta.CreatedAt
+		if rec.Meta.Archive != nil && rec.Meta.Archive.ArchivedAt != "" {
+			ts = rec.Meta.Archive.ArchivedAt
+		}
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			return t
+		}
+	}
+	if info, err := os.Stat(rec.RunDir); err == nil {
+		return info.ModTime()
+	}
+	return time.Now()
+}
+
+// gcRunActive reports whether a run has a live tmux session, a running
+// headless runner or a worktree on disk, or is waiting in the run queue. A
+// failed tmux check counts as active.
+func gcRunActive(ctx context.Context, tmuxClient tmux.Client, rec store.RunRecord) bool {
+	sessionName := tmux.SessionName(rec.RunID)
+	if rec.Meta != nil {
+		if rec.Meta.Queue.Waiting() || rec.Meta.Queue.Starting() || headless.IsActive(rec.Meta) {
+			return true
+		}
+		if rec.Meta.TmuxSessionName != "" {
+			sessionName = rec.Meta.TmuxSessionName
+		}
+		if rec.Meta.WorktreePath != "" {
+			if _, err := os.Stat(rec.Meta.WorktreePath); err == nil {
+				return true
+			}
+		}
+	}
+	exists, err := tmuxClient.HasSession(ctx, sessionName)
+	return err != nil || exists
+}
+
+// gcRemoveRun kills an active run's session (or headless runner) and removes its worktree,
+// deletes its checkpoint refs, then deletes the run record directory (only if
+// it is under the data dir).
+func gcRemoveRun(ctx context.Context, cr agencyexec.CommandRunner, tmuxClient tmux.Client, dataDir, repoRoot string, r gcRun) error {
+	if r.active && r.rec.Meta != nil {
+		meta := r.rec.Meta
+		sessionName := meta.TmuxSessionName
+		if sessionName == "" {
+			sessionName = tmux.SessionName(meta.RunID)
+		}
+		if meta.Headless != nil {
+			if _, err := headless.SignalRunner(meta, syscall.SIGTERM); err != nil {
+				return fmt.Errorf("terminate headless runner: %w", err)
+			}
+		} else if err := tmuxClient.KillSession(ctx, sessionName); err != nil && !tmux.IsNoSessionErr(err) {
+			return fmt.Errorf("kill tmux session: %w", err)
+		}
+		if meta.WorktreePath != "" {
+			if res := worktree.Remove(ctx, cr, repoRoot, meta.WorktreePath); !res.Success {
+				allowed := filepath.Join(dataDir, "repos", meta.RepoID, "worktrees")
+				if err := agencyfs.SafeRemoveAll(meta.WorktreePath, allowed); err != nil {
+					return fmt.Errorf("remove worktree: %w", err)
+				}
+			}
+		}
+	}
+	if len(r.refs) > 0 {
+		if _, err := checkpoint.DeleteRefs(ctx, cr, repoRoot, r.rec.RunID); err != nil {
+			return fmt.Errorf("delete checkpoint refs: %w", err)
+		}
+	}
+	return agencyfs.SafeRemoveAll(r.rec.RunDir, dataDir)
+}
+
+// gcBranches returns the local agency/* branches to delete in repoRoot:
+// the branches of removed runs, plus (if stale) every agency/* branch that
+// no kept run, integration worktree, or invocation refers to. Branches
+// checked out in any worktree are never returned.
+func gcBranches(ctx context.Context, cr agencyexec.CommandRunner, dataDir, repoRoot, repoID string, removed, kept []store.RunRecord, stale bool, maxAge time.Duration, now time.Time) []gcBranch {
+	out, ok := gitText(ctx, cr, repoRoot, []string{"for-each-ref", "--format=%(refname:short) %(committerdate:unix)", "refs/heads/agency/"})
+	if !ok {
+		return nil
+	}
+
+	candidates := make(map[string]bool)
+	removedPaths := make(map[string]bool)
+	for _, rec := range removed {
+		if rec.Meta != nil && rec.Meta.Branch != "" {
+			candidates[rec.Meta.Branch] = true
+			removedPaths[rec.Meta.WorktreePath] = true
+		}
+	}
+
+	// Worktrees that are prunable or belong to removed runs are gone (or
+	// will be, in a dry run) and do not hold their branch
+	inUse := make(map[string]bool)
+	if wt, ok := gitText(ctx, cr, repoRoot, []string{"worktree", "list", "--porcelain"}); ok {
+		for _, block := range strings.Split(strings.TrimSpace(wt), "\n\n") {
+			var path, branch string
+			prunable := false
+			for _, line := range strings.Split(block, "\n") {
+				switch {
+				case strings.HasPrefix(line, "worktree "):
+					path = strings.TrimPrefix(line, "worktree ")
+				case strings.HasPrefix(line, "branch refs/heads/"):
+					branch = strings.TrimPrefix(line, "branch refs/heads/")
+				case strings.HasPrefix(line, "prunable"):
+					prunable = true
+				}
+			}
+			if branch != "" && !prunable && !removedPaths[path] {
+				inUse[branch] = true
+			}
+		}
+	}
+
+	// A broken record's branch is unknown, so stale detection is unsafe
+	if stale {
+		for _, rec := range kept {
+			if rec.Meta == nil {
+				stale = false
+				break
+			}
+			inUse[rec.Meta.Branch] = true
+		}
+	}
+	if stale {
+		wts, err := store.ScanIntegrationWorktreesForRepo(dataDir, repoID)
+		if err != nil {
+			stale = false
+		}
+		for _, w := range wts {
+			if w.Meta == nil {
+				stale = false
+				break
+			}
+			inUse[w.Meta.Branch] = true
+		}
+	}
+	if stale {
+		invs, err := store.ScanInvocationsForRepo(dataDir, repoID)
+		if err != nil {
+			stale = false
+		}
+		for _, inv := range invs {
+			if inv.Meta == nil {
+				stale = false
+				break
+			}
+			inUse[inv.Meta.SandboxBranch] = true
+		}
+	}
+
+	var branches []gcBranch
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		name, unix, found := strings.Cut(line, " ")
+		if !found || inUse[name] {
+			continue
+		}
+		if !candidates[name] {
+			if !stale {
+				continue
+			}
+			if maxAge > 0 {
+				sec, err := strconv.ParseInt(unix, 10, 64)
+				if err != nil || now.Sub(time.Unix(sec, 0)) <= maxAge {
+					continue
+				}
+			}
+		}
+		branches = append(branches, gcBranch{repoRoot: repoRoot, name: name})
+	}
+	return branches
+}
+
+// gcRepoRoot returns the last seen repo root for repoID if it still exists.
+func gcRepoRoot(st *store.Store, repoID string) string {
+	rec, ok, err := st.LoadRepoRecord(repoID)
+	if err != nil || !ok || rec.RepoRootLastSeen == "" {
+		return ""
+	}
+	if info, err := os.Stat(rec.RepoRootLastSeen); err != nil || !info.IsDir() {
+		return ""
+	}
+	return rec.RepoRootLastSeen
+}
+
+// parseGCAge parses an --older-than value: a Go duration ("12h") or a whole
+// number of days or weeks ("30d", "2w").
+func parseGCAge(s string) (time.Duration, error) {
+	invalid := errors.New(errors.EUsage, fmt.Sprintf("invalid --older-than %q; use e.g. 30d, 2w, or 12h", s))
+	if n := len(s); n > 1 && (s[n-1] == 'd' || s[n-1] == 'w') {
+		count, err := strconv.Atoi(s[:n-1])
+		if err != nil || count <= 0 {
+			return 0, invalid
+		}
+		unit := 24 * time.Hour
+		if s[n-1] == 'w' {
+			unit *= 7
+		}
+		return time.Duration(count) * unit, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, invalid
+	}
+	return d, nil
+}
+
+// dirSize returns the total size of regular files under path (0 if missing).
+func dirSize(path string) int64 {
+	var total int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// formatBytes formats a byte count with binary units (e.g. "12.3 MiB").
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// plural returns singular if n is 1, else pluralForm.
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/checkpoint"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+// setupGCTest creates a repo with three runs: a merged run archived 40 days
+// ago (worktree gone, branch left behind), an active run with a worktree, and
+// a leftover agency/* branch no run refers to.
+func setupGCTest(t *testing.T) (repoDir, dataDir string) {
+	t.Helper()
+	testutil.HermeticGitEnv(t)
+
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: dir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+
+	repoDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(repoDir, "init", "-b", "main")
+	git(repoDir, "add", ".")
+	git(repoDir, "commit", "-m", "Initial commit")
+	git(repoDir, "branch", "agency/old-a111")
+	git(repoDir, "branch", "agency/orphan-c333")
+	activeWorktree := filepath.Join(t.TempDir(), "wt")
+	git(repoDir, "worktree", "add", "-b", "agency/active-b222", activeWorktree, "main")
+
+	dataDir = t.TempDir()
+	t.Setenv("AGENCY_DATA_DIR", dataDir)
+	st := store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+	repoID := "repo123456789012"
+	rec := st.UpsertRepoRecord(nil, store.BuildRepoRecordInput{RepoKey: "path:" + repoDir, RepoID: repoID, RepoRootLastSeen: repoDir})
+	if err := st.SaveRepoRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	writeRun := func(runID, name, worktreePath string, createdAt time.Time, archive *store.RunMetaArchive) {
+		t.Helper()
+		if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+			t.Fatal(err)
+		}
+		meta := store.NewRunMeta(runID, repoID, name, "claude", "claude", "main", "agency/"+name+"-"+runID[len(runID)-4:], worktreePath, createdAt)
+		meta.Archive = archive
+		if err := st.WriteInitialMeta(repoID, runID, meta); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(st.RunLogsDir(repoID, runID), "raw.jsonl"), []byte(strings.Repeat("x", 2048)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-40 * 24 * time.Hour)
+	writeRun("20260101120000-a111", "old", filepath.Join(t.TempDir(), "gone"), old, &store.RunMetaArchive{
+		ArchivedAt: old.UTC().Format(time.RFC3339),
+		MergedAt:   old.UTC().Format(time.RFC3339),
+	})
+	writeRun("20260102120000-b222", "active", activeWorktree, old, nil)
+
+	return repoDir, dataDir
+}
+
+func branchExists(t *testing.T, repoDir, name string) bool {
+	t.Helper()
+	result, err := exec.NewRealRunner().Run(context.Background(), "git", []string{"rev-parse", "--verify", "--quiet", "refs/heads/" + name}, exec.RunOpts{Dir: repoDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.ExitCode == 0
+}
+
+func TestGC_DryRun(t *testing.T) {
+	repoDir, dataDir := setupGCTest(t)
+
+	var stdout, stderr bytes.Buffer
+	err := GCWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, GCOpts{All: true, DryRun: true}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"would remove run old (20260101120000-a111): merged, 2.",
+		"would delete branch agency/old-a111",
+		"would delete branch agency/orphan-c333",
+		"skipped 1 active run",
+		"would remove 1 run, 2 branches; would reclaim",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "agency/active-b222") {
+		t.Errorf("active run's branch should be kept:\n%s", out)
+	}
+
+	// Nothing was removed
+	if _, err := os.Stat(filepath.Join(dataDir, "repos", "repo123456789012", "runs", "20260101120000-a111")); err != nil {
+		t.Errorf("dry run removed run dir: %v", err)
+	}
+	if !branchExists(t, repoDir, "agency/old-a111") {
+		t.Error("dry run deleted branch")
+	}
+}
+
+func TestGC_Merged(t *testing.T) {
+	repoDir, dataDir := setupGCTest(t)
+
+	var stdout, stderr bytes.Buffer
+	err := GCWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, GCOpts{Merged: true, OlderThan: "30d"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+
+	runsDir := filepath.Join(dataDir, "repos", "repo123456789012", "runs")
+	if _, err := os.Stat(filepath.Join(runsDir, "20260101120000-a111")); !os.IsNotExist(err) {
+		t.Errorf("merged run dir still present: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(runsDir, "20260102120000-b222")); err != nil {
+		t.Errorf("active run dir removed: %v", err)
+	}
+	if branchExists(t, repoDir, "agency/old-a111") {
+		t.Error("merged run's branch not deleted")
+	}
+	// A status filter limits gc to matching runs; stale branches are left
+	if !branchExists(t, repoDir, "agency/orphan-c333") || !branchExists(t, repoDir, "agency/active-b222") {
+		t.Error("unrelated branches deleted")
+	}
+	if !strings.Contains(stdout.String(), "removed 1 run, 1 branch; reclaimed") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+}
+
+func TestGC_BareKeepsUnarchivedRuns(t *testing.T) {
+	repoDir, dataDir := setupGCTest(t)
+	// The active run's worktree was removed by hand; the run was never archived
+	st := store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+	meta, err := st.ReadMeta("repo123456789012", "20260102120000-b222")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(meta.WorktreePath); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = GCWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, GCOpts{}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+
+	runsDir := filepath.Join(dataDir, "repos", "repo123456789012", "runs")
+	if _, err := os.Stat(filepath.Join(runsDir, "20260101120000-a111")); !os.IsNotExist(err) {
+		t.Errorf("merged run dir still present: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(runsDir, "20260102120000-b222")); err != nil {
+		t.Errorf("unarchived run dir removed: %v", err)
+	}
+	if !branchExists(t, repoDir, "agency/active-b222") || !branchExists(t, repoDir, "agency/orphan-c333") {
+		t.Error("bare gc deleted an unarchived run's branch or a stale branch")
+	}
+	if !strings.Contains(stdout.String(), "removed 1 run, 1 branch; reclaimed") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+
+	// --all goes further
+	stdout.Reset()
+	err = GCWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, GCOpts{All: true}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("GC(--all) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(runsDir, "20260102120000-b222")); !os.IsNotExist(err) {
+		t.Errorf("unarchived run dir still present: %v", err)
+	}
+	if branchExists(t, repoDir, "agency/active-b222") || branchExists(t, repoDir, "agency/orphan-c333") {
+		t.Error("--all kept an unarchived run's branch or a stale branch")
+	}
+}
+
+func TestGC_AllWithStatusFilter(t *testing.T) {
+	setupGCTest(t)
+
+	var stdout, stderr bytes.Buffer
+	err := GCWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, GCOpts{All: true, Merged: true}, &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.EUsage {
+		t.Fatalf("error code = %q, want %q", code, errors.EUsage)
+	}
+}
+
+func TestGC_DeletesCheckpointRefs(t *testing.T) {
+	repoDir, _ := setupGCTest(t)
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	git := func(args ...string) string {
+		t.Helper()
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: repoDir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+		return strings.TrimSpace(result.Stdout)
+	}
+	head := git("rev-parse", "HEAD")
+	git("update-ref", checkpoint.Ref("20260101120000-a111", 1), head)
+	git("update-ref", checkpoint.Ref("20260101120000-a111", 2), head)
+	git("update-ref", checkpoint.Ref("20260102120000-b222", 1), head)
+
+	var stdout, stderr bytes.Buffer
+	opts := GCOpts{Merged: true, DryRun: true}
+	if err := GCWithTmux(ctx, cr, fs.NewRealFS(), &fakeTmuxClient{}, opts, &stdout, &stderr); err != nil {
+		t.Fatalf("GC(dry run) error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "would remove 1 run, 1 branch, 2 checkpoint refs; would reclaim") {
+		t.Errorf("dry run stdout = %q", stdout.String())
+	}
+	if refs, _ := checkpoint.ListRefs(ctx, cr, repoDir, "20260101120000-a111"); len(refs) != 2 {
+		t.Errorf("dry run deleted checkpoint refs: %v", refs)
+	}
+
+	stdout.Reset()
+	opts.DryRun = false
+	if err := GCWithTmux(ctx, cr, fs.NewRealFS(), &fakeTmuxClient{}, opts, &stdout, &stderr); err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "removed run old (20260101120000-a111): merged, 2.4 KiB, 2 checkpoint refs") ||
+		!strings.Contains(stdout.String(), "removed 1 run, 1 branch, 2 checkpoint refs; reclaimed") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if refs := git("for-each-ref", "--format=%(refname)", checkpoint.RefPrefix); refs != checkpoint.Ref("20260102120000-b222", 1) {
+		t.Errorf("remaining checkpoint refs = %q, want only the kept run's", refs)
+	}
+}
+
+func TestGC_IncludeActive(t *testing.T) {
+	repoDir, dataDir := setupGCTest(t)
+
+	tc := &fakeTmuxClient{hasSessionResult: true}
+	var stdout, stderr bytes.Buffer
+	err := GCWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, GCOpts{IncludeActive: true}, &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.EUsage {
+		t.Fatalf("error code = %q, want %q", code, errors.EUsage)
+	}
+
+	err = GCWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, GCOpts{IncludeActive: true, All: true, OlderThan: "1w"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+	if len(tc.killCalls) == 0 {
+		t.Error("expected the active run's session to be killed")
+	}
+	entries, _ := os.ReadDir(filepath.Join(dataDir, "repos", "repo123456789012", "runs"))
+	if len(entries) != 0 {
+		t.Errorf("run dirs left: %d", len(entries))
+	}
+	if branchExists(t, repoDir, "agency/active-b222") {
+		t.Error("active run's branch not deleted")
+	}
+}
+
+func TestParseGCAge(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"30d", 30 * 24 * time.Hour, true},
+		{"2w", 14 * 24 * time.Hour, true},
+		{"12h", 12 * time.Hour, true},
+		{"0d", 0, false},
+		{"d", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseGCAge(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseGCAge(%q) = %v, %v; want %v, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGCRemoveRun_Headless(t *testing.T) {
+	runID := "20260110120000-a3f2"
+	_, dataDir, repoID, _, fsys := setupStopTestEnv(t, runID, true)
+	st := store.NewStore(fsys, dataDir, nil)
+	_, done := startHeadlessTestRunner(t, st, repoID, runID)
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := store.RunRecord{RepoID: repoID, RunID: runID, Meta: meta, RunDir: st.RunDir(repoID, runID)}
+
+	tc := &fakeTmuxClient{}
+	if !gcRunActive(context.Background(), tc, rec) {
+		t.Error("running headless run not active")
+	}
+	err = gcRemoveRun(context.Background(), exec.NewRealRunner(), tc, dataDir, t.TempDir(), gcRun{rec: rec, active: true})
+	if err != nil {
+		t.Fatalf("gcRemoveRun() error = %v", err)
+	}
+	waitExited(t, done)
+	if len(tc.killCalls) != 0 {
+		t.Error("tmux used for a headless run")
+	}
+	if _, err := os.Stat(rec.RunDir); !os.IsNotExist(err) {
+		t.Errorf("run dir not removed: %v", err)
+	}
+}
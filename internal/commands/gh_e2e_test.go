@@ -386,3 +386,7 @@ func (noopTmuxClient) KillSession(context.Context, string) error {
 func (noopTmuxClient) SendKeys(context.Context, string, []tmux.Key) error {
 	return nil
 }
+
+func (noopTmuxClient) SendText(context.Context, string, string) error {
+	return nil
+}
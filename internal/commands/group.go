@@ -0,0 +1,540 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/core"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/lock"
+	"github.com/NielsdaWheelz/agency/internal/pipeline"
+	"github.com/NielsdaWheelz/agency/internal/render"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/runservice"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+// runAttempts creates opts.Attempts sibling runs of the same prompt in a new
+// best-of-N group. Attempts are named <name>-1..<name>-N, started detached
+// like a batch, and cycle through opts.Runners when set.
+func runAttempts(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts RunOpts, prompt string, stdout, stderr io.Writer) error {
+	runID, err := core.NewRunID(time.Now())
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to generate group id", err)
+	}
+	groupID := opts.Name + "-" + core.ShortID(runID)
+
+	tasks, err := attemptTasks(opts, prompt, groupID)
+	if err != nil {
+		return err
+	}
+
+	targetCwd, err := resolveRunTargetCwd(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stderr, "starting %d attempts in group %s\n", len(tasks), groupID)
+	results, err := startBatchRuns(ctx, cr, fsys, targetCwd, tasks, DefaultBatchConcurrency, opts.Queue, stderr)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(stdout, "group: %s\n", groupID)
+	failed := printBatchSummary(stdout, results)
+	_, _ = fmt.Fprintf(stdout, "next: agency group show %s\n", groupID)
+	if failed > 0 {
+		return errors.New(errors.EBatchFailed, fmt.Sprintf("%d of %d attempts failed", failed, len(results)))
+	}
+	return nil
+}
+
+// attemptTasks returns the pipeline options of each attempt in a group.
+func attemptTasks(opts RunOpts, prompt, groupID string) ([]pipeline.RunPipelineOpts, error) {
+	if opts.Attempts < 2 {
+		return nil, errors.New(errors.EUsage, "--attempts must be at least 2")
+	}
+	if len(opts.Runners) > 0 && opts.Runner != "" {
+		return nil, errors.New(errors.EUsage, "--runner and --runners are mutually exclusive")
+	}
+
+	tasks := make([]pipeline.RunPipelineOpts, opts.Attempts)
+	for i := range tasks {
+		name := fmt.Sprintf("%s-%d", opts.Name, i+1)
+		if err := core.ValidateName(name); err != nil {
+			if ae, ok := errors.AsAgencyError(err); ok {
+				return nil, errors.NewWithDetails(ae.Code, fmt.Sprintf("attempt name %q is invalid: %s", name, ae.Msg), ae.Details)
+			}
+			return nil, err
+		}
+		runner := opts.Runner
+		if len(opts.Runners) > 0 {
+			runner = opts.Runners[i%len(opts.Runners)]
+		}
+		tasks[i] = pipeline.RunPipelineOpts{
+			Name:         name,
+			Runner:       runner,
+			Parent:       opts.Parent,
+			Headless:     opts.Headless,
+			Prompt:       prompt,
+			GroupID:      groupID,
+			GroupAttempt: i + 1,
+			MaxDuration:  opts.MaxDuration,
+			MaxStall:     opts.MaxStall,
+		}
+	}
+	return tasks, nil
+}
+
+// resolveGroup returns the id and runs (ordered by attempt) of the group
+// matching ref: an exact group id or a unique prefix of one.
+func resolveGroup(dataDir, ref string) (string, []store.RunRecord, error) {
+	if ref == "" {
+		return "", nil, errors.New(errors.EUsage, "group id is required")
+	}
+	records, err := store.ScanAllRuns(dataDir)
+	if err != nil {
+		return "", nil, errors.Wrap(errors.EInternal, "failed to scan runs", err)
+	}
+
+	groups := make(map[string][]store.RunRecord)
+	for _, rec := range records {
+		if rec.Meta != nil && rec.Meta.GroupID != "" {
+			groups[rec.Meta.GroupID] = append(groups[rec.Meta.GroupID], rec)
+		}
+	}
+
+	groupID := ""
+	if _, ok := groups[ref]; ok {
+		groupID = ref
+	} else {
+		var matches []string
+		for id := range groups {
+			if strings.HasPrefix(id, ref) {
+				matches = append(matches, id)
+			}
+		}
+		sort.Strings(matches)
+		switch len(matches) {
+		case 0:
+			return "", nil, errors.New(errors.EGroupNotFound, fmt.Sprintf("group not found: %s", ref))
+		case 1:
+			groupID = matches[0]
+		default:
+			return "", nil, errors.NewWithDetails(
+				errors.EGroupAmbiguous,
+				fmt.Sprintf("group %q matches %d groups", ref, len(matches)),
+				map[string]string{"candidates": strings.Join(matches, ", ")},
+			)
+		}
+	}
+
+	members := groups[groupID]
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Meta.GroupAttempt < members[j].Meta.GroupAttempt
+	})
+	return groupID, members, nil
+}
+
+// GroupShowOpts holds options for the group show command.
+type GroupShowOpts struct {
+	// GroupID is the group id or a unique prefix.
+	GroupID string
+
+	// JSON enables JSON output.
+	JSON bool
+}
+
+// GroupAttempt is one attempt in group show output.
+type GroupAttempt struct {
+	Attempt      int    `json:"attempt"`
+	RunID        string `json:"run_id"`
+	Name         string `json:"name"`
+	Runner       string `json:"runner"`
+	State        string `json:"state"`
+	RunnerStatus string `json:"runner_status,omitempty"`
+	Summary      string `json:"summary,omitempty"`
+	VerifyOK     *bool  `json:"verify_ok"`
+	Additions    *int   `json:"additions"`
+	Deletions    *int   `json:"deletions"`
+	FilesChanged *int   `json:"files_changed"`
+}
+
+// GroupShowResult is the data for group show --json.
+type GroupShowResult struct {
+	GroupID  string         `json:"group_id"`
+	Attempts []GroupAttempt `json:"attempts"`
+}
+
+// groupShowJSONEnvelope is the stable JSON output format for group show --json.
+type groupShowJSONEnvelope struct {
+	SchemaVersion string           `json:"schema_version"`
+	Data          *GroupShowResult `json:"data"`
+}
+
+// Attempt states in group show.
+const (
+	groupStateActive    = "active"
+	groupStateAbandoned = "abandoned"
+	groupStateArchived  = "archived"
+)
+
+// GroupShow compares the attempts of a best-of-N group side by side: verify
+// result, committed diff size against the parent, files touched, and the
+// runner's reported status and summary.
+// This is a read-only command.
+func GroupShow(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts GroupShowOpts, stdout, stderr io.Writer) error {
+	rctx, err := ResolveRunContext(ctx, cr, cwd, "")
+	if err != nil {
+		return err
+	}
+	groupID, members, err := resolveGroup(rctx.DataDir, opts.GroupID)
+	if err != nil {
+		return err
+	}
+
+	st := store.NewStore(fsys, rctx.DataDir, time.Now)
+	res := &GroupShowResult{GroupID: groupID, Attempts: []GroupAttempt{}}
+	for _, rec := range members {
+		res.Attempts = append(res.Attempts, groupAttempt(ctx, cr, fsys, st, rec))
+	}
+
+	if opts.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(groupShowJSONEnvelope{SchemaVersion: "1.0", Data: res})
+	}
+	return writeGroupHuman(stdout, res)
+}
+
+// groupAttempt gathers the comparison data of one attempt. Diff and runner
+// status need the worktree and are left empty once it is gone.
+func groupAttempt(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, st *store.Store, rec store.RunRecord) GroupAttempt {
+	meta := rec.Meta
+	a := GroupAttempt{
+		Attempt:  meta.GroupAttempt,
+		RunID:    meta.RunID,
+		Name:     meta.Name,
+		Runner:   meta.Runner,
+		State:    groupStateActive,
+		VerifyOK: readVerifyOK(fsys, st.VerifyRecordPath(rec.RepoID, rec.RunID)),
+	}
+	switch {
+	case meta.Archive != nil && meta.Flags != nil && meta.Flags.Abandoned:
+		a.State = groupStateAbandoned
+	case meta.Archive != nil:
+		a.State = groupStateArchived
+	}
+
+	if info, err := os.Stat(meta.WorktreePath); err != nil || !info.IsDir() {
+		return a
+	}
+	if rs, err := runnerstatus.Load(meta.WorktreePath); err == nil && rs != nil {
+		a.RunnerStatus = string(rs.Status)
+		a.Summary = rs.Summary
+	}
+	if parentRef, err := resolveParentRef(ctx, cr, meta.WorktreePath, meta.ParentBranch); err == nil {
+		if base, ok := gitText(ctx, cr, meta.WorktreePath, []string{"merge-base", parentRef, "HEAD"}); ok {
+			if numstat, ok := gitText(ctx, cr, meta.WorktreePath, []string{"diff", "--numstat", strings.TrimSpace(base) + "..HEAD"}); ok {
+				add, del := 0, 0
+				files := parseNumstat(numstat)
+				for _, f := range files {
+					add += f.Additions
+					del += f.Deletions
+				}
+				n := len(files)
+				a.Additions, a.Deletions, a.FilesChanged = &add, &del, &n
+			}
+		}
+	}
+	return a
+}
+
+// writeGroupHuman writes the group comparison table.
+func writeGroupHuman(w io.Writer, res *GroupShowResult) error {
+	nameW, runnerW, stateW := len("NAME"), len("RUNNER"), len("STATE")
+	for _, a := range res.Attempts {
+		nameW = max(nameW, len(a.Name))
+		runnerW = max(runnerW, len(a.Runner))
+		state := a.State
+		if a.RunnerStatus != "" && a.State == groupStateActive {
+			state = a.RunnerStatus
+		}
+		stateW = max(stateW, len(state))
+	}
+
+	_, _ = fmt.Fprintf(w, "group: %s (%d %s)\n\n", res.GroupID, len(res.Attempts), plural(len(res.Attempts), "attempt", "attempts"))
+	_, _ = fmt.Fprintf(w, "#  %-*s  %-*s  %-*s  %-6s  %-13s  %-5s  %s\n", nameW, "NAME", runnerW, "RUNNER", stateW, "STATE", "VERIFY", "DIFF", "FILES", "SUMMARY")
+	for _, a := range res.Attempts {
+		state := a.State
+		if a.RunnerStatus != "" && a.State == groupStateActive {
+			state = a.RunnerStatus
+		}
+		verify := "-"
+		if a.VerifyOK != nil {
+			verify = "failed"
+			if *a.VerifyOK {
+				verify = "ok"
+			}
+		}
+		diff, files := "-", "-"
+		if a.Additions != nil {
+			diff = fmt.Sprintf("+%d -%d", *a.Additions, *a.Deletions)
+			files = fmt.Sprintf("%d", *a.FilesChanged)
+		}
+		summary := "-"
+		if a.Summary != "" {
+			summary = render.TruncateForDisplay(a.Summary, 60)
+		}
+		_, _ = fmt.Fprintf(w, "%-2d %-*s  %-*s  %-*s  %-6s  %-13s  %-5s  %s\n", a.Attempt, nameW, a.Name, runnerW, a.Runner, stateW, state, verify, diff, files, summary)
+	}
+	_, _ = fmt.Fprintf(w, "\nnext: agency group pick <name>\n")
+	return nil
+}
+
+// GroupPickOpts holds options for the group pick command.
+type GroupPickOpts struct {
+	// RunID is the attempt to keep (name, run_id, or unique prefix).
+	RunID string
+
+	// RepoPath is the optional --repo flag to scope name resolution.
+	RepoPath string
+
+	// Yes skips the confirmation prompt.
+	Yes bool
+
+	// AllowDirty abandons attempts with uncommitted changes.
+	AllowDirty bool
+
+	// DeleteBranch deletes the abandoned attempts' branches and closes their PRs.
+	DeleteBranch bool
+}
+
+// GroupPick keeps one attempt of a best-of-N group and abandons every other
+// active attempt through the clean pipeline.
+func GroupPick(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts GroupPickOpts, stdin io.Reader, stdout, stderr io.Writer) error {
+	tmuxClient := tmux.NewExecClient(cr)
+	return GroupPickWithTmux(ctx, cr, fsys, tmuxClient, cwd, opts, stdin, stdout, stderr)
+}
+
+// GroupPickWithTmux picks a group attempt using the provided tmux client.
+// This variant is used for testing with a fake tmux client.
+func GroupPickWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, tmuxClient tmux.Client, cwd string, opts GroupPickOpts, stdin io.Reader, stdout, stderr io.Writer) error {
+	if opts.RunID == "" {
+		return errors.New(errors.EUsage, "run_id is required")
+	}
+
+	rctx, err := ResolveRunContext(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	resolved, err := ResolveRun(rctx, opts.RunID)
+	if err != nil {
+		return err
+	}
+	if resolved.Broken || resolved.Record == nil || resolved.Record.Meta == nil {
+		return errors.NewWithDetails(
+			errors.ERunBroken,
+			"run exists but meta.json is unreadable or invalid",
+			map[string]string{"run_id": resolved.RunID, "repo_id": resolved.RepoID},
+		)
+	}
+	picked := resolved.Record.Meta
+	if picked.GroupID == "" {
+		return errors.New(errors.EUsage, fmt.Sprintf("run %s is not an attempt in a group (see agency run --attempts)", picked.Name))
+	}
+	if picked.Archive != nil {
+		return errors.New(errors.EUsage, fmt.Sprintf("run %s is archived and cannot be picked", picked.Name))
+	}
+
+	_, members, err := resolveGroup(rctx.DataDir, picked.GroupID)
+	if err != nil {
+		return err
+	}
+	var losers []*store.RunMeta
+	for _, rec := range members {
+		if rec.RunID != picked.RunID && rec.Meta.Archive == nil {
+			losers = append(losers, rec.Meta)
+		}
+	}
+
+	_, _ = fmt.Fprintf(stderr, "keeping %s (%s); abandoning:\n", picked.Name, picked.RunID)
+	for _, m := range losers {
+		_, _ = fmt.Fprintf(stderr, "  %s (%s)\n", m.Name, m.RunID)
+	}
+	if len(losers) == 0 {
+		_, _ = fmt.Fprintln(stderr, "  (none)")
+	}
+	if len(losers) > 0 && !opts.Yes {
+		if !isInteractive() {
+			return errors.New(errors.ENotInteractive, "group pick requires an interactive terminal for confirmation; pass --yes to skip")
+		}
+		_, _ = fmt.Fprint(stderr, "confirm: type 'pick' to proceed: ")
+		input, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil {
+			return errors.Wrap(errors.EAborted, "failed to read confirmation", err)
+		}
+		if strings.TrimSpace(input) != "pick" {
+			return errors.New(errors.EAborted, "confirmation failed; expected 'pick'")
+		}
+	}
+
+	repoID := picked.RepoID
+	unlock, err := lock.NewRepoLock(rctx.DataDir).Lock(repoID, "group-pick")
+	if err != nil {
+		if _, ok := err.(*lock.ErrLocked); ok {
+			return errors.New(errors.ERepoLocked, err.Error())
+		}
+		return errors.Wrap(errors.EInternal, "failed to acquire repo lock", err)
+	}
+	defer func() { _ = unlock() }()
+
+	st := store.NewStore(fsys, rctx.DataDir, time.Now)
+	repoRoot := ""
+	if rctx.CWDRepoRoot != "" && rctx.CWDRepoID == repoID {
+		repoRoot = rctx.CWDRepoRoot
+	} else if rctx.ExplicitRepoRoot != "" && rctx.ExplicitRepoID == repoID {
+		repoRoot = rctx.ExplicitRepoRoot
+	} else {
+		repoRoot = gcRepoRoot(st, repoID)
+	}
+
+	var abandoned, failed []string
+	for _, m := range losers {
+		err := cleanDirtyGate(ctx, cr, st.EventsPath(repoID, m.RunID), repoID, m.RunID, m.WorktreePath, opts.AllowDirty, stderr)
+		if err == nil {
+			err = runCleanPipeline(ctx, cr, fsys, tmuxClient, st, rctx.DataDir, repoRoot, m, opts.DeleteBranch, stdout, stderr)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "error: %s: %v\n", m.Name, err)
+			failed = append(failed, m.Name)
+			continue
+		}
+		abandoned = append(abandoned, m.RunID)
+	}
+
+	_ = events.AppendEvent(st.EventsPath(repoID, picked.RunID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		RepoID:        repoID,
+		RunID:         picked.RunID,
+		Event:         "group_picked",
+		Data: map[string]any{
+			"group_id":  picked.GroupID,
+			"abandoned": abandoned,
+		},
+	})
+
+	_, _ = fmt.Fprintf(stdout, "picked: %s (group %s); abandoned %d %s\n", picked.Name, picked.GroupID, len(abandoned), plural(len(abandoned), "attempt", "attempts"))
+	if len(failed) > 0 {
+		return errors.NewWithDetails(
+			errors.EArchiveFailed,
+			fmt.Sprintf("failed to abandon %d %s", len(failed), plural(len(failed), "attempt", "attempts")),
+			map[string]string{"runs": strings.Join(failed, ", ")},
+		)
+	}
+	return nil
+}
+
+// autoVerifyGroupRun runs verify for a group attempt that reports
+// ready_for_review and has not been verified since. Returns whether verify
+// ran. Used by the run monitor so attempts can be compared.
+// failedReport holds the updated_at of the last report verify failed to run
+// for; that report is not retried, so a broken verify does not rerun on every
+// tick, but the attempt's next report is verified again.
+func autoVerifyGroupRun(ctx context.Context, st *store.Store, repoID, runID string, failedReport *string, verify func(context.Context) (bool, error), log io.Writer) bool {
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil || meta.GroupID == "" || meta.Archive != nil {
+		return false
+	}
+	rs, err := runnerstatus.Load(meta.WorktreePath)
+	if err != nil || rs == nil || rs.Status != runnerstatus.StatusReadyForReview || rs.UpdatedAt == *failedReport {
+		return false
+	}
+	reportedAt, err := time.Parse(time.RFC3339, rs.UpdatedAt)
+	if err != nil {
+		return false
+	}
+	if lastVerify, err := time.Parse(time.RFC3339Nano, meta.LastVerifyAt); err == nil && !lastVerify.Before(reportedAt) {
+		return false
+	}
+
+	ok, err := verify(ctx)
+	stamp := time.Now().UTC().Format(time.RFC3339)
+	switch {
+	case err != nil:
+		*failedReport = rs.UpdatedAt
+		_, _ = fmt.Fprintf(log, "%s auto-verify failed to run: %v (retrying on the next ready_for_review report)\n", stamp, err)
+	case ok:
+		_, _ = fmt.Fprintf(log, "%s auto-verify: ok\n", stamp)
+	default:
+		_, _ = fmt.Fprintf(log, "%s auto-verify: failed\n", stamp)
+	}
+	return err == nil
+}
+
+// groupAutoVerifyInterval is how often the run monitor of a group
+// attempt checks for a ready_for_review report.
+var groupAutoVerifyInterval = 15 * time.Second
+
+// startBatchRuns runs tasks through the pipeline (setup concurrency capped),
+// then starts the checkpoint watcher and run monitor of each run that
+// started. When the max_active_runs limits leave fewer free slots than tasks,
+// the rest are queued if queue is set; otherwise nothing starts and
+// E_RUN_LIMIT is returned.
+func startBatchRuns(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, targetCwd string, tasks []pipeline.RunPipelineOpts, concurrency int, queue bool, stderr io.Writer) ([]batchResult, error) {
+	adm, err := admitRuns(ctx, cr, fsys, targetCwd)
+	if err != nil {
+		return nil, err
+	}
+	startN := len(tasks)
+	if adm.Free >= 0 && adm.Free < startN {
+		if !queue {
+			return nil, adm.limitError(len(tasks))
+		}
+		startN = adm.Free
+	}
+
+	for i := range tasks {
+		tasks[i].Dir = targetCwd
+	}
+	results := runBatchTasks(ctx, newBatchService(runservice.New(), concurrency), tasks[:startN])
+	for i := range results {
+		r := &results[i]
+		if r.Err != nil {
+			continue
+		}
+		result, err := getRunResult(ctx, cr, fsys, targetCwd, r.RunID)
+		if err != nil {
+			r.Err = errors.Wrap(errors.EInternal, "failed to read run result", err)
+			continue
+		}
+		for _, w := range startRunWatchers(store.NewStore(fsys, result.DataDir, time.Now), result.RepoID, result.RunID) {
+			_, _ = fmt.Fprintf(stderr, "warning: %s: %s\n", result.Name, w)
+		}
+	}
+
+	if rest := tasks[startN:]; len(rest) > 0 {
+		runIDs, err := enqueueRuns(ctx, cr, fsys, adm, rest)
+		for i, task := range rest {
+			r := batchResult{Task: task, Queued: true}
+			if i < len(runIDs) {
+				r.RunID = runIDs[i]
+			} else {
+				r.Err = err
+			}
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
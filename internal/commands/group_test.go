@@ -0,0 +1,358 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+func TestAttemptTasks(t *testing.T) {
+	tasks, err := attemptTasks(RunOpts{Name: "fix-auth", Attempts: 3, Runners: []string{"claude", "codex"}, Parent: "main"}, "fix it", "fix-auth-a1b2")
+	if err != nil {
+		t.Fatalf("attemptTasks() error = %v", err)
+	}
+	wantRunners := []string{"claude", "codex", "claude"}
+	if len(tasks) != 3 {
+		t.Fatalf("got %d tasks, want 3", len(tasks))
+	}
+	for i, task := range tasks {
+		if task.Name != "fix-auth-"+string(rune('1'+i)) || task.Runner != wantRunners[i] || task.Parent != "main" ||
+			task.Prompt != "fix it" || task.GroupID != "fix-auth-a1b2" || task.GroupAttempt != i+1 {
+			t.Errorf("task %d = %+v", i, task)
+		}
+	}
+
+	for _, opts := range []RunOpts{
+		{Name: "fix-auth", Attempts: 1},
+		{Name: "fix-auth", Attempts: -2},
+		{Name: "fix-auth", Attempts: 2, Runner: "claude", Runners: []string{"codex"}},
+	} {
+		if _, err := attemptTasks(opts, "p", "g"); errors.GetCode(err) != errors.EUsage {
+			t.Errorf("attemptTasks(%+v) error = %v, want E_USAGE", opts, err)
+		}
+	}
+	if _, err := attemptTasks(RunOpts{Name: strings.Repeat("a", 40), Attempts: 2}, "p", "g"); errors.GetCode(err) != errors.EInvalidName ||
+		!strings.Contains(err.Error(), "at most 40 characters") {
+		t.Errorf("long name error = %v, want E_INVALID_NAME with the length limit", err)
+	}
+	if _, err := attemptTasks(RunOpts{Name: "Fix", Attempts: 2}, "p", "g"); errors.GetCode(err) != errors.EInvalidName ||
+		!strings.Contains(err.Error(), "lowercase letters") {
+		t.Errorf("uppercase name error = %v, want E_INVALID_NAME with the allowed characters", err)
+	}
+}
+
+func TestRun_AttemptsRequirePrompt(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := Run(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), t.TempDir(), RunOpts{Name: "fix-auth", Attempts: 2}, &stdout, &stderr)
+	if errors.GetCode(err) != errors.EUsage || !strings.Contains(err.Error(), "--attempts requires") {
+		t.Errorf("error = %v, want --attempts prompt usage error", err)
+	}
+	err = Run(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), t.TempDir(), RunOpts{Name: "fix-auth", Runners: []string{"codex"}}, &stdout, &stderr)
+	if errors.GetCode(err) != errors.EUsage {
+		t.Errorf("--runners without --attempts error = %v, want E_USAGE", err)
+	}
+}
+
+// setupGroupTest creates a repo with three attempts of group "fix-auth-g1":
+// attempt 1 committed one file and reported ready_for_review with verify ok,
+// attempt 2 is untouched, and attempt 3 was already abandoned.
+func setupGroupTest(t *testing.T) (repoDir, dataDir string, st *store.Store) {
+	t.Helper()
+	testutil.HermeticGitEnv(t)
+
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: dir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+
+	repoDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(repoDir, "init", "-b", "main")
+	git(repoDir, "add", ".")
+	git(repoDir, "commit", "-m", "Initial commit")
+
+	dataDir = t.TempDir()
+	t.Setenv("AGENCY_DATA_DIR", dataDir)
+	st = store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+	repoID := "repo123456789012"
+	rec := st.UpsertRepoRecord(nil, store.BuildRepoRecordInput{RepoKey: "path:" + repoDir, RepoID: repoID, RepoRootLastSeen: repoDir})
+	if err := st.SaveRepoRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	worktrees := t.TempDir()
+	writeAttempt := func(n int, runID string, archived bool) string {
+		t.Helper()
+		name := "fix-auth-" + string(rune('0'+n))
+		branch := "agency/" + name + "-" + runID[len(runID)-4:]
+		wt := filepath.Join(worktrees, name)
+		if !archived {
+			git(repoDir, "worktree", "add", "-b", branch, wt, "main")
+		}
+		if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+			t.Fatal(err)
+		}
+		meta := store.NewRunMeta(runID, repoID, name, "claude", "claude", "main", branch, wt, time.Now())
+		meta.GroupID = "fix-auth-g1"
+		meta.GroupAttempt = n
+		if archived {
+			meta.Runner = "codex"
+			meta.Archive = &store.RunMetaArchive{ArchivedAt: time.Now().UTC().Format(time.RFC3339)}
+			meta.Flags = &store.RunMetaFlags{Abandoned: true}
+		}
+		if err := st.WriteInitialMeta(repoID, runID, meta); err != nil {
+			t.Fatal(err)
+		}
+		return wt
+	}
+	// Written out of order to check attempt ordering.
+	writeAttempt(2, "20260102120000-b222", false)
+	wt1 := writeAttempt(1, "20260101120000-a111", false)
+	writeAttempt(3, "20260103120000-c333", true)
+
+	if err := os.WriteFile(filepath.Join(wt1, "auth.go"), []byte("package auth\n\nfunc Fix() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(wt1, "add", "auth.go")
+	git(wt1, "commit", "-m", "Fix auth")
+	writeRunnerStatus(t, wt1, "ready_for_review", "fixed the token refresh", time.Now())
+	if err := os.WriteFile(st.VerifyRecordPath(repoID, "20260101120000-a111"), []byte(`{"ok": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return repoDir, dataDir, st
+}
+
+func writeRunnerStatus(t *testing.T, worktree, status, summary string, updatedAt time.Time) {
+	t.Helper()
+	dir := filepath.Join(worktree, ".agency", "state")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"schema_version": "1.0", "status": "` + status + `", "updated_at": "` + updatedAt.UTC().Format(time.RFC3339) + `", "summary": "` + summary + `"}`
+	if err := os.WriteFile(filepath.Join(dir, "runner_status.json"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveGroup(t *testing.T) {
+	_, dataDir, _ := setupGroupTest(t)
+
+	groupID, members, err := resolveGroup(dataDir, "fix-auth-g")
+	if err != nil {
+		t.Fatalf("resolveGroup() error = %v", err)
+	}
+	if groupID != "fix-auth-g1" || len(members) != 3 {
+		t.Fatalf("group = %s with %d members", groupID, len(members))
+	}
+	for i, m := range members {
+		if m.Meta.GroupAttempt != i+1 {
+			t.Errorf("member %d is attempt %d", i, m.Meta.GroupAttempt)
+		}
+	}
+
+	if _, _, err := resolveGroup(dataDir, "nope"); errors.GetCode(err) != errors.EGroupNotFound {
+		t.Errorf("error = %v, want E_GROUP_NOT_FOUND", err)
+	}
+}
+
+func TestGroupShow_JSON(t *testing.T) {
+	repoDir, _, _ := setupGroupTest(t)
+
+	var stdout, stderr bytes.Buffer
+	err := GroupShow(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), repoDir, GroupShowOpts{GroupID: "fix-auth-g1", JSON: true}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("GroupShow() error = %v", err)
+	}
+	var env struct {
+		SchemaVersion string          `json:"schema_version"`
+		Data          GroupShowResult `json:"data"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &env); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	attempts := env.Data.Attempts
+	if env.SchemaVersion != "1.0" || len(attempts) != 3 {
+		t.Fatalf("output = %s", stdout.String())
+	}
+
+	a1 := attempts[0]
+	if a1.State != "active" || a1.RunnerStatus != "ready_for_review" || a1.Summary != "fixed the token refresh" ||
+		a1.VerifyOK == nil || !*a1.VerifyOK || a1.Additions == nil || *a1.Additions != 3 || *a1.FilesChanged != 1 {
+		t.Errorf("attempt 1 = %+v", a1)
+	}
+	a2 := attempts[1]
+	if a2.VerifyOK != nil || a2.Additions == nil || *a2.Additions != 0 || *a2.FilesChanged != 0 {
+		t.Errorf("attempt 2 = %+v", a2)
+	}
+	a3 := attempts[2]
+	if a3.State != "abandoned" || a3.Runner != "codex" || a3.Additions != nil {
+		t.Errorf("attempt 3 = %+v", a3)
+	}
+}
+
+func TestGroupShow_Human(t *testing.T) {
+	repoDir, _, _ := setupGroupTest(t)
+
+	var stdout, stderr bytes.Buffer
+	err := GroupShow(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), repoDir, GroupShowOpts{GroupID: "fix-auth-g1"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("GroupShow() error = %v", err)
+	}
+	for _, want := range []string{
+		"group: fix-auth-g1 (3 attempts)",
+		"1  fix-auth-1  claude  ready_for_review  ok      +3 -0          1      fixed the token refresh",
+		"3  fix-auth-3  codex   abandoned         -       -              -      -",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestGroupPick(t *testing.T) {
+	repoDir, _, st := setupGroupTest(t)
+
+	var stdout, stderr bytes.Buffer
+	err := GroupPickWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, repoDir,
+		GroupPickOpts{RunID: "fix-auth-1", Yes: true}, strings.NewReader(""), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("GroupPick() error = %v\nstderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "picked: fix-auth-1 (group fix-auth-g1); abandoned 1 attempt") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+
+	repoID := "repo123456789012"
+	loser, err := st.ReadMeta(repoID, "20260102120000-b222")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loser.Archive == nil || loser.Flags == nil || !loser.Flags.Abandoned {
+		t.Errorf("attempt 2 not abandoned: archive=%+v flags=%+v", loser.Archive, loser.Flags)
+	}
+	if _, err := os.Stat(loser.WorktreePath); !os.IsNotExist(err) {
+		t.Errorf("attempt 2 worktree still exists: %v", err)
+	}
+	winner, err := st.ReadMeta(repoID, "20260101120000-a111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if winner.Archive != nil {
+		t.Error("picked attempt was archived")
+	}
+	data, err := os.ReadFile(st.EventsPath(repoID, "20260101120000-a111"))
+	if err != nil || !strings.Contains(string(data), `"event":"group_picked"`) {
+		t.Errorf("expected group_picked event, err = %v", err)
+	}
+}
+
+func TestGroupPick_NotInGroup(t *testing.T) {
+	repoDir, _ := setupGCTest(t)
+
+	var stdout, stderr bytes.Buffer
+	err := GroupPickWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, repoDir,
+		GroupPickOpts{RunID: "active", Yes: true}, strings.NewReader(""), &stdout, &stderr)
+	if errors.GetCode(err) != errors.EUsage {
+		t.Errorf("error = %v, want E_USAGE", err)
+	}
+}
+
+func TestGroupPick_RequiresConfirmation(t *testing.T) {
+	repoDir, _, _ := setupGroupTest(t)
+	origIsInteractive := isInteractive
+	isInteractive = func() bool { return false }
+	t.Cleanup(func() { isInteractive = origIsInteractive })
+
+	var stdout, stderr bytes.Buffer
+	err := GroupPickWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, repoDir,
+		GroupPickOpts{RunID: "fix-auth-1"}, strings.NewReader(""), &stdout, &stderr)
+	if errors.GetCode(err) != errors.ENotInteractive {
+		t.Errorf("error = %v, want E_NOT_INTERACTIVE", err)
+	}
+}
+
+func TestAutoVerifyGroupRun(t *testing.T) {
+	_, _, st := setupGroupTest(t)
+	repoID := "repo123456789012"
+
+	calls := 0
+	verify := func(context.Context) (bool, error) {
+		calls++
+		if err := st.UpdateMeta(repoID, "20260101120000-a111", func(m *store.RunMeta) {
+			m.LastVerifyAt = time.Now().UTC().Format(time.RFC3339Nano)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return true, nil
+	}
+	var log bytes.Buffer
+	failedReport := ""
+
+	// Attempt 2 has not reported ready_for_review.
+	if autoVerifyGroupRun(context.Background(), st, repoID, "20260102120000-b222", &failedReport, verify, &log) {
+		t.Error("verified an attempt that is not ready_for_review")
+	}
+	if !autoVerifyGroupRun(context.Background(), st, repoID, "20260101120000-a111", &failedReport, verify, &log) {
+		t.Error("did not verify a ready_for_review attempt")
+	}
+	// Already verified since the report.
+	if autoVerifyGroupRun(context.Background(), st, repoID, "20260101120000-a111", &failedReport, verify, &log) {
+		t.Error("verified twice for the same report")
+	}
+	if calls != 1 || !strings.Contains(log.String(), "auto-verify: ok") {
+		t.Errorf("calls = %d, log = %q", calls, log.String())
+	}
+}
+
+func TestAutoVerifyGroupRun_FailureNotRetried(t *testing.T) {
+	_, _, st := setupGroupTest(t)
+	repoID := "repo123456789012"
+	runID := "20260101120000-a111"
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	verify := func(context.Context) (bool, error) {
+		calls++
+		return false, stderrors.New("verify script missing")
+	}
+	var log bytes.Buffer
+	failedReport := ""
+
+	if autoVerifyGroupRun(context.Background(), st, repoID, runID, &failedReport, verify, &log) {
+		t.Error("autoVerifyGroupRun() = true when verify failed to run")
+	}
+	// The same report is not retried on the next tick.
+	autoVerifyGroupRun(context.Background(), st, repoID, runID, &failedReport, verify, &log)
+	if calls != 1 || !strings.Contains(log.String(), "auto-verify failed to run: verify script missing") {
+		t.Fatalf("calls = %d, log = %q", calls, log.String())
+	}
+
+	// A new report is.
+	writeRunnerStatus(t, meta.WorktreePath, "ready_for_review", "fixed it again", time.Now().Add(time.Minute))
+	autoVerifyGroupRun(context.Background(), st, repoID, runID, &failedReport, verify, &log)
+	if calls != 2 {
+		t.Errorf("calls after a new report = %d, want 2", calls)
+	}
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
+	"github.com/NielsdaWheelz/agency/internal/lifecycle"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// HeadlessSuperviseOpts holds options for the hidden headless supervisor command.
+type HeadlessSuperviseOpts struct {
+	// DataDir is the resolved AGENCY_DATA_DIR of the spawning process.
+	DataDir string
+
+	// RepoID and RunID identify the run to supervise.
+	RepoID string
+	RunID  string
+}
+
+// HeadlessSupervise runs the headless runner for a run and blocks until it exits.
+// It is spawned detached by `agency run --headless`; the ready line is written to stdout.
+func HeadlessSupervise(fsys fs.FS, opts HeadlessSuperviseOpts, stdout io.Writer) error {
+	if opts.DataDir == "" || opts.RepoID == "" || opts.RunID == "" {
+		return errors.New(errors.EUsage, "--data-dir, --repo-id and --run-id are required")
+	}
+
+	st := store.NewStore(fsys, opts.DataDir, time.Now)
+	err := headless.Supervise(st, opts.RepoID, opts.RunID, stdout)
+
+	// The run's slot is free: start the next queued run, if any
+	kickQueue(context.Background(), agencyexec.NewRealRunner(), fsys, opts.DataDir)
+	return err
+}
+
+// RunnerExitedOpts holds options for the hidden runner-exited command.
+type RunnerExitedOpts struct {
+	// DataDir is the resolved AGENCY_DATA_DIR of the process that started the session.
+	DataDir string
+
+	// RepoID and RunID identify the run whose runner exited.
+	RepoID string
+	RunID  string
+
+	// Status is the runner's shell exit status.
+	Status int
+}
+
+// RunnerExited records a tmux run's runner exit in meta.json.
+// It is called by the session wrapper script when the runner exits, and then
+// starts the next queued run, if any.
+func RunnerExited(fsys fs.FS, opts RunnerExitedOpts) error {
+	if opts.DataDir == "" || opts.RepoID == "" || opts.RunID == "" {
+		return errors.New(errors.EUsage, "--data-dir, --repo-id and --run-id are required")
+	}
+
+	st := store.NewStore(fsys, opts.DataDir, time.Now)
+	if err := lifecycle.RecordRunnerExit(st, opts.RepoID, opts.RunID, opts.Status); err != nil {
+		return err
+	}
+
+	// The run's slot is free: start the next queued run, if any
+	kickQueue(context.Background(), agencyexec.NewRealRunner(), fsys, opts.DataDir)
+	return nil
+}
@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/render"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// writeShowHistory writes the runner status timeline of a run (show --history).
+func writeShowHistory(record *store.RunRecord, eventsPath string, jsonOutput bool, now time.Time, stdout io.Writer) error {
+	evs, err := readStatusEvents(eventsPath)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to read events.jsonl", err)
+	}
+
+	end := now
+	if record.Meta.Archive != nil && record.Meta.Archive.ArchivedAt != "" {
+		if t, err := time.Parse(time.RFC3339, record.Meta.Archive.ArchivedAt); err == nil {
+			end = t
+		}
+	} else if record.Meta.RunnerExitedAt != "" {
+		if t, err := time.Parse(time.RFC3339, record.Meta.RunnerExitedAt); err == nil {
+			end = t
+		}
+	}
+
+	h := buildStatusHistory(evs, end)
+	h.RunID = record.RunID
+	h.Name = record.Name
+
+	if jsonOutput {
+		return render.WriteStatusHistoryJSON(stdout, h)
+	}
+	render.WriteStatusHistoryHuman(stdout, h)
+	return nil
+}
+
+// readStatusEvents returns the runner_status_changed events in eventsPath,
+// oldest first. Unparseable lines are skipped; a missing file has none.
+func readStatusEvents(eventsPath string) ([]events.Event, error) {
+	f, err := os.Open(eventsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var evs []events.Event
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e events.Event
+		if err := json.Unmarshal(line, &e); err != nil || e.Event != "runner_status_changed" {
+			continue
+		}
+		evs = append(evs, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	// Observed reports are dated by their updated_at, so they can be
+	// appended after later ones
+	sort.SliceStable(evs, func(i, j int) bool { return evs[i].Timestamp < evs[j].Timestamp })
+	return evs, nil
+}
+
+// buildStatusHistory computes the timeline and per-status totals from
+// runner_status_changed events (oldest first). The last entry lasts until end.
+func buildStatusHistory(evs []events.Event, end time.Time) *render.StatusHistory {
+	h := &render.StatusHistory{
+		EndedAt: end.UTC().Format(time.RFC3339),
+		Entries: []render.StatusHistoryEntry{},
+		Totals:  []render.StatusTotal{},
+	}
+
+	var times []time.Time
+	for _, e := range evs {
+		at, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		times = append(times, at)
+		h.Entries = append(h.Entries, render.StatusHistoryEntry{
+			At:             at.UTC().Format(time.RFC3339),
+			Status:         dataString(e.Data, "status"),
+			PreviousStatus: dataString(e.Data, "previous_status"),
+			Summary:        dataString(e.Data, "summary"),
+			Questions:      dataStrings(e.Data, "questions"),
+			Source:         dataString(e.Data, "source"),
+		})
+	}
+
+	totals := map[string]int{}
+	asked := map[string]bool{}
+	prevStatus := ""
+	for i := range h.Entries {
+		entry := &h.Entries[i]
+		until := end
+		if i+1 < len(times) {
+			until = times[i+1]
+		}
+		if d := until.Sub(times[i]); d > 0 {
+			entry.DurationSeconds = int64(d / time.Second)
+		}
+
+		idx, ok := totals[entry.Status]
+		if !ok {
+			idx = len(h.Totals)
+			totals[entry.Status] = idx
+			h.Totals = append(h.Totals, render.StatusTotal{Status: entry.Status})
+		}
+		h.Totals[idx].DurationSeconds += entry.DurationSeconds
+		if entry.Status != prevStatus {
+			h.Totals[idx].Entries++
+		}
+		prevStatus = entry.Status
+
+		if entry.Status == string(runnerstatus.StatusNeedsInput) {
+			for _, q := range entry.Questions {
+				if !asked[q] {
+					asked[q] = true
+					h.QuestionsAsked++
+				}
+			}
+		}
+	}
+	return h
+}
+
+// dataString returns the string value of key in event data ("" if absent).
+func dataString(data map[string]any, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
+
+// dataStrings returns the string list value of key in event data (never nil).
+func dataStrings(data map[string]any, key string) []string {
+	out := []string{}
+	list, _ := data[key].([]any)
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -25,17 +25,21 @@ type InitOpts struct {
 	RepoPath    string
 	NoGitignore bool
 	Force       bool
+	// MCP registers `agency mcp` in the repo's .mcp.json. An existing
+	// agency.json is kept instead of being an error.
+	MCP bool
 }
 
 // InitResult holds the result of the init command for output formatting.
 type InitResult struct {
 	RepoRoot        string
-	AgencyJSONState string // "created" or "overwritten"
+	AgencyJSONState string // "created", "overwritten" or "exists"
 	ScriptsCreated  []string
 	GitignoreState  scaffold.GitignoreResult
 	UserConfigPath  string
 	UserConfigState string // "created" or "exists"
 	ClaudeMDState   string // "created" or "exists"
+	MCPConfigState  string // "created", "updated", "unchanged" or "" (not requested)
 }
 
 // Init implements the `agency init` command.
@@ -96,8 +100,8 @@ func Init(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 		return errors.Wrap(errors.ENoRepo, "failed to check agency.json", err)
 	}
 
-	// If exists and not --force, error
-	if agencyJSONExists && !opts.Force {
+	// If exists and not --force, error (--mcp keeps it)
+	if agencyJSONExists && !opts.Force && !opts.MCP {
 		return errors.New(errors.EAgencyJSONExists, "agency.json already exists; use --force to overwrite")
 	}
 
@@ -105,11 +109,16 @@ func Init(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 	agencyJSONState := "created"
 	if agencyJSONExists {
 		agencyJSONState = "overwritten"
+		if !opts.Force {
+			agencyJSONState = "exists"
+		}
 	}
 
 	// Write agency.json atomically
-	if err := fs.WriteFileAtomic(fsys, agencyJSONPath, []byte(scaffold.AgencyJSONTemplate), 0644); err != nil {
-		return errors.Wrap(errors.ENoRepo, "failed to write agency.json", err)
+	if agencyJSONState != "exists" {
+		if err := fs.WriteFileAtomic(fsys, agencyJSONPath, []byte(scaffold.AgencyJSONTemplate), 0644); err != nil {
+			return errors.Wrap(errors.ENoRepo, "failed to write agency.json", err)
+		}
 	}
 
 	// Create stub scripts (never overwrite existing)
@@ -140,6 +149,16 @@ func Init(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 		claudeMDState = "created"
 	}
 
+	// Register the MCP server (agency mcp) for runners
+	var mcpConfigState string
+	if opts.MCP {
+		state, err := scaffold.EnsureMCPConfig(fsys, repoRoot.Path)
+		if err != nil {
+			return errors.Wrap(errors.ENoRepo, "failed to register MCP server in "+scaffold.MCPConfigFileName, err)
+		}
+		mcpConfigState = string(state)
+	}
+
 	// Build result
 	result := InitResult{
 		RepoRoot:        repoRoot.Path,
@@ -149,6 +168,7 @@ func Init(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 		UserConfigPath:  userConfigPath,
 		UserConfigState: userConfigState,
 		ClaudeMDState:   claudeMDState,
+		MCPConfigState:  mcpConfigState,
 	}
 
 	// Output result
@@ -179,4 +199,7 @@ func writeInitOutput(w io.Writer, r InitResult) {
 
 	_, _ = fmt.Fprintf(w, "gitignore: %s\n", r.GitignoreState)
 	_, _ = fmt.Fprintf(w, "claude_md: %s\n", r.ClaudeMDState)
+	if r.MCPConfigState != "" {
+		_, _ = fmt.Fprintf(w, "mcp_config: %s\n", r.MCPConfigState)
+	}
 }
@@ -3,6 +3,7 @@ package commands
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -494,3 +495,54 @@ func TestInit_ScriptsNotCreatedIfExist(t *testing.T) {
 		t.Errorf("output should say 'scripts_created: none': %s", output)
 	}
 }
+
+func TestInit_MCP(t *testing.T) {
+	repoRoot, _ := setupTempGitRepo(t)
+	agencyJSON := filepath.Join(repoRoot, "agency.json")
+	mcpConfig := filepath.Join(repoRoot, ".mcp.json")
+	if err := os.WriteFile(agencyJSON, []byte(`{"version": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mcpConfig, []byte(`{"mcpServers": {"docs": {"command": "docs-mcp"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cr := &stubRunner{repoRoot: repoRoot, exitCode: 0}
+	var stdout, stderr bytes.Buffer
+	if err := Init(context.Background(), cr, fs.NewRealFS(), repoRoot, InitOpts{MCP: true}, &stdout, &stderr); err != nil {
+		t.Fatalf("Init --mcp failed: %v", err)
+	}
+	output := stdout.String()
+	if !strings.Contains(output, "agency_json: exists") || !strings.Contains(output, "mcp_config: updated") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+	if data, _ := os.ReadFile(agencyJSON); string(data) != `{"version": 1}` {
+		t.Errorf("existing agency.json was modified: %s", data)
+	}
+
+	var cfg struct {
+		MCPServers map[string]struct {
+			Command string   `json:"command"`
+			Args    []string `json:"args"`
+		} `json:"mcpServers"`
+	}
+	data, _ := os.ReadFile(mcpConfig)
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf(".mcp.json is invalid: %v", err)
+	}
+	if cfg.MCPServers["docs"].Command != "docs-mcp" {
+		t.Error("existing MCP server was dropped")
+	}
+	if got := cfg.MCPServers[scaffold.MCPServerName]; got.Command != "agency" || len(got.Args) != 1 || got.Args[0] != "mcp" {
+		t.Errorf("agency server = %+v, want agency mcp", got)
+	}
+
+	// Idempotent
+	stdout.Reset()
+	if err := Init(context.Background(), cr, fs.NewRealFS(), repoRoot, InitOpts{MCP: true}, &stdout, &stderr); err != nil {
+		t.Fatalf("second Init --mcp failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "mcp_config: unchanged") {
+		t.Errorf("second run output:\n%s", stdout.String())
+	}
+}
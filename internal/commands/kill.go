@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	"github.com/NielsdaWheelz/agency/internal/events"
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
 	"github.com/NielsdaWheelz/agency/internal/store"
 	"github.com/NielsdaWheelz/agency/internal/tmux"
 )
@@ -25,7 +27,8 @@ type KillOpts struct {
 	RepoPath string
 }
 
-// Kill kills the tmux session for a run. Workspace remains intact.
+// Kill kills the tmux session for a run (SIGTERM to the runner for headless
+// runs). Workspace remains intact.
 // Works from any directory; resolves runs globally.
 func Kill(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts KillOpts, stdout, stderr io.Writer) error {
 	// Create real tmux client
@@ -60,6 +63,11 @@ func KillWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 	// Create store for later operations
 	st := store.NewStore(fsys, rctx.DataDir, nil)
 
+	// Headless runs have no session: terminate the runner's process group
+	if meta, err := st.ReadMeta(repoID, runID); err == nil && meta.Headless != nil {
+		return killHeadlessRunner(st, meta, stderr)
+	}
+
 	// Compute session name from run_id (source of truth from tmux.SessionName)
 	sessionName := tmux.SessionName(runID)
 
@@ -98,3 +106,29 @@ func KillWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 
 	return nil
 }
+
+// killHeadlessRunner sends SIGTERM to a headless runner and its children.
+func killHeadlessRunner(st *store.Store, meta *store.RunMeta, stderr io.Writer) error {
+	signaled, err := headless.SignalRunner(meta, syscall.SIGTERM)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to terminate headless runner", err)
+	}
+	if !signaled {
+		// Runner not running - no-op, exit 0
+		_, _ = fmt.Fprintf(stderr, "runner not running for %s\n", meta.RunID)
+		return nil
+	}
+
+	eventErr := events.AppendEvent(st.EventsPath(meta.RepoID, meta.RunID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		RepoID:        meta.RepoID,
+		RunID:         meta.RunID,
+		Event:         "kill_runner",
+		Data:          events.SignalRunnerData(meta.RunnerPID, "SIGTERM"),
+	})
+	if eventErr != nil {
+		return errors.Wrap(errors.EPersistFailed, "failed to append kill_runner event", eventErr)
+	}
+	return nil
+}
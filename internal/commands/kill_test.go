@@ -130,3 +130,24 @@ func TestKill_KillSessionError(t *testing.T) {
 		t.Errorf("error code = %q, want %q", code, errors.ETmuxFailed)
 	}
 }
+
+func TestKill_Headless(t *testing.T) {
+	runID := "20260110120000-a3f2"
+	repoDir, dataDir, repoID, cr, fsys := setupStopTestEnv(t, runID, true)
+	st := store.NewStore(fsys, dataDir, nil)
+	_, done := startHeadlessTestRunner(t, st, repoID, runID)
+
+	fakeTmux := &fakeTmuxClient{}
+	var stdout, stderr bytes.Buffer
+	if err := KillWithTmux(context.Background(), cr, fsys, fakeTmux, repoDir, KillOpts{RunID: runID}, &stdout, &stderr); err != nil {
+		t.Fatalf("Kill() error = %v, want nil", err)
+	}
+	waitExited(t, done)
+	if len(fakeTmux.killCalls) != 0 {
+		t.Error("tmux used for a headless run")
+	}
+	evs := readTestEvents(t, st, repoID, runID, "kill_runner")
+	if len(evs) != 1 || evs[0].Data["signal"] != "SIGTERM" {
+		t.Errorf("kill_runner events = %+v", evs)
+	}
+}
@@ -0,0 +1,21 @@
+package commands
+
+import (
+	"github.com/NielsdaWheelz/agency/internal/lifecycle"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// reconcileRunnerExit records the runner exit for a tmux run whose session is
+// missing from tmuxSessions, updating rec.Meta in place. Best-effort: errors are
+// ignored so read paths never fail because of reconciliation.
+func reconcileRunnerExit(st *store.Store, rec *store.RunRecord, tmuxSessions map[string]bool) {
+	if rec.Broken || rec.Meta == nil {
+		return
+	}
+	if !lifecycle.NeedsRunnerExit(rec.Meta, tmuxSessions[rec.Meta.TmuxSessionName]) {
+		return
+	}
+	if updated, err := lifecycle.ReconcileRunnerExit(st, rec.RepoID, rec.RunID, false); err == nil && updated != nil {
+		rec.Meta = updated
+	}
+}
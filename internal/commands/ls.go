@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -12,6 +13,7 @@ import (
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
 	"github.com/NielsdaWheelz/agency/internal/git"
+	"github.com/NielsdaWheelz/agency/internal/headless"
 	"github.com/NielsdaWheelz/agency/internal/identity"
 	"github.com/NielsdaWheelz/agency/internal/paths"
 	"github.com/NielsdaWheelz/agency/internal/render"
@@ -34,11 +36,19 @@ type LSOpts struct {
 
 	// JSON outputs machine-readable JSON.
 	JSON bool
+
+	// readOnly derives statuses without recording anything or kicking the
+	// queue (the API's GET /v1/runs).
+	readOnly bool
 }
 
 // LS executes the agency ls command.
 // Lists runs with sane defaults and stable JSON output.
-// This is a read-only command: no state files are mutated.
+// Listing derives each run's status and records what it observes on the way:
+// runner exits (meta.json), runner status history (events.jsonl) and newly
+// discovered runner sessions. Notification hooks are not delivered here; the
+// run monitor and the daemon do that. If runs are queued, it kicks the
+// queue processor in the background. With opts.readOnly nothing is written.
 func LS(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts LSOpts, stdout, stderr io.Writer) error {
 	// Resolve data directory
 	homeDir, err := os.UserHomeDir()
@@ -88,12 +98,24 @@ func LS(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string
 	}
 
 	// Get tmux session set (single call)
-	tmuxSessions := getTmuxSessions(ctx, cr)
+	tmuxSessions, tmuxOK := listTmuxSessions(ctx, cr)
+	var st *store.Store
+	if !opts.readOnly {
+		st = store.NewStore(fsys, dataDir, nil)
+	}
 
 	// Convert records to summaries with snapshot data
 	summaries := make([]render.RunSummary, 0, len(records))
 	for _, rec := range records {
-		summary := recordToSummary(rec, tmuxSessions, fsys)
+		if st != nil {
+			// Record runner exits for runs whose tmux session is gone (best-effort)
+			if tmuxOK {
+				reconcileRunnerExit(st, &rec, tmuxSessions)
+			}
+			observeRunnerStatus(st, rec, time.Now())
+		}
+
+		summary := recordToSummary(st, rec, tmuxSessions, fsys)
 
 		// Filter archived unless --all
 		if summary.Archived && !opts.All {
@@ -106,6 +128,11 @@ func LS(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string
 	// Sort: created_at descending (newest first), broken runs last
 	sortSummaries(summaries)
 
+	// A slot may have freed up since the runs were queued
+	if st != nil {
+		kickQueue(ctx, cr, fsys, dataDir)
+	}
+
 	// Output
 	if opts.JSON {
 		return render.WriteLSJSON(stdout, summaries)
@@ -129,7 +156,9 @@ func LS(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string
 }
 
 // recordToSummary converts a RunRecord to a RunSummary with snapshot data.
-func recordToSummary(rec store.RunRecord, tmuxSessions map[string]bool, fsys fs.FS) render.RunSummary {
+// A runner session discovered for the activity signal is recorded through st
+// (nil = never write).
+func recordToSummary(st *store.Store, rec store.RunRecord, tmuxSessions map[string]bool, fsys fs.FS) render.RunSummary {
 	summary := render.RunSummary{
 		RunID:  rec.RunID,
 		RepoID: rec.RepoID,
@@ -193,7 +222,6 @@ func recordToSummary(rec store.RunRecord, tmuxSessions map[string]bool, fsys fs.
 
 	// Check worktree presence
 	summary.WorktreePresent = dirExists(meta.WorktreePath)
-	summary.Archived = !summary.WorktreePresent
 
 	// Load runner status and compute stall detection (only if worktree present)
 	var runnerStatus *runnerstatus.RunnerStatus
@@ -205,6 +233,7 @@ func recordToSummary(rec store.RunRecord, tmuxSessions map[string]bool, fsys fs.
 			if rs.Validate() == nil {
 				runnerStatus = rs
 				summary.Summary = &rs.Summary
+				summary.StatusSince = runnerStatusSince(rec, rs)
 			}
 		}
 
@@ -215,6 +244,11 @@ func recordToSummary(rec store.RunRecord, tmuxSessions map[string]bool, fsys fs.
 		if !modTime.IsZero() {
 			signals.StatusFileModTime = &modTime
 		}
+		if summary.TmuxActive {
+			if act := runActivity(st, rec, false); act != nil && !act.LastActivityAt.IsZero() {
+				signals.LastActivityAt = &act.LastActivityAt
+			}
+		}
 		result := watchdog.CheckStallWithDefault(signals)
 		stallResult = &result
 
@@ -231,13 +265,28 @@ func recordToSummary(rec store.RunRecord, tmuxSessions map[string]bool, fsys fs.
 		WorktreePresent: summary.WorktreePresent,
 		RunnerStatus:    runnerStatus,
 		StallResult:     stallResult,
+		HeadlessActive:  headless.IsActive(meta),
 	}
 	derived := status.Derive(meta, snapshot)
 	summary.DerivedStatus = derived.DerivedStatus
+	summary.Archived = derived.Archived
 
 	return summary
 }
 
+// runnerStatusSince returns when the run entered its current runner status,
+// from the run's status history (nil if the report is not recorded yet).
+func runnerStatusSince(rec store.RunRecord, rs *runnerstatus.RunnerStatus) *string {
+	if rec.RunDir == "" {
+		return nil
+	}
+	state, err := runnerstatus.LoadHistoryState(filepath.Join(rec.RunDir, store.RunnerStatusStateFileName))
+	if err != nil || state == nil || state.Status != rs.Status || state.Since == "" {
+		return nil
+	}
+	return &state.Since
+}
+
 // formatStalledDuration formats a stall duration for display (e.g., "45m", "2h").
 func formatStalledDuration(d time.Duration) string {
 	if d < time.Hour {
@@ -255,17 +304,25 @@ func formatStalledDuration(d time.Duration) string {
 // Returns empty map if tmux is not available or server not running.
 // This is a single call per ls invocation.
 func getTmuxSessions(ctx context.Context, cr agencyexec.CommandRunner) map[string]bool {
-	sessions := make(map[string]bool)
+	sessions, _ := listTmuxSessions(ctx, cr)
+	return sessions
+}
+
+// listTmuxSessions returns the set of tmux session names and whether the set is
+// authoritative. ok is false if tmux could not be executed (e.g. not installed),
+// in which case a missing session says nothing about the runner.
+func listTmuxSessions(ctx context.Context, cr agencyexec.CommandRunner) (sessions map[string]bool, ok bool) {
+	sessions = make(map[string]bool)
 
 	result, err := cr.Run(ctx, "tmux", []string{"list-sessions", "-F", "#{session_name}"}, agencyexec.RunOpts{})
 	if err != nil {
 		// tmux not installed or execution failed
-		return sessions
+		return sessions, false
 	}
 
 	if result.ExitCode != 0 {
 		// tmux server not running or no sessions
-		return sessions
+		return sessions, true
 	}
 
 	// Parse session names
@@ -277,7 +334,7 @@ func getTmuxSessions(ctx context.Context, cr agencyexec.CommandRunner) map[strin
 		}
 	}
 
-	return sessions
+	return sessions, true
 }
 
 // sortSummaries sorts summaries by created_at descending (newest first).
@@ -509,7 +509,7 @@ func TestLS_IntegrationWithFakeData(t *testing.T) {
 	tmuxSessions := make(map[string]bool)
 	summaries := make([]render.RunSummary, len(records))
 	for i, rec := range records {
-		summaries[i] = recordToSummary(rec, tmuxSessions, nil)
+		summaries[i] = recordToSummary(nil, rec, tmuxSessions, nil)
 	}
 
 	// Sort
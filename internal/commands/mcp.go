@@ -0,0 +1,324 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/mcp"
+	"github.com/NielsdaWheelz/agency/internal/report"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/version"
+)
+
+// mcpInstructions is sent to the client on initialize.
+const mcpInstructions = `This server connects you to agency, which supervises the run you are working in.
+Call set_status at milestones (working, needs_input, blocked, ready_for_review) instead of editing .agency/state/runner_status.json by hand.
+Use ask_question when you need an answer from the user, write_report before ready_for_review, and run_verify to run the repo's verify script.`
+
+// MCP serves the agency Model Context Protocol tools on stdin/stdout until
+// stdin is closed. Tools act on the run whose worktree contains cwd; the run
+// is resolved on every call, so the server starts even outside a worktree and
+// its tools then fail with E_NOT_IN_RUN_WORKTREE.
+func MCP(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, stdin io.Reader, stdout, stderr io.Writer) error {
+	t := &mcpTools{cr: cr, fsys: fsys, cwd: cwd, now: time.Now}
+	server := &mcp.Server{
+		Name:         "agency",
+		Version:      version.FullVersion(),
+		Instructions: mcpInstructions,
+		Tools:        t.tools(),
+	}
+	if err := server.Serve(ctx, stdin, stdout); err != nil {
+		return errors.Wrap(errors.EInternal, "mcp server failed", err)
+	}
+	return nil
+}
+
+// mcpTools implements the agency MCP tools for one worktree.
+type mcpTools struct {
+	cr   agencyexec.CommandRunner
+	fsys fs.FS
+	cwd  string
+	now  func() time.Time
+}
+
+// stringList is the JSON schema for a list of strings.
+var stringList = map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+
+func (t *mcpTools) tools() []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name: "set_status",
+			Description: "Report your current status to agency. Required fields depend on status: " +
+				"needs_input needs questions, blocked needs blockers, ready_for_review needs how_to_test.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"status": map[string]any{
+						"type": "string",
+						"enum": []string{string(runnerstatus.StatusWorking), string(runnerstatus.StatusNeedsInput), string(runnerstatus.StatusBlocked), string(runnerstatus.StatusReadyForReview)},
+					},
+					"summary":     map[string]any{"type": "string", "description": "one line describing the current state"},
+					"questions":   stringList,
+					"blockers":    stringList,
+					"how_to_test": map[string]any{"type": "string"},
+					"risks":       stringList,
+				},
+				"required":             []string{"status", "summary"},
+				"additionalProperties": false,
+			},
+			Handler: t.setStatus,
+		},
+		{
+			Name:        "ask_question",
+			Description: "Ask the user a question and mark the run needs_input. Questions already open are kept.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"question": map[string]any{"type": "string"},
+					"summary":  map[string]any{"type": "string", "description": "optional; defaults to the open summary or the question"},
+				},
+				"required":             []string{"question"},
+				"additionalProperties": false,
+			},
+			Handler: t.askQuestion,
+		},
+		{
+			Name:        "write_report",
+			Description: "Write .agency/report.md (markdown, used as the PR body). Include ## Summary and ## How to test sections.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"content": map[string]any{"type": "string"},
+					"append":  map[string]any{"type": "boolean", "description": "append instead of replacing the report"},
+				},
+				"required":             []string{"content"},
+				"additionalProperties": false,
+			},
+			Handler: t.writeReport,
+		},
+		{
+			Name:        "run_verify",
+			Description: "Run the repo's verify script for this run and record the result. Returns the verify output.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"timeout": map[string]any{"type": "string", "description": "script timeout, e.g. 10m (default from agency.json)"},
+				},
+				"additionalProperties": false,
+			},
+			Handler: t.runVerify,
+		},
+		{
+			Name:        "get_run_info",
+			Description: "Show this run as agency sees it: name, branch, parent, status, PR and paths (agency show --json).",
+			InputSchema: map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{},
+				"additionalProperties": false,
+			},
+			Handler: t.getRunInfo,
+		},
+	}
+}
+
+// run resolves the run whose worktree contains cwd, and the data dir.
+func (t *mcpTools) run() (string, *store.RunRecord, error) {
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		return "", nil, err
+	}
+	rec, err := resolveWorktreeRun(dataDir, t.cwd)
+	return dataDir, rec, err
+}
+
+// decodeArgs decodes tool arguments strictly.
+func decodeArgs(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errors.New(errors.EUsage, "invalid arguments: "+err.Error())
+	}
+	return nil
+}
+
+func (t *mcpTools) setStatus(ctx context.Context, args json.RawMessage) (string, error) {
+	var in struct {
+		Status    runnerstatus.Status `json:"status"`
+		Summary   string              `json:"summary"`
+		Questions []string            `json:"questions"`
+		Blockers  []string            `json:"blockers"`
+		HowToTest string              `json:"how_to_test"`
+		Risks     []string            `json:"risks"`
+	}
+	if err := decodeArgs(args, &in); err != nil {
+		return "", err
+	}
+	dataDir, rec, err := t.run()
+	if err != nil {
+		return "", err
+	}
+	rs := &runnerstatus.RunnerStatus{
+		Status:    in.Status,
+		Summary:   in.Summary,
+		Questions: in.Questions,
+		Blockers:  in.Blockers,
+		HowToTest: in.HowToTest,
+		Risks:     in.Risks,
+	}
+	if err := saveRunnerStatus(t.fsys, dataDir, rec, rs, runnerStatusSourceMCP, t.now()); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("status set to %s for run %s", rs.Status, rec.Meta.Name), nil
+}
+
+func (t *mcpTools) askQuestion(ctx context.Context, args json.RawMessage) (string, error) {
+	var in struct {
+		Question string `json:"question"`
+		Summary  string `json:"summary"`
+	}
+	if err := decodeArgs(args, &in); err != nil {
+		return "", err
+	}
+	in.Question = strings.TrimSpace(in.Question)
+	if in.Question == "" {
+		return "", errors.New(errors.EUsage, "question is required")
+	}
+	dataDir, rec, err := t.run()
+	if err != nil {
+		return "", err
+	}
+
+	rs := &runnerstatus.RunnerStatus{Status: runnerstatus.StatusNeedsInput, Summary: in.Summary}
+	current, err := runnerstatus.Load(rec.Meta.WorktreePath)
+	if err != nil {
+		return "", errors.Wrap(errors.EInternal, "failed to read runner_status.json", err)
+	}
+	if current != nil {
+		rs.HowToTest = current.HowToTest
+		rs.Risks = current.Risks
+		// Keep questions that are still open
+		if current.Status == runnerstatus.StatusNeedsInput {
+			rs.Questions = append(rs.Questions, current.Questions...)
+			if rs.Summary == "" {
+				rs.Summary = current.Summary
+			}
+		}
+	}
+	if !containsString(rs.Questions, in.Question) {
+		rs.Questions = append(rs.Questions, in.Question)
+	}
+	if rs.Summary == "" {
+		rs.Summary = in.Question
+	}
+
+	if err := saveRunnerStatus(t.fsys, dataDir, rec, rs, runnerStatusSourceMCP, t.now()); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("run %s is needs_input with %d open question(s); the user answers with `agency answer %s`",
+		rec.Meta.Name, len(rs.Questions), rec.Meta.Name), nil
+}
+
+func (t *mcpTools) writeReport(ctx context.Context, args json.RawMessage) (string, error) {
+	var in struct {
+		Content string `json:"content"`
+		Append  bool   `json:"append"`
+	}
+	if err := decodeArgs(args, &in); err != nil {
+		return "", err
+	}
+	_, rec, err := t.run()
+	if err != nil {
+		return "", err
+	}
+
+	reportPath := filepath.Join(rec.Meta.WorktreePath, ".agency", "report.md")
+	content := in.Content
+	if in.Append {
+		existing, err := t.fsys.ReadFile(reportPath)
+		if err != nil && !os.IsNotExist(err) {
+			return "", errors.Wrap(errors.EInternal, "failed to read report", err)
+		}
+		if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+			existing = append(existing, '\n')
+		}
+		content = string(existing) + content
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if err := t.fsys.MkdirAll(filepath.Dir(reportPath), 0o755); err != nil {
+		return "", errors.Wrap(errors.EInternal, "failed to create .agency directory", err)
+	}
+	if err := fs.WriteFileAtomic(t.fsys, reportPath, []byte(content), 0o644); err != nil {
+		return "", errors.Wrap(errors.EInternal, "failed to write report", err)
+	}
+
+	msg := fmt.Sprintf("wrote .agency/report.md (%d bytes)", len(content))
+	if res := report.CheckCompleteness(content); !res.Complete {
+		msg += "; missing sections: " + strings.Join(res.MissingSections, ", ")
+	}
+	return msg, nil
+}
+
+func (t *mcpTools) runVerify(ctx context.Context, args json.RawMessage) (string, error) {
+	var in struct {
+		Timeout string `json:"timeout"`
+	}
+	if err := decodeArgs(args, &in); err != nil {
+		return "", err
+	}
+	_, rec, err := t.run()
+	if err != nil {
+		return "", err
+	}
+	opts := VerifyOpts{RunID: rec.RunID}
+	if in.Timeout != "" {
+		d, err := time.ParseDuration(in.Timeout)
+		if err != nil || d <= 0 {
+			return "", errors.New(errors.EUsage, "timeout must be a positive duration (e.g. 10m)")
+		}
+		opts.Timeout = d
+	}
+
+	var out bytes.Buffer
+	if err := Verify(ctx, t.cr, t.fsys, t.cwd, opts, &out, &out); err != nil {
+		return "", fmt.Errorf("%w\n\n%s", err, strings.TrimSpace(out.String()))
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+func (t *mcpTools) getRunInfo(ctx context.Context, args json.RawMessage) (string, error) {
+	if err := decodeArgs(args, &struct{}{}); err != nil {
+		return "", err
+	}
+	_, rec, err := t.run()
+	if err != nil {
+		return "", err
+	}
+	var out, errOut bytes.Buffer
+	if err := Show(ctx, t.cr, t.fsys, t.cwd, ShowOpts{RunID: rec.RunID, JSON: true}, &out, &errOut); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+)
+
+// setupMCPTest writes a run and returns tools rooted in a subdirectory of
+// its worktree, plus the worktree path.
+func setupMCPTest(t *testing.T) (*mcpTools, string) {
+	t.Helper()
+	st, repoID := setupDaemonTest(t)
+	runID := "20260110120000-a3f2"
+	writeDaemonTestRun(t, st, repoID, runID, "auth-fix")
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd := filepath.Join(meta.WorktreePath, "internal", "auth")
+	if err := os.MkdirAll(cwd, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	return &mcpTools{
+		cr:   &fakeCommandRunner{responses: map[string]fakeResponse{}},
+		fsys: fs.NewRealFS(),
+		cwd:  cwd,
+		now:  func() time.Time { return now },
+	}, meta.WorktreePath
+}
+
+func TestMCPTools_SetStatus(t *testing.T) {
+	tools, worktree := setupMCPTest(t)
+	ctx := context.Background()
+
+	if _, err := tools.setStatus(ctx, json.RawMessage(`{"status":"ready_for_review","summary":"done"}`)); errors.GetCode(err) != errors.EInvalidRunnerStatus {
+		t.Errorf("setStatus() without how_to_test error = %v, want E_INVALID_RUNNER_STATUS", err)
+	}
+	if _, err := tools.setStatus(ctx, json.RawMessage(`{"status":"working","summery":"typo"}`)); errors.GetCode(err) != errors.EUsage {
+		t.Errorf("setStatus() with unknown field error = %v, want E_USAGE", err)
+	}
+	if _, err := os.Stat(runnerstatus.StatusPath(worktree)); !os.IsNotExist(err) {
+		t.Fatal("invalid status was written")
+	}
+
+	if _, err := tools.setStatus(ctx, json.RawMessage(`{"status":"blocked","summary":"no creds","blockers":["missing API key"]}`)); err != nil {
+		t.Fatalf("setStatus() error = %v", err)
+	}
+	rs, err := runnerstatus.Load(worktree)
+	if err != nil || rs == nil {
+		t.Fatalf("Load() = %v, %v", rs, err)
+	}
+	if rs.Status != runnerstatus.StatusBlocked || rs.UpdatedAt != "2026-01-10T12:00:00Z" || rs.SchemaVersion != runnerstatus.SchemaVersion || rs.Questions == nil {
+		t.Errorf("written status = %+v", rs)
+	}
+}
+
+func TestMCPTools_AskQuestion(t *testing.T) {
+	tools, worktree := setupMCPTest(t)
+	ctx := context.Background()
+
+	if _, err := tools.askQuestion(ctx, json.RawMessage(`{"question":"postgres or sqlite?"}`)); err != nil {
+		t.Fatalf("askQuestion() error = %v", err)
+	}
+	if _, err := tools.askQuestion(ctx, json.RawMessage(`{"question":"keep the v1 endpoint?"}`)); err != nil {
+		t.Fatalf("askQuestion() error = %v", err)
+	}
+	rs, _ := runnerstatus.Load(worktree)
+	if rs == nil || rs.Status != runnerstatus.StatusNeedsInput || len(rs.Questions) != 2 || rs.Summary != "postgres or sqlite?" {
+		t.Errorf("status after two questions = %+v, want both questions open", rs)
+	}
+
+	// Leaving needs_input closes the old questions
+	if _, err := tools.setStatus(ctx, json.RawMessage(`{"status":"working","summary":"using postgres"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tools.askQuestion(ctx, json.RawMessage(`{"question":"which port?","summary":"configuring db"}`)); err != nil {
+		t.Fatal(err)
+	}
+	rs, _ = runnerstatus.Load(worktree)
+	if rs == nil || len(rs.Questions) != 1 || rs.Summary != "configuring db" {
+		t.Errorf("status after new question = %+v", rs)
+	}
+}
+
+func TestMCPTools_WriteReport(t *testing.T) {
+	tools, worktree := setupMCPTest(t)
+	ctx := context.Background()
+
+	msg, err := tools.writeReport(ctx, json.RawMessage(`{"content":"## Summary\nadded oauth"}`))
+	if err != nil {
+		t.Fatalf("writeReport() error = %v", err)
+	}
+	if !strings.Contains(msg, "missing sections: how to test") {
+		t.Errorf("writeReport() = %q, want missing section note", msg)
+	}
+	msg, err = tools.writeReport(ctx, json.RawMessage(`{"content":"## How to test\ngo test ./...","append":true}`))
+	if err != nil {
+		t.Fatalf("writeReport(append) error = %v", err)
+	}
+	if strings.Contains(msg, "missing") {
+		t.Errorf("writeReport(append) = %q, want complete report", msg)
+	}
+	data, _ := os.ReadFile(filepath.Join(worktree, ".agency", "report.md"))
+	if string(data) != "## Summary\nadded oauth\n## How to test\ngo test ./...\n" {
+		t.Errorf("report.md = %q", data)
+	}
+}
+
+func TestMCPTools_OutsideWorktree(t *testing.T) {
+	tools, _ := setupMCPTest(t)
+	tools.cwd = t.TempDir()
+	_, err := tools.setStatus(context.Background(), json.RawMessage(`{"status":"working","summary":"x"}`))
+	if errors.GetCode(err) != errors.ENotInRunWorktree {
+		t.Errorf("setStatus() outside a worktree error = %v, want E_NOT_IN_RUN_WORKTREE", err)
+	}
+}
+
+func TestMCP_Stdio(t *testing.T) {
+	_, worktree := setupMCPTest(t)
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"set_status","arguments":{"status":"needs_input","summary":"q"}}}`,
+	}, "\n") + "\n"
+
+	var stdout, stderr bytes.Buffer
+	err := MCP(context.Background(), &fakeCommandRunner{responses: map[string]fakeResponse{}}, fs.NewRealFS(), worktree, strings.NewReader(in), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("MCP() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d responses, want 3:\n%s", len(lines), stdout.String())
+	}
+	for _, name := range []string{"set_status", "ask_question", "write_report", "run_verify", "get_run_info"} {
+		if !strings.Contains(lines[1], `"name":"`+name+`"`) {
+			t.Errorf("tools/list missing %s", name)
+		}
+	}
+	if !strings.Contains(lines[2], `"isError":true`) || !strings.Contains(lines[2], "questions[] is required") {
+		t.Errorf("invalid set_status response = %s", lines[2])
+	}
+}
+
+func TestMCPTools_GetRunInfo(t *testing.T) {
+	tools, _ := setupMCPTest(t)
+	out, err := tools.getRunInfo(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("getRunInfo() error = %v", err)
+	}
+	if !strings.Contains(out, `"run_id": "20260110120000-a3f2"`) || !strings.Contains(out, `"name": "auth-fix"`) {
+		t.Errorf("getRunInfo() = %s", out)
+	}
+}
@@ -61,6 +61,10 @@ type MergeOpts struct {
 
 	// TmuxClient is an injectable tmux client for testing. If nil, uses real tmux client.
 	TmuxClient tmux.Client
+
+	// ConfirmFromStdin reads the typed confirmation from stdin without
+	// requiring a TTY (agency serve passes the client's confirmation).
+	ConfirmFromStdin bool
 }
 
 // ghPRViewFull represents the full JSON output of gh pr view with all required fields.
@@ -87,7 +91,7 @@ func Merge(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, o
 	}
 
 	// Check for interactive TTY (stdin and stderr must be TTYs)
-	if !isInteractive() {
+	if !opts.ConfirmFromStdin && !isInteractive() {
 		return errors.New(errors.ENotInteractive, "merge requires an interactive terminal; stdin and stderr must be TTYs")
 	}
 
@@ -411,12 +415,7 @@ func Merge(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, o
 	appendMergeEvent(eventsPath, repoID, meta.RunID, "gh_merge_finished", events.GHMergeFinishedData(true, pr.Number, pr.URL))
 
 	// === Set merged_at ===
-	_ = st.UpdateMeta(repoID, meta.RunID, func(m *store.RunMeta) {
-		if m.Archive == nil {
-			m.Archive = &store.RunMetaArchive{}
-		}
-		m.Archive.MergedAt = time.Now().UTC().Format(time.RFC3339)
-	})
+	markMerged(ctx, cr, fsys, st, repoID, meta.RunID)
 
 	// === Run archive pipeline ===
 	return runArchivePipeline(ctx, cr, fsys, st, meta, repoID, ghRepo, opts, stdout, stderr, eventsPath, dataDir, true)
@@ -449,7 +448,16 @@ func handleAlreadyMergedPR(ctx context.Context, cr exec.CommandRunner, fsys fs.F
 	appendMergeEvent(eventsPath, repoID, meta.RunID, "merge_confirmed", events.MergeConfirmedData())
 
 	// Set merged_at if missing
-	_ = st.UpdateMeta(repoID, meta.RunID, func(m *store.RunMeta) {
+	markMerged(ctx, cr, fsys, st, repoID, meta.RunID)
+
+	// Run archive pipeline
+	return runArchivePipeline(ctx, cr, fsys, st, meta, repoID, ghRepo, opts, stdout, stderr, eventsPath, dataDir, false)
+}
+
+// markMerged sets merged_at (unless already set) and sends the pr_merged
+// notification while the run is still live, before the archive pipeline.
+func markMerged(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, st *store.Store, repoID, runID string) {
+	_ = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
 		if m.Archive == nil {
 			m.Archive = &store.RunMetaArchive{}
 		}
@@ -457,9 +465,9 @@ func handleAlreadyMergedPR(ctx context.Context, cr exec.CommandRunner, fsys fs.F
 			m.Archive.MergedAt = time.Now().UTC().Format(time.RFC3339)
 		}
 	})
-
-	// Run archive pipeline
-	return runArchivePipeline(ctx, cr, fsys, st, meta, repoID, ghRepo, opts, stdout, stderr, eventsPath, dataDir, false)
+	if userCfg, err := loadUserConfig(fsys); err == nil {
+		notifyRun(ctx, cr, fsys, st, userCfg.Notify, repoID, runID)
+	}
 }
 
 // executeGHMerge runs gh pr merge and captures output to merge.log.
@@ -10,8 +10,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/NielsdaWheelz/agency/internal/config"
 	"github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/notify"
 	"github.com/NielsdaWheelz/agency/internal/store"
 )
 
@@ -958,3 +960,33 @@ func TestGetOriginURLForMerge(t *testing.T) {
 		t.Errorf("getOriginURLForMerge() = %q, want %q", url, "git@github.com:owner/repo.git")
 	}
 }
+
+func TestMarkMerged_Notifies(t *testing.T) {
+	st, repoID := setupDaemonTest(t)
+	configDir := t.TempDir()
+	t.Setenv("AGENCY_CONFIG_DIR", configDir)
+	cfg := `{"version": 1, "defaults": {"runner": "claude", "editor": "code"}, "notify": {"command": "true", "events": ["pr_merged"]}}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runID := "20260110120000-a3f2"
+	writeDaemonTestRun(t, st, repoID, runID, "auth-fix")
+
+	var sent []notify.Payload
+	orig := sendNotification
+	sendNotification = func(_ context.Context, _ exec.CommandRunner, _ config.UserNotify, p notify.Payload) error {
+		sent = append(sent, p)
+		return nil
+	}
+	defer func() { sendNotification = orig }()
+
+	markMerged(context.Background(), &mergeTestCommandRunner{}, fs.NewRealFS(), st, repoID, runID)
+
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil || meta.Archive == nil || meta.Archive.MergedAt == "" {
+		t.Fatalf("merged_at not set: %+v, %v", meta, err)
+	}
+	if len(sent) != 1 || sent[0].Event != config.NotifyPRMerged {
+		t.Fatalf("sent = %+v, want one pr_merged", sent)
+	}
+}
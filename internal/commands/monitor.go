@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
+	"github.com/NielsdaWheelz/agency/internal/monitor"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+	"github.com/NielsdaWheelz/agency/internal/verifyservice"
+)
+
+// spawnRunMonitor starts the detached run monitor (stubbed in tests).
+var spawnRunMonitor = monitor.Spawn
+
+// monitorAliveInterval is how often the run monitor checks that the runner
+// is still alive.
+var monitorAliveInterval = 15 * time.Second
+
+// startRunMonitor spawns the run's monitor unless one is already alive, and
+// records its pid in meta.json. The monitor enforces the run's budgets, so a
+// failure is also recorded as unenforced budgets (see recordBudgetEnforcement).
+func startRunMonitor(st *store.Store, repoID, runID string) error {
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		return err
+	}
+	if headless.ProcessAlive(meta.MonitorPID) {
+		return nil
+	}
+	pid, err := spawnRunMonitor(monitor.SpawnOpts{
+		DataDir: st.DataDir,
+		RepoID:  repoID,
+		RunID:   runID,
+		LogPath: st.RunMonitorLogPath(repoID, runID),
+	})
+	if err == nil {
+		err = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+			m.MonitorPID = pid
+		})
+	}
+	if meta.Budget != nil {
+		recordBudgetEnforcement(st, repoID, runID, err)
+		if err != nil {
+			return fmt.Errorf("%w (max_duration/max_stall budgets are not enforced)", err)
+		}
+	}
+	return err
+}
+
+// RunMonitorOpts holds options for the hidden run monitor command.
+type RunMonitorOpts struct {
+	// DataDir is the resolved AGENCY_DATA_DIR of the spawning process.
+	DataDir string
+
+	// RepoID and RunID identify the run to monitor.
+	RepoID string
+	RunID  string
+}
+
+// RunMonitor watches a run until its runner is gone: it auto-verifies group
+// attempts, enforces the run's budgets, records runner status history and
+// sends state transition notifications. It is spawned detached by run and
+// resume next to the checkpoint watcher, so none of this depends on
+// checkpointing; output goes to logs/monitor.log.
+func RunMonitor(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, opts RunMonitorOpts, stdout io.Writer) error {
+	if opts.DataDir == "" || opts.RepoID == "" || opts.RunID == "" {
+		return errors.New(errors.EUsage, "--data-dir, --repo-id and --run-id are required")
+	}
+
+	st := store.NewStore(fsys, opts.DataDir, time.Now)
+	meta, err := st.ReadMeta(opts.RepoID, opts.RunID)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Group attempts are verified as soon as the runner reports
+	// ready_for_review, so group show can compare them.
+	if meta.GroupID != "" {
+		verify := func(ctx context.Context) (bool, error) {
+			res, err := verifyservice.NewService(opts.DataDir, fsys).VerifyRun(ctx, opts.RunID, 0)
+			if res == nil || res.Record == nil {
+				return false, err
+			}
+			return res.Record.OK, nil
+		}
+		failedReport := ""
+		stopVerify := every(ctx, groupAutoVerifyInterval, func(ctx context.Context, _ time.Time) {
+			autoVerifyGroupRun(ctx, st, opts.RepoID, opts.RunID, &failedReport, verify, stdout)
+		})
+		defer func() {
+			stopVerify()
+			// Verify a ready_for_review reported since the last tick.
+			autoVerifyGroupRun(context.Background(), st, opts.RepoID, opts.RunID, &failedReport, verify, stdout)
+		}()
+	}
+
+	// Runs with a max_duration or max_stall budget are interrupted by the
+	// monitor, so budgets hold without any agency command being invoked.
+	if meta.Budget != nil {
+		tmuxClient := tmux.NewExecClient(cr)
+		stopBudget := every(ctx, budgetCheckInterval, func(ctx context.Context, now time.Time) {
+			enforceBudget(ctx, tmuxClient, st, opts.RepoID, opts.RunID, now, stdout)
+		})
+		// No final check: the runner is gone, there is nothing to interrupt.
+		defer stopBudget()
+	}
+
+	// Runner status reports written by hand are recorded in the run's history
+	// while the runner works, without ls being invoked.
+	stopHistory := every(ctx, runnerStatusCheckInterval, func(_ context.Context, now time.Time) {
+		observeRunStatus(st, opts.RepoID, opts.RunID, now)
+	})
+	defer func() {
+		stopHistory()
+		// Record a report written since the last tick, e.g. the runner's last.
+		observeRunStatus(st, opts.RepoID, opts.RunID, time.Now())
+	}()
+
+	// Notifications fire while the runner works, without ls being invoked.
+	if userCfg, err := loadUserConfig(fsys); err == nil && userCfg.Notify.Enabled() {
+		stopNotify := every(ctx, notifyCheckInterval, func(ctx context.Context, _ time.Time) {
+			notifyRun(ctx, cr, fsys, st, userCfg.Notify, opts.RepoID, opts.RunID)
+		})
+		defer func() {
+			stopNotify()
+			// Notify a transition since the last tick, e.g. the runner's last report.
+			notifyRun(context.Background(), cr, fsys, st, userCfg.Notify, opts.RepoID, opts.RunID)
+		}()
+	}
+
+	self := os.Getpid()
+	alive := func() bool {
+		meta, err := st.ReadMeta(opts.RepoID, opts.RunID)
+		if err != nil || (meta.MonitorPID != 0 && meta.MonitorPID != self) {
+			return false
+		}
+		return runnerAlive(ctx, cr, meta)
+	}
+	// The first check waits a tick, so the spawner has recorded our pid.
+	ticker := time.NewTicker(monitorAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if !alive() {
+				return nil
+			}
+		}
+	}
+}
+
+// every calls fn every interval until ctx is done or the returned stop is
+// called. stop waits for a call in progress to return.
+func every(ctx context.Context, interval time.Duration, fn func(ctx context.Context, now time.Time)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				fn(ctx, now)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+func TestRunnerAlive(t *testing.T) {
+	worktree := t.TempDir()
+	// has-session succeeds: the tmux session lives on
+	cr := &fakeCommandRunner{responses: map[string]fakeResponse{}}
+
+	tests := []struct {
+		name string
+		meta store.RunMeta
+		want bool
+	}{
+		{
+			name: "tmux runner working",
+			meta: store.RunMeta{WorktreePath: worktree, TmuxSessionName: "agency_auth-fix"},
+			want: true,
+		},
+		{
+			name: "tmux runner exited, shell lingers",
+			meta: store.RunMeta{WorktreePath: worktree, TmuxSessionName: "agency_auth-fix", RunnerExitedAt: "2026-01-10T12:30:00Z"},
+			want: false,
+		},
+		{
+			name: "no tmux session",
+			meta: store.RunMeta{WorktreePath: worktree},
+			want: false,
+		},
+		{
+			name: "worktree removed",
+			meta: store.RunMeta{WorktreePath: worktree + "/missing", TmuxSessionName: "agency_auth-fix"},
+			want: false,
+		},
+		{
+			name: "archived",
+			meta: store.RunMeta{WorktreePath: worktree, TmuxSessionName: "agency_auth-fix", Archive: &store.RunMetaArchive{}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runnerAlive(context.Background(), cr, &tt.meta); got != tt.want {
+				t.Errorf("runnerAlive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/notify"
+	"github.com/NielsdaWheelz/agency/internal/render"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// notifyCheckInterval is how often the run monitor checks a run for
+// notifiable transitions.
+var notifyCheckInterval = 15 * time.Second
+
+// sendNotification delivers a notification (stubbed in tests).
+var sendNotification = notify.Send
+
+// notifyTransition notifies the run's state if it is a new notifiable
+// transition, and records a notify event. Best-effort: delivery errors are
+// recorded in the event and never fail the calling command.
+func notifyTransition(ctx context.Context, cr agencyexec.CommandRunner, st *store.Store, cfg config.UserNotify, rec store.RunRecord, summary render.RunSummary) {
+	if !cfg.Enabled() || rec.Broken || rec.Meta == nil {
+		return
+	}
+	meta := rec.Meta
+	event := notify.EventFor(summary.DerivedStatus, meta)
+	if event != "" && !cfg.Wants(event) {
+		event = ""
+	}
+
+	var text string
+	if summary.Summary != nil {
+		text = *summary.Summary
+	}
+	// A new runner report (e.g. another question) or verify is a new
+	// transition even if the status is unchanged.
+	key := text
+	if event == config.NotifyVerifyFailed {
+		key = meta.LastVerifyAt
+	}
+
+	now := time.Now()
+	fire, err := notify.Observe(st.RunNotifyStatePath(rec.RepoID, rec.RunID), event, key, now)
+	if err != nil || !fire {
+		return
+	}
+
+	data := map[string]any{"notify_event": event}
+	if err := sendNotification(ctx, cr, cfg, notify.NewPayload(event, meta, summary.DerivedStatus, text, now)); err != nil {
+		data["error"] = err.Error()
+	}
+	_ = events.AppendEvent(st.EventsPath(rec.RepoID, rec.RunID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     now.UTC().Format(time.RFC3339),
+		RepoID:        rec.RepoID,
+		RunID:         rec.RunID,
+		Event:         "notify",
+		Data:          data,
+	})
+}
+
+// notifyRun derives a single run's status and notifies a new transition.
+// Used after commands that change a run's state (verify, merge) and by the
+// run monitor.
+func notifyRun(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, st *store.Store, cfg config.UserNotify, repoID, runID string) {
+	if !cfg.Enabled() {
+		return
+	}
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		return
+	}
+	rec := store.RunRecord{RepoID: repoID, RunID: runID, Name: meta.Name, Meta: meta, RunDir: st.RunDir(repoID, runID)}
+	tmuxSessions, _ := listTmuxSessions(ctx, cr)
+	notifyTransition(ctx, cr, st, cfg, rec, recordToSummary(st, rec, tmuxSessions, fsys))
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/notify"
+	"github.com/NielsdaWheelz/agency/internal/render"
+	"github.com/NielsdaWheelz/agency/internal/status"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+func TestNotifyTransition(t *testing.T) {
+	st := store.NewStore(fs.NewRealFS(), t.TempDir(), time.Now)
+	repoID, runID := "abcd1234ef567890", "20260110120000-a3f2"
+	if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+		t.Fatal(err)
+	}
+	meta := store.NewRunMeta(runID, repoID, "auth-fix", "claude", "claude", "main", "agency/auth-fix-a3f2", t.TempDir(), time.Now())
+	rec := store.RunRecord{RepoID: repoID, RunID: runID, Name: meta.Name, Meta: meta}
+
+	var sent []notify.Payload
+	orig := sendNotification
+	sendNotification = func(_ context.Context, _ agencyexec.CommandRunner, _ config.UserNotify, p notify.Payload) error {
+		sent = append(sent, p)
+		return nil
+	}
+	defer func() { sendNotification = orig }()
+
+	question := "which db?"
+	needsInput := render.RunSummary{DerivedStatus: status.StatusNeedsInput, Summary: &question}
+	cfg := config.UserNotify{Command: "true", Events: []string{config.NotifyNeedsInput}}
+
+	// Off without sinks
+	notifyTransition(context.Background(), nil, st, config.UserNotify{}, rec, needsInput)
+	if len(sent) != 0 {
+		t.Fatalf("sent %d notifications with notify unset", len(sent))
+	}
+
+	// ls and the checkpoint watcher both observe the same state: one notification
+	notifyTransition(context.Background(), nil, st, cfg, rec, needsInput)
+	notifyTransition(context.Background(), nil, st, cfg, rec, needsInput)
+	if len(sent) != 1 || sent[0].Event != config.NotifyNeedsInput || sent[0].Summary != question {
+		t.Fatalf("sent = %+v, want one needs_input", sent)
+	}
+
+	// Events not listed in notify.events are skipped
+	notifyTransition(context.Background(), nil, st, cfg, rec, render.RunSummary{DerivedStatus: status.StatusStalled})
+	if len(sent) != 1 {
+		t.Errorf("sent = %+v, want stalled filtered out", sent)
+	}
+
+	// Re-entering needs input after working fires again
+	notifyTransition(context.Background(), nil, st, cfg, rec, needsInput)
+	if len(sent) != 2 {
+		t.Errorf("sent %d notifications, want 2", len(sent))
+	}
+
+	data, err := os.ReadFile(st.EventsPath(repoID, runID))
+	if err != nil || strings.Count(string(data), `"event":"notify"`) != 2 {
+		t.Errorf("expected 2 notify events, got %s (err = %v)", data, err)
+	}
+}
@@ -117,9 +117,18 @@ func gitLines(ctx context.Context, cr exec.CommandRunner, workDir string, args [
 }
 
 func gitText(ctx context.Context, cr exec.CommandRunner, workDir string, args []string) (string, bool) {
+	return gitTextEnv(ctx, cr, workDir, nil, args)
+}
+
+// gitTextEnv is gitText with extra environment variables (e.g. GIT_INDEX_FILE).
+func gitTextEnv(ctx context.Context, cr exec.CommandRunner, workDir string, env map[string]string, args []string) (string, bool) {
+	runEnv := nonInteractiveEnv()
+	for k, v := range env {
+		runEnv[k] = v
+	}
 	result, err := cr.Run(ctx, "git", args, exec.RunOpts{
 		Dir: workDir,
-		Env: nonInteractiveEnv(),
+		Env: runEnv,
 	})
 	if err != nil || result.ExitCode != 0 {
 		return "", false
@@ -65,6 +65,10 @@ type PushOpts struct {
 	// Required after rebasing or amending commits.
 	ForceWithLease bool
 
+	// AllowDenylisted allows pushing when added or untracked files match the
+	// secret denylist.
+	AllowDenylisted bool
+
 	// Sleeper is an injectable sleeper for testing. If nil, uses real time.Sleep.
 	Sleeper Sleeper
 }
@@ -274,7 +278,40 @@ func Push(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 		return errors.New(errors.EEmptyDiff, "no commits ahead of parent; make at least one commit")
 	}
 
-	// Step 12: Prepare PR body (report or fallback)
+	// Step 12: Secret denylist gate (files added on the branch + untracked
+	// files), configured by the parent's agency.json
+	denylist, err := loadPushDenylist(ctx, cr, meta.WorktreePath, parentRef)
+	if err != nil {
+		appendPushEvent(eventsPath, repoID, meta.RunID, "push_failed", map[string]any{
+			"error_code": string(errors.GetCode(err)),
+			"step":       "denylist_check",
+		})
+		return err
+	}
+	denylisted, err := findDenylistedFiles(ctx, cr, meta.WorktreePath, parentRef, meta.Branch, denylist)
+	if err != nil {
+		appendPushEvent(eventsPath, repoID, meta.RunID, "push_failed", map[string]any{
+			"error_code": string(errors.GetCode(err)),
+			"step":       "denylist_check",
+		})
+		return err
+	}
+	if len(denylisted) > 0 {
+		if !opts.AllowDenylisted {
+			appendPushEvent(eventsPath, repoID, meta.RunID, "push_blocked", map[string]any{
+				"error_code": string(errors.EDenylistedFile),
+				"paths":      denylisted,
+			})
+			return denylistErrorWithContext(denylisted)
+		}
+		appendPushEvent(eventsPath, repoID, meta.RunID, "denylist_allowed", map[string]any{
+			"cmd":   "push",
+			"paths": denylisted,
+		})
+		printDenylistWarning(stderr, denylisted)
+	}
+
+	// Step 13: Prepare PR body (report or fallback)
 	bodyPath := reportPath
 	bodyHash := ""
 	if reportUsable {
@@ -292,7 +329,7 @@ func Push(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 		bodyHash = fallbackHash
 	}
 
-	// Step 13: git push -u origin <workspace_branch>
+	// Step 14: git push -u origin <workspace_branch>
 	// Determine the ref to use in printed commands (same ref user invoked)
 	userRef := opts.RunID
 	if meta.Name != "" && meta.Name == opts.RunID {
@@ -303,6 +340,11 @@ func Push(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 		userRef = meta.RunID
 	}
 
+	// A rebase after the last push rewrites the pushed history
+	if !opts.ForceWithLease && rebasedSincePush(meta) {
+		_, _ = fmt.Fprintf(stderr, "hint: run was rebased since its last push; if push is rejected, retry with:\n  agency push %s --force-with-lease\n", userRef)
+	}
+
 	pushStart := time.Now()
 	if err := gitPushBranch(ctx, cr, meta.WorktreePath, meta.Branch, opts.ForceWithLease, userRef, stderr); err != nil {
 		appendPushEvent(eventsPath, repoID, meta.RunID, "push_failed", map[string]any{
@@ -317,7 +359,7 @@ func Push(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 		"duration_ms": pushDurationMs,
 	})
 
-	// Step 14: Update last_push_at immediately after git push
+	// Step 15: Update last_push_at immediately after git push
 	now := time.Now().UTC().Format(time.RFC3339)
 	if err := st.UpdateMeta(repoID, meta.RunID, func(m *store.RunMeta) {
 		m.LastPushAt = now
@@ -326,7 +368,7 @@ func Push(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, op
 		_, _ = fmt.Fprintf(stderr, "warning: failed to update meta.json: %v\n", err)
 	}
 
-	// Step 15: PR lookup / create / update
+	// Step 16: PR lookup / create / update
 	sleeper := opts.Sleeper
 	if sleeper == nil {
 		sleeper = realSleeper{}
@@ -1096,3 +1138,19 @@ func ResolveRepoIdentity(ctx context.Context, cr exec.CommandRunner, cwd string)
 
 	return repoIdentity.RepoID, repoRoot.Path, nil
 }
+
+// rebasedSincePush reports whether the run was rebased after its last push.
+func rebasedSincePush(meta *store.RunMeta) bool {
+	if meta.LastPushAt == "" || meta.LastRebaseAt == "" {
+		return false
+	}
+	pushed, err := time.Parse(time.RFC3339, meta.LastPushAt)
+	if err != nil {
+		return false
+	}
+	rebased, err := time.Parse(time.RFC3339, meta.LastRebaseAt)
+	if err != nil {
+		return false
+	}
+	return !rebased.Before(pushed)
+}
@@ -0,0 +1,778 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/core"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
+	"github.com/NielsdaWheelz/agency/internal/ids"
+	"github.com/NielsdaWheelz/agency/internal/lock"
+	"github.com/NielsdaWheelz/agency/internal/paths"
+	"github.com/NielsdaWheelz/agency/internal/pipeline"
+	"github.com/NielsdaWheelz/agency/internal/runservice"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// spawnQueueRunner starts a detached queue processor (stubbed in tests).
+var spawnQueueRunner = startQueueRunner
+
+// startQueueRunner spawns `agency queue run` detached, appending its output
+// to ${AGENCY_DATA_DIR}/queue.log.
+func startQueueRunner(dataDir string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(filepath.Join(dataDir, "queue.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logFile.Close() }()
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = devnull.Close() }()
+
+	cmd := osexec.Command(exe, "queue", "run")
+	cmd.Dir = dataDir
+	cmd.Env = append(os.Environ(), "AGENCY_DATA_DIR="+dataDir)
+	cmd.Stdin = devnull
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// kickQueue spawns the queue processor if a waiting run has a free slot.
+// Best-effort: called when a slot may have freed up (ls, runner exit, daemon
+// poll). Without waiting runs nothing is spawned, and tmux is not queried.
+func kickQueue(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, dataDir string) {
+	records, err := store.ScanAllRuns(dataDir)
+	if err != nil || !queueWaiting(records) {
+		return
+	}
+	cfg, err := loadUserConfig(fsys)
+	if err != nil {
+		return
+	}
+	tmuxSessions, _ := listTmuxSessions(ctx, cr)
+	if queueStartable(records, tmuxSessions, cfg.Limits) {
+		_ = spawnQueueRunner(dataDir)
+	}
+}
+
+// queueWaiting reports whether any run is waiting in the queue.
+func queueWaiting(records []store.RunRecord) bool {
+	for _, rec := range records {
+		if rec.Meta != nil && rec.Meta.Archive == nil && rec.Meta.Queue.Waiting() {
+			return true
+		}
+	}
+	return false
+}
+
+// queueStartable reports whether a waiting run's repo has a free slot.
+func queueStartable(records []store.RunRecord, tmuxSessions map[string]bool, limits config.UserLimits) bool {
+	total, byRepo := activeRuns(records, tmuxSessions)
+	for _, rec := range queuedRuns(records) {
+		if !rec.Meta.Queue.Waiting() {
+			continue
+		}
+		if free, _ := freeSlots(limits, total, byRepo[rec.RepoID]); free != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// loadUserConfig loads the user config (defaults if missing).
+func loadUserConfig(fsys fs.FS) (config.UserConfig, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return config.UserConfig{}, errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dirs := paths.ResolveDirs(osEnv{}, homeDir)
+	cfg, _, err := config.LoadUserConfig(fsys, dirs.ConfigDir)
+	return cfg, err
+}
+
+// runHoldsSlot reports whether a run counts against max_active_runs: its
+// runner is alive (tmux session with the runner not exited, or a headless
+// process), or the queue processor is starting it.
+func runHoldsSlot(meta *store.RunMeta, tmuxSessions map[string]bool) bool {
+	switch {
+	case meta == nil || meta.Archive != nil:
+		return false
+	case meta.Queue != nil:
+		return meta.Queue.Starting()
+	case meta.Headless != nil:
+		return headless.IsActive(meta)
+	default:
+		return meta.TmuxSessionName != "" && tmuxSessions[meta.TmuxSessionName] && meta.RunnerExitedAt == ""
+	}
+}
+
+// activeRuns counts the runs holding a slot, in total and per repo.
+func activeRuns(records []store.RunRecord, tmuxSessions map[string]bool) (int, map[string]int) {
+	total := 0
+	byRepo := make(map[string]int)
+	for _, rec := range records {
+		if runHoldsSlot(rec.Meta, tmuxSessions) {
+			total++
+			byRepo[rec.RepoID]++
+		}
+	}
+	return total, byRepo
+}
+
+// freeSlots returns how many more runs may start in a repo with inRepo of
+// total active runs (-1 = unlimited), and the limit that binds.
+func freeSlots(limits config.UserLimits, total, inRepo int) (int, string) {
+	free, limit := -1, ""
+	if limits.MaxActiveRuns > 0 {
+		free, limit = max(limits.MaxActiveRuns-total, 0), "max_active_runs"
+	}
+	if limits.MaxActiveRunsPerRepo > 0 {
+		if repoFree := max(limits.MaxActiveRunsPerRepo-inRepo, 0); free < 0 || repoFree < free {
+			free, limit = repoFree, "max_active_runs_per_repo"
+		}
+	}
+	return free, limit
+}
+
+// runAdmission is the outcome of checking the max_active_runs limits before
+// starting runs in a repo.
+type runAdmission struct {
+	// Free is how many runs may start now (-1 = unlimited).
+	Free int
+
+	// Limit is the binding limit, and Active the runs counted against it.
+	Limit  string
+	Active int
+
+	cfg      config.UserConfig
+	dataDir  string
+	repoID   string
+	repoRoot string
+}
+
+// admitRuns checks the user config limits for new runs in the repo at
+// targetCwd. Without limits (the default) nothing is scanned.
+func admitRuns(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, targetCwd string) (*runAdmission, error) {
+	cfg, err := loadUserConfig(fsys)
+	if err != nil {
+		return nil, err
+	}
+	adm := &runAdmission{Free: -1, cfg: cfg}
+	if cfg.Limits.MaxActiveRuns == 0 && cfg.Limits.MaxActiveRunsPerRepo == 0 {
+		return adm, nil
+	}
+
+	rctx, err := ResolveRunContext(ctx, cr, targetCwd, "")
+	if err != nil {
+		return nil, err
+	}
+	if rctx.CWDRepoRoot == "" {
+		// Not in a repo: let the run pipeline report it
+		return adm, nil
+	}
+	adm.dataDir, adm.repoID, adm.repoRoot = rctx.DataDir, rctx.CWDRepoID, rctx.CWDRepoRoot
+
+	records, err := store.ScanAllRuns(rctx.DataDir)
+	if err != nil {
+		return nil, errors.Wrap(errors.EInternal, "failed to scan runs", err)
+	}
+	tmuxSessions, _ := listTmuxSessions(ctx, cr)
+	total, byRepo := activeRuns(records, tmuxSessions)
+	adm.Free, adm.Limit = freeSlots(cfg.Limits, total, byRepo[adm.repoID])
+	adm.Active = total
+	if adm.Limit == "max_active_runs_per_repo" {
+		adm.Active = byRepo[adm.repoID]
+	}
+	return adm, nil
+}
+
+// limitError is returned when runs cannot start and --queue was not given.
+func (a *runAdmission) limitError(requested int) error {
+	msg := fmt.Sprintf("%s reached (%d active); pass --queue to start when a slot frees up", a.Limit, a.Active)
+	if requested > 1 {
+		msg = fmt.Sprintf("%d runs requested but only %d can start (%s, %d active); pass --queue to queue the rest", requested, a.Free, a.Limit, a.Active)
+	}
+	return errors.NewWithDetails(errors.ERunLimit, msg, map[string]string{
+		"limit":  a.Limit,
+		"active": fmt.Sprintf("%d", a.Active),
+	})
+}
+
+// enqueueRuns queues tasks in the admitted repo under the repo lock: each
+// gets a run_id, run directory, prompt file and meta.json with a queue
+// record, but no worktree. Runner and parent are resolved now so the run
+// starts as requested. Returns the run ids in task order.
+func enqueueRuns(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, adm *runAdmission, tasks []pipeline.RunPipelineOpts) ([]string, error) {
+	unlock, err := lock.NewRepoLock(adm.dataDir).Lock(adm.repoID, "run-queue")
+	if err != nil {
+		if _, ok := err.(*lock.ErrLocked); ok {
+			return nil, errors.New(errors.ERepoLocked, err.Error())
+		}
+		return nil, errors.Wrap(errors.EInternal, "failed to acquire repo lock", err)
+	}
+	defer func() { _ = unlock() }()
+
+	records, err := store.ScanRunsForRepo(adm.dataDir, adm.repoID)
+	if err != nil {
+		return nil, errors.Wrap(errors.EInternal, "failed to scan runs", err)
+	}
+	refs := make([]ids.RunRef, 0, len(records))
+	archived := make(map[string]bool)
+	for _, r := range records {
+		refs = append(refs, ids.RunRef{RepoID: r.RepoID, RunID: r.RunID, Name: r.Name, Broken: r.Broken})
+		archived[r.RunID] = r.Meta != nil && r.Meta.Archive != nil
+	}
+	isArchived := func(ref ids.RunRef) bool { return archived[ref.RunID] }
+
+	currentBranch := ""
+	if out, ok := gitText(ctx, cr, adm.repoRoot, []string{"branch", "--show-current"}); ok {
+		currentBranch = strings.TrimSpace(out)
+	}
+
+	st := store.NewStore(fsys, adm.dataDir, time.Now)
+	runIDs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		if err := core.ValidateName(task.Name); err != nil {
+			return runIDs, err
+		}
+		if err := ids.CheckNameUnique(task.Name, refs, isArchived); err != nil {
+			return runIDs, err
+		}
+		runner := task.Runner
+		if runner == "" {
+			runner = adm.cfg.Defaults.Runner
+		}
+		parent := task.Parent
+		if parent == "" {
+			parent = currentBranch
+		}
+		if parent == "" {
+			return runIDs, errors.New(errors.EParentBranchNotFound, "current branch is empty; provide --parent")
+		}
+
+		now := time.Now()
+		runID, err := core.NewRunID(now)
+		if err != nil {
+			return runIDs, errors.Wrap(errors.EInternal, "failed to generate run_id", err)
+		}
+		if _, err := st.EnsureRunDir(adm.repoID, runID); err != nil {
+			return runIDs, err
+		}
+		if task.Prompt != "" {
+			if err := os.WriteFile(st.RunPromptPath(adm.repoID, runID), []byte(task.Prompt), 0o600); err != nil {
+				return runIDs, errors.Wrap(errors.EInternal, "failed to write prompt file", err)
+			}
+		}
+		meta := store.NewRunMeta(runID, adm.repoID, task.Name, runner, "", parent, "", "", now)
+		meta.GroupID = task.GroupID
+		meta.GroupAttempt = task.GroupAttempt
+		if task.MaxDuration > 0 || task.MaxStall > 0 {
+			// Only the flag overrides; agency.json budgets apply when the run starts
+			meta.Budget = &store.RunMetaBudget{}
+			if task.MaxDuration > 0 {
+				meta.Budget.MaxDuration = task.MaxDuration.String()
+			}
+			if task.MaxStall > 0 {
+				meta.Budget.MaxStall = task.MaxStall.String()
+			}
+		}
+		meta.Queue = &store.RunMetaQueue{
+			QueuedAt: now.UTC().Format(time.RFC3339Nano),
+			Headless: task.Headless,
+		}
+		if err := st.WriteInitialMeta(adm.repoID, runID, meta); err != nil {
+			return runIDs, err
+		}
+		_ = events.AppendEvent(st.EventsPath(adm.repoID, runID), events.Event{
+			SchemaVersion: "1.0",
+			Timestamp:     now.UTC().Format(time.RFC3339),
+			RepoID:        adm.repoID,
+			RunID:         runID,
+			Event:         "run_queued",
+			Data:          map[string]any{"limit": adm.Limit, "active": adm.Active},
+		})
+		refs = append(refs, ids.RunRef{RepoID: adm.repoID, RunID: runID, Name: task.Name})
+		runIDs = append(runIDs, runID)
+	}
+	return runIDs, nil
+}
+
+// queuedRuns returns the runs in the queue (waiting, starting or failed to
+// start), oldest first.
+func queuedRuns(records []store.RunRecord) []store.RunRecord {
+	var queued []store.RunRecord
+	for _, rec := range records {
+		if rec.Meta != nil && rec.Meta.Archive == nil && rec.Meta.Queue != nil && rec.Meta.WorktreePath == "" {
+			queued = append(queued, rec)
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		a, b := queued[i].Meta.Queue.QueuedAt, queued[j].Meta.Queue.QueuedAt
+		if a != b {
+			return a < b
+		}
+		return queued[i].RunID < queued[j].RunID
+	})
+	return queued
+}
+
+// QueueRun starts queued runs, oldest first, while the limits allow.
+func QueueRun(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, stdout, stderr io.Writer) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dataDir := paths.ResolveDirs(osEnv{}, homeDir).DataDir
+
+	if _, err := processQueue(ctx, cr, fsys, runservice.New(), dataDir, stdout, stderr); err != nil {
+		return err
+	}
+
+	records, err := store.ScanAllRuns(dataDir)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to scan runs", err)
+	}
+	waiting := 0
+	for _, rec := range queuedRuns(records) {
+		if rec.Meta.Queue.Waiting() {
+			waiting++
+		}
+	}
+	_, _ = fmt.Fprintf(stdout, "queue: %d waiting\n", waiting)
+	return nil
+}
+
+// processQueue claims and starts queued runs one at a time until none is
+// waiting or no slot is free. A run that fails to start is marked failed and
+// does not stop the others. Returns the number of runs started.
+//
+// Only one processor drains the queue at a time: it holds
+// ${AGENCY_DATA_DIR}/queue.lock, and a processor that finds it held exits,
+// leaving the queue to the holder. The holder checks the queue again after
+// releasing the lock, so a slot freed while it was finishing is not missed; a
+// pass that starts nothing after the first (runs left behind held repo locks)
+// ends it.
+func processQueue(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, svc pipeline.RunService, dataDir string, stdout, stderr io.Writer) (int, error) {
+	cfg, err := loadUserConfig(fsys)
+	if err != nil {
+		return 0, err
+	}
+	st := store.NewStore(fsys, dataDir, time.Now)
+
+	started := 0
+	for pass := 0; ; pass++ {
+		unlock, err := lock.TryLockFile(filepath.Join(dataDir, "queue.lock"))
+		if stderrors.Is(err, lock.ErrHeld) {
+			_, _ = fmt.Fprintln(stdout, "queue: another processor is running")
+			return started, nil
+		}
+		if err != nil {
+			return started, errors.Wrap(errors.EInternal, "failed to lock the queue", err)
+		}
+		n, err := drainQueue(ctx, cr, fsys, svc, st, cfg.Limits, stdout, stderr)
+		_ = unlock()
+		started += n
+		if err != nil || (n == 0 && pass > 0) {
+			return started, err
+		}
+
+		records, err := store.ScanAllRuns(dataDir)
+		if err != nil || !queueWaiting(records) {
+			return started, nil
+		}
+		tmuxSessions, _ := listTmuxSessions(ctx, cr)
+		if !queueStartable(records, tmuxSessions, cfg.Limits) {
+			return started, nil
+		}
+	}
+}
+
+// drainQueue starts queued runs until nothing can start. The caller holds the
+// queue lock.
+func drainQueue(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, svc pipeline.RunService, st *store.Store, limits config.UserLimits, stdout, stderr io.Writer) (int, error) {
+	started := 0
+	for {
+		meta, err := claimQueuedRun(ctx, cr, st, limits)
+		if err != nil {
+			return started, err
+		}
+		if meta == nil {
+			return started, nil
+		}
+		if err := startQueuedRun(ctx, cr, fsys, svc, st, meta, stdout); err != nil {
+			_, _ = fmt.Fprintf(stderr, "failed: %s (%s): %v\n", meta.Name, meta.RunID, err)
+			continue
+		}
+		started++
+	}
+}
+
+// claimQueuedRun marks the oldest waiting run whose repo has a free slot as
+// starting, under its repo lock. Active runs are counted again once the lock
+// is held, since runs may have started (e.g. agency run in that repo) since
+// the first scan. Runs in repos whose lock is held are left for the next
+// pass. Returns nil if nothing can start.
+func claimQueuedRun(ctx context.Context, cr agencyexec.CommandRunner, st *store.Store, limits config.UserLimits) (*store.RunMeta, error) {
+	records, err := store.ScanAllRuns(st.DataDir)
+	if err != nil {
+		return nil, errors.Wrap(errors.EInternal, "failed to scan runs", err)
+	}
+	tmuxSessions, _ := listTmuxSessions(ctx, cr)
+	total, byRepo := activeRuns(records, tmuxSessions)
+
+	for _, rec := range queuedRuns(records) {
+		if !rec.Meta.Queue.Waiting() {
+			continue
+		}
+		if free, _ := freeSlots(limits, total, byRepo[rec.RepoID]); free == 0 {
+			continue
+		}
+
+		unlock, err := lock.NewRepoLock(st.DataDir).Lock(rec.RepoID, "queue-run")
+		if err != nil {
+			continue
+		}
+		if limits.MaxActiveRuns > 0 || limits.MaxActiveRunsPerRepo > 0 {
+			if current, err := store.ScanAllRuns(st.DataDir); err == nil {
+				tmuxSessions, _ = listTmuxSessions(ctx, cr)
+				total, byRepo = activeRuns(current, tmuxSessions)
+				if free, _ := freeSlots(limits, total, byRepo[rec.RepoID]); free == 0 {
+					_ = unlock()
+					continue
+				}
+			}
+		}
+		var claimed *store.RunMeta
+		err = st.UpdateMeta(rec.RepoID, rec.RunID, func(m *store.RunMeta) {
+			if m.Archive == nil && m.Queue.Waiting() {
+				m.Queue.StartedAt = time.Now().UTC().Format(time.RFC3339)
+				claimed = m
+			}
+		})
+		_ = unlock()
+		if err != nil {
+			return nil, err
+		}
+		if claimed != nil {
+			return claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+// startQueuedRun runs the run pipeline for a claimed queued run from its repo
+// root, then clears the queue record. If the pipeline fails before the
+// worktree exists, the queue record keeps the error; later failures leave a
+// regular failed run.
+func startQueuedRun(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, svc pipeline.RunService, st *store.Store, meta *store.RunMeta, stdout io.Writer) error {
+	repoID, runID := meta.RepoID, meta.RunID
+
+	var runErr error
+	repoRoot := gcRepoRoot(st, repoID)
+	if repoRoot == "" {
+		runErr = errors.NewWithDetails(errors.ERepoNotFound, "repo root for queued run not found", map[string]string{"repo_id": repoID})
+	} else {
+		prompt, _ := os.ReadFile(st.RunPromptPath(repoID, runID))
+		maxDuration, maxStall := budgetOpts(meta)
+		_, runErr = pipeline.NewPipeline(svc).Run(ctx, pipeline.RunPipelineOpts{
+			Name:         meta.Name,
+			Runner:       meta.Runner,
+			Parent:       meta.ParentBranch,
+			Headless:     meta.Queue.Headless,
+			Prompt:       string(prompt),
+			GroupID:      meta.GroupID,
+			GroupAttempt: meta.GroupAttempt,
+			RunID:        runID,
+			MaxDuration:  maxDuration,
+			MaxStall:     maxStall,
+			Dir:          repoRoot,
+		})
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_ = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		if runErr != nil && m.WorktreePath == "" && m.Queue != nil {
+			m.Queue.FailedAt = now
+			m.Queue.Error = runErr.Error()
+			return
+		}
+		m.Queue = nil
+	})
+
+	if runErr != nil {
+		_ = events.AppendEvent(st.EventsPath(repoID, runID), events.Event{
+			SchemaVersion: "1.0",
+			Timestamp:     now,
+			RepoID:        repoID,
+			RunID:         runID,
+			Event:         "queue_start_failed",
+			Data:          map[string]any{"error_code": string(errors.GetCode(runErr))},
+		})
+		return runErr
+	}
+
+	_ = events.AppendEvent(st.EventsPath(repoID, runID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     now,
+		RepoID:        repoID,
+		RunID:         runID,
+		Event:         "queue_started",
+		Data:          map[string]any{"queued_at": meta.Queue.QueuedAt},
+	})
+	for _, w := range startRunWatchers(st, repoID, runID) {
+		_, _ = fmt.Fprintf(stdout, "warning: %s: %s\n", meta.Name, w)
+	}
+	_, _ = fmt.Fprintf(stdout, "started: %s (%s)\n", meta.Name, runID)
+	return nil
+}
+
+// QueueLSOpts holds options for the queue ls command.
+type QueueLSOpts struct {
+	// JSON enables JSON output.
+	JSON bool
+}
+
+// QueueEntry is one queued run in queue ls output.
+type QueueEntry struct {
+	// Position is the 1-based place among waiting runs (0 if not waiting).
+	Position int    `json:"position"`
+	RunID    string `json:"run_id"`
+	RepoID   string `json:"repo_id"`
+	Name     string `json:"name"`
+	Runner   string `json:"runner"`
+	State    string `json:"state"`
+	QueuedAt string `json:"queued_at"`
+	Error    string `json:"error,omitempty"`
+}
+
+// QueueLSResult is the data for queue ls --json.
+type QueueLSResult struct {
+	ActiveRuns           int          `json:"active_runs"`
+	MaxActiveRuns        int          `json:"max_active_runs"`
+	MaxActiveRunsPerRepo int          `json:"max_active_runs_per_repo"`
+	Queue                []QueueEntry `json:"queue"`
+}
+
+// queueLSJSONEnvelope is the stable JSON output format for queue ls --json.
+type queueLSJSONEnvelope struct {
+	SchemaVersion string         `json:"schema_version"`
+	Data          *QueueLSResult `json:"data"`
+}
+
+// Queue entry states.
+const (
+	queueStateWaiting  = "waiting"
+	queueStateStarting = "starting"
+	queueStateFailed   = "failed"
+)
+
+// QueueLS lists queued runs across all repos, oldest first, with the active
+// run count and limits.
+// This is a read-only command.
+func QueueLS(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, opts QueueLSOpts, stdout, stderr io.Writer) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dataDir := paths.ResolveDirs(osEnv{}, homeDir).DataDir
+	cfg, err := loadUserConfig(fsys)
+	if err != nil {
+		return err
+	}
+
+	records, err := store.ScanAllRuns(dataDir)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to scan runs", err)
+	}
+	tmuxSessions, _ := listTmuxSessions(ctx, cr)
+	total, _ := activeRuns(records, tmuxSessions)
+
+	res := &QueueLSResult{
+		ActiveRuns:           total,
+		MaxActiveRuns:        cfg.Limits.MaxActiveRuns,
+		MaxActiveRunsPerRepo: cfg.Limits.MaxActiveRunsPerRepo,
+		Queue:                []QueueEntry{},
+	}
+	position := 0
+	for _, rec := range queuedRuns(records) {
+		q := rec.Meta.Queue
+		e := QueueEntry{
+			RunID:    rec.RunID,
+			RepoID:   rec.RepoID,
+			Name:     rec.Meta.Name,
+			Runner:   rec.Meta.Runner,
+			State:    queueStateWaiting,
+			QueuedAt: q.QueuedAt,
+			Error:    q.Error,
+		}
+		switch {
+		case q.FailedAt != "":
+			e.State = queueStateFailed
+		case q.StartedAt != "":
+			e.State = queueStateStarting
+		default:
+			position++
+			e.Position = position
+		}
+		res.Queue = append(res.Queue, e)
+	}
+
+	if opts.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(queueLSJSONEnvelope{SchemaVersion: "1.0", Data: res})
+	}
+	return writeQueueHuman(stdout, res)
+}
+
+// writeQueueHuman writes the queue table, then the error of each failed entry.
+func writeQueueHuman(w io.Writer, res *QueueLSResult) error {
+	limit := func(n int) string {
+		if n == 0 {
+			return "unlimited"
+		}
+		return fmt.Sprintf("%d", n)
+	}
+	_, _ = fmt.Fprintf(w, "active runs: %d (max_active_runs: %s, max_active_runs_per_repo: %s)\n",
+		res.ActiveRuns, limit(res.MaxActiveRuns), limit(res.MaxActiveRunsPerRepo))
+	if len(res.Queue) == 0 {
+		_, _ = fmt.Fprintln(w, "No queued runs.")
+		return nil
+	}
+
+	nameW, idW := len("NAME"), len("RUN_ID")
+	for _, e := range res.Queue {
+		nameW = max(nameW, len(e.Name))
+		idW = max(idW, len(e.RunID))
+	}
+	_, _ = fmt.Fprintf(w, "\n%-3s  %-*s  %-*s  %-8s  %s\n", "#", nameW, "NAME", idW, "RUN_ID", "STATE", "QUEUED")
+	for _, e := range res.Queue {
+		pos := "-"
+		if e.Position > 0 {
+			pos = fmt.Sprintf("%d", e.Position)
+		}
+		queuedAt := e.QueuedAt
+		if t, err := time.Parse(time.RFC3339Nano, e.QueuedAt); err == nil {
+			queuedAt = t.UTC().Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%-3s  %-*s  %-*s  %-8s  %s\n", pos, nameW, e.Name, idW, e.RunID, e.State, queuedAt)
+	}
+	for _, e := range res.Queue {
+		if e.State == queueStateFailed {
+			_, _ = fmt.Fprintf(w, "\nfailed: %s\nerror: %s\n", e.Name, e.Error)
+		}
+	}
+	return nil
+}
+
+// QueueRmOpts holds options for the queue rm command.
+type QueueRmOpts struct {
+	// RunID is the run reference (name, run_id, or unique prefix).
+	RunID string
+
+	// RepoPath is the optional --repo flag to scope name resolution.
+	RepoPath string
+}
+
+// QueueRm removes a waiting (or failed to start) run from the queue, deleting
+// its run record. A queued run has no worktree or branch to clean up.
+func QueueRm(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts QueueRmOpts, stdout, stderr io.Writer) error {
+	if opts.RunID == "" {
+		return errors.New(errors.EUsage, "run_id is required")
+	}
+	rctx, err := ResolveRunContext(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	resolved, err := ResolveRun(rctx, opts.RunID)
+	if err != nil {
+		return err
+	}
+	if resolved.Broken || resolved.Record == nil || resolved.Record.Meta == nil {
+		return errors.NewWithDetails(
+			errors.ERunBroken,
+			"run exists but meta.json is unreadable or invalid",
+			map[string]string{"run_id": resolved.RunID, "repo_id": resolved.RepoID},
+		)
+	}
+	repoID, runID := resolved.RepoID, resolved.RunID
+
+	unlock, err := lock.NewRepoLock(rctx.DataDir).Lock(repoID, "queue-rm")
+	if err != nil {
+		if _, ok := err.(*lock.ErrLocked); ok {
+			return errors.New(errors.ERepoLocked, err.Error())
+		}
+		return errors.Wrap(errors.EInternal, "failed to acquire repo lock", err)
+	}
+	defer func() { _ = unlock() }()
+
+	// Re-check under the lock: the queue processor may have claimed it
+	st := store.NewStore(fsys, rctx.DataDir, time.Now)
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		return err
+	}
+	if meta.Queue == nil || meta.WorktreePath != "" || meta.Archive != nil {
+		return errors.New(errors.ENotQueued, fmt.Sprintf("run %s is not queued", meta.Name))
+	}
+	if meta.Queue.Starting() {
+		return errors.New(errors.ENotQueued, fmt.Sprintf("run %s is already starting", meta.Name))
+	}
+
+	if err := os.RemoveAll(st.RunDir(repoID, runID)); err != nil {
+		return errors.Wrap(errors.EInternal, "failed to remove run record", err)
+	}
+	_, _ = fmt.Fprintf(stdout, "removed: %s (%s)\n", meta.Name, runID)
+	return nil
+}
+
+// runQueued queues a single run and prints its place in the queue.
+func runQueued(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, adm *runAdmission, task pipeline.RunPipelineOpts, stdout io.Writer) error {
+	runIDs, err := enqueueRuns(ctx, cr, fsys, adm, []pipeline.RunPipelineOpts{task})
+	if err != nil {
+		return err
+	}
+
+	position := 0
+	if records, err := store.ScanAllRuns(adm.dataDir); err == nil {
+		for _, rec := range queuedRuns(records) {
+			if rec.Meta.Queue.Waiting() {
+				position++
+			}
+			if rec.RunID == runIDs[0] {
+				break
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(stdout, "queued: %s (%s)\n", task.Name, runIDs[0])
+	_, _ = fmt.Fprintf(stdout, "position: %d (%s reached, %d active)\n", position, adm.Limit, adm.Active)
+	_, _ = fmt.Fprintln(stdout, "next: agency queue ls")
+	return nil
+}
@@ -0,0 +1,400 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/lock"
+	"github.com/NielsdaWheelz/agency/internal/pipeline"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+func TestFreeSlots(t *testing.T) {
+	tests := []struct {
+		limits    config.UserLimits
+		total     int
+		inRepo    int
+		wantFree  int
+		wantLimit string
+	}{
+		{config.UserLimits{}, 10, 5, -1, ""},
+		{config.UserLimits{MaxActiveRuns: 3}, 1, 1, 2, "max_active_runs"},
+		{config.UserLimits{MaxActiveRuns: 3}, 4, 0, 0, "max_active_runs"},
+		{config.UserLimits{MaxActiveRunsPerRepo: 2}, 5, 1, 1, "max_active_runs_per_repo"},
+		{config.UserLimits{MaxActiveRuns: 5, MaxActiveRunsPerRepo: 2}, 4, 0, 1, "max_active_runs"},
+		{config.UserLimits{MaxActiveRuns: 5, MaxActiveRunsPerRepo: 2}, 2, 2, 0, "max_active_runs_per_repo"},
+	}
+	for _, tt := range tests {
+		free, limit := freeSlots(tt.limits, tt.total, tt.inRepo)
+		if free != tt.wantFree || limit != tt.wantLimit {
+			t.Errorf("freeSlots(%+v, %d, %d) = %d, %q; want %d, %q", tt.limits, tt.total, tt.inRepo, free, limit, tt.wantFree, tt.wantLimit)
+		}
+	}
+}
+
+func TestActiveRuns(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	records := []store.RunRecord{
+		{RepoID: "r1", Meta: &store.RunMeta{TmuxSessionName: "agency-a"}},
+		{RepoID: "r1", Meta: &store.RunMeta{TmuxSessionName: "agency-b", RunnerExitedAt: now}},
+		{RepoID: "r1", Meta: &store.RunMeta{TmuxSessionName: "agency-c"}},
+		{RepoID: "r2", Meta: &store.RunMeta{Queue: &store.RunMetaQueue{QueuedAt: now, StartedAt: now}}},
+		{RepoID: "r2", Meta: &store.RunMeta{Queue: &store.RunMetaQueue{QueuedAt: now}}},
+		{RepoID: "r2", Meta: &store.RunMeta{Queue: &store.RunMetaQueue{QueuedAt: now, StartedAt: now, FailedAt: now}}},
+		{RepoID: "r2", Meta: &store.RunMeta{TmuxSessionName: "agency-d", Archive: &store.RunMetaArchive{ArchivedAt: now}}},
+		{RepoID: "r2"},
+	}
+	sessions := map[string]bool{"agency-a": true, "agency-b": true, "agency-d": true}
+
+	total, byRepo := activeRuns(records, sessions)
+	if total != 2 || byRepo["r1"] != 1 || byRepo["r2"] != 1 {
+		t.Errorf("activeRuns() = %d, %v; want 2, r1=1 r2=1", total, byRepo)
+	}
+}
+
+// setupQueueTest creates a repo on main with a repo record, and a user config
+// with the given limits. Returns the admission enqueueRuns needs.
+func setupQueueTest(t *testing.T, limits string) (*runAdmission, *store.Store) {
+	t.Helper()
+	testutil.HermeticGitEnv(t)
+
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-b", "main"}, {"add", "."}, {"commit", "-m", "Initial commit"}} {
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: repoDir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+
+	dataDir := t.TempDir()
+	t.Setenv("AGENCY_DATA_DIR", dataDir)
+	configDir := t.TempDir()
+	t.Setenv("AGENCY_CONFIG_DIR", configDir)
+	cfgJSON := `{"version": 1, "defaults": {"runner": "claude", "editor": "code"}, "limits": ` + limits + `}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(cfgJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	st := store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+	repoID := "repo123456789012"
+	rec := st.UpsertRepoRecord(nil, store.BuildRepoRecordInput{RepoKey: "path:" + repoDir, RepoID: repoID, RepoRootLastSeen: repoDir})
+	if err := st.SaveRepoRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadUserConfig(fs.NewRealFS())
+	if err != nil {
+		t.Fatalf("loadUserConfig() error = %v", err)
+	}
+	return &runAdmission{Free: 0, Limit: "max_active_runs", cfg: cfg, dataDir: dataDir, repoID: repoID, repoRoot: repoDir}, st
+}
+
+func TestEnqueueRuns(t *testing.T) {
+	adm, st := setupQueueTest(t, `{"max_active_runs": 1}`)
+
+	runIDs, err := enqueueRuns(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{
+		{Name: "task-a", Prompt: "do a", Headless: true},
+		{Name: "task-b", Runner: "codex", Parent: "develop"},
+	})
+	if err != nil {
+		t.Fatalf("enqueueRuns() error = %v", err)
+	}
+	if len(runIDs) != 2 {
+		t.Fatalf("runIDs = %v, want 2", runIDs)
+	}
+
+	a, err := st.ReadMeta(adm.repoID, runIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "task-a" || a.Runner != "claude" || a.ParentBranch != "main" || a.WorktreePath != "" ||
+		!a.Queue.Waiting() || !a.Queue.Headless {
+		t.Errorf("task-a meta = %+v, queue = %+v", a, a.Queue)
+	}
+	prompt, err := os.ReadFile(st.RunPromptPath(adm.repoID, runIDs[0]))
+	if err != nil || string(prompt) != "do a" {
+		t.Errorf("prompt = %q, err = %v", prompt, err)
+	}
+	b, err := st.ReadMeta(adm.repoID, runIDs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Runner != "codex" || b.ParentBranch != "develop" || b.Queue.Headless {
+		t.Errorf("task-b meta = %+v, queue = %+v", b, b.Queue)
+	}
+
+	// Names stay unique across queued runs
+	_, err = enqueueRuns(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{{Name: "task-a"}})
+	if errors.GetCode(err) != errors.ENameExists {
+		t.Errorf("duplicate name error = %v, want E_NAME_EXISTS", err)
+	}
+}
+
+func TestProcessQueue(t *testing.T) {
+	adm, st := setupQueueTest(t, `{"max_active_runs": 1}`)
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+
+	// Another processor is starting a run, holding the only slot
+	holder, err := enqueueRuns(ctx, cr, fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{{Name: "holder"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateMeta(adm.repoID, holder[0], func(m *store.RunMeta) {
+		m.Queue.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	runIDs, err := enqueueRuns(ctx, cr, fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{
+		{Name: "task-a", Prompt: "do a"},
+		{Name: "task-b", Prompt: "do b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &batchFakeService{}
+	var stdout, stderr bytes.Buffer
+	started, err := processQueue(ctx, cr, fs.NewRealFS(), fake, adm.dataDir, &stdout, &stderr)
+	if err != nil || started != 0 {
+		t.Fatalf("processQueue() with no free slot = %d, %v; want 0", started, err)
+	}
+
+	if err := os.RemoveAll(st.RunDir(adm.repoID, holder[0])); err != nil {
+		t.Fatal(err)
+	}
+	started, err = processQueue(ctx, cr, fs.NewRealFS(), fake, adm.dataDir, &stdout, &stderr)
+	if err != nil || started != 2 {
+		t.Fatalf("processQueue() = %d, %v; want 2\nstderr: %s", started, err, stderr.String())
+	}
+	if strings.Join(fake.started, ",") != "task-a:do a,task-b:do b" {
+		t.Errorf("started = %v, want oldest first", fake.started)
+	}
+	for _, runID := range runIDs {
+		meta, err := st.ReadMeta(adm.repoID, runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.Queue != nil {
+			t.Errorf("%s queue = %+v, want cleared", meta.Name, meta.Queue)
+		}
+		data, err := os.ReadFile(st.EventsPath(adm.repoID, runID))
+		if err != nil || !strings.Contains(string(data), `"event":"queue_started"`) {
+			t.Errorf("expected queue_started event for %s, err = %v", meta.Name, err)
+		}
+	}
+	if !strings.Contains(stdout.String(), "started: task-a ("+runIDs[0]+")") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+}
+
+func TestProcessQueue_StartFailure(t *testing.T) {
+	adm, st := setupQueueTest(t, `{"max_active_runs": 1}`)
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+
+	runIDs, err := enqueueRuns(ctx, cr, fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{
+		{Name: "task-a", Prompt: "do a"},
+		{Name: "task-b", Prompt: "do b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	started, err := processQueue(ctx, cr, fs.NewRealFS(), &batchFakeService{failSetup: "task-a"}, adm.dataDir, &stdout, &stderr)
+	if err != nil || started != 1 {
+		t.Fatalf("processQueue() = %d, %v; want 1", started, err)
+	}
+	if !strings.Contains(stderr.String(), "failed: task-a") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+	meta, err := st.ReadMeta(adm.repoID, runIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Queue == nil || meta.Queue.FailedAt == "" || !strings.Contains(meta.Queue.Error, "setup script failed") {
+		t.Errorf("task-a queue = %+v, want failed", meta.Queue)
+	}
+}
+
+func TestProcessQueue_Singleton(t *testing.T) {
+	adm, st := setupQueueTest(t, `{"max_active_runs": 1}`)
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+	runIDs, err := enqueueRuns(ctx, cr, fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{{Name: "task-a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another processor holds the queue lock: this one leaves the queue to it
+	unlock, err := lock.TryLockFile(filepath.Join(adm.dataDir, "queue.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &batchFakeService{}
+	var stdout, stderr bytes.Buffer
+	started, err := processQueue(ctx, cr, fs.NewRealFS(), fake, adm.dataDir, &stdout, &stderr)
+	if err != nil || started != 0 || len(fake.started) != 0 {
+		t.Fatalf("processQueue() with the lock held = %d, %v; want 0", started, err)
+	}
+	if meta, _ := st.ReadMeta(adm.repoID, runIDs[0]); meta == nil || !meta.Queue.Waiting() {
+		t.Errorf("run should still be waiting: %+v", meta)
+	}
+
+	_ = unlock()
+	if started, err := processQueue(ctx, cr, fs.NewRealFS(), fake, adm.dataDir, &stdout, &stderr); err != nil || started != 1 {
+		t.Fatalf("processQueue() = %d, %v; want 1", started, err)
+	}
+}
+
+func TestKickQueue_NeedsFreeSlot(t *testing.T) {
+	adm, st := setupQueueTest(t, `{"max_active_runs": 1}`)
+	ctx := context.Background()
+	cr := exec.NewRealRunner()
+
+	spawned := 0
+	orig := spawnQueueRunner
+	spawnQueueRunner = func(string) error { spawned++; return nil }
+	defer func() { spawnQueueRunner = orig }()
+
+	// Nothing waiting: nothing to start
+	kickQueue(ctx, cr, fs.NewRealFS(), adm.dataDir)
+	if spawned != 0 {
+		t.Fatalf("spawned %d processors with an empty queue", spawned)
+	}
+
+	runIDs, err := enqueueRuns(ctx, cr, fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{{Name: "holder"}, {Name: "task-a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateMeta(adm.repoID, runIDs[0], func(m *store.RunMeta) {
+		m.Queue.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The only slot is held by a starting run
+	kickQueue(ctx, cr, fs.NewRealFS(), adm.dataDir)
+	if spawned != 0 {
+		t.Fatalf("spawned %d processors with no free slot", spawned)
+	}
+
+	if err := os.RemoveAll(st.RunDir(adm.repoID, runIDs[0])); err != nil {
+		t.Fatal(err)
+	}
+	kickQueue(ctx, cr, fs.NewRealFS(), adm.dataDir)
+	if spawned != 1 {
+		t.Errorf("spawned %d processors with a free slot, want 1", spawned)
+	}
+}
+
+func TestQueueLS_JSON(t *testing.T) {
+	adm, st := setupQueueTest(t, `{"max_active_runs": 2, "max_active_runs_per_repo": 1}`)
+	runIDs, err := enqueueRuns(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{
+		{Name: "task-a"},
+		{Name: "task-b"},
+		{Name: "task-c"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := st.UpdateMeta(adm.repoID, runIDs[0], func(m *store.RunMeta) {
+		m.Queue.StartedAt = now
+		m.Queue.FailedAt = now
+		m.Queue.Error = "E_SCRIPT_FAILED: setup script failed"
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := QueueLS(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), QueueLSOpts{JSON: true}, &stdout, &stderr); err != nil {
+		t.Fatalf("QueueLS() error = %v", err)
+	}
+	var env struct {
+		Data QueueLSResult `json:"data"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &env); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	if env.Data.MaxActiveRuns != 2 || env.Data.MaxActiveRunsPerRepo != 1 || len(env.Data.Queue) != 3 {
+		t.Fatalf("data = %+v", env.Data)
+	}
+	want := []struct {
+		name     string
+		state    string
+		position int
+	}{
+		{"task-a", "failed", 0},
+		{"task-b", "waiting", 1},
+		{"task-c", "waiting", 2},
+	}
+	for i, w := range want {
+		e := env.Data.Queue[i]
+		if e.Name != w.name || e.State != w.state || e.Position != w.position {
+			t.Errorf("queue[%d] = %+v, want %+v", i, e, w)
+		}
+	}
+
+	stdout.Reset()
+	if err := QueueLS(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), QueueLSOpts{}, &stdout, &stderr); err != nil {
+		t.Fatalf("QueueLS() error = %v", err)
+	}
+	for _, want := range []string{
+		"max_active_runs: 2, max_active_runs_per_repo: 1",
+		"failed: task-a\nerror: E_SCRIPT_FAILED: setup script failed",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("human output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestQueueRm(t *testing.T) {
+	adm, st := setupQueueTest(t, `{"max_active_runs": 1}`)
+	runIDs, err := enqueueRuns(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), adm, []pipeline.RunPipelineOpts{
+		{Name: "task-a"},
+		{Name: "task-b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateMeta(adm.repoID, runIDs[1], func(m *store.RunMeta) {
+		m.Queue.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := QueueRm(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), adm.repoRoot, QueueRmOpts{RunID: runIDs[0]}, &stdout, &stderr); err != nil {
+		t.Fatalf("QueueRm() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "removed: task-a") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if _, err := os.Stat(st.RunDir(adm.repoID, runIDs[0])); !os.IsNotExist(err) {
+		t.Errorf("run dir still exists: %v", err)
+	}
+
+	err = QueueRm(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), adm.repoRoot, QueueRmOpts{RunID: runIDs[1]}, &stdout, &stderr)
+	if errors.GetCode(err) != errors.ENotQueued {
+		t.Errorf("rm of starting run error = %v, want E_NOT_QUEUED", err)
+	}
+}
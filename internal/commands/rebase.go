@@ -0,0 +1,328 @@
+package commands
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/git"
+	"github.com/NielsdaWheelz/agency/internal/lock"
+	"github.com/NielsdaWheelz/agency/internal/render"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+// NeedsAttentionReasonRebaseConflict is the reason set when a rebase stops on conflicts.
+const NeedsAttentionReasonRebaseConflict = "rebase_conflict"
+
+// RebaseOpts holds options for the rebase command.
+type RebaseOpts struct {
+	// RunID is the run reference (name, run_id, or unique prefix).
+	RunID string
+
+	// RepoPath is the optional --repo flag to scope name resolution.
+	RepoPath string
+
+	// Agent leaves a conflicted rebase in progress and sends resolution
+	// instructions to the runner's tmux session instead of aborting.
+	Agent bool
+}
+
+// Rebase rebases a run branch onto the up-to-date parent branch.
+// Works from any directory; resolves runs globally.
+func Rebase(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts RebaseOpts, stdout, stderr io.Writer) error {
+	tmuxClient := tmux.NewExecClient(cr)
+	return RebaseWithTmux(ctx, cr, fsys, tmuxClient, cwd, opts, stdout, stderr)
+}
+
+// RebaseWithTmux rebases a run using the provided tmux client.
+// This variant is used for testing with a fake tmux client.
+//
+// Operations:
+//  1. Check the worktree is present, has no tracked changes, and no rebase is in progress
+//  2. With --agent, check the runner's tmux session is alive
+//  3. Under the repo lock: fetch origin, pick the newer of <parent> and origin/<parent>, rebase
+//  4. On conflict: abort and print the conflict card, or (--agent) leave the
+//     rebase in progress and send instructions to the runner; either way set
+//     needs_attention_reason=rebase_conflict
+//  5. On success: record last_rebase_at (if the branch moved) and clear a rebase_conflict flag
+func RebaseWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, tmuxClient tmux.Client, cwd string, opts RebaseOpts, stdout, stderr io.Writer) error {
+	if opts.RunID == "" {
+		return errors.New(errors.EUsage, "run_id is required")
+	}
+
+	rctx, err := ResolveRunContext(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	resolved, err := ResolveRun(rctx, opts.RunID)
+	if err != nil {
+		return err
+	}
+	if resolved.Broken || resolved.Record == nil || resolved.Record.Meta == nil {
+		return errors.NewWithDetails(
+			errors.ERunBroken,
+			"run exists but meta.json is unreadable or invalid",
+			map[string]string{"run_id": resolved.RunID, "repo_id": resolved.RepoID},
+		)
+	}
+	meta := resolved.Record.Meta
+	repoID, runID := resolved.RepoID, resolved.RunID
+	workDir := meta.WorktreePath
+
+	st := store.NewStore(fsys, rctx.DataDir, time.Now)
+	eventsPath := st.EventsPath(repoID, runID)
+	fail := func(step string, err error) error {
+		appendRebaseEvent(eventsPath, repoID, runID, "rebase_failed", map[string]any{
+			"error_code": string(errors.GetCode(err)),
+			"step":       step,
+		})
+		return err
+	}
+
+	// Step 1: Worktree checks
+	if info, statErr := os.Stat(workDir); statErr != nil || !info.IsDir() {
+		return errors.NewWithDetails(
+			errors.EWorktreeMissing,
+			"worktree path missing on disk (run may be archived)",
+			map[string]string{"worktree_path": workDir},
+		)
+	}
+	if rebaseInProgress(ctx, cr, workDir) {
+		return errors.NewWithDetails(
+			errors.ERebaseConflict,
+			"a rebase is already in progress in the run worktree",
+			map[string]string{
+				"worktree_path": workDir,
+				"hint":          "finish it with git rebase --continue, or git rebase --abort",
+			},
+		)
+	}
+	status, ok := gitText(ctx, cr, workDir, []string{"status", "--porcelain", "--untracked-files=no"})
+	if !ok {
+		return errors.New(errors.EInternal, "git status failed in run worktree")
+	}
+	if strings.TrimSpace(status) != "" {
+		return errors.NewWithDetails(
+			errors.EDirtyWorktree,
+			"run worktree has uncommitted changes; commit or stash them before rebasing",
+			map[string]string{"worktree_path": workDir},
+		)
+	}
+
+	// Step 2: --agent needs a live runner to hand the conflict to
+	var sessionName string
+	if opts.Agent {
+		if sessionName, err = runnerSession(ctx, tmuxClient, meta, opts.RunID); err != nil {
+			return err
+		}
+	}
+
+	// Step 3: Rebase under the repo lock
+	unlock, err := lock.NewRepoLock(rctx.DataDir).Lock(repoID, "rebase")
+	if err != nil {
+		var lockErr *lock.ErrLocked
+		if stderrors.As(err, &lockErr) {
+			return errors.New(errors.ERepoLocked, lockErr.Error())
+		}
+		return errors.Wrap(errors.EInternal, "failed to acquire repo lock", err)
+	}
+	defer func() { _ = unlock() }()
+
+	appendRebaseEvent(eventsPath, repoID, runID, "rebase_started", map[string]any{"agent": opts.Agent})
+
+	if git.GetOriginURL(ctx, cr, workDir) != "" {
+		if err := gitFetchOrigin(ctx, cr, workDir); err != nil {
+			return fail("git_fetch", err)
+		}
+	}
+	onto, err := resolveRebaseOnto(ctx, cr, workDir, meta.ParentBranch)
+	if err != nil {
+		return fail("parent_ref", err)
+	}
+
+	before, ok := gitText(ctx, cr, workDir, []string{"rev-parse", "HEAD"})
+	if !ok {
+		return fail("rev_parse", errors.New(errors.EInternal, "failed to read run HEAD"))
+	}
+	before = strings.TrimSpace(before)
+
+	env := nonInteractiveEnv()
+	env["GIT_EDITOR"] = "true"
+	result, err := cr.Run(ctx, "git", []string{"rebase", onto}, agencyexec.RunOpts{Dir: workDir, Env: env})
+	if err != nil {
+		return fail("git_rebase", errors.Wrap(errors.EInternal, "git rebase failed to start", err))
+	}
+
+	if result.ExitCode != 0 {
+		files := conflictedFiles(ctx, cr, workDir)
+		if len(files) == 0 {
+			_, _ = gitText(ctx, cr, workDir, []string{"rebase", "--abort"})
+			return fail("git_rebase", errors.NewWithDetails(
+				errors.ERebaseFailed,
+				"git rebase failed: "+strings.TrimSpace(result.Stderr),
+				map[string]string{"onto": onto},
+			))
+		}
+		return rebaseConflict(ctx, cr, st, tmuxClient, resolved.Record, opts, onto, sessionName, files, stdout, stderr)
+	}
+
+	// Step 5: Record the result
+	after, _ := gitText(ctx, cr, workDir, []string{"rev-parse", "HEAD"})
+	after = strings.TrimSpace(after)
+	changed := after != before
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		if changed {
+			m.LastRebaseAt = now
+		}
+		if m.Flags != nil && m.Flags.NeedsAttentionReason == NeedsAttentionReasonRebaseConflict {
+			m.Flags.NeedsAttention = false
+			m.Flags.NeedsAttentionReason = ""
+		}
+	}); err != nil {
+		return err
+	}
+	appendRebaseEvent(eventsPath, repoID, runID, "rebase_finished", map[string]any{
+		"onto":     onto,
+		"from_sha": before,
+		"to_sha":   after,
+		"changed":  changed,
+	})
+
+	if !changed {
+		_, _ = fmt.Fprintf(stdout, "%s is already up to date with %s\n", meta.Branch, onto)
+		return nil
+	}
+	_, _ = fmt.Fprintf(stdout, "rebased %s onto %s (%s -> %s)\n", meta.Branch, onto, shortSHA(before), shortSHA(after))
+	if meta.LastPushAt != "" {
+		_, _ = fmt.Fprintf(stdout, "next: agency push %s --force-with-lease\n", opts.RunID)
+	}
+	return nil
+}
+
+// rebaseConflict handles a rebase that stopped on conflicts: by default the
+// rebase is aborted and the conflict card printed; with --agent it is left in
+// progress and the runner is told to resolve it.
+func rebaseConflict(ctx context.Context, cr agencyexec.CommandRunner, st *store.Store, tmuxClient tmux.Client, rec *store.RunRecord, opts RebaseOpts, onto, sessionName string, files []string, stdout, stderr io.Writer) error {
+	meta := rec.Meta
+	eventsPath := st.EventsPath(rec.RepoID, rec.RunID)
+
+	metaErr := st.UpdateMeta(rec.RepoID, rec.RunID, func(m *store.RunMeta) {
+		if m.Flags == nil {
+			m.Flags = &store.RunMetaFlags{}
+		}
+		m.Flags.NeedsAttention = true
+		m.Flags.NeedsAttentionReason = NeedsAttentionReasonRebaseConflict
+	})
+	appendRebaseEvent(eventsPath, rec.RepoID, rec.RunID, "rebase_conflict", map[string]any{
+		"onto":  onto,
+		"files": files,
+		"agent": opts.Agent,
+	})
+
+	if opts.Agent {
+		instruction := fmt.Sprintf(
+			"agency rebase: rebasing %s onto %s stopped with conflicts in %s. "+
+				"Resolve the conflicts, git add the files, and run git rebase --continue until the rebase completes. Do not abort the rebase.",
+			meta.Branch, onto, strings.Join(files, ", "))
+		if err := sendToRunner(ctx, tmuxClient, sessionName, instruction); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(stdout, "rebase onto %s stopped on conflicts in %s\n", onto, strings.Join(files, ", "))
+		_, _ = fmt.Fprintf(stdout, "resolution instructions sent to %s; the rebase is left in progress\n", sessionName)
+		return metaErr
+	}
+
+	_, _ = gitText(ctx, cr, meta.WorktreePath, []string{"rebase", "--abort"})
+	render.WriteConflictCard(stderr, render.ConflictCardInputs{
+		Ref:          opts.RunID,
+		PRURL:        meta.PRURL,
+		PRNumber:     meta.PRNumber,
+		Base:         meta.ParentBranch,
+		Branch:       meta.Branch,
+		WorktreePath: meta.WorktreePath,
+	})
+	if metaErr != nil {
+		return metaErr
+	}
+	return errors.NewWithDetails(
+		errors.ERebaseConflict,
+		fmt.Sprintf("rebase onto %s conflicts in %s; the rebase was aborted", onto, strings.Join(files, ", ")),
+		map[string]string{"onto": onto, "files": strings.Join(files, ",")},
+	)
+}
+
+// resolveRebaseOnto picks the rebase target: origin/<parent> when the local
+// parent is missing or is an ancestor of it (stale), otherwise the local parent.
+func resolveRebaseOnto(ctx context.Context, cr agencyexec.CommandRunner, workDir, parentBranch string) (string, error) {
+	localExists, err := refExists(ctx, cr, workDir, "refs/heads/"+parentBranch)
+	if err != nil {
+		return "", err
+	}
+	remoteExists, err := refExists(ctx, cr, workDir, "refs/remotes/origin/"+parentBranch)
+	if err != nil {
+		return "", err
+	}
+
+	remote := "origin/" + parentBranch
+	switch {
+	case localExists && remoteExists:
+		if _, ok := gitText(ctx, cr, workDir, []string{"merge-base", "--is-ancestor", parentBranch, remote}); ok {
+			return remote, nil
+		}
+		return parentBranch, nil
+	case localExists:
+		return parentBranch, nil
+	case remoteExists:
+		return remote, nil
+	}
+	return "", errors.NewWithDetails(
+		errors.EParentNotFound,
+		fmt.Sprintf("parent branch %q not found locally or on origin", parentBranch),
+		map[string]string{"parent_branch": parentBranch},
+	)
+}
+
+// rebaseInProgress reports whether the worktree has a stopped rebase.
+func rebaseInProgress(ctx context.Context, cr agencyexec.CommandRunner, workDir string) bool {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		path := git.GitPath(ctx, cr, workDir, name)
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictedFiles returns the unmerged paths in the worktree.
+func conflictedFiles(ctx context.Context, cr agencyexec.CommandRunner, workDir string) []string {
+	out, ok := gitText(ctx, cr, workDir, []string{"diff", "--name-only", "--diff-filter=U"})
+	if !ok {
+		return nil
+	}
+	return strings.Fields(out)
+}
+
+// appendRebaseEvent appends a rebase event to events.jsonl (best-effort).
+func appendRebaseEvent(eventsPath, repoID, runID, eventName string, data map[string]any) {
+	_ = events.AppendEvent(eventsPath, events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		RepoID:        repoID,
+		RunID:         runID,
+		Event:         eventName,
+		Data:          data,
+	})
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+// commitOnMain commits a file on main in repoDir.
+func commitOnMain(t *testing.T, repoDir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cr := exec.NewRealRunner()
+	for _, args := range [][]string{{"add", "."}, {"commit", "-m", "Change " + name}} {
+		result, err := cr.Run(context.Background(), "git", args, exec.RunOpts{Dir: repoDir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+}
+
+func loadRebaseTestMeta(t *testing.T, runID string) *store.RunMeta {
+	t.Helper()
+	dataDir := os.Getenv("AGENCY_DATA_DIR")
+	st := store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+	meta, err := st.ReadMeta("repo123456789012", runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return meta
+}
+
+func TestRebase_OntoParent(t *testing.T) {
+	repoDir, worktreePath, runID := setupDiffTestRun(t)
+
+	var stdout, stderr bytes.Buffer
+	opts := RebaseOpts{RunID: runID}
+	err := RebaseWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, repoDir, opts, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Rebase() error = %v, stderr: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "rebased agency/feature-a3f2 onto main") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(worktreePath, "later.txt")); err != nil {
+		t.Errorf("worktree missing parent change after rebase: %v", err)
+	}
+	if meta := loadRebaseTestMeta(t, runID); meta.LastRebaseAt == "" {
+		t.Error("last_rebase_at not set")
+	}
+
+	// A second rebase has nothing to do
+	stdout.Reset()
+	err = RebaseWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, repoDir, opts, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("second Rebase() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "already up to date") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+}
+
+func TestRebase_ConflictAborts(t *testing.T) {
+	repoDir, worktreePath, runID := setupDiffTestRun(t)
+	commitOnMain(t, repoDir, "feature.go", "package other\n")
+
+	var stdout, stderr bytes.Buffer
+	err := RebaseWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, repoDir, RebaseOpts{RunID: runID}, &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.ERebaseConflict {
+		t.Fatalf("error code = %q, want %q (err: %v)", code, errors.ERebaseConflict, err)
+	}
+	if !strings.Contains(stderr.String(), "git rebase origin/main") {
+		t.Errorf("conflict card not printed:\n%s", stderr.String())
+	}
+	if rebaseInProgress(context.Background(), exec.NewRealRunner(), worktreePath) {
+		t.Error("rebase should have been aborted")
+	}
+
+	meta := loadRebaseTestMeta(t, runID)
+	if meta.Flags == nil || !meta.Flags.NeedsAttention || meta.Flags.NeedsAttentionReason != NeedsAttentionReasonRebaseConflict {
+		t.Errorf("flags = %+v", meta.Flags)
+	}
+}
+
+func TestRebase_ConflictAgent(t *testing.T) {
+	repoDir, worktreePath, runID := setupDiffTestRun(t)
+	commitOnMain(t, repoDir, "feature.go", "package other\n")
+
+	tc := &fakeTmuxClient{hasSessionResult: true}
+	var stdout, stderr bytes.Buffer
+	err := RebaseWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, repoDir, RebaseOpts{RunID: runID, Agent: true}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Rebase() error = %v", err)
+	}
+	if len(tc.sendTextCalls) != 1 || !strings.Contains(tc.sendTextCalls[0], "feature.go") {
+		t.Errorf("sendText calls = %q", tc.sendTextCalls)
+	}
+	if len(tc.sendKeysCalls) != 1 || tc.sendKeysCalls[0].Keys[0] != tmux.KeyEnter {
+		t.Errorf("sendKeys calls = %+v", tc.sendKeysCalls)
+	}
+	if !rebaseInProgress(context.Background(), exec.NewRealRunner(), worktreePath) {
+		t.Error("rebase should be left in progress for the runner")
+	}
+	if meta := loadRebaseTestMeta(t, runID); meta.Flags == nil || meta.Flags.NeedsAttentionReason != NeedsAttentionReasonRebaseConflict {
+		t.Errorf("flags = %+v", meta.Flags)
+	}
+}
+
+func TestRebase_AgentNeedsSession(t *testing.T) {
+	repoDir, _, runID := setupDiffTestRun(t)
+
+	var stdout, stderr bytes.Buffer
+	err := RebaseWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), &fakeTmuxClient{}, repoDir, RebaseOpts{RunID: runID, Agent: true}, &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.ETmuxSessionMissing {
+		t.Fatalf("error code = %q, want %q", code, errors.ETmuxSessionMissing)
+	}
+}
+
+func TestRebasedSincePush(t *testing.T) {
+	tests := []struct {
+		push, rebase string
+		want         bool
+	}{
+		{"", "2026-01-15T12:00:00Z", false},
+		{"2026-01-15T12:00:00Z", "", false},
+		{"2026-01-15T12:00:00Z", "2026-01-15T11:00:00Z", false},
+		{"2026-01-15T12:00:00Z", "2026-01-15T13:00:00Z", true},
+	}
+	for _, tt := range tests {
+		meta := &store.RunMeta{LastPushAt: tt.push, LastRebaseAt: tt.rebase}
+		if got := rebasedSincePush(meta); got != tt.want {
+			t.Errorf("rebasedSincePush(push=%q, rebase=%q) = %v, want %v", tt.push, tt.rebase, got, tt.want)
+		}
+	}
+}
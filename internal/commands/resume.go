@@ -12,12 +12,15 @@ import (
 	"time"
 
 	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/core"
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	"github.com/NielsdaWheelz/agency/internal/events"
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/lifecycle"
 	"github.com/NielsdaWheelz/agency/internal/lock"
 	"github.com/NielsdaWheelz/agency/internal/paths"
+	"github.com/NielsdaWheelz/agency/internal/sessionlog"
 	"github.com/NielsdaWheelz/agency/internal/store"
 	"github.com/NielsdaWheelz/agency/internal/tmux"
 	"github.com/NielsdaWheelz/agency/internal/tty"
@@ -39,6 +42,9 @@ type ResumeOpts struct {
 
 	// Yes skips confirmation prompt for --restart when session exists.
 	Yes bool
+
+	// Fresh starts a new runner conversation instead of continuing the last one.
+	Fresh bool
 }
 
 // isInteractive is a package-level var for testing override.
@@ -142,6 +148,11 @@ func ResumeWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS
 		return errors.Wrap(errors.ETmuxNotInstalled, "failed to check tmux session", err)
 	}
 
+	// Record the previous runner exit before a new session replaces it (best-effort)
+	if !sessionExists {
+		reconcileRunnerExit(st, resolved.Record, nil)
+	}
+
 	// Resolve user config for runner resolution
 	userCfg, _, err := config.LoadUserConfig(fsys, dirs.ConfigDir)
 	if err != nil {
@@ -159,6 +170,11 @@ func ResumeWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS
 		return handleRestart(ctx, cr, fsys, tmuxClient, st, repoID, opts, meta, sessionName, sessionExists, runnerCmd, stdin, stdout, stderr, eventsPath, dataDir)
 	}
 
+	// Runner exited but the session lives on as a shell: relaunch inside it
+	if sessionExists && lifecycle.RunnerInShell(meta, sessionExists) {
+		return handleRelaunch(ctx, tmuxClient, st, repoID, opts, meta, sessionName, runnerCmd, stdout, stderr, eventsPath, dataDir)
+	}
+
 	// Handle attach or create path
 	if sessionExists {
 		// Append resume_attach event
@@ -208,7 +224,11 @@ func handleRestart(
 		}
 
 		// Prompt for confirmation (user interaction via stderr)
-		_, _ = fmt.Fprintf(stderr, "restart session? in-tool history will be lost (git state unchanged) [y/N]: ")
+		prompt := "restart session? the runner conversation is continued when possible (git state unchanged) [y/N]: "
+		if opts.Fresh {
+			prompt = "restart session? in-tool history will be lost (git state unchanged) [y/N]: "
+		}
+		_, _ = fmt.Fprint(stderr, prompt)
 		scanner := bufio.NewScanner(stdin)
 		if !scanner.Scan() {
 			// No input - treat as cancel
@@ -250,12 +270,25 @@ func handleRestart(
 		}
 	}
 
-	// Create new session
-	argv := []string{runnerCmd}
-	if err := tmuxClient.NewSession(ctx, sessionName, meta.WorktreePath, argv); err != nil {
+	// Create new session, continuing the runner's conversation unless --fresh
+	launchCmd, runnerSessionID := runnerLaunchCmd(st, repoID, meta, runnerCmd, opts.Fresh, stderr)
+	script, err := runnerSessionScript(dataDir, repoID, meta, launchCmd)
+	if err != nil {
+		return err
+	}
+	if err := tmuxClient.NewSession(ctx, sessionName, meta.WorktreePath, []string{"sh", "-lc", script}); err != nil {
 		return errors.Wrap(errors.ETmuxFailed, "failed to create tmux session", err)
 	}
 
+	// New runner session: record its start and clear the recorded exit (best-effort)
+	_ = lifecycle.RecordRunnerStart(st, repoID, opts.RunID)
+
+	// Restart the checkpoint watcher and run monitor if they stopped with the
+	// previous session
+	for _, w := range startRunWatchers(st, repoID, opts.RunID) {
+		_, _ = fmt.Fprintf(stderr, "warning: %s\n", w)
+	}
+
 	// Append resume_restart event
 	_ = events.AppendEvent(eventsPath, events.Event{
 		SchemaVersion: "1.0",
@@ -263,7 +296,7 @@ func handleRestart(
 		RepoID:        repoID,
 		RunID:         opts.RunID,
 		Event:         "resume_restart",
-		Data:          events.ResumeData(sessionName, meta.Runner, opts.Detached, true),
+		Data:          resumeCreateData(sessionName, meta.Runner, opts.Detached, true, runnerSessionID),
 	})
 
 	if opts.Detached {
@@ -328,12 +361,25 @@ func handleCreateSession(
 		return attachToTmuxSession(sessionName, stdout, stderr)
 	}
 
-	// Create new session
-	argv := []string{runnerCmd}
-	if err := tmuxClient.NewSession(ctx, sessionName, meta.WorktreePath, argv); err != nil {
+	// Create new session, continuing the runner's conversation unless --fresh
+	launchCmd, runnerSessionID := runnerLaunchCmd(st, repoID, meta, runnerCmd, opts.Fresh, stderr)
+	script, err := runnerSessionScript(dataDir, repoID, meta, launchCmd)
+	if err != nil {
+		return err
+	}
+	if err := tmuxClient.NewSession(ctx, sessionName, meta.WorktreePath, []string{"sh", "-lc", script}); err != nil {
 		return errors.Wrap(errors.ETmuxFailed, "failed to create tmux session", err)
 	}
 
+	// New runner session: record its start and clear the recorded exit (best-effort)
+	_ = lifecycle.RecordRunnerStart(st, repoID, opts.RunID)
+
+	// Restart the checkpoint watcher and run monitor if they stopped with the
+	// previous session
+	for _, w := range startRunWatchers(st, repoID, opts.RunID) {
+		_, _ = fmt.Fprintf(stderr, "warning: %s\n", w)
+	}
+
 	// Append resume_create event
 	_ = events.AppendEvent(eventsPath, events.Event{
 		SchemaVersion: "1.0",
@@ -341,7 +387,7 @@ func handleCreateSession(
 		RepoID:        repoID,
 		RunID:         opts.RunID,
 		Event:         "resume_create",
-		Data:          events.ResumeData(sessionName, meta.Runner, opts.Detached, false),
+		Data:          resumeCreateData(sessionName, meta.Runner, opts.Detached, false, runnerSessionID),
 	})
 
 	if opts.Detached {
@@ -350,3 +396,128 @@ func handleCreateSession(
 	}
 	return attachToTmuxSession(sessionName, stdout, stderr)
 }
+
+// handleRelaunch starts the runner again inside a session whose runner has
+// exited, by typing the session script into the session's shell. The shell
+// is replaced (exec), so relaunching does not nest shells.
+func handleRelaunch(
+	ctx context.Context,
+	tmuxClient tmux.Client,
+	st *store.Store,
+	repoID string,
+	opts ResumeOpts,
+	meta *store.RunMeta,
+	sessionName string,
+	runnerCmd string,
+	stdout, stderr io.Writer,
+	eventsPath, dataDir string,
+) error {
+	rl := lock.NewRepoLock(dataDir)
+	unlock, err := rl.Lock(repoID, "resume")
+	if err != nil {
+		if _, ok := err.(*lock.ErrLocked); ok {
+			return errors.New(errors.ERepoLocked, err.Error())
+		}
+		return errors.Wrap(errors.EInternal, "failed to acquire repo lock", err)
+	}
+	defer func() {
+		if uerr := unlock(); uerr != nil {
+			_ = uerr // Lock package handles logging internally
+		}
+	}()
+
+	launchCmd, runnerSessionID := runnerLaunchCmd(st, repoID, meta, runnerCmd, opts.Fresh, stderr)
+	script, err := runnerSessionScript(dataDir, repoID, meta, launchCmd)
+	if err != nil {
+		return err
+	}
+	if err := sendToRunner(ctx, tmuxClient, sessionName, "exec sh -lc "+core.ShellEscapePosix(script)); err != nil {
+		return err
+	}
+
+	// New runner: record its start and clear the recorded exit (best-effort)
+	_ = lifecycle.RecordRunnerStart(st, repoID, opts.RunID)
+
+	for _, w := range startRunWatchers(st, repoID, opts.RunID) {
+		_, _ = fmt.Fprintf(stderr, "warning: %s\n", w)
+	}
+
+	_ = events.AppendEvent(eventsPath, events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		RepoID:        repoID,
+		RunID:         opts.RunID,
+		Event:         "resume_relaunch",
+		Data:          resumeCreateData(sessionName, meta.Runner, opts.Detached, false, runnerSessionID),
+	})
+
+	if opts.Detached {
+		_, _ = fmt.Fprintf(stdout, "ok: runner relaunched in session %s\n", sessionName)
+		return nil
+	}
+	return attachToTmuxSession(sessionName, stdout, stderr)
+}
+
+// runnerSessionScript returns the tmux pane script that runs launchCmd and
+// keeps the session alive as a shell in the worktree once it exits.
+func runnerSessionScript(dataDir, repoID string, meta *store.RunMeta, launchCmd string) (string, error) {
+	runRef := meta.Name
+	if runRef == "" {
+		runRef = meta.RunID
+	}
+	return lifecycle.RunnerSessionScript(lifecycle.SessionScriptOpts{
+		DataDir:      dataDir,
+		RepoID:       repoID,
+		RunID:        meta.RunID,
+		RunRef:       runRef,
+		WorktreePath: meta.WorktreePath,
+		RunnerCmd:    launchCmd,
+	})
+}
+
+// runnerLaunchCmd returns the command for a new runner session and the runner
+// session id it continues ("" for a fresh conversation). Unless fresh is set,
+// a newer session log for the worktree is preferred over the recorded id, and
+// a newly discovered id is recorded in meta.json. A fresh conversation clears
+// the recorded id so the new session is discovered once the runner writes it.
+func runnerLaunchCmd(st *store.Store, repoID string, meta *store.RunMeta, runnerCmd string, fresh bool, stderr io.Writer) (string, string) {
+	if fresh {
+		if meta.RunnerSessionID != "" {
+			_ = st.UpdateMeta(repoID, meta.RunID, func(m *store.RunMeta) {
+				m.RunnerSessionID = ""
+			})
+			meta.RunnerSessionID = ""
+		}
+		return runnerCmd, ""
+	}
+
+	rec := &store.RunRecord{RepoID: repoID, RunID: meta.RunID, Meta: meta}
+	recordRunnerSession(st, rec, findRunnerSession(meta))
+
+	args := sessionlog.ResumeArgs(meta.Runner, meta.RunnerSessionID)
+	if args == nil {
+		if meta.RunnerSessionID == "" {
+			_, _ = fmt.Fprintln(stderr, "note: no runner session found; starting a fresh conversation")
+		} else {
+			_, _ = fmt.Fprintf(stderr, "note: runner %s cannot continue a session; starting a fresh conversation\n", meta.Runner)
+		}
+		return runnerCmd, ""
+	}
+
+	cmd := runnerCmd
+	for _, arg := range args {
+		cmd += " " + core.ShellEscapePosix(arg)
+	}
+	return cmd, meta.RunnerSessionID
+}
+
+// resumeCreateData returns the event data for a resume that started a runner,
+// recording the continued runner session (if any).
+func resumeCreateData(sessionName, runner string, detached, restart bool, runnerSessionID string) map[string]any {
+	data := events.ResumeData(sessionName, runner, detached, restart)
+	data["fresh"] = runnerSessionID == ""
+	if runnerSessionID != "" {
+		data["runner_session_id"] = runnerSessionID
+	}
+	return data
+}
@@ -31,6 +31,8 @@ type resumeFakeTmuxClient struct {
 	killCalls []string
 	killErr   error
 
+	sendTextCalls []string
+
 	callIndex int // tracks which hasSessionResult to return
 }
 
@@ -72,6 +74,11 @@ func (f *resumeFakeTmuxClient) SendKeys(ctx context.Context, name string, keys [
 	return nil
 }
 
+func (f *resumeFakeTmuxClient) SendText(ctx context.Context, name, text string) error {
+	f.sendTextCalls = append(f.sendTextCalls, text)
+	return nil
+}
+
 // setupResumeTestEnv creates a temporary test environment for resume tests.
 // If createWorktree is true, also creates the worktree directory.
 func setupResumeTestEnv(t *testing.T, runID string, setupMeta, createWorktree bool, archived bool) (string, string, string, *fakeCommandRunner, fs.FS) {
@@ -253,6 +260,51 @@ func TestResume_SessionExists_Detached(t *testing.T) {
 	}
 }
 
+func TestResume_RunnerExited_RelaunchInSession(t *testing.T) {
+	runID := "20260110120000-a3f2"
+	repoDir, dataDir, repoID, cr, fsys := setupResumeTestEnv(t, runID, true, true, false)
+
+	st := store.NewStore(fsys, dataDir, nil)
+	code := 0
+	if err := st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.RunnerExitCode = &code
+		m.ExitReason = store.ExitReasonExited
+		m.RunnerExitedAt = "2026-01-10T13:00:00Z"
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeTmux := &resumeFakeTmuxClient{hasSessionResults: []bool{true}}
+	var stdout, stderr bytes.Buffer
+	opts := ResumeOpts{RunID: runID, Detached: true}
+	if err := ResumeWithTmux(context.Background(), cr, fsys, fakeTmux, repoDir, opts, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	// The runner is relaunched in the existing session's shell
+	if len(fakeTmux.newSessionCalls) != 0 || len(fakeTmux.killCalls) != 0 {
+		t.Errorf("session should be reused: new=%d kill=%d", len(fakeTmux.newSessionCalls), len(fakeTmux.killCalls))
+	}
+	if len(fakeTmux.sendTextCalls) != 1 || !strings.HasPrefix(fakeTmux.sendTextCalls[0], "exec sh -lc ") {
+		t.Fatalf("sendText calls = %q", fakeTmux.sendTextCalls)
+	}
+	if !strings.Contains(stdout.String(), "ok: runner relaunched in session") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.RunnerExitedAt != "" || meta.RunnerExitCode != nil {
+		t.Errorf("runner exit not cleared: %q %v", meta.RunnerExitedAt, meta.RunnerExitCode)
+	}
+	eventsData, err := os.ReadFile(st.EventsPath(repoID, runID))
+	if err != nil || !strings.Contains(string(eventsData), `"event":"resume_relaunch"`) {
+		t.Errorf("events.jsonl = %s (err %v)", eventsData, err)
+	}
+}
+
 func TestResume_SessionMissing_CreateSession(t *testing.T) {
 	runID := "20260110120000-a3f2"
 	repoDir, dataDir, repoID, cr, fsys := setupResumeTestEnv(t, runID, true, true, false)
@@ -279,8 +331,15 @@ func TestResume_SessionMissing_CreateSession(t *testing.T) {
 	if call.Name != expectedSession {
 		t.Errorf("NewSession name = %q, want %q", call.Name, expectedSession)
 	}
-	if len(call.Argv) != 1 || filepath.Base(call.Argv[0]) != "claude" {
-		t.Errorf("NewSession argv = %v, want [claude]", call.Argv)
+	// The runner runs under a wrapper that keeps the session alive after it exits
+	if len(call.Argv) != 3 || call.Argv[0] != "sh" || call.Argv[1] != "-lc" {
+		t.Fatalf("NewSession argv = %q, want [sh -lc <script>]", call.Argv)
+	}
+	if lines := strings.Split(call.Argv[2], "\n"); len(lines) < 2 || filepath.Base(lines[1]) != "claude" {
+		t.Errorf("session script does not run claude:\n%s", call.Argv[2])
+	}
+	if !strings.Contains(call.Argv[2], " runner-exited ") || !strings.Contains(call.Argv[2], `exec "${SHELL:-/bin/sh}" -l`) {
+		t.Errorf("session script does not record the exit and fall back to a shell:\n%s", call.Argv[2])
 	}
 
 	// Verify resume_create event was written
@@ -295,6 +354,69 @@ func TestResume_SessionMissing_CreateSession(t *testing.T) {
 	}
 }
 
+func TestResume_ContinuesRunnerSession(t *testing.T) {
+	runID := "20260110120000-a3f2"
+	repoDir, dataDir, repoID, cr, fsys := setupResumeTestEnv(t, runID, true, true, false)
+
+	// Claude session log for the run's worktree, named <session id>.jsonl
+	claudeDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", claudeDir)
+	st := store.NewStore(fsys, dataDir, nil)
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := "0b6f4c2e-9d1a-4e8b-a7c3-5f2d8e1b9a40"
+	logDir := filepath.Join(claudeDir, "projects", "wt")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"type":"user","cwd":"` + meta.WorktreePath + `","message":{"role":"user","content":"hi"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(logDir, sessionID+".jsonl"), []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resume := func(fresh bool) newSessionCall {
+		t.Helper()
+		fakeTmux := &resumeFakeTmuxClient{hasSessionResults: []bool{false, false}}
+		var stdout, stderr bytes.Buffer
+		opts := ResumeOpts{RunID: runID, Detached: true, Fresh: fresh}
+		if err := ResumeWithTmux(context.Background(), cr, fsys, fakeTmux, repoDir, opts, strings.NewReader(""), &stdout, &stderr); err != nil {
+			t.Fatalf("Resume() error = %v", err)
+		}
+		if len(fakeTmux.newSessionCalls) != 1 {
+			t.Fatalf("expected 1 NewSession call, got %d", len(fakeTmux.newSessionCalls))
+		}
+		return fakeTmux.newSessionCalls[0]
+	}
+
+	call := resume(false)
+	if len(call.Argv) != 3 || !strings.Contains(call.Argv[2], "claude '--resume' '"+sessionID+"'\n") {
+		t.Errorf("NewSession argv = %q, want claude --resume %s", call.Argv, sessionID)
+	}
+	meta, err = st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.RunnerSessionID != sessionID {
+		t.Errorf("runner_session_id = %q, want %q", meta.RunnerSessionID, sessionID)
+	}
+
+	// --fresh ignores the recorded session
+	call = resume(true)
+	if len(call.Argv) != 3 || strings.Contains(call.Argv[2], "--resume") {
+		t.Errorf("NewSession argv with --fresh = %q", call.Argv)
+	}
+
+	eventsData, err := os.ReadFile(filepath.Join(st.RunDir(repoID, runID), "events.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(eventsData), `"runner_session_id":"`+sessionID+`"`) {
+		t.Errorf("expected runner_session_id in resume_create event:\n%s", eventsData)
+	}
+}
+
 func TestResume_Restart_WithYes(t *testing.T) {
 	runID := "20260110120000-a3f2"
 	repoDir, dataDir, repoID, cr, fsys := setupResumeTestEnv(t, runID, true, true, false)
@@ -7,6 +7,9 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
@@ -35,65 +38,96 @@ type RunOpts struct {
 
 	// Attach indicates whether to attach after tmux creation.
 	Attach bool
+
+	// Headless runs the runner as a supervised subprocess instead of in tmux.
+	Headless bool
+
+	// Prompt is the prompt text for a headless run.
+	Prompt string
+
+	// PromptFile is a path to a file containing the prompt for a headless run.
+	PromptFile string
+
+	// Attempts creates this many sibling runs of the prompt in a best-of-N
+	// group (0 = a single run).
+	Attempts int
+
+	// Runners are cycled across attempts (empty = Runner for every attempt).
+	Runners []string
+
+	// Queue queues the run when the max_active_runs limits are reached
+	// instead of failing; it starts detached once a slot frees up.
+	Queue bool
+
+	// MaxDuration and MaxStall override the agency.json budgets (0 = use agency.json).
+	MaxDuration time.Duration
+	MaxStall    time.Duration
 }
 
 // RunResult holds the result of a successful run for output formatting.
 type RunResult struct {
 	RunID           string
+	RepoID          string
+	DataDir         string
 	Name            string
 	Runner          string
 	Parent          string
 	Branch          string
 	WorktreePath    string
 	TmuxSessionName string
+	Headless        bool
+	RawLogPath      string
+	StderrLogPath   string
 	Warnings        []pipeline.Warning
 }
 
 // Run executes the agency run command.
 // Creates a workspace, runs setup, starts tmux session.
 func Run(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts RunOpts, stdout, stderr io.Writer) error {
-	// Handle --repo path: if provided, use it instead of cwd
-	targetCwd := cwd
-	if opts.RepoPath != "" {
-		// Validate the path exists
-		info, err := os.Stat(opts.RepoPath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				return errors.NewWithDetails(
-					errors.EInvalidRepoPath,
-					fmt.Sprintf("--repo path does not exist: %s", opts.RepoPath),
-					map[string]string{"path": opts.RepoPath},
-				)
-			}
-			return errors.Wrap(errors.EInvalidRepoPath, "failed to stat --repo path", err)
-		}
-		if !info.IsDir() {
-			return errors.NewWithDetails(
-				errors.EInvalidRepoPath,
-				fmt.Sprintf("--repo path is not a directory: %s", opts.RepoPath),
-				map[string]string{"path": opts.RepoPath},
-			)
-		}
+	if opts.Attempts == 0 && len(opts.Runners) > 0 {
+		return errors.New(errors.EUsage, "--runners requires --attempts")
+	}
+	if err := validateBudgetFlags(opts.MaxDuration, opts.MaxStall); err != nil {
+		return err
+	}
 
-		// Verify it's inside a git repo
-		repoRoot, err := git.GetRepoRoot(ctx, cr, opts.RepoPath)
-		if err != nil {
-			return errors.NewWithDetails(
-				errors.EInvalidRepoPath,
-				fmt.Sprintf("--repo path is not inside a git repository: %s", opts.RepoPath),
-				map[string]string{"path": opts.RepoPath},
-			)
-		}
-		targetCwd = repoRoot.Path
+	// Resolve the prompt before any side effects
+	prompt, err := resolveRunPrompt(fsys, cwd, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Attempts != 0 {
+		return runAttempts(ctx, cr, fsys, cwd, opts, prompt, stdout, stderr)
+	}
+	if opts.Headless {
+		// Headless runs have no tmux session to attach to
+		opts.Attach = false
 	}
 
-	// Change working directory for pipeline if --repo was specified
-	origWd, _ := os.Getwd()
-	if targetCwd != cwd {
-		if err := os.Chdir(targetCwd); err != nil {
-			return errors.Wrap(errors.EInternal, "failed to change directory", err)
+	// Handle --repo path: if provided, use it instead of cwd
+	targetCwd, err := resolveRunTargetCwd(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+
+	// Enforce the max_active_runs limits before any side effects
+	adm, err := admitRuns(ctx, cr, fsys, targetCwd)
+	if err != nil {
+		return err
+	}
+	if adm.Free == 0 {
+		if !opts.Queue {
+			return adm.limitError(1)
 		}
-		defer func() { _ = os.Chdir(origWd) }()
+		return runQueued(ctx, cr, fsys, adm, pipeline.RunPipelineOpts{
+			Name:        opts.Name,
+			Runner:      opts.Runner,
+			Parent:      opts.Parent,
+			Headless:    opts.Headless,
+			Prompt:      prompt,
+			MaxDuration: opts.MaxDuration,
+			MaxStall:    opts.MaxStall,
+		}, stdout)
 	}
 
 	// Create the run service with production dependencies
@@ -104,10 +138,15 @@ func Run(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd strin
 
 	// Execute the pipeline
 	pipelineOpts := pipeline.RunPipelineOpts{
-		Name:   opts.Name,
-		Runner: opts.Runner,
-		Parent: opts.Parent,
-		Attach: opts.Attach,
+		Name:        opts.Name,
+		Runner:      opts.Runner,
+		Parent:      opts.Parent,
+		Attach:      opts.Attach,
+		Headless:    opts.Headless,
+		Prompt:      prompt,
+		MaxDuration: opts.MaxDuration,
+		MaxStall:    opts.MaxStall,
+		Dir:         targetCwd,
 	}
 
 	runID, err := p.Run(ctx, pipelineOpts)
@@ -132,7 +171,12 @@ func Run(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd strin
 		_, _ = fmt.Fprintf(stderr, "warning: %s\n", w.Message)
 	}
 
-	// Handle attach (default) - skip if --detached was specified
+	// Start the checkpoint watcher and run monitor (best-effort; never fails the run)
+	for _, w := range startRunWatchers(store.NewStore(fsys, result.DataDir, time.Now), result.RepoID, result.RunID) {
+		_, _ = fmt.Fprintf(stderr, "warning: %s\n", w)
+	}
+
+	// Handle attach (default) - skip if --detached or --headless was specified
 	if opts.Attach && result.TmuxSessionName != "" {
 		return attachToTmuxSessionRun(result.TmuxSessionName)
 	}
@@ -140,6 +184,85 @@ func Run(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd strin
 	return nil
 }
 
+// resolveRunTargetCwd returns the directory the run pipeline works from:
+// the repo root of --repo when given, otherwise cwd.
+func resolveRunTargetCwd(ctx context.Context, cr agencyexec.CommandRunner, cwd, repoPath string) (string, error) {
+	if repoPath == "" {
+		return cwd, nil
+	}
+
+	// Validate the path exists
+	info, err := os.Stat(repoPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.NewWithDetails(
+				errors.EInvalidRepoPath,
+				fmt.Sprintf("--repo path does not exist: %s", repoPath),
+				map[string]string{"path": repoPath},
+			)
+		}
+		return "", errors.Wrap(errors.EInvalidRepoPath, "failed to stat --repo path", err)
+	}
+	if !info.IsDir() {
+		return "", errors.NewWithDetails(
+			errors.EInvalidRepoPath,
+			fmt.Sprintf("--repo path is not a directory: %s", repoPath),
+			map[string]string{"path": repoPath},
+		)
+	}
+
+	// Verify it's inside a git repo
+	repoRoot, err := git.GetRepoRoot(ctx, cr, repoPath)
+	if err != nil {
+		return "", errors.NewWithDetails(
+			errors.EInvalidRepoPath,
+			fmt.Sprintf("--repo path is not inside a git repository: %s", repoPath),
+			map[string]string{"path": repoPath},
+		)
+	}
+	return repoRoot.Path, nil
+}
+
+// resolveRunPrompt validates the prompt flags and returns the prompt text.
+// Returns "" for single tmux runs.
+func resolveRunPrompt(fsys fs.FS, cwd string, opts RunOpts) (string, error) {
+	if opts.Prompt != "" && opts.PromptFile != "" {
+		return "", errors.New(errors.EUsage, "--prompt and --prompt-file are mutually exclusive")
+	}
+	if !opts.Headless && opts.Attempts == 0 {
+		if opts.Prompt != "" || opts.PromptFile != "" {
+			return "", errors.New(errors.EUsage, "--prompt and --prompt-file require --headless or --attempts")
+		}
+		return "", nil
+	}
+
+	prompt := opts.Prompt
+	if opts.PromptFile != "" {
+		path := opts.PromptFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return "", errors.WrapWithDetails(
+				errors.EUsage,
+				fmt.Sprintf("failed to read --prompt-file: %s", opts.PromptFile),
+				err,
+				map[string]string{"path": path},
+			)
+		}
+		prompt = string(data)
+	}
+
+	if strings.TrimSpace(prompt) == "" {
+		if opts.Attempts != 0 {
+			return "", errors.New(errors.EUsage, "--attempts requires a non-empty --prompt or --prompt-file")
+		}
+		return "", errors.New(errors.EUsage, "--headless requires a non-empty --prompt or --prompt-file")
+	}
+	return prompt, nil
+}
+
 // getRunResult reads the run metadata and constructs the result.
 func getRunResult(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, runID string) (*RunResult, error) {
 	// Resolve repo root
@@ -170,15 +293,23 @@ func getRunResult(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 		return nil, err
 	}
 
-	return &RunResult{
+	result := &RunResult{
 		RunID:           meta.RunID,
+		RepoID:          repoID,
+		DataDir:         dataDir,
 		Name:            meta.Name,
 		Runner:          meta.Runner,
 		Parent:          meta.ParentBranch,
 		Branch:          meta.Branch,
 		WorktreePath:    meta.WorktreePath,
 		TmuxSessionName: meta.TmuxSessionName,
-	}, nil
+	}
+	if meta.Headless != nil {
+		result.Headless = true
+		result.RawLogPath = meta.Headless.RawLogPath
+		result.StderrLogPath = meta.Headless.StderrLogPath
+	}
+	return result, nil
 }
 
 // printRunSuccess prints the success output in the required format.
@@ -192,6 +323,13 @@ func printRunSuccess(w io.Writer, result *RunResult, detached bool) {
 	_, _ = fmt.Fprintf(w, "parent: %s\n", result.Parent)
 	_, _ = fmt.Fprintf(w, "branch: %s\n", result.Branch)
 	_, _ = fmt.Fprintf(w, "worktree: %s\n", result.WorktreePath)
+	if result.Headless {
+		_, _ = fmt.Fprintf(w, "mode: headless\n")
+		_, _ = fmt.Fprintf(w, "raw_log: %s\n", result.RawLogPath)
+		_, _ = fmt.Fprintf(w, "stderr_log: %s\n", result.StderrLogPath)
+		_, _ = fmt.Fprintf(w, "next: agency show %s\n", result.Name)
+		return
+	}
 	_, _ = fmt.Fprintf(w, "tmux: %s\n", result.TmuxSessionName)
 	if detached {
 		_, _ = fmt.Fprintf(w, "next: agency attach %s\n", result.Name)
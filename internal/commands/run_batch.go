@@ -0,0 +1,336 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/NielsdaWheelz/agency/internal/core"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/pipeline"
+)
+
+// DefaultBatchConcurrency is the default number of setup scripts a batch runs at once.
+const DefaultBatchConcurrency = 4
+
+// RunBatchOpts holds options for `agency run --from`.
+type RunBatchOpts struct {
+	// From is the path to the task file (YAML, or JSON with a .json extension).
+	From string
+
+	// RepoPath is the optional --repo flag to target a specific repo.
+	RepoPath string
+
+	// Runner, Parent and Headless are defaults for tasks that do not set them.
+	Runner   string
+	Parent   string
+	Headless bool
+
+	// Concurrency caps how many setup scripts run at once
+	// (0 = the task file's concurrency, else DefaultBatchConcurrency).
+	Concurrency int
+
+	// Queue queues the tasks that exceed the max_active_runs limits instead
+	// of failing.
+	Queue bool
+
+	// MaxDuration and MaxStall override the agency.json budgets of every task.
+	MaxDuration time.Duration
+	MaxStall    time.Duration
+}
+
+// batchFile is the task file format.
+type batchFile struct {
+	Concurrency int         `json:"concurrency" yaml:"concurrency"`
+	Tasks       []batchTask `json:"tasks" yaml:"tasks"`
+}
+
+// batchTask is one run in a task file.
+type batchTask struct {
+	Name       string `json:"name" yaml:"name"`
+	Prompt     string `json:"prompt" yaml:"prompt"`
+	PromptFile string `json:"prompt_file" yaml:"prompt_file"`
+	Runner     string `json:"runner" yaml:"runner"`
+	Parent     string `json:"parent" yaml:"parent"`
+	Headless   *bool  `json:"headless" yaml:"headless"`
+}
+
+// batchResult is the outcome of one batch task.
+type batchResult struct {
+	Task  pipeline.RunPipelineOpts
+	RunID string
+	Err   error
+
+	// Queued is set when the task was queued rather than started.
+	Queued bool
+}
+
+// RunBatch creates one detached run per task in the task file. Every run goes
+// through the regular run pipeline; setup scripts run at most Concurrency at
+// a time. Prints a summary table, then each failure with its setup log.
+func RunBatch(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts RunBatchOpts, stdout, stderr io.Writer) error {
+	if err := validateBudgetFlags(opts.MaxDuration, opts.MaxStall); err != nil {
+		return err
+	}
+	tasks, concurrency, err := loadBatchTasks(fsys, cwd, opts)
+	if err != nil {
+		return err
+	}
+
+	targetCwd, err := resolveRunTargetCwd(ctx, cr, cwd, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stderr, "starting %d %s (setup concurrency %d)\n", len(tasks), plural(len(tasks), "run", "runs"), concurrency)
+	results, err := startBatchRuns(ctx, cr, fsys, targetCwd, tasks, concurrency, opts.Queue, stderr)
+	if err != nil {
+		return err
+	}
+
+	failed := printBatchSummary(stdout, results)
+	if failed > 0 {
+		return errors.New(errors.EBatchFailed, fmt.Sprintf("%d of %d runs failed", failed, len(results)))
+	}
+	return nil
+}
+
+// loadBatchTasks parses and validates the task file, resolving prompt files
+// (relative to the task file) and defaults. Nothing is created if any task is
+// invalid.
+func loadBatchTasks(fsys fs.FS, cwd string, opts RunBatchOpts) ([]pipeline.RunPipelineOpts, int, error) {
+	path := opts.From
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		return nil, 0, errors.WrapWithDetails(errors.EUsage, "failed to read task file: "+opts.From, err, map[string]string{"path": path})
+	}
+
+	var file batchFile
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&file)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&file)
+	}
+	if err != nil {
+		return nil, 0, errors.WrapWithDetails(errors.EInvalidTaskFile, "failed to parse task file", err, map[string]string{"path": path})
+	}
+	invalid := func(msg string) error {
+		return errors.NewWithDetails(errors.EInvalidTaskFile, msg, map[string]string{"path": path})
+	}
+	if len(file.Tasks) == 0 {
+		return nil, 0, invalid("task file has no tasks")
+	}
+
+	if opts.Concurrency < 0 {
+		return nil, 0, errors.New(errors.EUsage, "--concurrency must be positive")
+	}
+	if file.Concurrency < 0 {
+		return nil, 0, invalid("concurrency must be positive")
+	}
+	concurrency := opts.Concurrency
+	if concurrency == 0 {
+		concurrency = file.Concurrency
+	}
+	if concurrency == 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	seen := make(map[string]bool)
+	tasks := make([]pipeline.RunPipelineOpts, 0, len(file.Tasks))
+	for i, t := range file.Tasks {
+		label := fmt.Sprintf("task %d", i+1)
+		if t.Name != "" {
+			label += " (" + t.Name + ")"
+		}
+		if err := core.ValidateName(t.Name); err != nil {
+			return nil, 0, invalid(label + ": " + err.Error())
+		}
+		if seen[t.Name] {
+			return nil, 0, invalid(label + ": duplicate name")
+		}
+		seen[t.Name] = true
+		if t.Prompt != "" && t.PromptFile != "" {
+			return nil, 0, invalid(label + ": prompt and prompt_file are mutually exclusive")
+		}
+
+		prompt := t.Prompt
+		if t.PromptFile != "" {
+			promptPath := t.PromptFile
+			if !filepath.IsAbs(promptPath) {
+				promptPath = filepath.Join(filepath.Dir(path), promptPath)
+			}
+			b, err := fsys.ReadFile(promptPath)
+			if err != nil {
+				return nil, 0, errors.WrapWithDetails(errors.EInvalidTaskFile, label+": failed to read prompt_file", err, map[string]string{"path": promptPath})
+			}
+			prompt = string(b)
+		}
+
+		task := pipeline.RunPipelineOpts{
+			Name:        t.Name,
+			Runner:      t.Runner,
+			Parent:      t.Parent,
+			Headless:    opts.Headless,
+			Prompt:      prompt,
+			MaxDuration: opts.MaxDuration,
+			MaxStall:    opts.MaxStall,
+		}
+		if task.Runner == "" {
+			task.Runner = opts.Runner
+		}
+		if task.Parent == "" {
+			task.Parent = opts.Parent
+		}
+		if t.Headless != nil {
+			task.Headless = *t.Headless
+		}
+		if task.Headless && strings.TrimSpace(prompt) == "" {
+			return nil, 0, invalid(label + ": headless tasks require a prompt or prompt_file")
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, concurrency, nil
+}
+
+// runBatchTasks runs every task through its own pipeline concurrently and
+// returns the results in task order.
+func runBatchTasks(ctx context.Context, svc pipeline.RunService, tasks []pipeline.RunPipelineOpts) []batchResult {
+	results := make([]batchResult, len(tasks))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task pipeline.RunPipelineOpts) {
+			defer wg.Done()
+			runID, err := pipeline.NewPipeline(svc).Run(ctx, task)
+			results[i] = batchResult{Task: task, RunID: runID, Err: err}
+		}(i, task)
+	}
+	wg.Wait()
+	return results
+}
+
+// batchService wraps the run service for concurrent batch runs. Setup scripts
+// run at most limit at a time; the other steps touch shared git, tmux and
+// store state and run one at a time.
+type batchService struct {
+	pipeline.RunService
+	mu    sync.Mutex
+	slots chan struct{}
+}
+
+func newBatchService(svc pipeline.RunService, limit int) *batchService {
+	return &batchService{RunService: svc, slots: make(chan struct{}, limit)}
+}
+
+func (b *batchService) serial(fn func() error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return fn()
+}
+
+func (b *batchService) CheckRepoSafe(ctx context.Context, st *pipeline.PipelineState) error {
+	return b.serial(func() error { return b.RunService.CheckRepoSafe(ctx, st) })
+}
+
+func (b *batchService) LoadAgencyConfig(ctx context.Context, st *pipeline.PipelineState) error {
+	return b.serial(func() error { return b.RunService.LoadAgencyConfig(ctx, st) })
+}
+
+func (b *batchService) CreateWorktree(ctx context.Context, st *pipeline.PipelineState) error {
+	return b.serial(func() error { return b.RunService.CreateWorktree(ctx, st) })
+}
+
+func (b *batchService) WriteMeta(ctx context.Context, st *pipeline.PipelineState) error {
+	return b.serial(func() error { return b.RunService.WriteMeta(ctx, st) })
+}
+
+func (b *batchService) RunSetup(ctx context.Context, st *pipeline.PipelineState) error {
+	b.slots <- struct{}{}
+	defer func() { <-b.slots }()
+	return b.RunService.RunSetup(ctx, st)
+}
+
+func (b *batchService) StartTmux(ctx context.Context, st *pipeline.PipelineState) error {
+	return b.serial(func() error { return b.RunService.StartTmux(ctx, st) })
+}
+
+func (b *batchService) StartHeadless(ctx context.Context, st *pipeline.PipelineState) error {
+	return b.serial(func() error { return b.RunService.StartHeadless(ctx, st) })
+}
+
+// printBatchSummary prints one row per task, then the details of each
+// failure. Returns the number of failed tasks.
+func printBatchSummary(w io.Writer, results []batchResult) int {
+	nameW, idW := len("NAME"), len("RUN_ID")
+	for _, r := range results {
+		nameW = max(nameW, len(r.Task.Name))
+		idW = max(idW, len(r.RunID))
+	}
+
+	failed, queued := 0, 0
+	_, _ = fmt.Fprintf(w, "%-*s  %-*s  %-8s  %s\n", nameW, "NAME", idW, "RUN_ID", "MODE", "RESULT")
+	for _, r := range results {
+		mode := "tmux"
+		if r.Task.Headless {
+			mode = "headless"
+		}
+		result := "started"
+		if r.Queued {
+			result = "queued"
+		}
+		if r.Err != nil {
+			failed++
+			result = "failed"
+			if ae, ok := errors.AsAgencyError(r.Err); ok {
+				result += " (" + string(ae.Code) + ")"
+			}
+		}
+		if r.Queued && r.Err == nil {
+			queued++
+		}
+		runID := r.RunID
+		if runID == "" {
+			runID = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%-*s  %-*s  %-8s  %s\n", nameW, r.Task.Name, idW, runID, mode, result)
+	}
+
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\nfailed: %s\n", r.Task.Name)
+		_, _ = fmt.Fprintf(w, "error: %s\n", r.Err.Error())
+		if ae, ok := errors.AsAgencyError(r.Err); ok && ae.Details != nil {
+			if wp := ae.Details["worktree_path"]; wp != "" {
+				_, _ = fmt.Fprintf(w, "worktree: %s\n", wp)
+			}
+			if lp := ae.Details["log_path"]; lp != "" {
+				_, _ = fmt.Fprintf(w, "setup_log: %s\n", lp)
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "\nstarted %d of %d runs", len(results)-failed-queued, len(results))
+	if queued > 0 {
+		_, _ = fmt.Fprintf(w, ", %d queued", queued)
+	}
+	_, _ = fmt.Fprintln(w)
+	return failed
+}
@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/pipeline"
+)
+
+func writeTaskFile(t *testing.T, name, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fix-auth.md"), []byte("fix the auth bug\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBatchTasks(t *testing.T) {
+	path := writeTaskFile(t, "tasks.yaml", `
+concurrency: 2
+tasks:
+  - name: fix-auth
+    prompt_file: fix-auth.md
+  - name: add-tests
+    prompt: add tests for the parser
+    runner: codex
+    parent: develop
+    headless: true
+`)
+
+	tasks, concurrency, err := loadBatchTasks(fs.NewRealFS(), t.TempDir(), RunBatchOpts{From: path, Runner: "claude", Parent: "main"})
+	if err != nil {
+		t.Fatalf("loadBatchTasks() error = %v", err)
+	}
+	if concurrency != 2 {
+		t.Errorf("concurrency = %d, want 2", concurrency)
+	}
+	want := []pipeline.RunPipelineOpts{
+		{Name: "fix-auth", Runner: "claude", Parent: "main", Prompt: "fix the auth bug\n"},
+		{Name: "add-tests", Runner: "codex", Parent: "develop", Headless: true, Prompt: "add tests for the parser"},
+	}
+	if len(tasks) != len(want) {
+		t.Fatalf("got %d tasks, want %d", len(tasks), len(want))
+	}
+	for i := range want {
+		if tasks[i] != want[i] {
+			t.Errorf("task %d = %+v, want %+v", i, tasks[i], want[i])
+		}
+	}
+
+	// --concurrency overrides the file
+	_, concurrency, err = loadBatchTasks(fs.NewRealFS(), t.TempDir(), RunBatchOpts{From: path, Concurrency: 5})
+	if err != nil || concurrency != 5 {
+		t.Errorf("concurrency = %d, err = %v; want 5", concurrency, err)
+	}
+}
+
+func TestLoadBatchTasks_JSON(t *testing.T) {
+	path := writeTaskFile(t, "tasks.json", `{"tasks": [{"name": "fix-auth", "prompt": "go"}]}`)
+	tasks, concurrency, err := loadBatchTasks(fs.NewRealFS(), t.TempDir(), RunBatchOpts{From: path})
+	if err != nil {
+		t.Fatalf("loadBatchTasks() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "fix-auth" || concurrency != DefaultBatchConcurrency {
+		t.Errorf("tasks = %+v, concurrency = %d", tasks, concurrency)
+	}
+}
+
+func TestLoadBatchTasks_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"no tasks", "tasks.yaml", "tasks: []\n"},
+		{"unknown field", "tasks.yaml", "tasks:\n  - name: fix-auth\n    promt: typo\n"},
+		{"unknown json field", "tasks.json", `{"tasks": [{"name": "fix-auth", "promt": "typo"}]}`},
+		{"invalid name", "tasks.yaml", "tasks:\n  - name: Fix_Auth\n"},
+		{"duplicate name", "tasks.yaml", "tasks:\n  - name: fix-auth\n  - name: fix-auth\n"},
+		{"both prompts", "tasks.yaml", "tasks:\n  - name: fix-auth\n    prompt: a\n    prompt_file: fix-auth.md\n"},
+		{"headless without prompt", "tasks.yaml", "tasks:\n  - name: fix-auth\n    headless: true\n"},
+		{"missing prompt file", "tasks.yaml", "tasks:\n  - name: fix-auth\n    prompt_file: nope.md\n"},
+		{"negative concurrency", "tasks.yaml", "concurrency: -1\ntasks:\n  - name: fix-auth\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTaskFile(t, tt.file, tt.content)
+			_, _, err := loadBatchTasks(fs.NewRealFS(), t.TempDir(), RunBatchOpts{From: path})
+			if code := errors.GetCode(err); code != errors.EInvalidTaskFile {
+				t.Errorf("error = %v, want code %s", err, errors.EInvalidTaskFile)
+			}
+		})
+	}
+}
+
+// batchFakeService records how many setup scripts run at once and fails
+// setup for the named run.
+type batchFakeService struct {
+	failSetup string
+
+	mu        sync.Mutex
+	running   int
+	maxActive int
+	started   []string
+}
+
+func (f *batchFakeService) CheckRepoSafe(ctx context.Context, st *pipeline.PipelineState) error {
+	return nil
+}
+
+func (f *batchFakeService) LoadAgencyConfig(ctx context.Context, st *pipeline.PipelineState) error {
+	return nil
+}
+
+func (f *batchFakeService) CreateWorktree(ctx context.Context, st *pipeline.PipelineState) error {
+	return nil
+}
+
+func (f *batchFakeService) WriteMeta(ctx context.Context, st *pipeline.PipelineState) error {
+	return nil
+}
+
+func (f *batchFakeService) RunSetup(ctx context.Context, st *pipeline.PipelineState) error {
+	f.mu.Lock()
+	f.running++
+	f.maxActive = max(f.maxActive, f.running)
+	f.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+	if st.Name == f.failSetup {
+		return errors.NewWithDetails(errors.EScriptFailed, "setup script failed", map[string]string{
+			"log_path": "/data/runs/" + st.RunID + "/logs/setup.log",
+		})
+	}
+	return nil
+}
+
+func (f *batchFakeService) StartTmux(ctx context.Context, st *pipeline.PipelineState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = append(f.started, st.Name+":"+st.Prompt)
+	return nil
+}
+
+func (f *batchFakeService) StartHeadless(ctx context.Context, st *pipeline.PipelineState) error {
+	return f.StartTmux(ctx, st)
+}
+
+func TestRunBatchTasks(t *testing.T) {
+	fake := &batchFakeService{failSetup: "task-c"}
+	tasks := []pipeline.RunPipelineOpts{
+		{Name: "task-a", Prompt: "a"},
+		{Name: "task-b", Prompt: "b", Headless: true},
+		{Name: "task-c", Prompt: "c"},
+		{Name: "task-d", Prompt: "d"},
+		{Name: "task-e", Prompt: "e"},
+	}
+
+	results := runBatchTasks(context.Background(), newBatchService(fake, 2), tasks)
+
+	if fake.maxActive != 2 {
+		t.Errorf("max concurrent setups = %d, want 2", fake.maxActive)
+	}
+	if len(fake.started) != 4 {
+		t.Errorf("started = %v, want 4 runners", fake.started)
+	}
+	for i, r := range results {
+		if r.Task.Name != tasks[i].Name || r.RunID == "" {
+			t.Errorf("result %d = %+v", i, r)
+		}
+		if (r.Err != nil) != (r.Task.Name == "task-c") {
+			t.Errorf("result %d error = %v", i, r.Err)
+		}
+	}
+
+	var out bytes.Buffer
+	if failed := printBatchSummary(&out, results); failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	for _, want := range []string{
+		"NAME    RUN_ID",
+		"task-b  " + results[1].RunID + "  headless  started",
+		"task-c  " + results[2].RunID + "  tmux      failed (E_SCRIPT_FAILED)",
+		"failed: task-c\nerror: E_SCRIPT_FAILED: setup script failed\nsetup_log: /data/runs/" + results[2].RunID + "/logs/setup.log",
+		"started 4 of 5 runs",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, out.String())
+		}
+	}
+}
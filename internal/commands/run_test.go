@@ -2,8 +2,12 @@ package commands
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/fs"
 	"github.com/NielsdaWheelz/agency/internal/pipeline"
 )
 
@@ -77,6 +81,32 @@ branch: agency/fix-bug-b4c5
 worktree: /tmp/worktree
 tmux: agency_20260110130000-b4c5
 next: agency attach fix-bug
+`,
+		},
+		{
+			name: "headless run",
+			result: &RunResult{
+				RunID:         "20260110140000-c6d7",
+				Name:          "batch-fix",
+				Runner:        "claude",
+				Parent:        "main",
+				Branch:        "agency/batch-fix-c6d7",
+				WorktreePath:  "/tmp/worktree",
+				Headless:      true,
+				RawLogPath:    "/data/logs/raw.jsonl",
+				StderrLogPath: "/data/logs/stderr.log",
+			},
+			detached: true,
+			expected: `run_id: 20260110140000-c6d7
+name: batch-fix
+runner: claude
+parent: main
+branch: agency/batch-fix-c6d7
+worktree: /tmp/worktree
+mode: headless
+raw_log: /data/logs/raw.jsonl
+stderr_log: /data/logs/stderr.log
+next: agency show batch-fix
 `,
 		},
 	}
@@ -231,3 +261,45 @@ func TestRunOptsWithValues(t *testing.T) {
 		t.Error("expected attach=true")
 	}
 }
+
+func TestResolveRunPrompt(t *testing.T) {
+	cwd := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cwd, "task.md"), []byte("fix the tests\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fs.NewRealFS()
+
+	tests := []struct {
+		name     string
+		opts     RunOpts
+		want     string
+		wantCode errors.Code
+	}{
+		{name: "not headless", opts: RunOpts{}, want: ""},
+		{name: "inline prompt", opts: RunOpts{Headless: true, Prompt: "do it"}, want: "do it"},
+		{name: "prompt file relative to cwd", opts: RunOpts{Headless: true, PromptFile: "task.md"}, want: "fix the tests\n"},
+		{name: "headless without prompt", opts: RunOpts{Headless: true}, wantCode: errors.EUsage},
+		{name: "blank prompt", opts: RunOpts{Headless: true, Prompt: "  "}, wantCode: errors.EUsage},
+		{name: "both prompt flags", opts: RunOpts{Headless: true, Prompt: "a", PromptFile: "task.md"}, wantCode: errors.EUsage},
+		{name: "prompt without headless", opts: RunOpts{Prompt: "a"}, wantCode: errors.EUsage},
+		{name: "missing prompt file", opts: RunOpts{Headless: true, PromptFile: "nope.md"}, wantCode: errors.EUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveRunPrompt(fsys, cwd, tt.opts)
+			if tt.wantCode != "" {
+				if errors.GetCode(err) != tt.wantCode {
+					t.Fatalf("error code = %q, want %q (err=%v)", errors.GetCode(err), tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("prompt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
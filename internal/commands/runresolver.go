@@ -6,11 +6,13 @@ import (
 	stderrors "errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
 	"github.com/NielsdaWheelz/agency/internal/git"
 	"github.com/NielsdaWheelz/agency/internal/identity"
 	"github.com/NielsdaWheelz/agency/internal/ids"
@@ -612,3 +614,38 @@ func ResolveRepoContext(ctx context.Context, cr agencyexec.CommandRunner, cwd st
 
 	return repoRoot.Path, repoIdentity.RepoID, nil
 }
+
+// resolveWorktreeRun finds the active run whose worktree contains cwd, for
+// commands the runner calls from inside its worktree. Fails with
+// E_NOT_IN_RUN_WORKTREE if cwd is not inside one.
+func resolveWorktreeRun(dataDir, cwd string) (*store.RunRecord, error) {
+	target := resolvePath(cwd)
+
+	records, err := store.ScanAllRuns(dataDir)
+	if err != nil {
+		return nil, errors.Wrap(errors.EInternal, "failed to scan runs", err)
+	}
+	for _, rec := range filterActiveRecords(records) {
+		if rec.Meta == nil || rec.Meta.WorktreePath == "" {
+			continue
+		}
+		worktree := resolvePath(rec.Meta.WorktreePath)
+		if target == worktree || fs.IsSubpath(target, worktree) {
+			return &rec, nil
+		}
+	}
+	return nil, errors.NewWithDetails(errors.ENotInRunWorktree, "not inside an agency run worktree",
+		map[string]string{"cwd": cwd, "hint": "run this from the worktree agency created for the run"})
+}
+
+// resolvePath returns the cleaned absolute path with symlinks resolved
+// (best-effort: the cleaned path is used if resolution fails).
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
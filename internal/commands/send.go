@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/lifecycle"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+// SendOpts holds options for the send command.
+type SendOpts struct {
+	// RunID is the run reference (name, run_id, or unique prefix).
+	RunID string
+
+	// RepoPath is the optional --repo flag to scope name resolution.
+	RepoPath string
+
+	// Message is the text to deliver to the runner.
+	Message string
+}
+
+// AnswerOpts holds options for the answer command.
+type AnswerOpts struct {
+	// RunID is the run reference (name, run_id, or unique prefix).
+	RunID string
+
+	// RepoPath is the optional --repo flag to scope name resolution.
+	RepoPath string
+}
+
+// Send types a message into the runner's tmux session and submits it.
+// Works from any directory; resolves runs globally.
+func Send(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts SendOpts, stdout, stderr io.Writer) error {
+	tmuxClient := tmux.NewExecClient(cr)
+	return SendWithTmux(ctx, cr, fsys, tmuxClient, cwd, opts, stdout, stderr)
+}
+
+// SendWithTmux sends a message using the provided tmux client.
+// This variant is used for testing with a fake tmux client.
+func SendWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, tmuxClient tmux.Client, cwd string, opts SendOpts, stdout, stderr io.Writer) error {
+	if opts.RunID == "" {
+		return errors.New(errors.EUsage, "run_id is required")
+	}
+	text := singleLine(opts.Message)
+	if text == "" {
+		return errors.New(errors.EUsage, "message is required")
+	}
+
+	rec, st, err := resolveRunForSend(ctx, cr, fsys, cwd, opts.RunID, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	sessionName, err := runnerSession(ctx, tmuxClient, rec.Meta, opts.RunID)
+	if err != nil {
+		return err
+	}
+	if err := sendToRunner(ctx, tmuxClient, sessionName, text); err != nil {
+		return err
+	}
+
+	if err := appendSendEvent(st, rec, "message_sent", map[string]any{
+		"session_name": sessionName,
+		"text":         text,
+	}); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "sent to %s\n", sessionName)
+	return nil
+}
+
+// Answer lists the runner's open questions from runner_status.json, prompts
+// for an answer to each, and sends them to the runner as one reply.
+// Works from any directory; resolves runs globally.
+func Answer(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts AnswerOpts, stdin io.Reader, stdout, stderr io.Writer) error {
+	tmuxClient := tmux.NewExecClient(cr)
+	return AnswerWithTmux(ctx, cr, fsys, tmuxClient, cwd, opts, stdin, stdout, stderr)
+}
+
+// AnswerWithTmux answers runner questions using the provided tmux client.
+// This variant is used for testing with a fake tmux client.
+//
+// Answers are read one line per question from stdin (prompts go to stderr),
+// so answers can also be piped in. An empty answer skips that question; if
+// every question is skipped nothing is sent.
+func AnswerWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, tmuxClient tmux.Client, cwd string, opts AnswerOpts, stdin io.Reader, stdout, stderr io.Writer) error {
+	if opts.RunID == "" {
+		return errors.New(errors.EUsage, "run_id is required")
+	}
+
+	rec, st, err := resolveRunForSend(ctx, cr, fsys, cwd, opts.RunID, opts.RepoPath)
+	if err != nil {
+		return err
+	}
+
+	status, err := runnerstatus.Load(rec.Meta.WorktreePath)
+	if err != nil || status == nil {
+		return errors.NewWithDetails(
+			errors.ENoQuestions,
+			"runner has not reported any questions (runner_status.json missing or invalid)",
+			map[string]string{"path": runnerstatus.StatusPath(rec.Meta.WorktreePath)},
+		)
+	}
+	var questions []string
+	for _, q := range status.Questions {
+		if q = strings.TrimSpace(q); q != "" {
+			questions = append(questions, q)
+		}
+	}
+	if len(questions) == 0 {
+		return errors.New(errors.ENoQuestions, fmt.Sprintf("runner has no open questions (status: %s)", status.Status))
+	}
+
+	// Check the session before prompting so answers are not typed in vain
+	sessionName, err := runnerSession(ctx, tmuxClient, rec.Meta, opts.RunID)
+	if err != nil {
+		return err
+	}
+
+	if status.Summary != "" {
+		_, _ = fmt.Fprintf(stderr, "%s\n\n", status.Summary)
+	}
+	reader := bufio.NewReader(stdin)
+	answers := make([]string, len(questions))
+	answered := 0
+	for i, q := range questions {
+		_, _ = fmt.Fprintf(stderr, "%d. %s\n> ", i+1, q)
+		line, readErr := reader.ReadString('\n')
+		answers[i] = singleLine(line)
+		if answers[i] != "" {
+			answered++
+		}
+		if readErr != nil {
+			_, _ = fmt.Fprintln(stderr)
+			break
+		}
+	}
+	if answered == 0 {
+		return errors.New(errors.EAborted, "no answers given; nothing sent")
+	}
+
+	reply := formatAnswerReply(questions, answers)
+	if err := sendToRunner(ctx, tmuxClient, sessionName, reply); err != nil {
+		return err
+	}
+
+	if err := appendSendEvent(st, rec, "answer_sent", map[string]any{
+		"session_name": sessionName,
+		"questions":    questions,
+		"answers":      answers,
+		"text":         reply,
+	}); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "sent %d %s to %s\n", answered, plural(answered, "answer", "answers"), sessionName)
+	return nil
+}
+
+// formatAnswerReply formats answers as a single-line reply that quotes each
+// answered question. Skipped questions are left out.
+func formatAnswerReply(questions, answers []string) string {
+	var parts []string
+	for i, q := range questions {
+		if answers[i] == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("(%d) %q: %s", i+1, q, answers[i]))
+	}
+	return "Answers to your questions: " + strings.Join(parts, " ")
+}
+
+// resolveRunForSend resolves a run that must have readable meta.
+func resolveRunForSend(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd, runRef, repoPath string) (*store.RunRecord, *store.Store, error) {
+	rctx, err := ResolveRunContext(ctx, cr, cwd, repoPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, err := ResolveRun(rctx, runRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resolved.Broken || resolved.Record == nil || resolved.Record.Meta == nil {
+		return nil, nil, errors.NewWithDetails(
+			errors.ERunBroken,
+			"run exists but meta.json is unreadable or invalid",
+			map[string]string{"run_id": resolved.RunID, "repo_id": resolved.RepoID},
+		)
+	}
+	return resolved.Record, store.NewStore(fsys, rctx.DataDir, time.Now), nil
+}
+
+// runnerSession returns the tmux session of a run's runner, failing if the
+// run is headless, the session is not running, or the runner exited and the
+// session is left as a shell (which would run the text as a command).
+func runnerSession(ctx context.Context, tmuxClient tmux.Client, meta *store.RunMeta, runRef string) (string, error) {
+	if meta.Headless != nil {
+		return "", errors.New(errors.EUsage, "run is headless; its runner reads no input after the prompt")
+	}
+	sessionName := meta.TmuxSessionName
+	if sessionName == "" {
+		sessionName = tmux.SessionName(meta.RunID)
+	}
+	exists, err := tmuxClient.HasSession(ctx, sessionName)
+	if err != nil {
+		return "", errors.Wrap(errors.ETmuxNotInstalled, "failed to check tmux session", err)
+	}
+	if !exists {
+		return "", errors.NewWithDetails(
+			errors.ETmuxSessionMissing,
+			"runner tmux session is not running",
+			map[string]string{"session": sessionName, "suggestion": "try: agency resume " + runRef},
+		)
+	}
+	if lifecycle.RunnerInShell(meta, exists) {
+		return "", errors.NewWithDetails(
+			errors.ERunnerExited,
+			fmt.Sprintf("runner exited (%s); its tmux session is a shell in the worktree", lifecycle.FormatExitStatus(meta)),
+			map[string]string{"session": sessionName, "suggestion": "try: agency resume " + runRef},
+		)
+	}
+	return sessionName, nil
+}
+
+// sendToRunner types text into the session literally and presses Enter.
+func sendToRunner(ctx context.Context, tmuxClient tmux.Client, sessionName, text string) error {
+	if err := tmuxClient.SendText(ctx, sessionName, text); err != nil {
+		return errors.Wrap(errors.ETmuxFailed, "failed to send text to tmux session", err)
+	}
+	if err := tmuxClient.SendKeys(ctx, sessionName, []tmux.Key{tmux.KeyEnter}); err != nil {
+		return errors.Wrap(errors.ETmuxFailed, "failed to send keys to tmux session", err)
+	}
+	return nil
+}
+
+// appendSendEvent records a message delivered to the runner.
+func appendSendEvent(st *store.Store, rec *store.RunRecord, eventName string, data map[string]any) error {
+	err := events.AppendEvent(st.EventsPath(rec.RepoID, rec.RunID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		RepoID:        rec.RepoID,
+		RunID:         rec.RunID,
+		Event:         eventName,
+		Data:          data,
+	})
+	if err != nil {
+		return errors.Wrap(errors.EPersistFailed, "failed to append "+eventName+" event", err)
+	}
+	return nil
+}
+
+// singleLine collapses whitespace (including newlines, which would submit
+// the runner's input early) into single spaces.
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+// setupSendTestRun creates a run whose worktree has the given runner_status.json
+// (none if empty) and returns the run_id and events.jsonl path.
+func setupSendTestRun(t *testing.T, runnerStatus string) (string, string) {
+	t.Helper()
+	dataDir := t.TempDir()
+	t.Setenv("AGENCY_DATA_DIR", dataDir)
+	st := store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+
+	worktreePath := t.TempDir()
+	if runnerStatus != "" {
+		stateDir := filepath.Join(worktreePath, ".agency", "state")
+		if err := os.MkdirAll(stateDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(stateDir, "runner_status.json"), []byte(runnerStatus), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repoID, runID := "repo123456789012", "20260115120000-a3f2"
+	if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+		t.Fatal(err)
+	}
+	meta := store.NewRunMeta(runID, repoID, "feature", "claude", "claude", "main", "agency/feature-a3f2", worktreePath, time.Now())
+	if err := st.WriteInitialMeta(repoID, runID, meta); err != nil {
+		t.Fatal(err)
+	}
+	return runID, st.EventsPath(repoID, runID)
+}
+
+func TestSend(t *testing.T) {
+	runID, eventsPath := setupSendTestRun(t, "")
+
+	tc := &fakeTmuxClient{hasSessionResult: true}
+	var stdout, stderr bytes.Buffer
+	opts := SendOpts{RunID: runID, Message: "use the v2 api\nand keep tests green"}
+	if err := SendWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, t.TempDir(), opts, &stdout, &stderr); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(tc.sendTextCalls) != 1 || tc.sendTextCalls[0] != "use the v2 api and keep tests green" {
+		t.Errorf("sendText calls = %q", tc.sendTextCalls)
+	}
+	if len(tc.sendKeysCalls) != 1 || tc.sendKeysCalls[0].Keys[0] != tmux.KeyEnter {
+		t.Errorf("sendKeys calls = %+v", tc.sendKeysCalls)
+	}
+	data, err := os.ReadFile(eventsPath)
+	if err != nil || !strings.Contains(string(data), `"event":"message_sent"`) {
+		t.Errorf("events.jsonl = %s (err %v)", data, err)
+	}
+}
+
+func TestSend_NoSession(t *testing.T) {
+	runID, _ := setupSendTestRun(t, "")
+
+	tc := &fakeTmuxClient{}
+	var stdout, stderr bytes.Buffer
+	err := SendWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, t.TempDir(), SendOpts{RunID: runID, Message: "hi"}, &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.ETmuxSessionMissing {
+		t.Fatalf("error code = %q, want %q", code, errors.ETmuxSessionMissing)
+	}
+	if len(tc.sendTextCalls) != 0 {
+		t.Error("nothing should be sent without a session")
+	}
+}
+
+func TestSend_RunnerExited(t *testing.T) {
+	runID, _ := setupSendTestRun(t, "")
+	st := store.NewStore(fs.NewRealFS(), os.Getenv("AGENCY_DATA_DIR"), time.Now)
+	err := st.UpdateMeta("repo123456789012", runID, func(m *store.RunMeta) {
+		code := 0
+		m.RunnerExitedAt = time.Now().UTC().Format(time.RFC3339)
+		m.ExitReason = store.ExitReasonExited
+		m.RunnerExitCode = &code
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The session is alive, but only as a login shell
+	tc := &fakeTmuxClient{hasSessionResult: true}
+	var stdout, stderr bytes.Buffer
+	err = SendWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, t.TempDir(), SendOpts{RunID: runID, Message: "rm -rf build"}, &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.ERunnerExited {
+		t.Fatalf("error code = %q, want %q", code, errors.ERunnerExited)
+	}
+	if ae, ok := errors.AsAgencyError(err); !ok || !strings.Contains(ae.Details["suggestion"], "agency resume") {
+		t.Errorf("error should suggest agency resume: %v", err)
+	}
+	if len(tc.sendTextCalls) != 0 || len(tc.sendKeysCalls) != 0 {
+		t.Error("nothing should be typed into the shell")
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	status := `{"schema_version":"1.0","status":"needs_input","updated_at":"2026-01-15T12:00:00Z",` +
+		`"summary":"need decisions","questions":["Which API?","Keep the old flag?","Rename the table?"],"blockers":[]}`
+	runID, eventsPath := setupSendTestRun(t, status)
+
+	tc := &fakeTmuxClient{hasSessionResult: true}
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("v2\n\nyes, to users\n")
+	if err := AnswerWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, t.TempDir(), AnswerOpts{RunID: runID}, stdin, &stdout, &stderr); err != nil {
+		t.Fatalf("Answer() error = %v", err)
+	}
+
+	if !strings.Contains(stderr.String(), "1. Which API?") || !strings.Contains(stderr.String(), "3. Rename the table?") {
+		t.Errorf("questions not listed:\n%s", stderr.String())
+	}
+	want := `Answers to your questions: (1) "Which API?": v2 (3) "Rename the table?": yes, to users`
+	if len(tc.sendTextCalls) != 1 || tc.sendTextCalls[0] != want {
+		t.Errorf("sendText calls = %q, want %q", tc.sendTextCalls, want)
+	}
+	if !strings.Contains(stdout.String(), "sent 2 answers") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	data, err := os.ReadFile(eventsPath)
+	if err != nil || !strings.Contains(string(data), `"event":"answer_sent"`) {
+		t.Errorf("events.jsonl = %s (err %v)", data, err)
+	}
+}
+
+func TestAnswer_NoQuestions(t *testing.T) {
+	status := `{"schema_version":"1.0","status":"working","updated_at":"2026-01-15T12:00:00Z","summary":"busy","questions":[],"blockers":[]}`
+	runID, _ := setupSendTestRun(t, status)
+
+	tc := &fakeTmuxClient{hasSessionResult: true}
+	var stdout, stderr bytes.Buffer
+	err := AnswerWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, t.TempDir(), AnswerOpts{RunID: runID}, strings.NewReader(""), &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.ENoQuestions {
+		t.Fatalf("error code = %q, want %q", code, errors.ENoQuestions)
+	}
+
+	// No runner_status.json at all
+	runID, _ = setupSendTestRun(t, "")
+	err = AnswerWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, t.TempDir(), AnswerOpts{RunID: runID}, strings.NewReader(""), &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.ENoQuestions {
+		t.Fatalf("error code = %q, want %q", code, errors.ENoQuestions)
+	}
+
+	// All questions skipped: nothing is sent
+	status = `{"schema_version":"1.0","status":"needs_input","updated_at":"2026-01-15T12:00:00Z","summary":"s","questions":["Which API?"],"blockers":[]}`
+	runID, _ = setupSendTestRun(t, status)
+	err = AnswerWithTmux(context.Background(), exec.NewRealRunner(), fs.NewRealFS(), tc, t.TempDir(), AnswerOpts{RunID: runID}, strings.NewReader("\n"), &stdout, &stderr)
+	if code := errors.GetCode(err); code != errors.EAborted {
+		t.Fatalf("error code = %q, want %q", code, errors.EAborted)
+	}
+	if len(tc.sendTextCalls) != 0 {
+		t.Errorf("sendText calls = %q", tc.sendTextCalls)
+	}
+}
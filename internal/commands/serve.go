@@ -0,0 +1,526 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/version"
+)
+
+// APIVersion is the path prefix of the local JSON API.
+const APIVersion = "v1"
+
+// serveEventsInterval is how often /v1/events checks run statuses.
+var serveEventsInterval = 2 * time.Second
+
+// serveKeepaliveInterval is how often /v1/events sends a comment line so
+// clients and proxies keep the stream open.
+var serveKeepaliveInterval = 15 * time.Second
+
+// ServeOpts holds options for the serve command.
+type ServeOpts struct {
+	// Socket is the unix socket path (default ${AGENCY_DATA_DIR}/agency.sock).
+	Socket string
+}
+
+// DefaultServeSocket returns the default API socket path.
+// Format: ${AGENCY_DATA_DIR}/agency.sock
+func DefaultServeSocket(dataDir string) string {
+	return filepath.Join(dataDir, "agency.sock")
+}
+
+// Serve serves the local JSON API on a unix socket until interrupted.
+func Serve(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, opts ServeOpts, stdout, stderr io.Writer) error {
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+	socket := opts.Socket
+	if socket == "" {
+		socket = DefaultServeSocket(dataDir)
+	}
+	if err := os.MkdirAll(filepath.Dir(socket), 0o755); err != nil {
+		return errors.Wrap(errors.EInternal, "failed to create socket directory", err)
+	}
+
+	// Replace a stale socket file, never a live server's
+	if conn, err := net.Dial("unix", socket); err == nil {
+		_ = conn.Close()
+		return errors.NewWithDetails(errors.EUsage, "socket is in use by another server", map[string]string{"socket": socket})
+	}
+	_ = os.Remove(socket)
+	// The API acts on runs: only the owner may connect. The umask keeps the
+	// socket private from the moment it is created; the chmod is a backstop.
+	oldMask := syscall.Umask(0o077)
+	ln, err := net.Listen("unix", socket)
+	syscall.Umask(oldMask)
+	if err != nil {
+		return errors.WrapWithDetails(errors.EInternal, "failed to listen on socket", err, map[string]string{"socket": socket})
+	}
+	defer func() { _ = os.Remove(socket) }()
+	if err := os.Chmod(socket, 0o600); err != nil {
+		_ = ln.Close()
+		return errors.WrapWithDetails(errors.EInternal, "failed to restrict socket permissions", err, map[string]string{"socket": socket})
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Handler:           &apiServer{cr: cr, fsys: fsys, dataDir: dataDir},
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	_, _ = fmt.Fprintf(stdout, "serving agency API %s on %s\n", APIVersion, socket)
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(errors.EInternal, "API server failed", err)
+	}
+	return nil
+}
+
+// apiServer routes /v1 requests to the command functions.
+type apiServer struct {
+	cr      agencyexec.CommandRunner
+	fsys    fs.FS
+	dataDir string
+}
+
+// apiEnvelope is the stable JSON response format: data on success, error on
+// failure.
+type apiEnvelope struct {
+	SchemaVersion string    `json:"schema_version"`
+	Data          any       `json:"data,omitempty"`
+	Error         *apiError `json:"error,omitempty"`
+}
+
+// apiError carries an agency error code as a structured field, with the
+// command's output when an action failed.
+type apiError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+	Stdout  string            `json:"stdout,omitempty"`
+	Stderr  string            `json:"stderr,omitempty"`
+}
+
+// apiActionResult is the data of a successful action.
+type apiActionResult struct {
+	Action string `json:"action"`
+	Run    string `json:"run"`
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+}
+
+// apiStatusEvent is the data of a /v1/events status event.
+type apiStatusEvent struct {
+	Timestamp      string  `json:"timestamp"`
+	RepoID         string  `json:"repo_id"`
+	RunID          string  `json:"run_id"`
+	Name           string  `json:"name"`
+	Status         string  `json:"status"`
+	PreviousStatus string  `json:"previous_status,omitempty"`
+	Archived       bool    `json:"archived"`
+	Summary        *string `json:"summary,omitempty"`
+}
+
+// ServeHTTP dispatches:
+//
+//	GET  /v1/health
+//	GET  /v1/runs                       ?all=true&repo=<path>
+//	GET  /v1/runs/<run>
+//	GET  /v1/runs/<run>/runner_status
+//	GET  /v1/runs/<run>/events          ?limit=<n>
+//	POST /v1/runs/<run>/<stop|kill|push|verify|merge|clean>
+//	GET  /v1/worktrees                  ?repo=<path>&all=true
+//	GET  /v1/worktrees/<worktree>       ?repo=<path>
+//	GET  /v1/events                     (server-sent events)
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != APIVersion {
+		writeAPIError(w, errors.New(errors.EAPINotFound, "no such endpoint: "+r.URL.Path), nil)
+		return
+	}
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	switch {
+	case len(parts) == 2 && parts[1] == "health":
+		if allowMethod(w, r, http.MethodGet) {
+			writeAPIData(w, map[string]any{"version": version.FullVersion(), "pid": os.Getpid(), "data_dir": s.dataDir})
+		}
+	case len(parts) == 2 && parts[1] == "events":
+		if allowMethod(w, r, http.MethodGet) {
+			s.streamEvents(w, r)
+		}
+	case len(parts) == 2 && parts[1] == "runs":
+		if allowMethod(w, r, http.MethodGet) {
+			opts := LSOpts{All: q.Get("all") == "true", JSON: true, RepoPath: q.Get("repo"), AllRepos: q.Get("repo") == "", readOnly: true}
+			s.writeCommandJSON(w, func(stdout, stderr io.Writer) error {
+				return LS(ctx, s.cr, s.fsys, s.dataDir, opts, stdout, stderr)
+			})
+		}
+	case len(parts) == 3 && parts[1] == "runs":
+		if allowMethod(w, r, http.MethodGet) {
+			s.writeCommandJSON(w, func(stdout, stderr io.Writer) error {
+				return Show(ctx, s.cr, s.fsys, s.dataDir, ShowOpts{RunID: parts[2], JSON: true, readOnly: true}, stdout, stderr)
+			})
+		}
+	case len(parts) == 4 && parts[1] == "runs" && parts[3] == "runner_status":
+		if allowMethod(w, r, http.MethodGet) {
+			s.runnerStatus(w, r, parts[2])
+		}
+	case len(parts) == 4 && parts[1] == "runs" && parts[3] == "events":
+		if allowMethod(w, r, http.MethodGet) {
+			s.runEvents(w, r, parts[2])
+		}
+	case len(parts) == 4 && parts[1] == "runs":
+		if allowMethod(w, r, http.MethodPost) {
+			s.runAction(w, r, parts[2], parts[3])
+		}
+	case len(parts) == 2 && parts[1] == "worktrees":
+		if allowMethod(w, r, http.MethodGet) {
+			opts := WorktreeLSOpts{RepoPath: q.Get("repo"), All: q.Get("all") == "true", JSON: true}
+			s.writeCommandJSON(w, func(stdout, stderr io.Writer) error {
+				return WorktreeLS(ctx, s.cr, s.fsys, s.dataDir, opts, stdout, stderr)
+			})
+		}
+	case len(parts) == 3 && parts[1] == "worktrees":
+		if allowMethod(w, r, http.MethodGet) {
+			cwd := s.dataDir
+			if repo := q.Get("repo"); repo != "" {
+				cwd = repo
+			}
+			s.writeCommandJSON(w, func(stdout, stderr io.Writer) error {
+				return WorktreeShow(ctx, s.cr, s.fsys, cwd, WorktreeShowOpts{WorktreeRef: parts[2], JSON: true}, stdout, stderr)
+			})
+		}
+	default:
+		writeAPIError(w, errors.New(errors.EAPINotFound, "no such endpoint: "+r.URL.Path), nil)
+	}
+}
+
+// allowMethod writes E_API_METHOD_NOT_ALLOWED unless r uses method.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeAPIError(w, errors.New(errors.EAPIMethodNotAllowed, r.Method+" not allowed; use "+method), nil)
+	return false
+}
+
+// writeCommandJSON runs a command in --json mode and returns the data of its
+// stable JSON envelope.
+func (s *apiServer) writeCommandJSON(w http.ResponseWriter, run func(stdout, stderr io.Writer) error) {
+	var stdout, stderr bytes.Buffer
+	if err := run(&stdout, &stderr); err != nil {
+		writeAPIError(w, err, &apiError{Stderr: stderr.String()})
+		return
+	}
+	var env struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &env); err != nil {
+		writeAPIError(w, errors.Wrap(errors.EInternal, "command produced invalid JSON", err), nil)
+		return
+	}
+	writeAPIData(w, env.Data)
+}
+
+// runnerStatus returns the run's parsed runner_status.json (null if missing).
+func (s *apiServer) runnerStatus(w http.ResponseWriter, r *http.Request, ref string) {
+	_, meta, err := resolveCheckpointRun(r.Context(), s.cr, s.fsys, s.dataDir, ref, "")
+	if err != nil {
+		writeAPIError(w, err, nil)
+		return
+	}
+	rs, modTime, err := runnerstatus.LoadWithModTime(meta.WorktreePath)
+	if err != nil {
+		writeAPIError(w, errors.Wrap(errors.EInternal, "failed to read runner_status.json", err), nil)
+		return
+	}
+	if rs == nil {
+		writeAPIData(w, nil)
+		return
+	}
+	data := map[string]any{"runner_status": rs, "modified_at": modTime.UTC().Format(time.RFC3339)}
+	if verr := rs.Validate(); verr != nil {
+		data["invalid"] = verr.Error()
+	}
+	writeAPIData(w, data)
+}
+
+// runEvents returns the run's events.jsonl as an array, oldest first.
+func (s *apiServer) runEvents(w http.ResponseWriter, r *http.Request, ref string) {
+	st, meta, err := resolveCheckpointRun(r.Context(), s.cr, s.fsys, s.dataDir, ref, "")
+	if err != nil {
+		writeAPIError(w, err, nil)
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			writeAPIError(w, errors.New(errors.EUsage, "limit must be a non-negative integer"), nil)
+			return
+		}
+	}
+
+	evs := []json.RawMessage{}
+	f, err := os.Open(st.EventsPath(meta.RepoID, meta.RunID))
+	if err != nil && !os.IsNotExist(err) {
+		writeAPIError(w, errors.Wrap(errors.EInternal, "failed to read events.jsonl", err), nil)
+		return
+	}
+	if f != nil {
+		defer func() { _ = f.Close() }()
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) > 0 && json.Valid(line) {
+				evs = append(evs, json.RawMessage(append([]byte(nil), line...)))
+			}
+		}
+	}
+	if limit > 0 && len(evs) > limit {
+		evs = evs[len(evs)-limit:]
+	}
+	writeAPIData(w, evs)
+}
+
+// apiActionBody is the JSON body of POST /v1/runs/<run>/<action>. Each
+// action reads the fields of its CLI flags; unknown fields are rejected.
+type apiActionBody struct {
+	// push
+	Force           bool `json:"force"`
+	AllowDirty      bool `json:"allow_dirty"`
+	ForceWithLease  bool `json:"force_with_lease"`
+	AllowDenylisted bool `json:"allow_denylisted"`
+
+	// verify
+	Timeout string `json:"timeout"`
+
+	// merge
+	Strategy       string `json:"strategy"`
+	NoDeleteBranch bool   `json:"no_delete_branch"`
+
+	// clean
+	DeleteBranch bool `json:"delete_branch"`
+
+	// Confirm is the token typed at the merge/clean prompt ("merge", "clean").
+	Confirm string `json:"confirm"`
+}
+
+// runAction runs a mutating command on a run.
+func (s *apiServer) runAction(w http.ResponseWriter, r *http.Request, ref, action string) {
+	var body apiActionBody
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil && err != io.EOF {
+		writeAPIError(w, errors.Wrap(errors.EUsage, "invalid request body", err), nil)
+		return
+	}
+
+	ctx := r.Context()
+	var run func(stdout, stderr io.Writer) error
+	switch action {
+	case "stop":
+		run = func(stdout, stderr io.Writer) error {
+			return Stop(ctx, s.cr, s.fsys, s.dataDir, StopOpts{RunID: ref}, stdout, stderr)
+		}
+	case "kill":
+		run = func(stdout, stderr io.Writer) error {
+			return Kill(ctx, s.cr, s.fsys, s.dataDir, KillOpts{RunID: ref}, stdout, stderr)
+		}
+	case "push":
+		opts := PushOpts{RunID: ref, Force: body.Force, AllowDirty: body.AllowDirty, ForceWithLease: body.ForceWithLease, AllowDenylisted: body.AllowDenylisted}
+		run = func(stdout, stderr io.Writer) error {
+			return Push(ctx, s.cr, s.fsys, s.dataDir, opts, stdout, stderr)
+		}
+	case "verify":
+		opts := VerifyOpts{RunID: ref}
+		if body.Timeout != "" {
+			d, err := time.ParseDuration(body.Timeout)
+			if err != nil || d <= 0 {
+				writeAPIError(w, errors.New(errors.EUsage, "timeout must be a positive duration (e.g. 10m)"), nil)
+				return
+			}
+			opts.Timeout = d
+		}
+		run = func(stdout, stderr io.Writer) error {
+			return Verify(ctx, s.cr, s.fsys, s.dataDir, opts, stdout, stderr)
+		}
+	case "merge":
+		opts := MergeOpts{RunID: ref, Strategy: MergeStrategy(body.Strategy), Force: body.Force, AllowDirty: body.AllowDirty, NoDeleteBranch: body.NoDeleteBranch, ConfirmFromStdin: true}
+		switch opts.Strategy {
+		case "", MergeStrategySquash, MergeStrategyMerge, MergeStrategyRebase:
+		default:
+			writeAPIError(w, errors.New(errors.EUsage, "strategy must be squash, merge or rebase"), nil)
+			return
+		}
+		run = func(stdout, stderr io.Writer) error {
+			return Merge(ctx, s.cr, s.fsys, s.dataDir, opts, strings.NewReader(body.Confirm+"\n"), stdout, stderr)
+		}
+	case "clean":
+		opts := CleanOpts{RunID: ref, AllowDirty: body.AllowDirty, DeleteBranch: body.DeleteBranch, ConfirmFromStdin: true}
+		run = func(stdout, stderr io.Writer) error {
+			return Clean(ctx, s.cr, s.fsys, s.dataDir, opts, strings.NewReader(body.Confirm+"\n"), stdout, stderr)
+		}
+	default:
+		writeAPIError(w, errors.New(errors.EAPINotFound, "unknown action: "+action+" (want stop, kill, push, verify, merge or clean)"), nil)
+		return
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run(&stdout, &stderr); err != nil {
+		writeAPIError(w, err, &apiError{Stdout: stdout.String(), Stderr: stderr.String()})
+		return
+	}
+	writeAPIData(w, apiActionResult{Action: action, Run: ref, Stdout: stdout.String(), Stderr: stderr.String()})
+}
+
+// streamEvents streams a status event whenever a run's derived status
+// changes, starting with the current status of every run.
+func (s *apiServer) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, errors.New(errors.EInternal, "streaming not supported"), nil)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	prev := make(map[string]string)
+	poll := time.NewTicker(serveEventsInterval)
+	defer poll.Stop()
+	keepalive := time.NewTicker(serveKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		for _, ev := range s.statusChanges(ctx, prev) {
+			data, _ := json.Marshal(ev)
+			_, _ = fmt.Fprintf(w, "event: status\nid: %s\ndata: %s\n\n", ev.RunID, data)
+		}
+		flusher.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepalive.C:
+			_, _ = fmt.Fprint(w, ": keepalive\n\n")
+		case <-poll.C:
+		}
+	}
+}
+
+// statusChanges derives every run's status and returns those that differ
+// from prev, updating prev. Like every GET endpoint it writes nothing.
+func (s *apiServer) statusChanges(ctx context.Context, prev map[string]string) []apiStatusEvent {
+	records, err := store.ScanAllRuns(s.dataDir)
+	if err != nil {
+		return nil
+	}
+	tmuxSessions, _ := listTmuxSessions(ctx, s.cr)
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	var changes []apiStatusEvent
+	for _, rec := range records {
+		summary := recordToSummary(nil, rec, tmuxSessions, s.fsys)
+		key := summary.DerivedStatus
+		if summary.Archived {
+			key += " (archived)"
+		}
+		if before, seen := prev[rec.RunID]; seen && before == key {
+			continue
+		}
+		ev := apiStatusEvent{
+			Timestamp:      now,
+			RepoID:         rec.RepoID,
+			RunID:          rec.RunID,
+			Name:           summary.Name,
+			Status:         summary.DerivedStatus,
+			PreviousStatus: strings.TrimSuffix(prev[rec.RunID], " (archived)"),
+			Archived:       summary.Archived,
+			Summary:        summary.Summary,
+		}
+		prev[rec.RunID] = key
+		changes = append(changes, ev)
+	}
+	return changes
+}
+
+// writeAPIData writes a 200 response with data.
+func writeAPIData(w http.ResponseWriter, data any) {
+	if data == nil {
+		data = json.RawMessage("null")
+	}
+	writeAPIJSON(w, http.StatusOK, apiEnvelope{SchemaVersion: "1.0", Data: data})
+}
+
+// writeAPIError writes err with its agency error code; extra carries the
+// command output of a failed action.
+func writeAPIError(w http.ResponseWriter, err error, extra *apiError) {
+	e := apiError{Code: string(errors.EInternal), Message: err.Error()}
+	if ae, ok := errors.AsAgencyError(err); ok {
+		e.Code = string(ae.Code)
+		e.Message = ae.Msg
+		e.Details = ae.Details
+	}
+	if extra != nil {
+		e.Stdout, e.Stderr = extra.Stdout, extra.Stderr
+	}
+	writeAPIJSON(w, apiStatusCode(errors.Code(e.Code)), apiEnvelope{SchemaVersion: "1.0", Error: &e})
+}
+
+// apiStatusCode maps an agency error code to an HTTP status.
+func apiStatusCode(code errors.Code) int {
+	switch {
+	case code == errors.EUsage || code == errors.ENoRepo:
+		return http.StatusBadRequest
+	case code == errors.EAPIMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case strings.HasSuffix(string(code), "_NOT_FOUND"):
+		return http.StatusNotFound
+	case strings.HasSuffix(string(code), "_AMBIGUOUS") || code == errors.ERepoLocked || code == errors.EAborted:
+		return http.StatusConflict
+	case code == errors.EInternal:
+		return http.StatusInternalServerError
+	default:
+		// The command ran and refused or failed
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// writeAPIJSON writes v as indented JSON with the given status.
+func writeAPIJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/status"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+// apiResponse decodes an API envelope.
+type apiResponse struct {
+	SchemaVersion string          `json:"schema_version"`
+	Data          json.RawMessage `json:"data"`
+	Error         *apiError       `json:"error"`
+}
+
+func doAPI(t *testing.T, h http.Handler, method, path, body string) (int, apiResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var resp apiResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: invalid JSON response %q: %v", method, path, rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func writeTestRunnerStatus(t *testing.T, worktreePath string, rs runnerstatus.RunnerStatus) {
+	t.Helper()
+	path := runnerstatus.StatusPath(worktreePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(rs)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServeAPI(t *testing.T) {
+	testutil.HermeticGitEnv(t)
+	st, repoID := setupDaemonTest(t)
+	runID := "20260110120000-a3f2"
+	writeDaemonTestRun(t, st, repoID, runID, "auth-fix")
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestRunnerStatus(t, meta.WorktreePath, runnerstatus.RunnerStatus{
+		SchemaVersion: "1.0", Status: runnerstatus.StatusNeedsInput, Summary: "which db?", Questions: []string{"postgres or sqlite?"},
+	})
+	for _, ev := range []string{"run_started", "stop"} {
+		_ = events.AppendEvent(st.EventsPath(repoID, runID), events.Event{SchemaVersion: "1.0", RepoID: repoID, RunID: runID, Event: ev})
+	}
+
+	h := &apiServer{cr: exec.NewRealRunner(), fsys: fs.NewRealFS(), dataDir: st.DataDir}
+
+	code, resp := doAPI(t, h, http.MethodGet, "/v1/runs", "")
+	var runs []struct {
+		RunID         string `json:"run_id"`
+		DerivedStatus string `json:"derived_status"`
+	}
+	if err := json.Unmarshal(resp.Data, &runs); code != http.StatusOK || err != nil || len(runs) != 1 || runs[0].RunID != runID {
+		t.Fatalf("GET /v1/runs = %d %s (err = %v)", code, resp.Data, err)
+	}
+	if runs[0].DerivedStatus != status.StatusNeedsInput {
+		t.Errorf("derived_status = %q, want %q", runs[0].DerivedStatus, status.StatusNeedsInput)
+	}
+
+	code, resp = doAPI(t, h, http.MethodGet, "/v1/runs/auth-fix", "")
+	if code != http.StatusOK || !strings.Contains(string(resp.Data), runID) {
+		t.Errorf("GET /v1/runs/auth-fix = %d %s", code, resp.Data)
+	}
+
+	code, resp = doAPI(t, h, http.MethodGet, "/v1/runs/auth-fix/runner_status", "")
+	if code != http.StatusOK || !strings.Contains(string(resp.Data), `"status": "needs_input"`) {
+		t.Errorf("GET runner_status = %d %s", code, resp.Data)
+	}
+
+	// The GETs above wrote nothing: no status history or runner exit events
+	_, resp = doAPI(t, h, http.MethodGet, "/v1/runs/"+runID+"/events", "")
+	var all []events.Event
+	if err := json.Unmarshal(resp.Data, &all); err != nil || len(all) != 2 || all[0].Event != "run_started" {
+		t.Fatalf("GET events = %s (err = %v)", resp.Data, err)
+	}
+	code, resp = doAPI(t, h, http.MethodGet, "/v1/runs/"+runID+"/events?limit=1", "")
+	var evs []events.Event
+	if err := json.Unmarshal(resp.Data, &evs); code != http.StatusOK || err != nil || len(evs) != 1 || evs[0].Event != "stop" {
+		t.Errorf("GET events?limit=1 = %d %s", code, resp.Data)
+	}
+
+	// Errors keep their agency codes as structured fields
+	errorCases := []struct {
+		method, path, body string
+		wantHTTP           int
+		wantCode           string
+	}{
+		{http.MethodGet, "/v1/runs/nope", "", http.StatusNotFound, "E_RUN_NOT_FOUND"},
+		{http.MethodGet, "/v2/runs", "", http.StatusNotFound, "E_API_NOT_FOUND"},
+		{http.MethodPost, "/v1/runs/auth-fix/explode", "", http.StatusNotFound, "E_API_NOT_FOUND"},
+		{http.MethodGet, "/v1/runs/auth-fix/stop", "", http.StatusMethodNotAllowed, "E_API_METHOD_NOT_ALLOWED"},
+		{http.MethodPost, "/v1/runs/auth-fix/push", `{"forse": true}`, http.StatusBadRequest, "E_USAGE"},
+		{http.MethodPost, "/v1/runs/auth-fix/verify", `{"timeout": "soon"}`, http.StatusBadRequest, "E_USAGE"},
+		{http.MethodPost, "/v1/runs/auth-fix/merge", `{"strategy": "octopus"}`, http.StatusBadRequest, "E_USAGE"},
+	}
+	for _, tc := range errorCases {
+		code, resp := doAPI(t, h, tc.method, tc.path, tc.body)
+		if code != tc.wantHTTP || resp.Error == nil || resp.Error.Code != tc.wantCode {
+			t.Errorf("%s %s = %d %+v, want %d %s", tc.method, tc.path, code, resp.Error, tc.wantHTTP, tc.wantCode)
+		}
+	}
+}
+
+func TestServeAPI_StatusChanges(t *testing.T) {
+	st, repoID := setupDaemonTest(t)
+	runID := "20260110120000-a3f2"
+	writeDaemonTestRun(t, st, repoID, runID, "auth-fix")
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr := &fakeCommandRunner{responses: map[string]fakeResponse{}}
+	h := &apiServer{cr: cr, fsys: fs.NewRealFS(), dataDir: st.DataDir}
+
+	prev := make(map[string]string)
+	first := h.statusChanges(context.Background(), prev)
+	if len(first) != 1 || first[0].RunID != runID || first[0].PreviousStatus != "" {
+		t.Fatalf("first statusChanges() = %+v, want the current status of the run", first)
+	}
+	if again := h.statusChanges(context.Background(), prev); len(again) != 0 {
+		t.Errorf("unchanged statusChanges() = %+v, want none", again)
+	}
+
+	writeTestRunnerStatus(t, meta.WorktreePath, runnerstatus.RunnerStatus{
+		SchemaVersion: "1.0", Status: runnerstatus.StatusReadyForReview, Summary: "done", HowToTest: "go test ./...",
+	})
+	changed := h.statusChanges(context.Background(), prev)
+	if len(changed) != 1 || changed[0].Status != status.StatusReadyForReview || changed[0].PreviousStatus != first[0].Status {
+		t.Errorf("statusChanges() after report = %+v", changed)
+	}
+}
+
+func TestServe_Socket(t *testing.T) {
+	st, _ := setupDaemonTest(t)
+	socket := filepath.Join(st.DataDir, "api.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, exec.NewRealRunner(), fs.NewRealFS(), ServeOpts{Socket: socket}, &bytes.Buffer{}, &bytes.Buffer{})
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	var resp *http.Response
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if resp, err = client.Get("http://agency/v1/events"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("socket not answering: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	_ = resp.Body.Close()
+
+	info, err := os.Stat(socket)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v (err = %v), want 0600", info.Mode().Perm(), err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Error("socket not removed on shutdown")
+	}
+}
+
+func TestServeAPI_EventStream(t *testing.T) {
+	st, repoID := setupDaemonTest(t)
+	writeDaemonTestRun(t, st, repoID, "20260110120000-a3f2", "auth-fix")
+	h := &apiServer{cr: &fakeCommandRunner{responses: map[string]fakeResponse{}}, fsys: fs.NewRealFS(), dataDir: st.DataDir}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	sc := bufio.NewScanner(resp.Body)
+	var lines []string
+	for sc.Scan() && len(lines) < 3 {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 3 || lines[0] != "event: status" || !strings.HasPrefix(lines[2], `data: {"timestamp"`) || !strings.Contains(lines[2], `"name":"auth-fix"`) {
+		t.Errorf("event stream starts with %q", lines)
+	}
+}
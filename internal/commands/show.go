@@ -14,11 +14,13 @@ import (
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
 	"github.com/NielsdaWheelz/agency/internal/git"
+	"github.com/NielsdaWheelz/agency/internal/headless"
 	"github.com/NielsdaWheelz/agency/internal/ids"
 	"github.com/NielsdaWheelz/agency/internal/lock"
 	"github.com/NielsdaWheelz/agency/internal/paths"
 	"github.com/NielsdaWheelz/agency/internal/render"
 	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/sessionlog"
 	"github.com/NielsdaWheelz/agency/internal/status"
 	"github.com/NielsdaWheelz/agency/internal/store"
 	"github.com/NielsdaWheelz/agency/internal/tmux"
@@ -40,8 +42,15 @@ type ShowOpts struct {
 	// This is a mutating mode: takes repo lock and emits events.
 	Capture bool
 
+	// History outputs the runner status timeline and per-status totals.
+	History bool
+
 	// Args is the raw args slice for event logging.
 	Args []string
+
+	// readOnly derives the status without recording runner exits, status
+	// history or session logs (the API's GET /v1/runs/<run>).
+	readOnly bool
 }
 
 // captureResult holds the result of a transcript capture attempt.
@@ -60,6 +69,9 @@ func Show(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd stri
 	if opts.RunID == "" {
 		return errors.New(errors.EUsage, "run_id is required")
 	}
+	if opts.History && (opts.Path || opts.Capture) {
+		return errors.New(errors.EUsage, "--history cannot be combined with --path or --capture")
+	}
 
 	// Resolve data directory
 	homeDir, err := os.UserHomeDir()
@@ -98,13 +110,26 @@ func Show(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd stri
 	}
 
 	// Get tmux session set (single call for efficiency)
-	tmuxSessions := getTmuxSessions(ctx, cr)
+	tmuxSessions, tmuxOK := listTmuxSessions(ctx, cr)
 	tmuxUnavailable := false // we don't know if tmux is unavailable, just that no sessions exist
 
+	var st *store.Store
+	if !opts.readOnly {
+		st = store.NewStore(fsys, dataDir, nil)
+		// Record the runner exit if the tmux session is gone (best-effort)
+		if tmuxOK {
+			reconcileRunnerExit(st, record, tmuxSessions)
+		}
+		observeRunnerStatus(st, *record, time.Now())
+	}
+
+	if opts.History {
+		return writeShowHistory(record, eventsPath, opts.JSON, time.Now(), stdout)
+	}
+
 	// Compute local snapshot for the run
 	worktreePath := record.Meta.WorktreePath
 	worktreePresent := dirExists(worktreePath)
-	archived := !worktreePresent
 
 	// Report info
 	reportPath := filepath.Join(worktreePath, ".agency", "report.md")
@@ -128,6 +153,7 @@ func Show(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd stri
 	var runnerStatus *runnerstatus.RunnerStatus
 	var runnerStatusModTime time.Time
 	var stallResult *watchdog.StallResult
+	var activity *sessionlog.Activity
 	if worktreePresent {
 		rs, modTime, err := runnerstatus.LoadWithModTime(worktreePath)
 		if err == nil && rs != nil {
@@ -145,6 +171,13 @@ func Show(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd stri
 		if !modTime.IsZero() {
 			signals.StatusFileModTime = &modTime
 		}
+
+		// Refresh logs/stream.jsonl from the runner's session log (derived,
+		// best-effort) unless read-only
+		activity = runActivity(st, *record, st != nil)
+		if activity != nil && !activity.LastActivityAt.IsZero() {
+			signals.LastActivityAt = &activity.LastActivityAt
+		}
 		result := watchdog.CheckStallWithDefault(signals)
 		stallResult = &result
 	}
@@ -155,15 +188,17 @@ func Show(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd stri
 		WorktreePresent: worktreePresent,
 		RunnerStatus:    runnerStatus,
 		StallResult:     stallResult,
+		HeadlessActive:  headless.IsActive(record.Meta),
 	}
 	derived := status.Derive(record.Meta, snapshot)
+	archived := derived.Archived
 
 	// Best-effort repo root resolution
 	repoRoot := resolveRepoRootForShow(ctx, cr, cwd, record, dataDir)
 
 	// Determine if we should show warnings
 	repoNotFoundWarning := repoRoot == nil && record.Repo != nil
-	worktreeMissingWarning := !worktreePresent
+	worktreeMissingWarning := !worktreePresent && worktreePath != "" // queued runs have no worktree yet
 
 	// Print capture warnings (only for human mode, to stderr)
 	if opts.Capture && captureRes != nil && !captureRes.ok && !opts.JSON && !opts.Path {
@@ -176,7 +211,7 @@ func Show(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd stri
 	}
 
 	if opts.JSON {
-		return outputShowJSONWithCapture(stdout, record, repoRoot, runDir, eventsPath, transcriptPath, derived, reportPath, reportExists, reportBytes, tmuxActive, worktreePresent, archived, setupLogPath, verifyLogPath, archiveLogPath, captureRes, runnerStatus)
+		return outputShowJSONWithCapture(stdout, record, repoRoot, runDir, eventsPath, transcriptPath, derived, reportPath, reportExists, reportBytes, tmuxActive, worktreePresent, archived, setupLogPath, verifyLogPath, archiveLogPath, captureRes, runnerStatus, activity)
 	}
 
 	// Build runner status display if available
@@ -194,7 +229,7 @@ func Show(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd stri
 	}
 
 	// Human output
-	return outputShowHuman(stdout, record, repoRoot, runDir, derived, reportPath, reportExists, reportBytes, tmuxActive, worktreePresent, archived, setupLogPath, verifyLogPath, archiveLogPath, repoNotFoundWarning, worktreeMissingWarning, tmuxUnavailable, runnerStatusDisplay)
+	return outputShowHuman(stdout, record, repoRoot, runDir, derived, reportPath, reportExists, reportBytes, tmuxActive, worktreePresent, archived, setupLogPath, verifyLogPath, archiveLogPath, repoNotFoundWarning, worktreeMissingWarning, tmuxUnavailable, runnerStatusDisplay, activity)
 }
 
 // performCapture executes the capture flow: acquire lock, emit events, capture transcript.
@@ -506,7 +541,7 @@ func outputShowPaths(stdout io.Writer, repoRoot *string, worktreePath, runDir, l
 }
 
 // outputShowJSONWithCapture writes the --json output, optionally including capture result.
-func outputShowJSONWithCapture(stdout io.Writer, record *store.RunRecord, repoRoot *string, runDir, eventsPath, transcriptPath string, derived status.Derived, reportPath string, reportExists bool, reportBytes int, tmuxActive, worktreePresent, archived bool, setupLogPath, verifyLogPath, archiveLogPath string, captureRes *captureResult, runnerStatus *runnerstatus.RunnerStatus) error {
+func outputShowJSONWithCapture(stdout io.Writer, record *store.RunRecord, repoRoot *string, runDir, eventsPath, transcriptPath string, derived status.Derived, reportPath string, reportExists bool, reportBytes int, tmuxActive, worktreePresent, archived bool, setupLogPath, verifyLogPath, archiveLogPath string, captureRes *captureResult, runnerStatus *runnerstatus.RunnerStatus, activity *sessionlog.Activity) error {
 	// Build runner status JSON if available
 	var runnerStatusJSON *render.RunnerStatusJSON
 	if runnerStatus != nil {
@@ -551,6 +586,19 @@ func outputShowJSONWithCapture(stdout io.Writer, record *store.RunRecord, repoRo
 		Broken: false,
 	}
 
+	// Session log activity (tmux runs only)
+	if activity != nil {
+		activityJSON := &render.ActivityJSON{
+			LastAction:     activity.LastAction,
+			SessionLogPath: activity.Session.Path,
+			StreamPath:     activity.StreamPath,
+		}
+		if !activity.LastActivityAt.IsZero() {
+			activityJSON.LastActivityAt = activity.LastActivityAt.UTC().Format(time.RFC3339)
+		}
+		detail.Derived.Activity = activityJSON
+	}
+
 	// Join repo info if available
 	if record.Repo != nil {
 		detail.RepoKey = &record.Repo.RepoKey
@@ -570,7 +618,7 @@ func outputShowJSONWithCapture(stdout io.Writer, record *store.RunRecord, repoRo
 }
 
 // outputShowHuman writes the human-readable output.
-func outputShowHuman(stdout io.Writer, record *store.RunRecord, repoRoot *string, runDir string, derived status.Derived, reportPath string, reportExists bool, reportBytes int, tmuxActive, worktreePresent, archived bool, setupLogPath, verifyLogPath, archiveLogPath string, repoNotFoundWarning, worktreeMissingWarning, tmuxUnavailable bool, runnerStatusDisplay *render.RunnerStatusDisplay) error {
+func outputShowHuman(stdout io.Writer, record *store.RunRecord, repoRoot *string, runDir string, derived status.Derived, reportPath string, reportExists bool, reportBytes int, tmuxActive, worktreePresent, archived bool, setupLogPath, verifyLogPath, archiveLogPath string, repoNotFoundWarning, worktreeMissingWarning, tmuxUnavailable bool, runnerStatusDisplay *render.RunnerStatusDisplay, activity *sessionlog.Activity) error {
 	meta := record.Meta
 
 	data := render.ShowHumanData{
@@ -619,6 +667,33 @@ func outputShowHuman(stdout io.Writer, record *store.RunRecord, repoRoot *string
 		TmuxUnavailableWarning: tmuxUnavailable,
 	}
 
+	// Headless runner details
+	if meta.Headless != nil {
+		data.Headless = &render.HeadlessDisplay{
+			PID:           meta.RunnerPID,
+			ExitCode:      meta.RunnerExitCode,
+			ExitReason:    meta.ExitReason,
+			LastOutputAt:  store.ReadLastOutputAt(filepath.Join(runDir, "logs", store.LastOutputFileName)),
+			RawLogPath:    meta.Headless.RawLogPath,
+			StderrLogPath: meta.Headless.StderrLogPath,
+		}
+	}
+
+	// Recorded runner exit (tmux runs)
+	if meta.Headless == nil && meta.RunnerExitedAt != "" {
+		if t, err := time.Parse(time.RFC3339, meta.RunnerExitedAt); err == nil {
+			data.RunnerExited = formatRelativeTimeForShow(t)
+		}
+	}
+
+	data.RunnerSessionID = meta.RunnerSessionID
+
+	// Last action from the runner's session log
+	if activity != nil && activity.LastAction != "" {
+		data.LastAction = activity.LastAction
+		data.LastActivityAt = formatRelativeTimeForShow(activity.LastActivityAt)
+	}
+
 	// Repo identity
 	if record.Repo != nil {
 		data.RepoKey = record.Repo.RepoKey
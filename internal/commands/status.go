@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// Sources recorded on runner_status_changed events.
+const (
+	runnerStatusSourceStatusSet = "status_set"
+	runnerStatusSourceMCP       = "mcp"
+	runnerStatusSourceObserved  = "observed" // found in runner_status.json by ls, show, the daemon or the watcher
+)
+
+// runnerStatusCheckInterval is how often the run monitor records new
+// runner status reports in the run's history.
+var runnerStatusCheckInterval = 15 * time.Second
+
+// StatusSetOpts holds options for the status set command.
+type StatusSetOpts struct {
+	// Status is the new runner status (working, needs_input, blocked, ready_for_review).
+	Status string
+
+	// Summary is the one-line summary. Defaults to the first question or
+	// blocker when those are given.
+	Summary string
+
+	Questions []string
+	Blockers  []string
+	HowToTest string
+	Risks     []string
+}
+
+// StatusSet implements `agency status set`: the runner reports its status
+// from inside its worktree. The run is found by worktree path; the status
+// replaces runner_status.json.
+func StatusSet(ctx context.Context, fsys fs.FS, cwd string, opts StatusSetOpts, stdout, stderr io.Writer) error {
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+	rec, err := resolveWorktreeRun(dataDir, cwd)
+	if err != nil {
+		return err
+	}
+
+	rs := &runnerstatus.RunnerStatus{
+		Status:    runnerstatus.Status(strings.TrimSpace(opts.Status)),
+		Summary:   strings.TrimSpace(opts.Summary),
+		Questions: opts.Questions,
+		Blockers:  opts.Blockers,
+		HowToTest: opts.HowToTest,
+		Risks:     opts.Risks,
+	}
+	if rs.Summary == "" {
+		switch {
+		case len(rs.Questions) > 0:
+			rs.Summary = rs.Questions[0]
+		case len(rs.Blockers) > 0:
+			rs.Summary = rs.Blockers[0]
+		}
+	}
+	if err := saveRunnerStatus(fsys, dataDir, rec, rs, runnerStatusSourceStatusSet, time.Now()); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(stdout, "run: %s\n", rec.Meta.Name)
+	_, _ = fmt.Fprintf(stdout, "status: %s\n", rs.Status)
+	_, _ = fmt.Fprintf(stdout, "summary: %s\n", rs.Summary)
+	return nil
+}
+
+// saveRunnerStatus validates rs, stamps its schema version and updated_at,
+// writes it to the run's runner_status.json and appends a
+// runner_status_changed event.
+func saveRunnerStatus(fsys fs.FS, dataDir string, rec *store.RunRecord, rs *runnerstatus.RunnerStatus, source string, now time.Time) error {
+	rs.SchemaVersion = runnerstatus.SchemaVersion
+	rs.UpdatedAt = now.UTC().Format(time.RFC3339)
+	if err := rs.Validate(); err != nil {
+		return errors.NewWithDetails(errors.EInvalidRunnerStatus, "invalid runner status: "+err.Error(),
+			map[string]string{"hint": "required fields: needs_input --question, blocked --blocker, ready_for_review --how-to-test"})
+	}
+
+	// Record a hand-written report being replaced, so the history has it
+	st := store.NewStore(fsys, dataDir, time.Now)
+	observeRunnerStatus(st, *rec, now)
+
+	worktreePath := rec.Meta.WorktreePath
+	if err := runnerstatus.Save(worktreePath, rs); err != nil {
+		return errors.WrapWithDetails(errors.EInternal, "failed to write runner_status.json", err,
+			map[string]string{"path": runnerstatus.StatusPath(worktreePath)})
+	}
+	recordRunnerStatus(st, rec.RepoID, rec.RunID, rs, source, now)
+	return nil
+}
+
+// observeRunnerStatus records the run's current runner_status.json in its
+// history if it is a report not recorded yet (e.g. written by hand).
+// Best-effort: invalid or missing status files are ignored.
+func observeRunnerStatus(st *store.Store, rec store.RunRecord, now time.Time) {
+	if rec.Broken || rec.Meta == nil || rec.Meta.WorktreePath == "" || rec.Meta.Archive != nil {
+		return
+	}
+	rs, err := runnerstatus.Load(rec.Meta.WorktreePath)
+	if err != nil || rs == nil || rs.Validate() != nil {
+		return
+	}
+	recordRunnerStatus(st, rec.RepoID, rec.RunID, rs, runnerStatusSourceObserved, now)
+}
+
+// observeRunStatus reads the run's meta and observes its runner status.
+func observeRunStatus(st *store.Store, repoID, runID string, now time.Time) {
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		return
+	}
+	observeRunnerStatus(st, store.RunRecord{RepoID: repoID, RunID: runID, Name: meta.Name, Meta: meta, RunDir: st.RunDir(repoID, runID)}, now)
+}
+
+// recordRunnerStatus appends a runner_status_changed event for rs unless it
+// is already the last report in the run's history. The event is dated by
+// the report's updated_at (now if unparseable). Best-effort.
+func recordRunnerStatus(st *store.Store, repoID, runID string, rs *runnerstatus.RunnerStatus, source string, now time.Time) {
+	at := now
+	if t, err := time.Parse(time.RFC3339, rs.UpdatedAt); err == nil {
+		at = t
+	}
+	prev, changed, err := runnerstatus.ObserveHistory(st.RunnerStatusStatePath(repoID, runID), rs, at)
+	if err != nil || !changed {
+		return
+	}
+	var previous string
+	if prev != nil {
+		previous = string(prev.Status)
+	}
+	_ = events.AppendEvent(st.EventsPath(repoID, runID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     at.UTC().Format(time.RFC3339),
+		RepoID:        repoID,
+		RunID:         runID,
+		Event:         "runner_status_changed",
+		Data:          events.RunnerStatusChangedData(previous, string(rs.Status), rs.Summary, rs.Questions, rs.UpdatedAt, source),
+	})
+}
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// readTestEvents returns the run's events with the given name.
+func readTestEvents(t *testing.T, st *store.Store, repoID, runID, name string) []events.Event {
+	t.Helper()
+	f, err := os.Open(st.EventsPath(repoID, runID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var out []events.Event
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e events.Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Event == name {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestStatusSet(t *testing.T) {
+	st, repoID := setupDaemonTest(t)
+	runID := "20260110120000-a3f2"
+	writeDaemonTestRun(t, st, repoID, runID, "auth-fix")
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd := filepath.Join(meta.WorktreePath, "cmd")
+	if err := os.MkdirAll(cwd, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	var stdout, stderr bytes.Buffer
+
+	if err := StatusSet(ctx, fs.NewRealFS(), cwd, StatusSetOpts{Status: "working", Summary: "adding oauth"}, &stdout, &stderr); err != nil {
+		t.Fatalf("StatusSet(working) error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "run: auth-fix") || !strings.Contains(stdout.String(), "status: working") {
+		t.Errorf("output = %q", stdout.String())
+	}
+
+	// The summary defaults to the first question
+	err = StatusSet(ctx, fs.NewRealFS(), cwd, StatusSetOpts{Status: "needs_input", Questions: []string{"postgres or sqlite?", "keep v1?"}}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("StatusSet(needs_input) error = %v", err)
+	}
+	rs, err := runnerstatus.Load(meta.WorktreePath)
+	if err != nil || rs == nil {
+		t.Fatalf("Load() = %v, %v", rs, err)
+	}
+	if rs.Status != runnerstatus.StatusNeedsInput || rs.Summary != "postgres or sqlite?" || len(rs.Questions) != 2 || rs.UpdatedAt == "" || rs.Blockers == nil {
+		t.Errorf("runner status = %+v", rs)
+	}
+
+	evs := readTestEvents(t, st, repoID, runID, "runner_status_changed")
+	if len(evs) != 2 {
+		t.Fatalf("got %d runner_status_changed events, want 2", len(evs))
+	}
+	data := evs[1].Data
+	if data["previous_status"] != "working" || data["status"] != "needs_input" || data["source"] != "status_set" || len(data["questions"].([]any)) != 2 {
+		t.Errorf("event data = %v", data)
+	}
+	if evs[0].Data["previous_status"] != "" {
+		t.Errorf("first event previous_status = %v, want empty", evs[0].Data["previous_status"])
+	}
+
+	// Invalid statuses are rejected without touching the file
+	for _, opts := range []StatusSetOpts{
+		{Status: "ready_for_review", Summary: "done"},
+		{Status: "done", Summary: "x"},
+		{Status: "working"},
+	} {
+		err := StatusSet(ctx, fs.NewRealFS(), cwd, opts, &stdout, &stderr)
+		if errors.GetCode(err) != errors.EInvalidRunnerStatus {
+			t.Errorf("StatusSet(%+v) error = %v, want E_INVALID_RUNNER_STATUS", opts, err)
+		}
+	}
+	if rs, _ := runnerstatus.Load(meta.WorktreePath); rs == nil || rs.Status != runnerstatus.StatusNeedsInput {
+		t.Errorf("invalid status overwrote the file: %+v", rs)
+	}
+	if n := len(readTestEvents(t, st, repoID, runID, "runner_status_changed")); n != 2 {
+		t.Errorf("got %d events after invalid calls, want 2", n)
+	}
+
+	err = StatusSet(ctx, fs.NewRealFS(), t.TempDir(), StatusSetOpts{Status: "working", Summary: "x"}, &stdout, &stderr)
+	if errors.GetCode(err) != errors.ENotInRunWorktree {
+		t.Errorf("StatusSet() outside a worktree error = %v, want E_NOT_IN_RUN_WORKTREE", err)
+	}
+}
+
+func TestRunnerStatusHistory(t *testing.T) {
+	st, repoID := setupDaemonTest(t)
+	runID := "20260110120000-a3f2"
+	writeDaemonTestRun(t, st, repoID, runID, "auth-fix")
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := store.RunRecord{RepoID: repoID, RunID: runID, Name: meta.Name, Meta: meta, RunDir: st.RunDir(repoID, runID)}
+
+	// A hand-written report is recorded once, however often it is observed
+	handWritten := &runnerstatus.RunnerStatus{
+		SchemaVersion: runnerstatus.SchemaVersion,
+		Status:        runnerstatus.StatusWorking,
+		UpdatedAt:     "2026-01-10T12:00:00Z",
+		Summary:       "starting",
+	}
+	if err := runnerstatus.Save(meta.WorktreePath, handWritten); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 10, 12, 5, 0, 0, time.UTC)
+	observeRunnerStatus(st, rec, now)
+	observeRunnerStatus(st, rec, now.Add(time.Minute))
+	evs := readTestEvents(t, st, repoID, runID, "runner_status_changed")
+	if len(evs) != 1 || evs[0].Data["source"] != "observed" || evs[0].Timestamp != "2026-01-10T12:00:00Z" {
+		t.Fatalf("events after observing = %+v, want one observed event dated by updated_at", evs)
+	}
+	if since := runnerStatusSince(rec, handWritten); since == nil || *since != "2026-01-10T12:00:00Z" {
+		t.Errorf("runnerStatusSince() = %v, want 2026-01-10T12:00:00Z", since)
+	}
+
+	// A new report of the same status keeps status_since
+	handWritten.UpdatedAt = "2026-01-10T12:10:00Z"
+	handWritten.Summary = "halfway"
+	if err := runnerstatus.Save(meta.WorktreePath, handWritten); err != nil {
+		t.Fatal(err)
+	}
+	observeRunnerStatus(st, rec, now)
+	if n := len(readTestEvents(t, st, repoID, runID, "runner_status_changed")); n != 2 {
+		t.Errorf("got %d events after a new report, want 2", n)
+	}
+	if since := runnerStatusSince(rec, handWritten); since == nil || *since != "2026-01-10T12:00:00Z" {
+		t.Errorf("runnerStatusSince() after same-status report = %v, want 2026-01-10T12:00:00Z", since)
+	}
+	if since := runnerStatusSince(rec, &runnerstatus.RunnerStatus{Status: runnerstatus.StatusBlocked}); since != nil {
+		t.Errorf("runnerStatusSince() for an unrecorded status = %v, want nil", *since)
+	}
+}
+
+func TestBuildStatusHistory(t *testing.T) {
+	ev := func(at, status, previous string, questions ...string) events.Event {
+		qs := []any{}
+		for _, q := range questions {
+			qs = append(qs, q)
+		}
+		return events.Event{Timestamp: at, Event: "runner_status_changed", Data: map[string]any{
+			"status": status, "previous_status": previous, "summary": status + " summary", "questions": qs, "source": "mcp",
+		}}
+	}
+	evs := []events.Event{
+		ev("2026-01-10T12:00:00Z", "working", ""),
+		ev("2026-01-10T12:25:00Z", "needs_input", "working", "postgres?"),
+		ev("2026-01-10T12:40:00Z", "needs_input", "needs_input", "postgres?", "keep v1?"),
+		ev("2026-01-10T13:30:00Z", "working", "needs_input"),
+		ev("2026-01-10T14:10:00Z", "ready_for_review", "working"),
+	}
+	h := buildStatusHistory(evs, time.Date(2026, 1, 10, 14, 22, 0, 0, time.UTC))
+
+	if len(h.Entries) != 5 || h.Entries[1].DurationSeconds != 15*60 || h.Entries[4].DurationSeconds != 12*60 {
+		t.Fatalf("entries = %+v", h.Entries)
+	}
+	want := []struct {
+		status  string
+		minutes int64
+		entries int
+	}{
+		{"working", 25 + 40, 2},
+		{"needs_input", 65, 1},
+		{"ready_for_review", 12, 1},
+	}
+	if len(h.Totals) != len(want) {
+		t.Fatalf("totals = %+v", h.Totals)
+	}
+	for i, w := range want {
+		got := h.Totals[i]
+		if got.Status != w.status || got.DurationSeconds != w.minutes*60 || got.Entries != w.entries {
+			t.Errorf("totals[%d] = %+v, want %s %dm %d entries", i, got, w.status, w.minutes, w.entries)
+		}
+	}
+	if h.QuestionsAsked != 2 {
+		t.Errorf("QuestionsAsked = %d, want 2 distinct questions", h.QuestionsAsked)
+	}
+}
+
+func TestShowHistory(t *testing.T) {
+	st, repoID := setupDaemonTest(t)
+	runID := "20260110120000-a3f2"
+	writeDaemonTestRun(t, st, repoID, runID, "auth-fix")
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := StatusSet(context.Background(), fs.NewRealFS(), meta.WorktreePath, StatusSetOpts{Status: "needs_input", Questions: []string{"postgres?"}}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+
+	cr := &fakeCommandRunner{responses: map[string]fakeResponse{
+		"tmux list-sessions -F #{session_name}": {stdout: "agency_" + runID + "\n"},
+	}}
+	stdout.Reset()
+	if err := Show(context.Background(), cr, fs.NewRealFS(), t.TempDir(), ShowOpts{RunID: "auth-fix", History: true}, &stdout, &stderr); err != nil {
+		t.Fatalf("Show(--history) error = %v", err)
+	}
+	out := stdout.String()
+	for _, want := range []string{"run: " + runID + " (auth-fix)", "needs_input", "? postgres?", "totals:", "questions asked: 1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	stdout.Reset()
+	if err := Show(context.Background(), cr, fs.NewRealFS(), t.TempDir(), ShowOpts{RunID: "auth-fix", History: true, JSON: true}, &stdout, &stderr); err != nil {
+		t.Fatalf("Show(--history --json) error = %v", err)
+	}
+	var env struct {
+		SchemaVersion string `json:"schema_version"`
+		Data          struct {
+			Entries []struct {
+				Status    string   `json:"status"`
+				Questions []string `json:"questions"`
+			} `json:"entries"`
+			Totals []struct {
+				Status string `json:"status"`
+			} `json:"totals"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &env); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	if env.SchemaVersion != "1.0" || len(env.Data.Entries) != 1 || env.Data.Entries[0].Status != "needs_input" || len(env.Data.Totals) != 1 {
+		t.Errorf("history JSON = %+v", env)
+	}
+
+	err = Show(context.Background(), cr, fs.NewRealFS(), t.TempDir(), ShowOpts{RunID: "auth-fix", History: true, Path: true}, &stdout, &stderr)
+	if errors.GetCode(err) != errors.EUsage {
+		t.Errorf("Show(--history --path) error = %v, want E_USAGE", err)
+	}
+}
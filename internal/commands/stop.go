@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	"github.com/NielsdaWheelz/agency/internal/events"
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
 	"github.com/NielsdaWheelz/agency/internal/store"
 	"github.com/NielsdaWheelz/agency/internal/tmux"
 )
@@ -26,6 +28,7 @@ type StopOpts struct {
 }
 
 // Stop sends C-c to the runner in the tmux session (best-effort interrupt).
+// Headless runners get SIGINT instead.
 // Works from any directory; resolves runs globally.
 func Stop(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts StopOpts, stdout, stderr io.Writer) error {
 	// Create real tmux client
@@ -60,6 +63,11 @@ func StopWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 	// Create store for later operations
 	st := store.NewStore(fsys, rctx.DataDir, nil)
 
+	// Headless runs have no session: interrupt the runner's process group
+	if meta, err := st.ReadMeta(repoID, runID); err == nil && meta.Headless != nil {
+		return stopHeadlessRunner(st, meta, stderr)
+	}
+
 	// Compute session name from run_id (source of truth from tmux.SessionName)
 	sessionName := tmux.SessionName(runID)
 
@@ -114,3 +122,37 @@ func StopWithTmux(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS,
 
 	return nil
 }
+
+// stopHeadlessRunner sends SIGINT (the equivalent of C-c) to a headless
+// runner and flags the run as needing attention.
+func stopHeadlessRunner(st *store.Store, meta *store.RunMeta, stderr io.Writer) error {
+	signaled, err := headless.SignalRunner(meta, syscall.SIGINT)
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to interrupt headless runner", err)
+	}
+	if !signaled {
+		// Runner not running - no-op, exit 0
+		_, _ = fmt.Fprintf(stderr, "runner not running for %s\n", meta.RunID)
+		return nil
+	}
+
+	metaErr := st.UpdateMeta(meta.RepoID, meta.RunID, func(m *store.RunMeta) {
+		if m.Flags == nil {
+			m.Flags = &store.RunMetaFlags{}
+		}
+		m.Flags.NeedsAttention = true
+	})
+
+	eventErr := events.AppendEvent(st.EventsPath(meta.RepoID, meta.RunID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		RepoID:        meta.RepoID,
+		RunID:         meta.RunID,
+		Event:         "stop",
+		Data:          events.SignalRunnerData(meta.RunnerPID, "SIGINT"),
+	})
+	if eventErr != nil {
+		return errors.Wrap(errors.EPersistFailed, "failed to append stop event", eventErr)
+	}
+	return metaErr
+}
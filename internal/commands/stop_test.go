@@ -5,9 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	osexec "os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	"github.com/NielsdaWheelz/agency/internal/exec"
@@ -27,6 +30,7 @@ type fakeTmuxClient struct {
 	// Track calls
 	hasSessionCalls []string
 	sendKeysCalls   []sendKeysCall
+	sendTextCalls   []string
 	killCalls       []string
 }
 
@@ -58,6 +62,11 @@ func (f *fakeTmuxClient) SendKeys(ctx context.Context, name string, keys []tmux.
 	return f.sendKeysErr
 }
 
+func (f *fakeTmuxClient) SendText(ctx context.Context, name, text string) error {
+	f.sendTextCalls = append(f.sendTextCalls, text)
+	return f.sendKeysErr
+}
+
 // setupStopTestEnv creates a temporary test environment for stop/kill tests.
 func setupStopTestEnv(t *testing.T, runID string, setupMeta bool) (string, string, string, *fakeCommandRunner, fs.FS) {
 	t.Helper()
@@ -282,3 +291,81 @@ func TestStop_RunNotFound(t *testing.T) {
 		t.Errorf("error code = %q, want %q", code, errors.ERunNotFound)
 	}
 }
+
+// startHeadlessTestRunner starts a long-running process in its own process
+// group, like the headless supervisor does, and marks the run as headless
+// with that runner pid. The returned channel is closed when it exits.
+func startHeadlessTestRunner(t *testing.T, st *store.Store, repoID, runID string) (int, <-chan struct{}) {
+	t.Helper()
+	cmd := osexec.Command("sleep", "60")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(done)
+	}()
+	t.Cleanup(func() {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+	})
+	err := st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.Headless = &store.RunMetaHeadless{}
+		m.TmuxSessionName = ""
+		m.RunnerPID = cmd.Process.Pid
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid, done
+}
+
+// waitExited fails the test unless done is closed within a few seconds.
+func waitExited(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runner still running")
+	}
+}
+
+func TestStop_Headless(t *testing.T) {
+	runID := "20260110120000-a3f2"
+	repoDir, dataDir, repoID, cr, fsys := setupStopTestEnv(t, runID, true)
+	st := store.NewStore(fsys, dataDir, nil)
+	pid, done := startHeadlessTestRunner(t, st, repoID, runID)
+
+	fakeTmux := &fakeTmuxClient{}
+	var stdout, stderr bytes.Buffer
+	if err := StopWithTmux(context.Background(), cr, fsys, fakeTmux, repoDir, StopOpts{RunID: runID}, &stdout, &stderr); err != nil {
+		t.Fatalf("Stop() error = %v, want nil", err)
+	}
+	waitExited(t, done)
+	if len(fakeTmux.hasSessionCalls) != 0 || len(fakeTmux.sendKeysCalls) != 0 {
+		t.Error("tmux used for a headless run")
+	}
+
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Flags == nil || !meta.Flags.NeedsAttention {
+		t.Error("expected needs_attention after stop")
+	}
+	evs := readTestEvents(t, st, repoID, runID, "stop")
+	if len(evs) != 1 || evs[0].Data["signal"] != "SIGINT" || evs[0].Data["runner_pid"] != float64(pid) {
+		t.Errorf("stop events = %+v", evs)
+	}
+
+	// The runner is gone: nothing to stop
+	stderr.Reset()
+	if err := StopWithTmux(context.Background(), cr, fsys, fakeTmux, repoDir, StopOpts{RunID: runID}, &stdout, &stderr); err != nil {
+		t.Fatalf("Stop() second call error = %v", err)
+	}
+	if !strings.Contains(stderr.String(), "runner not running") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
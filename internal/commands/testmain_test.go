@@ -1,10 +1,17 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/NielsdaWheelz/agency/internal/checkpoint"
+	"github.com/NielsdaWheelz/agency/internal/config"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/monitor"
+	"github.com/NielsdaWheelz/agency/internal/notify"
 	"github.com/NielsdaWheelz/agency/internal/testutil"
 )
 
@@ -13,5 +20,15 @@ func TestMain(m *testing.M) {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	// Never re-exec the test binary as a detached checkpoint watcher
+	spawnCheckpointWatcher = func(checkpoint.SpawnOpts) (int, error) { return 0, nil }
+	// ...or as a detached run monitor
+	spawnRunMonitor = func(monitor.SpawnOpts) (int, error) { return 0, nil }
+	// ...or as a detached queue processor
+	spawnQueueRunner = func(string) error { return nil }
+	// ...or as a detached daemon
+	spawnDaemon = func(string, time.Duration) (int, error) { return 0, nil }
+	// Never run a developer's notify command or webhook
+	sendNotification = func(context.Context, agencyexec.CommandRunner, config.UserNotify, notify.Payload) error { return nil }
 	os.Exit(m.Run())
 }
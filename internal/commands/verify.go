@@ -57,6 +57,9 @@ func Verify(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd st
 	// Create verify service and run verification
 	svc := verifyservice.NewService(rctx.DataDir, fsys)
 	result, err := svc.VerifyRun(ctx, runID, timeout)
+	if userCfg, cfgErr := loadUserConfig(fsys); cfgErr == nil {
+		notifyRun(ctx, cr, fsys, store.NewStore(fsys, rctx.DataDir, time.Now), userCfg.Notify, resolved.RepoID, runID)
+	}
 
 	// Handle the result/error based on spec output contract
 	return formatVerifyOutput(result, err, stdout, stderr)
@@ -0,0 +1,334 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/git"
+	"github.com/NielsdaWheelz/agency/internal/identity"
+	"github.com/NielsdaWheelz/agency/internal/paths"
+	"github.com/NielsdaWheelz/agency/internal/render"
+	"github.com/NielsdaWheelz/agency/internal/runnerstatus"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+	"github.com/NielsdaWheelz/agency/internal/tty"
+	"github.com/NielsdaWheelz/agency/internal/watch"
+)
+
+// WatchOpts holds options for the watch command.
+type WatchOpts struct {
+	// RepoPath is the optional --repo flag to scope the view to a specific repo.
+	RepoPath string
+
+	// AllRepos shows all repos (ignores current repo scope).
+	AllRepos bool
+
+	// All includes archived runs and worktrees.
+	All bool
+
+	// Interval is the refresh interval (default: watch.DefaultInterval).
+	Interval time.Duration
+
+	// JSON streams one JSON line per run state transition instead of the TUI.
+	JSON bool
+
+	// Once prints the JSON snapshot line and exits (requires JSON).
+	Once bool
+}
+
+// watchScope is the set of repos a watch snapshot covers.
+type watchScope struct {
+	// RepoID limits the snapshot to one repo; empty means all repos.
+	RepoID string
+
+	// Label describes the scope in the TUI header.
+	Label string
+}
+
+// Watch runs the interactive watch TUI, or with --json streams run state
+// transitions as JSON lines.
+// Watch is a read-only client of the store: it polls meta files and tmux,
+// and every action re-invokes the agency binary.
+func Watch(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, cwd string, opts WatchOpts, stdout, stderr io.Writer) error {
+	if opts.Once && !opts.JSON {
+		return errors.New(errors.EUsage, "--once requires --json")
+	}
+	if !opts.JSON && (!tty.IsInteractive() || !tty.IsTTY(os.Stdout)) {
+		return errors.New(errors.ENotInteractive, "watch requires an interactive terminal; use 'agency ls --json' in scripts")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dataDir := paths.ResolveDirs(osEnv{}, homeDir).DataDir
+
+	scope, err := resolveWatchScope(ctx, cr, fsys, dataDir, cwd, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// Always load archived runs so the archived transition is visible;
+		// --all only controls the snapshot line.
+		return watch.Stream(ctx, watch.StreamOptions{
+			Load: func(ctx context.Context) (*watch.Snapshot, error) {
+				return collectWatchSnapshot(ctx, cr, fsys, dataDir, scope, true)
+			},
+			Interval: opts.Interval,
+			Once:     opts.Once,
+			All:      opts.All,
+		}, stdout, stderr)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to locate agency executable", err)
+	}
+
+	return watch.Run(ctx, watch.Options{
+		Load: func(ctx context.Context) (*watch.Snapshot, error) {
+			return collectWatchSnapshot(ctx, cr, fsys, dataDir, scope, opts.All)
+		},
+		Executable: exe,
+		Interval:   opts.Interval,
+		Scope:      scope.Label,
+	})
+}
+
+// resolveWatchScope mirrors ls: --repo or the cwd repo scopes to one repo,
+// otherwise (or with --all-repos) all repos are shown.
+func resolveWatchScope(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, dataDir, cwd string, opts WatchOpts) (watchScope, error) {
+	if opts.AllRepos {
+		return watchScope{Label: "all repos"}, nil
+	}
+
+	var repoID string
+	if opts.RepoPath != "" {
+		_, id, err := ResolveRepoContext(ctx, cr, cwd, opts.RepoPath)
+		if err != nil {
+			return watchScope{}, err
+		}
+		repoID = id
+	} else if repoRoot, err := git.GetRepoRoot(ctx, cr, cwd); err == nil {
+		originInfo := git.GetOriginInfo(ctx, cr, repoRoot.Path)
+		repoID = identity.DeriveRepoIdentity(repoRoot.Path, originInfo.URL).RepoID
+	} else {
+		return watchScope{Label: "all repos"}, nil
+	}
+
+	label := "repo " + repoID
+	st := store.NewStore(fsys, dataDir, nil)
+	if rec, ok, err := st.LoadRepoRecord(repoID); err == nil && ok && rec.RepoKey != "" {
+		label = rec.RepoKey
+	}
+	return watchScope{RepoID: repoID, Label: label}, nil
+}
+
+// collectWatchSnapshot polls the store and tmux for one watch refresh.
+// It never writes: runner exits are not reconciled here (ls does that).
+func collectWatchSnapshot(ctx context.Context, cr agencyexec.CommandRunner, fsys fs.FS, dataDir string, scope watchScope, all bool) (*watch.Snapshot, error) {
+	st := store.NewStore(fsys, dataDir, nil)
+	tmuxClient := tmux.NewExecClient(cr)
+
+	repoRoots := map[string]string{}
+	repoRoot := func(repoID string) string {
+		root, ok := repoRoots[repoID]
+		if !ok {
+			if rec, found, err := st.LoadRepoRecord(repoID); err == nil && found {
+				root = rec.RepoRootLastSeen
+			}
+			repoRoots[repoID] = root
+		}
+		return root
+	}
+
+	var (
+		runRecords []store.RunRecord
+		wtRecords  []store.IntegrationWorktreeRecord
+		invRecords []store.InvocationRecord
+		err        error
+	)
+	if scope.RepoID == "" {
+		if runRecords, err = store.ScanAllRuns(dataDir); err != nil {
+			return nil, err
+		}
+		if wtRecords, err = store.ScanAllIntegrationWorktrees(dataDir); err != nil {
+			return nil, err
+		}
+		if invRecords, err = store.ScanAllInvocations(dataDir); err != nil {
+			return nil, err
+		}
+	} else {
+		if runRecords, err = store.ScanRunsForRepo(dataDir, scope.RepoID); err != nil {
+			return nil, err
+		}
+		if wtRecords, err = store.ScanIntegrationWorktreesForRepo(dataDir, scope.RepoID); err != nil {
+			return nil, err
+		}
+		if invRecords, err = store.ScanInvocationsForRepo(dataDir, scope.RepoID); err != nil {
+			return nil, err
+		}
+	}
+
+	snap := &watch.Snapshot{TakenAt: time.Now().UTC()}
+
+	// Integration worktrees with their invocations
+	byWorktree := map[string][]watch.InvocationItem{}
+	for _, r := range invRecords {
+		if r.Meta == nil || (r.Broken && !all) {
+			continue
+		}
+		byWorktree[r.Meta.IntegrationWorktreeID] = append(byWorktree[r.Meta.IntegrationWorktreeID],
+			watchInvocation(ctx, tmuxClient, r, repoRoot(r.RepoID)))
+	}
+	for _, r := range wtRecords {
+		w := watch.WorktreeItem{WorktreeID: r.WorktreeID, RepoID: r.RepoID, RepoRoot: repoRoot(r.RepoID), Broken: r.Broken}
+		if m := r.Meta; m != nil {
+			if m.State == store.WorktreeStateArchived && !all {
+				continue
+			}
+			w.Name = m.Name
+			w.Branch = m.Branch
+			w.ParentBranch = m.ParentBranch
+			w.TreePath = m.TreePath
+			w.State = string(m.State)
+			w.CreatedAt = m.CreatedAt
+			w.Landings = len(m.Landings)
+		}
+		w.Invocations = byWorktree[r.WorktreeID]
+		sort.Slice(w.Invocations, func(i, j int) bool {
+			return w.Invocations[i].InvocationID < w.Invocations[j].InvocationID
+		})
+		snap.Worktrees = append(snap.Worktrees, w)
+	}
+	sort.Slice(snap.Worktrees, func(i, j int) bool {
+		return snap.Worktrees[i].WorktreeID < snap.Worktrees[j].WorktreeID
+	})
+
+	// v1 runs, with the same derivation as ls
+	records := map[string]store.RunRecord{}
+	summaries := make([]render.RunSummary, 0, len(runRecords))
+	for _, rec := range runRecords {
+		sessions := map[string]bool{}
+		if meta := rec.Meta; meta != nil && meta.Headless == nil && dirExists(meta.WorktreePath) {
+			name := meta.TmuxSessionName
+			if name == "" {
+				name = "agency_" + rec.RunID
+			}
+			sessions[name], _ = tmuxClient.HasSession(ctx, name)
+		}
+		summary := recordToSummary(nil, rec, sessions, fsys)
+		if summary.Archived && !all {
+			continue
+		}
+		summaries = append(summaries, summary)
+		records[rec.RepoID+"/"+rec.RunID] = rec
+	}
+	sortSummaries(summaries)
+	for _, s := range summaries {
+		r := watchRun(s, records[s.RepoID+"/"+s.RunID], repoRoot(s.RepoID))
+		if r.LastVerifyAt != "" {
+			r.VerifyOK = readVerifyOK(fsys, st.VerifyRecordPath(r.RepoID, r.RunID))
+		}
+		snap.Runs = append(snap.Runs, r)
+	}
+
+	return snap, nil
+}
+
+// watchRun converts an ls summary plus its record into a watch row.
+func watchRun(s render.RunSummary, rec store.RunRecord, repoRoot string) watch.RunItem {
+	r := watch.RunItem{
+		RunID:         s.RunID,
+		RepoID:        s.RepoID,
+		RepoRoot:      repoRoot,
+		DerivedStatus: s.DerivedStatus,
+		TmuxActive:    s.TmuxActive,
+		Archived:      s.Archived,
+		Broken:        s.Broken,
+	}
+	if s.Summary != nil {
+		r.Summary = *s.Summary
+	}
+	if s.StalledDuration != nil {
+		r.Stalled = *s.StalledDuration
+	}
+
+	meta := rec.Meta
+	if meta == nil {
+		return r
+	}
+	r.Name = meta.Name
+	r.Runner = meta.Runner
+	r.Branch = meta.Branch
+	r.WorktreePath = meta.WorktreePath
+	r.CreatedAt = meta.CreatedAt
+	r.Headless = meta.Headless != nil
+	r.PRNumber = meta.PRNumber
+	r.PRURL = meta.PRURL
+	r.LastVerifyAt = meta.LastVerifyAt
+
+	if s.WorktreePresent {
+		if rs, err := runnerstatus.Load(meta.WorktreePath); err == nil && rs != nil && rs.Validate() == nil {
+			r.RunnerStatus = string(rs.Status)
+			r.StatusUpdatedAt = rs.UpdatedAt
+			r.Questions = rs.Questions
+			r.Blockers = rs.Blockers
+			r.Risks = rs.Risks
+			r.HowToTest = rs.HowToTest
+		}
+	}
+	return r
+}
+
+// readVerifyOK returns the ok field of a verify_record.json, or nil if the
+// record is missing or unreadable.
+func readVerifyOK(fsys fs.FS, path string) *bool {
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var rec store.VerifyRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil
+	}
+	return &rec.OK
+}
+
+// watchInvocation converts an invocation record into a watch row. A headed
+// invocation still marked running whose tmux session is gone is shown as
+// "exited" without writing meta (agent ls reconciles it).
+func watchInvocation(ctx context.Context, tmuxClient tmux.Client, r store.InvocationRecord, repoRoot string) watch.InvocationItem {
+	m := r.Meta
+	inv := watch.InvocationItem{
+		InvocationID:  r.InvocationID,
+		Name:          m.InvocationName,
+		Runner:        m.Runner,
+		Mode:          string(m.Mode),
+		Status:        string(m.Status),
+		LandingStatus: m.LandingStatus,
+		StartedAt:     m.StartedAt,
+		SandboxPath:   m.SandboxPath,
+		RepoRoot:      repoRoot,
+		Broken:        r.Broken,
+	}
+	if m.Mode == store.InvocationModeHeaded && m.Status == store.InvocationStatusRunning && m.TmuxSession != "" {
+		if exists, err := tmuxClient.HasSession(ctx, m.TmuxSession); err == nil && !exists {
+			inv.Status = "exited"
+		}
+	}
+	return inv
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+func TestCollectWatchSnapshot(t *testing.T) {
+	dataDir := t.TempDir()
+	st := store.NewStore(fs.NewRealFS(), dataDir, time.Now)
+	repoID := "repo123456789012"
+	now := time.Now()
+
+	// Run with a present worktree and a needs_input runner status
+	worktreePath := t.TempDir()
+	stateDir := filepath.Join(worktreePath, ".agency", "state")
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	status := `{"schema_version":"1.0","status":"needs_input","updated_at":"2026-01-15T12:00:00Z",` +
+		`"summary":"need a decision","questions":["Which API?"],"blockers":[],"risks":["breaking change"]}`
+	if err := os.WriteFile(filepath.Join(stateDir, "runner_status.json"), []byte(status), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeRun := func(runID, name, path string) {
+		t.Helper()
+		if _, err := st.EnsureRunDir(repoID, runID); err != nil {
+			t.Fatal(err)
+		}
+		meta := store.NewRunMeta(runID, repoID, name, "claude", "claude", "main", "agency/"+name, path, now)
+		if err := st.WriteInitialMeta(repoID, runID, meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeRun("20260115120000-a3f2", "feature", worktreePath)
+	writeRun("20260115110000-b4c3", "gone", filepath.Join(t.TempDir(), "missing"))
+
+	// Integration worktree with one invocation
+	wtID := "20260115100000-c5d4"
+	if _, err := st.EnsureIntegrationWorktreeDir(repoID, wtID); err != nil {
+		t.Fatal(err)
+	}
+	wtMeta := store.NewIntegrationWorktreeMeta(wtID, "integration", repoID, "agency/integration-c5d4", "main", t.TempDir(), now)
+	if err := st.WriteIntegrationWorktreeMeta(repoID, wtID, wtMeta); err != nil {
+		t.Fatal(err)
+	}
+	invID := "20260115100500-d6e5"
+	if _, err := st.EnsureInvocationDir(repoID, invID); err != nil {
+		t.Fatal(err)
+	}
+	sandbox := filepath.Join(st.InvocationDir(repoID, invID), "tree")
+	if err := os.MkdirAll(sandbox, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	invMeta := store.NewInvocationMeta(invID, "", repoID, wtID, sandbox, "agency/sandbox-"+invID, "abc123", "claude", store.InvocationModeHeadless, now)
+	if err := st.WriteInvocationMeta(repoID, invID, invMeta); err != nil {
+		t.Fatal(err)
+	}
+
+	cr := &stubRunner{exitCode: 1}
+	scope := watchScope{RepoID: repoID}
+	snap, err := collectWatchSnapshot(context.Background(), cr, fs.NewRealFS(), dataDir, scope, false)
+	if err != nil {
+		t.Fatalf("collectWatchSnapshot() error = %v", err)
+	}
+
+	if len(snap.Worktrees) != 1 || snap.Worktrees[0].Name != "integration" {
+		t.Fatalf("worktrees = %+v", snap.Worktrees)
+	}
+	if invs := snap.Worktrees[0].Invocations; len(invs) != 1 || invs[0].InvocationID != invID {
+		t.Errorf("invocations = %+v", invs)
+	}
+
+	// Archived run is hidden by default
+	if len(snap.Runs) != 1 {
+		t.Fatalf("runs = %+v, want only the present run", snap.Runs)
+	}
+	run := snap.Runs[0]
+	if run.Name != "feature" || run.Summary != "need a decision" || run.RunnerStatus != "needs_input" {
+		t.Errorf("run = %+v", run)
+	}
+	if len(run.Questions) != 1 || run.Questions[0] != "Which API?" || len(run.Risks) != 1 {
+		t.Errorf("runner report not loaded: %+v", run)
+	}
+	if run.DerivedStatus == "" {
+		t.Error("expected a derived status")
+	}
+
+	snap, err = collectWatchSnapshot(context.Background(), cr, fs.NewRealFS(), dataDir, scope, true)
+	if err != nil {
+		t.Fatalf("collectWatchSnapshot(all) error = %v", err)
+	}
+	if len(snap.Runs) != 2 {
+		t.Errorf("runs with all = %d, want 2", len(snap.Runs))
+	}
+}
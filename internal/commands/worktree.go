@@ -19,6 +19,7 @@ import (
 	"github.com/NielsdaWheelz/agency/internal/identity"
 	"github.com/NielsdaWheelz/agency/internal/integrationworktree"
 	"github.com/NielsdaWheelz/agency/internal/paths"
+	"github.com/NielsdaWheelz/agency/internal/render"
 	"github.com/NielsdaWheelz/agency/internal/store"
 )
 
@@ -562,6 +563,120 @@ func WorktreeRm(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd stri
 	return nil
 }
 
+// WorktreeLandOpts holds options for the worktree land command.
+type WorktreeLandOpts struct {
+	WorktreeRef string
+	RunRef      string
+
+	// Apply lands the run's working-tree diff as one commit instead of cherry-picking.
+	Apply bool
+
+	// RequireBase fails unless the integration HEAD contains the run's base.
+	RequireBase bool
+}
+
+// WorktreeLand lands a run's work into an integration worktree.
+func WorktreeLand(ctx context.Context, cr exec.CommandRunner, fsys fs.FS, cwd string, opts WorktreeLandOpts, stdout, stderr io.Writer) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(errors.EInternal, "failed to get home directory", err)
+	}
+	dirs := paths.ResolveDirs(osEnv{}, homeDir)
+
+	// Get repo context
+	repoRoot, err := git.GetRepoRoot(ctx, cr, cwd)
+	if err != nil {
+		return errors.New(errors.ENoRepo, "not inside a git repository")
+	}
+	originInfo := git.GetOriginInfo(ctx, cr, repoRoot.Path)
+	repoIdentity := identity.DeriveRepoIdentity(repoRoot.Path, originInfo.URL)
+
+	// Resolve worktree
+	st := store.NewStore(fsys, dirs.DataDir, time.Now)
+	svc := integrationworktree.NewService(st, cr, fsys, time.Now)
+
+	record, err := svc.Resolve(repoIdentity.RepoID, opts.WorktreeRef, false)
+	if err != nil {
+		return err
+	}
+	if record.Broken {
+		return errors.NewWithDetails(
+			errors.EWorktreeBroken,
+			"worktree exists but meta.json is unreadable or invalid",
+			map[string]string{
+				"worktree_id":  record.WorktreeID,
+				"worktree_dir": record.WorktreeDir,
+			},
+		)
+	}
+
+	// Resolve run (must belong to the same repo)
+	rctx, err := ResolveRunContext(ctx, cr, cwd, repoRoot.Path)
+	if err != nil {
+		return err
+	}
+	resolved, err := ResolveRun(rctx, opts.RunRef)
+	if err != nil {
+		return err
+	}
+	if resolved.Broken || resolved.Record == nil || resolved.Record.Meta == nil {
+		return errors.NewWithDetails(
+			errors.ERunBroken,
+			"run exists but meta.json is unreadable or invalid",
+			map[string]string{"run_id": resolved.RunID, "repo_id": resolved.RepoID},
+		)
+	}
+	run := resolved.Record.Meta
+	if resolved.RepoID != repoIdentity.RepoID {
+		return errors.NewWithDetails(
+			errors.ERunNotFound,
+			"run belongs to a different repo than the worktree",
+			map[string]string{"run_id": run.RunID, "repo_id": resolved.RepoID},
+		)
+	}
+
+	parentRef, err := resolveParentRef(ctx, cr, repoRoot.Path, run.ParentBranch)
+	if err != nil {
+		return err
+	}
+
+	mode := integrationworktree.LandModeCherryPick
+	if opts.Apply {
+		mode = integrationworktree.LandModeApply
+	}
+
+	res, err := svc.Land(ctx, integrationworktree.LandOpts{
+		RepoID:      repoIdentity.RepoID,
+		WorktreeID:  record.WorktreeID,
+		Run:         run,
+		ParentRef:   parentRef,
+		Mode:        mode,
+		RequireBase: opts.RequireBase,
+	})
+	if err != nil {
+		if errors.GetCode(err) == errors.ELandConflict && res != nil {
+			render.WriteLandConflictCard(stderr, render.LandConflictCardInputs{
+				WorktreeRef:     opts.WorktreeRef,
+				RunRef:          opts.RunRef,
+				Mode:            res.Mode,
+				TreePath:        record.Meta.TreePath,
+				Branch:          run.Branch,
+				RunWorktreePath: run.WorktreePath,
+				BaseSHA:         res.BaseSHA,
+				Commit:          res.ConflictCommit,
+				Files:           res.ConflictFiles,
+			})
+		}
+		return err
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Landed run '%s' (%s) into worktree '%s' via %s\n", run.Name, run.RunID, record.Meta.Name, res.Mode)
+	for _, sha := range res.Commits {
+		_, _ = fmt.Fprintf(stdout, "  %s\n", shortSHA(sha))
+	}
+	return nil
+}
+
 // ensureRepoRecord ensures a repo record exists for the given repo identity.
 func ensureRepoRecord(fsys fs.FS, dataDir string, repoIdentity identity.RepoIdentity, originInfo git.OriginInfo) error {
 	st := store.NewStore(fsys, dataDir, time.Now)
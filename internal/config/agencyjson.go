@@ -22,8 +22,19 @@ const (
 
 // AgencyConfig represents the parsed and validated agency.json configuration.
 type AgencyConfig struct {
-	Version int     `json:"version"`
-	Scripts Scripts `json:"scripts"`
+	Version  int      `json:"version"`
+	Scripts  Scripts  `json:"scripts"`
+	Denylist Denylist `json:"denylist"`
+	Budgets  Budgets  `json:"budgets"`
+}
+
+// Budgets contains the default runtime limits of runs (0 = no limit).
+type Budgets struct {
+	// MaxDuration interrupts a runner this long after the run started.
+	MaxDuration time.Duration `json:"-"` // Parsed from "max_duration" string field
+
+	// MaxStall interrupts a runner with no activity for this long.
+	MaxStall time.Duration `json:"-"` // Parsed from "max_stall" string field
 }
 
 // Scripts contains configuration for the required agency scripts.
@@ -53,7 +64,12 @@ func LoadAgencyConfig(filesystem fs.FS, repoRoot string) (AgencyConfig, error) {
 		}
 		return AgencyConfig{}, errors.Wrap(errors.ENoAgencyJSON, "failed to read agency.json", err)
 	}
+	return ParseAgencyConfig(data)
+}
 
+// ParseAgencyConfig parses the contents of an agency.json, e.g. one read from
+// a git ref rather than a checkout. Errors are as for LoadAgencyConfig.
+func ParseAgencyConfig(data []byte) (AgencyConfig, error) {
 	// First, unmarshal into raw map for type checking
 	var raw map[string]json.RawMessage
 	if err := json.Unmarshal(data, &raw); err != nil {
@@ -74,8 +90,10 @@ func LoadAgencyConfig(filesystem fs.FS, repoRoot string) (AgencyConfig, error) {
 func parseWithStrictTypes(raw map[string]json.RawMessage) (AgencyConfig, error) {
 	var cfg AgencyConfig
 	allowedKeys := map[string]bool{
-		"version": true,
-		"scripts": true,
+		"version":  true,
+		"scripts":  true,
+		"denylist": true,
+		"budgets":  true,
 	}
 	for key := range raw {
 		if !allowedKeys[key] {
@@ -138,9 +156,62 @@ func parseWithStrictTypes(raw map[string]json.RawMessage) (AgencyConfig, error)
 		}
 	}
 
+	// Parse denylist - optional, must be object
+	if rawDenylist, ok := raw["denylist"]; ok {
+		denylist, err := parseDenylist(rawDenylist)
+		if err != nil {
+			return AgencyConfig{}, err
+		}
+		cfg.Denylist = denylist
+	}
+
+	// Parse budgets - optional, must be object
+	if rawBudgets, ok := raw["budgets"]; ok {
+		budgets, err := parseBudgets(rawBudgets)
+		if err != nil {
+			return AgencyConfig{}, err
+		}
+		cfg.Budgets = budgets
+	}
+
 	return cfg, nil
 }
 
+// parseBudgets parses the budgets object: optional "max_duration" and
+// "max_stall" Go duration strings of at least 1m.
+func parseBudgets(raw json.RawMessage) (Budgets, error) {
+	var budgets Budgets
+	var budgetsMap map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &budgetsMap); err != nil {
+		return budgets, errors.New(errors.EInvalidAgencyJSON, "budgets must be an object")
+	}
+
+	fields := map[string]*time.Duration{
+		"max_duration": &budgets.MaxDuration,
+		"max_stall":    &budgets.MaxStall,
+	}
+	for key, rawValue := range budgetsMap {
+		target, ok := fields[key]
+		if !ok {
+			return budgets, errors.New(errors.EInvalidAgencyJSON, "budgets contains unknown field: "+key)
+		}
+		var value string
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return budgets, errors.New(errors.EInvalidAgencyJSON, "budgets."+key+" must be a string (Go duration format, e.g., '45m', '2h')")
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return budgets, errors.New(errors.EInvalidAgencyJSON, "budgets."+key+" invalid duration: "+err.Error())
+		}
+		if d < MinTimeout {
+			return budgets, errors.New(errors.EInvalidAgencyJSON, "budgets."+key+" must be at least 1m")
+		}
+		*target = d
+	}
+
+	return budgets, nil
+}
+
 // parseScriptConfig parses a script configuration from raw JSON.
 // The script config must be an object with "path" (required) and "timeout" (optional) fields.
 func parseScriptConfig(raw json.RawMessage, fieldName string, defaultTimeout time.Duration) (ScriptConfig, error) {
@@ -432,3 +432,44 @@ func TestLoadAgencyConfig_RealFS(t *testing.T) {
 		t.Errorf("Scripts.Setup.Timeout = %v, want %v", cfg.Scripts.Setup.Timeout, 10*time.Minute)
 	}
 }
+
+func TestLoadAgencyConfig_Budgets(t *testing.T) {
+	stub := newStubFS()
+	stub.files["/repo/agency.json"] = []byte(`{
+		"version": 1,
+		"scripts": {"setup": {"path": "s"}, "verify": {"path": "v"}, "archive": {"path": "a"}},
+		"budgets": {"max_duration": "2h", "max_stall": "45m"}
+	}`)
+
+	cfg, err := LoadAgencyConfig(stub, "/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Budgets.MaxDuration != 2*time.Hour || cfg.Budgets.MaxStall != 45*time.Minute {
+		t.Errorf("Budgets = %+v", cfg.Budgets)
+	}
+}
+
+func TestLoadAgencyConfig_InvalidBudgets(t *testing.T) {
+	tests := []struct {
+		name    string
+		budgets string
+		wantMsg string
+	}{
+		{"not object", `"2h"`, "budgets must be an object"},
+		{"unknown field", `{"max_tokens": "1000"}`, "budgets contains unknown field: max_tokens"},
+		{"not string", `{"max_duration": 7200}`, "budgets.max_duration must be a string"},
+		{"bad duration", `{"max_stall": "45 minutes"}`, "budgets.max_stall invalid duration"},
+		{"too short", `{"max_stall": "30s"}`, "budgets.max_stall must be at least 1m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubFS()
+			stub.files["/repo/agency.json"] = []byte(`{"version": 1, "budgets": ` + tt.budgets + `}`)
+			_, err := LoadAgencyConfig(stub, "/repo")
+			if errors.GetCode(err) != errors.EInvalidAgencyJSON || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %v, want E_INVALID_AGENCY_JSON containing %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+)
+
+// DefaultDenylistPatterns are the built-in secret file patterns blocked by push.
+// agency.json denylist.patterns extends (never replaces) this list.
+var DefaultDenylistPatterns = []string{
+	".env",
+	".env.*",
+	"*.pem",
+	"*.key",
+	"*.p12",
+	"*.pfx",
+	"*.jks",
+	"*.keystore",
+	"id_rsa",
+	"id_dsa",
+	"id_ecdsa",
+	"id_ed25519",
+	"credentials.json",
+	".netrc",
+	".git-credentials",
+}
+
+// DefaultDenylistAllow are built-in exceptions for conventional non-secret templates.
+var DefaultDenylistAllow = []string{
+	".env.example",
+	".env.sample",
+	".env.template",
+	".env.dist",
+}
+
+// Denylist holds the repo's additional secret file patterns (agency.json "denylist").
+//
+// Pattern syntax:
+//   - no "/": matched against the file's base name (e.g. "*.pem", ".env")
+//   - with "/": matched against the repo-relative path (e.g. "config/secrets.yml")
+//   - trailing "/**": everything under a directory (e.g. "secrets/**")
+//
+// Matching uses path.Match, so "*" does not cross "/".
+type Denylist struct {
+	// Patterns are blocked in addition to DefaultDenylistPatterns.
+	Patterns []string `json:"patterns,omitempty"`
+
+	// Allow are exceptions in addition to DefaultDenylistAllow.
+	Allow []string `json:"allow,omitempty"`
+}
+
+// Match reports whether a repo-relative path (slash-separated) is denylisted:
+// it matches a default or configured pattern and no allow pattern.
+func (d Denylist) Match(p string) bool {
+	if matchAny(DefaultDenylistAllow, p) || matchAny(d.Allow, p) {
+		return false
+	}
+	return matchAny(DefaultDenylistPatterns, p) || matchAny(d.Patterns, p)
+}
+
+// matchAny reports whether p matches any of the patterns.
+func matchAny(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if matchDenylistPattern(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDenylistPattern matches one pattern against a repo-relative path.
+func matchDenylistPattern(pattern, p string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(p))
+		return ok
+	}
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		n := strings.Count(dir, "/") + 1
+		parts := strings.SplitN(p, "/", n+1)
+		if len(parts) <= n {
+			return false
+		}
+		ok, _ := path.Match(dir, strings.Join(parts[:n], "/"))
+		return ok
+	}
+	ok, _ := path.Match(pattern, p)
+	return ok
+}
+
+// parseDenylist parses the agency.json "denylist" object.
+func parseDenylist(raw json.RawMessage) (Denylist, error) {
+	var d Denylist
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return d, errors.New(errors.EInvalidAgencyJSON, "denylist must be an object")
+	}
+	for key := range m {
+		if key != "patterns" && key != "allow" {
+			return d, errors.New(errors.EInvalidAgencyJSON, "denylist contains unknown field: "+key)
+		}
+	}
+
+	var err error
+	if d.Patterns, err = parsePatternList(m["patterns"], "denylist.patterns"); err != nil {
+		return d, err
+	}
+	if d.Allow, err = parsePatternList(m["allow"], "denylist.allow"); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+// parsePatternList parses an optional array of non-empty, valid glob patterns.
+func parsePatternList(raw json.RawMessage, fieldName string) ([]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var patterns []string
+	if err := json.Unmarshal(raw, &patterns); err != nil {
+		return nil, errors.New(errors.EInvalidAgencyJSON, fieldName+" must be an array of strings")
+	}
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return nil, errors.New(errors.EInvalidAgencyJSON, fieldName+" must not contain empty patterns")
+		}
+		if _, err := path.Match(strings.TrimSuffix(p, "/**"), ""); err != nil {
+			return nil, errors.New(errors.EInvalidAgencyJSON, fieldName+" contains invalid pattern: "+p)
+		}
+	}
+	return patterns, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+)
+
+func TestDenylistMatch(t *testing.T) {
+	d := Denylist{
+		Patterns: []string{"*.sqlite", "secrets/**", "config/prod.yml"},
+		Allow:    []string{"testdata/fixture.pem"},
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{".env", true},
+		{"services/api/.env", true},
+		{".env.local", true},
+		{".env.example", false},
+		{"certs/server.pem", true},
+		{"credentials.json", true},
+		{"home/.ssh/id_ed25519", true},
+		{"id_ed25519.pub", false},
+		{"main.go", false},
+		{"data/app.sqlite", true},
+		{"secrets/token.txt", true},
+		{"secrets/nested/token.txt", true},
+		{"secrets", false},
+		{"other/secrets/token.txt", false},
+		{"config/prod.yml", true},
+		{"deploy/config/prod.yml", false},
+		{"testdata/fixture.pem", false},
+	}
+	for _, tt := range tests {
+		if got := d.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadAgencyConfig_Denylist(t *testing.T) {
+	stub := newStubFS()
+	stub.files["/repo/agency.json"] = []byte(`{
+		"version": 1,
+		"scripts": {"setup": {"path": "s"}, "verify": {"path": "v"}, "archive": {"path": "a"}},
+		"denylist": {"patterns": ["*.sqlite"], "allow": ["fixtures/*.pem"]}
+	}`)
+
+	cfg, err := LoadAgencyConfig(stub, "/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Denylist.Patterns) != 1 || cfg.Denylist.Patterns[0] != "*.sqlite" {
+		t.Errorf("Patterns = %v", cfg.Denylist.Patterns)
+	}
+	if len(cfg.Denylist.Allow) != 1 || cfg.Denylist.Allow[0] != "fixtures/*.pem" {
+		t.Errorf("Allow = %v", cfg.Denylist.Allow)
+	}
+}
+
+func TestLoadAgencyConfig_InvalidDenylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		denylist string
+	}{
+		{"not object", `["*.pem"]`},
+		{"unknown field", `{"paths": ["*.pem"]}`},
+		{"patterns not array", `{"patterns": "*.pem"}`},
+		{"empty pattern", `{"patterns": [" "]}`},
+		{"bad glob", `{"allow": ["[abc"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubFS()
+			stub.files["/repo/agency.json"] = []byte(`{"version": 1, "denylist": ` + tt.denylist + `}`)
+			_, err := LoadAgencyConfig(stub, "/repo")
+			if errors.GetCode(err) != errors.EInvalidAgencyJSON {
+				t.Errorf("expected E_INVALID_AGENCY_JSON, got %v", err)
+			}
+		})
+	}
+}
@@ -3,8 +3,11 @@ package config
 
 import (
 	"encoding/json"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	"github.com/NielsdaWheelz/agency/internal/fs"
@@ -16,6 +19,8 @@ type UserConfig struct {
 	Defaults UserDefaults      `json:"defaults"`
 	Runners  map[string]string `json:"runners,omitempty"`
 	Editors  map[string]string `json:"editors,omitempty"`
+	Limits   UserLimits        `json:"limits,omitempty"`
+	Notify   UserNotify        `json:"notify,omitempty"`
 }
 
 // UserDefaults contains default values for user-scoped operations.
@@ -24,6 +29,53 @@ type UserDefaults struct {
 	Editor string `json:"editor"`
 }
 
+// UserLimits caps how many runs may be active at once (0 = unlimited).
+type UserLimits struct {
+	// MaxActiveRuns caps active runs across all repos.
+	MaxActiveRuns int `json:"max_active_runs,omitempty"`
+
+	// MaxActiveRunsPerRepo caps active runs within a single repo.
+	MaxActiveRunsPerRepo int `json:"max_active_runs_per_repo,omitempty"`
+}
+
+// Notification events, fired once per run state transition.
+const (
+	NotifyNeedsInput     = "needs_input"
+	NotifyBlocked        = "blocked"
+	NotifyReadyForReview = "ready_for_review"
+	NotifyStalled        = "stalled"
+	NotifyVerifyFailed   = "verify_failed"
+	NotifyPRMerged       = "pr_merged"
+)
+
+// NotifyEvents lists every notification event.
+var NotifyEvents = []string{NotifyNeedsInput, NotifyBlocked, NotifyReadyForReview, NotifyStalled, NotifyVerifyFailed, NotifyPRMerged}
+
+// UserNotify configures notification sinks (empty = notifications off).
+type UserNotify struct {
+	// Command is a shell command run on each notification (e.g. notify-send).
+	Command string `json:"command,omitempty"`
+
+	// Webhook is an http(s) URL that receives each notification as a JSON POST.
+	Webhook string `json:"webhook,omitempty"`
+
+	// Events limits notifications to these events (empty = all NotifyEvents).
+	Events []string `json:"events,omitempty"`
+}
+
+// Enabled reports whether any notification sink is configured.
+func (n UserNotify) Enabled() bool {
+	return n.Command != "" || n.Webhook != ""
+}
+
+// Wants reports whether notifications for event are enabled.
+func (n UserNotify) Wants(event string) bool {
+	if !n.Enabled() {
+		return false
+	}
+	return len(n.Events) == 0 || slices.Contains(n.Events, event)
+}
+
 // DefaultUserConfig returns built-in defaults used when config.json is missing.
 func DefaultUserConfig() UserConfig {
 	return UserConfig{
@@ -81,6 +133,8 @@ func parseUserConfigStrict(raw map[string]json.RawMessage) (UserConfig, error) {
 		"defaults": true,
 		"runners":  true,
 		"editors":  true,
+		"limits":   true,
+		"notify":   true,
 	}
 	for key := range raw {
 		if !allowedKeys[key] {
@@ -159,6 +213,54 @@ func parseUserConfigStrict(raw map[string]json.RawMessage) (UserConfig, error) {
 		}
 	}
 
+	// Parse limits
+	if rawLimits, ok := raw["limits"]; ok {
+		var limitsMap map[string]json.RawMessage
+		if err := json.Unmarshal(rawLimits, &limitsMap); err != nil {
+			return UserConfig{}, errors.New(errors.EInvalidUserConfig, "limits must be an object")
+		}
+		fields := map[string]*int{
+			"max_active_runs":          &cfg.Limits.MaxActiveRuns,
+			"max_active_runs_per_repo": &cfg.Limits.MaxActiveRunsPerRepo,
+		}
+		for key, rawVal := range limitsMap {
+			field, ok := fields[key]
+			if !ok {
+				return UserConfig{}, errors.New(errors.EInvalidUserConfig, "unknown field: limits."+key)
+			}
+			if err := json.Unmarshal(rawVal, field); err != nil {
+				return UserConfig{}, errors.New(errors.EInvalidUserConfig, "limits."+key+" must be an integer")
+			}
+		}
+	}
+
+	// Parse notify
+	if rawNotify, ok := raw["notify"]; ok {
+		var notifyMap map[string]json.RawMessage
+		if err := json.Unmarshal(rawNotify, &notifyMap); err != nil {
+			return UserConfig{}, errors.New(errors.EInvalidUserConfig, "notify must be an object")
+		}
+		for key, rawVal := range notifyMap {
+			var err error
+			switch key {
+			case "command":
+				err = json.Unmarshal(rawVal, &cfg.Notify.Command)
+			case "webhook":
+				err = json.Unmarshal(rawVal, &cfg.Notify.Webhook)
+			case "events":
+				err = json.Unmarshal(rawVal, &cfg.Notify.Events)
+				if err != nil {
+					return UserConfig{}, errors.New(errors.EInvalidUserConfig, "notify.events must be an array of strings")
+				}
+			default:
+				return UserConfig{}, errors.New(errors.EInvalidUserConfig, "unknown field: notify."+key)
+			}
+			if err != nil {
+				return UserConfig{}, errors.New(errors.EInvalidUserConfig, "notify."+key+" must be a string")
+			}
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -181,6 +283,23 @@ func ValidateUserConfig(cfg UserConfig) (UserConfig, error) {
 			return cfg, errors.New(errors.EInvalidUserConfig, "runners."+name+" must be a single executable (no args); use a wrapper script")
 		}
 	}
+	if cfg.Limits.MaxActiveRuns < 0 {
+		return cfg, errors.New(errors.EInvalidUserConfig, "limits.max_active_runs must be >= 0")
+	}
+	if cfg.Limits.MaxActiveRunsPerRepo < 0 {
+		return cfg, errors.New(errors.EInvalidUserConfig, "limits.max_active_runs_per_repo must be >= 0")
+	}
+	if cfg.Notify.Webhook != "" {
+		u, err := url.Parse(cfg.Notify.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return cfg, errors.New(errors.EInvalidUserConfig, "notify.webhook must be an http(s) URL")
+		}
+	}
+	for _, event := range cfg.Notify.Events {
+		if !slices.Contains(NotifyEvents, event) {
+			return cfg, errors.New(errors.EInvalidUserConfig, "notify.events: unknown event "+event+" (want one of "+strings.Join(NotifyEvents, ", ")+")")
+		}
+	}
 	for name, cmd := range cfg.Editors {
 		if cmd == "" {
 			return cfg, errors.New(errors.EInvalidUserConfig, "editors."+name+" must be a non-empty string")
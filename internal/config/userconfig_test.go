@@ -71,6 +71,34 @@ func TestLoadUserConfig_UnknownKeys(t *testing.T) {
 	}
 }
 
+func TestLoadUserConfig_Limits(t *testing.T) {
+	stub := newStubFS()
+	stub.files["/cfg/config.json"] = []byte(`{
+  "version": 1,
+  "defaults": { "runner": "claude", "editor": "code" },
+  "limits": { "max_active_runs": 4, "max_active_runs_per_repo": 2 }
+}`)
+	cfg, _, err := LoadUserConfig(stub, "/cfg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Limits.MaxActiveRuns != 4 || cfg.Limits.MaxActiveRunsPerRepo != 2 {
+		t.Errorf("Limits = %+v, want {4 2}", cfg.Limits)
+	}
+
+	for _, limits := range []string{
+		`{ "max_active_runs": -1 }`,
+		`{ "max_active_runs": 1.5 }`,
+		`{ "max_runs": 2 }`,
+		`3`,
+	} {
+		stub.files["/cfg/config.json"] = []byte(`{"version": 1, "defaults": {"runner": "claude", "editor": "code"}, "limits": ` + limits + `}`)
+		if _, _, err := LoadUserConfig(stub, "/cfg"); errors.GetCode(err) != errors.EInvalidUserConfig {
+			t.Errorf("limits %s: error = %v, want E_INVALID_USER_CONFIG", limits, err)
+		}
+	}
+}
+
 func TestValidateUserConfig_RequiredFields(t *testing.T) {
 	cfg := UserConfig{Version: 1}
 	_, err := ValidateUserConfig(cfg)
@@ -141,3 +169,43 @@ func TestResolveEditorCmd_Path(t *testing.T) {
 		t.Errorf("cmd = %q, want %q", cmd, binPath)
 	}
 }
+
+func TestLoadUserConfig_Notify(t *testing.T) {
+	stub := newStubFS()
+	stub.files["/cfg/config.json"] = []byte(`{
+  "version": 1,
+  "defaults": { "runner": "claude", "editor": "code" },
+  "notify": {
+    "command": "notify-send agency \"$AGENCY_MESSAGE\"",
+    "webhook": "http://127.0.0.1:8787/agency",
+    "events": ["needs_input", "verify_failed"]
+  }
+}`)
+	cfg, _, err := LoadUserConfig(stub, "/cfg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Notify.Command == "" || cfg.Notify.Webhook != "http://127.0.0.1:8787/agency" {
+		t.Errorf("Notify = %+v", cfg.Notify)
+	}
+	if !cfg.Notify.Wants(NotifyNeedsInput) || cfg.Notify.Wants(NotifyStalled) {
+		t.Errorf("Wants() does not follow notify.events %v", cfg.Notify.Events)
+	}
+	if DefaultUserConfig().Notify.Wants(NotifyNeedsInput) {
+		t.Error("notifications must be off without sinks")
+	}
+
+	for _, notify := range []string{
+		`{ "command": ["notify-send"] }`,
+		`{ "webhook": "ftp://example.com" }`,
+		`{ "webhook": "127.0.0.1:8787" }`,
+		`{ "events": ["merged"] }`,
+		`{ "events": "needs_input" }`,
+		`{ "email": "me@example.com" }`,
+	} {
+		stub.files["/cfg/config.json"] = []byte(`{"version": 1, "defaults": {"runner": "claude", "editor": "code"}, "notify": ` + notify + `}`)
+		if _, _, err := LoadUserConfig(stub, "/cfg"); errors.GetCode(err) != errors.EInvalidUserConfig {
+			t.Errorf("notify %s: error = %v, want E_INVALID_USER_CONFIG", notify, err)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Package daemon provides the process plumbing for the optional agency daemon:
+// its pidfile, health socket and detached spawn. The polling work itself lives
+// in the commands package; nothing in agency requires the daemon to run.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+)
+
+// DefaultInterval is the default poll interval.
+const DefaultInterval = 15 * time.Second
+
+// HealthPath is the HTTP path served on the daemon socket.
+const HealthPath = "/v1/health"
+
+// PIDPath returns the path to the daemon pidfile.
+// Format: ${AGENCY_DATA_DIR}/daemon.pid
+func PIDPath(dataDir string) string {
+	return filepath.Join(dataDir, "daemon.pid")
+}
+
+// SocketPath returns the path to the daemon health socket.
+// Format: ${AGENCY_DATA_DIR}/daemon.sock
+func SocketPath(dataDir string) string {
+	return filepath.Join(dataDir, "daemon.sock")
+}
+
+// LogPath returns the path to the daemon log.
+// Format: ${AGENCY_DATA_DIR}/daemon.log
+func LogPath(dataDir string) string {
+	return filepath.Join(dataDir, "daemon.log")
+}
+
+// Health is the daemon's self-reported state, served on the socket.
+type Health struct {
+	SchemaVersion string `json:"schema_version"`
+	PID           int    `json:"pid"`
+	StartedAt     string `json:"started_at"`
+	Interval      string `json:"interval"`
+	LastPollAt    string `json:"last_poll_at,omitempty"`
+	LastPollError string `json:"last_poll_error,omitempty"`
+	Polls         int    `json:"polls"`
+	Repos         int    `json:"repos"`
+	Runs          int    `json:"runs"`
+	Active        int    `json:"active"`
+	Stalled       int    `json:"stalled"`
+}
+
+// ReadPID returns the pid recorded in the pidfile (0 if missing or invalid).
+func ReadPID(dataDir string) int {
+	data, err := os.ReadFile(PIDPath(dataDir))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// Running returns the pid of the live daemon, or 0 if none is running. A
+// daemon is live while it holds the pidfile lock (see Acquire); the recorded
+// pid alone is not trusted, since a crashed daemon's pid may have been reused
+// by an unrelated process.
+func Running(dataDir string) int {
+	f, err := os.Open(PIDPath(dataDir))
+	if err != nil {
+		return 0
+	}
+	defer func() { _ = f.Close() }()
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == nil {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		return 0
+	}
+	if err != syscall.EWOULDBLOCK {
+		return 0
+	}
+	return ReadPID(dataDir)
+}
+
+// RemoveStale removes the pidfile and socket left by a daemon that died
+// without cleaning up. The files of a live (or starting) daemon are kept.
+func RemoveStale(dataDir string) {
+	f, err := lockPIDFile(PIDPath(dataDir))
+	if err != nil {
+		return
+	}
+	_ = os.Remove(PIDPath(dataDir))
+	_ = os.Remove(SocketPath(dataDir))
+	_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	_ = f.Close()
+}
+
+// Acquire writes the pidfile for the current process and holds an exclusive
+// flock on it until release, so two daemons can never both acquire it. Fails
+// with E_DAEMON_RUNNING if another daemon holds the lock; an unlocked pidfile
+// is stale, whatever pid it names, and is replaced. The returned release
+// removes the pidfile and drops the lock.
+func Acquire(dataDir string) (release func(), err error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, errors.Wrap(errors.EDaemonFailed, "failed to create data dir", err)
+	}
+	f, err := lockPIDFile(PIDPath(dataDir))
+	if err == errPIDFileLocked {
+		return nil, runningError(dataDir, ReadPID(dataDir))
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.EDaemonFailed, "failed to lock daemon pidfile", err)
+	}
+	unlock := func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}
+
+	self := os.Getpid()
+	if err := f.Truncate(0); err != nil {
+		unlock()
+		return nil, errors.Wrap(errors.EDaemonFailed, "failed to write daemon pidfile", err)
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(self)+"\n"), 0); err != nil {
+		unlock()
+		return nil, errors.Wrap(errors.EDaemonFailed, "failed to write daemon pidfile", err)
+	}
+	return func() {
+		// Remove before unlocking: a daemon starting now locks a new file
+		_ = os.Remove(PIDPath(dataDir))
+		unlock()
+	}, nil
+}
+
+// errPIDFileLocked reports that another process holds the pidfile lock.
+var errPIDFileLocked = stderrors.New("daemon pidfile is locked")
+
+// lockPIDFile opens path and takes an exclusive flock on it without blocking.
+// If the holder removed the file between our open and lock, the lock is on an
+// unlinked file and the open is retried.
+func lockPIDFile(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			_ = f.Close()
+			if err == syscall.EWOULDBLOCK {
+				return nil, errPIDFileLocked
+			}
+			return nil, err
+		}
+		held, herr := f.Stat()
+		onDisk, derr := os.Stat(path)
+		if herr == nil && derr == nil && os.SameFile(held, onDisk) {
+			return f, nil
+		}
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}
+}
+
+// runningError is the E_DAEMON_RUNNING error for a daemon with pid (0 if unknown).
+func runningError(dataDir string, pid int) error {
+	msg := "daemon already running"
+	if pid != 0 {
+		msg = fmt.Sprintf("daemon already running (pid %d)", pid)
+	}
+	return errors.NewWithDetails(errors.EDaemonRunning, msg,
+		map[string]string{"pid": strconv.Itoa(pid), "pidfile": PIDPath(dataDir)})
+}
+
+// Listen opens the daemon socket. A socket file nothing answers on is stale
+// and replaced; one a process still answers on fails with E_DAEMON_RUNNING.
+func Listen(dataDir string) (net.Listener, error) {
+	path := SocketPath(dataDir)
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return nil, errors.NewWithDetails(errors.EDaemonRunning, "another process is listening on the daemon socket",
+			map[string]string{"socket": path})
+	}
+	_ = os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.WrapWithDetails(errors.EDaemonFailed, "failed to listen on daemon socket", err,
+			map[string]string{"socket": path})
+	}
+	return ln, nil
+}
+
+// Serve answers health requests on ln until ctx is done.
+func Serve(ctx context.Context, ln net.Listener, health func() Health) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(health())
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// QueryHealth asks the daemon listening on socketPath for its health.
+func QueryHealth(ctx context.Context, socketPath string) (*Health, error) {
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://agency"+HealthPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon health: %s", resp.Status)
+	}
+	var h Health
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Spawn starts `agency daemon run` detached in its own session, appending its
+// output to daemon.log, and returns its pid. interval 0 uses the default.
+func Spawn(dataDir string, interval time.Duration) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, errors.Wrap(errors.EDaemonFailed, "failed to locate agency executable", err)
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return 0, errors.Wrap(errors.EDaemonFailed, "failed to create data dir", err)
+	}
+	logFile, err := os.OpenFile(LogPath(dataDir), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, errors.WrapWithDetails(errors.EDaemonFailed, "failed to open daemon log", err,
+			map[string]string{"log_path": LogPath(dataDir)})
+	}
+	defer func() { _ = logFile.Close() }()
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		return 0, errors.Wrap(errors.EDaemonFailed, "failed to open /dev/null", err)
+	}
+	defer func() { _ = devnull.Close() }()
+
+	args := []string{"daemon", "run"}
+	if interval != 0 {
+		args = append(args, "--interval", interval.String())
+	}
+	cmd := osexec.Command(exe, args...)
+	cmd.Dir = dataDir
+	cmd.Env = append(os.Environ(), "AGENCY_DATA_DIR="+dataDir)
+	cmd.Stdin = devnull
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, errors.WrapWithDetails(errors.EDaemonFailed, "failed to start daemon", err,
+			map[string]string{"log_path": LogPath(dataDir)})
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
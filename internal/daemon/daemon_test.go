@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+)
+
+// shortTempDir returns a temp dir short enough for a unix socket path.
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "agd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func TestAcquire(t *testing.T) {
+	dataDir := shortTempDir(t)
+
+	// A stale pidfile is replaced
+	if err := os.WriteFile(PIDPath(dataDir), []byte("999999999\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	release, err := Acquire(dataDir)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if got := Running(dataDir); got != os.Getpid() {
+		t.Errorf("Running() = %d, want %d", got, os.Getpid())
+	}
+
+	// A second daemon is refused while the first holds the lock
+	if _, err := Acquire(dataDir); errors.GetCode(err) != errors.EDaemonRunning {
+		t.Errorf("second Acquire() error = %v, want E_DAEMON_RUNNING", err)
+	}
+
+	release()
+	if _, err := os.Stat(PIDPath(dataDir)); !os.IsNotExist(err) {
+		t.Errorf("pidfile not removed on release: %v", err)
+	}
+
+	// An unlocked pidfile is stale even if its pid is alive (e.g. reused)
+	if err := os.WriteFile(PIDPath(dataDir), []byte(strconv.Itoa(os.Getppid())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Running(dataDir); got != 0 {
+		t.Errorf("Running() with an unlocked pidfile = %d, want 0", got)
+	}
+	release, err = Acquire(dataDir)
+	if err != nil {
+		t.Fatalf("Acquire() over a stale pidfile of a live pid error = %v", err)
+	}
+	release()
+}
+
+func TestListen_ReplacesOnlyStaleSocket(t *testing.T) {
+	dataDir := shortTempDir(t)
+
+	// A socket file nobody listens on is replaced
+	stale, err := net.Listen("unix", SocketPath(dataDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+	ln, err := Listen(dataDir)
+	if err != nil {
+		t.Fatalf("Listen() over stale socket error = %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	// A socket that answers is left alone
+	if _, err := Listen(dataDir); errors.GetCode(err) != errors.EDaemonRunning {
+		t.Errorf("Listen() over live socket error = %v, want E_DAEMON_RUNNING", err)
+	}
+	if _, err := os.Stat(SocketPath(dataDir)); err != nil {
+		t.Errorf("live socket removed: %v", err)
+	}
+}
+
+func TestServeAndQueryHealth(t *testing.T) {
+	dataDir := shortTempDir(t)
+	ln, err := Listen(dataDir)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, ln, func() Health { return Health{SchemaVersion: "1.0", PID: 42, Polls: 3, Runs: 5} })
+	}()
+
+	h, err := QueryHealth(context.Background(), SocketPath(dataDir))
+	if err != nil {
+		t.Fatalf("QueryHealth() error = %v", err)
+	}
+	if h.PID != 42 || h.Polls != 3 || h.Runs != 5 {
+		t.Errorf("health = %+v", h)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+	if _, err := QueryHealth(context.Background(), SocketPath(dataDir)); err == nil {
+		t.Error("QueryHealth() after shutdown: error = nil")
+	}
+}
@@ -74,6 +74,7 @@ const (
 	EEmptyDiff             Code = "E_EMPTY_DIFF"              // no commits ahead of parent branch
 	EWorktreeMissing       Code = "E_WORKTREE_MISSING"        // run worktree path is missing on disk
 	EDirtyWorktree         Code = "E_DIRTY_WORKTREE"          // run worktree has uncommitted changes
+	EDenylistedFile        Code = "E_DENYLISTED_FILE"         // push would ship a file matching the secret denylist
 
 	// Slice 4 lifecycle control error codes
 	ESessionNotFound      Code = "E_SESSION_NOT_FOUND"     // attach when tmux session is missing; suggests resume
@@ -113,6 +114,58 @@ const (
 	EWorktreeBroken       Code = "E_WORKTREE_BROKEN"        // worktree exists but meta.json is unreadable
 	EWorktreeDirExists    Code = "E_WORKTREE_DIR_EXISTS"    // worktree directory already exists
 	EWorktreeRemoveFailed Code = "E_WORKTREE_REMOVE_FAILED" // git worktree remove failed
+
+	// Integration worktree land error codes
+	ELandConflict     Code = "E_LAND_CONFLICT"      // cherry-pick or apply conflicted; integration tree left unchanged
+	ELandBaseMismatch Code = "E_LAND_BASE_MISMATCH" // --require-base: run base is not contained in the integration HEAD
+
+	// Slice 8 invocation + sandbox error codes
+	EInvocationNotFound    Code = "E_INVOCATION_NOT_FOUND"    // invocation does not exist
+	EInvocationIDAmbiguous Code = "E_INVOCATION_ID_AMBIGUOUS" // invocation id prefix matches multiple
+	EInvocationBroken      Code = "E_INVOCATION_BROKEN"       // invocation meta.json unreadable or sandbox tree missing
+	EInvocationDirExists   Code = "E_INVOCATION_DIR_EXISTS"   // invocation directory already exists
+	ENotIntegrationTree    Code = "E_NOT_INTEGRATION_TREE"    // target tree lacks .agency/INTEGRATION_MARKER
+	ESandboxPathUnsafe     Code = "E_SANDBOX_PATH_UNSAFE"     // sandbox path overlaps an integration tree
+	ESandboxCreateFailed   Code = "E_SANDBOX_CREATE_FAILED"   // sandbox branch/worktree/marker creation failed
+
+	// Checkpoint error codes
+	ECheckpointNotFound Code = "E_CHECKPOINT_NOT_FOUND" // no checkpoint with that number (or its ref is gone)
+	ECheckpointFailed   Code = "E_CHECKPOINT_FAILED"    // snapshot or restore git plumbing failed
+
+	// Rebase error codes
+	ERebaseConflict Code = "E_REBASE_CONFLICT" // rebase onto the parent stopped on conflicts
+	ERebaseFailed   Code = "E_REBASE_FAILED"   // git rebase failed without conflicts
+
+	// Runner messaging error codes
+	ENoQuestions  Code = "E_NO_QUESTIONS"  // answer: runner_status.json lists no questions
+	ERunnerExited Code = "E_RUNNER_EXITED" // the runner exited; its tmux session is a shell in the worktree
+
+	// Batch run error codes
+	EInvalidTaskFile Code = "E_INVALID_TASK_FILE" // run --from: task file unreadable as YAML/JSON or a task is invalid
+	EBatchFailed     Code = "E_BATCH_FAILED"      // run --from / --attempts: one or more runs failed to start
+
+	// Best-of-N group error codes
+	EGroupNotFound  Code = "E_GROUP_NOT_FOUND" // no run belongs to the given group id
+	EGroupAmbiguous Code = "E_GROUP_AMBIGUOUS" // group id prefix matches more than one group
+
+	// Run queue error codes
+	ERunLimit  Code = "E_RUN_LIMIT"  // max_active_runs limit reached and --queue not given
+	ENotQueued Code = "E_NOT_QUEUED" // queue rm: run is not waiting in the queue
+
+	// Headless runner error codes
+	ERunnerStartFailed Code = "E_RUNNER_START_FAILED" // headless runner supervisor failed to start the runner
+
+	// Daemon error codes
+	EDaemonRunning Code = "E_DAEMON_RUNNING" // daemon run: another daemon holds the pidfile
+	EDaemonFailed  Code = "E_DAEMON_FAILED"  // daemon failed to start, listen or stop
+
+	// Local API (agency serve) error codes
+	EAPINotFound         Code = "E_API_NOT_FOUND"          // no such API endpoint
+	EAPIMethodNotAllowed Code = "E_API_METHOD_NOT_ALLOWED" // endpoint exists but not for this HTTP method
+
+	// Runner-side error codes (commands run by the runner inside its worktree)
+	ENotInRunWorktree    Code = "E_NOT_IN_RUN_WORKTREE"   // cwd is not inside an active run's worktree
+	EInvalidRunnerStatus Code = "E_INVALID_RUNNER_STATUS" // runner status report fails validation
 )
 
 // AgencyError is the standard error type for agency errors.
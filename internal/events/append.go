@@ -107,6 +107,15 @@ func KillSessionData(sessionName string) map[string]any {
 	}
 }
 
+// SignalRunnerData returns the data map for a stop or kill_runner event of
+// a headless run.
+func SignalRunnerData(runnerPID int, signal string) map[string]any {
+	return map[string]any{
+		"runner_pid": runnerPID,
+		"signal":     signal,
+	}
+}
+
 // ResumeData returns the data map for a resume_* event (resume_attach, resume_create, resume_restart).
 func ResumeData(sessionName, runner string, detached, restart bool) map[string]any {
 	return map[string]any{
@@ -125,6 +134,42 @@ func ResumeFailedData(sessionName, reason string) map[string]any {
 	}
 }
 
+// RunnerExitedData returns the data map for a runner_exited event.
+func RunnerExitedData(sessionName, reason, exitedAt string) map[string]any {
+	return map[string]any{
+		"session_name": sessionName,
+		"exit_reason":  reason,
+		"exited_at":    exitedAt,
+	}
+}
+
+// RunnerStatusChangedData returns the data map for a runner_status_changed
+// event. previousStatus is "" for the run's first recorded report; source
+// names how the report arrived ("status_set", "mcp", "observed").
+func RunnerStatusChangedData(previousStatus, status, summary string, questions []string, updatedAt, source string) map[string]any {
+	if questions == nil {
+		questions = []string{}
+	}
+	return map[string]any{
+		"previous_status": previousStatus,
+		"status":          status,
+		"summary":         summary,
+		"questions":       questions,
+		"updated_at":      updatedAt,
+		"source":          source,
+	}
+}
+
+// RunLandedData returns the data map for a run_landed event.
+func RunLandedData(worktreeID, worktreeName, mode string, commits []string) map[string]any {
+	return map[string]any{
+		"worktree_id":   worktreeID,
+		"worktree_name": worktreeName,
+		"mode":          mode,
+		"commits":       commits,
+	}
+}
+
 // MergeConfirmPromptedData returns the data map for a merge_confirm_prompted event.
 func MergeConfirmPromptedData() map[string]any {
 	return map[string]any{}
@@ -250,3 +250,23 @@ func GetOriginURL(ctx context.Context, cr exec.CommandRunner, repoRoot string) s
 	}
 	return strings.TrimSpace(result.Stdout)
 }
+
+// GitPath resolves a path inside the git dir of the worktree at workDir
+// (e.g. "index", "rebase-merge") using `git rev-parse --git-path`, and makes
+// it absolute: git prints it relative to workDir in a main worktree. This
+// avoids --path-format=absolute, which needs git 2.31.
+// Never returns an error; failures result in empty string.
+func GitPath(ctx context.Context, cr exec.CommandRunner, workDir, name string) string {
+	result, err := cr.Run(ctx, "git", []string{"rev-parse", "--git-path", name}, exec.RunOpts{Dir: workDir})
+	if err != nil || result.ExitCode != 0 {
+		return ""
+	}
+	path := strings.TrimSpace(result.Stdout)
+	if path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	return filepath.Clean(path)
+}
@@ -548,3 +548,33 @@ func TestGetOriginURL_Missing(t *testing.T) {
 		t.Errorf("GetOriginURL = %q, want empty for missing origin", url)
 	}
 }
+
+func TestGitPath(t *testing.T) {
+	ctx := context.Background()
+	cr := newStubRunner()
+
+	// Main worktree: git prints the path relative to the working directory
+	cr.On("git", []string{"rev-parse", "--git-path", "index"}, "/some/project", exec.CmdResult{
+		Stdout:   ".git/index\n",
+		ExitCode: 0,
+	})
+	// Linked worktree: git prints an absolute path
+	cr.On("git", []string{"rev-parse", "--git-path", "index"}, "/some/wt", exec.CmdResult{
+		Stdout:   "/some/project/.git/worktrees/wt/index\n",
+		ExitCode: 0,
+	})
+
+	tests := []struct {
+		workDir string
+		want    string
+	}{
+		{"/some/project", "/some/project/.git/index"},
+		{"/some/wt", "/some/project/.git/worktrees/wt/index"},
+		{"/not/a/repo", ""},
+	}
+	for _, tt := range tests {
+		if got := GitPath(ctx, cr, tt.workDir, "index"); got != tt.want {
+			t.Errorf("GitPath(%q) = %q, want %q", tt.workDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package headless runs non-interactive runners as supervised, detached
+// subprocesses. Runner stdout is streamed verbatim to logs/raw.jsonl, stderr
+// to logs/stderr.log, and process state (pid, exit code, exit reason,
+// last_output_at) is recorded in the run's meta.json.
+package headless
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/core"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// SupervisorCommand is the hidden agency subcommand that runs the supervisor.
+const SupervisorCommand = "headless-supervise"
+
+// readyLine is written by the supervisor to stdout once the runner has started
+// and meta.json records its pid.
+const readyLine = "ready"
+
+// DefaultReadyTimeout bounds how long Spawn waits for the supervisor to report ready.
+const DefaultReadyTimeout = 30 * time.Second
+
+// RunnerArgs returns the extra arguments that put a known runner into
+// non-interactive streaming mode. The prompt is always supplied on stdin.
+// Unknown runners get no extra arguments.
+func RunnerArgs(runner string) []string {
+	switch runner {
+	case "claude":
+		return []string{"-p", "--output-format", "stream-json", "--verbose"}
+	case "codex":
+		return []string{"exec", "--json", "-"}
+	default:
+		return nil
+	}
+}
+
+// BuildRunnerScript returns the `sh -lc` script for a headless runner:
+// cd into the worktree, then exec the runner command with headless args.
+func BuildRunnerScript(worktreePath, runner, runnerCmd string) string {
+	cmd := runnerCmd
+	for _, arg := range RunnerArgs(runner) {
+		cmd += " " + core.ShellEscapePosix(arg)
+	}
+	return core.BuildRunnerShellScript(worktreePath, cmd)
+}
+
+// ProcessAlive reports whether a process with the given pid exists.
+// Returns false for pid <= 0.
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// IsActive reports whether a headless run's runner is still in flight.
+// A run is active when no exit has been recorded and either the runner
+// process or (before the runner pid is recorded) its supervisor is alive.
+func IsActive(meta *store.RunMeta) bool {
+	if meta == nil || meta.Headless == nil || meta.RunnerExitedAt != "" {
+		return false
+	}
+	if meta.RunnerPID > 0 {
+		return ProcessAlive(meta.RunnerPID)
+	}
+	return ProcessAlive(meta.Headless.SupervisorPID)
+}
+
+// SignalRunner sends sig to a headless run's runner and its children (the
+// supervisor starts the runner in its own process group). Returns false
+// without error if the runner is not running.
+func SignalRunner(meta *store.RunMeta, sig syscall.Signal) (bool, error) {
+	if !IsActive(meta) || meta.RunnerPID <= 0 {
+		return false, nil
+	}
+	if err := syscall.Kill(-meta.RunnerPID, sig); err != nil {
+		if err == syscall.ESRCH {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// SpawnOpts configures a supervisor spawn.
+type SpawnOpts struct {
+	// Executable is the agency binary to re-exec (default: os.Executable()).
+	Executable string
+
+	// DataDir, RepoID and RunID identify the run to supervise.
+	DataDir string
+	RepoID  string
+	RunID   string
+
+	// LogPath receives the supervisor's own stderr (logs/supervisor.log).
+	LogPath string
+
+	// ReadyTimeout bounds the wait for the ready line (default: DefaultReadyTimeout).
+	ReadyTimeout time.Duration
+}
+
+// Spawn starts a detached supervisor process for the run and waits until it
+// reports that the runner has started. The supervisor runs in its own session
+// so it survives the calling agency process and its terminal.
+// Returns the supervisor pid.
+func Spawn(opts SpawnOpts) (int, error) {
+	exe := opts.Executable
+	if exe == "" {
+		var err error
+		exe, err = os.Executable()
+		if err != nil {
+			return 0, errors.Wrap(errors.ERunnerStartFailed, "failed to locate agency executable", err)
+		}
+	}
+	timeout := opts.ReadyTimeout
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+
+	logFile, err := os.OpenFile(opts.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, errors.WrapWithDetails(errors.ERunnerStartFailed, "failed to open supervisor log", err,
+			map[string]string{"log_path": opts.LogPath})
+	}
+	defer func() { _ = logFile.Close() }()
+
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		return 0, errors.Wrap(errors.ERunnerStartFailed, "failed to open /dev/null", err)
+	}
+	defer func() { _ = devnull.Close() }()
+
+	cmd := osexec.Command(exe, SupervisorCommand,
+		"--data-dir", opts.DataDir,
+		"--repo-id", opts.RepoID,
+		"--run-id", opts.RunID,
+	)
+	cmd.Stdin = devnull
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, errors.Wrap(errors.ERunnerStartFailed, "failed to create supervisor pipe", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return 0, errors.WrapWithDetails(errors.ERunnerStartFailed, "failed to start supervisor", err,
+			map[string]string{"log_path": opts.LogPath})
+	}
+	pid := cmd.Process.Pid
+
+	lineCh := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		lineCh <- strings.TrimSpace(line)
+	}()
+
+	select {
+	case line := <-lineCh:
+		if line != readyLine {
+			// Supervisor exited (or wrote garbage) before the runner started; reap it.
+			_ = cmd.Wait()
+			return pid, errors.NewWithDetails(errors.ERunnerStartFailed,
+				"headless runner failed to start; see supervisor log",
+				map[string]string{"log_path": opts.LogPath})
+		}
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return pid, errors.NewWithDetails(errors.ERunnerStartFailed,
+			fmt.Sprintf("headless supervisor did not report ready within %s", timeout),
+			map[string]string{"log_path": opts.LogPath})
+	}
+
+	// The supervisor keeps running on its own; we never wait for it.
+	_ = cmd.Process.Release()
+	return pid, nil
+}
+
+// signalReady writes the ready line to w (the supervisor's stdout pipe).
+func signalReady(w io.Writer) {
+	if w == nil {
+		return
+	}
+	_, _ = fmt.Fprintln(w, readyLine)
+}
@@ -0,0 +1,235 @@
+package headless
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+const (
+	testRepoID = "abcd1234ef567890"
+	testRunID  = "20260110120000-a3f2"
+)
+
+// setupHeadlessRun writes meta.json for a headless run whose runner is runnerCmd.
+func setupHeadlessRun(t *testing.T, runnerCmd, prompt string) *store.Store {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	worktree := t.TempDir()
+	st := store.NewStore(fs.NewRealFS(), dataDir, func() time.Time {
+		return time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	})
+
+	if _, err := st.EnsureRunDir(testRepoID, testRunID); err != nil {
+		t.Fatalf("EnsureRunDir: %v", err)
+	}
+	promptPath := st.RunPromptPath(testRepoID, testRunID)
+	if err := os.WriteFile(promptPath, []byte(prompt), 0o600); err != nil {
+		t.Fatalf("write prompt: %v", err)
+	}
+
+	meta := store.NewRunMeta(testRunID, testRepoID, "headless-test", "custom", runnerCmd,
+		"main", "agency/headless-test-a3f2", worktree, time.Now())
+	meta.Headless = &store.RunMetaHeadless{
+		PromptPath:    promptPath,
+		RawLogPath:    st.RunRawLogPath(testRepoID, testRunID),
+		StderrLogPath: st.RunStderrLogPath(testRepoID, testRunID),
+	}
+	if err := st.WriteInitialMeta(testRepoID, testRunID, meta); err != nil {
+		t.Fatalf("WriteInitialMeta: %v", err)
+	}
+	return st
+}
+
+func TestSupervise_CapturesStdoutAndExit(t *testing.T) {
+	prompt := `{"type":"user","text":"hello"}` + "\n"
+	st := setupHeadlessRun(t, "cat", prompt)
+
+	var ready strings.Builder
+	if err := Supervise(st, testRepoID, testRunID, &ready); err != nil {
+		t.Fatalf("Supervise: %v", err)
+	}
+
+	if ready.String() != "ready\n" {
+		t.Errorf("ready = %q, want %q", ready.String(), "ready\n")
+	}
+
+	raw, err := os.ReadFile(st.RunRawLogPath(testRepoID, testRunID))
+	if err != nil {
+		t.Fatalf("read raw log: %v", err)
+	}
+	if string(raw) != prompt {
+		t.Errorf("raw.jsonl = %q, want %q", raw, prompt)
+	}
+
+	meta, err := st.ReadMeta(testRepoID, testRunID)
+	if err != nil {
+		t.Fatalf("ReadMeta: %v", err)
+	}
+	if meta.RunnerPID == 0 {
+		t.Error("runner_pid not recorded")
+	}
+	if meta.Headless.SupervisorPID != os.Getpid() {
+		t.Errorf("supervisor_pid = %d, want %d", meta.Headless.SupervisorPID, os.Getpid())
+	}
+	if meta.Headless.StartedAt == "" {
+		t.Error("headless.started_at not recorded")
+	}
+	if meta.RunnerExitCode == nil || *meta.RunnerExitCode != 0 {
+		t.Errorf("runner_exit_code = %v, want 0", meta.RunnerExitCode)
+	}
+	if meta.ExitReason != store.ExitReasonExited {
+		t.Errorf("exit_reason = %q, want %q", meta.ExitReason, store.ExitReasonExited)
+	}
+	if meta.RunnerExitedAt == "" {
+		t.Error("runner_exited_at not recorded")
+	}
+	if got := store.ReadLastOutputAt(st.RunLastOutputPath(meta.RepoID, meta.RunID)); got != "2026-01-10T12:00:00Z" {
+		t.Errorf("last_output_at = %q, want %q", got, "2026-01-10T12:00:00Z")
+	}
+}
+
+func TestSupervise_CapturesStderrAndNonZeroExit(t *testing.T) {
+	st := setupHeadlessRun(t, `sh -c 'echo oops >&2; exit 3'`, "prompt")
+
+	if err := Supervise(st, testRepoID, testRunID, nil); err != nil {
+		t.Fatalf("Supervise: %v", err)
+	}
+
+	stderrLog, err := os.ReadFile(st.RunStderrLogPath(testRepoID, testRunID))
+	if err != nil {
+		t.Fatalf("read stderr log: %v", err)
+	}
+	if string(stderrLog) != "oops\n" {
+		t.Errorf("stderr.log = %q, want %q", stderrLog, "oops\n")
+	}
+
+	meta, err := st.ReadMeta(testRepoID, testRunID)
+	if err != nil {
+		t.Fatalf("ReadMeta: %v", err)
+	}
+	if meta.RunnerExitCode == nil || *meta.RunnerExitCode != 3 {
+		t.Errorf("runner_exit_code = %v, want 3", meta.RunnerExitCode)
+	}
+	if meta.ExitReason != store.ExitReasonExited {
+		t.Errorf("exit_reason = %q, want %q", meta.ExitReason, store.ExitReasonExited)
+	}
+}
+
+func TestSupervise_Signaled(t *testing.T) {
+	st := setupHeadlessRun(t, `sh -c 'kill -9 $$'`, "prompt")
+
+	if err := Supervise(st, testRepoID, testRunID, nil); err != nil {
+		t.Fatalf("Supervise: %v", err)
+	}
+
+	meta, err := st.ReadMeta(testRepoID, testRunID)
+	if err != nil {
+		t.Fatalf("ReadMeta: %v", err)
+	}
+	if meta.ExitReason != store.ExitReasonSignaled {
+		t.Errorf("exit_reason = %q, want %q", meta.ExitReason, store.ExitReasonSignaled)
+	}
+	if meta.RunnerExitCode == nil || *meta.RunnerExitCode != -1 {
+		t.Errorf("runner_exit_code = %v, want -1", meta.RunnerExitCode)
+	}
+}
+
+func TestSupervise_MissingPromptRecordsStartFailed(t *testing.T) {
+	st := setupHeadlessRun(t, "cat", "prompt")
+	if err := os.Remove(st.RunPromptPath(testRepoID, testRunID)); err != nil {
+		t.Fatal(err)
+	}
+
+	var ready strings.Builder
+	err := Supervise(st, testRepoID, testRunID, &ready)
+	if errors.GetCode(err) != errors.ERunnerStartFailed {
+		t.Fatalf("error code = %q, want %q", errors.GetCode(err), errors.ERunnerStartFailed)
+	}
+	if ready.Len() != 0 {
+		t.Errorf("ready written on start failure: %q", ready.String())
+	}
+
+	meta, err := st.ReadMeta(testRepoID, testRunID)
+	if err != nil {
+		t.Fatalf("ReadMeta: %v", err)
+	}
+	if meta.ExitReason != store.ExitReasonStartFailed {
+		t.Errorf("exit_reason = %q, want %q", meta.ExitReason, store.ExitReasonStartFailed)
+	}
+}
+
+func TestBuildRunnerScript(t *testing.T) {
+	tests := []struct {
+		runner string
+		want   string
+	}{
+		{"claude", "cd '/wt' && exec claude '-p' '--output-format' 'stream-json' '--verbose'"},
+		{"codex", "cd '/wt' && exec codex 'exec' '--json' '-'"},
+		{"custom", "cd '/wt' && exec custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.runner, func(t *testing.T) {
+			got := BuildRunnerScript("/wt", tt.runner, tt.runner)
+			if got != tt.want {
+				t.Errorf("BuildRunnerScript = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpawn(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "supervisor.log")
+
+	readyExe := filepath.Join(dir, "ready.sh")
+	if err := os.WriteFile(readyExe, []byte("#!/bin/sh\necho ready\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	pid, err := Spawn(SpawnOpts{Executable: readyExe, DataDir: dir, RepoID: testRepoID, RunID: testRunID, LogPath: logPath})
+	if err != nil {
+		t.Fatalf("Spawn: %v", err)
+	}
+	if pid <= 0 {
+		t.Errorf("pid = %d, want > 0", pid)
+	}
+
+	failExe := filepath.Join(dir, "fail.sh")
+	if err := os.WriteFile(failExe, []byte("#!/bin/sh\necho boom >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Spawn(SpawnOpts{Executable: failExe, DataDir: dir, RepoID: testRepoID, RunID: testRunID, LogPath: logPath})
+	if errors.GetCode(err) != errors.ERunnerStartFailed {
+		t.Fatalf("error code = %q, want %q", errors.GetCode(err), errors.ERunnerStartFailed)
+	}
+	logData, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(logData), "boom") {
+		t.Errorf("supervisor log = %q, want it to contain supervisor stderr", logData)
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	if IsActive(&store.RunMeta{}) {
+		t.Error("non-headless run reported active")
+	}
+	self := &store.RunMeta{Headless: &store.RunMetaHeadless{}, RunnerPID: os.Getpid()}
+	if !IsActive(self) {
+		t.Error("live runner pid reported inactive")
+	}
+	self.RunnerExitedAt = "2026-01-10T12:00:00Z"
+	if IsActive(self) {
+		t.Error("exited runner reported active")
+	}
+	starting := &store.RunMeta{Headless: &store.RunMetaHeadless{SupervisorPID: os.Getpid()}}
+	if !IsActive(starting) {
+		t.Error("live supervisor without runner pid reported inactive")
+	}
+}
@@ -0,0 +1,197 @@
+package headless
+
+import (
+	stderrors "errors"
+	"io"
+	"os"
+	osexec "os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// Supervise runs the headless runner for a run in the foreground and blocks
+// until it exits. It is the body of the hidden supervisor subcommand.
+//
+// Behavior:
+//   - feeds the prompt file to the runner on stdin
+//   - streams stdout verbatim to logs/raw.jsonl and stderr to logs/stderr.log
+//   - records runner_pid and headless.supervisor_pid, then writes the ready line to ready
+//   - bumps logs/last_output_at on every output chunk (at most one write per second)
+//   - records runner_exit_code, exit_reason and runner_exited_at on exit
+func Supervise(st *store.Store, repoID, runID string, ready io.Writer) error {
+	meta, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		return err
+	}
+	if meta.Headless == nil {
+		return errors.NewWithDetails(errors.ERunnerStartFailed, "run is not headless",
+			map[string]string{"run_id": runID})
+	}
+	h := meta.Headless
+
+	prompt, err := os.Open(h.PromptPath)
+	if err != nil {
+		return recordStartFailed(st, repoID, runID, errors.WrapWithDetails(errors.ERunnerStartFailed,
+			"failed to open prompt file", err, map[string]string{"prompt_path": h.PromptPath}))
+	}
+	defer func() { _ = prompt.Close() }()
+
+	rawLog, err := os.OpenFile(h.RawLogPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return recordStartFailed(st, repoID, runID, errors.WrapWithDetails(errors.ERunnerStartFailed,
+			"failed to open raw log", err, map[string]string{"raw_log_path": h.RawLogPath}))
+	}
+	defer func() { _ = rawLog.Close() }()
+
+	stderrLog, err := os.OpenFile(h.StderrLogPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return recordStartFailed(st, repoID, runID, errors.WrapWithDetails(errors.ERunnerStartFailed,
+			"failed to open stderr log", err, map[string]string{"stderr_log_path": h.StderrLogPath}))
+	}
+	defer func() { _ = stderrLog.Close() }()
+
+	cmd := osexec.Command("sh", "-lc", BuildRunnerScript(meta.WorktreePath, meta.Runner, meta.RunnerCmd))
+	cmd.Dir = meta.WorktreePath
+	cmd.Stdin = prompt
+	cmd.Env = append(os.Environ(),
+		"AGENCY_RUN_ID="+runID,
+		"AGENCY_NAME="+meta.Name,
+		"AGENCY_WORKSPACE_ROOT="+meta.WorktreePath,
+		"AGENCY_HEADLESS=1",
+	)
+	// Own process group so stop/kill can signal the runner and its children together.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return recordStartFailed(st, repoID, runID, errors.Wrap(errors.ERunnerStartFailed, "failed to create stdout pipe", err))
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return recordStartFailed(st, repoID, runID, errors.Wrap(errors.ERunnerStartFailed, "failed to create stderr pipe", err))
+	}
+
+	if err := cmd.Start(); err != nil {
+		return recordStartFailed(st, repoID, runID, errors.Wrap(errors.ERunnerStartFailed, "failed to start runner", err))
+	}
+
+	startedAt := now(st).UTC().Format(time.RFC3339)
+	err = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.RunnerPID = cmd.Process.Pid
+		if m.Headless != nil {
+			m.Headless.SupervisorPID = os.Getpid()
+			m.Headless.StartedAt = startedAt
+		}
+	})
+	if err != nil {
+		// Without a recorded pid nobody can observe or stop the runner; give up.
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		_ = cmd.Wait()
+		return err
+	}
+	signalReady(ready)
+
+	tracker := &outputTracker{st: st, repoID: repoID, runID: runID}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(&trackedWriter{w: rawLog, t: tracker}, stdoutPipe)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(&trackedWriter{w: stderrLog, t: tracker}, stderrPipe)
+	}()
+	// All reads must complete before Wait closes the pipes.
+	wg.Wait()
+	waitErr := cmd.Wait()
+
+	exitCode, reason := exitStatus(waitErr)
+	exitedAt := now(st).UTC().Format(time.RFC3339)
+	return st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.RunnerExitCode = &exitCode
+		m.ExitReason = reason
+		m.RunnerExitedAt = exitedAt
+	})
+}
+
+// exitStatus maps a Wait error to an exit code and exit reason.
+func exitStatus(waitErr error) (int, string) {
+	if waitErr == nil {
+		return 0, store.ExitReasonExited
+	}
+	var exitErr *osexec.ExitError
+	if stderrors.As(waitErr, &exitErr) {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return -1, store.ExitReasonSignaled
+		}
+		return exitErr.ExitCode(), store.ExitReasonExited
+	}
+	return -1, store.ExitReasonStartFailed
+}
+
+// recordStartFailed marks the run as failed to start (best-effort) and returns cause.
+func recordStartFailed(st *store.Store, repoID, runID string, cause error) error {
+	code := -1
+	exitedAt := now(st).UTC().Format(time.RFC3339)
+	_ = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.RunnerExitCode = &code
+		m.ExitReason = store.ExitReasonStartFailed
+		m.RunnerExitedAt = exitedAt
+		if m.Headless != nil {
+			m.Headless.SupervisorPID = os.Getpid()
+		}
+	})
+	return cause
+}
+
+// now returns the store clock, defaulting to time.Now.
+func now(st *store.Store) time.Time {
+	if st.Now != nil {
+		return st.Now()
+	}
+	return time.Now()
+}
+
+// outputTracker records last_output_at in the run's logs/last_output_at, not
+// meta.json, so output never races meta updates. Timestamps have second
+// resolution, so the file is rewritten at most once per second.
+type outputTracker struct {
+	st     *store.Store
+	repoID string
+	runID  string
+
+	mu   sync.Mutex
+	last string
+}
+
+// touch records an output chunk at the current time.
+func (o *outputTracker) touch() {
+	ts := now(o.st).UTC().Format(time.RFC3339)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if ts == o.last {
+		return
+	}
+	o.last = ts
+	_ = o.st.WriteLastOutputAt(o.repoID, o.runID, ts)
+}
+
+// trackedWriter writes chunks verbatim and notifies the tracker on each one.
+type trackedWriter struct {
+	w io.Writer
+	t *outputTracker
+}
+
+func (tw *trackedWriter) Write(p []byte) (int, error) {
+	n, err := tw.w.Write(p)
+	if n > 0 {
+		tw.t.touch()
+	}
+	return n, err
+}
@@ -0,0 +1,102 @@
+// Package ids provides identifier resolution for agency commands.
+// This file implements invocation resolution (Slice 8 PR-02).
+package ids
+
+import (
+	"sort"
+	"strings"
+)
+
+// InvocationRef represents a reference to a discovered invocation.
+type InvocationRef struct {
+	// InvocationID is the invocation_id from the directory name (canonical identity).
+	InvocationID string
+
+	// RepoID is the repo_id.
+	RepoID string
+
+	// Broken indicates meta.json is unreadable/invalid or the sandbox is missing.
+	Broken bool
+}
+
+// ErrInvocationNotFound indicates no matching invocation_id (exact or prefix).
+type ErrInvocationNotFound struct {
+	Input string
+}
+
+func (e *ErrInvocationNotFound) Error() string {
+	return "invocation not found: " + e.Input
+}
+
+// ErrInvocationAmbiguous indicates prefix matched multiple invocation_ids.
+type ErrInvocationAmbiguous struct {
+	Input      string
+	Candidates []InvocationRef
+}
+
+func (e *ErrInvocationAmbiguous) Error() string {
+	ids := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		ids[i] = c.InvocationID
+	}
+	return "ambiguous invocation id " + e.Input + " matches: " + strings.Join(ids, ", ")
+}
+
+// ResolveInvocationRef resolves an input identifier to a single invocation reference.
+//
+// Resolution rules:
+//  1. Exact ID match: if exactly one candidate has InvocationID == input, resolve.
+//  2. Prefix match: treat input as a prefix of InvocationID (broken invocations excluded).
+//
+// Invocation names are display labels only and are never matched.
+// Exact ID match always works, including for broken invocations (escape hatch).
+func ResolveInvocationRef(input string, refs []InvocationRef) (InvocationRef, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return InvocationRef{}, &ErrInvocationNotFound{Input: ""}
+	}
+
+	// 1. Exact ID match
+	var exactMatches []InvocationRef
+	for _, ref := range refs {
+		if ref.InvocationID == input {
+			exactMatches = append(exactMatches, ref)
+		}
+	}
+
+	if len(exactMatches) == 1 {
+		return exactMatches[0], nil
+	}
+	if len(exactMatches) > 1 {
+		sortInvocationCandidates(exactMatches)
+		return InvocationRef{}, &ErrInvocationAmbiguous{Input: input, Candidates: exactMatches}
+	}
+
+	// 2. Prefix match among non-broken invocations
+	var prefixMatches []InvocationRef
+	for _, ref := range refs {
+		if strings.HasPrefix(ref.InvocationID, input) && !ref.Broken {
+			prefixMatches = append(prefixMatches, ref)
+		}
+	}
+
+	switch len(prefixMatches) {
+	case 0:
+		return InvocationRef{}, &ErrInvocationNotFound{Input: input}
+	case 1:
+		return prefixMatches[0], nil
+	default:
+		sortInvocationCandidates(prefixMatches)
+		return InvocationRef{}, &ErrInvocationAmbiguous{Input: input, Candidates: prefixMatches}
+	}
+}
+
+// sortInvocationCandidates sorts candidates deterministically by RepoID, then InvocationID.
+func sortInvocationCandidates(refs []InvocationRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].InvocationID != refs[j].InvocationID {
+			return refs[i].InvocationID < refs[j].InvocationID
+		}
+		return refs[i].RepoID < refs[j].RepoID
+	})
+}
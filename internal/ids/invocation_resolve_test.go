@@ -0,0 +1,83 @@
+package ids
+
+import (
+	"testing"
+)
+
+func TestResolveInvocationRef_ByExactID(t *testing.T) {
+	refs := []InvocationRef{
+		{InvocationID: "20260131100000-a1b2"},
+		{InvocationID: "20260131110000-c3d4", Broken: true},
+	}
+
+	ref, err := ResolveInvocationRef("20260131100000-a1b2", refs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ref.InvocationID != "20260131100000-a1b2" {
+		t.Errorf("got InvocationID = %v, want 20260131100000-a1b2", ref.InvocationID)
+	}
+
+	// Exact ID should work for broken invocations (escape hatch)
+	ref, err = ResolveInvocationRef("20260131110000-c3d4", refs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ref.Broken {
+		t.Error("expected Broken=true")
+	}
+}
+
+func TestResolveInvocationRef_ByPrefix(t *testing.T) {
+	refs := []InvocationRef{
+		{InvocationID: "20260131100000-a1b2"},
+		{InvocationID: "20260131110000-c3d4"},
+		{InvocationID: "20260131120000-e5f6", Broken: true},
+	}
+
+	ref, err := ResolveInvocationRef("2026013111", refs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ref.InvocationID != "20260131110000-c3d4" {
+		t.Errorf("got InvocationID = %v, want 20260131110000-c3d4", ref.InvocationID)
+	}
+
+	// Broken invocations are excluded from prefix matching
+	_, err = ResolveInvocationRef("2026013112", refs)
+	if _, ok := err.(*ErrInvocationNotFound); !ok {
+		t.Errorf("expected ErrInvocationNotFound, got %T", err)
+	}
+}
+
+func TestResolveInvocationRef_AmbiguousPrefix(t *testing.T) {
+	refs := []InvocationRef{
+		{InvocationID: "20260131110000-c3d4"},
+		{InvocationID: "20260131100000-a1b2"},
+	}
+
+	_, err := ResolveInvocationRef("202601311", refs)
+	ambErr, ok := err.(*ErrInvocationAmbiguous)
+	if !ok {
+		t.Fatalf("expected ErrInvocationAmbiguous, got %T", err)
+	}
+	if len(ambErr.Candidates) != 2 {
+		t.Fatalf("got %d candidates, want 2", len(ambErr.Candidates))
+	}
+	if ambErr.Candidates[0].InvocationID != "20260131100000-a1b2" {
+		t.Errorf("candidates not sorted: %v", ambErr.Candidates)
+	}
+}
+
+func TestResolveInvocationRef_NeverMatchesName(t *testing.T) {
+	refs := []InvocationRef{
+		{InvocationID: "20260131100000-a1b2"},
+	}
+
+	for _, input := range []string{"", "  ", "arch-agent", "a1b2"} {
+		_, err := ResolveInvocationRef(input, refs)
+		if _, ok := err.(*ErrInvocationNotFound); !ok {
+			t.Errorf("ResolveInvocationRef(%q): expected ErrInvocationNotFound, got %T", input, err)
+		}
+	}
+}
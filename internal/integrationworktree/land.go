@@ -0,0 +1,411 @@
+package integrationworktree
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/git"
+	"github.com/NielsdaWheelz/agency/internal/lock"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// Land modes recorded in landing records.
+const (
+	// LandModeCherryPick cherry-picks the run's commits onto the integration HEAD.
+	LandModeCherryPick = "cherry-pick"
+
+	// LandModeApply applies the run's working-tree diff as a single commit.
+	LandModeApply = "apply"
+)
+
+// EventRunLanded is the run event appended after a successful landing.
+const EventRunLanded = "run_landed"
+
+// LandOpts contains options for landing a run into an integration worktree.
+type LandOpts struct {
+	// RepoID is the repo identifier (shared by the run and the worktree).
+	RepoID string
+
+	// WorktreeID is the target integration worktree.
+	WorktreeID string
+
+	// Run is the run whose work is landed.
+	Run *store.RunMeta
+
+	// ParentRef is the resolved git ref for Run.ParentBranch (local or origin/<parent>).
+	ParentRef string
+
+	// Mode is LandModeCherryPick or LandModeApply.
+	Mode string
+
+	// RequireBase fails the landing unless the integration HEAD already
+	// contains the run's merge-base with its parent.
+	RequireBase bool
+}
+
+// LandResult holds the outcome of a landing attempt.
+type LandResult struct {
+	// Mode is the landing mode used.
+	Mode string
+
+	// BaseSHA is the merge-base of the run branch and its parent.
+	BaseSHA string
+
+	// SourceCommits are the run commits that were cherry-picked (cherry-pick mode only).
+	SourceCommits []string
+
+	// Commits are the commits created on the integration branch, oldest first.
+	Commits []string
+
+	// LandedAt is the landing timestamp (RFC3339 UTC).
+	LandedAt string
+
+	// ConflictCommit is the run commit that failed to cherry-pick (set on conflict).
+	ConflictCommit string
+
+	// ConflictFiles are the paths that conflicted (set on conflict, best-effort).
+	ConflictFiles []string
+}
+
+// Land lands a run's work into an integration worktree.
+//
+// Operations:
+//  1. Check the worktree is present and has no tracked changes
+//  2. Compute the run's merge-base with its parent (and check it with RequireBase)
+//  3. Collect the run's commits (cherry-pick) or working-tree diff (apply)
+//  4. Under the repo lock: cherry-pick / apply + commit, aborting on conflict
+//  5. Record the landing in both the worktree and run meta.json, and append a run_landed event
+//
+// On conflict the integration tree is restored to its previous HEAD and the
+// returned result carries the conflicting commit and files with E_LAND_CONFLICT.
+func (s *Service) Land(ctx context.Context, opts LandOpts) (*LandResult, error) {
+	run := opts.Run
+	if opts.Mode != LandModeCherryPick && opts.Mode != LandModeApply {
+		return nil, errors.New(errors.EUsage, "invalid land mode: "+opts.Mode)
+	}
+
+	meta, err := s.Store.ReadIntegrationWorktreeMeta(opts.RepoID, opts.WorktreeID)
+	if err != nil {
+		return nil, err
+	}
+	if meta.State == store.WorktreeStateArchived {
+		return nil, errors.NewWithDetails(
+			errors.EWorktreeNotFound,
+			"worktree is archived",
+			map[string]string{"worktree_id": opts.WorktreeID},
+		)
+	}
+	tree := meta.TreePath
+	if info, statErr := os.Stat(tree); statErr != nil || !info.IsDir() {
+		return nil, errors.NewWithDetails(
+			errors.EWorktreeNotFound,
+			"worktree tree directory is missing",
+			map[string]string{"tree_path": tree},
+		)
+	}
+
+	// Untracked files are ignored: they only matter if the landing touches
+	// them, in which case git refuses and the landing aborts as a conflict.
+	status, err := s.git(ctx, tree, nil, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(status.Stdout) != "" {
+		return nil, errors.NewWithDetails(
+			errors.EDirtyWorktree,
+			"integration worktree has uncommitted changes; commit or stash them first",
+			map[string]string{"tree_path": tree},
+		)
+	}
+
+	res := &LandResult{Mode: opts.Mode}
+
+	base, err := s.git(ctx, tree, nil, "merge-base", opts.ParentRef, run.Branch)
+	if err != nil || base.ExitCode != 0 {
+		return nil, errors.NewWithDetails(
+			errors.EParentNotFound,
+			fmt.Sprintf("no merge-base between %s and %s", opts.ParentRef, run.Branch),
+			map[string]string{"parent_ref": opts.ParentRef, "branch": run.Branch},
+		)
+	}
+	res.BaseSHA = strings.TrimSpace(base.Stdout)
+
+	if opts.RequireBase {
+		contained, err := s.git(ctx, tree, nil, "merge-base", "--is-ancestor", res.BaseSHA, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		if contained.ExitCode != 0 {
+			return nil, errors.NewWithDetails(
+				errors.ELandBaseMismatch,
+				fmt.Sprintf("run base %s is not contained in the integration worktree HEAD", shortSHA(res.BaseSHA)),
+				map[string]string{
+					"base_sha": res.BaseSHA,
+					"hint":     "update the integration worktree from " + run.ParentBranch + ", or land without --require-base",
+				},
+			)
+		}
+	}
+
+	// Collect what to land before taking the lock
+	var patchPath string
+	switch opts.Mode {
+	case LandModeCherryPick:
+		revs, err := s.git(ctx, tree, nil, "rev-list", "--reverse", "--no-merges", res.BaseSHA+".."+run.Branch)
+		if err != nil || revs.ExitCode != 0 {
+			return nil, errors.New(errors.EInternal, "failed to list run commits")
+		}
+		res.SourceCommits = strings.Fields(revs.Stdout)
+		if len(res.SourceCommits) == 0 {
+			return nil, errors.NewWithDetails(
+				errors.EEmptyDiff,
+				fmt.Sprintf("run has no commits ahead of %s", run.ParentBranch),
+				map[string]string{"hint": "use --apply to land uncommitted changes"},
+			)
+		}
+	case LandModeApply:
+		tmpDir, err := os.MkdirTemp("", "agency-land-")
+		if err != nil {
+			return nil, errors.Wrap(errors.EInternal, "failed to create temp dir", err)
+		}
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		patch, err := s.workingTreeDiff(ctx, run.WorktreePath, res.BaseSHA, tmpDir)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(patch) == "" {
+			return nil, errors.NewWithDetails(
+				errors.EEmptyDiff,
+				fmt.Sprintf("run has no changes against %s", run.ParentBranch),
+				map[string]string{"worktree_path": run.WorktreePath},
+			)
+		}
+		patchPath = filepath.Join(tmpDir, "land.patch")
+		if err := os.WriteFile(patchPath, []byte(patch), 0o600); err != nil {
+			return nil, errors.Wrap(errors.EInternal, "failed to write patch", err)
+		}
+	}
+
+	// Hold the repo lock only while the integration branch is mutated
+	if err := s.landLocked(ctx, opts, tree, patchPath, res); err != nil {
+		return res, err
+	}
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	res.LandedAt = now().UTC().Format(time.RFC3339)
+
+	if err := s.Store.UpdateIntegrationWorktreeMeta(opts.RepoID, opts.WorktreeID, func(m *store.IntegrationWorktreeMeta) {
+		m.Landings = append(m.Landings, store.WorktreeLanding{
+			RunID:    run.RunID,
+			RunName:  run.Name,
+			Mode:     res.Mode,
+			Commits:  res.Commits,
+			LandedAt: res.LandedAt,
+		})
+	}); err != nil {
+		return res, err
+	}
+	if err := s.Store.UpdateMeta(run.RepoID, run.RunID, func(m *store.RunMeta) {
+		m.Landings = append(m.Landings, store.RunMetaLanding{
+			WorktreeID:   meta.WorktreeID,
+			WorktreeName: meta.Name,
+			Mode:         res.Mode,
+			Commits:      res.Commits,
+			LandedAt:     res.LandedAt,
+		})
+	}); err != nil {
+		return res, err
+	}
+
+	// Best-effort event
+	_ = events.AppendEvent(s.Store.EventsPath(run.RepoID, run.RunID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     res.LandedAt,
+		RepoID:        run.RepoID,
+		RunID:         run.RunID,
+		Event:         EventRunLanded,
+		Data:          events.RunLandedData(meta.WorktreeID, meta.Name, res.Mode, res.Commits),
+	})
+
+	return res, nil
+}
+
+// landLocked performs the git mutation under the repo lock and fills res.Commits.
+// On failure the integration tree is restored to its previous HEAD.
+func (s *Service) landLocked(ctx context.Context, opts LandOpts, tree, patchPath string, res *LandResult) error {
+	unlock, err := lock.NewRepoLock(s.Store.DataDir).Lock(opts.RepoID, "worktree land")
+	if err != nil {
+		var lockErr *lock.ErrLocked
+		if stderrors.As(err, &lockErr) {
+			return errors.New(errors.ERepoLocked, lockErr.Error())
+		}
+		return errors.Wrap(errors.EInternal, "failed to acquire repo lock", err)
+	}
+	defer func() { _ = unlock() }()
+
+	before, err := s.git(ctx, tree, nil, "rev-parse", "HEAD")
+	if err != nil || before.ExitCode != 0 {
+		return errors.New(errors.EInternal, "failed to read integration worktree HEAD")
+	}
+	beforeSHA := strings.TrimSpace(before.Stdout)
+
+	switch opts.Mode {
+	case LandModeCherryPick:
+		args := append([]string{"cherry-pick", "-x"}, res.SourceCommits...)
+		pick, err := s.git(ctx, tree, nil, args...)
+		if err != nil {
+			return err
+		}
+		if pick.ExitCode != 0 {
+			if head, _ := s.git(ctx, tree, nil, "rev-parse", "-q", "--verify", "CHERRY_PICK_HEAD"); head.ExitCode == 0 {
+				res.ConflictCommit = strings.TrimSpace(head.Stdout)
+			}
+			if files, _ := s.git(ctx, tree, nil, "diff", "--name-only", "--diff-filter=U"); files.ExitCode == 0 {
+				res.ConflictFiles = strings.Fields(files.Stdout)
+			}
+			_, _ = s.git(ctx, tree, nil, "cherry-pick", "--abort")
+			_, _ = s.git(ctx, tree, nil, "reset", "-q", "--hard", beforeSHA)
+			return landConflictError(opts.Run, res, pick.Stderr)
+		}
+	case LandModeApply:
+		check, err := s.git(ctx, tree, nil, "apply", "--check", "--binary", patchPath)
+		if err != nil {
+			return err
+		}
+		if check.ExitCode != 0 {
+			res.ConflictFiles = parseApplyFailures(check.Stderr)
+			return landConflictError(opts.Run, res, check.Stderr)
+		}
+		msg := fmt.Sprintf("Land %s (%s)\n\nApplied the working-tree changes of agency run %s (branch %s).\n",
+			opts.Run.Name, opts.Run.RunID, opts.Run.RunID, opts.Run.Branch)
+		applied, err := s.git(ctx, tree, nil, "apply", "--index", "--binary", patchPath)
+		if err == nil && applied.ExitCode == 0 {
+			applied, err = s.git(ctx, tree, nil, "commit", "-q", "--no-verify", "-m", msg)
+		}
+		if err != nil || applied.ExitCode != 0 {
+			_, _ = s.git(ctx, tree, nil, "reset", "-q", "--hard", beforeSHA)
+			return errors.NewWithDetails(
+				errors.EInternal,
+				"failed to commit applied changes: "+strings.TrimSpace(applied.Stderr),
+				map[string]string{"tree_path": tree},
+			)
+		}
+	}
+
+	revs, err := s.git(ctx, tree, nil, "rev-list", "--reverse", beforeSHA+"..HEAD")
+	if err != nil || revs.ExitCode != 0 {
+		return errors.New(errors.EInternal, "failed to list landed commits")
+	}
+	res.Commits = strings.Fields(revs.Stdout)
+	return nil
+}
+
+// workingTreeDiff returns a binary patch of the run worktree (committed,
+// uncommitted, and untracked changes) against base. The working tree is
+// staged into a throwaway index under tmpDir so the real index is untouched.
+func (s *Service) workingTreeDiff(ctx context.Context, workDir, base, tmpDir string) (string, error) {
+	if info, statErr := os.Stat(workDir); statErr != nil || !info.IsDir() {
+		return "", errors.NewWithDetails(
+			errors.EWorktreeMissing,
+			"run worktree path missing on disk (run may be archived)",
+			map[string]string{"worktree_path": workDir},
+		)
+	}
+
+	// Seed from the real index so unchanged files are not rehashed
+	indexPath := filepath.Join(tmpDir, "index")
+	if realIndex := git.GitPath(ctx, s.CR, workDir, "index"); realIndex != "" {
+		if data, err := os.ReadFile(realIndex); err == nil {
+			_ = os.WriteFile(indexPath, data, 0o600)
+		}
+	}
+
+	env := map[string]string{"GIT_INDEX_FILE": indexPath}
+	if added, err := s.git(ctx, workDir, env, "add", "-A"); err != nil || added.ExitCode != 0 {
+		return "", errors.New(errors.EInternal, "failed to snapshot run working tree changes")
+	}
+	diff, err := s.git(ctx, workDir, env, "diff", "--cached", "--binary", base)
+	if err != nil || diff.ExitCode != 0 {
+		return "", errors.New(errors.EInternal, "git diff failed in run worktree")
+	}
+	return diff.Stdout, nil
+}
+
+// git runs a git command in dir. Returns an error only for execution failures.
+func (s *Service) git(ctx context.Context, dir string, env map[string]string, args ...string) (exec.CmdResult, error) {
+	result, err := s.CR.Run(ctx, "git", args, exec.RunOpts{Dir: dir, Env: env})
+	if err != nil {
+		return result, errors.WrapWithDetails(
+			errors.EInternal,
+			"failed to execute git",
+			err,
+			map[string]string{"command": "git " + strings.Join(args, " ")},
+		)
+	}
+	return result, nil
+}
+
+// landConflictError builds the E_LAND_CONFLICT error for a failed landing.
+func landConflictError(run *store.RunMeta, res *LandResult, stderr string) error {
+	details := map[string]string{"run_id": run.RunID, "mode": res.Mode}
+	if res.ConflictCommit != "" {
+		details["commit"] = res.ConflictCommit
+	}
+	if len(res.ConflictFiles) > 0 {
+		details["files"] = strings.Join(res.ConflictFiles, ",")
+	}
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		details["stderr"] = msg
+	}
+	return errors.NewWithDetails(
+		errors.ELandConflict,
+		fmt.Sprintf("landing %s conflicts with the integration worktree; nothing was changed", run.Name),
+		details,
+	)
+}
+
+// parseApplyFailures extracts paths from `git apply --check` errors such as
+// "error: patch failed: path:12" and "error: path: already exists in working directory".
+func parseApplyFailures(stderr string) []string {
+	var files []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(stderr, "\n") {
+		rest, ok := strings.CutPrefix(line, "error: ")
+		if !ok {
+			continue
+		}
+		var path string
+		if p, ok := strings.CutPrefix(rest, "patch failed: "); ok {
+			if i := strings.LastIndex(p, ":"); i > 0 {
+				path = p[:i]
+			}
+		} else if i := strings.Index(rest, ": "); i > 0 {
+			path = rest[:i]
+		}
+		if path != "" && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// shortSHA returns the first 7 characters of a sha.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,229 @@
+package integrationworktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+)
+
+// landFixture is a repo with an integration worktree and a run worktree, both
+// branched from main.
+type landFixture struct {
+	t       *testing.T
+	svc     *Service
+	repoDir string
+	wt      *CreateResult
+	run     *store.RunMeta
+}
+
+func (f *landFixture) git(dir string, args ...string) string {
+	f.t.Helper()
+	result, err := exec.NewRealRunner().Run(context.Background(), "git", args, exec.RunOpts{Dir: dir})
+	if err != nil || result.ExitCode != 0 {
+		f.t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+	}
+	return strings.TrimSpace(result.Stdout)
+}
+
+func (f *landFixture) write(path, content string) {
+	f.t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *landFixture) land(mode string, requireBase bool) (*LandResult, error) {
+	return f.svc.Land(context.Background(), LandOpts{
+		RepoID:      "repo1",
+		WorktreeID:  f.wt.WorktreeID,
+		Run:         f.run,
+		ParentRef:   "main",
+		Mode:        mode,
+		RequireBase: requireBase,
+	})
+}
+
+func newLandFixture(t *testing.T) *landFixture {
+	t.Helper()
+	testutil.HermeticGitEnv(t)
+
+	tmpDir := t.TempDir()
+	f := &landFixture{t: t, repoDir: filepath.Join(tmpDir, "repo")}
+	if err := os.Mkdir(f.repoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f.write(filepath.Join(f.repoDir, "README.md"), "# Test\n")
+	f.git(f.repoDir, "init", "-b", "main")
+	f.git(f.repoDir, "add", ".")
+	f.git(f.repoDir, "commit", "-m", "Initial commit")
+
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	st := store.NewStore(fs.NewRealFS(), filepath.Join(tmpDir, "data"), func() time.Time { return now })
+	f.svc = NewService(st, exec.NewRealRunner(), fs.NewRealFS(), st.Now)
+
+	wt, err := f.svc.Create(context.Background(), CreateOpts{Name: "integ", RepoRoot: f.repoDir, RepoID: "repo1", ParentBranch: "main"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	f.wt = wt
+
+	runPath := filepath.Join(tmpDir, "run")
+	f.git(f.repoDir, "worktree", "add", "-b", "agency/feature-a3f2", runPath, "main")
+	f.run = store.NewRunMeta("r1", "repo1", "feature", "claude", "claude", "main", "agency/feature-a3f2", runPath, now)
+	if _, err := st.EnsureRunDir("repo1", "r1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.WriteInitialMeta("repo1", "r1", f.run); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestLand_CherryPick(t *testing.T) {
+	f := newLandFixture(t)
+	f.write(filepath.Join(f.run.WorktreePath, "a.txt"), "a\n")
+	f.git(f.run.WorktreePath, "add", ".")
+	f.git(f.run.WorktreePath, "commit", "-m", "Add a")
+	f.write(filepath.Join(f.run.WorktreePath, "b.txt"), "b\n")
+	f.git(f.run.WorktreePath, "add", ".")
+	f.git(f.run.WorktreePath, "commit", "-m", "Add b")
+
+	res, err := f.land(LandModeCherryPick, true)
+	if err != nil {
+		t.Fatalf("Land() error = %v", err)
+	}
+	if len(res.SourceCommits) != 2 || len(res.Commits) != 2 {
+		t.Fatalf("source=%v commits=%v, want 2 each", res.SourceCommits, res.Commits)
+	}
+	if got := f.git(f.wt.TreePath, "log", "--format=%s", "-2"); got != "Add b\nAdd a" {
+		t.Errorf("integration log = %q", got)
+	}
+	if got := f.git(f.wt.TreePath, "rev-parse", "HEAD"); got != res.Commits[1] {
+		t.Errorf("HEAD = %s, want %s", got, res.Commits[1])
+	}
+
+	wtMeta, err := f.svc.Store.ReadIntegrationWorktreeMeta("repo1", f.wt.WorktreeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wtMeta.Landings) != 1 || wtMeta.Landings[0].RunID != "r1" || wtMeta.Landings[0].Mode != LandModeCherryPick {
+		t.Errorf("worktree landings = %+v", wtMeta.Landings)
+	}
+	runMeta, err := f.svc.Store.ReadMeta("repo1", "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runMeta.Landings) != 1 || runMeta.Landings[0].WorktreeName != "integ" || len(runMeta.Landings[0].Commits) != 2 {
+		t.Errorf("run landings = %+v", runMeta.Landings)
+	}
+	data, err := os.ReadFile(f.svc.Store.EventsPath("repo1", "r1"))
+	if err != nil || !strings.Contains(string(data), `"event":"run_landed"`) {
+		t.Errorf("run_landed event missing: %v\n%s", err, data)
+	}
+}
+
+func TestLand_ConflictAbortsCleanly(t *testing.T) {
+	f := newLandFixture(t)
+	f.write(filepath.Join(f.run.WorktreePath, "README.md"), "# Run\n")
+	f.git(f.run.WorktreePath, "commit", "-am", "Run edit")
+	f.write(filepath.Join(f.wt.TreePath, "README.md"), "# Integration\n")
+	f.git(f.wt.TreePath, "commit", "-am", "Integration edit")
+	before := f.git(f.wt.TreePath, "rev-parse", "HEAD")
+
+	res, err := f.land(LandModeCherryPick, false)
+	if errors.GetCode(err) != errors.ELandConflict {
+		t.Fatalf("expected E_LAND_CONFLICT, got %v", err)
+	}
+	if res == nil || res.ConflictCommit == "" || strings.Join(res.ConflictFiles, ",") != "README.md" {
+		t.Errorf("conflict result = %+v", res)
+	}
+	if got := f.git(f.wt.TreePath, "rev-parse", "HEAD"); got != before {
+		t.Errorf("HEAD moved: %s, want %s", got, before)
+	}
+	if status := f.git(f.wt.TreePath, "status", "--porcelain", "--untracked-files=no"); status != "" {
+		t.Errorf("tree not clean after abort:\n%s", status)
+	}
+
+	wtMeta, err := f.svc.Store.ReadIntegrationWorktreeMeta("repo1", f.wt.WorktreeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wtMeta.Landings) != 0 {
+		t.Errorf("conflicted landing was recorded: %+v", wtMeta.Landings)
+	}
+}
+
+func TestLand_Apply(t *testing.T) {
+	f := newLandFixture(t)
+	f.write(filepath.Join(f.run.WorktreePath, "committed.txt"), "c\n")
+	f.git(f.run.WorktreePath, "add", ".")
+	f.git(f.run.WorktreePath, "commit", "-m", "Committed")
+	f.write(filepath.Join(f.run.WorktreePath, "README.md"), "# Changed\n")
+	f.write(filepath.Join(f.run.WorktreePath, "untracked.txt"), "u\n")
+
+	res, err := f.land(LandModeApply, false)
+	if err != nil {
+		t.Fatalf("Land() error = %v", err)
+	}
+	if len(res.Commits) != 1 {
+		t.Fatalf("commits = %v, want 1", res.Commits)
+	}
+	if got := f.git(f.wt.TreePath, "log", "--format=%s", "-1"); got != "Land feature (r1)" {
+		t.Errorf("commit subject = %q", got)
+	}
+	files := f.git(f.wt.TreePath, "show", "--name-only", "--format=", "HEAD")
+	if files != "README.md\ncommitted.txt\nuntracked.txt" {
+		t.Errorf("landed files = %q", files)
+	}
+
+	// The run's own index is untouched
+	if status := f.git(f.run.WorktreePath, "status", "--porcelain"); !strings.Contains(status, "?? untracked.txt") {
+		t.Errorf("run index was modified:\n%s", status)
+	}
+}
+
+func TestLand_RequireBase(t *testing.T) {
+	f := newLandFixture(t)
+
+	// main moves on after the integration worktree was created; the run
+	// branches from the new tip, which the integration worktree lacks
+	f.write(filepath.Join(f.repoDir, "later.txt"), "later\n")
+	f.git(f.repoDir, "add", ".")
+	f.git(f.repoDir, "commit", "-m", "Later change on main")
+	f.git(f.run.WorktreePath, "reset", "-q", "--hard", "main")
+	f.write(filepath.Join(f.run.WorktreePath, "a.txt"), "a\n")
+	f.git(f.run.WorktreePath, "add", ".")
+	f.git(f.run.WorktreePath, "commit", "-m", "Add a")
+
+	if _, err := f.land(LandModeCherryPick, true); errors.GetCode(err) != errors.ELandBaseMismatch {
+		t.Fatalf("expected E_LAND_BASE_MISMATCH, got %v", err)
+	}
+
+	// Without strict mode only the run's own commit is landed
+	res, err := f.land(LandModeCherryPick, false)
+	if err != nil {
+		t.Fatalf("Land() error = %v", err)
+	}
+	if len(res.Commits) != 1 {
+		t.Errorf("commits = %v, want 1", res.Commits)
+	}
+}
+
+func TestLand_NothingToLand(t *testing.T) {
+	f := newLandFixture(t)
+	if _, err := f.land(LandModeCherryPick, false); errors.GetCode(err) != errors.EEmptyDiff {
+		t.Errorf("cherry-pick: expected E_EMPTY_DIFF, got %v", err)
+	}
+	if _, err := f.land(LandModeApply, false); errors.GetCode(err) != errors.EEmptyDiff {
+		t.Errorf("apply: expected E_EMPTY_DIFF, got %v", err)
+	}
+}
@@ -0,0 +1,460 @@
+// Package invocation provides agent invocation and sandbox operations for Slice 8.
+// Every invocation runs in its own sandbox worktree branched from an integration
+// worktree; runners never execute inside the integration tree itself.
+package invocation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/core"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/ids"
+	"github.com/NielsdaWheelz/agency/internal/integrationworktree"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+// SandboxMarkerFileName is the name of the marker file that identifies sandbox worktrees.
+const SandboxMarkerFileName = "SANDBOX_MARKER"
+
+// sandboxMarkerContent is written to .agency/SANDBOX_MARKER in every sandbox tree.
+const sandboxMarkerContent = "# This directory is a sandbox worktree.\n# Runners may execute here.\n"
+
+// SandboxBranchPrefix is the branch prefix for sandbox branches.
+const SandboxBranchPrefix = "agency/sandbox-"
+
+// Service provides invocation operations.
+type Service struct {
+	Store *store.Store
+	CR    exec.CommandRunner
+	FS    fs.FS
+	Now   func() time.Time
+}
+
+// NewService creates a new invocation service.
+func NewService(st *store.Store, cr exec.CommandRunner, fsys fs.FS, now func() time.Time) *Service {
+	return &Service{
+		Store: st,
+		CR:    cr,
+		FS:    fsys,
+		Now:   now,
+	}
+}
+
+// SandboxBranch returns the sandbox branch name for an invocation.
+func SandboxBranch(invocationID string) string {
+	return SandboxBranchPrefix + invocationID
+}
+
+// HasSandboxMarker checks if a directory contains the SANDBOX_MARKER file.
+func HasSandboxMarker(path string) bool {
+	markerPath := filepath.Join(path, ".agency", SandboxMarkerFileName)
+	_, err := os.Stat(markerPath)
+	return err == nil
+}
+
+// CheckSandboxPathSafe rejects sandbox paths that could resolve to an integration tree.
+// The sandbox path must not equal, contain, or be contained by the integration tree path,
+// and must not contain .agency/INTEGRATION_MARKER.
+func CheckSandboxPathSafe(sandboxPath, integrationTreePath string) error {
+	sandbox := filepath.Clean(sandboxPath)
+	integration := filepath.Clean(integrationTreePath)
+
+	details := map[string]string{
+		"sandbox_path":     sandbox,
+		"integration_path": integration,
+		"hint":             "this is a bug in sandbox path computation; refusing to continue",
+	}
+
+	if sandbox == integration || isWithin(sandbox, integration) || isWithin(integration, sandbox) {
+		return errors.NewWithDetails(
+			errors.ESandboxPathUnsafe,
+			"sandbox path overlaps the integration tree",
+			details,
+		)
+	}
+
+	if integrationworktree.HasIntegrationMarker(sandbox) {
+		return errors.NewWithDetails(
+			errors.ESandboxPathUnsafe,
+			"sandbox path contains .agency/"+integrationworktree.IntegrationMarkerFileName,
+			details,
+		)
+	}
+
+	return nil
+}
+
+// isWithin reports whether path is strictly inside dir.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// CreateOpts contains options for creating an invocation sandbox.
+type CreateOpts struct {
+	// RepoRoot is the absolute path to the git repository root.
+	RepoRoot string
+
+	// RepoID is the repo identifier.
+	RepoID string
+
+	// Worktree is the resolved integration worktree meta the sandbox branches from.
+	Worktree *store.IntegrationWorktreeMeta
+
+	// Name is the optional invocation label (display only).
+	Name string
+
+	// Runner is the runner name (e.g., "claude").
+	Runner string
+
+	// RunnerCmd is the resolved runner command.
+	RunnerCmd string
+
+	// Mode is the execution mode.
+	Mode store.InvocationMode
+}
+
+// Prepare performs the pre-lock checks for an invocation and returns its invocation_id.
+//
+// Checks:
+//  1. Integration tree carries .agency/INTEGRATION_MARKER
+//  2. Computed sandbox tree path cannot resolve to the integration tree
+func (s *Service) Prepare(opts CreateOpts) (string, error) {
+	if opts.Worktree == nil {
+		return "", errors.New(errors.EInternal, "integration worktree meta is required")
+	}
+
+	if !integrationworktree.HasIntegrationMarker(opts.Worktree.TreePath) {
+		return "", errors.NewWithDetails(
+			errors.ENotIntegrationTree,
+			"target is not an integration worktree (missing .agency/"+integrationworktree.IntegrationMarkerFileName+")",
+			map[string]string{
+				"worktree_id": opts.Worktree.WorktreeID,
+				"tree_path":   opts.Worktree.TreePath,
+			},
+		)
+	}
+
+	invocationID, err := core.NewRunID(s.Now())
+	if err != nil {
+		return "", errors.Wrap(errors.EInternal, "failed to generate invocation_id", err)
+	}
+
+	sandboxPath := s.Store.SandboxTreePath(opts.RepoID, invocationID)
+	if err := CheckSandboxPathSafe(sandboxPath, opts.Worktree.TreePath); err != nil {
+		return "", err
+	}
+
+	return invocationID, nil
+}
+
+// Create creates the sandbox worktree and invocation record for a prepared invocation.
+// The caller must hold the repo lock for the duration of this call.
+//
+// Operations (in order):
+//  1. Create invocation record directory (exclusive)
+//  2. Capture base_commit (git rev-parse <integration_branch>)
+//  3. Run git worktree add -b agency/sandbox-<id> <sandbox_tree> <integration_branch>
+//  4. Write SANDBOX_MARKER to .agency/
+//  5. Write meta.json (status = starting)
+//
+// On failure, the sandbox worktree, sandbox branch, and invocation dir are removed.
+// There is no fallback to the integration tree.
+func (s *Service) Create(ctx context.Context, invocationID string, opts CreateOpts) (*store.InvocationMeta, error) {
+	wt := opts.Worktree
+	sandboxPath := s.Store.SandboxTreePath(opts.RepoID, invocationID)
+	branch := SandboxBranch(invocationID)
+
+	// Re-check path safety right before mutating git state
+	if err := CheckSandboxPathSafe(sandboxPath, wt.TreePath); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.Store.EnsureInvocationDir(opts.RepoID, invocationID); err != nil {
+		return nil, err
+	}
+
+	gitWorktreeCreated := false
+
+	cleanup := func() {
+		if gitWorktreeCreated {
+			// Remove worktree and branch (best-effort)
+			_, _ = s.CR.Run(ctx, "git", []string{"-C", opts.RepoRoot, "worktree", "remove", "--force", sandboxPath}, exec.RunOpts{})
+			_, _ = s.CR.Run(ctx, "git", []string{"-C", opts.RepoRoot, "branch", "-D", branch}, exec.RunOpts{})
+		}
+		_ = s.Store.RemoveSandboxDir(opts.RepoID, invocationID)
+		_ = s.Store.RemoveInvocationDir(opts.RepoID, invocationID)
+	}
+
+	// Capture base commit
+	revArgs := []string{"-C", opts.RepoRoot, "rev-parse", "--verify", wt.Branch + "^{commit}"}
+	revResult, err := s.CR.Run(ctx, "git", revArgs, exec.RunOpts{})
+	if err != nil || revResult.ExitCode != 0 {
+		cleanup()
+		details := map[string]string{
+			"command": "git " + strings.Join(revArgs, " "),
+			"branch":  wt.Branch,
+		}
+		if revResult.Stderr != "" {
+			details["stderr"] = strings.TrimSpace(revResult.Stderr)
+		}
+		return nil, errors.WrapWithDetails(
+			errors.ESandboxCreateFailed,
+			"failed to resolve integration branch "+wt.Branch,
+			err,
+			details,
+		)
+	}
+	baseCommit := strings.TrimSpace(revResult.Stdout)
+
+	// Create sandbox worktree + branch at base commit
+	args := []string{
+		"-C", opts.RepoRoot,
+		"worktree", "add",
+		"-b", branch,
+		sandboxPath,
+		baseCommit,
+	}
+
+	result, err := s.CR.Run(ctx, "git", args, exec.RunOpts{})
+	if err != nil {
+		cleanup()
+		return nil, errors.WrapWithDetails(
+			errors.ESandboxCreateFailed,
+			"failed to execute git worktree add",
+			err,
+			map[string]string{"command": "git " + strings.Join(args, " ")},
+		)
+	}
+
+	if result.ExitCode != 0 {
+		cleanup()
+		details := map[string]string{
+			"command":   "git " + strings.Join(args, " "),
+			"exit_code": fmt.Sprintf("%d", result.ExitCode),
+		}
+		if result.Stderr != "" {
+			details["stderr"] = strings.TrimSpace(result.Stderr)
+		}
+		return nil, errors.NewWithDetails(
+			errors.ESandboxCreateFailed,
+			"git worktree add failed: "+strings.TrimSpace(result.Stderr),
+			details,
+		)
+	}
+
+	gitWorktreeCreated = true
+
+	// The integration tree's own marker must never be inherited by a sandbox
+	if integrationworktree.HasIntegrationMarker(sandboxPath) {
+		cleanup()
+		return nil, errors.NewWithDetails(
+			errors.ESandboxPathUnsafe,
+			"sandbox tree contains .agency/"+integrationworktree.IntegrationMarkerFileName,
+			map[string]string{
+				"sandbox_path": sandboxPath,
+				"hint":         "remove .agency/" + integrationworktree.IntegrationMarkerFileName + " from the integration branch history",
+			},
+		)
+	}
+
+	// Create .agency/ directory
+	agencyDir := filepath.Join(sandboxPath, ".agency")
+	if err := s.FS.MkdirAll(agencyDir, 0o755); err != nil {
+		cleanup()
+		return nil, errors.WrapWithDetails(
+			errors.ESandboxCreateFailed,
+			"failed to create .agency directory",
+			err,
+			map[string]string{"path": agencyDir},
+		)
+	}
+
+	// Write SANDBOX_MARKER (before meta.json per spec)
+	markerPath := filepath.Join(agencyDir, SandboxMarkerFileName)
+	if err := s.FS.WriteFile(markerPath, []byte(sandboxMarkerContent), 0o644); err != nil {
+		cleanup()
+		return nil, errors.WrapWithDetails(
+			errors.ESandboxCreateFailed,
+			"failed to write SANDBOX_MARKER",
+			err,
+			map[string]string{"path": markerPath},
+		)
+	}
+
+	// Write meta.json
+	meta := store.NewInvocationMeta(
+		invocationID,
+		opts.Name,
+		opts.RepoID,
+		wt.WorktreeID,
+		sandboxPath,
+		branch,
+		baseCommit,
+		opts.Runner,
+		opts.Mode,
+		s.Now(),
+	)
+	meta.RunnerCmd = opts.RunnerCmd
+
+	if err := s.Store.WriteInvocationMeta(opts.RepoID, invocationID, meta); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// StartHeaded starts the runner for an invocation in a detached tmux session
+// whose CWD is the sandbox tree.
+//
+// Refuses to start if the sandbox tree lacks SANDBOX_MARKER or carries
+// INTEGRATION_MARKER. On success, status becomes running and tmux_session is
+// recorded; on tmux failure, status becomes failed.
+func (s *Service) StartHeaded(ctx context.Context, tmuxClient tmux.Client, meta *store.InvocationMeta) error {
+	sandboxPath := meta.SandboxPath
+
+	if integrationworktree.HasIntegrationMarker(sandboxPath) {
+		return errors.NewWithDetails(
+			errors.ESandboxPathUnsafe,
+			"refusing to start runner: path contains .agency/"+integrationworktree.IntegrationMarkerFileName,
+			map[string]string{"sandbox_path": sandboxPath, "invocation_id": meta.InvocationID},
+		)
+	}
+	if !HasSandboxMarker(sandboxPath) {
+		return errors.NewWithDetails(
+			errors.EInvocationBroken,
+			"refusing to start runner: sandbox tree is missing .agency/"+SandboxMarkerFileName,
+			map[string]string{"sandbox_path": sandboxPath, "invocation_id": meta.InvocationID},
+		)
+	}
+
+	sessionName := tmux.SessionName(meta.InvocationID)
+	paneCmd := core.BuildRunnerShellScript(sandboxPath, meta.RunnerCmd)
+
+	if err := tmuxClient.NewSession(ctx, sessionName, sandboxPath, []string{"sh", "-lc", paneCmd}); err != nil {
+		_ = s.Store.UpdateInvocationMeta(meta.RepoID, meta.InvocationID, func(m *store.InvocationMeta) {
+			m.Status = store.InvocationStatusFailed
+			m.ExitReason = "unknown"
+			m.FinishedAt = s.Now().UTC().Format(time.RFC3339)
+		})
+		return errors.WrapWithDetails(
+			errors.ETmuxFailed,
+			"failed to create tmux session",
+			err,
+			map[string]string{"session": sessionName, "invocation_id": meta.InvocationID},
+		)
+	}
+
+	err := s.Store.UpdateInvocationMeta(meta.RepoID, meta.InvocationID, func(m *store.InvocationMeta) {
+		m.Status = store.InvocationStatusRunning
+		m.TmuxSession = sessionName
+	})
+	if err != nil {
+		// Meta write failed, but tmux session was created; best-effort kill
+		_ = tmuxClient.KillSession(ctx, sessionName)
+		return err
+	}
+
+	meta.Status = store.InvocationStatusRunning
+	meta.TmuxSession = sessionName
+	return nil
+}
+
+// Reconcile updates a headed invocation whose tmux session has disappeared.
+// If status is running and the session no longer exists, status becomes finished
+// with exit_reason=exited and finished_at=now. Idempotent; returns the current meta.
+func (s *Service) Reconcile(ctx context.Context, tmuxClient tmux.Client, meta *store.InvocationMeta) (*store.InvocationMeta, error) {
+	if meta == nil || meta.Mode != store.InvocationModeHeaded || meta.Status != store.InvocationStatusRunning || meta.TmuxSession == "" {
+		return meta, nil
+	}
+
+	exists, err := tmuxClient.HasSession(ctx, meta.TmuxSession)
+	if err != nil || exists {
+		// Unknown tmux state is not evidence of exit
+		return meta, nil
+	}
+
+	finishedAt := s.Now().UTC().Format(time.RFC3339)
+	err = s.Store.UpdateInvocationMeta(meta.RepoID, meta.InvocationID, func(m *store.InvocationMeta) {
+		if m.Status != store.InvocationStatusRunning {
+			return
+		}
+		m.Status = store.InvocationStatusFinished
+		m.FinishedAt = finishedAt
+		m.ExitReason = "exited"
+	})
+	if err != nil {
+		return meta, err
+	}
+
+	return s.Store.ReadInvocationMeta(meta.RepoID, meta.InvocationID)
+}
+
+// Resolve resolves an invocation identifier (id or unique prefix) within a repo.
+// Invocation names are never matched.
+func (s *Service) Resolve(repoID, input string) (*store.InvocationRecord, error) {
+	records, err := store.ScanInvocationsForRepo(s.Store.DataDir, repoID)
+	if err != nil {
+		return nil, errors.Wrap(errors.EInternal, "failed to scan invocations", err)
+	}
+	return ResolveRecord(input, records)
+}
+
+// ResolveRecord resolves an invocation identifier against pre-scanned records,
+// converting resolver errors to agency errors.
+func ResolveRecord(input string, records []store.InvocationRecord) (*store.InvocationRecord, error) {
+	refs := make([]ids.InvocationRef, len(records))
+	for i, r := range records {
+		refs[i] = ids.InvocationRef{
+			InvocationID: r.InvocationID,
+			RepoID:       r.RepoID,
+			Broken:       r.Broken,
+		}
+	}
+
+	ref, err := ids.ResolveInvocationRef(input, refs)
+	if err != nil {
+		if _, ok := err.(*ids.ErrInvocationNotFound); ok {
+			return nil, errors.NewWithDetails(
+				errors.EInvocationNotFound,
+				"invocation not found: "+input,
+				map[string]string{"input": input},
+			)
+		}
+		if ambErr, ok := err.(*ids.ErrInvocationAmbiguous); ok {
+			candidates := make([]string, len(ambErr.Candidates))
+			for i, c := range ambErr.Candidates {
+				candidates[i] = c.InvocationID
+			}
+			return nil, errors.NewWithDetails(
+				errors.EInvocationIDAmbiguous,
+				"ambiguous invocation identifier '"+input+"' matches multiple invocations: "+strings.Join(candidates, ", "),
+				map[string]string{"input": input},
+			)
+		}
+		return nil, err
+	}
+
+	for i := range records {
+		if records[i].InvocationID == ref.InvocationID && records[i].RepoID == ref.RepoID {
+			return &records[i], nil
+		}
+	}
+	return nil, errors.NewWithDetails(
+		errors.EInvocationNotFound,
+		"invocation not found: "+input,
+		map[string]string{"input": input},
+	)
+}
@@ -0,0 +1,382 @@
+package invocation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/integrationworktree"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/testutil"
+	"github.com/NielsdaWheelz/agency/internal/tmux"
+)
+
+const testRepoID = "abc123def456"
+
+// testEnv holds a git repo with one integration worktree.
+type testEnv struct {
+	ctx      context.Context
+	cr       exec.CommandRunner
+	repoDir  string
+	st       *store.Store
+	worktree *store.IntegrationWorktreeMeta
+}
+
+// setupTestEnv creates a git repo and an integration worktree.
+// This is an integration test helper that requires git to be installed.
+func setupTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	testutil.HermeticGitEnv(t)
+
+	cr := exec.NewRealRunner()
+	ctx := context.Background()
+
+	if result, err := cr.Run(ctx, "git", []string{"--version"}, exec.RunOpts{}); err != nil || result.ExitCode != 0 {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	dataDir := filepath.Join(tmpDir, "data")
+	if err := os.Mkdir(repoDir, 0o755); err != nil {
+		t.Fatalf("failed to create repo dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("# Test\n"), 0o644); err != nil {
+		t.Fatalf("failed to write readme: %v", err)
+	}
+	// Keep .agency/ out of git so markers are never committed
+	if err := os.WriteFile(filepath.Join(repoDir, ".gitignore"), []byte(".agency/\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"add", "."},
+		{"commit", "-m", "Initial commit"},
+	} {
+		result, err := cr.Run(ctx, "git", args, exec.RunOpts{Dir: repoDir})
+		if err != nil || result.ExitCode != 0 {
+			t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+		}
+	}
+
+	fsys := fs.NewRealFS()
+	st := store.NewStore(fsys, dataDir, time.Now)
+	wtSvc := integrationworktree.NewService(st, cr, fsys, time.Now)
+	created, err := wtSvc.Create(ctx, integrationworktree.CreateOpts{
+		Name:         "feature",
+		RepoRoot:     repoDir,
+		RepoID:       testRepoID,
+		ParentBranch: "main",
+	})
+	if err != nil {
+		t.Fatalf("integration worktree Create() error = %v", err)
+	}
+	wt, err := st.ReadIntegrationWorktreeMeta(testRepoID, created.WorktreeID)
+	if err != nil {
+		t.Fatalf("ReadIntegrationWorktreeMeta() error = %v", err)
+	}
+
+	return &testEnv{ctx: ctx, cr: cr, repoDir: repoDir, st: st, worktree: wt}
+}
+
+func (e *testEnv) createOpts() CreateOpts {
+	return CreateOpts{
+		RepoRoot:  e.repoDir,
+		RepoID:    testRepoID,
+		Worktree:  e.worktree,
+		Name:      "arch-agent",
+		Runner:    "claude",
+		RunnerCmd: "claude",
+		Mode:      store.InvocationModeHeaded,
+	}
+}
+
+func (e *testEnv) git(t *testing.T, args ...string) string {
+	t.Helper()
+	result, err := e.cr.Run(e.ctx, "git", append([]string{"-C", e.repoDir}, args...), exec.RunOpts{})
+	if err != nil || result.ExitCode != 0 {
+		t.Fatalf("git %v failed: %v, stderr: %s", args, err, result.Stderr)
+	}
+	return strings.TrimSpace(result.Stdout)
+}
+
+func TestCreate_SandboxFromIntegrationBranch(t *testing.T) {
+	env := setupTestEnv(t)
+	svc := NewService(env.st, env.cr, fs.NewRealFS(), time.Now)
+	opts := env.createOpts()
+
+	baseCommit := env.git(t, "rev-parse", env.worktree.Branch)
+
+	// Multiple sandboxes per integration worktree
+	var metas []*store.InvocationMeta
+	for i := 0; i < 2; i++ {
+		id, err := svc.Prepare(opts)
+		if err != nil {
+			t.Fatalf("Prepare() error = %v", err)
+		}
+		meta, err := svc.Create(env.ctx, id, opts)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		metas = append(metas, meta)
+	}
+
+	for _, meta := range metas {
+		if meta.BaseCommit != baseCommit {
+			t.Errorf("BaseCommit = %v, want %v", meta.BaseCommit, baseCommit)
+		}
+		if meta.Status != store.InvocationStatusStarting {
+			t.Errorf("Status = %v, want starting", meta.Status)
+		}
+		if meta.SandboxBranch != "agency/sandbox-"+meta.InvocationID {
+			t.Errorf("SandboxBranch = %v", meta.SandboxBranch)
+		}
+		if meta.SandboxPath != env.st.SandboxTreePath(testRepoID, meta.InvocationID) {
+			t.Errorf("SandboxPath = %v", meta.SandboxPath)
+		}
+		if !HasSandboxMarker(meta.SandboxPath) {
+			t.Error("SANDBOX_MARKER not written")
+		}
+		if integrationworktree.HasIntegrationMarker(meta.SandboxPath) {
+			t.Error("sandbox tree must not contain INTEGRATION_MARKER")
+		}
+		if _, err := env.st.ReadInvocationMeta(testRepoID, meta.InvocationID); err != nil {
+			t.Errorf("ReadInvocationMeta() error = %v", err)
+		}
+	}
+
+	// Integration tree is untouched
+	if HasSandboxMarker(env.worktree.TreePath) {
+		t.Error("integration tree must not contain SANDBOX_MARKER")
+	}
+	if !integrationworktree.HasIntegrationMarker(env.worktree.TreePath) {
+		t.Error("integration tree lost INTEGRATION_MARKER")
+	}
+}
+
+func TestPrepare_RefusesNonIntegrationTree(t *testing.T) {
+	env := setupTestEnv(t)
+	svc := NewService(env.st, env.cr, fs.NewRealFS(), time.Now)
+
+	if err := os.Remove(filepath.Join(env.worktree.TreePath, ".agency", integrationworktree.IntegrationMarkerFileName)); err != nil {
+		t.Fatalf("failed to remove marker: %v", err)
+	}
+
+	_, err := svc.Prepare(env.createOpts())
+	if errors.GetCode(err) != errors.ENotIntegrationTree {
+		t.Fatalf("Prepare() error = %v, want %s", err, errors.ENotIntegrationTree)
+	}
+}
+
+func TestCreate_RefusesSandboxPathCollision(t *testing.T) {
+	env := setupTestEnv(t)
+	svc := NewService(env.st, env.cr, fs.NewRealFS(), time.Now)
+	opts := env.createOpts()
+
+	// Force the integration tree to live where the sandbox would be created
+	invocationID := "20260131120500-b7c9"
+	wt := *env.worktree
+	wt.TreePath = env.st.SandboxTreePath(testRepoID, invocationID)
+	opts.Worktree = &wt
+
+	_, err := svc.Create(env.ctx, invocationID, opts)
+	if errors.GetCode(err) != errors.ESandboxPathUnsafe {
+		t.Fatalf("Create() error = %v, want %s", err, errors.ESandboxPathUnsafe)
+	}
+	if _, err := os.Stat(env.st.InvocationDir(testRepoID, invocationID)); !os.IsNotExist(err) {
+		t.Error("invocation dir must not be created on path collision")
+	}
+}
+
+func TestCheckSandboxPathSafe(t *testing.T) {
+	markerDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(markerDir, ".agency"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(markerDir, ".agency", integrationworktree.IntegrationMarkerFileName), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		sandbox     string
+		integration string
+		wantErr     bool
+	}{
+		{"disjoint", "/data/sandboxes/a/tree", "/data/integration_worktrees/b/tree", false},
+		{"sibling with shared prefix", "/data/tree2", "/data/tree", false},
+		{"equal", "/data/tree", "/data/tree/", true},
+		{"child of integration", "/data/tree/sub", "/data/tree", true},
+		{"parent of integration", "/data", "/data/tree", true},
+		{"contains integration marker", markerDir, "/elsewhere/tree", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSandboxPathSafe(tt.sandbox, tt.integration)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckSandboxPathSafe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// failingMarkerFS fails WriteFile for the sandbox marker path.
+type failingMarkerFS struct {
+	*fs.RealFS
+}
+
+func (f failingMarkerFS) WriteFile(path string, data []byte, perm os.FileMode) error {
+	if filepath.Base(path) == SandboxMarkerFileName {
+		return os.ErrPermission
+	}
+	return f.RealFS.WriteFile(path, data, perm)
+}
+
+func TestCreate_CleanupOnMarkerWriteFailure(t *testing.T) {
+	env := setupTestEnv(t)
+	svc := NewService(env.st, env.cr, failingMarkerFS{fs.NewRealFS()}, time.Now)
+	opts := env.createOpts()
+
+	id, err := svc.Prepare(opts)
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	_, err = svc.Create(env.ctx, id, opts)
+	if errors.GetCode(err) != errors.ESandboxCreateFailed {
+		t.Fatalf("Create() error = %v, want %s", err, errors.ESandboxCreateFailed)
+	}
+
+	sandboxPath := env.st.SandboxTreePath(testRepoID, id)
+	if strings.Contains(env.git(t, "worktree", "list"), sandboxPath) {
+		t.Error("sandbox worktree still registered after failed create")
+	}
+	if env.git(t, "branch", "--list", SandboxBranch(id)) != "" {
+		t.Error("sandbox branch still exists after failed create")
+	}
+	if _, err := os.Stat(env.st.InvocationDir(testRepoID, id)); !os.IsNotExist(err) {
+		t.Error("invocation dir still exists after failed create")
+	}
+	if _, err := os.Stat(sandboxPath); !os.IsNotExist(err) {
+		t.Error("sandbox tree still exists after failed create")
+	}
+}
+
+// fakeTmuxClient records tmux calls.
+type fakeTmuxClient struct {
+	sessions      map[string]bool
+	newSessionErr error
+	lastCWD       string
+	lastArgv      []string
+}
+
+func (f *fakeTmuxClient) HasSession(ctx context.Context, name string) (bool, error) {
+	return f.sessions[name], nil
+}
+
+func (f *fakeTmuxClient) NewSession(ctx context.Context, name, cwd string, argv []string) error {
+	if f.newSessionErr != nil {
+		return f.newSessionErr
+	}
+	if f.sessions == nil {
+		f.sessions = make(map[string]bool)
+	}
+	f.sessions[name] = true
+	f.lastCWD = cwd
+	f.lastArgv = argv
+	return nil
+}
+
+func (f *fakeTmuxClient) Attach(ctx context.Context, name string) error { return nil }
+
+func (f *fakeTmuxClient) KillSession(ctx context.Context, name string) error {
+	delete(f.sessions, name)
+	return nil
+}
+
+func (f *fakeTmuxClient) SendKeys(ctx context.Context, name string, keys []tmux.Key) error {
+	return nil
+}
+
+func (f *fakeTmuxClient) SendText(ctx context.Context, name, text string) error {
+	return nil
+}
+
+func TestStartHeadedAndReconcile(t *testing.T) {
+	env := setupTestEnv(t)
+	svc := NewService(env.st, env.cr, fs.NewRealFS(), time.Now)
+	opts := env.createOpts()
+
+	id, err := svc.Prepare(opts)
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	meta, err := svc.Create(env.ctx, id, opts)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	fake := &fakeTmuxClient{}
+	if err := svc.StartHeaded(env.ctx, fake, meta); err != nil {
+		t.Fatalf("StartHeaded() error = %v", err)
+	}
+	if fake.lastCWD != meta.SandboxPath {
+		t.Errorf("tmux cwd = %v, want sandbox %v", fake.lastCWD, meta.SandboxPath)
+	}
+	if len(fake.lastArgv) != 3 || !strings.Contains(fake.lastArgv[2], meta.SandboxPath) {
+		t.Errorf("tmux argv = %v, want sh -lc script cd-ing into sandbox", fake.lastArgv)
+	}
+
+	read, err := env.st.ReadInvocationMeta(testRepoID, id)
+	if err != nil {
+		t.Fatalf("ReadInvocationMeta() error = %v", err)
+	}
+	if read.Status != store.InvocationStatusRunning || read.TmuxSession != tmux.SessionName(id) {
+		t.Errorf("after start: status=%v tmux=%v", read.Status, read.TmuxSession)
+	}
+
+	// Session alive: reconcile is a no-op
+	read, err = svc.Reconcile(env.ctx, fake, read)
+	if err != nil || read.Status != store.InvocationStatusRunning {
+		t.Fatalf("Reconcile() with live session: status=%v err=%v", read.Status, err)
+	}
+
+	// Session gone: reconcile marks finished, idempotently
+	delete(fake.sessions, read.TmuxSession)
+	for i := 0; i < 2; i++ {
+		read, err = svc.Reconcile(env.ctx, fake, read)
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		if read.Status != store.InvocationStatusFinished || read.ExitReason != "exited" || read.FinishedAt == "" {
+			t.Errorf("after reconcile: status=%v exit_reason=%v finished_at=%v", read.Status, read.ExitReason, read.FinishedAt)
+		}
+	}
+}
+
+func TestStartHeaded_RefusesIntegrationTree(t *testing.T) {
+	env := setupTestEnv(t)
+	svc := NewService(env.st, env.cr, fs.NewRealFS(), time.Now)
+
+	// A meta pointing at the integration tree must never start a runner
+	meta := store.NewInvocationMeta("20260131120500-b7c9", "", testRepoID, env.worktree.WorktreeID,
+		env.worktree.TreePath, SandboxBranch("20260131120500-b7c9"), "abc", "claude", store.InvocationModeHeaded, time.Now())
+	meta.RunnerCmd = "claude"
+
+	fake := &fakeTmuxClient{}
+	err := svc.StartHeaded(env.ctx, fake, meta)
+	if errors.GetCode(err) != errors.ESandboxPathUnsafe {
+		t.Fatalf("StartHeaded() error = %v, want %s", err, errors.ESandboxPathUnsafe)
+	}
+	if len(fake.sessions) != 0 {
+		t.Error("tmux session must not be created in integration tree")
+	}
+}
@@ -0,0 +1,91 @@
+// Package lifecycle reconciles recorded run state in meta.json with the
+// observed state of the run's runner.
+//
+// Reconciliation is lazy and idempotent: commands that already look at tmux
+// (ls, show, attach, resume) call it with what they observed, and it records a
+// state transition at most once.
+package lifecycle
+
+import (
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// EventRunnerExited is the event name appended when a runner exit is recorded.
+const EventRunnerExited = "runner_exited"
+
+// NeedsRunnerExit reports whether a tmux run's missing session should be
+// recorded as a runner exit. True iff the run is a tmux run whose session was
+// created, is not archived, has no exit recorded yet, and sessionExists is false.
+func NeedsRunnerExit(meta *store.RunMeta, sessionExists bool) bool {
+	if meta == nil || sessionExists {
+		return false
+	}
+	return meta.Headless == nil &&
+		meta.TmuxSessionName != "" &&
+		meta.RunnerExitedAt == "" &&
+		meta.Archive == nil
+}
+
+// ReconcileRunnerExit records runner_exited_at and exit_reason for a tmux run
+// whose session is gone, and appends a runner_exited event.
+// The condition is re-checked against the current meta.json before writing.
+// Returns the updated meta if an exit was recorded, or nil if nothing changed.
+func ReconcileRunnerExit(st *store.Store, repoID, runID string, sessionExists bool) (*store.RunMeta, error) {
+	current, err := st.ReadMeta(repoID, runID)
+	if err != nil {
+		return nil, err
+	}
+	if !NeedsRunnerExit(current, sessionExists) {
+		return nil, nil
+	}
+
+	now := time.Now
+	if st.Now != nil {
+		now = st.Now
+	}
+	exitedAt := now().UTC().Format(time.RFC3339)
+
+	var updated *store.RunMeta
+	err = st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		if !NeedsRunnerExit(m, sessionExists) {
+			return
+		}
+		m.RunnerExitedAt = exitedAt
+		m.ExitReason = store.ExitReasonSessionGone
+		updated = m
+	})
+	if err != nil || updated == nil {
+		return nil, err
+	}
+
+	// Best-effort event
+	_ = events.AppendEvent(st.EventsPath(repoID, runID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     exitedAt,
+		RepoID:        repoID,
+		RunID:         runID,
+		Event:         EventRunnerExited,
+		Data:          events.RunnerExitedData(updated.TmuxSessionName, updated.ExitReason, exitedAt),
+	})
+	return updated, nil
+}
+
+// RecordRunnerStart records runner_started_at when a new runner is started
+// for the run (resume) and resets the recorded runner exit, so the next exit
+// can be recorded.
+func RecordRunnerStart(st *store.Store, repoID, runID string) error {
+	now := time.Now
+	if st.Now != nil {
+		now = st.Now
+	}
+	startedAt := now().UTC().Format(time.RFC3339)
+	return st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.RunnerStartedAt = startedAt
+		m.RunnerExitedAt = ""
+		m.ExitReason = ""
+		m.RunnerExitCode = nil
+	})
+}
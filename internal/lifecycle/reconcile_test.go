@@ -0,0 +1,219 @@
+package lifecycle
+
+import (
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+func TestNeedsRunnerExit(t *testing.T) {
+	tmuxRun := func() *store.RunMeta {
+		return &store.RunMeta{RunID: "r1", TmuxSessionName: "agency_r1"}
+	}
+
+	tests := []struct {
+		name          string
+		meta          func() *store.RunMeta
+		sessionExists bool
+		want          bool
+	}{
+		{"nil meta", func() *store.RunMeta { return nil }, false, false},
+		{"session exists", tmuxRun, true, false},
+		{"session gone", tmuxRun, false, true},
+		{"session never created", func() *store.RunMeta { return &store.RunMeta{RunID: "r1"} }, false, false},
+		{"already recorded", func() *store.RunMeta {
+			m := tmuxRun()
+			m.RunnerExitedAt = "2026-01-01T00:00:00Z"
+			return m
+		}, false, false},
+		{"archived", func() *store.RunMeta {
+			m := tmuxRun()
+			m.Archive = &store.RunMetaArchive{ArchivedAt: "2026-01-01T00:00:00Z"}
+			return m
+		}, false, false},
+		{"headless", func() *store.RunMeta {
+			m := tmuxRun()
+			m.Headless = &store.RunMetaHeadless{}
+			return m
+		}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NeedsRunnerExit(tt.meta(), tt.sessionExists); got != tt.want {
+				t.Errorf("NeedsRunnerExit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestRun(t *testing.T) *store.Store {
+	t.Helper()
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	st := store.NewStore(fs.NewRealFS(), t.TempDir(), func() time.Time { return now })
+	if _, err := st.EnsureRunDir("repo1", "r1"); err != nil {
+		t.Fatal(err)
+	}
+	meta := &store.RunMeta{SchemaVersion: "1.0", RunID: "r1", RepoID: "repo1", TmuxSessionName: "agency_r1"}
+	if err := st.WriteInitialMeta("repo1", "r1", meta); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestReconcileRunnerExit(t *testing.T) {
+	st := newTestRun(t)
+
+	// Session still present: nothing recorded
+	updated, err := ReconcileRunnerExit(st, "repo1", "r1", true)
+	if err != nil || updated != nil {
+		t.Fatalf("ReconcileRunnerExit(exists) = %+v, %v; want nil, nil", updated, err)
+	}
+
+	updated, err = ReconcileRunnerExit(st, "repo1", "r1", false)
+	if err != nil {
+		t.Fatalf("ReconcileRunnerExit() error = %v", err)
+	}
+	if updated == nil || updated.RunnerExitedAt != "2026-01-10T12:00:00Z" || updated.ExitReason != store.ExitReasonSessionGone {
+		t.Fatalf("ReconcileRunnerExit() = %+v", updated)
+	}
+
+	meta, err := st.ReadMeta("repo1", "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.RunnerExitedAt != "2026-01-10T12:00:00Z" || meta.ExitReason != store.ExitReasonSessionGone {
+		t.Errorf("meta not updated: exited_at=%q reason=%q", meta.RunnerExitedAt, meta.ExitReason)
+	}
+
+	// Idempotent: second call records nothing and emits no second event
+	updated, err = ReconcileRunnerExit(st, "repo1", "r1", false)
+	if err != nil || updated != nil {
+		t.Fatalf("second ReconcileRunnerExit() = %+v, %v; want nil, nil", updated, err)
+	}
+
+	data, err := os.ReadFile(st.EventsPath("repo1", "r1"))
+	if err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	if n := strings.Count(string(data), `"event":"runner_exited"`); n != 1 {
+		t.Errorf("runner_exited events = %d, want 1\n%s", n, data)
+	}
+}
+
+func TestRecordRunnerStart(t *testing.T) {
+	st := newTestRun(t)
+	if _, err := ReconcileRunnerExit(st, "repo1", "r1", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RecordRunnerStart(st, "repo1", "r1"); err != nil {
+		t.Fatalf("RecordRunnerStart() error = %v", err)
+	}
+	meta, err := st.ReadMeta("repo1", "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.RunnerExitedAt != "" || meta.ExitReason != "" {
+		t.Errorf("exit not cleared: exited_at=%q reason=%q", meta.RunnerExitedAt, meta.ExitReason)
+	}
+	if meta.RunnerStartedAt == "" {
+		t.Error("runner_started_at not recorded")
+	}
+
+	// A later exit can be recorded again
+	if updated, err := ReconcileRunnerExit(st, "repo1", "r1", false); err != nil || updated == nil {
+		t.Errorf("ReconcileRunnerExit() after clear = %+v, %v", updated, err)
+	}
+}
+
+func TestRecordRunnerExit(t *testing.T) {
+	st := newTestRun(t)
+
+	if err := RecordRunnerExit(st, "repo1", "r1", 3); err != nil {
+		t.Fatalf("RecordRunnerExit() error = %v", err)
+	}
+	meta, err := st.ReadMeta("repo1", "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.ExitReason != store.ExitReasonExited || meta.RunnerExitCode == nil || *meta.RunnerExitCode != 3 || meta.RunnerExitedAt == "" {
+		t.Errorf("exit = %q %v %q", meta.ExitReason, meta.RunnerExitCode, meta.RunnerExitedAt)
+	}
+	if FormatExitStatus(meta) != "status 3" {
+		t.Errorf("FormatExitStatus() = %q", FormatExitStatus(meta))
+	}
+	if !RunnerInShell(meta, true) || RunnerInShell(meta, false) {
+		t.Error("RunnerInShell() should be true only while the session exists")
+	}
+
+	// 128+n is a signal
+	if err := RecordRunnerExit(st, "repo1", "r1", 130); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ = st.ReadMeta("repo1", "r1")
+	if meta.ExitReason != store.ExitReasonSignaled || *meta.RunnerExitCode != -1 {
+		t.Errorf("exit = %q %v", meta.ExitReason, *meta.RunnerExitCode)
+	}
+
+	data, err := os.ReadFile(st.EventsPath("repo1", "r1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), `"event":"runner_exited"`) != 2 || !strings.Contains(string(data), `"exit_code":3`) {
+		t.Errorf("events.jsonl:\n%s", data)
+	}
+}
+
+func TestRunnerSessionScript(t *testing.T) {
+	dir := t.TempDir()
+	hookLog := filepath.Join(dir, "hook.log")
+	exe := filepath.Join(dir, "agency")
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\necho \"$@\" > '"+hookLog+"'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(dir, "my wt")
+	if err := os.Mkdir(worktree, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := RunnerSessionScript(SessionScriptOpts{
+		Executable:   exe,
+		DataDir:      "/data",
+		RepoID:       "repo1",
+		RunID:        "r1",
+		RunRef:       "feature",
+		WorktreePath: worktree,
+		RunnerCmd:    "pwd; exit 3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A subshell stands in for the runner; SHELL=true ends the fallback shell
+	cmd := osexec.Command("sh", "-c", strings.Replace(script, "pwd; exit 3", "(pwd; exit 3)", 1))
+	cmd.Env = append(os.Environ(), "SHELL=true")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), worktree+"\n") {
+		t.Errorf("runner did not run in the worktree:\n%s", out)
+	}
+	if !strings.Contains(string(out), "runner exited with status 3") || !strings.Contains(string(out), "agency resume feature") {
+		t.Errorf("banner missing:\n%s", out)
+	}
+	hook, err := os.ReadFile(hookLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(hook)); got != "runner-exited --data-dir /data --repo-id repo1 --run-id r1 --status 3" {
+		t.Errorf("hook args = %q", got)
+	}
+}
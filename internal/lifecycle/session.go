@@ -0,0 +1,126 @@
+package lifecycle
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/core"
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/events"
+	"github.com/NielsdaWheelz/agency/internal/store"
+)
+
+// RunnerExitedCommand is the hidden agency subcommand the session wrapper
+// calls when the runner exits.
+const RunnerExitedCommand = "runner-exited"
+
+// SessionScriptOpts configures RunnerSessionScript.
+type SessionScriptOpts struct {
+	// Executable is the agency binary the wrapper calls to record the exit
+	// (default: os.Executable()).
+	Executable string
+
+	// DataDir, RepoID and RunID identify the run.
+	DataDir string
+	RepoID  string
+	RunID   string
+
+	// RunRef is the run reference shown in the exit banner (name or run_id).
+	RunRef string
+
+	// WorktreePath is the directory the runner and the fallback shell run in.
+	WorktreePath string
+
+	// RunnerCmd is the runner command (a shell snippet, run verbatim).
+	RunnerCmd string
+}
+
+// RunnerSessionScript returns the `sh -lc` script for a run's tmux pane.
+// The script runs the runner (not exec'd), records its exit status in
+// meta.json, prints a banner, and then replaces itself with a login shell in
+// the worktree, so the session, terminal and scrollback outlive the runner.
+func RunnerSessionScript(opts SessionScriptOpts) (string, error) {
+	exe := opts.Executable
+	if exe == "" {
+		var err error
+		exe, err = os.Executable()
+		if err != nil {
+			return "", errors.Wrap(errors.EInternal, "failed to locate agency executable", err)
+		}
+	}
+
+	hook := strings.Join([]string{
+		core.ShellEscapePosix(exe), RunnerExitedCommand,
+		"--data-dir", core.ShellEscapePosix(opts.DataDir),
+		"--repo-id", core.ShellEscapePosix(opts.RepoID),
+		"--run-id", core.ShellEscapePosix(opts.RunID),
+		"--status", `"$agency_status"`,
+	}, " ")
+	banner := "\\n[agency] runner exited with status %s; this shell is in the run worktree.\\n" +
+		"[agency] relaunch the runner with: agency resume " + opts.RunRef + "\\n\\n"
+
+	return strings.Join([]string{
+		"cd " + core.ShellEscapePosix(opts.WorktreePath) + " || exit 1",
+		opts.RunnerCmd,
+		"agency_status=$?",
+		hook + " >/dev/null 2>&1",
+		"printf " + core.ShellEscapePosix(banner) + ` "$agency_status"`,
+		`exec "${SHELL:-/bin/sh}" -l`,
+	}, "\n"), nil
+}
+
+// RecordRunnerExit records the exit of a tmux run's runner, as reported by
+// the session wrapper's shell status (128+n means killed by signal n), and
+// appends a runner_exited event.
+func RecordRunnerExit(st *store.Store, repoID, runID string, shellStatus int) error {
+	code, reason := shellStatus, store.ExitReasonExited
+	if shellStatus > 128 {
+		code, reason = -1, store.ExitReasonSignaled
+	}
+
+	now := time.Now
+	if st.Now != nil {
+		now = st.Now
+	}
+	exitedAt := now().UTC().Format(time.RFC3339)
+
+	var updated *store.RunMeta
+	err := st.UpdateMeta(repoID, runID, func(m *store.RunMeta) {
+		m.RunnerExitCode = &code
+		m.ExitReason = reason
+		m.RunnerExitedAt = exitedAt
+		updated = m
+	})
+	if err != nil {
+		return err
+	}
+
+	data := events.RunnerExitedData(updated.TmuxSessionName, reason, exitedAt)
+	data["exit_code"] = code
+	_ = events.AppendEvent(st.EventsPath(repoID, runID), events.Event{
+		SchemaVersion: "1.0",
+		Timestamp:     exitedAt,
+		RepoID:        repoID,
+		RunID:         runID,
+		Event:         EventRunnerExited,
+		Data:          data,
+	})
+	return nil
+}
+
+// RunnerInShell reports whether a tmux run's runner has exited while its
+// session lives on as a shell in the worktree.
+func RunnerInShell(meta *store.RunMeta, sessionExists bool) bool {
+	return meta != nil && sessionExists && meta.Headless == nil &&
+		meta.RunnerExitedAt != "" && meta.ExitReason != store.ExitReasonSessionGone
+}
+
+// FormatExitStatus describes a recorded runner exit, e.g. "status 0" or "signaled".
+func FormatExitStatus(meta *store.RunMeta) string {
+	if meta.ExitReason == store.ExitReasonExited && meta.RunnerExitCode != nil {
+		return "status " + strconv.Itoa(*meta.RunnerExitCode)
+	}
+	return meta.ExitReason
+}
@@ -0,0 +1,41 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// ErrHeld indicates an exclusive file lock is held by another process.
+var ErrHeld = errors.New("lock is held by another process")
+
+// LockFile takes an exclusive flock on path, creating the file if needed and
+// blocking until the lock is free. The lock is released by unlock, or by the
+// kernel when the process exits, so it is never left stale.
+func LockFile(path string) (unlock func() error, err error) {
+	return lockFile(path, syscall.LOCK_EX)
+}
+
+// TryLockFile is LockFile without blocking: it returns ErrHeld if another
+// process holds the lock.
+func TryLockFile(path string) (unlock func() error, err error) {
+	return lockFile(path, syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+func lockFile(path string, how int) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrHeld
+		}
+		return nil, err
+	}
+	return func() error {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		return f.Close()
+	}, nil
+}
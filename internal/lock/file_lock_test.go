@@ -0,0 +1,28 @@
+package lock
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestTryLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.lock")
+
+	unlock, err := TryLockFile(path)
+	if err != nil {
+		t.Fatalf("TryLockFile() error = %v", err)
+	}
+	if _, err := TryLockFile(path); !errors.Is(err, ErrHeld) {
+		t.Fatalf("second TryLockFile() error = %v, want ErrHeld", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock() error = %v", err)
+	}
+
+	unlock, err = TryLockFile(path)
+	if err != nil {
+		t.Fatalf("TryLockFile() after unlock error = %v", err)
+	}
+	_ = unlock()
+}
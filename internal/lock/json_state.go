@@ -0,0 +1,38 @@
+package lock
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// UpdateJSON updates the JSON state file at path under an exclusive flock, so
+// concurrent updaters take turns and each sees the previous one's record. The
+// file is decoded into v (found reports whether it held a valid record; a
+// missing, empty or corrupt file does not) and update is called; if it
+// returns true, v is written back. The file and its directory are created as
+// needed.
+func UpdateJSON(path string, v any, update func(found bool) bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	unlock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unlock() }()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	found := len(data) > 0 && json.Unmarshal(data, v) == nil
+	if !update(found) {
+		return nil
+	}
+	data, err = json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,51 @@
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "notify_state.json")
+	type record struct {
+		Event string `json:"event"`
+	}
+
+	// A missing file is created with the updated record
+	var rec record
+	err := UpdateJSON(path, &rec, func(found bool) bool {
+		if found {
+			t.Error("found = true for a missing file")
+		}
+		rec.Event = "needs_input"
+		return true
+	})
+	if err != nil {
+		t.Fatalf("UpdateJSON() error = %v", err)
+	}
+
+	// The next update sees it; returning false leaves the file alone
+	rec = record{}
+	_ = UpdateJSON(path, &rec, func(found bool) bool {
+		if !found || rec.Event != "needs_input" {
+			t.Errorf("found = %v, record = %+v", found, rec)
+		}
+		rec.Event = "ignored"
+		return false
+	})
+	if data, _ := os.ReadFile(path); string(data) != "{\"event\":\"needs_input\"}\n" {
+		t.Errorf("state file = %q", data)
+	}
+
+	// A corrupt file is not a record
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = UpdateJSON(path, &record{}, func(found bool) bool {
+		if found {
+			t.Error("found = true for a corrupt file")
+		}
+		return false
+	})
+}
@@ -0,0 +1,196 @@
+// Package mcp implements a minimal Model Context Protocol server over stdio:
+// newline-delimited JSON-RPC 2.0 with the initialize handshake and the tools
+// capability. The tools themselves are supplied by the caller.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the latest MCP protocol revision this server speaks.
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions lists the protocol revisions accepted from clients.
+var supportedVersions = map[string]bool{
+	"2024-11-05": true,
+	"2025-03-26": true,
+	"2025-06-18": true,
+}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// maxMessageSize bounds a single JSON-RPC message.
+const maxMessageSize = 4 << 20
+
+// Tool is a callable tool exposed to the client.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+
+	// Handler runs the tool with the raw JSON arguments and returns its text
+	// result. A returned error is reported to the client as a tool error
+	// (isError), not a protocol error.
+	Handler func(ctx context.Context, args json.RawMessage) (string, error) `json:"-"`
+}
+
+// Server serves tools to a single client.
+type Server struct {
+	Name         string
+	Version      string
+	Instructions string
+	Tools        []Tool
+}
+
+// request is an incoming JSON-RPC request or notification (no id).
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is an outgoing JSON-RPC response.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// textContent is an MCP text content block.
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callResult is the result of tools/call.
+type callResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// Serve reads requests from in and writes responses to out until in is closed
+// or ctx is done. Requests are handled one at a time.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	enc := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := enc.Encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		// Notifications (no id) never get a response
+		if len(req.ID) == 0 {
+			continue
+		}
+
+		resp := response{JSONRPC: "2.0", ID: req.ID}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			resp.Error = &rpcError{Code: codeInvalidRequest, Message: "invalid request"}
+		} else {
+			resp.Result, resp.Error = s.handle(ctx, req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// handle dispatches a single request.
+func (s *Server) handle(ctx context.Context, req request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := ProtocolVersion
+		if supportedVersions[params.ProtocolVersion] {
+			version = params.ProtocolVersion
+		}
+		result := map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": s.Name, "version": s.Version},
+		}
+		if s.Instructions != "" {
+			result["instructions"] = s.Instructions
+		}
+		return result, nil
+
+	case "ping":
+		return map[string]any{}, nil
+
+	case "tools/list":
+		tools := s.Tools
+		if tools == nil {
+			tools = []Tool{}
+		}
+		return map[string]any{"tools": tools}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+		tool := s.tool(params.Name)
+		if tool == nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
+		}
+		args := params.Arguments
+		if len(args) == 0 || string(args) == "null" {
+			args = json.RawMessage("{}")
+		}
+		text, err := tool.Handler(ctx, args)
+		if err != nil {
+			return callResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return callResult{Content: []textContent{{Type: "text", Text: text}}}, nil
+
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// tool returns the named tool, or nil.
+func (s *Server) tool(name string) *Tool {
+	for i := range s.Tools {
+		if s.Tools[i].Name == name {
+			return &s.Tools[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testServer() *Server {
+	return &Server{
+		Name:    "test",
+		Version: "1.0",
+		Tools: []Tool{
+			{
+				Name:        "echo",
+				Description: "echo the message",
+				InputSchema: map[string]any{"type": "object"},
+				Handler: func(ctx context.Context, args json.RawMessage) (string, error) {
+					var in struct {
+						Message string `json:"message"`
+					}
+					if err := json.Unmarshal(args, &in); err != nil {
+						return "", err
+					}
+					if in.Message == "" {
+						return "", errors.New("message is required")
+					}
+					return in.Message, nil
+				},
+			},
+		},
+	}
+}
+
+// serveLines runs the server over the given input lines and returns the
+// decoded responses.
+func serveLines(t *testing.T, s *Server, lines ...string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	var resps []map[string]any
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r map[string]any
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		resps = append(resps, r)
+	}
+	return resps
+}
+
+func TestServe_Handshake(t *testing.T) {
+	resps := serveLines(t, testServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"c","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+	)
+	if len(resps) != 3 {
+		t.Fatalf("got %d responses, want 3 (notifications are not answered): %v", len(resps), resps)
+	}
+	result := resps[0]["result"].(map[string]any)
+	if result["protocolVersion"] != "2025-03-26" {
+		t.Errorf("protocolVersion = %v, want the client's supported version", result["protocolVersion"])
+	}
+	if _, ok := result["capabilities"].(map[string]any)["tools"]; !ok {
+		t.Errorf("capabilities = %v, want tools", result["capabilities"])
+	}
+	if resps[1]["id"].(float64) != 2 || resps[1]["error"] != nil {
+		t.Errorf("ping response = %v", resps[1])
+	}
+	if v := resps[2]["result"].(map[string]any)["protocolVersion"]; v != ProtocolVersion {
+		t.Errorf("unsupported client version: protocolVersion = %v, want %s", v, ProtocolVersion)
+	}
+}
+
+func TestServe_Tools(t *testing.T) {
+	resps := serveLines(t, testServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"nope"}}`,
+	)
+	if len(resps) != 4 {
+		t.Fatalf("got %d responses, want 4", len(resps))
+	}
+
+	tools := resps[0]["result"].(map[string]any)["tools"].([]any)
+	if len(tools) != 1 || tools[0].(map[string]any)["name"] != "echo" || tools[0].(map[string]any)["inputSchema"] == nil {
+		t.Errorf("tools/list = %v", tools)
+	}
+
+	ok := resps[1]["result"].(map[string]any)
+	if ok["isError"] != nil || ok["content"].([]any)[0].(map[string]any)["text"] != "hi" {
+		t.Errorf("tools/call echo = %v", ok)
+	}
+
+	failed := resps[2]["result"].(map[string]any)
+	if failed["isError"] != true || failed["content"].([]any)[0].(map[string]any)["text"] != "message is required" {
+		t.Errorf("tools/call with a failing handler = %v, want isError result", failed)
+	}
+
+	if e := resps[3]["error"].(map[string]any); e["code"].(float64) != codeInvalidParams {
+		t.Errorf("unknown tool error = %v", e)
+	}
+}
+
+func TestServe_Errors(t *testing.T) {
+	resps := serveLines(t, testServer(),
+		`not json`,
+		`{"jsonrpc":"2.0","id":"a","method":"resources/list"}`,
+		`{"jsonrpc":"1.0","id":"b","method":"ping"}`,
+	)
+	want := []float64{codeParseError, codeMethodNotFound, codeInvalidRequest}
+	if len(resps) != len(want) {
+		t.Fatalf("got %d responses, want %d", len(resps), len(want))
+	}
+	for i, code := range want {
+		e, _ := resps[i]["error"].(map[string]any)
+		if e == nil || e["code"].(float64) != code {
+			t.Errorf("response %d = %v, want error code %v", i, resps[i], code)
+		}
+	}
+	if resps[1]["id"] != "a" {
+		t.Errorf("string ids must be echoed: got %v", resps[1]["id"])
+	}
+}
@@ -0,0 +1,74 @@
+// Package monitor spawns a run's monitor: the detached process that, while
+// the run's runner is alive, enforces its budgets, auto-verifies group
+// attempts, records runner status history and sends notifications. It is
+// separate from the checkpoint watcher, so none of that depends on
+// checkpointing working.
+package monitor
+
+import (
+	"os"
+	osexec "os/exec"
+	"syscall"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+)
+
+// Command is the hidden agency subcommand that runs the monitor.
+const Command = "run-monitor"
+
+// SpawnOpts configures a monitor spawn.
+type SpawnOpts struct {
+	// Executable is the agency binary to re-exec (default: os.Executable()).
+	Executable string
+
+	// DataDir, RepoID and RunID identify the run to monitor.
+	DataDir string
+	RepoID  string
+	RunID   string
+
+	// LogPath receives the monitor's output (logs/monitor.log).
+	LogPath string
+}
+
+// Spawn starts a detached monitor for the run in its own session and
+// returns its pid. The caller never waits for it.
+func Spawn(opts SpawnOpts) (int, error) {
+	exe := opts.Executable
+	if exe == "" {
+		var err error
+		exe, err = os.Executable()
+		if err != nil {
+			return 0, errors.Wrap(errors.EInternal, "failed to locate agency executable", err)
+		}
+	}
+
+	logFile, err := os.OpenFile(opts.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, errors.WrapWithDetails(errors.EInternal, "failed to open monitor log", err,
+			map[string]string{"log_path": opts.LogPath})
+	}
+	defer func() { _ = logFile.Close() }()
+
+	devnull, err := os.Open(os.DevNull)
+	if err != nil {
+		return 0, errors.Wrap(errors.EInternal, "failed to open /dev/null", err)
+	}
+	defer func() { _ = devnull.Close() }()
+
+	cmd := osexec.Command(exe, Command,
+		"--data-dir", opts.DataDir,
+		"--repo-id", opts.RepoID,
+		"--run-id", opts.RunID,
+	)
+	cmd.Stdin = devnull
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, errors.WrapWithDetails(errors.EInternal, "failed to start run monitor", err,
+			map[string]string{"log_path": opts.LogPath})
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
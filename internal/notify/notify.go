@@ -0,0 +1,207 @@
+// Package notify delivers run state transition notifications to the sinks
+// configured in the user config: a shell command and/or a local webhook.
+//
+// Transitions are observed by the callers that already derive run status
+// (the run monitor, the daemon, verify, merge); a per-run state file makes each
+// transition fire once no matter how many observers see it.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/lock"
+	"github.com/NielsdaWheelz/agency/internal/status"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/verifyservice"
+)
+
+// Timeouts for the notification sinks.
+const (
+	CommandTimeout = 10 * time.Second
+	WebhookTimeout = 5 * time.Second
+)
+
+// EventFor returns the notification event for a derived status, or "" if the
+// status is not notifiable. Only verify failures notify among needs attention
+// reasons.
+func EventFor(derivedStatus string, meta *store.RunMeta) string {
+	switch derivedStatus {
+	case status.StatusNeedsInput:
+		return config.NotifyNeedsInput
+	case status.StatusBlocked:
+		return config.NotifyBlocked
+	case status.StatusReadyForReview:
+		return config.NotifyReadyForReview
+	case status.StatusStalled:
+		return config.NotifyStalled
+	case status.StatusMerged:
+		return config.NotifyPRMerged
+	case status.StatusNeedsAttention:
+		if meta != nil && meta.Flags != nil && meta.Flags.NeedsAttentionReason == verifyservice.NeedsAttentionReasonVerifyFailed {
+			return config.NotifyVerifyFailed
+		}
+	}
+	return ""
+}
+
+// Payload is the notification body: POSTed to the webhook as JSON and passed
+// to the command as AGENCY_NOTIFY_PAYLOAD.
+type Payload struct {
+	SchemaVersion string `json:"schema_version"`
+	Event         string `json:"event"`
+	Timestamp     string `json:"timestamp"` // RFC3339
+	RepoID        string `json:"repo_id"`
+	RunID         string `json:"run_id"`
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	Summary       string `json:"summary,omitempty"`
+	PRURL         string `json:"pr_url,omitempty"`
+	Message       string `json:"message"`
+}
+
+// NewPayload builds the payload for event on the run described by meta.
+func NewPayload(event string, meta *store.RunMeta, derivedStatus, summary string, now time.Time) Payload {
+	return Payload{
+		SchemaVersion: "1.0",
+		Event:         event,
+		Timestamp:     now.UTC().Format(time.RFC3339),
+		RepoID:        meta.RepoID,
+		RunID:         meta.RunID,
+		Name:          meta.Name,
+		Status:        derivedStatus,
+		Summary:       summary,
+		PRURL:         meta.PRURL,
+		Message:       message(event, meta.Name, summary),
+	}
+}
+
+// message returns a one-line human-readable notification text.
+func message(event, name, summary string) string {
+	var text string
+	switch event {
+	case config.NotifyNeedsInput:
+		text = name + " needs input"
+	case config.NotifyBlocked:
+		text = name + " is blocked"
+	case config.NotifyReadyForReview:
+		text = name + " is ready for review"
+	case config.NotifyStalled:
+		text = name + " has stalled"
+	case config.NotifyVerifyFailed:
+		text = name + " failed verify"
+	case config.NotifyPRMerged:
+		text = name + " was merged"
+	default:
+		text = name + ": " + event
+	}
+	if summary != "" {
+		text += ": " + summary
+	}
+	return text
+}
+
+// state is the dedup record stored in the run's notify_state.json.
+type state struct {
+	Event      string `json:"event"`
+	Key        string `json:"key,omitempty"`
+	NotifiedAt string `json:"notified_at,omitempty"`
+}
+
+// Observe records that the run is in event ("" = not notifiable) and reports
+// whether this is a new transition that should be notified. key distinguishes
+// repeated entries into the same event (e.g. a new runner status report);
+// leaving a notifiable state resets the record so re-entering it fires again.
+// The state file is locked so concurrent observers notify once.
+func Observe(path, event, key string, now time.Time) (bool, error) {
+	var rec state
+	fire := false
+	err := lock.UpdateJSON(path, &rec, func(bool) bool {
+		if rec.Event == event && rec.Key == key {
+			return false
+		}
+		rec = state{Event: event, Key: key}
+		if event != "" {
+			rec.NotifiedAt = now.UTC().Format(time.RFC3339)
+		}
+		fire = event != ""
+		return true
+	})
+	if err != nil {
+		return false, err
+	}
+	return fire, nil
+}
+
+// Send delivers p to every configured sink. Both sinks are attempted; the
+// first error is returned.
+func Send(ctx context.Context, cr agencyexec.CommandRunner, cfg config.UserNotify, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	if cfg.Command != "" {
+		if err := runCommand(ctx, cr, cfg.Command, p, body); err != nil {
+			firstErr = err
+		}
+	}
+	if cfg.Webhook != "" {
+		if err := postWebhook(ctx, cfg.Webhook, body); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// runCommand runs the notify command via sh -c with the payload in its env.
+func runCommand(ctx context.Context, cr agencyexec.CommandRunner, command string, p Payload, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
+	defer cancel()
+	result, err := cr.Run(ctx, "sh", []string{"-c", command}, agencyexec.RunOpts{
+		Env: map[string]string{
+			"AGENCY_EVENT":          p.Event,
+			"AGENCY_REPO_ID":        p.RepoID,
+			"AGENCY_RUN_ID":         p.RunID,
+			"AGENCY_NAME":           p.Name,
+			"AGENCY_STATUS":         p.Status,
+			"AGENCY_MESSAGE":        p.Message,
+			"AGENCY_NOTIFY_PAYLOAD": string(body),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("notify command: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("notify command exited %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	return nil
+}
+
+// postWebhook POSTs body to url as application/json.
+func postWebhook(ctx context.Context, url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify webhook: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify webhook: %s returned %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/config"
+	"github.com/NielsdaWheelz/agency/internal/exec"
+	"github.com/NielsdaWheelz/agency/internal/status"
+	"github.com/NielsdaWheelz/agency/internal/store"
+	"github.com/NielsdaWheelz/agency/internal/verifyservice"
+)
+
+// stubRunner records commands and returns result.
+type stubRunner struct {
+	calls  []stubCall
+	result exec.CmdResult
+}
+
+type stubCall struct {
+	Name string
+	Args []string
+	Opts exec.RunOpts
+}
+
+func (s *stubRunner) Run(ctx context.Context, name string, args []string, opts exec.RunOpts) (exec.CmdResult, error) {
+	s.calls = append(s.calls, stubCall{Name: name, Args: args, Opts: opts})
+	return s.result, nil
+}
+
+func (s *stubRunner) LookPath(file string) (string, error) {
+	return "/usr/bin/" + file, nil
+}
+
+func TestEventFor(t *testing.T) {
+	verifyFailed := &store.RunMeta{Flags: &store.RunMetaFlags{NeedsAttention: true, NeedsAttentionReason: verifyservice.NeedsAttentionReasonVerifyFailed}}
+	otherAttention := &store.RunMeta{Flags: &store.RunMetaFlags{NeedsAttention: true, NeedsAttentionReason: "budget_exceeded"}}
+
+	tests := []struct {
+		status string
+		meta   *store.RunMeta
+		want   string
+	}{
+		{status.StatusNeedsInput, &store.RunMeta{}, config.NotifyNeedsInput},
+		{status.StatusBlocked, &store.RunMeta{}, config.NotifyBlocked},
+		{status.StatusReadyForReview, &store.RunMeta{}, config.NotifyReadyForReview},
+		{status.StatusStalled, &store.RunMeta{}, config.NotifyStalled},
+		{status.StatusMerged, &store.RunMeta{}, config.NotifyPRMerged},
+		{status.StatusNeedsAttention, verifyFailed, config.NotifyVerifyFailed},
+		{status.StatusNeedsAttention, otherAttention, ""},
+		{status.StatusWorking, &store.RunMeta{}, ""},
+		{status.StatusActive, &store.RunMeta{}, ""},
+		{status.StatusBroken, nil, ""},
+	}
+	for _, tt := range tests {
+		if got := EventFor(tt.status, tt.meta); got != tt.want {
+			t.Errorf("EventFor(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "notify_state.json")
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		event, key string
+		want       bool
+	}{
+		{"", "", false}, // working: nothing to notify
+		{config.NotifyNeedsInput, "which db?", true}, // transition
+		{config.NotifyNeedsInput, "which db?", false},
+		{config.NotifyNeedsInput, "which port?", true}, // new question
+		{"", "", false}, // answered, working again
+		{config.NotifyNeedsInput, "which port?", true}, // re-entered
+		{config.NotifyReadyForReview, "done", true},
+		{config.NotifyReadyForReview, "done", false},
+	}
+	for i, s := range steps {
+		got, err := Observe(path, s.event, s.key, now)
+		if err != nil {
+			t.Fatalf("step %d: Observe() error = %v", i, err)
+		}
+		if got != s.want {
+			t.Errorf("step %d: Observe(%q, %q) = %v, want %v", i, s.event, s.key, got, s.want)
+		}
+	}
+}
+
+func TestSend_Command(t *testing.T) {
+	meta := &store.RunMeta{RepoID: "abcd1234ef567890", RunID: "20260110120000-a3f2", Name: "auth-fix"}
+	p := NewPayload(config.NotifyNeedsInput, meta, status.StatusNeedsInput, "which db?", time.Now())
+	cr := &stubRunner{}
+
+	if err := Send(context.Background(), cr, config.UserNotify{Command: `notify-send agency "$AGENCY_MESSAGE"`}, p); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(cr.calls) != 1 || cr.calls[0].Name != "sh" || cr.calls[0].Args[1] != `notify-send agency "$AGENCY_MESSAGE"` {
+		t.Fatalf("calls = %+v, want one sh -c", cr.calls)
+	}
+	env := cr.calls[0].Opts.Env
+	if env["AGENCY_EVENT"] != "needs_input" || env["AGENCY_NAME"] != "auth-fix" || env["AGENCY_MESSAGE"] != "auth-fix needs input: which db?" {
+		t.Errorf("env = %v", env)
+	}
+	var got Payload
+	if err := json.Unmarshal([]byte(env["AGENCY_NOTIFY_PAYLOAD"]), &got); err != nil || got != p {
+		t.Errorf("AGENCY_NOTIFY_PAYLOAD = %s (err = %v)", env["AGENCY_NOTIFY_PAYLOAD"], err)
+	}
+
+	cr.result = exec.CmdResult{ExitCode: 1, Stderr: "notify-send: not found\n"}
+	if err := Send(context.Background(), cr, config.UserNotify{Command: "notify-send"}, p); err == nil {
+		t.Error("Send() error = nil for a failing command")
+	}
+}
+
+func TestSend_Webhook(t *testing.T) {
+	var got Payload
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	meta := &store.RunMeta{RepoID: "abcd1234ef567890", RunID: "20260110120000-a3f2", Name: "auth-fix", PRURL: "https://github.com/o/r/pull/7"}
+	p := NewPayload(config.NotifyPRMerged, meta, status.StatusMerged, "", time.Now())
+	if err := Send(context.Background(), &stubRunner{}, config.UserNotify{Webhook: srv.URL}, p); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if contentType != "application/json" || got != p || got.Message != "auth-fix was merged" {
+		t.Errorf("webhook got %+v (content type %q)", got, contentType)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := Send(context.Background(), &stubRunner{}, config.UserNotify{Webhook: failing.URL}, p); err == nil {
+		t.Error("Send() error = nil for a 500 response")
+	}
+}
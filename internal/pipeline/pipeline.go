@@ -24,6 +24,28 @@ type RunPipelineOpts struct {
 
 	// Attach indicates whether to attach to tmux after creation (used in later PRs).
 	Attach bool
+
+	// Headless runs the runner as a supervised subprocess instead of in tmux.
+	Headless bool
+
+	// Prompt is the prompt text fed to a headless runner on stdin, or passed to
+	// a tmux runner as its first message (may be empty for tmux runs).
+	Prompt string
+
+	// GroupID and GroupAttempt place the run in a best-of-N group (empty/0 = none).
+	GroupID      string
+	GroupAttempt int
+
+	// RunID starts an already queued run (empty = generate a new run_id).
+	RunID string
+
+	// MaxDuration and MaxStall override the agency.json budgets (0 = use agency.json).
+	MaxDuration time.Duration
+	MaxStall    time.Duration
+
+	// Dir is a directory inside the repo to create the run in (empty = the
+	// process's working directory).
+	Dir string
 }
 
 // Warning represents a non-fatal warning emitted during pipeline execution.
@@ -39,10 +61,23 @@ type Warning struct {
 // Fields are populated by steps as they execute.
 type PipelineState struct {
 	// From opts (copied at start)
-	Name   string
-	Runner string
-	Parent string
-	Attach bool
+	Name     string
+	Runner   string
+	Parent   string
+	Attach   bool
+	Headless bool
+	Prompt   string
+
+	// Best-of-N group (from opts; empty/0 = none)
+	GroupID      string
+	GroupAttempt int
+
+	// Runtime budgets (from opts, then defaulted from agency.json by LoadAgencyConfig)
+	MaxDuration time.Duration
+	MaxStall    time.Duration
+
+	// Directory the repo is resolved from (from opts; empty = working directory)
+	Dir string
 
 	// Generated immediately
 	RunID string
@@ -89,6 +124,9 @@ type RunService interface {
 
 	// StartTmux creates the tmux session with the runner command
 	StartTmux(ctx context.Context, st *PipelineState) error
+
+	// StartHeadless spawns the supervised headless runner (replaces StartTmux for --headless)
+	StartHeadless(ctx context.Context, st *PipelineState) error
 }
 
 // Pipeline orchestrates the execution of run steps in a fixed order.
@@ -116,10 +154,10 @@ func (p *Pipeline) SetNowFunc(fn func() time.Time) {
 //  3. CreateWorktree
 //  4. WriteMeta
 //  5. RunSetup
-//  6. StartTmux
+//  6. StartTmux (or StartHeadless when opts.Headless is set)
 //
 // Behavior:
-//   - Generates run_id immediately and stores it in state
+//   - Generates run_id immediately (unless opts.RunID is set) and stores it in state
 //   - Executes steps in order; short-circuits on first error
 //   - If error is *AgencyError, preserves code/message/details exactly
 //   - If error is not *AgencyError, wraps into *AgencyError with:
@@ -129,20 +167,32 @@ func (p *Pipeline) SetNowFunc(fn func() time.Time) {
 func (p *Pipeline) Run(ctx context.Context, opts RunPipelineOpts) (string, error) {
 	// Initialize state with opts
 	st := &PipelineState{
-		Name:   opts.Name,
-		Runner: opts.Runner,
-		Parent: opts.Parent,
-		Attach: opts.Attach,
+		Name:     opts.Name,
+		Runner:   opts.Runner,
+		Parent:   opts.Parent,
+		Attach:   opts.Attach,
+		Headless: opts.Headless,
+		Prompt:   opts.Prompt,
+
+		GroupID:      opts.GroupID,
+		GroupAttempt: opts.GroupAttempt,
+
+		MaxDuration: opts.MaxDuration,
+		MaxStall:    opts.MaxStall,
+
+		Dir: opts.Dir,
 	}
 
-	// Generate run_id immediately
-	now := p.nowFunc()
-	runID, err := core.NewRunID(now)
-	if err != nil {
-		// Extremely rare: crypto/rand failure
-		return "", errors.Wrap(errors.EInternal, "failed to generate run_id", err)
+	// Generate run_id immediately (queued runs already have one)
+	st.RunID = opts.RunID
+	if st.RunID == "" {
+		runID, err := core.NewRunID(p.nowFunc())
+		if err != nil {
+			// Extremely rare: crypto/rand failure
+			return "", errors.Wrap(errors.EInternal, "failed to generate run_id", err)
+		}
+		st.RunID = runID
 	}
-	st.RunID = runID
 
 	// Validate name (required and must match pattern)
 	if err := core.ValidateName(st.Name); err != nil {
@@ -170,6 +220,13 @@ func (p *Pipeline) Run(ctx context.Context, opts RunPipelineOpts) (string, error
 		return st.RunID, wrapStepError(err, StepRunSetup)
 	}
 
+	if st.Headless {
+		if err := p.svc.StartHeadless(ctx, st); err != nil {
+			return st.RunID, wrapStepError(err, StepStartHeadless)
+		}
+		return st.RunID, nil
+	}
+
 	if err := p.svc.StartTmux(ctx, st); err != nil {
 		return st.RunID, wrapStepError(err, StepStartTmux)
 	}
@@ -207,4 +264,5 @@ const (
 	StepWriteMeta        = "WriteMeta"
 	StepRunSetup         = "RunSetup"
 	StepStartTmux        = "StartTmux"
+	StepStartHeadless    = "StartHeadless"
 )
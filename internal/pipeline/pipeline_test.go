@@ -24,6 +24,7 @@ type mockRunService struct {
 	writeMetaErr        error
 	runSetupErr         error
 	startTmuxErr        error
+	startHeadlessErr    error
 
 	// Track which methods were called
 	called []string
@@ -59,6 +60,11 @@ func (m *mockRunService) StartTmux(_ context.Context, _ *PipelineState) error {
 	return m.startTmuxErr
 }
 
+func (m *mockRunService) StartHeadless(_ context.Context, _ *PipelineState) error {
+	m.called = append(m.called, StepStartHeadless)
+	return m.startHeadlessErr
+}
+
 // TestShortCircuitPreservesErrorCode tests that the pipeline short-circuits
 // on first step error and preserves AgencyError codes.
 func TestShortCircuitPreservesErrorCode(t *testing.T) {
@@ -292,6 +298,9 @@ func (m *stateCapturingMock) CreateWorktree(_ context.Context, _ *PipelineState)
 func (m *stateCapturingMock) WriteMeta(_ context.Context, _ *PipelineState) error { return nil }
 func (m *stateCapturingMock) RunSetup(_ context.Context, _ *PipelineState) error  { return nil }
 func (m *stateCapturingMock) StartTmux(_ context.Context, _ *PipelineState) error { return nil }
+func (m *stateCapturingMock) StartHeadless(_ context.Context, _ *PipelineState) error {
+	return nil
+}
 
 // TestOptsPassedToState tests that RunPipelineOpts are correctly copied
 // into the pipeline state.
@@ -352,6 +361,7 @@ func (m *optCapturingMock) CreateWorktree(_ context.Context, _ *PipelineState) e
 func (m *optCapturingMock) WriteMeta(_ context.Context, _ *PipelineState) error        { return nil }
 func (m *optCapturingMock) RunSetup(_ context.Context, _ *PipelineState) error         { return nil }
 func (m *optCapturingMock) StartTmux(_ context.Context, _ *PipelineState) error        { return nil }
+func (m *optCapturingMock) StartHeadless(_ context.Context, _ *PipelineState) error    { return nil }
 
 // TestStepsExecuteInOrder tests that steps execute in the expected fixed order.
 func TestStepsExecuteInOrder(t *testing.T) {
@@ -416,3 +426,33 @@ func TestMiddleStepFailure(t *testing.T) {
 		t.Errorf("expected %d steps called, got %d: %v", len(expected), len(mock.called), mock.called)
 	}
 }
+
+// TestHeadlessReplacesStartTmux tests that headless runs call StartHeadless instead of StartTmux.
+func TestHeadlessReplacesStartTmux(t *testing.T) {
+	mock := &mockRunService{}
+
+	p := NewPipeline(mock)
+	p.SetNowFunc(fixedTime)
+
+	_, err := p.Run(context.Background(), RunPipelineOpts{Name: "test-run", Headless: true, Prompt: "do it"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		StepCheckRepoSafe,
+		StepLoadAgencyConfig,
+		StepCreateWorktree,
+		StepWriteMeta,
+		StepRunSetup,
+		StepStartHeadless,
+	}
+	if len(mock.called) != len(expected) {
+		t.Fatalf("expected %d steps, got %d: %v", len(expected), len(mock.called), mock.called)
+	}
+	for i, step := range expected {
+		if mock.called[i] != step {
+			t.Errorf("step %d: expected %q, got %q", i, step, mock.called[i])
+		}
+	}
+}
@@ -100,6 +100,84 @@ func WritePartialConflictCard(w io.Writer, inputs ConflictCardInputs) {
 	_, _ = fmt.Fprintln(w, "hint: worktree no longer exists; resolve conflicts via GitHub web UI or restore locally")
 }
 
+// LandConflictCardInputs holds all inputs needed to render a land conflict card.
+type LandConflictCardInputs struct {
+	// WorktreeRef is the integration worktree reference the user invoked.
+	WorktreeRef string
+
+	// RunRef is the run reference the user invoked.
+	RunRef string
+
+	// Mode is "cherry-pick" or "apply".
+	Mode string
+
+	// TreePath is the integration worktree tree path. Required.
+	TreePath string
+
+	// Branch is the run branch (e.g., "agency/feature-x-a3f2"). Required.
+	Branch string
+
+	// RunWorktreePath is the run worktree path (used by apply mode steps).
+	RunWorktreePath string
+
+	// BaseSHA is the run's merge-base with its parent branch.
+	BaseSHA string
+
+	// Commit is the run commit that failed to cherry-pick. Omitted if empty.
+	Commit string
+
+	// Files are the conflicting paths. Omitted if empty.
+	Files []string
+}
+
+// WriteLandConflictCard writes the action card for a landing that conflicted
+// and was aborted. Same plain-text layout as WriteConflictCard.
+//
+// Output format (cherry-pick mode):
+//
+//	worktree: /path/to/tree
+//	branch: agency/feature-x-a3f2
+//	commit: 1a2b3c4
+//	conflicts: main.go, util.go
+//
+//	next:
+//
+//	1. agency worktree shell integ
+//	2. git cherry-pick -x 5d6e7f8..agency/feature-x-a3f2
+//	3. resolve conflicts, then:
+//	   git add -A && git cherry-pick --continue
+//
+//	alt: cd "/path/to/tree"
+func WriteLandConflictCard(w io.Writer, inputs LandConflictCardInputs) {
+	_, _ = fmt.Fprintf(w, "worktree: %s\n", inputs.TreePath)
+	_, _ = fmt.Fprintf(w, "branch: %s\n", inputs.Branch)
+	if inputs.Commit != "" {
+		_, _ = fmt.Fprintf(w, "commit: %s\n", shortSHA(inputs.Commit))
+	}
+	if len(inputs.Files) > 0 {
+		_, _ = fmt.Fprintf(w, "conflicts: %s\n", strings.Join(inputs.Files, ", "))
+	}
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "next:")
+	_, _ = fmt.Fprintln(w)
+
+	base := shortSHA(inputs.BaseSHA)
+	_, _ = fmt.Fprintf(w, "1. agency worktree shell %s\n", inputs.WorktreeRef)
+	if inputs.Mode == "apply" {
+		_, _ = fmt.Fprintf(w, "2. (cd \"%s\" && git add -A && git diff --cached --binary %s) | git apply --3way\n", inputs.RunWorktreePath, base)
+		_, _ = fmt.Fprintln(w, "3. resolve conflicts, then:")
+		_, _ = fmt.Fprintln(w, "   git add -A && git commit")
+	} else {
+		_, _ = fmt.Fprintf(w, "2. git cherry-pick -x %s..%s\n", base, inputs.Branch)
+		_, _ = fmt.Fprintln(w, "3. resolve conflicts, then:")
+		_, _ = fmt.Fprintln(w, "   git add -A && git cherry-pick --continue")
+	}
+	_, _ = fmt.Fprintln(w)
+
+	_, _ = fmt.Fprintf(w, "alt: cd \"%s\"\n", inputs.TreePath)
+}
+
 // FormatConflictErrorMessage formats the one-line error message for merge conflicts.
 // Example: "PR #93 has conflicts with main and cannot be merged."
 func FormatConflictErrorMessage(prNumber int, base string) string {
@@ -163,3 +241,11 @@ func IsNonFastForwardError(stderr string) bool {
 
 	return false
 }
+
+// shortSHA returns the first 7 characters of a sha.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
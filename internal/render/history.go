@@ -0,0 +1,121 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// StatusHistoryEntry is one runner status report in a run's history.
+type StatusHistoryEntry struct {
+	At             string   `json:"at"` // RFC3339
+	Status         string   `json:"status"`
+	PreviousStatus string   `json:"previous_status"`
+	Summary        string   `json:"summary"`
+	Questions      []string `json:"questions"`
+	Source         string   `json:"source"`
+
+	// DurationSeconds is the time until the next report, or until the end of
+	// the history for the last one.
+	DurationSeconds int64 `json:"duration_seconds"`
+}
+
+// StatusTotal is the time a run spent in one runner status.
+type StatusTotal struct {
+	Status          string `json:"status"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	// Entries is the number of times the run entered the status.
+	Entries int `json:"entries"`
+}
+
+// StatusHistory is the output of show --history.
+type StatusHistory struct {
+	RunID string `json:"run_id"`
+	Name  string `json:"name"`
+	// EndedAt is where the last entry's duration stops: archive time, runner
+	// exit time, or now for a live run.
+	EndedAt string               `json:"ended_at"`
+	Entries []StatusHistoryEntry `json:"entries"`
+	// Totals are ordered by first appearance in the history.
+	Totals []StatusTotal `json:"totals"`
+	// QuestionsAsked counts distinct questions across needs_input reports.
+	QuestionsAsked int `json:"questions_asked"`
+}
+
+// WriteStatusHistoryJSON writes show --history --json output.
+func WriteStatusHistoryJSON(w io.Writer, h *StatusHistory) error {
+	env := struct {
+		SchemaVersion string         `json:"schema_version"`
+		Data          *StatusHistory `json:"data"`
+	}{SchemaVersion: "1.0", Data: h}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(env)
+}
+
+// WriteStatusHistoryHuman writes the show --history timeline and totals.
+func WriteStatusHistoryHuman(w io.Writer, h *StatusHistory) {
+	displayName := h.Name
+	if displayName == "" {
+		displayName = NameUntitled
+	}
+	_, _ = fmt.Fprintf(w, "run: %s (%s)\n", h.RunID, displayName)
+	if len(h.Entries) == 0 {
+		_, _ = fmt.Fprintln(w, "no runner status reports recorded")
+		return
+	}
+
+	statusW := len("status")
+	for _, e := range h.Entries {
+		if len(e.Status) > statusW {
+			statusW = len(e.Status)
+		}
+	}
+
+	_, _ = fmt.Fprintln(w)
+	for _, e := range h.Entries {
+		_, _ = fmt.Fprintf(w, "%s  %-*s  %6s  %s\n", e.At, statusW, e.Status,
+			FormatHistoryDuration(time.Duration(e.DurationSeconds)*time.Second), TruncateForDisplay(e.Summary, 60))
+		if e.Status == "needs_input" {
+			for _, q := range e.Questions {
+				_, _ = fmt.Fprintf(w, "    ? %s\n", q)
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "totals:")
+	for _, t := range h.Totals {
+		times := "times"
+		if t.Entries == 1 {
+			times = "time"
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s  %6s  (%d %s)\n", statusW, t.Status,
+			FormatHistoryDuration(time.Duration(t.DurationSeconds)*time.Second), t.Entries, times)
+	}
+	_, _ = fmt.Fprintf(w, "questions asked: %d\n", h.QuestionsAsked)
+}
+
+// FormatHistoryDuration formats a duration compactly (e.g. "45s", "12m", "2h5m", "3d4h").
+func FormatHistoryDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return trimZeroUnit(fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60))
+	default:
+		return trimZeroUnit(fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24))
+	}
+}
+
+// trimZeroUnit drops a trailing zero minor unit ("2h0m" -> "2h").
+func trimZeroUnit(s string) string {
+	if strings.HasSuffix(s, "h0m") || strings.HasSuffix(s, "d0h") {
+		return s[:len(s)-2]
+	}
+	return s
+}
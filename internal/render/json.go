@@ -57,6 +57,10 @@ type RunSummary struct {
 	// Summary is the runner-reported summary (null if no runner_status.json).
 	Summary *string `json:"summary"`
 
+	// StatusSince is when the runner entered its current runner status
+	// (RFC3339; null if no runner_status.json or not yet recorded).
+	StatusSince *string `json:"status_since"`
+
 	// StalledDuration is the duration since last status update, if stalled (null if not stalled).
 	StalledDuration *string `json:"stalled_duration,omitempty"`
 
@@ -140,6 +144,24 @@ type DerivedJSON struct {
 
 	// RunnerStatus contains runner-reported status (null if no runner_status.json).
 	RunnerStatus *RunnerStatusJSON `json:"runner_status,omitempty"`
+
+	// Activity contains activity parsed from the runner's session log (omitted if none found).
+	Activity *ActivityJSON `json:"activity,omitempty"`
+}
+
+// ActivityJSON contains session log activity for show --json.
+type ActivityJSON struct {
+	// LastActivityAt is the RFC3339 timestamp of the last runner action (empty if none).
+	LastActivityAt string `json:"last_activity_at,omitempty"`
+
+	// LastAction is a one-line description of the last runner action.
+	LastAction string `json:"last_action,omitempty"`
+
+	// SessionLogPath is the runner's source session log.
+	SessionLogPath string `json:"session_log_path"`
+
+	// StreamPath is the normalized logs/stream.jsonl.
+	StreamPath string `json:"stream_path"`
 }
 
 // RunnerStatusJSON contains runner-reported status for show --json.
@@ -43,6 +43,27 @@ type RunnerStatusDisplay struct {
 	Risks []string
 }
 
+// HeadlessDisplay holds headless runner details for human show output.
+type HeadlessDisplay struct {
+	// PID is the runner process id (0 if not started).
+	PID int
+
+	// ExitCode is the runner exit code (nil while running).
+	ExitCode *int
+
+	// ExitReason is the recorded exit reason (empty while running).
+	ExitReason string
+
+	// LastOutputAt is the timestamp of the last output chunk (RFC3339, may be empty).
+	LastOutputAt string
+
+	// RawLogPath is the path to logs/raw.jsonl.
+	RawLogPath string
+
+	// StderrLogPath is the path to logs/stderr.log.
+	StderrLogPath string
+}
+
 // ShowHumanData holds the data for human show output.
 type ShowHumanData struct {
 	// Core
@@ -86,6 +107,19 @@ type ShowHumanData struct {
 	// Runner status (nil if no runner_status.json or invalid)
 	RunnerStatus *RunnerStatusDisplay
 
+	// Headless runner details (nil for tmux runs)
+	Headless *HeadlessDisplay
+
+	// RunnerExited is when the tmux runner was recorded as exited, relative ("2h ago"; empty if not exited)
+	RunnerExited string
+
+	// RunnerSessionID is the runner's conversation id used by resume (empty if unknown)
+	RunnerSessionID string
+
+	// Last action parsed from the runner's session log (empty if unknown)
+	LastAction     string
+	LastActivityAt string // relative ("5m ago")
+
 	// Warnings
 	RepoNotFoundWarning    bool
 	WorktreeMissingWarning bool
@@ -159,12 +193,18 @@ func WriteShowHuman(w io.Writer, data ShowHumanData) error {
 
 	// Format status with archived suffix if applicable
 	statusDisplay := formatStatus(data.DerivedStatus, data.Archived)
+	if data.RunnerExited != "" && data.DerivedStatus == "idle" {
+		statusDisplay += fmt.Sprintf(" (runner exited %s)", data.RunnerExited)
+	}
 
 	// Output in spec-defined order with blank line between worktree and tmux
 	_, _ = fmt.Fprintf(w, "run: %s\n", data.RunID)
 	_, _ = fmt.Fprintf(w, "name: %s\n", displayName)
 	_, _ = fmt.Fprintf(w, "repo: %s\n", data.RepoID)
 	_, _ = fmt.Fprintf(w, "runner: %s\n", data.Runner)
+	if data.RunnerSessionID != "" {
+		_, _ = fmt.Fprintf(w, "runner_session: %s\n", data.RunnerSessionID)
+	}
 	_, _ = fmt.Fprintf(w, "parent: %s\n", data.ParentBranch)
 	_, _ = fmt.Fprintf(w, "branch: %s\n", data.Branch)
 	_, _ = fmt.Fprintf(w, "worktree: %s\n", data.WorktreePath)
@@ -178,6 +218,14 @@ func WriteShowHuman(w io.Writer, data ShowHumanData) error {
 	_, _ = fmt.Fprintf(w, "last_report_sync_at: %s\n", lastReportSyncDisplay)
 	_, _ = fmt.Fprintf(w, "report_hash: %s\n", reportHashDisplay)
 	_, _ = fmt.Fprintf(w, "status: %s\n", statusDisplay)
+	if data.LastAction != "" {
+		_, _ = fmt.Fprintf(w, "last_action: %s (%s)\n", data.LastAction, data.LastActivityAt)
+	}
+
+	// Headless section (headless runs only)
+	if data.Headless != nil {
+		writeHeadlessSection(w, data.Headless)
+	}
 
 	// Runner status section (if available)
 	if data.RunnerStatus != nil {
@@ -220,6 +268,30 @@ func WriteShowHuman(w io.Writer, data ShowHumanData) error {
 	return nil
 }
 
+// writeHeadlessSection writes the headless runner section of show output.
+func writeHeadlessSection(w io.Writer, h *HeadlessDisplay) {
+	pidDisplay := "none"
+	if h.PID != 0 {
+		pidDisplay = fmt.Sprintf("%d", h.PID)
+	}
+	exitDisplay := "none"
+	if h.ExitCode != nil {
+		exitDisplay = fmt.Sprintf("%d (%s)", *h.ExitCode, h.ExitReason)
+	}
+	lastOutputDisplay := h.LastOutputAt
+	if lastOutputDisplay == "" {
+		lastOutputDisplay = "none"
+	}
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "headless:")
+	_, _ = fmt.Fprintf(w, "  pid: %s\n", pidDisplay)
+	_, _ = fmt.Fprintf(w, "  exit: %s\n", exitDisplay)
+	_, _ = fmt.Fprintf(w, "  last_output_at: %s\n", lastOutputDisplay)
+	_, _ = fmt.Fprintf(w, "  raw_log: %s\n", h.RawLogPath)
+	_, _ = fmt.Fprintf(w, "  stderr_log: %s\n", h.StderrLogPath)
+}
+
 // ResolveScriptLogPaths resolves the log paths for setup/verify/archive scripts.
 // Uses the canonical s1 log path format: <run_dir>/logs/<script>.log
 // Returns absolute paths even if files don't exist (for display purposes).
@@ -0,0 +1,69 @@
+package runnerstatus
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/lock"
+)
+
+// HistoryState is the last runner status report recorded in a run's history
+// (runner_status_changed events). It is kept in the run directory so each
+// report is recorded once no matter how many observers see it.
+type HistoryState struct {
+	Status    Status `json:"status"`
+	UpdatedAt string `json:"updated_at"`
+	// Since is when the run entered Status (RFC3339); later reports with
+	// the same status keep it.
+	Since string `json:"since"`
+}
+
+// LoadHistoryState reads the history state file.
+// Returns (nil, nil) if the file does not exist or is empty.
+func LoadHistoryState(path string) (*HistoryState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var state HistoryState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// ObserveHistory records s, reported at, as the latest report in the history
+// state file at path. It returns the previously recorded state (nil if none)
+// and whether s is a new report, i.e. its status or updated_at differs from
+// the recorded one. The file is locked so concurrent observers record a
+// report once.
+func ObserveHistory(path string, s *RunnerStatus, at time.Time) (prev *HistoryState, changed bool, err error) {
+	var rec HistoryState
+	err = lock.UpdateJSON(path, &rec, func(found bool) bool {
+		// A corrupt state file is treated as empty and replaced
+		if found {
+			recorded := rec
+			prev = &recorded
+		}
+		if prev != nil && prev.Status == s.Status && prev.UpdatedAt == s.UpdatedAt {
+			return false
+		}
+		rec = HistoryState{Status: s.Status, UpdatedAt: s.UpdatedAt, Since: at.UTC().Format(time.RFC3339)}
+		if prev != nil && prev.Status == s.Status && prev.Since != "" {
+			rec.Since = prev.Since
+		}
+		changed = true
+		return true
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return prev, changed, nil
+}
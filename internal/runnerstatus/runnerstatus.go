@@ -11,6 +11,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/fs"
 )
 
 // Status represents the runner's current state.
@@ -87,6 +89,25 @@ func LoadWithModTime(worktreePath string) (*RunnerStatus, time.Time, error) {
 	return status, info.ModTime(), nil
 }
 
+// Save writes the status to the worktree's runner_status.json atomically,
+// creating .agency/state/ if needed. Nil lists are written as [].
+func Save(worktreePath string, s *RunnerStatus) error {
+	out := *s
+	for _, list := range []*[]string{&out.Questions, &out.Blockers, &out.Risks} {
+		if *list == nil {
+			*list = []string{}
+		}
+	}
+	path := StatusPath(worktreePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := fs.WriteJSONAtomic(path, &out, 0o644); err != nil {
+		return fmt.Errorf("failed to write runner status file: %w", err)
+	}
+	return nil
+}
+
 // Validate checks that the RunnerStatus has valid values.
 // Returns nil if valid, or an error describing the validation failure.
 func (s *RunnerStatus) Validate() error {
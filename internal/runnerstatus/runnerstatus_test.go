@@ -3,6 +3,7 @@ package runnerstatus
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -319,3 +320,74 @@ func TestStatus_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestSave(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	in := &RunnerStatus{
+		SchemaVersion: SchemaVersion,
+		Status:        StatusNeedsInput,
+		UpdatedAt:     "2026-01-19T12:00:00Z",
+		Summary:       "Choosing a database",
+		Questions:     []string{"postgres or sqlite?"},
+	}
+	if err := Save(tmpDir, in); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(StatusPath(tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"blockers": []`) || !strings.Contains(string(data), `"risks": []`) {
+		t.Errorf("nil lists not written as []:\n%s", data)
+	}
+	if in.Blockers != nil {
+		t.Error("Save() modified its argument")
+	}
+
+	got, err := Load(tmpDir)
+	if err != nil || got == nil {
+		t.Fatalf("Load() = %v, %v", got, err)
+	}
+	if got.Status != StatusNeedsInput || len(got.Questions) != 1 || got.Summary != in.Summary {
+		t.Errorf("Load() after Save() = %+v", got)
+	}
+}
+
+func TestObserveHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runner_status_state.json")
+	t0 := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	working := &RunnerStatus{Status: StatusWorking, UpdatedAt: "2026-01-10T12:00:00Z", Summary: "start"}
+	prev, changed, err := ObserveHistory(path, working, t0)
+	if err != nil || !changed || prev != nil {
+		t.Fatalf("first ObserveHistory() = %v, %v, %v; want new report with no previous", prev, changed, err)
+	}
+
+	// The same report again is not a change
+	if _, changed, _ := ObserveHistory(path, working, t0.Add(time.Minute)); changed {
+		t.Error("repeated report observed as a change")
+	}
+
+	// A new report with the same status keeps since
+	again := &RunnerStatus{Status: StatusWorking, UpdatedAt: "2026-01-10T12:05:00Z", Summary: "halfway"}
+	prev, changed, _ = ObserveHistory(path, again, t0.Add(5*time.Minute))
+	if !changed || prev == nil || prev.Status != StatusWorking {
+		t.Fatalf("ObserveHistory(new report) = %v, %v", prev, changed)
+	}
+	state, err := LoadHistoryState(path)
+	if err != nil || state == nil || state.Since != "2026-01-10T12:00:00Z" || state.UpdatedAt != again.UpdatedAt {
+		t.Errorf("state after same-status report = %+v, %v", state, err)
+	}
+
+	// A new status resets since
+	blocked := &RunnerStatus{Status: StatusBlocked, UpdatedAt: "2026-01-10T12:10:00Z", Summary: "stuck"}
+	if _, changed, _ := ObserveHistory(path, blocked, t0.Add(10*time.Minute)); !changed {
+		t.Fatal("status change not observed")
+	}
+	state, _ = LoadHistoryState(path)
+	if state == nil || state.Status != StatusBlocked || state.Since != "2026-01-10T12:10:00Z" {
+		t.Errorf("state after status change = %+v", state)
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
 	"github.com/NielsdaWheelz/agency/internal/git"
+	"github.com/NielsdaWheelz/agency/internal/headless"
 	"github.com/NielsdaWheelz/agency/internal/ids"
+	"github.com/NielsdaWheelz/agency/internal/lifecycle"
 	"github.com/NielsdaWheelz/agency/internal/paths"
 	"github.com/NielsdaWheelz/agency/internal/pipeline"
 	"github.com/NielsdaWheelz/agency/internal/repo"
@@ -30,9 +32,10 @@ import (
 
 // Service is the production implementation of pipeline.RunService.
 type Service struct {
-	cr      exec.CommandRunner
-	fsys    fs.FS
-	nowFunc func() time.Time
+	cr        exec.CommandRunner
+	fsys      fs.FS
+	nowFunc   func() time.Time
+	spawnFunc func(headless.SpawnOpts) (int, error)
 }
 
 type osEnv struct{}
@@ -44,18 +47,20 @@ func (osEnv) Get(key string) string {
 // New creates a new Service with production dependencies.
 func New() *Service {
 	return &Service{
-		cr:      exec.NewRealRunner(),
-		fsys:    fs.NewRealFS(),
-		nowFunc: time.Now,
+		cr:        exec.NewRealRunner(),
+		fsys:      fs.NewRealFS(),
+		nowFunc:   time.Now,
+		spawnFunc: headless.Spawn,
 	}
 }
 
 // NewWithDeps creates a new Service with injected dependencies for testing.
 func NewWithDeps(cr exec.CommandRunner, fsys fs.FS) *Service {
 	return &Service{
-		cr:      cr,
-		fsys:    fsys,
-		nowFunc: time.Now,
+		cr:        cr,
+		fsys:      fsys,
+		nowFunc:   time.Now,
+		spawnFunc: headless.Spawn,
 	}
 }
 
@@ -64,12 +69,21 @@ func (s *Service) SetNowFunc(fn func() time.Time) {
 	s.nowFunc = fn
 }
 
+// SetSpawnFunc overrides the headless supervisor spawner for testing.
+func (s *Service) SetSpawnFunc(fn func(headless.SpawnOpts) (int, error)) {
+	s.spawnFunc = fn
+}
+
 // CheckRepoSafe verifies repo safety (clean working tree, parent branch exists, etc.).
 func (s *Service) CheckRepoSafe(ctx context.Context, st *pipeline.PipelineState) error {
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return errors.Wrap(errors.EInternal, "failed to get current directory", err)
+	// Resolve the repo from the requested directory, else the working directory
+	cwd := st.Dir
+	if cwd == "" {
+		var err error
+		cwd, err = os.Getwd()
+		if err != nil {
+			return errors.Wrap(errors.EInternal, "failed to get current directory", err)
+		}
 	}
 
 	// Determine parent branch: use from opts if provided, otherwise will be resolved from config later
@@ -165,14 +179,18 @@ func (s *Service) checkNameUnique(st *pipeline.PipelineState) error {
 	}
 
 	// Convert to RunRef for the uniqueness check
-	refs := make([]ids.RunRef, len(records))
-	for i, r := range records {
-		refs[i] = ids.RunRef{
+	// A queued run being started must not conflict with itself
+	refs := make([]ids.RunRef, 0, len(records))
+	for _, r := range records {
+		if r.RunID == st.RunID {
+			continue
+		}
+		refs = append(refs, ids.RunRef{
 			RepoID: r.RepoID,
 			RunID:  r.RunID,
 			Name:   r.Name,
 			Broken: r.Broken,
-		}
+		})
 	}
 
 	// isArchived checks if a run is archived (has Archive field set)
@@ -286,6 +304,12 @@ func (s *Service) LoadAgencyConfig(ctx context.Context, st *pipeline.PipelineSta
 	st.SetupScript = cfg.Scripts.Setup.Path
 	st.SetupTimeout = cfg.Scripts.Setup.Timeout
 	st.ParentBranch = parentBranch
+	if st.MaxDuration == 0 {
+		st.MaxDuration = cfg.Budgets.MaxDuration
+	}
+	if st.MaxStall == 0 {
+		st.MaxStall = cfg.Budgets.MaxStall
+	}
 
 	return nil
 }
@@ -370,10 +394,14 @@ func (s *Service) WriteMeta(ctx context.Context, st *pipeline.PipelineState) err
 	// Create a store for the run operations
 	st2 := store.NewStore(s.fsys, st.DataDir, s.nowFunc)
 
-	// Create run directory (exclusive semantics) + logs subdirectory
-	_, err = st2.EnsureRunDir(st.RepoID, st.RunID)
-	if err != nil {
-		return err
+	// A queued run already has its run directory; its meta is replaced below
+	// (keeping the queue record until the runner starts)
+	queued, _ := st2.ReadMeta(st.RepoID, st.RunID)
+	if queued == nil || queued.Queue == nil {
+		// Create run directory (exclusive semantics) + logs subdirectory
+		if _, err := st2.EnsureRunDir(st.RepoID, st.RunID); err != nil {
+			return err
+		}
 	}
 
 	// Create initial meta (runner name was resolved in LoadAgencyConfig)
@@ -388,6 +416,20 @@ func (s *Service) WriteMeta(ctx context.Context, st *pipeline.PipelineState) err
 		st.WorktreePath,
 		s.nowFunc(),
 	)
+	meta.GroupID = st.GroupID
+	meta.GroupAttempt = st.GroupAttempt
+	if queued != nil {
+		meta.Queue = queued.Queue
+	}
+	if st.MaxDuration > 0 || st.MaxStall > 0 {
+		meta.Budget = &store.RunMetaBudget{}
+		if st.MaxDuration > 0 {
+			meta.Budget.MaxDuration = st.MaxDuration.String()
+		}
+		if st.MaxStall > 0 {
+			meta.Budget.MaxStall = st.MaxStall.String()
+		}
+	}
 
 	// Write meta.json atomically
 	if err := st2.WriteInitialMeta(st.RepoID, st.RunID, meta); err != nil {
@@ -708,8 +750,36 @@ func (s *Service) StartTmux(ctx context.Context, st *pipeline.PipelineState) err
 		)
 	}
 
-	// Build the pane command
-	paneCmd := core.BuildRunnerShellScript(st.WorktreePath, st.ResolvedRunnerCmd)
+	// Pass a prompt (batch runs) to the runner as its first message. It is read
+	// from logs/prompt.txt so the tmux argv stays small.
+	runnerCmd := st.ResolvedRunnerCmd
+	if st.Prompt != "" {
+		promptPath := st2.RunPromptPath(st.RepoID, st.RunID)
+		if err := s.fsys.WriteFile(promptPath, []byte(st.Prompt), 0o600); err != nil {
+			s.setTmuxFailedFlag(st.DataDir, st.RepoID, st.RunID)
+			return errors.WrapWithDetails(
+				errors.ETmuxFailed,
+				"failed to write prompt file",
+				err,
+				map[string]string{"prompt_path": promptPath},
+			)
+		}
+		runnerCmd += ` "$(cat ` + core.ShellEscapePosix(promptPath) + `)"`
+	}
+
+	// Build the pane command: the runner, then a shell in the worktree once it exits
+	paneCmd, err := lifecycle.RunnerSessionScript(lifecycle.SessionScriptOpts{
+		DataDir:      st.DataDir,
+		RepoID:       st.RepoID,
+		RunID:        st.RunID,
+		RunRef:       st.Name,
+		WorktreePath: st.WorktreePath,
+		RunnerCmd:    runnerCmd,
+	})
+	if err != nil {
+		s.setTmuxFailedFlag(st.DataDir, st.RepoID, st.RunID)
+		return err
+	}
 
 	// Create the tmux session detached
 	// Use: tmux new-session -d -s <session> -- sh -lc '<pane_cmd>'
@@ -739,9 +809,11 @@ func (s *Service) StartTmux(ctx context.Context, st *pipeline.PipelineState) err
 		)
 	}
 
-	// Update meta.json with tmux_session_name
+	// Update meta.json with tmux_session_name and the runner start time
+	startedAt := s.nowFunc().UTC().Format(time.RFC3339)
 	err = st2.UpdateMeta(st.RepoID, st.RunID, func(m *store.RunMeta) {
 		m.TmuxSessionName = sessionName
+		m.RunnerStartedAt = startedAt
 	})
 	if err != nil {
 		// Meta write failed, but tmux session was created
@@ -764,3 +836,56 @@ func (s *Service) setTmuxFailedFlag(dataDir, repoID, runID string) {
 		m.Flags.TmuxFailed = true
 	})
 }
+
+// StartHeadless starts the runner as a supervised, detached subprocess.
+// Only runs if setup succeeded (flags.setup_failed is absent/false).
+// Writes the prompt to logs/prompt.txt, records headless paths in meta.json,
+// then spawns the supervisor and waits until it reports the runner has started.
+// The supervisor records runner_pid, last_output_at and the runner's exit.
+func (s *Service) StartHeadless(ctx context.Context, st *pipeline.PipelineState) error {
+	st2 := store.NewStore(s.fsys, st.DataDir, s.nowFunc)
+	meta, err := st2.ReadMeta(st.RepoID, st.RunID)
+	if err != nil {
+		return err
+	}
+
+	if meta.Flags != nil && meta.Flags.SetupFailed {
+		return errors.NewWithDetails(
+			errors.ERunnerStartFailed,
+			"cannot start headless runner: setup failed",
+			map[string]string{
+				"run_id": st.RunID,
+			},
+		)
+	}
+
+	// Persist the prompt; the supervisor feeds it to the runner on stdin
+	promptPath := st2.RunPromptPath(st.RepoID, st.RunID)
+	if err := s.fsys.WriteFile(promptPath, []byte(st.Prompt), 0o600); err != nil {
+		return errors.WrapWithDetails(
+			errors.ERunnerStartFailed,
+			"failed to write prompt file",
+			err,
+			map[string]string{"prompt_path": promptPath},
+		)
+	}
+
+	err = st2.UpdateMeta(st.RepoID, st.RunID, func(m *store.RunMeta) {
+		m.Headless = &store.RunMetaHeadless{
+			PromptPath:    promptPath,
+			RawLogPath:    st2.RunRawLogPath(st.RepoID, st.RunID),
+			StderrLogPath: st2.RunStderrLogPath(st.RepoID, st.RunID),
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.spawnFunc(headless.SpawnOpts{
+		DataDir: st.DataDir,
+		RepoID:  st.RepoID,
+		RunID:   st.RunID,
+		LogPath: st2.RunSupervisorLogPath(st.RepoID, st.RunID),
+	})
+	return err
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	agencyexec "github.com/NielsdaWheelz/agency/internal/exec"
 	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/headless"
 	"github.com/NielsdaWheelz/agency/internal/pipeline"
+	"github.com/NielsdaWheelz/agency/internal/store"
 	"github.com/NielsdaWheelz/agency/internal/testutil"
 )
 
@@ -251,6 +253,32 @@ func TestService_CheckRepoSafe_DirtyRepo(t *testing.T) {
 	}
 }
 
+func TestService_CheckRepoSafe_Dir(t *testing.T) {
+	repoRoot, dataDir := setupTempRepo(t)
+	t.Setenv("AGENCY_DATA_DIR", dataDir)
+
+	resolvedRepoRoot, err := filepath.EvalSymlinks(repoRoot)
+	if err != nil {
+		t.Fatalf("failed to resolve symlinks: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+
+	// The repo is resolved from Dir; the working directory is left alone
+	st := &pipeline.PipelineState{Name: "dir-test", Parent: "main", Dir: repoRoot}
+	if err := New().CheckRepoSafe(context.Background(), st); err != nil {
+		t.Fatalf("CheckRepoSafe failed: %v", err)
+	}
+	if st.RepoRoot != resolvedRepoRoot {
+		t.Errorf("RepoRoot = %q, want %q", st.RepoRoot, resolvedRepoRoot)
+	}
+	if got, _ := os.Getwd(); got != wd {
+		t.Errorf("working directory changed to %q", got)
+	}
+}
+
 func TestService_LoadAgencyConfig(t *testing.T) {
 	repoRoot, dataDir := setupTempRepo(t)
 
@@ -336,6 +364,7 @@ func TestService_WriteMeta_Success(t *testing.T) {
 
 	// Populate remaining fields needed for WriteMeta
 	st.ResolvedRunnerCmd = "claude"
+	st.MaxStall = 45 * time.Minute
 
 	// Now test WriteMeta
 	err = svc.WriteMeta(ctx, st)
@@ -397,6 +426,9 @@ func TestService_WriteMeta_Success(t *testing.T) {
 	if strings.Contains(content, `"tmux_session_name"`) {
 		t.Error("meta.json should not contain tmux_session_name")
 	}
+	if !strings.Contains(content, `"max_stall": "45m0s"`) || strings.Contains(content, `"max_duration"`) {
+		t.Error("meta.json should contain budget.max_stall only")
+	}
 }
 
 func TestService_WriteMeta_WorktreeMissing(t *testing.T) {
@@ -1167,3 +1199,111 @@ func TestService_StartTmux_SessionExists(t *testing.T) {
 		t.Errorf("error code = %q, want %q", code, errors.ETmuxSessionExists)
 	}
 }
+
+func TestService_StartHeadless_Success(t *testing.T) {
+	repoRoot, dataDir := setupTempRepo(t)
+	t.Setenv("AGENCY_DATA_DIR", dataDir)
+
+	resolvedRepoRoot, err := filepath.EvalSymlinks(repoRoot)
+	if err != nil {
+		t.Fatalf("failed to resolve symlinks: %v", err)
+	}
+
+	svc := NewWithDeps(agencyexec.NewRealRunner(), fs.NewRealFS())
+	ctx := context.Background()
+
+	runID := "20260110120002-head"
+	repoID := "abcd1234ef567890"
+
+	st := &pipeline.PipelineState{
+		RunID:        runID,
+		Name:         "headless-test",
+		RepoRoot:     resolvedRepoRoot,
+		RepoID:       repoID,
+		DataDir:      dataDir,
+		ParentBranch: "main",
+		Runner:       "sh",
+		Headless:     true,
+		Prompt:       "{\"prompt\":\"hello\"}\n",
+	}
+
+	if err := svc.CreateWorktree(ctx, st); err != nil {
+		t.Fatalf("CreateWorktree failed: %v", err)
+	}
+	// cat echoes the prompt back on stdout, standing in for a streaming runner
+	st.ResolvedRunnerCmd = "cat"
+	if err := svc.WriteMeta(ctx, st); err != nil {
+		t.Fatalf("WriteMeta failed: %v", err)
+	}
+
+	// Run the supervisor in-process instead of re-executing the agency binary
+	var spawned headless.SpawnOpts
+	svc.SetSpawnFunc(func(opts headless.SpawnOpts) (int, error) {
+		spawned = opts
+		st2 := store.NewStore(fs.NewRealFS(), opts.DataDir, time.Now)
+		return os.Getpid(), headless.Supervise(st2, opts.RepoID, opts.RunID, nil)
+	})
+
+	if err := svc.StartHeadless(ctx, st); err != nil {
+		t.Fatalf("StartHeadless failed: %v", err)
+	}
+
+	if spawned.RunID != runID || spawned.RepoID != repoID || spawned.DataDir != dataDir {
+		t.Errorf("spawn opts = %+v, want run %s in repo %s", spawned, runID, repoID)
+	}
+
+	st2 := store.NewStore(fs.NewRealFS(), dataDir, nil)
+	meta, err := st2.ReadMeta(repoID, runID)
+	if err != nil {
+		t.Fatalf("ReadMeta failed: %v", err)
+	}
+	if meta.Headless == nil {
+		t.Fatal("meta.headless not set")
+	}
+	if meta.TmuxSessionName != "" {
+		t.Errorf("tmux_session_name = %q, want empty for headless run", meta.TmuxSessionName)
+	}
+
+	raw, err := os.ReadFile(meta.Headless.RawLogPath)
+	if err != nil {
+		t.Fatalf("failed to read raw.jsonl: %v", err)
+	}
+	if string(raw) != st.Prompt {
+		t.Errorf("raw.jsonl = %q, want %q", raw, st.Prompt)
+	}
+	if meta.RunnerExitCode == nil || *meta.RunnerExitCode != 0 {
+		t.Errorf("runner_exit_code = %v, want 0", meta.RunnerExitCode)
+	}
+	if store.ReadLastOutputAt(st2.RunLastOutputPath(meta.RepoID, meta.RunID)) == "" {
+		t.Error("last_output_at not recorded")
+	}
+}
+
+func TestService_StartHeadless_SetupFailed(t *testing.T) {
+	dataDir := t.TempDir()
+	repoID := "abcd1234ef567890"
+	runID := "20260110120003-hfai"
+
+	st2 := store.NewStore(fs.NewRealFS(), dataDir, nil)
+	if _, err := st2.EnsureRunDir(repoID, runID); err != nil {
+		t.Fatalf("EnsureRunDir failed: %v", err)
+	}
+	meta := store.NewRunMeta(runID, repoID, "headless-fail", "sh", "cat", "main", "agency/headless-fail", t.TempDir(), time.Now())
+	meta.Flags = &store.RunMetaFlags{SetupFailed: true}
+	if err := st2.WriteInitialMeta(repoID, runID, meta); err != nil {
+		t.Fatalf("WriteInitialMeta failed: %v", err)
+	}
+
+	svc := NewWithDeps(agencyexec.NewRealRunner(), fs.NewRealFS())
+	svc.SetSpawnFunc(func(headless.SpawnOpts) (int, error) {
+		t.Fatal("supervisor must not be spawned when setup failed")
+		return 0, nil
+	})
+
+	err := svc.StartHeadless(context.Background(), &pipeline.PipelineState{
+		RunID: runID, RepoID: repoID, DataDir: dataDir, Headless: true, Prompt: "x",
+	})
+	if code := errors.GetCode(err); code != errors.ERunnerStartFailed {
+		t.Errorf("error code = %q, want %q", code, errors.ERunnerStartFailed)
+	}
+}
@@ -11,33 +11,29 @@ import (
 const ClaudeMDFileName = "CLAUDE.md"
 
 // ClaudeMDTemplate is the content of CLAUDE.md that instructs runners
-// on how to communicate status via .agency/state/runner_status.json.
+// on how to report status with `agency status set`.
 const ClaudeMDTemplate = `# Agency Runner Protocol
 
-Update ` + "`" + `.agency/state/runner_status.json` + "`" + ` at milestones:
+Report your status at milestones by running ` + "`" + `agency status set` + "`" + ` from this worktree:
 
-| Status | When | Required Fields |
-|--------|------|-----------------|
-| ` + "`" + `working` + "`" + ` | Actively making progress | ` + "`" + `summary` + "`" + ` |
-| ` + "`" + `needs_input` + "`" + ` | Waiting for user answer | ` + "`" + `summary` + "`" + `, ` + "`" + `questions[]` + "`" + ` |
-| ` + "`" + `blocked` + "`" + ` | Cannot proceed | ` + "`" + `summary` + "`" + `, ` + "`" + `blockers[]` + "`" + ` |
-| ` + "`" + `ready_for_review` + "`" + ` | Work complete | ` + "`" + `summary` + "`" + `, ` + "`" + `how_to_test` + "`" + ` |
+| Status | When | Required Flags |
+|--------|------|----------------|
+| ` + "`" + `working` + "`" + ` | Actively making progress | ` + "`" + `--summary` + "`" + ` |
+| ` + "`" + `needs_input` + "`" + ` | Waiting for user answer | ` + "`" + `--question` + "`" + ` (repeatable) |
+| ` + "`" + `blocked` + "`" + ` | Cannot proceed | ` + "`" + `--blocker` + "`" + ` (repeatable) |
+| ` + "`" + `ready_for_review` + "`" + ` | Work complete | ` + "`" + `--summary` + "`" + `, ` + "`" + `--how-to-test` + "`" + ` |
 
-Schema:
+Examples:
 
-` + "```" + `json
-{
-  "schema_version": "1.0",
-  "status": "working",
-  "updated_at": "2026-01-19T12:00:00Z",
-  "summary": "Implementing user authentication",
-  "questions": [],
-  "blockers": [],
-  "how_to_test": "",
-  "risks": []
-}
+` + "```" + `bash
+agency status set working --summary "Implementing user authentication"
+agency status set needs_input --question "Should sessions expire after 24h?"
+agency status set blocked --blocker "CI secrets are not available locally"
+agency status set ready_for_review --summary "Added OAuth login" --how-to-test "go test ./..." --risk "token refresh untested"
 ` + "```" + `
 
+The command validates the status and writes ` + "`" + `.agency/state/runner_status.json` + "`" + `; do not edit that file by hand. If the agency MCP server is available, its ` + "`" + `set_status` + "`" + ` and ` + "`" + `ask_question` + "`" + ` tools do the same.
+
 Before ` + "`" + `ready_for_review` + "`" + `, update ` + "`" + `.agency/report.md` + "`" + ` with summary, decisions, testing instructions, and risks.
 `
 
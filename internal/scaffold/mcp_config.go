@@ -0,0 +1,76 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+// MCPConfigFileName is the project-scoped MCP server config read by claude.
+const MCPConfigFileName = ".mcp.json"
+
+// MCPServerName is the key agency registers under mcpServers.
+const MCPServerName = "agency"
+
+// MCPConfigResult indicates what happened to .mcp.json.
+type MCPConfigResult string
+
+const (
+	MCPConfigCreated   MCPConfigResult = "created"
+	MCPConfigUpdated   MCPConfigResult = "updated"
+	MCPConfigUnchanged MCPConfigResult = "unchanged"
+)
+
+// mcpServerEntry is the agency server registration.
+var mcpServerEntry = map[string]any{
+	"command": "agency",
+	"args":    []any{"mcp"},
+}
+
+// EnsureMCPConfig registers `agency mcp` in the repo's .mcp.json, creating
+// the file if missing. Other servers and top-level keys are preserved.
+func EnsureMCPConfig(fsys fs.FS, repoRoot string) (MCPConfigResult, error) {
+	path := filepath.Join(repoRoot, MCPConfigFileName)
+
+	config := map[string]any{}
+	result := MCPConfigCreated
+	data, err := fsys.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return "", fmt.Errorf("invalid %s: %w", MCPConfigFileName, err)
+		}
+		if config == nil {
+			config = map[string]any{}
+		}
+		result = MCPConfigUpdated
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	servers, ok := config["mcpServers"].(map[string]any)
+	if !ok {
+		if config["mcpServers"] != nil {
+			return "", fmt.Errorf("invalid %s: mcpServers must be an object", MCPConfigFileName)
+		}
+		servers = map[string]any{}
+		config["mcpServers"] = servers
+	}
+	if reflect.DeepEqual(servers[MCPServerName], mcpServerEntry) {
+		return MCPConfigUnchanged, nil
+	}
+	servers[MCPServerName] = mcpServerEntry
+
+	out, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	out = append(out, '\n')
+	if err := fs.WriteFileAtomic(fsys, path, out, 0644); err != nil {
+		return "", err
+	}
+	return result, nil
+}
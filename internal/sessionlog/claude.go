@@ -0,0 +1,267 @@
+package sessionlog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// claudeHeaderLines bounds how many lines are read looking for a session's cwd.
+const claudeHeaderLines = 50
+
+// claudeLine is the subset of a Claude Code session log line that we parse.
+type claudeLine struct {
+	Type      string         `json:"type"`
+	Cwd       string         `json:"cwd"`
+	Timestamp string         `json:"timestamp"`
+	IsMeta    bool           `json:"isMeta"`
+	Message   *claudeMessage `json:"message"`
+}
+
+type claudeMessage struct {
+	ID         string          `json:"id"`
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	StopReason string          `json:"stop_reason"`
+	Usage      *claudeUsage    `json:"usage"`
+}
+
+type claudeUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+}
+
+type claudeBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// claudeShellTool is the Claude Code tool that runs shell commands.
+const claudeShellTool = "Bash"
+
+// claudeExitCodeRe extracts the exit code from a failed Bash tool result.
+var claudeExitCodeRe = regexp.MustCompile(`(?m)^Exit code (-?\d+)`)
+
+// claudeProjectDirName encodes a cwd the way Claude Code names project dirs.
+func claudeProjectDirName(cwd string) string {
+	return strings.NewReplacer("/", "-", ".", "-", "_", "-").Replace(cwd)
+}
+
+// findClaudeSession finds the newest Claude Code session whose recorded cwd is worktreePath.
+// The encoded project dir is checked first; all project dirs are scanned as a fallback.
+func findClaudeSession(projectsDir, worktreePath string, since time.Time) (*Session, error) {
+	var dirs []string
+	preferred := filepath.Join(projectsDir, claudeProjectDirName(worktreePath))
+	if info, err := os.Stat(preferred); err == nil && info.IsDir() {
+		dirs = append(dirs, preferred)
+	}
+	entries, err := os.ReadDir(projectsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			if dir := filepath.Join(projectsDir, e.Name()); dir != preferred {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	for _, dir := range dirs {
+		if sess := newestMatchingSession(dir, worktreePath, since); sess != nil {
+			return sess, nil
+		}
+	}
+	return nil, nil
+}
+
+// lookupClaudeSession locates the Claude Code session log <id>.jsonl for
+// worktreePath: in the encoded project dir, else in any project dir.
+// Returns nil if none is found.
+func lookupClaudeSession(projectsDir, worktreePath, id string) *Session {
+	paths := []string{filepath.Join(projectsDir, claudeProjectDirName(worktreePath), id+".jsonl")}
+	if matches, err := filepath.Glob(filepath.Join(projectsDir, "*", id+".jsonl")); err == nil {
+		paths = append(paths, matches...)
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if samePath(readHeaderField(path, claudeHeaderLines, claudeLineCwd), worktreePath) {
+			return &Session{Path: path, Format: FormatClaude, ModTime: info.ModTime(), ID: id}
+		}
+	}
+	return nil
+}
+
+// newestMatchingSession returns the newest *.jsonl in dir whose recorded cwd matches.
+func newestMatchingSession(dir, worktreePath string, since time.Time) *Session {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var best *Session
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".jsonl" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || (!since.IsZero() && info.ModTime().Before(since)) {
+			continue
+		}
+		if best != nil && !info.ModTime().After(best.ModTime) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if samePath(readHeaderField(path, claudeHeaderLines, claudeLineCwd), worktreePath) {
+			// Claude Code names session logs <session id>.jsonl
+			id := strings.TrimSuffix(e.Name(), ".jsonl")
+			best = &Session{Path: path, Format: FormatClaude, ModTime: info.ModTime(), ID: id}
+		}
+	}
+	return best
+}
+
+// claudeLineCwd returns the cwd recorded on a Claude Code session log line.
+func claudeLineCwd(line []byte) string {
+	var l claudeLine
+	if json.Unmarshal(line, &l) != nil {
+		return ""
+	}
+	return l.Cwd
+}
+
+// parseClaude normalizes a Claude Code session log.
+func parseClaude(r io.Reader) ([]Record, error) {
+	var records []Record
+	pendingShell := make(map[string]string) // tool_use id -> command
+	seenUsage := make(map[string]bool)      // message id -> usage emitted
+
+	err := scanLines(r, func(raw []byte) {
+		var l claudeLine
+		if json.Unmarshal(raw, &l) != nil || l.Message == nil || l.IsMeta {
+			return
+		}
+		ts := normalizeTimestamp(l.Timestamp)
+
+		switch l.Type {
+		case "user":
+			text, blocks := claudeContent(l.Message.Content)
+			if text != "" {
+				records = append(records, Record{Timestamp: ts, Kind: KindTurnStart, Text: text})
+			}
+			for _, b := range blocks {
+				switch b.Type {
+				case "text":
+					if strings.TrimSpace(b.Text) != "" {
+						records = append(records, Record{Timestamp: ts, Kind: KindTurnStart, Text: b.Text})
+					}
+				case "tool_result":
+					cmd, ok := pendingShell[b.ToolUseID]
+					if !ok {
+						continue
+					}
+					delete(pendingShell, b.ToolUseID)
+					records = append(records, Record{
+						Timestamp: ts,
+						Kind:      KindShell,
+						Tool:      claudeShellTool,
+						Command:   cmd,
+						ExitCode:  claudeShellExitCode(b),
+					})
+				}
+			}
+
+		case "assistant":
+			_, blocks := claudeContent(l.Message.Content)
+			for _, b := range blocks {
+				switch b.Type {
+				case "text":
+					if strings.TrimSpace(b.Text) != "" {
+						records = append(records, Record{Timestamp: ts, Kind: KindAssistantText, Text: b.Text})
+					}
+				case "tool_use":
+					if b.Name == claudeShellTool {
+						// Emitted as a shell record once its result (and exit code) arrives
+						pendingShell[b.ID] = claudeInputString(b.Input, "command")
+						continue
+					}
+					records = append(records, Record{
+						Timestamp: ts,
+						Kind:      KindToolCall,
+						Tool:      b.Name,
+						Input:     summarizeInput(b.Input),
+					})
+				}
+			}
+			// Claude Code writes one line per content block with the same message id
+			// and usage; count usage once per message.
+			if u := l.Message.Usage; u != nil && (l.Message.ID == "" || !seenUsage[l.Message.ID]) {
+				seenUsage[l.Message.ID] = true
+				records = append(records, Record{Timestamp: ts, Kind: KindUsage, Usage: &Usage{
+					InputTokens:      u.InputTokens,
+					OutputTokens:     u.OutputTokens,
+					CacheReadTokens:  u.CacheReadInputTokens,
+					CacheWriteTokens: u.CacheCreationInputTokens,
+				}})
+			}
+			if l.Message.StopReason == "end_turn" {
+				records = append(records, Record{Timestamp: ts, Kind: KindTurnEnd})
+			}
+		}
+	})
+	return records, err
+}
+
+// claudeContent decodes message content, which is either a string or a list of blocks.
+func claudeContent(raw json.RawMessage) (string, []claudeBlock) {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return strings.TrimSpace(text), nil
+	}
+	var blocks []claudeBlock
+	_ = json.Unmarshal(raw, &blocks)
+	return "", blocks
+}
+
+// claudeShellExitCode derives a Bash tool exit code: 0 on success, otherwise
+// the "Exit code N" prefix Claude Code writes into failed results (1 if absent).
+func claudeShellExitCode(b claudeBlock) *int {
+	if !b.IsError {
+		return intPtr(0)
+	}
+	text, blocks := claudeContent(b.Content)
+	for _, blk := range blocks {
+		text += blk.Text + "\n"
+	}
+	if m := claudeExitCodeRe.FindStringSubmatch(text); m != nil {
+		if code, ok := atoi(m[1]); ok {
+			return intPtr(code)
+		}
+	}
+	return intPtr(1)
+}
+
+// claudeInputString returns a string field from a tool input object.
+func claudeInputString(input json.RawMessage, key string) string {
+	var m map[string]any
+	if json.Unmarshal(input, &m) != nil {
+		return ""
+	}
+	s, _ := m[key].(string)
+	return s
+}
@@ -0,0 +1,286 @@
+package sessionlog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// codexHeaderLines bounds how many lines are read looking for a session's cwd.
+const codexHeaderLines = 20
+
+// codexLine is the envelope of a Codex rollout log line.
+type codexLine struct {
+	Timestamp string          `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// codexPayload is the subset of Codex payload fields that we parse.
+type codexPayload struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Cwd       string          `json:"cwd"`
+	Role      string          `json:"role"`
+	Content   []codexContent  `json:"content"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+	Input     json.RawMessage `json:"input"`
+	CallID    string          `json:"call_id"`
+	Output    json.RawMessage `json:"output"`
+	Message   string          `json:"message"`
+	Info      *codexTokenInfo `json:"info"`
+}
+
+type codexContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type codexTokenInfo struct {
+	LastTokenUsage *codexUsage `json:"last_token_usage"`
+}
+
+type codexUsage struct {
+	InputTokens       int `json:"input_tokens"`
+	CachedInputTokens int `json:"cached_input_tokens"`
+	OutputTokens      int `json:"output_tokens"`
+}
+
+// codexShellTools are the Codex function names that run shell commands.
+var codexShellTools = map[string]bool{
+	"shell":         true,
+	"shell_command": true,
+	"exec_command":  true,
+	"local_shell":   true,
+}
+
+// codexExitCodeRe extracts the exit code from plain-text shell output.
+var codexExitCodeRe = regexp.MustCompile(`(?m)^Exit code: (-?\d+)`)
+
+// findCodexSession finds the newest Codex rollout log whose session_meta cwd is worktreePath.
+// Rollouts live at sessions/YYYY/MM/DD/rollout-*.jsonl.
+func findCodexSession(sessionsDir, worktreePath string, since time.Time) (*Session, error) {
+	var best *Session
+	err := filepath.WalkDir(sessionsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == sessionsDir {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasPrefix(d.Name(), "rollout-") || filepath.Ext(d.Name()) != ".jsonl" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || (!since.IsZero() && info.ModTime().Before(since)) {
+			return nil
+		}
+		if best != nil && !info.ModTime().After(best.ModTime) {
+			return nil
+		}
+		if samePath(readHeaderField(path, codexHeaderLines, codexLineCwd), worktreePath) {
+			id := readHeaderField(path, codexHeaderLines, codexLineSessionID)
+			best = &Session{Path: path, Format: FormatCodex, ModTime: info.ModTime(), ID: id}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return best, nil
+}
+
+// lookupCodexSession locates the Codex rollout log of thread id for
+// worktreePath. Rollouts are named rollout-<timestamp>-<id>.jsonl.
+// Returns nil if none is found.
+func lookupCodexSession(sessionsDir, worktreePath, id string) *Session {
+	matches, err := filepath.Glob(filepath.Join(sessionsDir, "*", "*", "*", "rollout-*"+id+".jsonl"))
+	if err != nil {
+		return nil
+	}
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if readHeaderField(path, codexHeaderLines, codexLineSessionID) == id &&
+			samePath(readHeaderField(path, codexHeaderLines, codexLineCwd), worktreePath) {
+			return &Session{Path: path, Format: FormatCodex, ModTime: info.ModTime(), ID: id}
+		}
+	}
+	return nil
+}
+
+// codexLineCwd returns the cwd recorded on a session_meta or turn_context line.
+func codexLineCwd(line []byte) string {
+	var l codexLine
+	if json.Unmarshal(line, &l) != nil || (l.Type != "session_meta" && l.Type != "turn_context") {
+		return ""
+	}
+	var p codexPayload
+	if json.Unmarshal(l.Payload, &p) != nil {
+		return ""
+	}
+	return p.Cwd
+}
+
+// codexLineSessionID returns the thread id recorded on a session_meta line.
+func codexLineSessionID(line []byte) string {
+	var l codexLine
+	if json.Unmarshal(line, &l) != nil || l.Type != "session_meta" {
+		return ""
+	}
+	var p codexPayload
+	if json.Unmarshal(l.Payload, &p) != nil {
+		return ""
+	}
+	return p.ID
+}
+
+// parseCodex normalizes a Codex rollout log.
+func parseCodex(r io.Reader) ([]Record, error) {
+	var records []Record
+	pendingShell := make(map[string]string) // call_id -> command
+
+	err := scanLines(r, func(raw []byte) {
+		var l codexLine
+		if json.Unmarshal(raw, &l) != nil {
+			return
+		}
+		var p codexPayload
+		if json.Unmarshal(l.Payload, &p) != nil {
+			return
+		}
+		ts := normalizeTimestamp(l.Timestamp)
+
+		switch l.Type {
+		case "response_item":
+			switch p.Type {
+			case "message":
+				if p.Role != "assistant" {
+					return
+				}
+				for _, c := range p.Content {
+					if c.Type == "output_text" && strings.TrimSpace(c.Text) != "" {
+						records = append(records, Record{Timestamp: ts, Kind: KindAssistantText, Text: c.Text})
+					}
+				}
+			case "function_call", "custom_tool_call":
+				args := p.Arguments
+				if len(args) == 0 {
+					args = p.Input
+				}
+				if codexShellTools[p.Name] {
+					// Emitted as a shell record once its output (and exit code) arrives
+					pendingShell[p.CallID] = codexShellCommand(args)
+					return
+				}
+				records = append(records, Record{
+					Timestamp: ts,
+					Kind:      KindToolCall,
+					Tool:      p.Name,
+					Input:     summarizeInput(args),
+				})
+			case "function_call_output", "custom_tool_call_output":
+				cmd, ok := pendingShell[p.CallID]
+				if !ok {
+					return
+				}
+				delete(pendingShell, p.CallID)
+				records = append(records, Record{
+					Timestamp: ts,
+					Kind:      KindShell,
+					Tool:      "shell",
+					Command:   cmd,
+					ExitCode:  codexShellExitCode(p.Output),
+				})
+			}
+
+		case "event_msg":
+			switch p.Type {
+			case "task_started", "user_message":
+				// Both mark the start of a turn; merge adjacent ones
+				if n := len(records); n > 0 && records[n-1].Kind == KindTurnStart {
+					if p.Message != "" {
+						records[n-1].Text = p.Message
+					}
+					return
+				}
+				records = append(records, Record{Timestamp: ts, Kind: KindTurnStart, Text: p.Message})
+			case "task_complete":
+				records = append(records, Record{Timestamp: ts, Kind: KindTurnEnd})
+			case "token_count":
+				if p.Info == nil || p.Info.LastTokenUsage == nil {
+					return
+				}
+				u := p.Info.LastTokenUsage
+				records = append(records, Record{Timestamp: ts, Kind: KindUsage, Usage: &Usage{
+					InputTokens:     u.InputTokens,
+					OutputTokens:    u.OutputTokens,
+					CacheReadTokens: u.CachedInputTokens,
+				}})
+			}
+		}
+	})
+	return records, err
+}
+
+// codexShellCommand extracts the command from shell tool arguments. Arguments
+// are a JSON-encoded object whose "command" is an argv array (bash -lc wrappers
+// are unwrapped) or a string; exec_command uses "cmd".
+func codexShellCommand(args json.RawMessage) string {
+	var s string
+	if json.Unmarshal(args, &s) == nil {
+		args = json.RawMessage(s)
+	}
+	var a struct {
+		Command json.RawMessage `json:"command"`
+		Cmd     string          `json:"cmd"`
+	}
+	if json.Unmarshal(args, &a) != nil {
+		return ""
+	}
+	var argv []string
+	if json.Unmarshal(a.Command, &argv) == nil && len(argv) > 0 {
+		if len(argv) == 3 && (argv[1] == "-lc" || argv[1] == "-c") {
+			return argv[2]
+		}
+		return strings.Join(argv, " ")
+	}
+	var cmd string
+	if json.Unmarshal(a.Command, &cmd) == nil && cmd != "" {
+		return cmd
+	}
+	return a.Cmd
+}
+
+// codexShellExitCode extracts the exit code from a shell call output, which is
+// either JSON with metadata.exit_code or text containing "Exit code: N".
+func codexShellExitCode(output json.RawMessage) *int {
+	text := string(output)
+	var s string
+	if json.Unmarshal(output, &s) == nil {
+		text = s
+	}
+	var o struct {
+		Metadata *struct {
+			ExitCode *int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal([]byte(text), &o) == nil && o.Metadata != nil && o.Metadata.ExitCode != nil {
+		return o.Metadata.ExitCode
+	}
+	if m := codexExitCodeRe.FindStringSubmatch(text); m != nil {
+		if code, ok := atoi(m[1]); ok {
+			return intPtr(code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,499 @@
+// Package sessionlog finds the on-disk session log a runner (Claude Code or
+// Codex) wrote for a run's worktree and normalizes it into stream.jsonl
+// records: assistant text, tool calls, shell commands with exit codes, turn
+// boundaries, and token usage.
+//
+// Sessions are matched to a worktree by the cwd recorded inside the session
+// log, never by file naming alone.
+package sessionlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+// Format identifies the source session log format.
+type Format string
+
+// Supported session log formats.
+const (
+	FormatClaude Format = "claude"
+	FormatCodex  Format = "codex"
+)
+
+// Kind is the normalized record kind written to stream.jsonl.
+type Kind string
+
+// Normalized record kinds.
+const (
+	KindAssistantText Kind = "assistant_text"
+	KindToolCall      Kind = "tool_call"
+	KindShell         Kind = "shell"
+	KindTurnStart     Kind = "turn_start"
+	KindTurnEnd       Kind = "turn_end"
+	KindUsage         Kind = "usage"
+)
+
+// Record is one normalized activity record (one line of stream.jsonl).
+// This is the public contract for the stream.jsonl format.
+type Record struct {
+	// Timestamp is the source timestamp in RFC3339 (UTC, sub-second precision kept).
+	Timestamp string `json:"ts"`
+
+	// Kind is the record kind.
+	Kind Kind `json:"kind"`
+
+	// Text is the assistant text (assistant_text) or prompt text (turn_start).
+	Text string `json:"text,omitempty"`
+
+	// Tool is the tool name (tool_call, shell).
+	Tool string `json:"tool,omitempty"`
+
+	// Input is a short summary of the tool input (tool_call).
+	Input string `json:"input,omitempty"`
+
+	// Command is the shell command (shell).
+	Command string `json:"command,omitempty"`
+
+	// ExitCode is the shell exit code (shell; nil if unknown).
+	ExitCode *int `json:"exit_code,omitempty"`
+
+	// Usage is the token usage for one model response (usage).
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Usage is normalized token usage.
+type Usage struct {
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+}
+
+// Time parses the record timestamp. Returns zero time if invalid.
+func (r Record) Time() time.Time {
+	t, err := time.Parse(time.RFC3339Nano, r.Timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// IsAction reports whether the record describes something the runner did
+// (everything except usage accounting).
+func (r Record) IsAction() bool {
+	return r.Kind != KindUsage
+}
+
+// Describe returns a one-line human description of the record ("last action").
+func (r Record) Describe() string {
+	switch r.Kind {
+	case KindAssistantText:
+		return "said: " + truncate(firstLine(r.Text), 80)
+	case KindToolCall:
+		if r.Input != "" {
+			return r.Tool + " " + truncate(r.Input, 80)
+		}
+		return r.Tool
+	case KindShell:
+		desc := "ran `" + truncate(firstLine(r.Command), 80) + "`"
+		if r.ExitCode != nil {
+			desc += fmt.Sprintf(" (exit %d)", *r.ExitCode)
+		}
+		return desc
+	case KindTurnStart:
+		return "received prompt"
+	case KindTurnEnd:
+		return "finished turn"
+	case KindUsage:
+		return "usage"
+	default:
+		return string(r.Kind)
+	}
+}
+
+// Session is a located session log.
+type Session struct {
+	// Path is the absolute path to the source session log.
+	Path string
+
+	// Format is the source format.
+	Format Format
+
+	// ID is the runner's session id (Claude session id, Codex thread id);
+	// empty if unknown.
+	ID string
+
+	// ModTime is the session log modification time.
+	ModTime time.Time
+}
+
+// Sources holds the root directories searched for session logs.
+type Sources struct {
+	// ClaudeProjectsDir is Claude Code's projects dir (default: ~/.claude/projects).
+	ClaudeProjectsDir string
+
+	// CodexSessionsDir is Codex's sessions dir (default: ~/.codex/sessions).
+	CodexSessionsDir string
+}
+
+// Env provides environment variable lookup.
+type Env interface {
+	Get(key string) string
+}
+
+// DefaultSources returns the session log roots, honoring CLAUDE_CONFIG_DIR and CODEX_HOME.
+func DefaultSources(env Env, homeDir string) Sources {
+	claudeDir := env.Get("CLAUDE_CONFIG_DIR")
+	if claudeDir == "" {
+		claudeDir = filepath.Join(homeDir, ".claude")
+	}
+	codexDir := env.Get("CODEX_HOME")
+	if codexDir == "" {
+		codexDir = filepath.Join(homeDir, ".codex")
+	}
+	return Sources{
+		ClaudeProjectsDir: filepath.Join(claudeDir, "projects"),
+		CodexSessionsDir:  filepath.Join(codexDir, "sessions"),
+	}
+}
+
+// Find locates the most recently modified session log whose recorded cwd is
+// worktreePath. runner selects the format ("claude" or "codex"); any other
+// runner searches both. Only logs modified at or after since are considered
+// (zero since disables the filter). Returns (nil, nil) if none is found.
+func (s Sources) Find(runner, worktreePath string, since time.Time) (*Session, error) {
+	var candidates []*Session
+
+	if runner != string(FormatCodex) && s.ClaudeProjectsDir != "" {
+		sess, err := findClaudeSession(s.ClaudeProjectsDir, worktreePath, since)
+		if err != nil {
+			return nil, err
+		}
+		if sess != nil {
+			candidates = append(candidates, sess)
+		}
+	}
+	if runner != string(FormatClaude) && s.CodexSessionsDir != "" {
+		sess, err := findCodexSession(s.CodexSessionsDir, worktreePath, since)
+		if err != nil {
+			return nil, err
+		}
+		if sess != nil {
+			candidates = append(candidates, sess)
+		}
+	}
+
+	var best *Session
+	for _, c := range candidates {
+		if best == nil || c.ModTime.After(best.ModTime) {
+			best = c
+		}
+	}
+	return best, nil
+}
+
+// Locate returns the run's session log. With a known sessionID (recorded
+// from an earlier discovery) the log is looked up by id, without Find's scan
+// of every session under the roots; an unknown id returns (nil, nil). A newer
+// Claude Code session for the worktree in its project dir (e.g. after /clear)
+// replaces the known one. Find runs only when sessionID is empty.
+func (s Sources) Locate(runner, worktreePath, sessionID string, since time.Time) (*Session, error) {
+	if sessionID == "" {
+		return s.Find(runner, worktreePath, since)
+	}
+	if strings.ContainsAny(sessionID, `/\*?[`) {
+		return nil, nil
+	}
+
+	var sess *Session
+	if runner != string(FormatCodex) && s.ClaudeProjectsDir != "" {
+		sess = lookupClaudeSession(s.ClaudeProjectsDir, worktreePath, sessionID)
+		if sess != nil {
+			preferred := filepath.Join(s.ClaudeProjectsDir, claudeProjectDirName(worktreePath))
+			if newer := newestMatchingSession(preferred, worktreePath, sess.ModTime.Add(time.Nanosecond)); newer != nil {
+				sess = newer
+			}
+		}
+	}
+	if sess == nil && runner != string(FormatClaude) && s.CodexSessionsDir != "" {
+		sess = lookupCodexSession(s.CodexSessionsDir, worktreePath, sessionID)
+	}
+	return sess, nil
+}
+
+// ResumeArgs returns the arguments that make a runner continue the
+// conversation with the given session id. Unknown runners or an empty id get nil.
+func ResumeArgs(runner, sessionID string) []string {
+	if sessionID == "" {
+		return nil
+	}
+	switch Format(runner) {
+	case FormatClaude:
+		return []string{"--resume", sessionID}
+	case FormatCodex:
+		return []string{"resume", sessionID}
+	default:
+		return nil
+	}
+}
+
+// Parse reads a session log and returns its normalized records.
+// Malformed lines are skipped.
+func Parse(sess *Session) ([]Record, error) {
+	f, err := os.Open(sess.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	switch sess.Format {
+	case FormatClaude:
+		return parseClaude(f)
+	case FormatCodex:
+		return parseCodex(f)
+	default:
+		return nil, fmt.Errorf("unknown session log format: %s", sess.Format)
+	}
+}
+
+// Activity summarizes normalized records.
+type Activity struct {
+	// Session is the source session log.
+	Session *Session
+
+	// StreamPath is the path to the written stream.jsonl.
+	StreamPath string
+
+	// Records is the number of normalized records.
+	Records int
+
+	// LastActivityAt is the timestamp of the last action record (zero if none).
+	LastActivityAt time.Time
+
+	// LastAction describes the last action record ("" if none).
+	LastAction string
+}
+
+// Sync locates the run's session log (see Locate), normalizes it and writes
+// streamPath atomically. The stream is only rebuilt when the session log is
+// newer than the existing stream file. Returns (nil, nil) if no session log
+// is found.
+func Sync(sources Sources, runner, worktreePath, sessionID string, since time.Time, streamPath string) (*Activity, error) {
+	return load(sources, runner, worktreePath, sessionID, since, streamPath, true)
+}
+
+// Load is like Sync but never writes: a stale or missing stream is parsed
+// from the session log in memory. Used by read-only commands.
+func Load(sources Sources, runner, worktreePath, sessionID string, since time.Time, streamPath string) (*Activity, error) {
+	return load(sources, runner, worktreePath, sessionID, since, streamPath, false)
+}
+
+func load(sources Sources, runner, worktreePath, sessionID string, since time.Time, streamPath string, write bool) (*Activity, error) {
+	sess, err := sources.Locate(runner, worktreePath, sessionID, since)
+	if err != nil || sess == nil {
+		return nil, err
+	}
+
+	var records []Record
+	if info, statErr := os.Stat(streamPath); statErr == nil && !info.ModTime().Before(sess.ModTime) {
+		records, err = ReadStream(streamPath)
+	} else {
+		records, err = Parse(sess)
+		if err == nil && write {
+			err = WriteStream(streamPath, records)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	act := Summarize(records)
+	act.Session = sess
+	act.StreamPath = streamPath
+	return act, nil
+}
+
+// Summarize computes activity from normalized records.
+func Summarize(records []Record) *Activity {
+	act := &Activity{Records: len(records)}
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].IsAction() {
+			act.LastActivityAt = records[i].Time()
+			act.LastAction = records[i].Describe()
+			break
+		}
+	}
+	return act
+}
+
+// WriteStream writes records as JSONL to path atomically.
+func WriteStream(path string, records []Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	return fs.WriteFileAtomic(fs.NewRealFS(), path, []byte(b.String()), 0o644)
+}
+
+// ReadStream reads normalized records from a stream.jsonl file.
+// Malformed lines are skipped.
+func ReadStream(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []Record
+	err = scanLines(f, func(line []byte) {
+		var r Record
+		if json.Unmarshal(line, &r) == nil && r.Kind != "" {
+			records = append(records, r)
+		}
+	})
+	return records, err
+}
+
+// maxLineBytes bounds a single JSONL line (tool outputs can be large).
+const maxLineBytes = 64 * 1024 * 1024
+
+// scanLines calls fn for each non-empty line in r.
+func scanLines(r io.Reader, fn func(line []byte)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		fn(line)
+	}
+	return sc.Err()
+}
+
+// readHeaderField returns the first non-empty value extract pulls from a
+// session log line (e.g. the recorded cwd). Reads at most maxLines lines.
+func readHeaderField(path string, maxLines int, extract func(line []byte) string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	for i := 0; i < maxLines && sc.Scan(); i++ {
+		if v := extract(sc.Bytes()); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// samePath reports whether two paths refer to the same directory,
+// resolving symlinks where possible (e.g. /var vs /private/var on macOS).
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if a == b {
+		return true
+	}
+	ra, errA := filepath.EvalSymlinks(a)
+	rb, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && ra == rb
+}
+
+// normalizeTimestamp converts a source timestamp to RFC3339Nano UTC.
+// Unparseable timestamps are returned unchanged.
+func normalizeTimestamp(ts string) string {
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return ts
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// firstLine returns the first non-empty line of s, trimmed.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// truncate shortens s to at most n runes, appending "…" if truncated.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// inputSummaryKeys are the tool input fields tried, in order, to summarize a tool call.
+var inputSummaryKeys = []string{"file_path", "path", "pattern", "command", "cmd", "url", "query", "description"}
+
+// summarizeInput returns a short description of a JSON tool input: the first
+// well-known field present, or "" if none.
+func summarizeInput(input json.RawMessage) string {
+	var m map[string]any
+	if json.Unmarshal(input, &m) != nil {
+		// Codex passes some tool arguments as a JSON-encoded string
+		var s string
+		if json.Unmarshal(input, &s) != nil || json.Unmarshal([]byte(s), &m) != nil {
+			return ""
+		}
+	}
+	for _, key := range inputSummaryKeys {
+		switch v := m[key].(type) {
+		case string:
+			if v != "" {
+				return firstLine(v)
+			}
+		case []any:
+			parts := make([]string, 0, len(v))
+			for _, p := range v {
+				if ps, ok := p.(string); ok {
+					parts = append(parts, ps)
+				}
+			}
+			if len(parts) > 0 {
+				return strings.Join(parts, " ")
+			}
+		}
+	}
+	return ""
+}
+
+// atoi parses a decimal integer.
+func atoi(s string) (int, bool) {
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// intPtr returns a pointer to v.
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,288 @@
+package sessionlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mapEnv map[string]string
+
+func (m mapEnv) Get(key string) string { return m[key] }
+
+// claudeFixture is a Claude Code session: a prompt, text, a Read tool call,
+// a failing Bash call, and a final answer.
+const claudeFixture = `{"type":"summary","summary":"x"}
+{"type":"user","cwd":"%CWD%","timestamp":"2026-01-02T10:00:00.000Z","message":{"role":"user","content":"fix the bug"}}
+{"type":"user","cwd":"%CWD%","isMeta":true,"timestamp":"2026-01-02T10:00:00.500Z","message":{"role":"user","content":"<meta>"}}
+{"type":"assistant","cwd":"%CWD%","timestamp":"2026-01-02T10:00:01.000Z","message":{"id":"msg_1","role":"assistant","content":[{"type":"text","text":"Looking at it."}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}
+{"type":"assistant","cwd":"%CWD%","timestamp":"2026-01-02T10:00:02.000Z","message":{"id":"msg_1","role":"assistant","content":[{"type":"tool_use","id":"tu_1","name":"Read","input":{"file_path":"/w/main.go"}}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}
+{"type":"user","cwd":"%CWD%","timestamp":"2026-01-02T10:00:03.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_1","content":"package main"}]}}
+{"type":"assistant","cwd":"%CWD%","timestamp":"2026-01-02T10:00:04.000Z","message":{"id":"msg_2","role":"assistant","content":[{"type":"tool_use","id":"tu_2","name":"Bash","input":{"command":"go test ./..."}}],"usage":{"input_tokens":20,"output_tokens":7}}}
+{"type":"user","cwd":"%CWD%","timestamp":"2026-01-02T10:00:09.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_2","is_error":true,"content":"Exit code 2\nFAIL"}]}}
+{"type":"assistant","cwd":"%CWD%","timestamp":"2026-01-02T10:00:10.000Z","message":{"id":"msg_3","role":"assistant","stop_reason":"end_turn","content":[{"type":"text","text":"Tests fail.\nDetails below."}],"usage":{"input_tokens":30,"output_tokens":9}}}
+not json
+`
+
+// codexFixture is a Codex rollout: a turn with text, a shell call, an apply_patch
+// call, token usage, and completion.
+const codexFixture = `{"timestamp":"2026-01-02T11:00:00.000Z","type":"session_meta","payload":{"id":"s1","cwd":"%CWD%"}}
+{"timestamp":"2026-01-02T11:00:01.000Z","type":"event_msg","payload":{"type":"task_started"}}
+{"timestamp":"2026-01-02T11:00:01.100Z","type":"event_msg","payload":{"type":"user_message","message":"add a flag"}}
+{"timestamp":"2026-01-02T11:00:02.000Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"add a flag"}]}}
+{"timestamp":"2026-01-02T11:00:03.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"On it."}]}}
+{"timestamp":"2026-01-02T11:00:04.000Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"bash\",\"-lc\",\"ls -la\"]}","call_id":"c1"}}
+{"timestamp":"2026-01-02T11:00:05.000Z","type":"response_item","payload":{"type":"function_call_output","call_id":"c1","output":"{\"output\":\"ok\",\"metadata\":{\"exit_code\":0}}"}}
+{"timestamp":"2026-01-02T11:00:06.000Z","type":"response_item","payload":{"type":"function_call","name":"shell_command","arguments":"{\"command\":\"make\"}","call_id":"c2"}}
+{"timestamp":"2026-01-02T11:00:07.000Z","type":"response_item","payload":{"type":"function_call_output","call_id":"c2","output":"Exit code: 3\nOutput:\nboom"}}
+{"timestamp":"2026-01-02T11:00:08.000Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch","call_id":"c3"}}
+{"timestamp":"2026-01-02T11:00:09.000Z","type":"event_msg","payload":{"type":"token_count","info":{"last_token_usage":{"input_tokens":50,"cached_input_tokens":40,"output_tokens":6}}}}
+{"timestamp":"2026-01-02T11:00:10.000Z","type":"event_msg","payload":{"type":"task_complete"}}
+`
+
+func writeFixture(t *testing.T, path, content, cwd string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(content, "%CWD%", cwd)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseClaude(t *testing.T) {
+	records, err := parseClaude(strings.NewReader(strings.ReplaceAll(claudeFixture, "%CWD%", "/w")))
+	if err != nil {
+		t.Fatalf("parseClaude() error = %v", err)
+	}
+
+	var kinds []string
+	for _, r := range records {
+		kinds = append(kinds, string(r.Kind))
+	}
+	want := "turn_start assistant_text usage tool_call usage shell assistant_text usage turn_end"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Fatalf("kinds = %q, want %q", got, want)
+	}
+
+	if records[0].Text != "fix the bug" {
+		t.Errorf("turn_start text = %q", records[0].Text)
+	}
+	if records[3].Tool != "Read" || records[3].Input != "/w/main.go" {
+		t.Errorf("tool_call = %+v", records[3])
+	}
+	shell := records[5]
+	if shell.Command != "go test ./..." || shell.ExitCode == nil || *shell.ExitCode != 2 {
+		t.Errorf("shell = %+v", shell)
+	}
+	if shell.Timestamp != "2026-01-02T10:00:09Z" {
+		t.Errorf("shell timestamp = %q, want result time", shell.Timestamp)
+	}
+	if u := records[2].Usage; u.InputTokens != 10 || u.OutputTokens != 5 || u.CacheReadTokens != 100 {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestParseCodex(t *testing.T) {
+	records, err := parseCodex(strings.NewReader(strings.ReplaceAll(codexFixture, "%CWD%", "/w")))
+	if err != nil {
+		t.Fatalf("parseCodex() error = %v", err)
+	}
+
+	var kinds []string
+	for _, r := range records {
+		kinds = append(kinds, string(r.Kind))
+	}
+	want := "turn_start assistant_text shell shell tool_call usage turn_end"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Fatalf("kinds = %q, want %q", got, want)
+	}
+
+	if records[0].Text != "add a flag" {
+		t.Errorf("turn_start text = %q", records[0].Text)
+	}
+	if r := records[2]; r.Command != "ls -la" || r.ExitCode == nil || *r.ExitCode != 0 {
+		t.Errorf("shell[0] = %+v", r)
+	}
+	if r := records[3]; r.Command != "make" || r.ExitCode == nil || *r.ExitCode != 3 {
+		t.Errorf("shell[1] = %+v", r)
+	}
+	if r := records[4]; r.Tool != "apply_patch" {
+		t.Errorf("tool_call = %+v", r)
+	}
+	if u := records[5].Usage; u.InputTokens != 50 || u.CacheReadTokens != 40 || u.OutputTokens != 6 {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestFind_MatchesByCwd(t *testing.T) {
+	home := t.TempDir()
+	wt := t.TempDir()
+	other := t.TempDir()
+	sources := DefaultSources(mapEnv{}, home)
+
+	// Claude session for another worktree in the encoded dir, and ours under an unrelated dir name
+	writeFixture(t, filepath.Join(sources.ClaudeProjectsDir, claudeProjectDirName(wt), "a.jsonl"), claudeFixture, other)
+	ours := filepath.Join(sources.ClaudeProjectsDir, "renamed", "4f9c2a1e-b.jsonl")
+	writeFixture(t, ours, claudeFixture, wt)
+
+	sess, err := sources.Find("claude", wt, time.Time{})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if sess == nil || sess.Path != ours || sess.Format != FormatClaude || sess.ID != "4f9c2a1e-b" {
+		t.Fatalf("Find() = %+v, want %s", sess, ours)
+	}
+
+	// Codex runner only searches codex sessions
+	if sess, _ := sources.Find("codex", wt, time.Time{}); sess != nil {
+		t.Errorf("Find(codex) = %+v, want nil", sess)
+	}
+	rollout := filepath.Join(sources.CodexSessionsDir, "2026", "01", "02", "rollout-x.jsonl")
+	writeFixture(t, rollout, codexFixture, wt)
+	sess, err = sources.Find("codex", wt, time.Time{})
+	if err != nil || sess == nil || sess.Path != rollout || sess.Format != FormatCodex || sess.ID != "s1" {
+		t.Fatalf("Find(codex) = %+v, %v", sess, err)
+	}
+
+	// since filters out logs older than the run
+	if sess, _ := sources.Find("claude", wt, time.Now().Add(time.Hour)); sess != nil {
+		t.Errorf("Find(since future) = %+v, want nil", sess)
+	}
+}
+
+func TestLocate_KnownSession(t *testing.T) {
+	home := t.TempDir()
+	wt := t.TempDir()
+	sources := DefaultSources(mapEnv{}, home)
+
+	// Known Claude session outside the encoded dir is found by id
+	ours := filepath.Join(sources.ClaudeProjectsDir, "renamed", "4f9c2a1e-b.jsonl")
+	writeFixture(t, ours, claudeFixture, wt)
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(ours, old, old); err != nil {
+		t.Fatal(err)
+	}
+	sess, err := sources.Locate("claude", wt, "4f9c2a1e-b", time.Time{})
+	if err != nil || sess == nil || sess.Path != ours || sess.ID != "4f9c2a1e-b" {
+		t.Fatalf("Locate() = %+v, %v", sess, err)
+	}
+
+	// Unknown or malformed ids are not found (and never fall back to a scan)
+	for _, id := range []string{"missing", "*", "../renamed/4f9c2a1e-b"} {
+		if sess, _ := sources.Locate("claude", wt, id, time.Time{}); sess != nil {
+			t.Errorf("Locate(%q) = %+v, want nil", id, sess)
+		}
+	}
+
+	// A newer session for the worktree in its project dir (e.g. after /clear) wins
+	newer := filepath.Join(sources.ClaudeProjectsDir, claudeProjectDirName(wt), "c-new.jsonl")
+	writeFixture(t, newer, claudeFixture, wt)
+	sess, _ = sources.Locate("claude", wt, "4f9c2a1e-b", time.Time{})
+	if sess == nil || sess.Path != newer || sess.ID != "c-new" {
+		t.Fatalf("Locate() = %+v, want %s", sess, newer)
+	}
+
+	// Codex rollouts are found by the id in their name and session_meta
+	rollout := filepath.Join(sources.CodexSessionsDir, "2026", "01", "02", "rollout-2026-01-02T11-00-00-s1.jsonl")
+	writeFixture(t, rollout, codexFixture, wt)
+	sess, err = sources.Locate("codex", wt, "s1", time.Time{})
+	if err != nil || sess == nil || sess.Path != rollout || sess.Format != FormatCodex || sess.ID != "s1" {
+		t.Fatalf("Locate(codex) = %+v, %v", sess, err)
+	}
+	if sess, _ := sources.Locate("codex", t.TempDir(), "s1", time.Time{}); sess != nil {
+		t.Errorf("Locate(codex, other worktree) = %+v, want nil", sess)
+	}
+}
+
+func TestResumeArgs(t *testing.T) {
+	tests := []struct {
+		runner, id string
+		want       string
+	}{
+		{"claude", "abc", "--resume abc"},
+		{"codex", "abc", "resume abc"},
+		{"claude", "", ""},
+		{"aider", "abc", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(ResumeArgs(tt.runner, tt.id), " "); got != tt.want {
+			t.Errorf("ResumeArgs(%q, %q) = %q, want %q", tt.runner, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultSources_EnvOverrides(t *testing.T) {
+	s := DefaultSources(mapEnv{"CLAUDE_CONFIG_DIR": "/c", "CODEX_HOME": "/x"}, "/home/u")
+	if s.ClaudeProjectsDir != "/c/projects" || s.CodexSessionsDir != "/x/sessions" {
+		t.Errorf("DefaultSources() = %+v", s)
+	}
+	s = DefaultSources(mapEnv{}, "/home/u")
+	if s.ClaudeProjectsDir != "/home/u/.claude/projects" || s.CodexSessionsDir != "/home/u/.codex/sessions" {
+		t.Errorf("DefaultSources() = %+v", s)
+	}
+}
+
+func TestSync_WritesStreamAndSummarizes(t *testing.T) {
+	home := t.TempDir()
+	wt := t.TempDir()
+	sources := DefaultSources(mapEnv{}, home)
+	writeFixture(t, filepath.Join(sources.ClaudeProjectsDir, "p", "s.jsonl"), claudeFixture, wt)
+	streamPath := filepath.Join(t.TempDir(), "logs", "stream.jsonl")
+
+	// Load never writes
+	act, err := Load(sources, "claude", wt, "", time.Time{}, streamPath)
+	if err != nil || act == nil {
+		t.Fatalf("Load() = %+v, %v", act, err)
+	}
+	if _, err := os.Stat(streamPath); !os.IsNotExist(err) {
+		t.Fatalf("Load() wrote stream: %v", err)
+	}
+
+	act, err = Sync(sources, "claude", wt, "", time.Time{}, streamPath)
+	if err != nil || act == nil {
+		t.Fatalf("Sync() = %+v, %v", act, err)
+	}
+	if act.Records != 9 {
+		t.Errorf("Records = %d, want 9", act.Records)
+	}
+	if act.LastAction != "finished turn" {
+		t.Errorf("LastAction = %q", act.LastAction)
+	}
+	if want := time.Date(2026, 1, 2, 10, 0, 10, 0, time.UTC); !act.LastActivityAt.Equal(want) {
+		t.Errorf("LastActivityAt = %v, want %v", act.LastActivityAt, want)
+	}
+
+	records, err := ReadStream(streamPath)
+	if err != nil || len(records) != 9 {
+		t.Fatalf("ReadStream() = %d records, %v", len(records), err)
+	}
+}
+
+func TestSync_NoSession(t *testing.T) {
+	sources := DefaultSources(mapEnv{}, t.TempDir())
+	act, err := Sync(sources, "claude", t.TempDir(), "", time.Time{}, filepath.Join(t.TempDir(), "stream.jsonl"))
+	if err != nil || act != nil {
+		t.Errorf("Sync() = %+v, %v; want nil, nil", act, err)
+	}
+}
+
+func TestRecordDescribe(t *testing.T) {
+	code := 1
+	tests := []struct {
+		r    Record
+		want string
+	}{
+		{Record{Kind: KindShell, Command: "make test\nmore", ExitCode: &code}, "ran `make test` (exit 1)"},
+		{Record{Kind: KindToolCall, Tool: "Edit", Input: "main.go"}, "Edit main.go"},
+		{Record{Kind: KindAssistantText, Text: "\nhello\nworld"}, "said: hello"},
+		{Record{Kind: KindTurnEnd}, "finished turn"},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Describe(); got != tt.want {
+			t.Errorf("Describe(%+v) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
@@ -22,6 +22,13 @@ const (
 	StatusStalled        = "stalled"
 	StatusActive         = "active"
 	StatusIdle           = "idle"
+
+	// Headless runner statuses (run --headless).
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+
+	// StatusQueued is a run waiting for a free slot (run --queue).
+	StatusQueued = "queued"
 )
 
 // Snapshot contains local-only inputs for status derivation.
@@ -38,6 +45,10 @@ type Snapshot struct {
 
 	// StallResult contains the result of stall detection, or nil if not computed.
 	StallResult *watchdog.StallResult
+
+	// HeadlessActive is true iff a headless runner (or its supervisor) is still alive.
+	// Only consulted for headless runs.
+	HeadlessActive bool
 }
 
 // Derived contains the computed status values.
@@ -46,7 +57,8 @@ type Derived struct {
 	// Does not include "(archived)" suffix; that's the render layer's responsibility.
 	DerivedStatus string
 
-	// Archived is true iff the worktree is not present.
+	// Archived is true iff the worktree is not present and the run is not
+	// queued (a queued run has no worktree yet).
 	Archived bool
 }
 
@@ -54,7 +66,7 @@ type Derived struct {
 // meta may be nil for broken runs; in that case DerivedStatus is "broken".
 // This function is pure and must not panic.
 func Derive(meta *store.RunMeta, in Snapshot) Derived {
-	// Compute presence-derived fields (independent of meta)
+	// Compute presence-derived fields
 	archived := !in.WorktreePresent
 
 	// Handle broken runs (nil meta)
@@ -65,6 +77,18 @@ func Derive(meta *store.RunMeta, in Snapshot) Derived {
 		}
 	}
 
+	// A queued run has no worktree until it starts
+	if isQueued(meta) {
+		status := StatusQueued
+		if meta.Queue.FailedAt != "" {
+			status = StatusFailed
+		}
+		return Derived{
+			DerivedStatus: status,
+			Archived:      meta.Archive != nil,
+		}
+	}
+
 	// Compute derived status using precedence rules
 	status := deriveStatus(meta, in)
 
@@ -87,9 +111,12 @@ func Derive(meta *store.RunMeta, in Snapshot) Derived {
 //  7. needs input      → runner_status.status == "needs_input"
 //  8. blocked          → runner_status.status == "blocked"
 //  9. working          → runner_status.status == "working"
-//  10. stalled         → watchdog.IsStalled && tmux exists
-//  11. active          → tmux exists (fallback)
-//  12. idle            → no tmux (fallback)
+//  10. stalled         → watchdog.IsStalled && tmux exists && runner not exited
+//  11. active          → tmux exists && runner not exited (fallback)
+//  12. idle            → no tmux, or runner exited to the session shell (fallback)
+//
+// Headless runs (meta.headless set) have no tmux session and stop after 5:
+// finished (exit 0), failed (non-zero exit, signaled, or supervisor lost), or running.
 func deriveStatus(meta *store.RunMeta, in Snapshot) string {
 	// 1) Terminal outcomes always win (broken handled above)
 	// 2) merged
@@ -110,6 +137,11 @@ func deriveStatus(meta *store.RunMeta, in Snapshot) string {
 		return StatusNeedsAttention
 	}
 
+	// Headless runs: process state decides
+	if meta.Headless != nil {
+		return deriveHeadlessStatus(meta, in)
+	}
+
 	// 6-9) Runner-reported status (if available and valid)
 	if in.RunnerStatus != nil && in.RunnerStatus.Status.IsValid() {
 		switch in.RunnerStatus.Status {
@@ -124,18 +156,42 @@ func deriveStatus(meta *store.RunMeta, in Snapshot) string {
 		}
 	}
 
+	// A session whose runner exited is just a shell in the worktree
+	runnerAlive := in.TmuxActive && meta.RunnerExitedAt == ""
+
 	// 10) Stalled detection
-	if in.StallResult != nil && in.StallResult.IsStalled && in.TmuxActive {
+	if in.StallResult != nil && in.StallResult.IsStalled && runnerAlive {
 		return StatusStalled
 	}
 
 	// 11-12) Activity fallbacks
-	if in.TmuxActive {
+	if runnerAlive {
 		return StatusActive
 	}
 	return StatusIdle
 }
 
+// deriveHeadlessStatus derives the status of a headless run from its recorded
+// exit and the liveness of its runner process.
+func deriveHeadlessStatus(meta *store.RunMeta, in Snapshot) string {
+	if meta.RunnerExitedAt != "" {
+		if meta.ExitReason == store.ExitReasonExited && meta.RunnerExitCode != nil && *meta.RunnerExitCode == 0 {
+			return StatusFinished
+		}
+		return StatusFailed
+	}
+	if in.HeadlessActive {
+		return StatusRunning
+	}
+	// No exit recorded and nothing alive: the supervisor was lost
+	return StatusFailed
+}
+
+// isQueued returns true if the run was queued and its worktree not yet created.
+func isQueued(meta *store.RunMeta) bool {
+	return meta.Queue != nil && meta.WorktreePath == ""
+}
+
 // isMerged returns true if archive.merged_at is set.
 func isMerged(meta *store.RunMeta) bool {
 	return meta.Archive != nil && meta.Archive.MergedAt != ""
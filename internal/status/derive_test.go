@@ -75,6 +75,30 @@ func TestDerive(t *testing.T) {
 			wantArchived:      false,
 		},
 
+		// ============================================================
+		// 1b. queued runs have no worktree yet but are not archived
+		// ============================================================
+		{
+			name: "queued, waiting",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Branch, m.WorktreePath = "", ""
+				m.Queue = &store.RunMetaQueue{QueuedAt: "2026-01-10T12:00:00Z"}
+			}),
+			snapshot:          Snapshot{TmuxActive: false, WorktreePresent: false},
+			wantDerivedStatus: StatusQueued,
+			wantArchived:      false,
+		},
+		{
+			name: "queued, failed to start",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Branch, m.WorktreePath = "", ""
+				m.Queue = &store.RunMetaQueue{QueuedAt: "2026-01-10T12:00:00Z", FailedAt: "2026-01-10T12:05:00Z"}
+			}),
+			snapshot:          Snapshot{TmuxActive: false, WorktreePresent: false},
+			wantDerivedStatus: StatusFailed,
+			wantArchived:      false,
+		},
+
 		// ============================================================
 		// 2. merged wins (even if other flags are set)
 		// ============================================================
@@ -377,6 +401,101 @@ func TestDerive(t *testing.T) {
 			wantDerivedStatus: StatusWorking,
 			wantArchived:      false,
 		},
+
+		// ============================================================
+		// 5a. headless runs
+		// ============================================================
+		{
+			name: "headless running",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Headless = &store.RunMetaHeadless{}
+				m.RunnerPID = 4242
+			}),
+			snapshot:          Snapshot{WorktreePresent: true, HeadlessActive: true},
+			wantDerivedStatus: StatusRunning,
+			wantArchived:      false,
+		},
+		{
+			name: "headless exit 0 is finished",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Headless = &store.RunMetaHeadless{}
+				m.RunnerExitCode = intPtr(0)
+				m.ExitReason = store.ExitReasonExited
+				m.RunnerExitedAt = "2026-01-10T13:00:00Z"
+			}),
+			snapshot:          Snapshot{WorktreePresent: true},
+			wantDerivedStatus: StatusFinished,
+			wantArchived:      false,
+		},
+		{
+			name: "headless non-zero exit is failed",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Headless = &store.RunMetaHeadless{}
+				m.RunnerExitCode = intPtr(2)
+				m.ExitReason = store.ExitReasonExited
+				m.RunnerExitedAt = "2026-01-10T13:00:00Z"
+			}),
+			snapshot:          Snapshot{WorktreePresent: true},
+			wantDerivedStatus: StatusFailed,
+			wantArchived:      false,
+		},
+		{
+			name: "headless signaled is failed",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Headless = &store.RunMetaHeadless{}
+				m.RunnerExitCode = intPtr(-1)
+				m.ExitReason = store.ExitReasonSignaled
+				m.RunnerExitedAt = "2026-01-10T13:00:00Z"
+			}),
+			snapshot:          Snapshot{WorktreePresent: true},
+			wantDerivedStatus: StatusFailed,
+			wantArchived:      false,
+		},
+		{
+			name: "headless with no exit and nothing alive is failed",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Headless = &store.RunMetaHeadless{}
+				m.RunnerPID = 4242
+			}),
+			snapshot:          Snapshot{WorktreePresent: true},
+			wantDerivedStatus: StatusFailed,
+			wantArchived:      false,
+		},
+		{
+			name: "headless ignores runner status and tmux",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Headless = &store.RunMetaHeadless{}
+			}),
+			snapshot: Snapshot{
+				TmuxActive:      true,
+				WorktreePresent: true,
+				RunnerStatus:    mkRunnerStatus(runnerstatus.StatusWorking),
+				HeadlessActive:  true,
+			},
+			wantDerivedStatus: StatusRunning,
+			wantArchived:      false,
+		},
+		{
+			name: "tmux run whose runner exited to the shell is idle",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.RunnerExitCode = intPtr(0)
+				m.ExitReason = store.ExitReasonExited
+				m.RunnerExitedAt = "2026-01-10T13:00:00Z"
+			}),
+			snapshot:          Snapshot{TmuxActive: true, WorktreePresent: true},
+			wantDerivedStatus: StatusIdle,
+			wantArchived:      false,
+		},
+		{
+			name: "merged beats headless",
+			meta: mkMeta(func(m *store.RunMeta) {
+				m.Headless = &store.RunMetaHeadless{}
+				m.Archive = &store.RunMetaArchive{MergedAt: "2026-01-10T14:00:00Z"}
+			}),
+			snapshot:          Snapshot{WorktreePresent: false},
+			wantDerivedStatus: StatusMerged,
+			wantArchived:      true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -393,6 +512,10 @@ func TestDerive(t *testing.T) {
 	}
 }
 
+func intPtr(v int) *int {
+	return &v
+}
+
 // TestDeriveNilMetaDoesNotPanic ensures Derive handles nil meta gracefully.
 func TestDeriveNilMetaDoesNotPanic(t *testing.T) {
 	// This test exists to explicitly verify the "must not panic" requirement
@@ -421,6 +544,8 @@ func TestStatusStringConstants(t *testing.T) {
 		"StatusStalled":        "stalled",
 		"StatusActive":         "active",
 		"StatusIdle":           "idle",
+		"StatusRunning":        "running",
+		"StatusFinished":       "finished",
 	}
 
 	actual := map[string]string{
@@ -436,6 +561,8 @@ func TestStatusStringConstants(t *testing.T) {
 		"StatusStalled":        StatusStalled,
 		"StatusActive":         StatusActive,
 		"StatusIdle":           StatusIdle,
+		"StatusRunning":        StatusRunning,
+		"StatusFinished":       StatusFinished,
 	}
 
 	for name, want := range expected {
@@ -51,6 +51,27 @@ type IntegrationWorktreeMeta struct {
 
 	// State is the lifecycle state (present or archived).
 	State WorktreeState `json:"state"`
+
+	// Landings records each run landed into this worktree, oldest first.
+	Landings []WorktreeLanding `json:"landings,omitempty"`
+}
+
+// WorktreeLanding records one run landed into an integration worktree.
+type WorktreeLanding struct {
+	// RunID is the landed run.
+	RunID string `json:"run_id"`
+
+	// RunName is the run name at landing time.
+	RunName string `json:"run_name"`
+
+	// Mode is "cherry-pick" or "apply".
+	Mode string `json:"mode"`
+
+	// Commits are the commits created on the integration branch, oldest first.
+	Commits []string `json:"commits"`
+
+	// LandedAt is the landing timestamp in RFC3339 UTC format.
+	LandedAt string `json:"landed_at"`
 }
 
 // NewIntegrationWorktreeMeta creates a new IntegrationWorktreeMeta with required fields set.
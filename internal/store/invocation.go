@@ -0,0 +1,250 @@
+// Package store provides persistence for agency data.
+// This file implements invocation metadata and operations (Slice 8 PR-02).
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/errors"
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+// InvocationStatus represents the lifecycle status of an agent invocation.
+type InvocationStatus string
+
+const (
+	// InvocationStatusStarting indicates the sandbox exists but the runner has not started.
+	InvocationStatusStarting InvocationStatus = "starting"
+
+	// InvocationStatusRunning indicates the runner has been started.
+	InvocationStatusRunning InvocationStatus = "running"
+
+	// InvocationStatusFinished indicates the runner exited normally.
+	InvocationStatusFinished InvocationStatus = "finished"
+
+	// InvocationStatusFailed indicates the runner crashed, was killed, or failed to start.
+	InvocationStatusFailed InvocationStatus = "failed"
+)
+
+// InvocationMode is the runner execution mode of an invocation.
+type InvocationMode string
+
+const (
+	// InvocationModeHeaded runs the runner interactively inside tmux.
+	InvocationModeHeaded InvocationMode = "headed"
+
+	// InvocationModeHeadless runs the runner as a supervised subprocess.
+	InvocationModeHeadless InvocationMode = "headless"
+)
+
+// InvocationMeta represents the canonical record for an agent invocation and its sandbox.
+// This is persisted to meta.json in the invocation record directory.
+type InvocationMeta struct {
+	// SchemaVersion is the schema version string (e.g., "1.0").
+	SchemaVersion string `json:"schema_version"`
+
+	// InvocationID is the unique identifier (format: <yyyymmddhhmmss>-<4hex>).
+	InvocationID string `json:"invocation_id"`
+
+	// InvocationName is an optional human-facing label. Not used for identity.
+	InvocationName string `json:"invocation_name,omitempty"`
+
+	// RepoID is the repository identifier (16 hex chars).
+	RepoID string `json:"repo_id"`
+
+	// IntegrationWorktreeID is the integration worktree this invocation targets.
+	IntegrationWorktreeID string `json:"integration_worktree_id"`
+
+	// SandboxPath is the absolute path to the sandbox tree (CWD for the runner).
+	SandboxPath string `json:"sandbox_path"`
+
+	// SandboxBranch is the sandbox branch name (format: agency/sandbox-<invocation_id>).
+	SandboxBranch string `json:"sandbox_branch"`
+
+	// BaseCommit is the integration branch commit the sandbox was created from.
+	BaseCommit string `json:"base_commit"`
+
+	// Runner is the runner name (e.g., "claude", "codex").
+	Runner string `json:"runner"`
+
+	// RunnerCmd is the resolved runner command.
+	RunnerCmd string `json:"runner_cmd,omitempty"`
+
+	// Mode is the execution mode (headed or headless).
+	Mode InvocationMode `json:"mode"`
+
+	// PID is the runner process ID (headless only).
+	PID int `json:"pid,omitempty"`
+
+	// TmuxSession is the tmux session name (headed only).
+	TmuxSession string `json:"tmux_session,omitempty"`
+
+	// StartedAt is the start timestamp in RFC3339 UTC format.
+	StartedAt string `json:"started_at"`
+
+	// FinishedAt is the finish timestamp in RFC3339 UTC format (empty while running).
+	FinishedAt string `json:"finished_at,omitempty"`
+
+	// Status is the lifecycle status.
+	Status InvocationStatus `json:"status"`
+
+	// ExitReason is why the runner stopped (exited, killed, stopped, unknown).
+	ExitReason string `json:"exit_reason,omitempty"`
+
+	// ExitCode is the runner exit code (headless only).
+	ExitCode *int `json:"exit_code,omitempty"`
+
+	// LastOutputAt is the last runner output timestamp in RFC3339 UTC format.
+	LastOutputAt string `json:"last_output_at,omitempty"`
+
+	// LandingStatus is the landing state (pending, landed, discarded).
+	LandingStatus string `json:"landing_status,omitempty"`
+}
+
+// NewInvocationMeta creates a new InvocationMeta with required fields set.
+// Status is initialized to starting.
+func NewInvocationMeta(invocationID, name, repoID, worktreeID, sandboxPath, sandboxBranch, baseCommit, runner string, mode InvocationMode, startedAt time.Time) *InvocationMeta {
+	return &InvocationMeta{
+		SchemaVersion:         "1.0",
+		InvocationID:          invocationID,
+		InvocationName:        name,
+		RepoID:                repoID,
+		IntegrationWorktreeID: worktreeID,
+		SandboxPath:           sandboxPath,
+		SandboxBranch:         sandboxBranch,
+		BaseCommit:            baseCommit,
+		Runner:                runner,
+		Mode:                  mode,
+		StartedAt:             startedAt.UTC().Format(time.RFC3339),
+		Status:                InvocationStatusStarting,
+	}
+}
+
+// EnsureInvocationDir creates the invocation record directory with exclusive semantics.
+// Returns the invocation dir path on success.
+// Fails with E_INVOCATION_DIR_EXISTS if the directory already exists.
+func (s *Store) EnsureInvocationDir(repoID, invocationID string) (string, error) {
+	invocationDir := s.InvocationDir(repoID, invocationID)
+
+	// Ensure parent directories exist (invocations/)
+	parentDir := s.InvocationsDir(repoID)
+	if err := s.FS.MkdirAll(parentDir, 0o700); err != nil {
+		return "", errors.WrapWithDetails(
+			errors.ESandboxCreateFailed,
+			"failed to create invocations directory",
+			err,
+			map[string]string{"dir": parentDir},
+		)
+	}
+
+	// Create invocation directory with exclusive semantics using os.Mkdir
+	if err := os.Mkdir(invocationDir, 0o700); err != nil {
+		if os.IsExist(err) {
+			return "", errors.NewWithDetails(
+				errors.EInvocationDirExists,
+				"invocation directory already exists (invocation_id collision or stale state)",
+				map[string]string{"invocation_dir": invocationDir},
+			)
+		}
+		return "", errors.WrapWithDetails(
+			errors.ESandboxCreateFailed,
+			"failed to create invocation directory",
+			err,
+			map[string]string{"invocation_dir": invocationDir},
+		)
+	}
+
+	return invocationDir, nil
+}
+
+// WriteInvocationMeta writes the meta.json for an invocation atomically.
+func (s *Store) WriteInvocationMeta(repoID, invocationID string, meta *InvocationMeta) error {
+	metaPath := s.InvocationMetaPath(repoID, invocationID)
+
+	if err := fs.WriteJSONAtomic(metaPath, meta, 0o644); err != nil {
+		return errors.WrapWithDetails(
+			errors.EMetaWriteFailed,
+			"failed to write invocation meta.json atomically",
+			err,
+			map[string]string{"meta_path": metaPath},
+		)
+	}
+
+	return nil
+}
+
+// UpdateInvocationMeta reads, updates, and writes meta.json atomically.
+func (s *Store) UpdateInvocationMeta(repoID, invocationID string, updateFn func(*InvocationMeta)) error {
+	metaPath := s.InvocationMetaPath(repoID, invocationID)
+
+	// Read current meta
+	meta, err := s.ReadInvocationMeta(repoID, invocationID)
+	if err != nil {
+		return err
+	}
+
+	// Apply update
+	updateFn(meta)
+
+	// Write back atomically
+	if err := fs.WriteJSONAtomic(metaPath, meta, 0o644); err != nil {
+		return errors.WrapWithDetails(
+			errors.EMetaWriteFailed,
+			"failed to write invocation meta.json atomically",
+			err,
+			map[string]string{"meta_path": metaPath},
+		)
+	}
+
+	return nil
+}
+
+// ReadInvocationMeta reads and parses meta.json for an invocation.
+// Returns E_INVOCATION_NOT_FOUND if the meta file doesn't exist.
+// Returns E_STORE_CORRUPT if the file can't be parsed.
+func (s *Store) ReadInvocationMeta(repoID, invocationID string) (*InvocationMeta, error) {
+	metaPath := s.InvocationMetaPath(repoID, invocationID)
+
+	data, err := s.FS.ReadFile(metaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NewWithDetails(
+				errors.EInvocationNotFound,
+				"invocation not found (meta.json does not exist)",
+				map[string]string{"meta_path": metaPath},
+			)
+		}
+		return nil, errors.WrapWithDetails(
+			errors.EStoreCorrupt,
+			"failed to read invocation meta.json",
+			err,
+			map[string]string{"meta_path": metaPath},
+		)
+	}
+
+	var meta InvocationMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, errors.WrapWithDetails(
+			errors.EStoreCorrupt,
+			"failed to parse invocation meta.json",
+			err,
+			map[string]string{"meta_path": metaPath},
+		)
+	}
+
+	return &meta, nil
+}
+
+// RemoveInvocationDir removes the invocation record directory completely.
+// This is used for cleanup on failed creation.
+func (s *Store) RemoveInvocationDir(repoID, invocationID string) error {
+	return os.RemoveAll(s.InvocationDir(repoID, invocationID))
+}
+
+// RemoveSandboxDir removes the sandbox directory completely (best-effort cleanup).
+// Callers must remove the git worktree registration first.
+func (s *Store) RemoveSandboxDir(repoID, invocationID string) error {
+	return os.RemoveAll(s.SandboxDir(repoID, invocationID))
+}
@@ -0,0 +1,175 @@
+// Package store provides persistence for agency data.
+// This file implements filesystem-based invocation discovery (Slice 8 PR-02).
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// InvocationRecord represents a discovered invocation with its parsed metadata.
+type InvocationRecord struct {
+	// InvocationID is the invocation_id from the directory name (canonical identity).
+	InvocationID string
+
+	// RepoID is the repo_id (from context, not directory).
+	RepoID string
+
+	// Broken indicates meta.json is unreadable/invalid, or the sandbox tree
+	// is missing for an invocation that has not been landed or discarded.
+	// Meta may be non-nil for broken records whose sandbox tree is missing.
+	Broken bool
+
+	// Meta is the parsed meta.json. Nil if meta.json is missing or invalid.
+	Meta *InvocationMeta
+
+	// InvocationDir is the absolute path to the invocation record directory:
+	// ${AGENCY_DATA_DIR}/repos/<repo_id>/invocations/<invocation_id>
+	InvocationDir string
+}
+
+// ScanInvocationsForRepo discovers invocations for a single repo_id.
+//
+// Invocation record directories are scanned first; sandbox directories without
+// a matching record directory are reported as broken (orphaned sandboxes).
+// Returns records sorted by started_at ascending, then invocation_id.
+// Missing directories result in empty slice (not error).
+func ScanInvocationsForRepo(dataDir, repoID string) ([]InvocationRecord, error) {
+	repoDir := filepath.Join(dataDir, "repos", repoID)
+	invocationsDir := filepath.Join(repoDir, "invocations")
+	sandboxesDir := filepath.Join(repoDir, "sandboxes")
+
+	entries, err := os.ReadDir(invocationsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var records []InvocationRecord
+	seen := make(map[string]bool)
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		invocationID := entry.Name()
+		invocationDir := filepath.Join(invocationsDir, invocationID)
+		seen[invocationID] = true
+
+		record := InvocationRecord{
+			InvocationID:  invocationID,
+			RepoID:        repoID,
+			InvocationDir: invocationDir,
+		}
+
+		// Try to read and parse meta.json
+		data, err := os.ReadFile(filepath.Join(invocationDir, "meta.json"))
+		if err != nil {
+			record.Broken = true
+			records = append(records, record)
+			continue
+		}
+
+		var meta InvocationMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			record.Broken = true
+			records = append(records, record)
+			continue
+		}
+
+		// Validate minimal required fields for non-broken status
+		if meta.SchemaVersion == "" || meta.StartedAt == "" {
+			record.Broken = true
+			records = append(records, record)
+			continue
+		}
+
+		record.Meta = &meta
+
+		// A sandbox tree must exist until the invocation is landed or discarded
+		if meta.LandingStatus != "landed" && meta.LandingStatus != "discarded" {
+			if _, err := os.Stat(meta.SandboxPath); err != nil {
+				record.Broken = true
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	// Orphaned sandboxes: sandbox dir exists but no invocation record
+	sandboxEntries, err := os.ReadDir(sandboxesDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range sandboxEntries {
+		if !entry.IsDir() || seen[entry.Name()] {
+			continue
+		}
+		records = append(records, InvocationRecord{
+			InvocationID:  entry.Name(),
+			RepoID:        repoID,
+			Broken:        true,
+			InvocationDir: filepath.Join(invocationsDir, entry.Name()),
+		})
+	}
+
+	sortInvocationRecords(records)
+	return records, nil
+}
+
+// ScanAllInvocations discovers invocations across all repos.
+// Returns records sorted by RepoID asc, then started_at asc, then InvocationID asc.
+func ScanAllInvocations(dataDir string) ([]InvocationRecord, error) {
+	reposDir := filepath.Join(dataDir, "repos")
+
+	entries, err := os.ReadDir(reposDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var records []InvocationRecord
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		repoRecords, err := ScanInvocationsForRepo(dataDir, entry.Name())
+		if err != nil {
+			// Skip repos with errors (e.g., permission denied)
+			continue
+		}
+		records = append(records, repoRecords...)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].RepoID < records[j].RepoID
+	})
+
+	return records, nil
+}
+
+// sortInvocationRecords sorts records by started_at ascending, then invocation_id.
+// Records without parsed meta sort last.
+func sortInvocationRecords(records []InvocationRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		mi, mj := records[i].Meta, records[j].Meta
+		if (mi == nil) != (mj == nil) {
+			return mi != nil
+		}
+		if mi == nil {
+			return records[i].InvocationID < records[j].InvocationID
+		}
+
+		ti, erri := time.Parse(time.RFC3339, mi.StartedAt)
+		tj, errj := time.Parse(time.RFC3339, mj.StartedAt)
+		if erri != nil || errj != nil || ti.Equal(tj) {
+			return records[i].InvocationID < records[j].InvocationID
+		}
+		return ti.Before(tj)
+	})
+}
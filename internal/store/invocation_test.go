@@ -0,0 +1,149 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NielsdaWheelz/agency/internal/fs"
+)
+
+func TestInvocationPaths(t *testing.T) {
+	st := NewStore(fs.NewRealFS(), "/data", time.Now)
+
+	repoID := "abc123"
+	invID := "20260131120500-b7c9"
+
+	if got, want := st.InvocationDir(repoID, invID), "/data/repos/abc123/invocations/20260131120500-b7c9"; got != want {
+		t.Errorf("InvocationDir() = %v, want %v", got, want)
+	}
+	if got, want := st.InvocationMetaPath(repoID, invID), "/data/repos/abc123/invocations/20260131120500-b7c9/meta.json"; got != want {
+		t.Errorf("InvocationMetaPath() = %v, want %v", got, want)
+	}
+	if got, want := st.SandboxDir(repoID, invID), "/data/repos/abc123/sandboxes/20260131120500-b7c9"; got != want {
+		t.Errorf("SandboxDir() = %v, want %v", got, want)
+	}
+	if got, want := st.SandboxTreePath(repoID, invID), "/data/repos/abc123/sandboxes/20260131120500-b7c9/tree"; got != want {
+		t.Errorf("SandboxTreePath() = %v, want %v", got, want)
+	}
+}
+
+func TestEnsureInvocationDir(t *testing.T) {
+	st := NewStore(fs.NewRealFS(), t.TempDir(), time.Now)
+
+	dir, err := st.EnsureInvocationDir("abc123", "20260131120500-b7c9")
+	if err != nil {
+		t.Fatalf("EnsureInvocationDir() error = %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("directory was not created: %v", err)
+	}
+
+	// Second call should fail (exclusive)
+	if _, err := st.EnsureInvocationDir("abc123", "20260131120500-b7c9"); err == nil {
+		t.Error("expected error on duplicate, got nil")
+	}
+}
+
+func TestWriteUpdateReadInvocationMeta(t *testing.T) {
+	st := NewStore(fs.NewRealFS(), t.TempDir(), time.Now)
+
+	repoID := "abc123"
+	invID := "20260131120500-b7c9"
+
+	if _, err := st.EnsureInvocationDir(repoID, invID); err != nil {
+		t.Fatalf("EnsureInvocationDir() error = %v", err)
+	}
+
+	now := time.Date(2026, 1, 31, 12, 5, 0, 0, time.UTC)
+	meta := NewInvocationMeta(invID, "arch-agent", repoID, "20260131120000-a3f2",
+		"/sandbox/tree", "agency/sandbox-"+invID, "789abc", "claude", InvocationModeHeaded, now)
+	if meta.Status != InvocationStatusStarting {
+		t.Errorf("Status = %v, want %v", meta.Status, InvocationStatusStarting)
+	}
+	if meta.StartedAt != "2026-01-31T12:05:00Z" {
+		t.Errorf("StartedAt = %v, want 2026-01-31T12:05:00Z", meta.StartedAt)
+	}
+
+	if err := st.WriteInvocationMeta(repoID, invID, meta); err != nil {
+		t.Fatalf("WriteInvocationMeta() error = %v", err)
+	}
+
+	err := st.UpdateInvocationMeta(repoID, invID, func(m *InvocationMeta) {
+		m.Status = InvocationStatusRunning
+		m.TmuxSession = "agency_" + invID
+	})
+	if err != nil {
+		t.Fatalf("UpdateInvocationMeta() error = %v", err)
+	}
+
+	read, err := st.ReadInvocationMeta(repoID, invID)
+	if err != nil {
+		t.Fatalf("ReadInvocationMeta() error = %v", err)
+	}
+	if read.Status != InvocationStatusRunning {
+		t.Errorf("Status = %v, want %v", read.Status, InvocationStatusRunning)
+	}
+	if read.BaseCommit != "789abc" {
+		t.Errorf("BaseCommit = %v, want 789abc", read.BaseCommit)
+	}
+
+	if _, err := st.ReadInvocationMeta(repoID, "notfound"); err == nil {
+		t.Error("expected error for nonexistent invocation")
+	}
+}
+
+func TestScanInvocationsForRepo(t *testing.T) {
+	tmpDir := t.TempDir()
+	st := NewStore(fs.NewRealFS(), tmpDir, time.Now)
+	repoID := "abc123"
+
+	write := func(invID string, startedAt time.Time, withTree bool) {
+		t.Helper()
+		if _, err := st.EnsureInvocationDir(repoID, invID); err != nil {
+			t.Fatalf("EnsureInvocationDir() error = %v", err)
+		}
+		treePath := st.SandboxTreePath(repoID, invID)
+		if withTree {
+			if err := os.MkdirAll(treePath, 0o755); err != nil {
+				t.Fatalf("failed to create tree: %v", err)
+			}
+		}
+		meta := NewInvocationMeta(invID, "", repoID, "wt", treePath, "agency/sandbox-"+invID, "abc", "claude", InvocationModeHeaded, startedAt)
+		if err := st.WriteInvocationMeta(repoID, invID, meta); err != nil {
+			t.Fatalf("WriteInvocationMeta() error = %v", err)
+		}
+	}
+
+	write("20260131110000-c3d4", time.Date(2026, 1, 31, 11, 0, 0, 0, time.UTC), true)
+	write("20260131100000-a1b2", time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC), true)
+	// Meta present but sandbox tree missing
+	write("20260131120000-e5f6", time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC), false)
+
+	// Orphaned sandbox with no invocation record
+	if err := os.MkdirAll(filepath.Join(st.SandboxTreePath(repoID, "20260131130000-0000")), 0o755); err != nil {
+		t.Fatalf("failed to create orphan: %v", err)
+	}
+
+	records, err := ScanInvocationsForRepo(tmpDir, repoID)
+	if err != nil {
+		t.Fatalf("ScanInvocationsForRepo() error = %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4", len(records))
+	}
+
+	if records[0].InvocationID != "20260131100000-a1b2" || records[0].Broken {
+		t.Errorf("records[0] = %+v, want healthy 20260131100000-a1b2", records[0])
+	}
+	if records[1].InvocationID != "20260131110000-c3d4" || records[1].Broken {
+		t.Errorf("records[1] = %+v, want healthy 20260131110000-c3d4", records[1])
+	}
+	if records[2].InvocationID != "20260131120000-e5f6" || !records[2].Broken || records[2].Meta == nil {
+		t.Errorf("records[2] = %+v, want broken with meta", records[2])
+	}
+	if records[3].InvocationID != "20260131130000-0000" || !records[3].Broken || records[3].Meta != nil {
+		t.Errorf("records[3] = %+v, want broken orphan", records[3])
+	}
+}
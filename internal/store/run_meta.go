@@ -3,10 +3,13 @@ package store
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/NielsdaWheelz/agency/internal/errors"
 	"github.com/NielsdaWheelz/agency/internal/fs"
+	"github.com/NielsdaWheelz/agency/internal/lock"
 )
 
 // RunMeta represents the metadata for a run, persisted to meta.json.
@@ -61,6 +64,9 @@ type RunMeta struct {
 	// LastPushAt is the timestamp of the last push (set by push, not in PR-06).
 	LastPushAt string `json:"last_push_at,omitempty"`
 
+	// LastRebaseAt is the timestamp of the last rebase that rewrote the branch (set by rebase).
+	LastRebaseAt string `json:"last_rebase_at,omitempty"`
+
 	// LastVerifyAt is the timestamp of the last verify (set by merge, not in PR-06).
 	LastVerifyAt string `json:"last_verify_at,omitempty"`
 
@@ -72,6 +78,151 @@ type RunMeta struct {
 
 	// Archive contains archive-related fields (set by merge/clean, not in PR-06).
 	Archive *RunMetaArchive `json:"archive,omitempty"`
+
+	// Headless contains headless runner details (set only for `run --headless`).
+	Headless *RunMetaHeadless `json:"headless,omitempty"`
+
+	// RunnerPID is the process id of the runner (headless runs only).
+	RunnerPID int `json:"runner_pid,omitempty"`
+
+	// RunnerStartedAt is when the current runner was started (tmux runs:
+	// the run's session, or the latest resume that started a new runner).
+	RunnerStartedAt string `json:"runner_started_at,omitempty"`
+
+	// RunnerExitCode is the runner's exit code (-1 if signaled or failed to start).
+	// Unknown (unset) for tmux runs.
+	RunnerExitCode *int `json:"runner_exit_code,omitempty"`
+
+	// RunnerExitedAt is the timestamp when the runner was observed to exit.
+	RunnerExitedAt string `json:"runner_exited_at,omitempty"`
+
+	// ExitReason describes why the runner exited (see ExitReason* constants).
+	ExitReason string `json:"exit_reason,omitempty"`
+
+	// RunnerSessionID is the runner's conversation id (Claude session id,
+	// Codex thread id), discovered from its session log. Used by resume.
+	RunnerSessionID string `json:"runner_session_id,omitempty"`
+
+	// CheckpointWatcherPID is the process id of the run's checkpoint watcher.
+	CheckpointWatcherPID int `json:"checkpoint_watcher_pid,omitempty"`
+
+	// MonitorPID is the process id of the run's monitor (budgets, auto-verify,
+	// status history, notifications).
+	MonitorPID int `json:"monitor_pid,omitempty"`
+
+	// GroupID identifies the best-of-N group this run is an attempt in (run --attempts).
+	GroupID string `json:"group_id,omitempty"`
+
+	// GroupAttempt is this run's 1-based attempt number within its group.
+	GroupAttempt int `json:"group_attempt,omitempty"`
+
+	// Landings records each time this run's work was landed into an integration worktree.
+	Landings []RunMetaLanding `json:"landings,omitempty"`
+
+	// Queue is set while the run waits for a free slot (run --queue) and
+	// cleared once its runner has started.
+	Queue *RunMetaQueue `json:"queue,omitempty"`
+
+	// Budget holds the run's runtime limits, enforced by its run monitor.
+	Budget *RunMetaBudget `json:"budget,omitempty"`
+}
+
+// RunMetaBudget records a run's runtime limits (agency.json budgets or run
+// flags) and whether one was exceeded. Durations use Go duration format.
+type RunMetaBudget struct {
+	// MaxDuration is the wall-clock limit since the run started (empty = none).
+	MaxDuration string `json:"max_duration,omitempty"`
+
+	// MaxStall is the limit on time without runner activity (empty = none).
+	MaxStall string `json:"max_stall,omitempty"`
+
+	// ExceededAt and Exceeded ("max_duration" or "max_stall") are set once
+	// the runner has been interrupted for exceeding a limit.
+	ExceededAt string `json:"exceeded_at,omitempty"`
+	Exceeded   string `json:"exceeded,omitempty"`
+
+	// Unenforced is set when the run monitor that enforces the
+	// budgets could not be started (the error message); empty otherwise.
+	Unenforced string `json:"unenforced,omitempty"`
+}
+
+// RunMetaQueue records a queued run. A queued run has meta.json but no
+// worktree, branch or runner until the queue processor starts it.
+type RunMetaQueue struct {
+	// QueuedAt is the timestamp when the run was queued.
+	QueuedAt string `json:"queued_at"`
+
+	// Headless starts the run with --headless.
+	Headless bool `json:"headless,omitempty"`
+
+	// StartedAt is set when the queue processor claims the run and starts its pipeline.
+	StartedAt string `json:"started_at,omitempty"`
+
+	// FailedAt and Error are set if the run failed before its worktree was created.
+	FailedAt string `json:"failed_at,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Waiting reports whether the run is still waiting for a slot.
+func (q *RunMetaQueue) Waiting() bool {
+	return q != nil && q.StartedAt == "" && q.FailedAt == ""
+}
+
+// Starting reports whether the queue processor is starting the run.
+func (q *RunMetaQueue) Starting() bool {
+	return q != nil && q.StartedAt != "" && q.FailedAt == ""
+}
+
+// RunMetaLanding records one landing of a run into an integration worktree.
+type RunMetaLanding struct {
+	// WorktreeID is the integration worktree the run was landed into.
+	WorktreeID string `json:"worktree_id"`
+
+	// WorktreeName is the integration worktree name at landing time.
+	WorktreeName string `json:"worktree_name"`
+
+	// Mode is "cherry-pick" or "apply".
+	Mode string `json:"mode"`
+
+	// Commits are the commits created on the integration branch, oldest first.
+	Commits []string `json:"commits"`
+
+	// LandedAt is the landing timestamp in RFC3339 UTC format.
+	LandedAt string `json:"landed_at"`
+}
+
+// Runner exit reasons recorded in RunMeta.ExitReason.
+const (
+	// ExitReasonExited means the runner process exited on its own (any exit code).
+	ExitReasonExited = "exited"
+
+	// ExitReasonSignaled means the runner process was terminated by a signal.
+	ExitReasonSignaled = "signaled"
+
+	// ExitReasonStartFailed means the runner process could not be started.
+	ExitReasonStartFailed = "start_failed"
+
+	// ExitReasonSessionGone means a tmux run's session was found missing
+	// (runner exited, session killed, or machine restarted).
+	ExitReasonSessionGone = "session_gone"
+)
+
+// RunMetaHeadless contains details for a headless (non-tmux) runner process.
+type RunMetaHeadless struct {
+	// SupervisorPID is the process id of the detached agency supervisor.
+	SupervisorPID int `json:"supervisor_pid,omitempty"`
+
+	// PromptPath is the path to the prompt file fed to the runner on stdin.
+	PromptPath string `json:"prompt_path"`
+
+	// RawLogPath is the path to logs/raw.jsonl (verbatim runner stdout).
+	RawLogPath string `json:"raw_log_path"`
+
+	// StderrLogPath is the path to logs/stderr.log (verbatim runner stderr).
+	StderrLogPath string `json:"stderr_log_path"`
+
+	// StartedAt is the timestamp when the runner process was started.
+	StartedAt string `json:"started_at,omitempty"`
 }
 
 // RunMetaFlags contains optional boolean flags for run state.
@@ -87,7 +238,8 @@ type RunMetaFlags struct {
 
 	// NeedsAttentionReason is the reason for needing attention.
 	// Allowed values (v1): "", "verify_failed", "stop_requested", "user_marked",
-	// "pr_not_mergeable", "setup_failed", "unknown".
+	// "pr_not_mergeable", "setup_failed", "rebase_conflict", "budget_exceeded",
+	// "budget_unenforced", "unknown".
 	// Empty string means no specific reason (omitted in JSON).
 	NeedsAttentionReason string `json:"needs_attention_reason,omitempty"`
 
@@ -200,10 +352,28 @@ func (s *Store) WriteInitialMeta(repoID, runID string, meta *RunMeta) error {
 
 // UpdateMeta reads, updates, and writes meta.json atomically.
 // The updateFn receives the current meta and should modify it in place.
+// Updates of a run are serialized with an exclusive flock on its meta.lock,
+// so concurrent writers (foreground commands, watchers, the daemon, headless
+// supervisors) never overwrite each other's changes with stale data.
 // Returns E_META_WRITE_FAILED on read or write errors.
 func (s *Store) UpdateMeta(repoID, runID string, updateFn func(*RunMeta)) error {
 	metaPath := s.RunMetaPath(repoID, runID)
 
+	unlock, err := lock.LockFile(s.runMetaLockPath(repoID, runID))
+	if err != nil {
+		// A missing run dir is E_RUN_NOT_FOUND, as for ReadMeta
+		if _, rerr := s.ReadMeta(repoID, runID); rerr != nil {
+			return rerr
+		}
+		return errors.WrapWithDetails(
+			errors.EMetaWriteFailed,
+			"failed to lock meta.json",
+			err,
+			map[string]string{"meta_path": metaPath},
+		)
+	}
+	defer func() { _ = unlock() }()
+
 	// Read current meta
 	meta, err := s.ReadMeta(repoID, runID)
 	if err != nil {
@@ -226,6 +396,27 @@ func (s *Store) UpdateMeta(repoID, runID string, updateFn func(*RunMeta)) error
 	return nil
 }
 
+// runMetaLockPath is the lock file serializing UpdateMeta of a run (meta.json
+// itself is replaced on every write, so it cannot carry the lock).
+func (s *Store) runMetaLockPath(repoID, runID string) string {
+	return filepath.Join(s.RunDir(repoID, runID), "meta.lock")
+}
+
+// WriteLastOutputAt records a headless run's last output time (RFC3339).
+func (s *Store) WriteLastOutputAt(repoID, runID, ts string) error {
+	return os.WriteFile(s.RunLastOutputPath(repoID, runID), []byte(ts+"\n"), 0o644)
+}
+
+// ReadLastOutputAt returns the last output time recorded at path (a
+// RunLastOutputPath), or "" if none.
+func ReadLastOutputAt(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
 // ReadMeta reads and parses meta.json for a run.
 // Returns E_RUN_NOT_FOUND if the meta file doesn't exist.
 // Returns E_STORE_CORRUPT if the file can't be parsed.
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -314,6 +315,45 @@ func TestUpdateMeta(t *testing.T) {
 	}
 }
 
+func TestUpdateMeta_Concurrent(t *testing.T) {
+	dataDir := t.TempDir()
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	s := NewStore(fs.NewRealFS(), dataDir, fixedTime(now))
+	if _, err := s.EnsureRunDir("repo123", "run456"); err != nil {
+		t.Fatal(err)
+	}
+	meta := NewRunMeta("run456", "repo123", "test-name", "claude", "claude", "main", "agency/test-a3f2", "/path/to/worktree", now)
+	if err := s.WriteInitialMeta("repo123", "run456", meta); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every update sees the previous one: none is lost to a stale read
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.UpdateMeta("repo123", "run456", func(m *RunMeta) { m.RunnerSessionID += "x" }); err != nil {
+				t.Errorf("UpdateMeta() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	loaded, err := s.ReadMeta("repo123", "run456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.RunnerSessionID) != writers {
+		t.Errorf("applied %d of %d updates", len(loaded.RunnerSessionID), writers)
+	}
+
+	if err := s.UpdateMeta("repo123", "missing", func(*RunMeta) {}); errors.GetCode(err) != errors.ERunNotFound {
+		t.Errorf("UpdateMeta(missing run) error = %v, want E_RUN_NOT_FOUND", err)
+	}
+}
+
 // TestNewRunMeta verifies the constructor sets all fields correctly.
 func TestNewRunMeta(t *testing.T) {
 	now := time.Date(2026, 1, 10, 15, 30, 45, 0, time.FixedZone("EST", -5*3600))
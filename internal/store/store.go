@@ -64,6 +64,65 @@ func (s *Store) RunLogsDir(repoID, runID string) string {
 	return filepath.Join(s.RunDir(repoID, runID), "logs")
 }
 
+// RunPromptPath returns the path to a headless run's prompt file.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/logs/prompt.txt
+func (s *Store) RunPromptPath(repoID, runID string) string {
+	return filepath.Join(s.RunLogsDir(repoID, runID), "prompt.txt")
+}
+
+// RunRawLogPath returns the path to a headless run's verbatim stdout log.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/logs/raw.jsonl
+func (s *Store) RunRawLogPath(repoID, runID string) string {
+	return filepath.Join(s.RunLogsDir(repoID, runID), "raw.jsonl")
+}
+
+// RunStderrLogPath returns the path to a headless run's verbatim stderr log.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/logs/stderr.log
+func (s *Store) RunStderrLogPath(repoID, runID string) string {
+	return filepath.Join(s.RunLogsDir(repoID, runID), "stderr.log")
+}
+
+// RunSupervisorLogPath returns the path to a headless run's supervisor log.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/logs/supervisor.log
+func (s *Store) RunSupervisorLogPath(repoID, runID string) string {
+	return filepath.Join(s.RunLogsDir(repoID, runID), "supervisor.log")
+}
+
+// RunStreamPath returns the path to a run's normalized activity stream.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/logs/stream.jsonl
+func (s *Store) RunStreamPath(repoID, runID string) string {
+	return filepath.Join(s.RunLogsDir(repoID, runID), "stream.jsonl")
+}
+
+// RunLastOutputPath returns the path to a headless run's last output time.
+// It is rewritten up to once a second, so it is kept out of meta.json.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/logs/last_output_at
+func (s *Store) RunLastOutputPath(repoID, runID string) string {
+	return filepath.Join(s.RunLogsDir(repoID, runID), LastOutputFileName)
+}
+
+// LastOutputFileName is the file name of RunLastOutputPath in the run's logs
+// dir, for callers holding a RunRecord.RunDir.
+const LastOutputFileName = "last_output_at"
+
+// RunCheckpointsPath returns the path to a run's checkpoint records.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/checkpoints.jsonl
+func (s *Store) RunCheckpointsPath(repoID, runID string) string {
+	return filepath.Join(s.RunDir(repoID, runID), "checkpoints.jsonl")
+}
+
+// RunCheckpointLogPath returns the path to a run's checkpoint watcher log.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/logs/checkpoint.log
+func (s *Store) RunCheckpointLogPath(repoID, runID string) string {
+	return filepath.Join(s.RunLogsDir(repoID, runID), "checkpoint.log")
+}
+
+// RunMonitorLogPath returns the path to a run's monitor log.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/logs/monitor.log
+func (s *Store) RunMonitorLogPath(repoID, runID string) string {
+	return filepath.Join(s.RunLogsDir(repoID, runID), "monitor.log")
+}
+
 // VerifyRecordPath returns the path to a run's verify_record.json.
 // Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/verify_record.json
 func (s *Store) VerifyRecordPath(repoID, runID string) string {
@@ -76,6 +135,23 @@ func (s *Store) EventsPath(repoID, runID string) string {
 	return filepath.Join(s.RunDir(repoID, runID), "events.jsonl")
 }
 
+// RunNotifyStatePath returns the path to a run's notification dedup state.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/notify_state.json
+func (s *Store) RunNotifyStatePath(repoID, runID string) string {
+	return filepath.Join(s.RunDir(repoID, runID), "notify_state.json")
+}
+
+// RunnerStatusStatePath returns the path to the last runner status recorded
+// in a run's history.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/runs/<run_id>/runner_status_state.json
+func (s *Store) RunnerStatusStatePath(repoID, runID string) string {
+	return filepath.Join(s.RunDir(repoID, runID), RunnerStatusStateFileName)
+}
+
+// RunnerStatusStateFileName is the file name of RunnerStatusStatePath, for
+// callers holding a RunRecord.RunDir.
+const RunnerStatusStateFileName = "runner_status_state.json"
+
 // ----- V2 Integration Worktree paths (Slice 8) -----
 
 // IntegrationWorktreesDir returns the integration worktrees directory for a repo.
@@ -101,3 +177,47 @@ func (s *Store) IntegrationWorktreeMetaPath(repoID, worktreeID string) string {
 func (s *Store) IntegrationWorktreeTreePath(repoID, worktreeID string) string {
 	return filepath.Join(s.IntegrationWorktreeDir(repoID, worktreeID), "tree")
 }
+
+// ----- V2 Invocation + Sandbox paths (Slice 8) -----
+
+// InvocationsDir returns the invocations directory for a repo.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/invocations/
+func (s *Store) InvocationsDir(repoID string) string {
+	return filepath.Join(s.RepoDir(repoID), "invocations")
+}
+
+// InvocationDir returns the record directory for a specific invocation.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/invocations/<invocation_id>/
+func (s *Store) InvocationDir(repoID, invocationID string) string {
+	return filepath.Join(s.InvocationsDir(repoID), invocationID)
+}
+
+// InvocationMetaPath returns the path to an invocation's meta.json.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/invocations/<invocation_id>/meta.json
+func (s *Store) InvocationMetaPath(repoID, invocationID string) string {
+	return filepath.Join(s.InvocationDir(repoID, invocationID), "meta.json")
+}
+
+// InvocationEventsPath returns the path to an invocation's events.jsonl.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/invocations/<invocation_id>/events.jsonl
+func (s *Store) InvocationEventsPath(repoID, invocationID string) string {
+	return filepath.Join(s.InvocationDir(repoID, invocationID), "events.jsonl")
+}
+
+// SandboxesDir returns the sandboxes directory for a repo.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/sandboxes/
+func (s *Store) SandboxesDir(repoID string) string {
+	return filepath.Join(s.RepoDir(repoID), "sandboxes")
+}
+
+// SandboxDir returns the sandbox directory for a specific invocation.
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/sandboxes/<invocation_id>/
+func (s *Store) SandboxDir(repoID, invocationID string) string {
+	return filepath.Join(s.SandboxesDir(repoID), invocationID)
+}
+
+// SandboxTreePath returns the path to a sandbox's tree directory (the git worktree).
+// Format: ${AGENCY_DATA_DIR}/repos/<repo_id>/sandboxes/<invocation_id>/tree/
+func (s *Store) SandboxTreePath(repoID, invocationID string) string {
+	return filepath.Join(s.SandboxDir(repoID, invocationID), "tree")
+}
@@ -10,6 +10,7 @@ type Key string
 // Key constants for common keys.
 const (
 	KeyCtrlC Key = "C-c"
+	KeyEnter Key = "Enter"
 )
 
 // Client is the interface for tmux operations.
@@ -42,4 +43,8 @@ type Client interface {
 	// keys must have at least 1 element.
 	// Returns error if keys is empty, session does not exist, or send fails.
 	SendKeys(ctx context.Context, name string, keys []Key) error
+
+	// SendText types literal text into a tmux session (no key name lookup).
+	// Returns error if text is empty, session does not exist, or send fails.
+	SendText(ctx context.Context, name, text string) error
 }
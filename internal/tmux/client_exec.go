@@ -121,6 +121,25 @@ func (c *ExecClient) SendKeys(ctx context.Context, name string, keys []Key) erro
 	return nil
 }
 
+// SendText implements Client.SendText.
+// Uses: tmux send-keys -t <name> -l -- <text>
+func (c *ExecClient) SendText(ctx context.Context, name, text string) error {
+	if text == "" {
+		return fmt.Errorf("tmux send-keys: text must not be empty")
+	}
+
+	args := []string{"send-keys", "-t", name, "-l", "--", text}
+	result, err := c.runner.Run(ctx, "tmux", args, exec.RunOpts{})
+	if err != nil {
+		return err
+	}
+
+	if result.ExitCode != 0 {
+		return c.formatError("send-keys", result.ExitCode, result.Stderr)
+	}
+	return nil
+}
+
 // formatError formats a tmux error with subcommand, exit code, and capped stderr.
 func (c *ExecClient) formatError(subcmd string, exitCode int, stderr string) error {
 	trimmed := strings.TrimSpace(stderr)
@@ -432,6 +432,26 @@ func TestExecClient_SendKeys(t *testing.T) {
 	}
 }
 
+func TestExecClient_SendText(t *testing.T) {
+	runner := newFakeRunner(fakeResponse{Result: exec.CmdResult{ExitCode: 0}})
+	client := NewExecClient(runner)
+
+	if err := client.SendText(context.Background(), "agency_abc", "-fix the tests"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	want := []string{"send-keys", "-t", "agency_abc", "-l", "--", "-fix the tests"}
+	if len(runner.calls) != 1 || !slicesEqual(runner.calls[0].Args, want) {
+		t.Errorf("calls = %+v, want args %v", runner.calls, want)
+	}
+
+	if err := client.SendText(context.Background(), "agency_abc", ""); err == nil {
+		t.Error("SendText(\"\") should fail")
+	}
+	if len(runner.calls) != 1 {
+		t.Errorf("empty text should not run tmux, got %d calls", len(runner.calls))
+	}
+}
+
 func TestExecClient_ErrorFormatting(t *testing.T) {
 	tests := []struct {
 		name         string
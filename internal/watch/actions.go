@@ -0,0 +1,133 @@
+package watch
+
+import (
+	"context"
+	"os"
+	osexec "os/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Action is a keybinding on the selected row. Actions re-invoke the agency
+// binary so they share the CLI's code paths; watch implements no logic itself.
+type Action struct {
+	// Key is the bubbletea key string that triggers the action.
+	Key string
+
+	// Label is shown in the help line and status messages.
+	Label string
+
+	// Args is the agency argv (without the binary). Nil for Shell actions.
+	Args []string
+
+	// Dir is the working directory for the command ("" inherits watch's cwd).
+	Dir string
+
+	// Interactive actions suspend the TUI and hand over the terminal
+	// (attach, open, shell). Others run in the background and their combined
+	// output is shown in the output pane.
+	Interactive bool
+
+	// Shell starts $SHELL in Dir instead of running agency.
+	Shell bool
+
+	// Confirm requires a "y" keypress before running.
+	Confirm bool
+}
+
+// runActions returns the actions available for a v1 run.
+func runActions(r RunItem) []Action {
+	if r.Broken {
+		return []Action{{Key: "s", Label: "show", Args: []string{"show", r.RunID}, Dir: r.RepoRoot}}
+	}
+	var actions []Action
+	if r.TmuxActive && !r.Headless {
+		actions = append(actions, Action{Key: "enter", Label: "attach", Args: []string{"attach", r.RunID}, Dir: r.RepoRoot, Interactive: true})
+	}
+	actions = append(actions, Action{Key: "s", Label: "show", Args: []string{"show", r.RunID}, Dir: r.RepoRoot})
+	if !r.Archived {
+		actions = append(actions,
+			Action{Key: "o", Label: "open", Args: []string{"open", r.RunID}, Dir: r.RepoRoot, Interactive: true},
+			Action{Key: "S", Label: "shell", Dir: r.WorktreePath, Interactive: true, Shell: true},
+			Action{Key: "x", Label: "stop", Args: []string{"stop", r.RunID}, Dir: r.RepoRoot},
+			Action{Key: "K", Label: "kill", Args: []string{"kill", r.RunID}, Dir: r.RepoRoot, Confirm: true},
+			Action{Key: "p", Label: "push", Args: []string{"push", r.RunID}, Dir: r.RepoRoot, Confirm: true},
+			Action{Key: "v", Label: "verify", Args: []string{"verify", r.RunID}, Dir: r.RepoRoot},
+		)
+	}
+	return actions
+}
+
+// worktreeActions returns the actions available for an integration worktree.
+// Worktree commands are repo-scoped, so they run from the repo root.
+func worktreeActions(w WorktreeItem) []Action {
+	actions := []Action{{Key: "s", Label: "show", Args: []string{"worktree", "show", w.WorktreeID}, Dir: w.RepoRoot}}
+	if !w.Broken && w.State == "present" {
+		actions = append(actions,
+			Action{Key: "o", Label: "open", Args: []string{"worktree", "open", w.WorktreeID}, Dir: w.RepoRoot, Interactive: true},
+			Action{Key: "S", Label: "shell", Args: []string{"worktree", "shell", w.WorktreeID}, Dir: w.RepoRoot, Interactive: true},
+		)
+	}
+	return actions
+}
+
+// invocationActions returns the actions available for an agent invocation.
+func invocationActions(inv InvocationItem) []Action {
+	return []Action{{Key: "s", Label: "show", Args: []string{"agent", "show", inv.InvocationID}, Dir: inv.RepoRoot}}
+}
+
+// target returns the action's subject (the last agency arg, e.g. a run id).
+func (a Action) target() string {
+	if len(a.Args) == 0 {
+		return a.Dir
+	}
+	return a.Args[len(a.Args)-1]
+}
+
+// command builds the process for an action.
+func (a Action) command(ctx context.Context, executable string) *osexec.Cmd {
+	var cmd *osexec.Cmd
+	if a.Shell {
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		cmd = osexec.CommandContext(ctx, shell, "-l")
+	} else {
+		cmd = osexec.CommandContext(ctx, executable, a.Args...)
+	}
+	cmd.Dir = a.Dir
+	return cmd
+}
+
+// actionDoneMsg reports a finished background action.
+type actionDoneMsg struct {
+	action Action
+	output string
+	err    error
+}
+
+// execDoneMsg reports the end of an interactive action.
+type execDoneMsg struct {
+	action Action
+	err    error
+}
+
+// captureFunc runs a background action and returns its combined output.
+type captureFunc func(ctx context.Context, executable string, a Action) (string, error)
+
+// captureOutput is the default captureFunc.
+func captureOutput(ctx context.Context, executable string, a Action) (string, error) {
+	out, err := a.command(ctx, executable).CombinedOutput()
+	return string(out), err
+}
+
+// execFunc hands the terminal to an interactive action.
+type execFunc func(ctx context.Context, executable string, a Action) tea.Cmd
+
+// execInteractive is the default execFunc.
+func execInteractive(ctx context.Context, executable string, a Action) tea.Cmd {
+	return tea.ExecProcess(a.command(ctx, executable), func(err error) tea.Msg {
+		return execDoneMsg{action: a, err: err}
+	})
+}
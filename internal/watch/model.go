@@ -0,0 +1,630 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultInterval is the default refresh interval.
+const DefaultInterval = 2 * time.Second
+
+// Options configures the watch TUI.
+type Options struct {
+	// Load collects a snapshot. It is called on every refresh and must be
+	// read-only.
+	Load func(ctx context.Context) (*Snapshot, error)
+
+	// Executable is the agency binary re-invoked for actions.
+	Executable string
+
+	// Interval is the refresh interval (default: DefaultInterval).
+	Interval time.Duration
+
+	// Scope describes what is listed (e.g. "repo github:owner/repo"); shown
+	// in the header.
+	Scope string
+}
+
+type rowKind int
+
+const (
+	rowWorktree rowKind = iota
+	rowInvocation
+	rowRun
+)
+
+// row is one selectable line of the list.
+type row struct {
+	kind rowKind
+	wt   int // index into Snapshot.Worktrees (worktree and invocation rows)
+	inv  int // index into Worktree.Invocations (invocation rows)
+	run  int // index into Snapshot.Runs (run rows)
+
+	// key is a stable identity used to keep the selection across refreshes.
+	key string
+}
+
+// outputPane holds the captured output of the last background action.
+type outputPane struct {
+	title  string
+	lines  []string
+	offset int
+}
+
+// Model is the bubbletea model for agency watch.
+type Model struct {
+	ctx  context.Context
+	opts Options
+
+	snap    *Snapshot
+	rows    []row
+	cursor  int
+	offset  int
+	loading bool
+	loadErr error
+
+	width  int
+	height int
+
+	busy    string
+	status  string
+	confirm *Action
+	output  *outputPane
+
+	capture captureFunc
+	exec    execFunc
+}
+
+// New creates the watch model.
+func New(ctx context.Context, opts Options) *Model {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	return &Model{
+		ctx:     ctx,
+		opts:    opts,
+		width:   80,
+		height:  24,
+		capture: captureOutput,
+		exec:    execInteractive,
+	}
+}
+
+type snapshotMsg struct {
+	snap *Snapshot
+	err  error
+}
+
+type tickMsg struct{}
+
+// Init starts the first load and the refresh ticker.
+func (m *Model) Init() tea.Cmd {
+	m.loading = true
+	return tea.Batch(m.load(), m.tick())
+}
+
+func (m *Model) load() tea.Cmd {
+	ctx, load := m.ctx, m.opts.Load
+	return func() tea.Msg {
+		snap, err := load(ctx)
+		return snapshotMsg{snap: snap, err: err}
+	}
+}
+
+func (m *Model) tick() tea.Cmd {
+	return tea.Tick(m.opts.Interval, func(time.Time) tea.Msg { return tickMsg{} })
+}
+
+// refresh starts a load unless one is already in flight.
+func (m *Model) refresh() tea.Cmd {
+	if m.loading {
+		return nil
+	}
+	m.loading = true
+	return m.load()
+}
+
+// Update handles messages.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case tickMsg:
+		return m, tea.Batch(m.refresh(), m.tick())
+
+	case snapshotMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.loadErr = msg.err
+			return m, nil
+		}
+		m.loadErr = nil
+		m.setSnapshot(msg.snap)
+		return m, nil
+
+	case actionDoneMsg:
+		m.busy = ""
+		title := "agency " + strings.Join(msg.action.Args, " ")
+		if msg.err != nil {
+			title += " (failed: " + msg.err.Error() + ")"
+			m.status = msg.action.Label + " failed"
+		} else {
+			m.status = msg.action.Label + " finished"
+		}
+		m.output = &outputPane{title: title, lines: splitLines(msg.output)}
+		return m, m.refresh()
+
+	case execDoneMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("%s exited: %v", msg.action.Label, msg.err)
+		} else {
+			m.status = ""
+		}
+		return m, m.refresh()
+
+	case tea.KeyMsg:
+		return m, m.handleKey(msg.String())
+	}
+	return m, nil
+}
+
+func (m *Model) handleKey(key string) tea.Cmd {
+	if m.confirm != nil {
+		a := *m.confirm
+		m.confirm = nil
+		if key == "y" || key == "Y" {
+			return m.run(a)
+		}
+		m.status = a.Label + " cancelled"
+		return nil
+	}
+
+	switch key {
+	case "ctrl+c", "q":
+		return tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "home", "g":
+		m.move(-len(m.rows))
+	case "end", "G":
+		m.move(len(m.rows))
+	case "r":
+		m.status = ""
+		return m.refresh()
+	case "esc":
+		m.output = nil
+	case "pgup", "ctrl+u":
+		m.scrollOutput(-m.bottomHeight() / 2)
+	case "pgdown", "ctrl+d":
+		m.scrollOutput(m.bottomHeight() / 2)
+	default:
+		for _, a := range m.selectedActions() {
+			if a.Key != key {
+				continue
+			}
+			if a.Confirm {
+				m.confirm = &a
+				return nil
+			}
+			return m.run(a)
+		}
+	}
+	return nil
+}
+
+// run starts an action.
+func (m *Model) run(a Action) tea.Cmd {
+	if a.Interactive {
+		return m.exec(m.ctx, m.opts.Executable, a)
+	}
+	if m.busy != "" {
+		m.status = "busy: " + m.busy + " is still running"
+		return nil
+	}
+	m.busy = a.Label
+	m.status = "running " + a.Label + "..."
+	ctx, exe, capture := m.ctx, m.opts.Executable, m.capture
+	return func() tea.Msg {
+		out, err := capture(ctx, exe, a)
+		return actionDoneMsg{action: a, output: out, err: err}
+	}
+}
+
+// setSnapshot installs a new snapshot, keeping the selected row if it still exists.
+func (m *Model) setSnapshot(snap *Snapshot) {
+	selected := ""
+	if m.cursor < len(m.rows) {
+		selected = m.rows[m.cursor].key
+	}
+	m.snap = snap
+	m.rows = buildRows(snap)
+	m.cursor = 0
+	for i, r := range m.rows {
+		if r.key == selected {
+			m.cursor = i
+			break
+		}
+	}
+	m.move(0)
+}
+
+// buildRows flattens the snapshot: worktrees with nested invocations, then runs.
+func buildRows(snap *Snapshot) []row {
+	if snap == nil {
+		return nil
+	}
+	var rows []row
+	for wi, w := range snap.Worktrees {
+		rows = append(rows, row{kind: rowWorktree, wt: wi, key: "w:" + w.WorktreeID})
+		for ii, inv := range w.Invocations {
+			rows = append(rows, row{kind: rowInvocation, wt: wi, inv: ii, key: "i:" + inv.InvocationID})
+		}
+	}
+	for ri, r := range snap.Runs {
+		rows = append(rows, row{kind: rowRun, run: ri, key: "r:" + r.RepoID + "/" + r.RunID})
+	}
+	return rows
+}
+
+func (m *Model) move(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *Model) scrollOutput(delta int) {
+	if m.output == nil {
+		return
+	}
+	m.output.offset += delta
+	if limit := len(m.output.lines) - (m.bottomHeight() - 1); m.output.offset > limit {
+		m.output.offset = limit
+	}
+	if m.output.offset < 0 {
+		m.output.offset = 0
+	}
+}
+
+// selectedActions returns the actions for the selected row.
+func (m *Model) selectedActions() []Action {
+	if m.snap == nil || m.cursor >= len(m.rows) {
+		return nil
+	}
+	r := m.rows[m.cursor]
+	switch r.kind {
+	case rowWorktree:
+		return worktreeActions(m.snap.Worktrees[r.wt])
+	case rowInvocation:
+		return invocationActions(m.snap.Worktrees[r.wt].Invocations[r.inv])
+	default:
+		return runActions(m.snap.Runs[r.run])
+	}
+}
+
+var (
+	styleHeader   = lipgloss.NewStyle().Bold(true)
+	styleSection  = lipgloss.NewStyle().Bold(true).Underline(true)
+	styleSelected = lipgloss.NewStyle().Reverse(true)
+	styleDim      = lipgloss.NewStyle().Faint(true)
+	styleWarn     = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
+	styleErr      = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+)
+
+// bottomHeight is the height of the detail/output pane: sized to its content,
+// at least 6 lines and at most half the screen.
+func (m *Model) bottomHeight() int {
+	h := len(m.bottomLines())
+	if m.output != nil {
+		h = m.height / 2
+	}
+	if limit := m.height / 2; h > limit {
+		h = limit
+	}
+	if h < 6 {
+		h = 6
+	}
+	return h
+}
+
+// View renders the screen.
+func (m *Model) View() string {
+	var b strings.Builder
+
+	b.WriteString(styleHeader.Render(truncate(m.header(), m.width)) + "\n")
+
+	listHeight := m.height - m.bottomHeight() - 4
+	if listHeight < 3 {
+		listHeight = 3
+	}
+	lines, cursorLine := m.listLines()
+	if cursorLine < m.offset {
+		m.offset = cursorLine
+	}
+	if cursorLine >= m.offset+listHeight {
+		m.offset = cursorLine - listHeight + 1
+	}
+	if m.offset > len(lines)-listHeight {
+		m.offset = max(0, len(lines)-listHeight)
+	}
+	for i := 0; i < listHeight; i++ {
+		if j := m.offset + i; j < len(lines) {
+			b.WriteString(lines[j])
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(styleDim.Render(strings.Repeat("─", max(0, m.width))) + "\n")
+	bottom := m.bottomLines()
+	for i := 0; i < m.bottomHeight(); i++ {
+		if i < len(bottom) {
+			b.WriteString(truncate(bottom[i], m.width))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(m.footer())
+	return b.String()
+}
+
+func (m *Model) header() string {
+	parts := []string{"agency watch"}
+	if m.opts.Scope != "" {
+		parts = append(parts, m.opts.Scope)
+	}
+	if m.snap != nil {
+		parts = append(parts,
+			fmt.Sprintf("%d worktrees", len(m.snap.Worktrees)),
+			fmt.Sprintf("%d runs", len(m.snap.Runs)),
+			"updated "+m.snap.TakenAt.Local().Format("15:04:05"),
+		)
+	} else {
+		parts = append(parts, "loading...")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// listLines renders the list and returns the line index of the cursor row.
+func (m *Model) listLines() ([]string, int) {
+	if m.loadErr != nil && m.snap == nil {
+		return []string{styleErr.Render("error: " + m.loadErr.Error())}, 0
+	}
+	if m.snap == nil {
+		return nil, 0
+	}
+	if len(m.rows) == 0 {
+		return []string{styleDim.Render("No worktrees or runs.")}, 0
+	}
+
+	var lines []string
+	cursorLine := 0
+	section := rowKind(-1)
+	for i, r := range m.rows {
+		if r.kind == rowWorktree && section != rowWorktree {
+			lines = append(lines, styleSection.Render("WORKTREES"))
+			section = rowWorktree
+		}
+		if r.kind == rowRun && section != rowRun {
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, styleSection.Render("RUNS"))
+			section = rowRun
+		}
+
+		text := truncate(m.rowText(i), m.width)
+		if i == m.cursor {
+			text = styleSelected.Render(padRight(text, m.width))
+			cursorLine = len(lines)
+		} else if r.kind == rowRun && m.snap.Runs[r.run].Stalled != "" {
+			text = styleWarn.Render(text)
+		}
+		lines = append(lines, text)
+	}
+	return lines, cursorLine
+}
+
+func (m *Model) rowText(i int) string {
+	r := m.rows[i]
+	switch r.kind {
+	case rowWorktree:
+		w := m.snap.Worktrees[r.wt]
+		if w.Broken {
+			return fmt.Sprintf("%s [broken]", w.WorktreeID)
+		}
+		text := fmt.Sprintf("%s (%s) [%s]", w.Name, w.Branch, w.State)
+		if w.Landings > 0 {
+			text += fmt.Sprintf(" · %d landed", w.Landings)
+		}
+		return text
+	case rowInvocation:
+		w := m.snap.Worktrees[r.wt]
+		inv := w.Invocations[r.inv]
+		glyph := "├─"
+		if r.inv == len(w.Invocations)-1 {
+			glyph = "└─"
+		}
+		if inv.Broken {
+			return fmt.Sprintf("  %s %s [broken]", glyph, inv.InvocationID)
+		}
+		text := fmt.Sprintf("  %s %s  %-7s %-8s %-8s", glyph, inv.InvocationID, inv.Runner, inv.Mode, inv.Status)
+		if inv.LandingStatus != "" {
+			text += "  [" + inv.LandingStatus + "]"
+		}
+		return strings.TrimRight(text, " ")
+	default:
+		run := m.snap.Runs[r.run]
+		text := fmt.Sprintf("%-24s %-18s %-7s", truncate(run.Label(), 24), run.DerivedStatus, run.Runner)
+		if run.Stalled != "" {
+			text += " stalled " + run.Stalled
+		}
+		if run.Summary != "" {
+			text += "  " + run.Summary
+		}
+		return strings.TrimRight(text, " ")
+	}
+}
+
+// bottomLines renders the output pane if open, otherwise the selected row's details.
+func (m *Model) bottomLines() []string {
+	if o := m.output; o != nil {
+		lines := []string{styleHeader.Render(o.title) + styleDim.Render("  (esc close, pgup/pgdn scroll)")}
+		end := min(len(o.lines), o.offset+m.bottomHeight()-1)
+		return append(lines, o.lines[o.offset:end]...)
+	}
+	if m.snap == nil || m.cursor >= len(m.rows) {
+		return nil
+	}
+	r := m.rows[m.cursor]
+	switch r.kind {
+	case rowWorktree:
+		return worktreeDetail(m.snap.Worktrees[r.wt])
+	case rowInvocation:
+		return invocationDetail(m.snap.Worktrees[r.wt].Invocations[r.inv])
+	default:
+		return runDetail(m.snap.Runs[r.run])
+	}
+}
+
+func worktreeDetail(w WorktreeItem) []string {
+	if w.Broken {
+		return []string{styleErr.Render("worktree " + w.WorktreeID + ": meta.json unreadable or invalid")}
+	}
+	return []string{
+		styleHeader.Render(w.Name) + "  " + w.WorktreeID,
+		"branch: " + w.Branch + " (parent " + w.ParentBranch + ")",
+		"state: " + w.State,
+		"tree: " + w.TreePath,
+		fmt.Sprintf("invocations: %d   landings: %d", len(w.Invocations), w.Landings),
+	}
+}
+
+func invocationDetail(inv InvocationItem) []string {
+	if inv.Broken {
+		return []string{styleErr.Render("invocation " + inv.InvocationID + ": broken (meta unreadable or sandbox missing)")}
+	}
+	lines := []string{
+		styleHeader.Render(inv.InvocationID) + "  " + inv.Name,
+		"runner: " + inv.Runner + "   mode: " + inv.Mode + "   status: " + inv.Status,
+		"started: " + inv.StartedAt,
+		"sandbox: " + inv.SandboxPath,
+	}
+	if inv.LandingStatus != "" {
+		lines = append(lines, "landing: "+inv.LandingStatus)
+	}
+	return lines
+}
+
+func runDetail(r RunItem) []string {
+	if r.Broken {
+		return []string{styleErr.Render("run " + r.RunID + ": meta.json unreadable or invalid")}
+	}
+	lines := []string{styleHeader.Render(r.Label()) + "  " + r.RunID + "  " + r.DerivedStatus}
+	if r.Stalled != "" {
+		lines = append(lines, styleWarn.Render("stalled: no activity for "+r.Stalled))
+	}
+	if r.RunnerStatus != "" {
+		lines = append(lines, "runner_status: "+r.RunnerStatus+" (updated "+r.StatusUpdatedAt+")")
+	}
+	if r.Summary != "" {
+		lines = append(lines, "summary: "+r.Summary)
+	}
+	lines = appendList(lines, "questions", r.Questions)
+	lines = appendList(lines, "blockers", r.Blockers)
+	lines = appendList(lines, "risks", r.Risks)
+	if r.HowToTest != "" {
+		lines = append(lines, "how to test: "+r.HowToTest)
+	}
+	lines = append(lines,
+		"branch: "+r.Branch+"   runner: "+r.Runner,
+		"worktree: "+r.WorktreePath,
+	)
+	if r.PRURL != "" {
+		lines = append(lines, fmt.Sprintf("pr: #%d %s", r.PRNumber, r.PRURL))
+	}
+	if r.LastVerifyAt != "" {
+		result := "finished"
+		if r.VerifyOK != nil && *r.VerifyOK {
+			result = "passed"
+		} else if r.VerifyOK != nil {
+			result = "failed"
+		}
+		lines = append(lines, "verify: "+result+" "+r.LastVerifyAt)
+	}
+	return lines
+}
+
+func appendList(lines []string, title string, items []string) []string {
+	if len(items) == 0 {
+		return lines
+	}
+	lines = append(lines, title+":")
+	for _, item := range items {
+		lines = append(lines, "  - "+item)
+	}
+	return lines
+}
+
+func (m *Model) footer() string {
+	if m.confirm != nil {
+		return styleWarn.Render(fmt.Sprintf("%s %s? [y/N]", m.confirm.Label, m.confirm.target()))
+	}
+	var keys []string
+	for _, a := range m.selectedActions() {
+		keys = append(keys, a.Key+" "+a.Label)
+	}
+	keys = append(keys, "r refresh", "q quit")
+	help := strings.Join(keys, "  ")
+
+	status := m.status
+	if m.loadErr != nil && m.snap != nil {
+		status = "refresh failed: " + m.loadErr.Error()
+	}
+	if status != "" {
+		help = status + "  |  " + help
+	}
+	return truncate(help, m.width)
+}
+
+// truncate shortens s to at most width runes, ending in "…" when cut.
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// padRight pads s with spaces to width runes.
+func padRight(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return []string{"(no output)"}
+	}
+	return strings.Split(strings.ReplaceAll(s, "\t", "    "), "\n")
+}
@@ -0,0 +1,196 @@
+package watch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{
+		TakenAt: time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC),
+		Worktrees: []WorktreeItem{{
+			WorktreeID: "20260115110000-aaaa",
+			Name:       "integration",
+			Branch:     "agency/integration-aaaa",
+			State:      "present",
+			RepoRoot:   "/repo",
+			Invocations: []InvocationItem{
+				{InvocationID: "20260115110500-bbbb", Runner: "claude", Mode: "headless", Status: "running"},
+			},
+		}},
+		Runs: []RunItem{
+			{
+				RunID: "20260115120000-cccc", Name: "feature", Runner: "claude",
+				DerivedStatus: "needs attention", TmuxActive: true, Stalled: "45m",
+				Summary: "waiting on schema decision", RunnerStatus: "needs_input",
+				Questions: []string{"Which schema version?"},
+				Blockers:  []string{"migration fails"},
+				Risks:     []string{"drops old column"},
+			},
+			{RunID: "20260115100000-dddd", Name: "old", DerivedStatus: "merged", Archived: true},
+		},
+	}
+}
+
+// newTestModel returns a model with a loaded snapshot and recording action hooks.
+func newTestModel(t *testing.T) (*Model, *[]Action) {
+	t.Helper()
+	var ran []Action
+	m := New(context.Background(), Options{Executable: "agency"})
+	m.capture = func(ctx context.Context, exe string, a Action) (string, error) {
+		ran = append(ran, a)
+		return "ok\n", nil
+	}
+	m.exec = func(ctx context.Context, exe string, a Action) tea.Cmd {
+		ran = append(ran, a)
+		return nil
+	}
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.Update(snapshotMsg{snap: testSnapshot()})
+	return m, &ran
+}
+
+func press(m *Model, keys ...string) tea.Cmd {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		_, cmd = m.Update(msg)
+	}
+	return cmd
+}
+
+// drain runs a command and feeds its message back into the model.
+func drain(m *Model, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	if msg := cmd(); msg != nil {
+		m.Update(msg)
+	}
+}
+
+func TestModel_RowsAreHierarchical(t *testing.T) {
+	m, _ := newTestModel(t)
+
+	if len(m.rows) != 4 {
+		t.Fatalf("rows = %d, want 4 (worktree, invocation, 2 runs)", len(m.rows))
+	}
+	want := []rowKind{rowWorktree, rowInvocation, rowRun, rowRun}
+	for i, r := range m.rows {
+		if r.kind != want[i] {
+			t.Errorf("row %d kind = %d, want %d", i, r.kind, want[i])
+		}
+	}
+
+	view := m.View()
+	for _, s := range []string{"WORKTREES", "integration (agency/integration-aaaa) [present]", "└─ 20260115110500-bbbb", "RUNS", "feature", "stalled 45m"} {
+		if !strings.Contains(view, s) {
+			t.Errorf("view missing %q:\n%s", s, view)
+		}
+	}
+}
+
+func TestModel_DetailPaneShowsRunnerReport(t *testing.T) {
+	m, _ := newTestModel(t)
+	press(m, "j", "j")
+
+	view := m.View()
+	for _, s := range []string{"questions:", "Which schema version?", "blockers:", "migration fails", "risks:", "drops old column", "stalled: no activity for 45m"} {
+		if !strings.Contains(view, s) {
+			t.Errorf("detail missing %q:\n%s", s, view)
+		}
+	}
+}
+
+func TestModel_SelectionSurvivesRefresh(t *testing.T) {
+	m, _ := newTestModel(t)
+	press(m, "G")
+	if got := m.rows[m.cursor].key; got != "r:/20260115100000-dddd" {
+		t.Fatalf("cursor on %q", got)
+	}
+
+	// A new run appears at the top; the selection stays on the same run
+	snap := testSnapshot()
+	snap.Runs = append([]RunItem{{RunID: "20260115130000-eeee", Name: "new"}}, snap.Runs...)
+	m.Update(snapshotMsg{snap: snap})
+	if got := m.rows[m.cursor].key; got != "r:/20260115100000-dddd" {
+		t.Errorf("cursor moved to %q after refresh", got)
+	}
+}
+
+func TestModel_ActionsRouteToAgencyCommands(t *testing.T) {
+	m, ran := newTestModel(t)
+	press(m, "j", "j") // feature run
+
+	press(m, "enter")
+	drain(m, press(m, "v"))
+
+	if len(*ran) != 2 {
+		t.Fatalf("ran %d actions, want 2", len(*ran))
+	}
+	if a := (*ran)[0]; !a.Interactive || strings.Join(a.Args, " ") != "attach 20260115120000-cccc" {
+		t.Errorf("enter ran %+v", a)
+	}
+	if a := (*ran)[1]; a.Interactive || strings.Join(a.Args, " ") != "verify 20260115120000-cccc" {
+		t.Errorf("v ran %+v", a)
+	}
+	if m.output == nil || !strings.Contains(m.output.title, "agency verify") {
+		t.Errorf("expected output pane for verify, got %+v", m.output)
+	}
+	press(m, "esc")
+	if m.output != nil {
+		t.Error("esc should close the output pane")
+	}
+}
+
+func TestModel_KillRequiresConfirmation(t *testing.T) {
+	m, ran := newTestModel(t)
+	press(m, "j", "j")
+
+	press(m, "K")
+	if m.confirm == nil || !strings.Contains(m.View(), "kill 20260115120000-cccc? [y/N]") {
+		t.Fatal("expected kill confirmation prompt")
+	}
+	press(m, "n")
+	if len(*ran) != 0 {
+		t.Fatalf("kill ran without confirmation: %+v", *ran)
+	}
+
+	drain(m, press(m, "K", "y"))
+	if len(*ran) != 1 || strings.Join((*ran)[0].Args, " ") != "kill 20260115120000-cccc" {
+		t.Errorf("ran %+v, want kill", *ran)
+	}
+}
+
+func TestModel_WorktreeActionsRunFromRepoRoot(t *testing.T) {
+	m, ran := newTestModel(t)
+
+	press(m, "S")
+	if len(*ran) != 1 {
+		t.Fatalf("ran %d actions, want 1", len(*ran))
+	}
+	a := (*ran)[0]
+	if strings.Join(a.Args, " ") != "worktree shell 20260115110000-aaaa" || a.Dir != "/repo" || !a.Interactive {
+		t.Errorf("S ran %+v", a)
+	}
+}
+
+func TestModel_ArchivedRunHasNoMutatingActions(t *testing.T) {
+	m, ran := newTestModel(t)
+	press(m, "G", "x", "K", "p", "v", "enter")
+	if len(*ran) != 0 {
+		t.Errorf("archived run ran actions: %+v", *ran)
+	}
+}
@@ -0,0 +1,109 @@
+// Package watch implements the agency watch TUI: a polling, read-only view of
+// integration worktrees, their agent invocations, and v1 runs.
+//
+// Watch never mutates state itself. Every action re-invokes the agency binary
+// (attach, show, open, stop, kill, push, verify, ...), so all mutations go
+// through the same code paths as the CLI.
+package watch
+
+import "time"
+
+// Snapshot is one poll of the agency store.
+type Snapshot struct {
+	// Worktrees are the integration worktrees in scope, oldest first.
+	Worktrees []WorktreeItem
+
+	// Runs are the v1 runs in scope, newest first.
+	Runs []RunItem
+
+	// TakenAt is when the snapshot was collected.
+	TakenAt time.Time
+}
+
+// WorktreeItem is an integration worktree with its agent invocations.
+type WorktreeItem struct {
+	WorktreeID   string
+	Name         string
+	RepoID       string
+	Branch       string
+	ParentBranch string
+	TreePath     string
+	State        string
+	CreatedAt    string
+	Landings     int
+
+	// RepoRoot is the repo's last seen root; actions run there so the
+	// repo-scoped worktree commands resolve. Empty if unknown.
+	RepoRoot string
+
+	// Broken is true when meta.json is unreadable or invalid.
+	Broken bool
+
+	Invocations []InvocationItem
+}
+
+// InvocationItem is an agent invocation nested under its integration worktree.
+type InvocationItem struct {
+	InvocationID  string
+	Name          string
+	Runner        string
+	Mode          string
+	Status        string
+	LandingStatus string
+	StartedAt     string
+	SandboxPath   string
+	RepoRoot      string
+	Broken        bool
+}
+
+// RunItem is a v1 run with its derived status and runner report.
+type RunItem struct {
+	RunID        string
+	Name         string
+	RepoID       string
+	Runner       string
+	Branch       string
+	WorktreePath string
+	CreatedAt    string
+
+	// RepoRoot is the repo's last seen root (empty if unknown).
+	RepoRoot string
+
+	// DerivedStatus is status.Derive output (same derivation as ls).
+	DerivedStatus string
+
+	// Summary is the runner_status.json summary (empty if none).
+	Summary string
+
+	// Stalled is the formatted stall duration ("45m"); empty when not stalled.
+	Stalled string
+
+	TmuxActive bool
+	Headless   bool
+	Archived   bool
+	Broken     bool
+
+	PRNumber int
+	PRURL    string
+
+	// LastVerifyAt is when verify last finished; VerifyOK is its result
+	// from verify_record.json (nil if unknown).
+	LastVerifyAt string
+	VerifyOK     *bool
+
+	// Runner report details from runner_status.json.
+	RunnerStatus    string
+	StatusUpdatedAt string
+	Questions       []string
+	Blockers        []string
+	Risks           []string
+	HowToTest       string
+}
+
+// Label returns the run's display name, falling back to its id.
+func (r RunItem) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.RunID
+}
@@ -0,0 +1,241 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Stream event names emitted by agency watch --json.
+const (
+	// EventSnapshot is the first line of a stream: the state of every run in scope.
+	EventSnapshot = "snapshot"
+
+	// EventRunAdded is emitted when a run appears after the snapshot.
+	EventRunAdded = "run_added"
+
+	// EventStatusChanged is emitted when a run's derived status changes.
+	EventStatusChanged = "status_changed"
+
+	// EventRunnerStatusUpdated is emitted when runner_status.json is rewritten.
+	EventRunnerStatusUpdated = "runner_status_updated"
+
+	// EventStalled is emitted when a run becomes stalled.
+	EventStalled = "stalled"
+
+	// EventPRCreated is emitted when a run gets a PR number.
+	EventPRCreated = "pr_created"
+
+	// EventVerifyFinished is emitted when a verify run finishes.
+	EventVerifyFinished = "verify_finished"
+
+	// EventArchived is emitted when a run is archived.
+	EventArchived = "archived"
+)
+
+// StreamEvent is one line of agency watch --json output.
+// It mirrors the events.jsonl line shape; repo_id and run_id are omitted on
+// the snapshot line.
+type StreamEvent struct {
+	SchemaVersion string         `json:"schema_version"`
+	Timestamp     string         `json:"timestamp"` // RFC3339
+	RepoID        string         `json:"repo_id,omitempty"`
+	RunID         string         `json:"run_id,omitempty"`
+	Event         string         `json:"event"`
+	Data          map[string]any `json:"data,omitempty"`
+}
+
+// RunState is the JSON form of a run on the snapshot and run_added lines.
+type RunState struct {
+	RunID           string  `json:"run_id"`
+	RepoID          string  `json:"repo_id"`
+	Name            string  `json:"name"`
+	Runner          string  `json:"runner"`
+	Branch          string  `json:"branch"`
+	DerivedStatus   string  `json:"derived_status"`
+	RunnerStatus    *string `json:"runner_status"`
+	Summary         *string `json:"summary"`
+	StalledDuration *string `json:"stalled_duration"`
+	TmuxActive      bool    `json:"tmux_active"`
+	Archived        bool    `json:"archived"`
+	Broken          bool    `json:"broken"`
+	PRNumber        *int    `json:"pr_number"`
+	PRURL           *string `json:"pr_url"`
+	LastVerifyAt    *string `json:"last_verify_at"`
+	VerifyOK        *bool   `json:"verify_ok"`
+}
+
+// NewRunState converts a watch run row into its JSON form.
+func NewRunState(r RunItem) RunState {
+	s := RunState{
+		RunID:         r.RunID,
+		RepoID:        r.RepoID,
+		Name:          r.Name,
+		Runner:        r.Runner,
+		Branch:        r.Branch,
+		DerivedStatus: r.DerivedStatus,
+		TmuxActive:    r.TmuxActive,
+		Archived:      r.Archived,
+		Broken:        r.Broken,
+		VerifyOK:      r.VerifyOK,
+	}
+	if r.RunnerStatus != "" {
+		s.RunnerStatus = &r.RunnerStatus
+	}
+	if r.Summary != "" {
+		s.Summary = &r.Summary
+	}
+	if r.Stalled != "" {
+		s.StalledDuration = &r.Stalled
+	}
+	if r.PRNumber != 0 {
+		s.PRNumber = &r.PRNumber
+	}
+	if r.PRURL != "" {
+		s.PRURL = &r.PRURL
+	}
+	if r.LastVerifyAt != "" {
+		s.LastVerifyAt = &r.LastVerifyAt
+	}
+	return s
+}
+
+// SnapshotEvent returns the snapshot line for snap. Archived runs are
+// included only if includeArchived is set.
+func SnapshotEvent(snap *Snapshot, includeArchived bool) StreamEvent {
+	runs := []RunState{}
+	for _, r := range snap.Runs {
+		if r.Archived && !includeArchived {
+			continue
+		}
+		runs = append(runs, NewRunState(r))
+	}
+	return StreamEvent{
+		SchemaVersion: "1.0",
+		Timestamp:     snap.TakenAt.UTC().Format(time.RFC3339),
+		Event:         EventSnapshot,
+		Data:          map[string]any{"runs": runs},
+	}
+}
+
+// Transitions returns the events that take prev to next, in snapshot order.
+// Per run the order is: status_changed, runner_status_updated, stalled,
+// pr_created, verify_finished, archived. Runs that disappear emit nothing.
+func Transitions(prev, next *Snapshot) []StreamEvent {
+	before := make(map[string]RunItem, len(prev.Runs))
+	for _, r := range prev.Runs {
+		before[r.RepoID+"/"+r.RunID] = r
+	}
+
+	ts := next.TakenAt.UTC().Format(time.RFC3339)
+	var out []StreamEvent
+	emit := func(r RunItem, name string, data map[string]any) {
+		out = append(out, StreamEvent{
+			SchemaVersion: "1.0",
+			Timestamp:     ts,
+			RepoID:        r.RepoID,
+			RunID:         r.RunID,
+			Event:         name,
+			Data:          data,
+		})
+	}
+
+	for _, r := range next.Runs {
+		p, ok := before[r.RepoID+"/"+r.RunID]
+		if !ok {
+			emit(r, EventRunAdded, map[string]any{"run": NewRunState(r)})
+			continue
+		}
+		if p.DerivedStatus != r.DerivedStatus {
+			emit(r, EventStatusChanged, map[string]any{"from": p.DerivedStatus, "to": r.DerivedStatus})
+		}
+		if r.RunnerStatus != "" && (p.RunnerStatus != r.RunnerStatus || p.StatusUpdatedAt != r.StatusUpdatedAt) {
+			emit(r, EventRunnerStatusUpdated, map[string]any{
+				"status":     r.RunnerStatus,
+				"updated_at": r.StatusUpdatedAt,
+				"summary":    r.Summary,
+			})
+		}
+		if p.Stalled == "" && r.Stalled != "" {
+			emit(r, EventStalled, map[string]any{"stalled_duration": r.Stalled})
+		}
+		if p.PRNumber == 0 && r.PRNumber != 0 {
+			emit(r, EventPRCreated, map[string]any{"pr_number": r.PRNumber, "pr_url": r.PRURL})
+		}
+		if r.LastVerifyAt != "" && p.LastVerifyAt != r.LastVerifyAt {
+			data := map[string]any{"finished_at": r.LastVerifyAt}
+			if r.VerifyOK != nil {
+				data["ok"] = *r.VerifyOK
+			}
+			emit(r, EventVerifyFinished, data)
+		}
+		if !p.Archived && r.Archived {
+			emit(r, EventArchived, nil)
+		}
+	}
+	return out
+}
+
+// StreamOptions configures Stream.
+type StreamOptions struct {
+	// Load collects one snapshot. It must include archived runs so the
+	// archived transition can be seen.
+	Load func(ctx context.Context) (*Snapshot, error)
+
+	// Interval is the poll interval (default: DefaultInterval).
+	Interval time.Duration
+
+	// Once prints the snapshot line and returns.
+	Once bool
+
+	// All includes archived runs on the snapshot line.
+	All bool
+}
+
+// Stream writes the snapshot line, then one JSON line per transition until
+// ctx is done. A failed poll after the first is reported on stderr and
+// skipped; the next poll diffs against the last good snapshot.
+func Stream(ctx context.Context, opts StreamOptions, stdout, stderr io.Writer) error {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	enc := json.NewEncoder(stdout)
+
+	prev, err := opts.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(SnapshotEvent(prev, opts.All)); err != nil {
+		return err
+	}
+	if opts.Once {
+		return nil
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := opts.Load(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			_, _ = fmt.Fprintf(stderr, "warning: watch poll failed: %v\n", err)
+			continue
+		}
+		for _, e := range Transitions(prev, next) {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		prev = next
+	}
+}
@@ -0,0 +1,117 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransitions(t *testing.T) {
+	ok := false
+	prev := &Snapshot{Runs: []RunItem{
+		{RunID: "r1", RepoID: "repo", DerivedStatus: "active", RunnerStatus: "working", StatusUpdatedAt: "t1"},
+		{RunID: "r2", RepoID: "repo", DerivedStatus: "ready for review"},
+	}}
+	next := &Snapshot{TakenAt: time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC), Runs: []RunItem{
+		{
+			RunID: "r1", RepoID: "repo", DerivedStatus: "needs input",
+			RunnerStatus: "needs_input", StatusUpdatedAt: "t2", Summary: "which api?",
+			Stalled: "15m", PRNumber: 7, PRURL: "https://example.com/pr/7",
+			LastVerifyAt: "2026-01-15T11:59:00Z", VerifyOK: &ok,
+		},
+		{RunID: "r2", RepoID: "repo", DerivedStatus: "merged", Archived: true},
+		{RunID: "r3", RepoID: "repo", DerivedStatus: "active"},
+	}}
+
+	events := Transitions(prev, next)
+	var got []string
+	for _, e := range events {
+		got = append(got, e.RunID+":"+e.Event)
+	}
+	want := []string{
+		"r1:status_changed", "r1:runner_status_updated", "r1:stalled", "r1:pr_created", "r1:verify_finished",
+		"r2:status_changed", "r2:archived",
+		"r3:run_added",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+
+	if d := events[0].Data; d["from"] != "active" || d["to"] != "needs input" {
+		t.Errorf("status_changed data = %v", d)
+	}
+	if d := events[4].Data; d["ok"] != false {
+		t.Errorf("verify_finished data = %v", d)
+	}
+	if events[0].Timestamp != "2026-01-15T12:00:00Z" || events[0].SchemaVersion != "1.0" {
+		t.Errorf("event header = %+v", events[0])
+	}
+
+	// Unchanged snapshots emit nothing; an ongoing stall is not repeated
+	if again := Transitions(next, next); len(again) != 0 {
+		t.Errorf("no-op transitions = %+v", again)
+	}
+}
+
+func TestStream_Once(t *testing.T) {
+	snap := testSnapshot()
+	var stdout, stderr bytes.Buffer
+	err := Stream(context.Background(), StreamOptions{
+		Load: func(ctx context.Context) (*Snapshot, error) { return snap, nil },
+		Once: true,
+	}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1:\n%s", len(lines), stdout.String())
+	}
+	var e struct {
+		Event string `json:"event"`
+		Data  struct {
+			Runs []RunState `json:"runs"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatal(err)
+	}
+	// The archived run is left off without All
+	if e.Event != EventSnapshot || len(e.Data.Runs) != 1 || e.Data.Runs[0].Name != "feature" {
+		t.Errorf("snapshot = %+v", e)
+	}
+	if s := e.Data.Runs[0].StalledDuration; s == nil || *s != "45m" {
+		t.Errorf("stalled_duration = %v", s)
+	}
+}
+
+func TestStream_EmitsTransitionsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	polls := 0
+	load := func(ctx context.Context) (*Snapshot, error) {
+		polls++
+		snap := &Snapshot{Runs: []RunItem{{RunID: "r1", RepoID: "repo", DerivedStatus: "active"}}}
+		if polls > 1 {
+			snap.Runs[0].Archived = true
+		}
+		if polls > 2 {
+			cancel()
+		}
+		return snap, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := Stream(ctx, StreamOptions{Load: load, Interval: time.Millisecond}, &stdout, &stderr); err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	out := stdout.String()
+	if strings.Count(out, `"event":"archived"`) != 1 || !strings.Contains(out, `"event":"snapshot"`) {
+		t.Errorf("stream output:\n%s", out)
+	}
+}
@@ -0,0 +1,17 @@
+package watch
+
+import (
+	"context"
+	stderrors "errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Run starts the full-screen TUI and blocks until the user quits or ctx is done.
+func Run(ctx context.Context, opts Options) error {
+	p := tea.NewProgram(New(ctx, opts), tea.WithAltScreen(), tea.WithContext(ctx))
+	if _, err := p.Run(); err != nil && !stderrors.Is(err, tea.ErrProgramKilled) {
+		return err
+	}
+	return nil
+}
@@ -1,7 +1,8 @@
 // Package watchdog provides stall detection for agency runs.
 //
-// A run is considered stalled if the runner_status.json file has not been
-// updated within the configured threshold and the tmux session still exists.
+// A run is considered stalled if neither the runner_status.json file nor the
+// runner's session log shows activity within the configured threshold and the
+// tmux session still exists.
 package watchdog
 
 import "time"
@@ -17,6 +18,10 @@ type ActivitySignals struct {
 
 	// TmuxSessionExists is true if the tmux session is running.
 	TmuxSessionExists bool
+
+	// LastActivityAt is the time of the runner's last action parsed from its
+	// session log (see sessionlog). Nil if unknown.
+	LastActivityAt *time.Time
 }
 
 // StallResult contains the result of a stall check.
@@ -32,10 +37,11 @@ type StallResult struct {
 // CheckStall determines if a run is stalled based on activity signals.
 //
 // A run is considered stalled if:
-// - The tmux session exists (runner is supposed to be active)
-// - The status file exists and hasn't been modified within the threshold
+//   - The tmux session exists (runner is supposed to be active)
+//   - The most recent of the status file mtime and last session activity
+//     is older than the threshold
 //
-// If the status file doesn't exist, the run is not considered stalled
+// If neither signal is available, the run is not considered stalled
 // (fallback to legacy tmux-only detection).
 func CheckStall(signals ActivitySignals, threshold time.Duration) StallResult {
 	// No tmux session = not running = not stalled
@@ -43,14 +49,20 @@ func CheckStall(signals ActivitySignals, threshold time.Duration) StallResult {
 		return StallResult{IsStalled: false}
 	}
 
-	// No status file = can't determine stall state = not stalled
+	// Use the most recent activity signal
+	last := signals.StatusFileModTime
+	if signals.LastActivityAt != nil && (last == nil || signals.LastActivityAt.After(*last)) {
+		last = signals.LastActivityAt
+	}
+
+	// No signal = can't determine stall state = not stalled
 	// This allows backward compatibility with runs that don't have the status file
-	if signals.StatusFileModTime == nil {
+	if last == nil {
 		return StallResult{IsStalled: false}
 	}
 
-	// Calculate time since last status update
-	stalledDuration := time.Since(*signals.StatusFileModTime)
+	// Calculate time since last activity
+	stalledDuration := time.Since(*last)
 
 	// Check if stalled
 	if stalledDuration >= threshold {
@@ -61,6 +61,32 @@ func TestCheckStall(t *testing.T) {
 			},
 			wantIsStalled: false,
 		},
+		{
+			name: "old status file but recent session activity",
+			signals: ActivitySignals{
+				TmuxSessionExists: true,
+				StatusFileModTime: ptr(time.Now().Add(-1 * time.Hour)),
+				LastActivityAt:    ptr(time.Now().Add(-2 * time.Minute)),
+			},
+			wantIsStalled: false,
+		},
+		{
+			name: "no status file with old session activity",
+			signals: ActivitySignals{
+				TmuxSessionExists: true,
+				LastActivityAt:    ptr(time.Now().Add(-20 * time.Minute)),
+			},
+			wantIsStalled: true,
+		},
+		{
+			name: "recent status file with old session activity",
+			signals: ActivitySignals{
+				TmuxSessionExists: true,
+				StatusFileModTime: ptr(time.Now().Add(-1 * time.Minute)),
+				LastActivityAt:    ptr(time.Now().Add(-1 * time.Hour)),
+			},
+			wantIsStalled: false,
+		},
 		{
 			name: "no tmux with old status file",
 			signals: ActivitySignals{